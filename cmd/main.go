@@ -1,3 +1,4 @@
+// Command cudly is the command line interface of CUDly, a thin cobra wrapper around the cudly package
 package main
 
 import (
@@ -5,64 +6,19 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strings"
+	"sort"
+	"syscall"
 	"time"
 
+	"github.com/LeanerCloud/CUDly/cudly"
+	"github.com/LeanerCloud/CUDly/internal/config"
 	"github.com/LeanerCloud/CUDly/pkg/common"
-	"github.com/LeanerCloud/CUDly/pkg/provider"
-	"github.com/LeanerCloud/CUDly/providers/aws/services/ec2"
-	"github.com/LeanerCloud/CUDly/providers/aws/services/elasticache"
-	"github.com/LeanerCloud/CUDly/providers/aws/services/memorydb"
-	"github.com/LeanerCloud/CUDly/providers/aws/services/opensearch"
-	"github.com/LeanerCloud/CUDly/providers/aws/services/rds"
-	"github.com/LeanerCloud/CUDly/providers/aws/services/redshift"
-	"github.com/LeanerCloud/CUDly/providers/aws/services/savingsplans"
-	_ "github.com/LeanerCloud/CUDly/providers/aws"
-	_ "github.com/LeanerCloud/CUDly/providers/azure"
-	_ "github.com/LeanerCloud/CUDly/providers/gcp"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/google/uuid"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
 	"github.com/spf13/cobra"
 )
 
-const (
-	// MaxReasonableInstances is the maximum number of instances that can be processed
-	// This is a safety limit to prevent accidental large purchases
-	MaxReasonableInstances = 10000
-)
-
-// Config holds all configuration for the RI helper tool
-type Config struct {
-	Providers            []string
-	Regions              []string
-	Services             []string
-	Coverage             float64
-	ActualPurchase       bool
-	CSVOutput            string
-	CSVInput             string
-	AllServices          bool
-	PaymentOption        string
-	TermYears            int
-	IncludeRegions       []string
-	ExcludeRegions       []string
-	IncludeInstanceTypes []string
-	ExcludeInstanceTypes []string
-	IncludeEngines       []string
-	ExcludeEngines       []string
-	IncludeAccounts        []string
-	ExcludeAccounts        []string
-	SkipConfirmation       bool
-	MaxInstances           int32
-	OverrideCount          int32
-	Profile                string
-	ValidationProfile      string
-	IncludeExtendedSupport bool
-	// Savings Plans specific filters
-	IncludeSPTypes []string
-	ExcludeSPTypes []string
-}
-
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatalf("Error executing command: %v", err)
@@ -73,336 +29,360 @@ var rootCmd = &cobra.Command{
 	Use:   "ri-helper",
 	Short: "AWS Reserved Instance purchase tool based on Cost Explorer recommendations",
 	Long: `A tool that fetches Reserved Instance recommendations from AWS Cost Explorer
-for multiple services (RDS, ElastiCache, EC2, OpenSearch, Redshift, MemoryDB) and
+for multiple services (RDS, ElastiCache, EC2, OpenSearch, Redshift, MemoryDB, DynamoDB) and
 purchases them based on specified coverage percentage. Supports multiple regions.`,
 	PreRunE: validateFlags,
 	Run:     runTool,
 }
 
+var coverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Print the existing reservation inventory without purchasing",
+	Long: `Lists the active reservations and Savings Plans of the selected services and regions,
+with their instance type, count, term and expiration date, for auditing what is already committed.
+Nothing is purchased.`,
+	PreRunE: validateCoverageFlags,
+	Run:     runCoverage,
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the recommendations of two reports",
+	Long: `Compares two CSV reports (or JSON reports, by their .json extension) and prints the recommendations
+that were added, removed or changed in count, keyed by service, region, instance type and engine.
+Nothing is fetched or purchased.`,
+	Run: runDiff,
+}
+
+var utilizationCmd = &cobra.Command{
+	Use:   "utilization",
+	Short: "Print the utilization and coverage of the existing reservations",
+	Long: `Reports the utilization and coverage of the existing reservations of the selected services
+over the last days, from Cost Explorer, to show whether current reservations are underutilized
+before choosing a coverage and buying more. Nothing is purchased.`,
+	PreRunE: validateCoverageFlags,
+	Run:     runUtilization,
+}
+
+// Flags of the diff subcommand
+var (
+	diffOldPath string
+	diffNewPath string
+	diffJSON    bool
+)
+
+// Flags of the utilization subcommand
+var (
+	utilizationDays int
+	utilizationJSON bool
+)
+
+func init() {
+	rootCmd.AddCommand(coverageCmd)
+	coverageCmd.Flags().StringSliceVarP(&toolCfg.Regions, "regions", "r", []string{}, "AWS regions (comma-separated or multiple flags). If empty, all enabled regions are listed")
+	coverageCmd.Flags().StringSliceVarP(&toolCfg.Services, "services", "s", []string{"rds"}, "Services to list (rds, elasticache, ec2, opensearch, redshift, memorydb, dynamodb, savingsplans)")
+	coverageCmd.Flags().StringSliceVar(&toolCfg.RegionSets, "region-set", []string{}, "Named AWS region groups to list in addition to --regions (us, eu, apac, all-opted-in)")
+	coverageCmd.Flags().BoolVar(&toolCfg.AllServices, "all-services", false, "List all supported services")
+	coverageCmd.Flags().StringSliceVar(&toolCfg.IncludeRegions, "include-regions", []string{}, "Only list these regions (comma-separated)")
+	coverageCmd.Flags().StringSliceVar(&toolCfg.ExcludeRegions, "exclude-regions", []string{}, "Skip these regions (comma-separated)")
+	coverageCmd.Flags().StringVar(&toolCfg.Profile, "profile", "", "AWS profile to use (defaults to AWS_PROFILE env var or default profile)")
+	coverageCmd.Flags().BoolVar(&toolCfg.NoEmoji, "no-emoji", false, "Strip emoji and box-drawing characters from all output (ASCII-only logs and summaries)")
+	coverageCmd.Flags().StringVar(&toolCfg.LogLevel, "log-level", "info", "Minimum level of log messages to print (debug, info, warn, error)")
+	coverageCmd.Flags().StringVar(&toolCfg.LogFormat, "log-format", common.LogFormatText, "Log message format: text, or json for one structured record per message")
+}
+
+func init() {
+	rootCmd.AddCommand(utilizationCmd)
+	utilizationCmd.Flags().StringSliceVarP(&toolCfg.Services, "services", "s", []string{"rds"}, "Services to report (rds, elasticache, ec2, opensearch, redshift, memorydb, dynamodb)")
+	utilizationCmd.Flags().BoolVar(&toolCfg.AllServices, "all-services", false, "Report all supported services")
+	utilizationCmd.Flags().IntVar(&utilizationDays, "days", 30, "Number of days before today to report utilization over")
+	utilizationCmd.Flags().BoolVar(&utilizationJSON, "json", false, "Print the utilization as JSON")
+	utilizationCmd.Flags().StringVar(&toolCfg.Profile, "profile", "", "AWS profile to use (defaults to AWS_PROFILE env var or default profile)")
+	utilizationCmd.Flags().BoolVar(&toolCfg.NoEmoji, "no-emoji", false, "Strip emoji and box-drawing characters from all output (ASCII-only logs and summaries)")
+	utilizationCmd.Flags().StringVar(&toolCfg.LogLevel, "log-level", "info", "Minimum level of log messages to print (debug, info, warn, error)")
+	utilizationCmd.Flags().StringVar(&toolCfg.LogFormat, "log-format", common.LogFormatText, "Log message format: text, or json for one structured record per message")
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVar(&diffOldPath, "old", "", "Earlier report to compare")
+	diffCmd.Flags().StringVar(&diffNewPath, "new", "", "Later report to compare")
+	diffCmd.Flags().BoolVar(&diffJSON, "json", false, "Print the diff as JSON")
+	_ = diffCmd.MarkFlagRequired("old")
+	_ = diffCmd.MarkFlagRequired("new")
+}
+
 func init() {
 	// Note: We still bind to package-level variables here for cobra's flag system
 	// These will be copied into a ToolConfig in runTool
+	rootCmd.Flags().StringSliceVar(&toolCfg.Providers, "providers", []string{cudly.ProviderAWS}, "Cloud providers to process (aws, azure, gcp). Azure VM reservation and GCP Compute Engine CUD recommendations are processed in dry-run mode only")
 	rootCmd.Flags().StringSliceVarP(&toolCfg.Regions, "regions", "r", []string{}, "AWS regions (comma-separated or multiple flags). If empty, auto-discovers regions from recommendations")
-	rootCmd.Flags().StringSliceVarP(&toolCfg.Services, "services", "s", []string{"rds"}, "Services to process (rds, elasticache, ec2, opensearch, redshift, memorydb, savingsplans)")
+	rootCmd.Flags().StringSliceVar(&toolCfg.RegionSets, "region-set", []string{}, "Named AWS region groups to process in addition to --regions (us, eu, apac, all-opted-in)")
+	rootCmd.Flags().IntVar(&toolCfg.MaxScanRegions, "max-scan-regions", 0, "Fail instead of scanning when region auto-discovery finds more than this many regions (0 = unlimited)")
+	rootCmd.Flags().StringSliceVarP(&toolCfg.Services, "services", "s", []string{"rds"}, "Services to process (rds, elasticache, ec2, opensearch, redshift, memorydb, dynamodb, savingsplans)")
 	rootCmd.Flags().BoolVar(&toolCfg.AllServices, "all-services", false, "Process all supported services")
 	rootCmd.Flags().Float64VarP(&toolCfg.Coverage, "coverage", "c", 80.0, "Percentage of recommendations to purchase (0-100)")
+	rootCmd.Flags().StringVar(&toolCfg.CoverageRounding, "coverage-rounding", "floor", "Rounding of coverage-adjusted instance counts (floor, ceil, round)")
 	rootCmd.Flags().BoolVar(&toolCfg.ActualPurchase, "purchase", false, "Actually purchase RIs instead of just printing the data")
 	rootCmd.Flags().StringVarP(&toolCfg.CSVOutput, "output", "o", "", "Output CSV file path (if not specified, auto-generates filename)")
+	rootCmd.Flags().StringVar(&toolCfg.OutputFormat, "output-format", cudly.OutputFormatCSV, "Output format: csv (purchase report), json (purchase report including service details) or aws-cli (reviewable script of equivalent AWS CLI purchase commands, dry-run only)")
+	rootCmd.Flags().StringVar(&toolCfg.HTMLOutput, "html-output", "", "Also write a self-contained HTML report with per-service tables and totals to this path (disabled if empty)")
+	rootCmd.Flags().StringVar(&toolCfg.MarkdownOutput, "markdown-output", "", "Also write a markdown table of per-service recommendations, instances and savings with totals to this path, for pasting into tickets (disabled if empty)")
+	rootCmd.Flags().StringVar(&toolCfg.ReceiptsDir, "receipts-dir", "", "Write a JSON receipt with the raw AWS API request and response of each purchase attempt to this directory (disabled if empty)")
+	rootCmd.Flags().StringVar(&toolCfg.MetricsFile, "metrics-file", "", "Write run metrics (recommendations, purchases and savings per service) to this path in the Prometheus textfile collector format (disabled if empty)")
+	rootCmd.Flags().StringVar(&toolCfg.OutputDir, "output-dir", "", "Existing base directory to write all artifacts (reports and log) of each run to, in a timestamped subdirectory such as output/20240101-120000")
+	rootCmd.Flags().BoolVar(&toolCfg.JSONSummary, "json-summary", false, "Print the final summary as a single JSON object on stdout instead of the formatted summary (logs go to stderr)")
 	rootCmd.Flags().StringVarP(&toolCfg.CSVInput, "input-csv", "i", "", "Input CSV file with recommendations to purchase")
-	rootCmd.Flags().StringVarP(&toolCfg.PaymentOption, "payment", "p", "no-upfront", "Payment option (all-upfront, partial-upfront, no-upfront)")
+	rootCmd.Flags().StringVar(&toolCfg.JSONInput, "input-json", "", "Input JSON file with recommendations to purchase, as written by --output-format json (keeps the service details)")
+	rootCmd.Flags().StringVarP(&toolCfg.PaymentOption, "payment", "p", "no-upfront", "Payment option (all-upfront, partial-upfront, no-upfront; aliases: all, partial, none)")
 	rootCmd.Flags().IntVarP(&toolCfg.TermYears, "term", "t", 3, "Term in years (1 or 3)")
+	rootCmd.Flags().BoolVar(&toolCfg.CompareTerms, "compare-terms", false, "Fetch 1-year and 3-year recommendations in a dry run and compare their savings per service; same as --terms 1,3")
+	rootCmd.Flags().IntSliceVar(&toolCfg.Terms, "terms", nil, "Terms in years to fetch and compare in a dry run (e.g. 1,3); overrides --term")
+	rootCmd.Flags().StringVar(&toolCfg.EC2OfferingClass, "ec2-offering-class", "standard", "EC2 Reserved Instance offering class (standard, convertible)")
+	rootCmd.Flags().StringVar(&toolCfg.EC2Scope, "ec2-scope", "as-recommended", "EC2 Reserved Instance scope (regional, az, as-recommended)")
+	rootCmd.Flags().BoolVar(&toolCfg.AutoPayment, "auto-payment", false, "Fall back to the nearest payment option a service supports when it doesn't offer the requested one for the term (e.g. partial-upfront for 3-year RDS no-upfront)")
+	rootCmd.Flags().IntVar(&toolCfg.LookbackDays, "lookback-days", 7, "Cost Explorer usage lookback window in days the recommendations are based on (7, 30 or 60)")
 	rootCmd.Flags().StringVar(&toolCfg.Profile, "profile", "", "AWS profile to use (defaults to AWS_PROFILE env var or default profile)")
+	rootCmd.Flags().BoolVar(&toolCfg.RetrySkipped, "retry-skipped", false, "Retry regions whose recommendations could not be fetched (e.g. due to throttling) once at the end of each service scan")
+	rootCmd.Flags().DurationVar(&toolCfg.RetrySkippedCooldown, "retry-skipped-cooldown", 60*time.Second, "Cooldown to wait before retrying skipped regions")
+	rootCmd.Flags().StringVar(&toolCfg.SlackWebhookURL, "slack-webhook-url", "", "Slack incoming webhook URL to post the run summary to when the run completes (disabled if empty)")
+	rootCmd.Flags().StringVar(&toolCfg.UploadS3, "upload-s3", "", "S3 URI (s3://bucket/prefix/) to upload the written reports to under a timestamped key (disabled if empty)")
+	rootCmd.Flags().StringVar(&toolCfg.EventBridgeBus, "emit-eventbridge", "", "EventBridge event bus name or ARN to publish purchase result events to (disabled if empty)")
+	rootCmd.Flags().StringVar(&toolCfg.CacheDir, "cache-dir", "", "Directory to cache fetched recommendations in (disabled if empty)")
+	rootCmd.Flags().DurationVar(&toolCfg.CacheTTL, "cache-ttl", 24*time.Hour, "How long cached recommendations stay valid (0 = never expire)")
+	rootCmd.Flags().StringVar(&toolCfg.AccountCacheFile, "account-cache-file", "", "File to persist account names resolved through AWS Organizations in between runs (disabled if empty)")
+	rootCmd.Flags().DurationVar(&toolCfg.AccountCacheTTL, "account-cache-ttl", 7*24*time.Hour, "How long account names in --account-cache-file stay valid (0 = never expire)")
+	rootCmd.Flags().BoolVar(&toolCfg.CacheOnly, "cache-only", false, "Read recommendations exclusively from --cache-dir and skip all live AWS queries (implies dry-run)")
+	rootCmd.Flags().BoolVar(&toolCfg.NoCache, "no-cache", false, "Ignore --cache-dir and fetch fresh recommendations, e.g. for purchase runs")
+	rootCmd.Flags().IntVar(&toolCfg.MaxConcurrency, "max-concurrency", 5, "Maximum number of regions whose recommendations are fetched concurrently (1 = serial); purchases always run serially")
+	rootCmd.Flags().IntVar(&toolCfg.ServiceConcurrency, "service-concurrency", 1, "Maximum number of services processed concurrently (1 = serial); purchase runs also need --allow-concurrent-purchase")
+	rootCmd.Flags().BoolVar(&toolCfg.AllowConcurrentPurchase, "allow-concurrent-purchase", false, "Let --service-concurrency process services concurrently in purchase runs (requires --yes)")
+	rootCmd.Flags().IntVar(&toolCfg.APIRetries, "api-retries", recommendations.DefaultMaxRetries, "Number of times a failed or throttled Cost Explorer or region listing request is retried (0 = no retries)")
+	rootCmd.Flags().DurationVar(&toolCfg.APIRetryDelay, "api-retry-delay", recommendations.DefaultRetryBaseDelay, "Base delay of the exponential backoff (with jitter) between Cost Explorer retries")
+	rootCmd.Flags().DurationVar(&toolCfg.Timeout, "timeout", 0, "Stop the run after this duration (e.g. 30m), skipping the remaining regions and purchases and writing a partial report (0 = no timeout)")
+	rootCmd.Flags().BoolVar(&toolCfg.PerRegionRateLimit, "per-region-rate-limit", false, "Use an independent rate limiter per region instead of one shared limiter, so a throttled region does not slow down others")
 
 	// Filter flags
 	rootCmd.Flags().StringSliceVar(&toolCfg.IncludeRegions, "include-regions", []string{}, "Only include recommendations for these regions (comma-separated)")
 	rootCmd.Flags().StringSliceVar(&toolCfg.ExcludeRegions, "exclude-regions", []string{}, "Exclude recommendations for these regions (comma-separated)")
 	rootCmd.Flags().StringSliceVar(&toolCfg.IncludeInstanceTypes, "include-instance-types", []string{}, "Only include these instance types (comma-separated, e.g., 'db.t3.micro,cache.t3.small')")
 	rootCmd.Flags().StringSliceVar(&toolCfg.ExcludeInstanceTypes, "exclude-instance-types", []string{}, "Exclude these instance types (comma-separated)")
+	rootCmd.Flags().StringSliceVar(&toolCfg.IncludeArchitectures, "include-architectures", []string{}, "Only include instance types of these CPU architectures (x86_64, arm64)")
+	rootCmd.Flags().StringSliceVar(&toolCfg.ExcludeArchitectures, "exclude-architectures", []string{}, "Exclude instance types of these CPU architectures (x86_64, arm64)")
+	rootCmd.Flags().StringSliceVar(&toolCfg.ExcludeInstanceTypePatterns, "exclude-instance-type-patterns", []string{}, "Exclude instance types matching these wildcard patterns (comma-separated, e.g. 'db.t3.*,*.nano')")
 	rootCmd.Flags().StringSliceVar(&toolCfg.IncludeEngines, "include-engines", []string{}, "Only include these engines (comma-separated, e.g., 'redis,mysql,postgresql')")
 	rootCmd.Flags().StringSliceVar(&toolCfg.ExcludeEngines, "exclude-engines", []string{}, "Exclude these engines (comma-separated)")
-	rootCmd.Flags().StringSliceVar(&toolCfg.IncludeAccounts, "include-accounts", []string{}, "Only include recommendations for these account names (comma-separated)")
-	rootCmd.Flags().StringSliceVar(&toolCfg.ExcludeAccounts, "exclude-accounts", []string{}, "Exclude recommendations for these account names (comma-separated)")
+	rootCmd.Flags().StringSliceVar(&toolCfg.IncludeAccounts, "include-accounts", []string{}, "Only include recommendations for these account IDs or names (comma-separated; names match as substrings)")
+	rootCmd.Flags().StringSliceVar(&toolCfg.ExcludeAccounts, "exclude-accounts", []string{}, "Exclude recommendations for these account IDs or names (comma-separated; names match as substrings)")
+	rootCmd.Flags().BoolVar(&toolCfg.DryRunDiff, "dry-run-diff", false, "In dry-run mode, print reserved, recommended and net new counts per instance type and region")
+	rootCmd.Flags().BoolVar(&toolCfg.RecommendationsOnly, "recommendations-only", false, "Export the filtered recommendations to CSV or JSON without simulating or making any purchase")
+	rootCmd.Flags().Float64Var(&toolCfg.CoverageSatisfiedThreshold, "coverage-satisfied-threshold", 0, "Skip recommendations whose instance type and region already have active reservations covering at least this percentage of the recommended count (0 = disabled)")
+	rootCmd.Flags().DurationVar(&toolCfg.IgnoreRIsExpiringWithin, "ignore-ris-expiring-within", 0, "Don't count existing reservations expiring within this duration (e.g. 720h) as coverage when checking for duplicates and --coverage-satisfied-threshold (0 = count all)")
 	rootCmd.Flags().BoolVar(&toolCfg.SkipConfirmation, "yes", false, "Skip confirmation prompt for purchases (use with caution)")
+	rootCmd.Flags().BoolVar(&toolCfg.Preview, "preview", false, "Before purchasing, print a table of every recommendation about to be bought with the totals")
+	rootCmd.Flags().BoolVar(&toolCfg.ConfirmPhrase, "confirm-phrase", false, "Require typing a confirmation phrase (e.g. PURCHASE 50 INSTANCES) instead of yes for purchases above the confirm-phrase thresholds")
+	rootCmd.Flags().IntVar(&toolCfg.ConfirmPhraseInstances, "confirm-phrase-instances", 20, "Instance count of the run above which --confirm-phrase requires the confirmation phrase")
+	rootCmd.Flags().Float64Var(&toolCfg.ConfirmPhraseCost, "confirm-phrase-cost", 5000, "Estimated commitment cost of the run in USD (upfront plus recurring charges over the term) above which --confirm-phrase requires the confirmation phrase")
+	rootCmd.Flags().BoolVar(&toolCfg.FailFast, "fail-fast", false, "Stop purchasing in a service and region after the first failed purchase, recording the remaining purchases as skipped")
+	rootCmd.Flags().BoolVar(&toolCfg.NoEmoji, "no-emoji", false, "Strip emoji and box-drawing characters from all output (ASCII-only logs and summaries)")
+	rootCmd.Flags().BoolVar(&toolCfg.Quiet, "quiet", false, "Suppress all output except errors on stderr; report files are still written")
+	rootCmd.Flags().BoolVar(&toolCfg.Progress, "progress", false, "Show a progress line (service: region N/total) updating in place while scanning regions; ignored when output is not a terminal")
+	rootCmd.Flags().StringVar(&toolCfg.LogLevel, "log-level", "info", "Minimum level of log messages to print (debug, info, warn, error)")
+	rootCmd.Flags().StringVar(&toolCfg.LogFormat, "log-format", common.LogFormatText, "Log message format: text, or json for one structured record per message")
+	rootCmd.Flags().DurationVar(&toolCfg.DelayJitter, "delay-jitter", 0, "Randomize the delay between purchases by up to +/- this duration (e.g. 1s, 0 = fixed delay)")
+	rootCmd.Flags().BoolVar(&toolCfg.ValidateOfferings, "validate-offerings", false, "Validate each offering right before purchasing it and skip recommendations that are no longer offered; in dry-run mode, print the quoted upfront and hourly price")
+	rootCmd.Flags().BoolVar(&toolCfg.DryRunPurchaseAPI, "dry-run-purchase-api", false, "In dry-run mode, call the purchase API with DryRun=true for services that support it (EC2) to validate permissions and offerings server-side")
+	rootCmd.Flags().StringVar(&toolCfg.SortBy, "sort-by", cudly.SortBySavings, "Order recommendations before display and purchase: savings (highest monthly savings first), count (most instances first) or none (Cost Explorer order)")
+	rootCmd.Flags().StringVar(&toolCfg.GroupBy, "group-by", "", "Add a breakdown of the final summary: account (recommendations, instances and savings per account)")
 	rootCmd.Flags().Int32Var(&toolCfg.MaxInstances, "max-instances", 0, "Maximum total number of instances to purchase (0 = no limit)")
+	rootCmd.Flags().Float64Var(&toolCfg.MaxUpfrontBudget, "max-upfront-budget", 0, "Maximum total upfront cost in USD to spend in this run; recommendations that don't fit are deferred to the next run (0 = no limit)")
+	rootCmd.Flags().StringVar(&toolCfg.StateFile, "state-file", "", "Append-only state journal (JSON lines) recording every purchase, so a restarted run skips the purchases already made; also carries the --max-upfront-budget deferred queue over to the next run, where deferred recommendations are purchased first")
+	rootCmd.Flags().Float64Var(&toolCfg.MaxMonthlySpend, "max-monthly-spend", 0, "Maximum estimated monthly commitment cost in USD to purchase, keeping the highest-savings recommendations first; applied per region like --max-instances (0 = no limit)")
 	rootCmd.Flags().Int32Var(&toolCfg.OverrideCount, "override-count", 0, "Override recommendation count with fixed number for all selected RIs (0 = use recommendation or coverage)")
 	rootCmd.Flags().StringVar(&toolCfg.ValidationProfile, "validation-profile", "", "AWS profile to use for validating running instances (if different from main profile)")
+	rootCmd.Flags().StringVar(&toolCfg.AccountID, "account-id", "", "Have Cost Explorer compute recommendations for this linked account only (12-digit account ID)")
+	rootCmd.Flags().StringSliceVar(&toolCfg.AccountRoles, "accounts-roles", []string{}, "Process each AWS account by assuming its role, given as <account-id>:<role-arn> entries (e.g. '123456789012:arn:aws:iam::123456789012:role/CUDlyRole')")
 	rootCmd.Flags().BoolVar(&toolCfg.IncludeExtendedSupport, "include-extended-support", false, "Include instances running on extended support engine versions (by default they are excluded)")
+	rootCmd.Flags().BoolVar(&toolCfg.SkipVersionChecks, "skip-version-checks", false, "Skip querying running RDS instances and engine versions, disabling extended support filtering (faster for large organizations)")
+	rootCmd.Flags().StringSliceVar(&toolCfg.ExcludeEngineVersions, "exclude-engine-versions", []string{}, "Subtract running RDS instances on these engine versions from the recommendations regardless of their support status (comma-separated engine:version, e.g. mysql:5.7,postgres:11)")
+	rootCmd.Flags().StringVar(&toolCfg.DecommissionTag, "decommission-tag", "", "Tag (key=value) marking RDS instances scheduled for termination; recommendations whose type/region only matches tagged instances are excluded")
+	rootCmd.Flags().Float64Var(&toolCfg.MinSavingsPerInstance, "min-savings-per-instance", 0, "Skip recommendations whose estimated monthly savings per instance is below this amount in USD (0 = no minimum)")
+	rootCmd.Flags().BoolVar(&toolCfg.ExcludeZeroSavings, "exclude-zero-savings", false, "Skip recommendations whose estimated monthly savings are exactly zero")
+	rootCmd.Flags().Float64Var(&toolCfg.MinMonthlySavings, "min-monthly-savings", 0, "Skip recommendations whose total estimated monthly savings (after coverage) is below this amount in USD (0 = no minimum)")
+	rootCmd.Flags().IntVar(&toolCfg.ExpectMaxInstances, "expect-max-instances", 0, "Fail the run if the selected recommendations total more instances than this, before any purchase (0 = no guard)")
+	rootCmd.Flags().Float64Var(&toolCfg.ExpectMinSavings, "expect-min-savings", 0, "Fail the run if the selected recommendations save less than this amount in USD per month, before any purchase (0 = no guard)")
+	rootCmd.Flags().DurationVar(&toolCfg.MinInstanceAge, "min-instance-age", 0, "For 3-year terms, exclude running RDS instances younger than this from the count, or the whole recommendation if most are younger (e.g. 2160h = 90 days, 0 = disabled)")
+	rootCmd.Flags().BoolVar(&toolCfg.CapToRunning, "cap-to-running", false, "Cap RDS recommendations at the number of running instances of the same instance class, engine and region")
+	rootCmd.Flags().StringVar(&toolCfg.FilterExpression, "filter", "", "Only include recommendations matching this expression, ANDed with the other filters (e.g. \"service=rds && savings_percent>20 && region!=us-east-1\")")
 
 	// Savings Plans specific filters
 	rootCmd.Flags().StringSliceVar(&toolCfg.IncludeSPTypes, "include-sp-types", []string{}, "Only include these Savings Plan types (comma-separated: Compute, EC2Instance, SageMaker, Database)")
 	rootCmd.Flags().StringSliceVar(&toolCfg.ExcludeSPTypes, "exclude-sp-types", []string{}, "Exclude these Savings Plan types (comma-separated: Compute, EC2Instance, SageMaker, Database)")
+	rootCmd.Flags().BoolVar(&toolCfg.NoDoubleCommit, "no-double-commit", false, "Refuse to select both EC2 RIs and Compute/EC2 Instance Savings Plans in one run, since they would cover the same usage")
+	rootCmd.Flags().BoolVar(&toolCfg.RespectExistingSP, "respect-existing-sp", false, "Skip EC2 and RDS recommendations whose usage active Savings Plans likely already cover, instead of only warning")
+	rootCmd.Flags().BoolVar(&toolCfg.IncludeMarketplaceSavings, "include-marketplace-savings", false, "Factor cheaper Reserved Instance Marketplace listings into the EC2 RI savings of the RI vs Savings Plans comparison (purchases always use standard offerings)")
+	rootCmd.Flags().StringSliceVar(&toolCfg.SPCommitments, "sp-commitment", []string{}, "Purchase Savings Plans at fixed hourly commitments instead of using recommendations (e.g. 'Compute=5.0,Database=2.0')")
+	rootCmd.Flags().StringVar(&configFile, "config", "", "YAML (.yaml, .yml) or TOML (.toml) file of flag settings, keyed by flag name. Flags given on the command line take precedence")
 }
 
+// configFile is the path of the --config settings file
+var configFile string
+
 // Package-level Config that cobra flags bind to
-var toolCfg = Config{}
+var toolCfg = cudly.RunConfig{}
 
 // validateFlags performs validation on command line flags before execution
 func validateFlags(cmd *cobra.Command, args []string) error {
-	// Validate coverage percentage
-	if toolCfg.Coverage < 0 || toolCfg.Coverage > 100 {
-		return fmt.Errorf("coverage percentage must be between 0 and 100, got: %.2f", toolCfg.Coverage)
-	}
-
-	// Validate max instances
-	if toolCfg.MaxInstances < 0 {
-		return fmt.Errorf("max-instances must be 0 (no limit) or a positive number, got: %d", toolCfg.MaxInstances)
-	}
-
-	// Validate max instances doesn't exceed reasonable limit
-	if toolCfg.MaxInstances > MaxReasonableInstances {
-		return fmt.Errorf("max-instances (%d) exceeds reasonable limit of %d", toolCfg.MaxInstances, MaxReasonableInstances)
-	}
-
-	// Validate override count
-	if toolCfg.OverrideCount < 0 {
-		return fmt.Errorf("override-count must be 0 (disabled) or a positive number, got: %d", toolCfg.OverrideCount)
+	if configFile != "" {
+		if err := applyConfigFile(cmd, configFile); err != nil {
+			return err
+		}
 	}
 
-	// Validate override count doesn't exceed reasonable limit
-	if toolCfg.OverrideCount > MaxReasonableInstances {
-		return fmt.Errorf("override-count (%d) exceeds reasonable limit of %d", toolCfg.OverrideCount, MaxReasonableInstances)
+	// Configure output first so validation warnings also honour --no-emoji, --json-summary and the log options
+	if err := cudly.ConfigureLogging(toolCfg.LogLevel, toolCfg.LogFormat); err != nil {
+		return err
 	}
+	cudly.ConfigureOutput(toolCfg.NoEmoji, toolCfg.JSONSummary)
+	cudly.ConfigureQuiet(toolCfg.Quiet)
+	return toolCfg.Validate()
+}
 
-	// Validate payment option
-	validPaymentOptions := map[string]bool{
-		"all-upfront":     true,
-		"partial-upfront": true,
-		"no-upfront":      true,
+// interruptibleContext returns a context cancelled on SIGINT or SIGTERM, and after timeout when positive
+// A second signal terminates the process immediately.
+func interruptibleContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupts
+		log.Printf("🛑 Interrupt received: finishing the purchase in progress and writing a partial report (interrupt again to quit immediately)")
+		signal.Stop(interrupts)
+		cancel()
+	}()
+
+	if timeout <= 0 {
+		return ctx, cancel
 	}
-	if !validPaymentOptions[toolCfg.PaymentOption] {
-		return fmt.Errorf("invalid payment option: %s. Must be one of: all-upfront, partial-upfront, no-upfront", toolCfg.PaymentOption)
-	}
-
-	// Validate term years
-	if toolCfg.TermYears != 1 && toolCfg.TermYears != 3 {
-		return fmt.Errorf("invalid term: %d years. Must be 1 or 3", toolCfg.TermYears)
+	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	return timeoutCtx, func() {
+		cancelTimeout()
+		cancel()
 	}
+}
 
-	// Warn about RDS 3-year no-upfront limitation
-	if toolCfg.PaymentOption == "no-upfront" && toolCfg.TermYears == 3 {
-		services := determineServicesToProcess(toolCfg)
-		hasRDS := false
-		for _, svc := range services {
-			if svc == common.ServiceRDS {
-				hasRDS = true
-				break
-			}
-		}
-		if hasRDS || toolCfg.AllServices {
-			log.Println("⚠️  WARNING: AWS does not offer 3-year no-upfront Reserved Instances for RDS.")
-			log.Println("    RDS 3-year RIs only support: all-upfront, partial-upfront")
-			log.Println("    No RDS recommendations will be found with this combination.")
+func runTool(cmd *cobra.Command, args []string) {
+	ctx, cancel := interruptibleContext(toolCfg.Timeout)
+	defer cancel()
+
+	// Write every artifact of the run, including the log, to a timestamped directory under --output-dir
+	if toolCfg.OutputDir != "" {
+		runDir, err := cudly.CreateRunOutputDir(toolCfg.OutputDir, time.Now())
+		if err != nil {
+			log.Fatalf("Error: %v", err)
 		}
-	}
-
-	// Validate CSV output path if provided
-	if toolCfg.CSVOutput != "" {
-		// Check if the directory exists
-		dir := filepath.Dir(toolCfg.CSVOutput)
-		if dir != "." && dir != "" {
-			if _, err := os.Stat(dir); os.IsNotExist(err) {
-				return fmt.Errorf("output directory does not exist: %s", dir)
-			}
+		logFile, err := os.Create(filepath.Join(runDir, cudly.RunLogFileName))
+		if err != nil {
+			log.Fatalf("Error: failed to create log file: %v", err)
 		}
+		defer logFile.Close()
+		cudly.ConfigureLogFile(logFile)
+		toolCfg.OutputDir = runDir
+		log.Printf("📁 Writing run artifacts to %s", runDir)
 	}
 
-	// Validate CSV input path if provided
-	if toolCfg.CSVInput != "" {
-		if _, err := os.Stat(toolCfg.CSVInput); os.IsNotExist(err) {
-			return fmt.Errorf("input CSV file does not exist: %s", toolCfg.CSVInput)
-		}
-		if !strings.HasSuffix(strings.ToLower(toolCfg.CSVInput), ".csv") {
-			return fmt.Errorf("input file must have .csv extension: %s", toolCfg.CSVInput)
-		}
+	report, err := cudly.Run(ctx, toolCfg)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
 	}
-
-	// Validate filter flags
-	if len(toolCfg.IncludeRegions) > 0 && len(toolCfg.ExcludeRegions) > 0 {
-		// Check for conflicts
-		for _, inc := range toolCfg.IncludeRegions {
-			for _, exc := range toolCfg.ExcludeRegions {
-				if inc == exc {
-					return fmt.Errorf("region '%s' cannot be both included and excluded", inc)
-				}
-			}
-		}
+	if report == nil {
+		return
 	}
 
-	if len(toolCfg.IncludeInstanceTypes) > 0 && len(toolCfg.ExcludeInstanceTypes) > 0 {
-		// Check for conflicts
-		for _, inc := range toolCfg.IncludeInstanceTypes {
-			for _, exc := range toolCfg.ExcludeInstanceTypes {
-				if inc == exc {
-					return fmt.Errorf("instance type '%s' cannot be both included and excluded", inc)
-				}
-			}
-		}
-	}
+	cudly.RenderReport(report, toolCfg)
+	// Reports and notifications are still delivered after an interrupt
+	cudly.UploadReports(context.WithoutCancel(ctx), report, toolCfg)
+	cudly.NotifySlack(context.WithoutCancel(ctx), report, toolCfg)
 
-	if len(toolCfg.IncludeEngines) > 0 && len(toolCfg.ExcludeEngines) > 0 {
-		// Check for conflicts
-		for _, inc := range toolCfg.IncludeEngines {
-			for _, exc := range toolCfg.ExcludeEngines {
-				if inc == exc {
-					return fmt.Errorf("engine '%s' cannot be both included and excluded", inc)
-				}
-			}
+	// Failed purchases and other non-fatal errors, including an interrupt, fail the run so scheduled runs notice them
+	if len(report.Errors) > 0 {
+		for _, err := range report.Errors {
+			log.Printf("❌ %v", err)
 		}
+		log.Fatalf("Error: run finished with %d error(s)", len(report.Errors))
 	}
+}
 
-	// Validate instance types format (basic validation)
-	if err := validateInstanceTypes(toolCfg.IncludeInstanceTypes); err != nil {
-		return fmt.Errorf("invalid include-instance-types: %w", err)
+// validateCoverageFlags performs validation on the coverage command flags before execution
+func validateCoverageFlags(cmd *cobra.Command, args []string) error {
+	if err := cudly.ConfigureLogging(toolCfg.LogLevel, toolCfg.LogFormat); err != nil {
+		return err
 	}
-	if err := validateInstanceTypes(toolCfg.ExcludeInstanceTypes); err != nil {
-		return fmt.Errorf("invalid exclude-instance-types: %w", err)
-	}
-
-	return nil
+	cudly.ConfigureOutput(toolCfg.NoEmoji, false)
+	return toolCfg.Validate()
 }
 
-// validateInstanceTypes performs basic validation on instance type names
-func validateInstanceTypes(instanceTypes []string) error {
-	if len(instanceTypes) == 0 {
-		return nil
+func runCoverage(cmd *cobra.Command, args []string) {
+	commitments, err := cudly.ListReservations(context.Background(), toolCfg)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
 	}
-	for _, t := range instanceTypes {
-		// Basic format validation: should contain at least one dot
-		if t == "" {
-			return fmt.Errorf("empty instance type")
-		}
-		if !strings.Contains(t, ".") {
-			return fmt.Errorf("invalid instance type format '%s': expected format like 'db.t3.micro'", t)
-		}
-	}
-	return nil
+	cudly.PrintReservationInventory(commitments, time.Now())
 }
 
-// parseServices converts service names to ServiceType
-func parseServices(serviceNames []string) []common.ServiceType {
-	var result []common.ServiceType
-	serviceMap := map[string]common.ServiceType{
-		"rds":           common.ServiceRDS,
-		"elasticache":   common.ServiceElastiCache,
-		"ec2":           common.ServiceEC2,
-		"opensearch":    common.ServiceOpenSearch,
-		"elasticsearch": common.ServiceOpenSearch, // Legacy alias maps to OpenSearch
-		"redshift":      common.ServiceRedshift,
-		"memorydb":      common.ServiceMemoryDB,
-		"savingsplans":  common.ServiceSavingsPlans,
-		"sp":            common.ServiceSavingsPlans, // Short alias
+func runUtilization(cmd *cobra.Command, args []string) {
+	results, err := cudly.ReportUtilization(context.Background(), toolCfg, utilizationDays)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
 	}
-
-	for _, name := range serviceNames {
-		if service, ok := serviceMap[strings.ToLower(name)]; ok {
-			result = append(result, service)
-		} else {
-			log.Printf("Warning: Unknown service '%s', skipping", name)
+	if utilizationJSON {
+		if err := cudly.WriteReservationUtilizationJSON(os.Stdout, results); err != nil {
+			log.Fatalf("Error: %v", err)
 		}
+		return
 	}
-
-	return result
+	cudly.PrintReservationUtilization(results, utilizationDays)
 }
 
-// getAllServices returns all supported services
-func getAllServices() []common.ServiceType {
-	return []common.ServiceType{
-		common.ServiceRDS,
-		common.ServiceElastiCache,
-		common.ServiceEC2,
-		common.ServiceOpenSearch,
-		common.ServiceRedshift,
-		common.ServiceMemoryDB,
-		common.ServiceSavingsPlans,
+func runDiff(cmd *cobra.Command, args []string) {
+	diff, err := cudly.DiffRecommendationFiles(diffOldPath, diffNewPath)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
 	}
-}
-
-// createServiceClient creates the appropriate service client for a service
-func createServiceClient(service common.ServiceType, cfg aws.Config) provider.ServiceClient {
-	switch service {
-	case common.ServiceRDS:
-		return rds.NewClient(cfg)
-	case common.ServiceElastiCache:
-		return elasticache.NewClient(cfg)
-	case common.ServiceEC2:
-		return ec2.NewClient(cfg)
-	case common.ServiceOpenSearch:
-		return opensearch.NewClient(cfg)
-	case common.ServiceRedshift:
-		return redshift.NewClient(cfg)
-	case common.ServiceMemoryDB:
-		return memorydb.NewClient(cfg)
-	case common.ServiceSavingsPlans:
-		return savingsplans.NewClient(cfg)
-	default:
-		return nil
+	if diffJSON {
+		if err := cudly.WriteRecommendationDiffJSON(os.Stdout, diff); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
 	}
+	cudly.PrintRecommendationDiff(diff)
 }
 
-
-// generatePurchaseID creates a descriptive purchase ID with UUID for uniqueness
-func generatePurchaseID(rec common.Recommendation, region string, _ int, isDryRun bool, coverage float64) string {
-	// Generate a short UUID suffix (first 8 characters) for uniqueness
-	uuidSuffix := uuid.New().String()[:8]
-	timestamp := time.Now().Format("20060102-150405")
-	prefix := "ri"
-	if isDryRun {
-		prefix = "dryrun"
+// applyConfigFile sets the flags listed in a --config file that were not given on the command line
+func applyConfigFile(cmd *cobra.Command, path string) error {
+	settings, err := config.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to load config file %s: %w", path, err)
 	}
 
-	service := strings.ToLower(string(rec.Service))
-	instanceType := strings.ReplaceAll(rec.ResourceType, ".", "-")
-
-	// Extract engine information from service details
-	engine := ""
-	switch details := rec.Details.(type) {
-	case common.DatabaseDetails:
-		engine = strings.ToLower(details.Engine)
-		engine = strings.ReplaceAll(engine, " ", "-")
-		engine = strings.ReplaceAll(engine, "_", "-")
-	case common.CacheDetails:
-		engine = strings.ToLower(details.Engine)
-	case common.ComputeDetails:
-		engine = strings.ToLower(details.Platform)
-		engine = strings.ReplaceAll(engine, " ", "-")
-		engine = strings.ReplaceAll(engine, "/", "-")
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	// Add account name if available
-	accountName := sanitizeAccountName(rec.AccountName)
-	coveragePct := fmt.Sprintf("%.0fpct", coverage)
-	if accountName != "" {
-		if engine != "" {
-			return fmt.Sprintf("%s-%s-%s-%s-%s-%s-%dx-%s-%s-%s",
-				prefix, accountName, service, engine, region, instanceType, rec.Count, coveragePct, timestamp, uuidSuffix)
+	for _, name := range names {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || name == "config" {
+			return fmt.Errorf("unknown setting %q in config file %s", name, path)
 		}
-		return fmt.Sprintf("%s-%s-%s-%s-%s-%dx-%s-%s-%s",
-			prefix, accountName, service, region, instanceType, rec.Count, coveragePct, timestamp, uuidSuffix)
-	}
-
-	// Fallback without account name
-	if engine != "" {
-		return fmt.Sprintf("%s-%s-%s-%s-%s-%dx-%s-%s-%s",
-			prefix, service, engine, region, instanceType, rec.Count, coveragePct, timestamp, uuidSuffix)
-	}
-	return fmt.Sprintf("%s-%s-%s-%s-%dx-%s-%s-%s",
-		prefix, service, region, instanceType, rec.Count, coveragePct, timestamp, uuidSuffix)
-}
-
-// sanitizeAccountName converts account name to a filesystem/ID-safe format
-func sanitizeAccountName(accountName string) string {
-	if accountName == "" {
-		return ""
-	}
-
-	// Convert to lowercase
-	clean := strings.ToLower(accountName)
-
-	// Replace spaces and special chars with hyphens
-	clean = strings.ReplaceAll(clean, " ", "-")
-	clean = strings.ReplaceAll(clean, "_", "-")
-	clean = strings.ReplaceAll(clean, ".", "-")
-
-	// Remove any characters that aren't alphanumeric or hyphens
-	result := ""
-	for _, r := range clean {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
-			result += string(r)
+		if flag.Changed {
+			continue
+		}
+		if err := cmd.Flags().Set(name, settings[name]); err != nil {
+			return fmt.Errorf("invalid value %q for %q in config file %s: %w", settings[name], name, path, err)
 		}
 	}
-
-	// Remove leading/trailing hyphens and collapse multiple hyphens
-	result = strings.Trim(result, "-")
-	for strings.Contains(result, "--") {
-		result = strings.ReplaceAll(result, "--", "-")
-	}
-
-	return result
-}
-
-func runTool(cmd *cobra.Command, args []string) {
-	ctx := context.Background()
-
-	// Always use the multi-service implementation
-	runToolMultiService(ctx, toolCfg)
+	return nil
 }
@@ -0,0 +1,112 @@
+package cudly
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// accountAliasFile is the on-disk form of the account alias cache (--account-cache-file)
+type accountAliasFile struct {
+	Accounts map[string]accountAliasEntry `json:"accounts"`
+}
+
+// accountAliasEntry is an account name resolved through Organizations and when it was resolved
+type accountAliasEntry struct {
+	Name       string    `json:"name"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// load adds the aliases of the cache file at path that are younger than ttl (0 = never expire)
+// A missing file is not an error, as it is created by the first save.
+func (c *AccountAliasCache) load(path string, ttl time.Duration, now time.Time) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read account cache file: %w", err)
+	}
+	var file accountAliasFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0, fmt.Errorf("failed to parse account cache file %s: %w", path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	loaded := 0
+	for accountID, entry := range file.Accounts {
+		if entry.Name == "" || (ttl > 0 && now.Sub(entry.ResolvedAt) > ttl) {
+			continue
+		}
+		c.cache[accountID] = entry.Name
+		c.resolved[accountID] = entry.ResolvedAt
+		loaded++
+	}
+	return loaded, nil
+}
+
+// save writes the aliases resolved through Organizations to path, replacing it atomically
+// Account IDs used as their own alias after a failed lookup are not saved, so they are looked up again next time.
+func (c *AccountAliasCache) save(path string) error {
+	c.mu.RLock()
+	file := accountAliasFile{Accounts: make(map[string]accountAliasEntry, len(c.resolved))}
+	for accountID, resolvedAt := range c.resolved {
+		file.Accounts[accountID] = accountAliasEntry{Name: c.cache[accountID], ResolvedAt: resolvedAt}
+	}
+	c.mu.RUnlock()
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode account cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write account cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write account cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write account cache file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write account cache file: %w", err)
+	}
+	return nil
+}
+
+// newAccountAliasCache creates the account alias cache of a run, loading --account-cache-file when set
+func newAccountAliasCache(awsCfg aws.Config, cfg RunConfig) *AccountAliasCache {
+	cache := NewAccountAliasCache(awsCfg)
+	if cfg.AccountCacheFile == "" {
+		return cache
+	}
+	loaded, err := cache.load(cfg.AccountCacheFile, cfg.AccountCacheTTL, time.Now())
+	if err != nil {
+		log.Printf("⚠️  Warning: Ignoring the account cache: %v", err)
+		return cache
+	}
+	if loaded > 0 {
+		AppLogger.Printf("👤 Loaded %d account name(s) from %s\n", loaded, cfg.AccountCacheFile)
+	}
+	return cache
+}
+
+// saveAccountAliasCache writes the account alias cache to --account-cache-file when set
+func saveAccountAliasCache(cache *AccountAliasCache, cfg RunConfig) {
+	if cache == nil || cfg.AccountCacheFile == "" {
+		return
+	}
+	if err := cache.save(cfg.AccountCacheFile); err != nil {
+		log.Printf("⚠️  Warning: Failed to save the account cache: %v", err)
+	}
+}
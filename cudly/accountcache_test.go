@@ -0,0 +1,63 @@
+package cudly
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAccountAliasCache() *AccountAliasCache {
+	return &AccountAliasCache{cache: make(map[string]string), resolved: make(map[string]time.Time)}
+}
+
+func TestAccountAliasCacheSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.json")
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	cache := newTestAccountAliasCache()
+	cache.cache["111111111111"] = "production"
+	cache.resolved["111111111111"] = now.Add(-time.Hour)
+	cache.cache["222222222222"] = "staging"
+	cache.resolved["222222222222"] = now.Add(-48 * time.Hour)
+	cache.cache["333333333333"] = "333333333333" // fallback after a failed lookup
+	require.NoError(t, cache.save(path))
+
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		expected map[string]string
+	}{
+		{"within ttl", 24 * time.Hour, map[string]string{"111111111111": "production"}},
+		{"never expire", 0, map[string]string{"111111111111": "production", "222222222222": "staging"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded := newTestAccountAliasCache()
+			n, err := loaded.load(path, tt.ttl, now)
+
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.expected), n)
+			assert.Equal(t, tt.expected, loaded.cache)
+			assert.Equal(t, "production", loaded.GetAccountAlias(context.Background(), "111111111111"))
+		})
+	}
+}
+
+func TestAccountAliasCacheLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte("not json"), 0o600))
+
+	n, err := newTestAccountAliasCache().load(filepath.Join(dir, "missing.json"), time.Hour, time.Now())
+	require.NoError(t, err, "a missing file is created by the first save")
+	assert.Zero(t, n)
+
+	_, err = newTestAccountAliasCache().load(invalid, time.Hour, time.Now())
+	assert.ErrorContains(t, err, "failed to parse account cache file")
+}
@@ -0,0 +1,85 @@
+package cudly
+
+import (
+	"sort"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// GroupByAccount is the --group-by value adding a per-account breakdown to the summary
+const GroupByAccount = "account"
+
+// AccountProcessingStats holds the recommendation totals of an account
+type AccountProcessingStats struct {
+	Account                 string  `json:"account"`
+	AccountName             string  `json:"account_name,omitempty"`
+	RecommendationsSelected int     `json:"recommendations_selected"`
+	InstancesProcessed      int     `json:"instances_processed"`
+	TotalEstimatedSavings   float64 `json:"total_estimated_savings"`
+}
+
+// calculateAccountStats sums recommendations by account, keyed by account ID or, when the ID is unknown, by account name
+// Accounts are sorted by savings, highest first.
+func calculateAccountStats(recs []common.Recommendation) []AccountProcessingStats {
+	byAccount := make(map[string]*AccountProcessingStats)
+	var keys []string
+	for _, rec := range recs {
+		key := rec.Account
+		if key == "" {
+			key = rec.AccountName
+		}
+		stats, ok := byAccount[key]
+		if !ok {
+			stats = &AccountProcessingStats{Account: rec.Account, AccountName: rec.AccountName}
+			byAccount[key] = stats
+			keys = append(keys, key)
+		}
+		if stats.AccountName == "" {
+			stats.AccountName = rec.AccountName
+		}
+		stats.RecommendationsSelected++
+		stats.InstancesProcessed += rec.Count
+		stats.TotalEstimatedSavings += rec.EstimatedSavings
+	}
+
+	result := make([]AccountProcessingStats, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *byAccount[key])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].TotalEstimatedSavings > result[j].TotalEstimatedSavings
+	})
+	return result
+}
+
+// accountDisplayName describes an account by its name and ID, falling back to "unknown" for recommendations without one
+func accountDisplayName(stats AccountProcessingStats) string {
+	switch {
+	case stats.AccountName != "" && stats.Account != "" && stats.AccountName != stats.Account:
+		return stats.AccountName + " (" + stats.Account + ")"
+	case stats.AccountName != "":
+		return stats.AccountName
+	case stats.Account != "":
+		return stats.Account
+	default:
+		return "unknown"
+	}
+}
+
+// printAccountSummary prints the per-account breakdown of --group-by account
+func printAccountSummary(recs []common.Recommendation) {
+	accounts := calculateAccountStats(recs)
+	if len(accounts) == 0 {
+		return
+	}
+
+	outPrintln("\n🏢 BY ACCOUNT:")
+	outPrintln("--------------------------------------------------")
+	for _, stats := range accounts {
+		outPrintf("%-30s | Recs: %3d | Instances: %3d | Savings: $%8.2f/mo\n",
+			accountDisplayName(stats),
+			stats.RecommendationsSelected,
+			stats.InstancesProcessed,
+			stats.TotalEstimatedSavings)
+	}
+}
@@ -0,0 +1,46 @@
+package cudly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func TestCalculateAccountStats(t *testing.T) {
+	recs := []common.Recommendation{
+		{Account: "111111111111", AccountName: "prod", Count: 2, EstimatedSavings: 100},
+		{Account: "222222222222", Count: 1, EstimatedSavings: 300},
+		{Account: "111111111111", AccountName: "prod", Count: 3, EstimatedSavings: 150},
+		{Count: 1, EstimatedSavings: 10},
+	}
+
+	stats := calculateAccountStats(recs)
+
+	assert.Equal(t, []AccountProcessingStats{
+		{Account: "222222222222", RecommendationsSelected: 1, InstancesProcessed: 1, TotalEstimatedSavings: 300},
+		{Account: "111111111111", AccountName: "prod", RecommendationsSelected: 2, InstancesProcessed: 5, TotalEstimatedSavings: 250},
+		{RecommendationsSelected: 1, InstancesProcessed: 1, TotalEstimatedSavings: 10},
+	}, stats)
+}
+
+func TestAccountDisplayName(t *testing.T) {
+	tests := []struct {
+		name     string
+		stats    AccountProcessingStats
+		expected string
+	}{
+		{"name and id", AccountProcessingStats{Account: "111111111111", AccountName: "prod"}, "prod (111111111111)"},
+		{"name resolved to the id", AccountProcessingStats{Account: "111111111111", AccountName: "111111111111"}, "111111111111"},
+		{"id only", AccountProcessingStats{Account: "111111111111"}, "111111111111"},
+		{"name only", AccountProcessingStats{AccountName: "prod"}, "prod"},
+		{"unknown", AccountProcessingStats{}, "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, accountDisplayName(tt.stats))
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package cudly
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+const (
+	// OutputFormatCSV writes the purchase results as a CSV report (default)
+	OutputFormatCSV = "csv"
+	// OutputFormatAWSCLI writes a reviewable shell script of equivalent AWS CLI purchase commands
+	OutputFormatAWSCLI = "aws-cli"
+	// OutputFormatJSON writes the purchase results as a JSON report, including the service-specific details
+	OutputFormatJSON = "json"
+)
+
+// shellQuote quotes a value for safe use as a single POSIX shell word
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+}
+
+// cliDurationSeconds returns the reservation duration in seconds for a term
+func cliDurationSeconds(term string) string {
+	if term == "3yr" || term == "3" {
+		return "94608000"
+	}
+	return "31536000"
+}
+
+// cliOfferingType returns the offering type name used by the RI describe APIs
+func cliOfferingType(paymentOption string) string {
+	switch paymentOption {
+	case "all-upfront":
+		return "All Upfront"
+	case "partial-upfront":
+		return "Partial Upfront"
+	default:
+		return "No Upfront"
+	}
+}
+
+// cliOpenSearchPaymentOption returns the payment option enum used by the OpenSearch API
+func cliOpenSearchPaymentOption(paymentOption string) string {
+	switch paymentOption {
+	case "all-upfront":
+		return "ALL_UPFRONT"
+	case "partial-upfront":
+		return "PARTIAL_UPFRONT"
+	default:
+		return "NO_UPFRONT"
+	}
+}
+
+// cliSavingsPlanType returns the plan type name used by the Savings Plans API
+func cliSavingsPlanType(planType string) string {
+	switch planType {
+	case "EC2Instance":
+		return "EC2Instance"
+	case "SageMaker", "Sagemaker":
+		return "SageMaker"
+	case "Database":
+		return "Database"
+	default:
+		return "Compute"
+	}
+}
+
+// awsCLICommand renders the AWS CLI command that purchases rec
+// The offering ID is looked up inline with the matching describe command, so the script stays reviewable
+// It returns false for services or recommendations without a known CLI mapping
+func awsCLICommand(rec common.Recommendation) (string, bool) {
+	region := shellQuote(rec.Region)
+	duration := cliDurationSeconds(rec.Term)
+	offeringType := shellQuote(cliOfferingType(rec.PaymentOption))
+	count := rec.Count
+
+	switch rec.Service {
+	case common.ServiceEC2:
+		platform, tenancy := "Linux/UNIX", "default"
+		if details, ok := rec.Details.(*common.ComputeDetails); ok && details != nil {
+			if details.Platform != "" {
+				platform = details.Platform
+			}
+			if details.Tenancy != "" {
+				tenancy = details.Tenancy
+			}
+		}
+		lookup := fmt.Sprintf("aws ec2 describe-reserved-instances-offerings --region %s --instance-type %s --product-description %s --instance-tenancy %s --offering-type %s --min-duration %s --max-duration %s --no-include-marketplace --query 'ReservedInstancesOfferings[0].ReservedInstancesOfferingId' --output text",
+			region, shellQuote(rec.ResourceType), shellQuote(platform), shellQuote(tenancy), offeringType, duration, duration)
+		return fmt.Sprintf("aws ec2 purchase-reserved-instances-offering --region %s --instance-count %d --reserved-instances-offering-id \"$(%s)\"", region, count, lookup), true
+
+	case common.ServiceRDS:
+		details, ok := rec.Details.(*common.DatabaseDetails)
+		if !ok || details == nil {
+			return "", false
+		}
+		multiAZ := "--no-multi-az"
+		if details.AZConfig == "multi-az" {
+			multiAZ = "--multi-az"
+		}
+		lookup := fmt.Sprintf("aws rds describe-reserved-db-instances-offerings --region %s --db-instance-class %s --product-description %s %s --duration %s --offering-type %s --query 'ReservedDBInstancesOfferings[0].ReservedDBInstancesOfferingId' --output text",
+			region, shellQuote(rec.ResourceType), shellQuote(normalizeEngineName(details.Engine)), multiAZ, duration, offeringType)
+		return fmt.Sprintf("aws rds purchase-reserved-db-instances-offering --region %s --db-instance-count %d --reserved-db-instances-offering-id \"$(%s)\"", region, count, lookup), true
+
+	case common.ServiceElastiCache:
+		details, ok := rec.Details.(*common.CacheDetails)
+		if !ok || details == nil {
+			return "", false
+		}
+		lookup := fmt.Sprintf("aws elasticache describe-reserved-cache-nodes-offerings --region %s --cache-node-type %s --product-description %s --duration %s --offering-type %s --query 'ReservedCacheNodesOfferings[0].ReservedCacheNodesOfferingId' --output text",
+			region, shellQuote(rec.ResourceType), shellQuote(strings.ToLower(details.Engine)), duration, offeringType)
+		return fmt.Sprintf("aws elasticache purchase-reserved-cache-nodes-offering --region %s --cache-node-count %d --reserved-cache-nodes-offering-id \"$(%s)\"", region, count, lookup), true
+
+	case common.ServiceMemoryDB:
+		lookup := fmt.Sprintf("aws memorydb describe-reserved-nodes-offerings --region %s --node-type %s --duration %s --offering-type %s --query 'ReservedNodesOfferings[0].ReservedNodesOfferingId' --output text",
+			region, shellQuote(rec.ResourceType), duration, offeringType)
+		return fmt.Sprintf("aws memorydb purchase-reserved-nodes-offering --region %s --node-count %d --reserved-nodes-offering-id \"$(%s)\"", region, count, lookup), true
+
+	case common.ServiceRedshift:
+		query := fmt.Sprintf("ReservedNodeOfferings[?NodeType==`\"%s\"` && Duration==`%s` && OfferingType==`\"%s\"`].ReservedNodeOfferingId | [0]",
+			rec.ResourceType, duration, cliOfferingType(rec.PaymentOption))
+		lookup := fmt.Sprintf("aws redshift describe-reserved-node-offerings --region %s --query %s --output text", region, shellQuote(query))
+		return fmt.Sprintf("aws redshift purchase-reserved-node-offering --region %s --node-count %d --reserved-node-offering-id \"$(%s)\"", region, count, lookup), true
+
+	case common.ServiceOpenSearch:
+		query := fmt.Sprintf("ReservedInstanceOfferings[?InstanceType==`\"%s\"` && Duration==`%s` && PaymentOption==`\"%s\"`].ReservedInstanceOfferingId | [0]",
+			rec.ResourceType, duration, cliOpenSearchPaymentOption(rec.PaymentOption))
+		lookup := fmt.Sprintf("aws opensearch describe-reserved-instance-offerings --region %s --query %s --output text", region, shellQuote(query))
+		return fmt.Sprintf("aws opensearch purchase-reserved-instance-offering --region %s --instance-count %d --reservation-name %s --reserved-instance-offering-id \"$(%s)\"",
+			region, count, shellQuote("cudly-"+rec.ResourceType), lookup), true
+
+	case common.ServiceSavingsPlans:
+		details, ok := rec.Details.(*common.SavingsPlanDetails)
+		if !ok || details == nil {
+			return "", false
+		}
+		lookup := fmt.Sprintf("aws savingsplans describe-savings-plans-offerings --plan-types %s --durations %s --payment-options %s --query 'searchResults[0].offeringId' --output text",
+			cliSavingsPlanType(details.PlanType), duration, offeringType)
+		return fmt.Sprintf("aws savingsplans create-savings-plan --commitment %s --savings-plan-offering-id \"$(%s)\"", shellQuote(fmt.Sprintf("%.2f", details.HourlyCommitment)), lookup), true
+
+	default:
+		return "", false
+	}
+}
+
+// writeAWSCLIScript writes a shell script with one AWS CLI purchase command per purchase result
+// Recommendations without a known CLI mapping are skipped with a warning
+func writeAWSCLIScript(results []common.PurchaseResult, filepath string) error {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# AWS CLI purchase commands generated by CUDly.\n")
+	b.WriteString("# Review every command before running it: each one makes a real, non-refundable purchase.\n")
+	b.WriteString("set -eu\n")
+
+	written := 0
+	for _, result := range results {
+		rec := result.Recommendation
+		command, ok := awsCLICommand(rec)
+		if !ok {
+			log.Printf("⚠️  Warning: No AWS CLI mapping for %s %s, skipping it in the script", getServiceDisplayName(rec.Service), rec.ResourceType)
+			continue
+		}
+		fmt.Fprintf(&b, "\n# %s %s in %s (%d), estimated savings $%.2f/mo\n", getServiceDisplayName(rec.Service), rec.ResourceType, rec.Region, rec.Count, rec.EstimatedSavings)
+		b.WriteString(command)
+		b.WriteString("\n")
+		written++
+	}
+
+	if err := os.WriteFile(filepath, []byte(b.String()), 0o755); err != nil {
+		return fmt.Errorf("failed to write AWS CLI script: %w", err)
+	}
+	AppLogger.Printf("🧾 Rendered %d AWS CLI purchase command(s)\n", written)
+	return nil
+}
+
+// generateAWSCLIScriptFilename returns the output path for the AWS CLI script
+func generateAWSCLIScriptFilename(cfg RunConfig) string {
+	if cfg.CSVOutput != "" {
+		return outputPath(cfg, cfg.CSVOutput)
+	}
+	return strings.TrimSuffix(generateCSVFilename(true, cfg), ".csv") + ".sh"
+}
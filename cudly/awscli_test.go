@@ -0,0 +1,102 @@
+package cudly
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "'db.r6g.large'", shellQuote("db.r6g.large"))
+	assert.Equal(t, `'it'"'"'s'`, shellQuote("it's"))
+}
+
+func TestAWSCLICommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		rec      common.Recommendation
+		contains []string
+	}{
+		{
+			name: "EC2",
+			rec: common.Recommendation{Service: common.ServiceEC2, Region: "us-east-1", ResourceType: "m5.large", Count: 3, Term: "1yr", PaymentOption: "no-upfront",
+				Details: &common.ComputeDetails{Platform: "Linux/UNIX", Tenancy: "default"}},
+			contains: []string{"aws ec2 purchase-reserved-instances-offering", "--instance-count 3", "--instance-type 'm5.large'", "--offering-type 'No Upfront'", "--min-duration 31536000"},
+		},
+		{
+			name: "RDS multi-AZ",
+			rec: common.Recommendation{Service: common.ServiceRDS, Region: "eu-west-1", ResourceType: "db.r6g.large", Count: 2, Term: "3yr", PaymentOption: "partial-upfront",
+				Details: &common.DatabaseDetails{Engine: "PostgreSQL", AZConfig: "multi-az"}},
+			contains: []string{"aws rds purchase-reserved-db-instances-offering", "--db-instance-count 2", "--product-description 'postgresql'", "--multi-az", "--duration 94608000", "--offering-type 'Partial Upfront'", "--region 'eu-west-1'"},
+		},
+		{
+			name: "ElastiCache",
+			rec: common.Recommendation{Service: common.ServiceElastiCache, Region: "us-west-2", ResourceType: "cache.r6g.large", Count: 1, Term: "1yr", PaymentOption: "all-upfront",
+				Details: &common.CacheDetails{Engine: "Redis"}},
+			contains: []string{"aws elasticache purchase-reserved-cache-nodes-offering", "--cache-node-count 1", "--product-description 'redis'", "--offering-type 'All Upfront'"},
+		},
+		{
+			name:     "MemoryDB",
+			rec:      common.Recommendation{Service: common.ServiceMemoryDB, Region: "us-east-1", ResourceType: "db.r6g.large", Count: 2, Term: "1yr"},
+			contains: []string{"aws memorydb purchase-reserved-nodes-offering", "--node-count 2", "--node-type 'db.r6g.large'"},
+		},
+		{
+			name:     "Redshift",
+			rec:      common.Recommendation{Service: common.ServiceRedshift, Region: "us-east-1", ResourceType: "ra3.xlplus", Count: 2, Term: "1yr", PaymentOption: "all-upfront"},
+			contains: []string{"aws redshift purchase-reserved-node-offering", "--node-count 2", "NodeType==`\"ra3.xlplus\"`", "OfferingType==`\"All Upfront\"`"},
+		},
+		{
+			name:     "OpenSearch",
+			rec:      common.Recommendation{Service: common.ServiceOpenSearch, Region: "us-east-1", ResourceType: "r6g.large.search", Count: 2, Term: "3yr", PaymentOption: "no-upfront"},
+			contains: []string{"aws opensearch purchase-reserved-instance-offering", "--instance-count 2", "PaymentOption==`\"NO_UPFRONT\"`", "--reservation-name 'cudly-r6g.large.search'"},
+		},
+		{
+			name: "Savings Plan",
+			rec: common.Recommendation{Service: common.ServiceSavingsPlans, Count: 1, Term: "1yr", PaymentOption: "no-upfront",
+				Details: &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 5}},
+			contains: []string{"aws savingsplans create-savings-plan", "--commitment '5.00'", "--plan-types Compute", "--payment-options 'No Upfront'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, ok := awsCLICommand(tt.rec)
+			require.True(t, ok)
+			for _, expected := range tt.contains {
+				assert.Contains(t, command, expected)
+			}
+		})
+	}
+
+	t.Run("RDS without details has no mapping", func(t *testing.T) {
+		_, ok := awsCLICommand(common.Recommendation{Service: common.ServiceRDS, ResourceType: "db.t3.micro"})
+		assert.False(t, ok)
+	})
+
+	t.Run("unknown service has no mapping", func(t *testing.T) {
+		_, ok := awsCLICommand(common.Recommendation{Service: common.ServiceType("lambda")})
+		assert.False(t, ok)
+	})
+}
+
+func TestWriteAWSCLIScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "purchase.sh")
+	results := []common.PurchaseResult{
+		{Recommendation: common.Recommendation{Service: common.ServiceMemoryDB, Region: "us-east-1", ResourceType: "db.r6g.large", Count: 2, EstimatedSavings: 120}, Success: true, DryRun: true},
+		{Recommendation: common.Recommendation{Service: common.ServiceType("lambda"), ResourceType: "unknown"}, Success: true, DryRun: true},
+	}
+
+	require.NoError(t, writeAWSCLIScript(results, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	script := string(data)
+	assert.Contains(t, script, "#!/bin/sh")
+	assert.Contains(t, script, "# MemoryDB db.r6g.large in us-east-1 (2), estimated savings $120.00/mo")
+	assert.Contains(t, script, "aws memorydb purchase-reserved-nodes-offering")
+	assert.NotContains(t, script, "unknown")
+}
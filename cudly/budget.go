@@ -0,0 +1,251 @@
+package cudly
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+)
+
+// deferredRecommendation is a recommendation that did not fit into the upfront budget of a run
+type deferredRecommendation struct {
+	Service       common.ServiceType `json:"service"`
+	Region        string             `json:"region"`
+	Account       string             `json:"account,omitempty"`
+	ResourceType  string             `json:"resource_type"`
+	Engine        string             `json:"engine,omitempty"`
+	Term          string             `json:"term"`
+	PaymentOption string             `json:"payment_option"`
+	Count         int                `json:"count"`
+	UpfrontCost   float64            `json:"upfront_cost"`
+	DeferredAt    time.Time          `json:"deferred_at"`
+}
+
+// key identifies the recommendation a deferred entry was created from
+func (d deferredRecommendation) key() string {
+	return strings.Join([]string{string(d.Service), d.Region, d.Account, d.ResourceType, strings.ToLower(d.Engine)}, "|")
+}
+
+// newDeferredRecommendation records count instances of rec as deferred
+func newDeferredRecommendation(rec common.Recommendation, count int, upfrontCost float64, now time.Time) deferredRecommendation {
+	return deferredRecommendation{
+		Service:       rec.Service,
+		Region:        rec.Region,
+		Account:       rec.Account,
+		ResourceType:  rec.ResourceType,
+		Engine:        getEngineFromRecommendationRaw(rec),
+		Term:          rec.Term,
+		PaymentOption: rec.PaymentOption,
+		Count:         count,
+		UpfrontCost:   upfrontCost,
+		DeferredAt:    now,
+	}
+}
+
+// upfrontBudget caps the upfront cost of the purchases made in a run (--max-upfront-budget)
+// Recommendations carried over from the previous run are served first, and budget for carried-over
+// recommendations of regions that were not processed yet is held back from fresh ones.
+type upfrontBudget struct {
+	limit    float64
+	spent    float64
+	carried  map[string]bool
+	pending  map[string][]deferredRecommendation
+	deferred []deferredRecommendation
+	now      func() time.Time
+}
+
+// newUpfrontBudget creates a budget of limit USD with the recommendations carried over from the previous run
+func newUpfrontBudget(limit float64, carried []deferredRecommendation) *upfrontBudget {
+	b := &upfrontBudget{
+		limit:   limit,
+		carried: make(map[string]bool),
+		pending: make(map[string][]deferredRecommendation),
+		now:     time.Now,
+	}
+	for _, entry := range carried {
+		b.carried[entry.key()] = true
+		regionKey := budgetRegionKey(entry.Service, entry.Region)
+		b.pending[regionKey] = append(b.pending[regionKey], entry)
+	}
+	return b
+}
+
+// budgetRegionKey groups carried-over recommendations by the service and region they are processed in
+func budgetRegionKey(service common.ServiceType, region string) string {
+	return string(service) + "|" + region
+}
+
+// reserved returns the upfront cost held back for carried-over recommendations not processed yet
+func (b *upfrontBudget) reserved() float64 {
+	total := 0.0
+	for _, entries := range b.pending {
+		for _, entry := range entries {
+			total += entry.UpfrontCost
+		}
+	}
+	return total
+}
+
+// isCarriedOver reports whether rec was deferred by the previous run
+func (b *upfrontBudget) isCarriedOver(rec common.Recommendation) bool {
+	return b.carried[newDeferredRecommendation(rec, 0, 0, time.Time{}).key()]
+}
+
+// prioritize moves carried-over recommendations to the front, keeping the order otherwise
+func (b *upfrontBudget) prioritize(recs []common.Recommendation) []common.Recommendation {
+	ordered := make([]common.Recommendation, 0, len(recs))
+	var fresh []common.Recommendation
+	for _, rec := range recs {
+		if b.isCarriedOver(rec) {
+			ordered = append(ordered, rec)
+		} else {
+			fresh = append(fresh, rec)
+		}
+	}
+	return append(ordered, fresh...)
+}
+
+// allocate returns the recommendations of one service and region that fit into the remaining budget
+// Counts are reduced to what is still affordable and the rest is deferred to the next run.
+// unitCost returns the upfront cost of a single instance (or plan) of a recommendation.
+// A nil budget allows everything.
+func (b *upfrontBudget) allocate(service common.ServiceType, region string, recs []common.Recommendation, unitCost func(rec common.Recommendation) (float64, error)) []common.Recommendation {
+	if b == nil {
+		return recs
+	}
+
+	// The carried-over recommendations of this region compete for the budget directly from now on
+	delete(b.pending, budgetRegionKey(service, region))
+	available := b.limit - b.spent - b.reserved()
+
+	allowed := make([]common.Recommendation, 0, len(recs))
+	for _, rec := range b.prioritize(recs) {
+		unit, err := unitCost(rec)
+		if err != nil {
+			log.Printf("    ⚠️  Could not determine upfront cost of %s %s, deferring it: %v", rec.Service, rec.ResourceType, err)
+			b.deferred = append(b.deferred, newDeferredRecommendation(rec, rec.Count, 0, b.now()))
+			continue
+		}
+		if unit <= 0 {
+			allowed = append(allowed, rec)
+			continue
+		}
+
+		affordable := 0
+		if available > 0 {
+			affordable = int(available / unit)
+		}
+		if affordable > rec.Count {
+			affordable = rec.Count
+		}
+		if affordable > 0 {
+			adjusted := rec
+			adjusted.Count = affordable
+			allowed = append(allowed, adjusted)
+			available -= unit * float64(affordable)
+			b.spent += unit * float64(affordable)
+		}
+		if remaining := rec.Count - affordable; remaining > 0 {
+			AppLogger.Printf("    💰 Upfront budget: deferring %d of %d %s to the next run ($%.2f upfront)\n", remaining, rec.Count, rec.ResourceType, unit*float64(remaining))
+			b.deferred = append(b.deferred, newDeferredRecommendation(rec, remaining, unit*float64(remaining), b.now()))
+		}
+	}
+	return allowed
+}
+
+// carryOver returns the deferred queue for the next run: this run's deferrals first, followed by
+// carried-over recommendations of regions this run did not process
+func (b *upfrontBudget) carryOver() []deferredRecommendation {
+	queue := append([]deferredRecommendation{}, b.deferred...)
+	regionKeys := make([]string, 0, len(b.pending))
+	for regionKey := range b.pending {
+		regionKeys = append(regionKeys, regionKey)
+	}
+	sort.Strings(regionKeys)
+	for _, regionKey := range regionKeys {
+		queue = append(queue, b.pending[regionKey]...)
+	}
+	return queue
+}
+
+// offeringUnitUpfrontCost returns the upfront cost of one instance of rec from its offering
+func offeringUnitUpfrontCost(ctx context.Context, client provider.ServiceClient, rec common.Recommendation) (float64, error) {
+	if rec.PaymentOption == "no-upfront" {
+		return 0, nil
+	}
+	offering, err := client.GetOfferingDetails(ctx, rec)
+	if err != nil {
+		return 0, err
+	}
+	return offering.UpfrontCost, nil
+}
+
+// allocateUpfrontBudget limits the recommendations of one service and region to the remaining upfront budget
+func allocateUpfrontBudget(ctx context.Context, budget *upfrontBudget, service common.ServiceType, region string, recs []common.Recommendation, client provider.ServiceClient) []common.Recommendation {
+	if budget == nil {
+		return recs
+	}
+	allowed := budget.allocate(service, region, recs, func(rec common.Recommendation) (float64, error) {
+		return offeringUnitUpfrontCost(ctx, client, rec)
+	})
+	AppLogger.Printf("  💰 Upfront budget: $%.2f of $%.2f used\n", budget.spent, budget.limit)
+	return allowed
+}
+
+// loadUpfrontBudget creates the run's upfront budget from --max-upfront-budget and the --state-file deferred queue
+// It returns nil when no budget is configured.
+func loadUpfrontBudget(cfg RunConfig) (*upfrontBudget, error) {
+	if cfg.MaxUpfrontBudget <= 0 {
+		return nil, nil
+	}
+	var carried []deferredRecommendation
+	if cfg.StateFile != "" {
+		journal, err := stateJournal(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if err := journal.Deferred(&carried); err != nil {
+			return nil, err
+		}
+		if len(carried) > 0 {
+			AppLogger.Printf("📒 Carrying over %d deferred recommendation(s) from %s\n", len(carried), cfg.StateFile)
+		}
+	}
+	AppLogger.Printf("💰 Upfront budget for this run: $%.2f\n", cfg.MaxUpfrontBudget)
+	return newUpfrontBudget(cfg.MaxUpfrontBudget, carried), nil
+}
+
+// finishUpfrontBudget reports the budget usage and persists the deferred queue after an actual purchase run
+func finishUpfrontBudget(budget *upfrontBudget, cfg RunConfig, isDryRun bool) {
+	if budget == nil {
+		return
+	}
+	queue := budget.carryOver()
+	deferredCost := 0.0
+	for _, entry := range queue {
+		deferredCost += entry.UpfrontCost
+	}
+	AppLogger.Printf("\n💰 Upfront budget: spent $%.2f of $%.2f, %d recommendation(s) ($%.2f upfront) deferred to the next run\n",
+		budget.spent, budget.limit, len(queue), deferredCost)
+
+	if cfg.StateFile == "" {
+		return
+	}
+	if isDryRun {
+		AppLogger.Printf("ℹ️  Dry run: not updating the deferred queue in %s\n", cfg.StateFile)
+		return
+	}
+	journal, err := stateJournal(cfg)
+	if err == nil {
+		err = journal.RecordDeferred(budget.now(), queue)
+	}
+	if err != nil {
+		log.Printf("⚠️  Warning: Failed to save deferred queue: %v", err)
+		return
+	}
+	AppLogger.Printf("📒 Saved %d deferred recommendation(s) to %s\n", len(queue), cfg.StateFile)
+}
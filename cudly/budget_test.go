@@ -0,0 +1,140 @@
+package cudly
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedUnitCosts prices recommendations by resource type
+func fixedUnitCosts(costs map[string]float64) func(rec common.Recommendation) (float64, error) {
+	return func(rec common.Recommendation) (float64, error) {
+		cost, ok := costs[rec.ResourceType]
+		if !ok {
+			return 0, errors.New("no offering found")
+		}
+		return cost, nil
+	}
+}
+
+func TestUpfrontBudgetAllocate(t *testing.T) {
+	budget := newUpfrontBudget(1500, nil)
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 2},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.xlarge", Count: 3},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.micro", Count: 4},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.unknown", Count: 1},
+	}
+	costs := map[string]float64{"db.r5.large": 300, "db.r5.xlarge": 600, "db.t3.micro": 0}
+
+	allowed := budget.allocate(common.ServiceRDS, "us-east-1", recs, fixedUnitCosts(costs))
+
+	require.Len(t, allowed, 3)
+	assert.Equal(t, 2, allowed[0].Count)
+	assert.Equal(t, "db.r5.xlarge", allowed[1].ResourceType)
+	assert.Equal(t, 1, allowed[1].Count, "only one xlarge fits into the remaining $900")
+	assert.Equal(t, 4, allowed[2].Count, "recommendations without upfront cost are always allowed")
+	assert.InDelta(t, 1200, budget.spent, 0.001)
+
+	require.Len(t, budget.deferred, 2)
+	assert.Equal(t, "db.r5.xlarge", budget.deferred[0].ResourceType)
+	assert.Equal(t, 2, budget.deferred[0].Count)
+	assert.InDelta(t, 1200, budget.deferred[0].UpfrontCost, 0.001)
+	assert.Equal(t, "db.unknown", budget.deferred[1].ResourceType, "unpriced recommendations are deferred")
+}
+
+func TestUpfrontBudgetCarriedOverFirst(t *testing.T) {
+	carried := []deferredRecommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.xlarge", Count: 1, UpfrontCost: 600},
+	}
+	budget := newUpfrontBudget(600, carried)
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 2},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.xlarge", Count: 1},
+	}
+
+	allowed := budget.allocate(common.ServiceRDS, "us-east-1", recs, fixedUnitCosts(map[string]float64{"db.r5.large": 300, "db.r5.xlarge": 600}))
+
+	require.Len(t, allowed, 1)
+	assert.Equal(t, "db.r5.xlarge", allowed[0].ResourceType, "carried-over recommendation jumps the queue")
+	require.Len(t, budget.deferred, 1)
+	assert.Equal(t, "db.r5.large", budget.deferred[0].ResourceType)
+	assert.Equal(t, 2, budget.deferred[0].Count)
+}
+
+func TestUpfrontBudgetReservesCarriedOverOfLaterRegions(t *testing.T) {
+	carried := []deferredRecommendation{
+		{Service: common.ServiceRDS, Region: "eu-west-1", ResourceType: "db.r5.xlarge", Count: 1, UpfrontCost: 600},
+		{Service: common.ServiceElastiCache, Region: "us-east-1", ResourceType: "cache.r5.large", Count: 1, UpfrontCost: 200},
+	}
+	budget := newUpfrontBudget(1000, carried)
+	costs := fixedUnitCosts(map[string]float64{"db.r5.large": 300, "db.r5.xlarge": 600})
+
+	// Fresh recommendations in another region only get what is not held back for carried-over ones
+	allowed := budget.allocate(common.ServiceRDS, "us-east-1", []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 2},
+	}, costs)
+	require.Len(t, allowed, 0)
+
+	allowed = budget.allocate(common.ServiceRDS, "eu-west-1", []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "eu-west-1", ResourceType: "db.r5.xlarge", Count: 1},
+	}, costs)
+	require.Len(t, allowed, 1)
+	assert.InDelta(t, 600, budget.spent, 0.001)
+
+	// The ElastiCache region was never processed, so its entry stays queued
+	queue := budget.carryOver()
+	require.Len(t, queue, 2)
+	assert.Equal(t, "db.r5.large", queue[0].ResourceType)
+	assert.Equal(t, common.ServiceElastiCache, queue[1].Service)
+}
+
+func TestUpfrontBudgetNilAllowsEverything(t *testing.T) {
+	var budget *upfrontBudget
+	recs := []common.Recommendation{{ResourceType: "db.r5.large", Count: 5}}
+	assert.Equal(t, recs, budget.allocate(common.ServiceRDS, "us-east-1", recs, nil))
+	assert.Equal(t, recs, allocateUpfrontBudget(context.Background(), nil, common.ServiceRDS, "us-east-1", recs, nil))
+}
+
+func TestAllocateUpfrontBudgetUsesOfferingPrices(t *testing.T) {
+	client := &MockServiceClient{}
+	partial := common.Recommendation{Service: common.ServiceEC2, Region: "us-east-1", ResourceType: "m5.large", Count: 3, PaymentOption: "partial-upfront"}
+	client.On("GetOfferingDetails", mock.Anything, partial).Return(&common.OfferingDetails{UpfrontCost: 250}, nil).Once()
+	noUpfront := common.Recommendation{Service: common.ServiceEC2, Region: "us-east-1", ResourceType: "m5.xlarge", Count: 2, PaymentOption: "no-upfront"}
+
+	budget := newUpfrontBudget(500, nil)
+	allowed := allocateUpfrontBudget(context.Background(), budget, common.ServiceEC2, "us-east-1", []common.Recommendation{partial, noUpfront}, client)
+
+	require.Len(t, allowed, 2)
+	assert.Equal(t, 2, allowed[0].Count)
+	assert.Equal(t, 2, allowed[1].Count)
+	client.AssertExpectations(t)
+}
+
+func TestFinishUpfrontBudgetPersistsDeferredQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	cfg := RunConfig{MaxUpfrontBudget: 100, StateFile: path}
+
+	budget := newUpfrontBudget(100, nil)
+	budget.allocate(common.ServiceRDS, "us-east-1", []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 1},
+	}, fixedUnitCosts(map[string]float64{"db.r5.large": 300}))
+
+	// Dry runs leave the queue untouched
+	finishUpfrontBudget(budget, cfg, true)
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	finishUpfrontBudget(budget, cfg, false)
+	loaded, err := loadUpfrontBudget(cfg)
+	require.NoError(t, err)
+	assert.True(t, loaded.isCarriedOver(common.Recommendation{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large"}))
+	assert.InDelta(t, 300, loaded.reserved(), 0.001)
+}
@@ -0,0 +1,849 @@
+package cudly
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/internal/state"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+const (
+	// MaxReasonableInstances is the maximum number of instances that can be processed
+	// This is a safety limit to prevent accidental large purchases
+	MaxReasonableInstances = 10000
+)
+
+// RunConfig holds all configuration of a run, mirroring the command line flags
+type RunConfig struct {
+	Providers                   []string
+	Regions                     []string
+	RegionSets                  []string
+	Services                    []string
+	Coverage                    float64
+	CoverageRounding            string
+	ActualPurchase              bool
+	CSVOutput                   string
+	CSVInput                    string
+	JSONInput                   string
+	AllServices                 bool
+	PaymentOption               string
+	TermYears                   int
+	Terms                       []int
+	CompareTerms                bool
+	AutoPayment                 bool
+	EC2OfferingClass            string
+	EC2Scope                    string
+	IncludeRegions              []string
+	ExcludeRegions              []string
+	IncludeInstanceTypes        []string
+	ExcludeInstanceTypes        []string
+	ExcludeInstanceTypePatterns []string
+	IncludeArchitectures        []string
+	ExcludeArchitectures        []string
+	IncludeEngines              []string
+	ExcludeEngines              []string
+	IncludeAccounts             []string
+	ExcludeAccounts             []string
+	SkipConfirmation            bool
+	Preview                     bool
+	ConfirmPhrase               bool
+	ConfirmPhraseInstances      int
+	ConfirmPhraseCost           float64
+	FailFast                    bool
+	MaxInstances                int32
+	OverrideCount               int32
+	Profile                     string
+	ValidationProfile           string
+	IncludeExtendedSupport      bool
+	SkipVersionChecks           bool
+	ExcludeEngineVersions       []string
+	MinSavingsPerInstance       float64
+	ExcludeZeroSavings          bool
+	EventBridgeBus              string
+	SlackWebhookURL             string
+	UploadS3                    string
+	DelayJitter                 time.Duration
+	ValidateOfferings           bool
+	DryRunPurchaseAPI           bool
+	RetrySkipped                bool
+	RetrySkippedCooldown        time.Duration
+	DecommissionTag             string
+	CacheDir                    string
+	CacheTTL                    time.Duration
+	AccountCacheFile            string
+	AccountCacheTTL             time.Duration
+	CacheOnly                   bool
+	NoCache                     bool
+	PerRegionRateLimit          bool
+	NoEmoji                     bool
+	Quiet                       bool
+	Progress                    bool
+	SPCommitments               []string
+	MaxScanRegions              int
+	OutputFormat                string
+	HTMLOutput                  string
+	MarkdownOutput              string
+	MetricsFile                 string
+	ReceiptsDir                 string
+	OutputDir                   string
+	NoDoubleCommit              bool
+	RespectExistingSP           bool
+	MinInstanceAge              time.Duration
+	CapToRunning                bool
+	FilterExpression            string
+	MaxUpfrontBudget            float64
+	StateFile                   string
+	LookbackDays                int
+	MinMonthlySavings           float64
+	ExpectMaxInstances          int
+	ExpectMinSavings            float64
+	SortBy                      string
+	GroupBy                     string
+	MaxMonthlySpend             float64
+	MaxConcurrency              int
+	ServiceConcurrency          int
+	AllowConcurrentPurchase     bool
+	APIRetries                  int
+	APIRetryDelay               time.Duration
+	Timeout                     time.Duration
+	DryRunDiff                  bool
+	RecommendationsOnly         bool
+	CoverageSatisfiedThreshold  float64
+	AccountRoles                []string
+	AccountID                   string
+	IgnoreRIsExpiringWithin     time.Duration
+	LogLevel                    string
+	LogFormat                   string
+	JSONSummary                 bool
+	// RI vs Savings Plans comparison
+	IncludeMarketplaceSavings bool
+	// Savings Plans specific filters
+	IncludeSPTypes []string
+	ExcludeSPTypes []string
+
+	// ConfirmFunc replaces the interactive purchase confirmation for programs embedding CUDly, such as to approve
+	// purchases with custom logic. It receives the instance count and estimated cost of each batch; --yes still skips it.
+	ConfirmFunc func(totalInstances int, totalCost float64) bool
+
+	// journal is the --state-file journal opened for the run, shared by the copies of the configuration
+	journal *state.Journal
+	// confirmed is the running total of the purchases confirmed during the run, shared by the copies of the configuration
+	confirmed *confirmedPurchases
+}
+
+// Validate checks the configuration and normalizes the provider names and payment option in place
+func (cfg *RunConfig) Validate() error {
+	// Validate cloud providers
+	cfg.Providers = normalizeProviders(cfg.Providers)
+	if err := validateProviders(*cfg); err != nil {
+		return err
+	}
+
+	// Validate coverage percentage
+	if cfg.Coverage < 0 || cfg.Coverage > 100 {
+		return fmt.Errorf("coverage percentage must be between 0 and 100, got: %.2f", cfg.Coverage)
+	}
+	rounding, err := validateCoverageRounding(cfg.CoverageRounding)
+	if err != nil {
+		return err
+	}
+	cfg.CoverageRounding = rounding
+
+	// Validate max instances
+	if cfg.MaxInstances < 0 {
+		return fmt.Errorf("max-instances must be 0 (no limit) or a positive number, got: %d", cfg.MaxInstances)
+	}
+
+	// Validate max instances doesn't exceed reasonable limit
+	if cfg.MaxInstances > MaxReasonableInstances {
+		return fmt.Errorf("max-instances (%d) exceeds reasonable limit of %d", cfg.MaxInstances, MaxReasonableInstances)
+	}
+
+	// Validate override count
+	if cfg.OverrideCount < 0 {
+		return fmt.Errorf("override-count must be 0 (disabled) or a positive number, got: %d", cfg.OverrideCount)
+	}
+
+	// Validate override count doesn't exceed reasonable limit
+	if cfg.OverrideCount > MaxReasonableInstances {
+		return fmt.Errorf("override-count (%d) exceeds reasonable limit of %d", cfg.OverrideCount, MaxReasonableInstances)
+	}
+
+	// Validate minimum savings per instance
+	if cfg.MinSavingsPerInstance < 0 {
+		return fmt.Errorf("min-savings-per-instance must be 0 (disabled) or a positive number, got: %.2f", cfg.MinSavingsPerInstance)
+	}
+
+	// Validate output format
+	switch cfg.OutputFormat {
+	case "", OutputFormatCSV, OutputFormatJSON:
+	case OutputFormatAWSCLI:
+		if cfg.ActualPurchase {
+			return fmt.Errorf("--output-format aws-cli renders commands for manual review and cannot be combined with --purchase")
+		}
+	default:
+		return fmt.Errorf("invalid output format: %s. Must be one of: %s, %s, %s", cfg.OutputFormat, OutputFormatCSV, OutputFormatJSON, OutputFormatAWSCLI)
+	}
+
+	// Validate sort order
+	switch cfg.SortBy {
+	case "", SortBySavings, SortByCount, SortByNone:
+	default:
+		return fmt.Errorf("invalid sort-by: %s. Must be one of: %s, %s, %s", cfg.SortBy, SortBySavings, SortByCount, SortByNone)
+	}
+
+	// Validate the S3 report upload destination
+	if cfg.UploadS3 != "" {
+		if _, _, err := parseS3URI(cfg.UploadS3); err != nil {
+			return fmt.Errorf("invalid upload-s3: %w", err)
+		}
+	}
+
+	// Validate summary grouping
+	switch cfg.GroupBy {
+	case "", GroupByAccount:
+	default:
+		return fmt.Errorf("invalid group-by: %s. Must be: %s", cfg.GroupBy, GroupByAccount)
+	}
+
+	// Validate coverage satisfied threshold
+	if cfg.CoverageSatisfiedThreshold < 0 || cfg.CoverageSatisfiedThreshold > 100 {
+		return fmt.Errorf("coverage-satisfied-threshold must be between 0 and 100, got: %.2f", cfg.CoverageSatisfiedThreshold)
+	}
+	if cfg.CoverageSatisfiedThreshold > 0 && cfg.CacheOnly {
+		return fmt.Errorf("--coverage-satisfied-threshold needs the existing reservations and cannot be combined with --cache-only")
+	}
+
+	if cfg.RespectExistingSP && cfg.CacheOnly {
+		return fmt.Errorf("--respect-existing-sp needs the existing Savings Plans and cannot be combined with --cache-only")
+	}
+
+	// Validate coverage diff
+	if cfg.DryRunDiff {
+		if cfg.ActualPurchase {
+			return fmt.Errorf("--dry-run-diff only applies to dry runs and cannot be combined with --purchase")
+		}
+		if cfg.CacheOnly {
+			return fmt.Errorf("--dry-run-diff needs the existing reservations and cannot be combined with --cache-only")
+		}
+	}
+
+	// A quiet run can't show the purchase confirmation prompt
+	if cfg.Quiet && cfg.ActualPurchase && !cfg.SkipConfirmation && cfg.ConfirmFunc == nil {
+		return fmt.Errorf("--quiet hides the purchase confirmation prompt and needs --yes with --purchase")
+	}
+
+	// Validate recommendations-only exports
+	if cfg.RecommendationsOnly {
+		if cfg.ActualPurchase {
+			return fmt.Errorf("--recommendations-only exports recommendations without purchasing and cannot be combined with --purchase")
+		}
+		if cfg.OutputFormat == OutputFormatAWSCLI {
+			return fmt.Errorf("--recommendations-only writes CSV or JSON and cannot be combined with --output-format aws-cli")
+		}
+	}
+
+	// Validate Cost Explorer retries
+	if cfg.APIRetries < 0 {
+		return fmt.Errorf("api-retries must be 0 (no retries) or a positive number, got: %d", cfg.APIRetries)
+	}
+	if cfg.APIRetries > 0 && cfg.APIRetryDelay <= 0 {
+		return fmt.Errorf("api-retry-delay must be positive when retries are enabled, got: %s", cfg.APIRetryDelay)
+	}
+
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("timeout must be 0 (no timeout) or a positive duration, got: %s", cfg.Timeout)
+	}
+
+	// Validate region fetch concurrency
+	if cfg.MaxConcurrency < 0 {
+		return fmt.Errorf("max-concurrency must be a positive number, got: %d", cfg.MaxConcurrency)
+	}
+
+	// Validate service concurrency; the options below depend on the services processed before, or on the terminal
+	if cfg.ServiceConcurrency < 0 {
+		return fmt.Errorf("service-concurrency must be a positive number, got: %d", cfg.ServiceConcurrency)
+	}
+	if cfg.AllowConcurrentPurchase && cfg.ServiceConcurrency <= 1 {
+		return fmt.Errorf("--allow-concurrent-purchase requires --service-concurrency greater than 1")
+	}
+	if cfg.ServiceConcurrency > 1 {
+		switch {
+		case cfg.ActualPurchase && !cfg.AllowConcurrentPurchase:
+			return fmt.Errorf("--service-concurrency with --purchase requires --allow-concurrent-purchase, as purchases change the account")
+		case cfg.ActualPurchase && !cfg.SkipConfirmation:
+			return fmt.Errorf("--allow-concurrent-purchase requires --yes, as the confirmation prompts of concurrent services can't be answered")
+		case cfg.NoDoubleCommit:
+			return fmt.Errorf("--service-concurrency cannot be combined with --no-double-commit")
+		case cfg.MaxUpfrontBudget > 0:
+			return fmt.Errorf("--service-concurrency cannot be combined with --max-upfront-budget")
+		case cfg.Progress:
+			return fmt.Errorf("--service-concurrency cannot be combined with --progress")
+		}
+	}
+
+	// Validate max scan regions
+	if cfg.MaxScanRegions < 0 {
+		return fmt.Errorf("max-scan-regions must be 0 (unlimited) or a positive number, got: %d", cfg.MaxScanRegions)
+	}
+
+	// Validate filter expression
+	if cfg.FilterExpression != "" {
+		if _, err := parseFilterExpression(cfg.FilterExpression); err != nil {
+			return fmt.Errorf("invalid --filter expression: %w", err)
+		}
+	}
+
+	// Validate minimum monthly savings
+	if cfg.MinMonthlySavings < 0 {
+		return fmt.Errorf("min-monthly-savings must be 0 (disabled) or a positive number, got: %.2f", cfg.MinMonthlySavings)
+	}
+
+	// Validate excluded engine versions
+	if _, err := parseExcludedEngineVersions(cfg.ExcludeEngineVersions); err != nil {
+		return fmt.Errorf("invalid exclude-engine-versions: %w", err)
+	}
+
+	// Validate minimum instance age
+	if cfg.MinInstanceAge < 0 {
+		return fmt.Errorf("min-instance-age must be 0 (disabled) or a positive duration, got: %s", cfg.MinInstanceAge)
+	}
+
+	// Validate monthly spend limit
+	if cfg.MaxMonthlySpend < 0 {
+		return fmt.Errorf("max-monthly-spend must be 0 (no limit) or a positive amount, got: %.2f", cfg.MaxMonthlySpend)
+	}
+
+	// Validate upfront budget and its state journal
+	if cfg.MaxUpfrontBudget < 0 {
+		return fmt.Errorf("max-upfront-budget must be 0 (no limit) or a positive amount, got: %.2f", cfg.MaxUpfrontBudget)
+	}
+	if cfg.MaxUpfrontBudget > 0 {
+		if cfg.CacheOnly {
+			return fmt.Errorf("--max-upfront-budget needs offering prices and cannot be combined with --cache-only")
+		}
+		if len(cfg.SPCommitments) > 0 {
+			return fmt.Errorf("--max-upfront-budget cannot be combined with --sp-commitment")
+		}
+	}
+
+	// Validate cross-account roles
+	for _, entry := range cfg.AccountRoles {
+		if _, err := common.ParseAccountRole(entry); err != nil {
+			return fmt.Errorf("invalid --accounts-roles entry: %w", err)
+		}
+	}
+	if len(cfg.AccountRoles) > 0 {
+		if cfg.CacheDir != "" {
+			return fmt.Errorf("--accounts-roles cannot be combined with --cache-dir, as cached recommendations are not kept per account")
+		}
+		if cfg.CSVInput != "" || cfg.JSONInput != "" || len(cfg.SPCommitments) > 0 {
+			return fmt.Errorf("--accounts-roles only applies to recommendations fetched from Cost Explorer and cannot be combined with --input-csv, --input-json or --sp-commitment")
+		}
+	}
+
+	// Validate the linked account Cost Explorer recommendations are scoped to
+	if cfg.AccountID != "" {
+		if len(cfg.AccountID) != 12 || strings.Trim(cfg.AccountID, "0123456789") != "" {
+			return fmt.Errorf("invalid account-id %q, expected 12 digits", cfg.AccountID)
+		}
+		if cfg.CSVInput != "" || cfg.JSONInput != "" || len(cfg.SPCommitments) > 0 {
+			return fmt.Errorf("--account-id only applies to recommendations fetched from Cost Explorer and cannot be combined with --input-csv, --input-json or --sp-commitment")
+		}
+	}
+
+	// Validate region sets
+	for _, name := range cfg.RegionSets {
+		if err := common.ValidateRegionSet(name); err != nil {
+			return err
+		}
+		if name == common.RegionSetAllOptedIn && cfg.CacheOnly {
+			return fmt.Errorf("--region-set %s needs to look up the enabled regions and cannot be combined with --cache-only", name)
+		}
+	}
+
+	// Validate logging options
+	if _, err := common.ParseLogLevel(cfg.LogLevel); err != nil {
+		return err
+	}
+	if cfg.LogFormat != "" && cfg.LogFormat != common.LogFormatText && cfg.LogFormat != common.LogFormatJSON {
+		return fmt.Errorf("invalid log-format: %s. Must be one of: %s, %s", cfg.LogFormat, common.LogFormatText, common.LogFormatJSON)
+	}
+
+	// Validate the expiring reservations window
+	if cfg.IgnoreRIsExpiringWithin < 0 {
+		return fmt.Errorf("ignore-ris-expiring-within must be 0 (disabled) or a positive duration, got: %s", cfg.IgnoreRIsExpiringWithin)
+	}
+
+	// Validate delay jitter
+	if cfg.DelayJitter < 0 {
+		return fmt.Errorf("delay-jitter must be 0 (disabled) or a positive duration, got: %s", cfg.DelayJitter)
+	}
+
+	// Validate retry cooldown
+	if cfg.RetrySkippedCooldown < 0 {
+		return fmt.Errorf("retry-skipped-cooldown must be a positive duration, got: %s", cfg.RetrySkippedCooldown)
+	}
+
+	// Validate recommendation cache options
+	if cfg.AccountCacheTTL < 0 {
+		return fmt.Errorf("account-cache-ttl must be 0 (never expire) or a positive duration, got: %s", cfg.AccountCacheTTL)
+	}
+	if cfg.CacheTTL < 0 {
+		return fmt.Errorf("cache-ttl must be 0 (never expire) or a positive duration, got: %s", cfg.CacheTTL)
+	}
+	if cfg.NoCache && cfg.CacheOnly {
+		return fmt.Errorf("--no-cache cannot be combined with --cache-only")
+	}
+	if cfg.CacheOnly {
+		if cfg.CacheDir == "" {
+			return fmt.Errorf("--cache-only requires --cache-dir")
+		}
+		if cfg.ActualPurchase {
+			return fmt.Errorf("--cache-only implies dry-run and cannot be combined with --purchase")
+		}
+		if cfg.CSVInput != "" || cfg.JSONInput != "" {
+			return fmt.Errorf("--cache-only cannot be combined with --input-csv or --input-json")
+		}
+		if cfg.EventBridgeBus != "" {
+			return fmt.Errorf("--cache-only skips all AWS calls and cannot be combined with --emit-eventbridge")
+		}
+		if cfg.IncludeMarketplaceSavings {
+			return fmt.Errorf("--cache-only skips all AWS calls and cannot be combined with --include-marketplace-savings")
+		}
+	}
+
+	// Validate Slack webhook URL
+	if cfg.SlackWebhookURL != "" {
+		if u, err := url.Parse(cfg.SlackWebhookURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid slack-webhook-url: must be an http(s) URL")
+		}
+	}
+
+	// Validate decommission tag format
+	if cfg.DecommissionTag != "" {
+		if _, _, err := parseDecommissionTag(cfg.DecommissionTag); err != nil {
+			return fmt.Errorf("invalid decommission-tag: %w", err)
+		}
+	}
+
+	// The filters below rely on the running RDS instances that --skip-version-checks doesn't query
+	if cfg.SkipVersionChecks {
+		switch {
+		case len(cfg.ExcludeEngineVersions) > 0:
+			return fmt.Errorf("--skip-version-checks cannot be combined with --exclude-engine-versions")
+		case cfg.DecommissionTag != "":
+			return fmt.Errorf("--skip-version-checks cannot be combined with --decommission-tag")
+		case cfg.MinInstanceAge > 0:
+			return fmt.Errorf("--skip-version-checks cannot be combined with --min-instance-age")
+		case cfg.CapToRunning:
+			return fmt.Errorf("--skip-version-checks cannot be combined with --cap-to-running")
+		}
+	}
+	if cfg.DryRunPurchaseAPI {
+		if cfg.ActualPurchase {
+			return fmt.Errorf("--dry-run-purchase-api validates purchases without making them and cannot be combined with --purchase")
+		}
+		if cfg.CacheOnly {
+			return fmt.Errorf("--dry-run-purchase-api calls the purchase API and cannot be combined with --cache-only")
+		}
+	}
+	if cfg.CapToRunning && cfg.CacheOnly {
+		return fmt.Errorf("--cap-to-running needs the running RDS instances and cannot be combined with --cache-only")
+	}
+
+	// Validate the --expect guards; purchase runs need the whole plan up front to check them before any purchase
+	if cfg.ExpectMaxInstances < 0 {
+		return fmt.Errorf("expect-max-instances must be 0 (disabled) or a positive number, got: %d", cfg.ExpectMaxInstances)
+	}
+	if cfg.ExpectMinSavings < 0 {
+		return fmt.Errorf("expect-min-savings must be 0 (disabled) or a positive number, got: %.2f", cfg.ExpectMinSavings)
+	}
+	if cfg.ExpectMaxInstances > 0 || cfg.ExpectMinSavings > 0 {
+		if len(cfg.SPCommitments) > 0 {
+			return fmt.Errorf("--expect-max-instances and --expect-min-savings cannot be combined with --sp-commitment")
+		}
+		if cfg.ActualPurchase && cfg.CSVInput == "" && cfg.JSONInput == "" {
+			return fmt.Errorf("--expect-max-instances and --expect-min-savings require --input-csv or --input-json with --purchase, so that the guards are checked before any purchase")
+		}
+	}
+
+	// Validate the --confirm-phrase thresholds
+	if cfg.ConfirmPhraseInstances < 0 {
+		return fmt.Errorf("confirm-phrase-instances must be 0 or a positive number, got: %d", cfg.ConfirmPhraseInstances)
+	}
+	if cfg.ConfirmPhraseCost < 0 {
+		return fmt.Errorf("confirm-phrase-cost must be 0 or a positive number, got: %.2f", cfg.ConfirmPhraseCost)
+	}
+
+	// Validate fixed Savings Plan commitments
+	if len(cfg.SPCommitments) > 0 {
+		if _, err := parseSPCommitments(cfg.SPCommitments); err != nil {
+			return fmt.Errorf("invalid sp-commitment: %w", err)
+		}
+		if cfg.CSVInput != "" || cfg.JSONInput != "" {
+			return fmt.Errorf("--sp-commitment cannot be combined with --input-csv or --input-json")
+		}
+		if cfg.CacheOnly {
+			return fmt.Errorf("--sp-commitment cannot be combined with --cache-only")
+		}
+	}
+
+	// Validate and normalize payment option
+	paymentOption, err := normalizePaymentOption(cfg.PaymentOption)
+	if err != nil {
+		return err
+	}
+	cfg.PaymentOption = paymentOption
+
+	// Validate and normalize the EC2 offering class (empty defaults to standard)
+	switch strings.ToLower(strings.TrimSpace(cfg.EC2OfferingClass)) {
+	case "", "standard":
+		cfg.EC2OfferingClass = "standard"
+	case "convertible":
+		cfg.EC2OfferingClass = "convertible"
+	default:
+		return fmt.Errorf("invalid ec2-offering-class: %s. Must be standard or convertible", cfg.EC2OfferingClass)
+	}
+	if cfg.EC2Scope, err = validateEC2Scope(cfg.EC2Scope); err != nil {
+		return err
+	}
+
+	// Validate term years
+	if cfg.TermYears != 1 && cfg.TermYears != 3 {
+		return fmt.Errorf("invalid term: %d years. Must be 1 or 3", cfg.TermYears)
+	}
+
+	// --compare-terms is a dry run of both terms
+	if cfg.CompareTerms {
+		if cfg.ActualPurchase {
+			return fmt.Errorf("--compare-terms only applies to dry runs and cannot be combined with --purchase")
+		}
+		if len(cfg.Terms) > 0 {
+			return fmt.Errorf("--compare-terms fetches both terms and cannot be combined with --terms")
+		}
+		cfg.Terms = []int{1, 3}
+	}
+
+	// Validate --terms, dropping repeated terms; a single term is the same as --term
+	if len(cfg.Terms) > 0 {
+		terms := make([]int, 0, len(cfg.Terms))
+		for _, years := range cfg.Terms {
+			if years != 1 && years != 3 {
+				return fmt.Errorf("invalid terms: %d years. Must be 1 or 3", years)
+			}
+			if !slices.Contains(terms, years) {
+				terms = append(terms, years)
+			}
+		}
+		cfg.Terms = terms
+		cfg.TermYears = terms[0]
+		if len(terms) > 1 {
+			if cfg.ActualPurchase {
+				return fmt.Errorf("--terms with more than one term compares recommendations and cannot be combined with --purchase")
+			}
+			if len(cfg.SPCommitments) > 0 {
+				return fmt.Errorf("--terms with more than one term cannot be combined with --sp-commitment")
+			}
+		}
+	}
+
+	// Validate lookback period (0 falls back to the 7 day default)
+	switch cfg.LookbackDays {
+	case 0, 7, 30, 60:
+	default:
+		return fmt.Errorf("invalid lookback-days: %d. Cost Explorer only offers recommendations for lookback windows of 7, 30 or 60 days", cfg.LookbackDays)
+	}
+
+	// Validate the output directory, under which relative report paths are placed
+	if cfg.OutputDir != "" {
+		if err := validateOutputDir(*cfg); err != nil {
+			return err
+		}
+	}
+
+	// Validate the purchase receipts directory, which is created on the first purchase
+	if cfg.ReceiptsDir != "" {
+		if info, err := os.Stat(cfg.ReceiptsDir); err == nil && !info.IsDir() {
+			return fmt.Errorf("receipts-dir is not a directory: %s", cfg.ReceiptsDir)
+		}
+	}
+
+	// Validate CSV output path if provided
+	if cfg.CSVOutput != "" && cfg.OutputDir == "" {
+		// Check if the directory exists
+		dir := filepath.Dir(cfg.CSVOutput)
+		if dir != "." && dir != "" {
+			if _, err := os.Stat(dir); os.IsNotExist(err) {
+				return fmt.Errorf("output directory does not exist: %s", dir)
+			}
+		}
+	}
+
+	if cfg.CSVInput != "" && cfg.JSONInput != "" {
+		return fmt.Errorf("--input-csv and --input-json cannot be combined")
+	}
+
+	// Validate CSV input path if provided
+	if cfg.CSVInput != "" {
+		if _, err := os.Stat(cfg.CSVInput); os.IsNotExist(err) {
+			return fmt.Errorf("input CSV file does not exist: %s", cfg.CSVInput)
+		}
+		if !strings.HasSuffix(strings.ToLower(cfg.CSVInput), ".csv") {
+			return fmt.Errorf("input file must have .csv extension: %s", cfg.CSVInput)
+		}
+	}
+
+	// Validate JSON input path if provided
+	if cfg.JSONInput != "" {
+		if _, err := os.Stat(cfg.JSONInput); os.IsNotExist(err) {
+			return fmt.Errorf("input JSON file does not exist: %s", cfg.JSONInput)
+		}
+		if !strings.HasSuffix(strings.ToLower(cfg.JSONInput), ".json") {
+			return fmt.Errorf("input file must have .json extension: %s", cfg.JSONInput)
+		}
+	}
+
+	// Validate filter flags
+	if len(cfg.IncludeRegions) > 0 && len(cfg.ExcludeRegions) > 0 {
+		// Check for conflicts
+		for _, inc := range cfg.IncludeRegions {
+			for _, exc := range cfg.ExcludeRegions {
+				if inc == exc {
+					return fmt.Errorf("region '%s' cannot be both included and excluded", inc)
+				}
+			}
+		}
+	}
+
+	if len(cfg.IncludeInstanceTypes) > 0 && len(cfg.ExcludeInstanceTypes) > 0 {
+		// Check for conflicts
+		for _, inc := range cfg.IncludeInstanceTypes {
+			for _, exc := range cfg.ExcludeInstanceTypes {
+				if inc == exc {
+					return fmt.Errorf("instance type '%s' cannot be both included and excluded", inc)
+				}
+			}
+		}
+	}
+
+	if len(cfg.IncludeEngines) > 0 && len(cfg.ExcludeEngines) > 0 {
+		// Check for conflicts
+		for _, inc := range cfg.IncludeEngines {
+			for _, exc := range cfg.ExcludeEngines {
+				if inc == exc {
+					return fmt.Errorf("engine '%s' cannot be both included and excluded", inc)
+				}
+			}
+		}
+	}
+
+	// Validate instance types format (basic validation)
+	if err := validateInstanceTypes(cfg.IncludeInstanceTypes); err != nil {
+		return fmt.Errorf("invalid include-instance-types: %w", err)
+	}
+	if err := validateInstanceTypes(cfg.ExcludeInstanceTypes); err != nil {
+		return fmt.Errorf("invalid exclude-instance-types: %w", err)
+	}
+	for _, pattern := range cfg.ExcludeInstanceTypePatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclude-instance-type-patterns pattern %q: %w", pattern, err)
+		}
+	}
+
+	// Validate architectures, normalizing them to lower case
+	if cfg.IncludeArchitectures, err = normalizeArchitectures(cfg.IncludeArchitectures); err != nil {
+		return fmt.Errorf("invalid include-architectures: %w", err)
+	}
+	if cfg.ExcludeArchitectures, err = normalizeArchitectures(cfg.ExcludeArchitectures); err != nil {
+		return fmt.Errorf("invalid exclude-architectures: %w", err)
+	}
+	for _, arch := range cfg.IncludeArchitectures {
+		if slices.Contains(cfg.ExcludeArchitectures, arch) {
+			return fmt.Errorf("architecture '%s' cannot be both included and excluded", arch)
+		}
+	}
+
+	return nil
+}
+
+// normalizeArchitectures lower-cases architecture names and checks that they are supported
+func normalizeArchitectures(archs []string) ([]string, error) {
+	normalized := make([]string, 0, len(archs))
+	for _, arch := range archs {
+		a := strings.ToLower(strings.TrimSpace(arch))
+		if !slices.Contains(common.Architectures, a) {
+			return nil, fmt.Errorf("unknown architecture '%s': must be one of %s", arch, strings.Join(common.Architectures, ", "))
+		}
+		normalized = append(normalized, a)
+	}
+	return normalized, nil
+}
+
+// validateInstanceTypes performs basic validation on instance type names
+func validateInstanceTypes(instanceTypes []string) error {
+	if len(instanceTypes) == 0 {
+		return nil
+	}
+	for _, t := range instanceTypes {
+		// Basic format validation: should contain at least one dot
+		if t == "" {
+			return fmt.Errorf("empty instance type")
+		}
+		if !strings.Contains(t, ".") {
+			return fmt.Errorf("invalid instance type format '%s': expected format like 'db.t3.micro'", t)
+		}
+	}
+	return nil
+}
+
+// parseDecommissionTag splits a key=value tag specification into its key and value
+func parseDecommissionTag(tag string) (string, string, error) {
+	key, value, found := strings.Cut(tag, "=")
+	if !found {
+		return "", "", fmt.Errorf("expected format key=value, got '%s'", tag)
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", "", fmt.Errorf("tag key cannot be empty in '%s'", tag)
+	}
+	return key, strings.TrimSpace(value), nil
+}
+
+// serviceNameMap maps the lowercase service names accepted on the command line to service types
+var serviceNameMap = map[string]common.ServiceType{
+	"rds":           common.ServiceRDS,
+	"elasticache":   common.ServiceElastiCache,
+	"ec2":           common.ServiceEC2,
+	"opensearch":    common.ServiceOpenSearch,
+	"elasticsearch": common.ServiceOpenSearch, // Legacy alias maps to OpenSearch
+	"redshift":      common.ServiceRedshift,
+	"memorydb":      common.ServiceMemoryDB,
+	"dynamodb":      common.ServiceDynamoDB,
+	"savingsplans":  common.ServiceSavingsPlans,
+	"sp":            common.ServiceSavingsPlans, // Short alias
+}
+
+// parseServices maps service names to service types, keeping the first occurrence of services named more than once,
+// such as opensearch and its elasticsearch alias
+func parseServices(serviceNames []string) []common.ServiceType {
+	var result []common.ServiceType
+	seen := make(map[common.ServiceType]bool)
+	for _, name := range serviceNames {
+		if service, ok := serviceNameMap[strings.ToLower(name)]; ok {
+			if seen[service] {
+				continue
+			}
+			seen[service] = true
+			result = append(result, service)
+		} else {
+			log.Printf("Warning: Unknown service '%s', skipping", name)
+		}
+	}
+
+	return result
+}
+
+// spPlanTypes maps lowercase Savings Plan type names to the canonical names used in SavingsPlanDetails
+var spPlanTypes = map[string]string{
+	"compute":     "Compute",
+	"ec2instance": "EC2Instance",
+	"sagemaker":   "SageMaker",
+	"database":    "Database",
+}
+
+// parseSPCommitments parses PlanType=hourly-commitment pairs into a map keyed by canonical plan type
+func parseSPCommitments(specs []string) (map[string]float64, error) {
+	commitments := make(map[string]float64, len(specs))
+	for _, spec := range specs {
+		name, value, found := strings.Cut(spec, "=")
+		if !found {
+			return nil, fmt.Errorf("expected PlanType=commitment, got %q", spec)
+		}
+		planType, ok := spPlanTypes[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown Savings Plan type %q (must be one of: Compute, EC2Instance, SageMaker, Database)", strings.TrimSpace(name))
+		}
+		commitment, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hourly commitment %q for %s: %w", strings.TrimSpace(value), planType, err)
+		}
+		if commitment <= 0 {
+			return nil, fmt.Errorf("hourly commitment for %s must be positive, got: %.2f", planType, commitment)
+		}
+		if _, exists := commitments[planType]; exists {
+			return nil, fmt.Errorf("duplicate commitment for %s", planType)
+		}
+		commitments[planType] = commitment
+	}
+	return commitments, nil
+}
+
+// paymentOptionAliases maps accepted payment option spellings to their canonical values
+// Keys are lowercase with spaces and underscores replaced by dashes
+var paymentOptionAliases = map[string]string{
+	"all-upfront":     "all-upfront",
+	"allupfront":      "all-upfront",
+	"all":             "all-upfront",
+	"full":            "all-upfront",
+	"partial-upfront": "partial-upfront",
+	"partialupfront":  "partial-upfront",
+	"partial":         "partial-upfront",
+	"no-upfront":      "no-upfront",
+	"noupfront":       "no-upfront",
+	"none":            "no-upfront",
+	"no":              "no-upfront",
+}
+
+// normalizePaymentOption maps a payment option or one of its aliases to the canonical value
+func normalizePaymentOption(option string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(option))
+	key = strings.NewReplacer(" ", "-", "_", "-").Replace(key)
+	if canonical, ok := paymentOptionAliases[key]; ok {
+		return canonical, nil
+	}
+	return "", fmt.Errorf("invalid payment option: %s. Must be one of: all-upfront, partial-upfront, no-upfront "+
+		"(accepted aliases: all, full, partial, none, no; case, spaces and underscores are ignored)", option)
+}
+
+// getAllServices returns all supported services
+// DynamoDB is left out as its reserved capacity can only be purchased in the console.
+func getAllServices() []common.ServiceType {
+	return []common.ServiceType{
+		common.ServiceRDS,
+		common.ServiceElastiCache,
+		common.ServiceEC2,
+		common.ServiceOpenSearch,
+		common.ServiceRedshift,
+		common.ServiceMemoryDB,
+		common.ServiceSavingsPlans,
+	}
+}
+
+// warnRDSNoUpfrontThreeYear warns that AWS offers no 3-year no-upfront RDS Reserved Instances, unless --auto-payment falls back
+func warnRDSNoUpfrontThreeYear(cfg RunConfig) {
+	if cfg.PaymentOption == "no-upfront" && slices.Contains(termsToProcess(cfg), 3) && !cfg.AutoPayment {
+		services := determineServicesToProcess(cfg)
+		hasRDS := false
+		for _, svc := range services {
+			if svc == common.ServiceRDS {
+				hasRDS = true
+				break
+			}
+		}
+		if hasRDS || cfg.AllServices {
+			log.Println("⚠️  WARNING: AWS does not offer 3-year no-upfront Reserved Instances for RDS.\n" +
+				"    RDS 3-year RIs only support: all-upfront, partial-upfront\n" +
+				"    No RDS recommendations will be found with this combination, use --auto-payment to fall back to partial-upfront for RDS.")
+		}
+	}
+}
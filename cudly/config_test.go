@@ -0,0 +1,1119 @@
+package cudly
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCfg is the configuration that tests adjust and restore field by field
+var testCfg = RunConfig{}
+
+func TestParseServices(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []common.ServiceType
+	}{
+		{
+			name:  "Valid services",
+			input: []string{"rds", "elasticache", "ec2"},
+			expected: []common.ServiceType{
+				common.ServiceRDS,
+				common.ServiceElastiCache,
+				common.ServiceEC2,
+			},
+		},
+		{
+			name:  "Mixed case services",
+			input: []string{"RDS", "ElastiCache", "EC2"},
+			expected: []common.ServiceType{
+				common.ServiceRDS,
+				common.ServiceElastiCache,
+				common.ServiceEC2,
+			},
+		},
+		{
+			name:     "Invalid services",
+			input:    []string{"invalid", "unknown"},
+			expected: nil,
+		},
+		{
+			name:  "Mix of valid and invalid",
+			input: []string{"rds", "invalid", "ec2"},
+			expected: []common.ServiceType{
+				common.ServiceRDS,
+				common.ServiceEC2,
+			},
+		},
+		{
+			name:  "Aliases of the same service",
+			input: []string{"opensearch", "elasticsearch", "rds"},
+			expected: []common.ServiceType{
+				common.ServiceOpenSearch,
+				common.ServiceRDS,
+			},
+		},
+		{
+			name:  "Repeated services",
+			input: []string{"rds", "ec2", "RDS", "sp", "savingsplans"},
+			expected: []common.ServiceType{
+				common.ServiceRDS,
+				common.ServiceEC2,
+				common.ServiceSavingsPlans,
+			},
+		},
+		{
+			name:  "All supported services",
+			input: []string{"rds", "elasticache", "ec2", "opensearch", "redshift", "memorydb", "dynamodb"},
+			expected: []common.ServiceType{
+				common.ServiceRDS,
+				common.ServiceElastiCache,
+				common.ServiceEC2,
+				common.ServiceOpenSearch,
+				common.ServiceRedshift,
+				common.ServiceMemoryDB,
+				common.ServiceDynamoDB,
+			},
+		},
+		{
+			name:  "Legacy elasticsearch alias",
+			input: []string{"elasticsearch"},
+			expected: []common.ServiceType{
+				common.ServiceElasticsearch,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseServices(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGetAllServices(t *testing.T) {
+	services := getAllServices()
+
+	expected := []common.ServiceType{
+		common.ServiceRDS,
+		common.ServiceElastiCache,
+		common.ServiceEC2,
+		common.ServiceOpenSearch,
+		common.ServiceRedshift,
+		common.ServiceMemoryDB,
+		common.ServiceSavingsPlans,
+	}
+
+	assert.Equal(t, expected, services)
+}
+
+func TestParseServicesWithEmptyAndNil(t *testing.T) {
+	// Empty slice
+	result := parseServices([]string{})
+	assert.Empty(t, result)
+
+	// Slice with empty strings
+	result = parseServices([]string{"", "rds", ""})
+	assert.Len(t, result, 1)
+	assert.Equal(t, common.ServiceRDS, result[0])
+
+	// All invalid
+	result = parseServices([]string{"foo", "bar", "baz"})
+	assert.Empty(t, result)
+}
+
+func TestFilterFlagValidation(t *testing.T) {
+	// Save original testCfg values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	tests := []struct {
+		name                 string
+		includeRegions       []string
+		excludeRegions       []string
+		includeInstanceTypes []string
+		excludeInstanceTypes []string
+		expectError          bool
+		errorContains        string
+	}{
+		{
+			name:                 "No conflicts",
+			includeRegions:       []string{"us-east-1"},
+			excludeRegions:       []string{"us-west-2"},
+			includeInstanceTypes: []string{"db.t3.micro"},
+			excludeInstanceTypes: []string{"db.t3.large"},
+			expectError:          false,
+		},
+		{
+			name:                 "Region conflict",
+			includeRegions:       []string{"us-east-1", "us-west-2"},
+			excludeRegions:       []string{"us-west-2"},
+			includeInstanceTypes: []string{},
+			excludeInstanceTypes: []string{},
+			expectError:          true,
+			errorContains:        "region 'us-west-2' cannot be both included and excluded",
+		},
+		{
+			name:                 "Instance type conflict",
+			includeRegions:       []string{},
+			excludeRegions:       []string{},
+			includeInstanceTypes: []string{"db.t3.small"},
+			excludeInstanceTypes: []string{"db.t3.small"},
+			expectError:          true,
+			errorContains:        "instance type 'db.t3.small' cannot be both included and excluded",
+		},
+		{
+			name:                 "Empty filters valid",
+			includeRegions:       []string{},
+			excludeRegions:       []string{},
+			includeInstanceTypes: []string{},
+			excludeInstanceTypes: []string{},
+			expectError:          false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Set test values in testCfg
+			testCfg.IncludeRegions = tt.includeRegions
+			testCfg.ExcludeRegions = tt.excludeRegions
+			testCfg.IncludeInstanceTypes = tt.includeInstanceTypes
+			testCfg.ExcludeInstanceTypes = tt.excludeInstanceTypes
+			testCfg.Coverage = 80.0
+			testCfg.PaymentOption = "no-upfront"
+			testCfg.TermYears = 3
+
+			// Validate the configuration
+			err := testCfg.Validate()
+
+			if tt.expectError {
+				assert.Error(t, err)
+				if err != nil && tt.errorContains != "" {
+					assert.Contains(t, err.Error(), tt.errorContains)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRunConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		setCoverage float64
+		setTerm     int
+		setPayment  string
+		expectError bool
+	}{
+		{
+			name:        "Valid flags",
+			setCoverage: 80.0,
+			setTerm:     1,
+			setPayment:  "partial-upfront",
+			expectError: false,
+		},
+		{
+			name:        "Coverage too high",
+			setCoverage: 150.0,
+			setTerm:     1,
+			setPayment:  "partial-upfront",
+			expectError: true,
+		},
+		{
+			name:        "Coverage negative",
+			setCoverage: -10.0,
+			setTerm:     1,
+			setPayment:  "partial-upfront",
+			expectError: true,
+		},
+		{
+			name:        "Invalid term",
+			setCoverage: 80.0,
+			setTerm:     2,
+			setPayment:  "partial-upfront",
+			expectError: true,
+		},
+		{
+			name:        "Invalid payment option",
+			setCoverage: 80.0,
+			setTerm:     1,
+			setPayment:  "invalid",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original values
+			origCfg := testCfg
+
+			// Set test values
+			testCfg.Coverage = tt.setCoverage
+			testCfg.TermYears = tt.setTerm
+			testCfg.PaymentOption = tt.setPayment
+
+			// Validate the configuration
+			err := testCfg.Validate()
+
+			// Restore original values
+			testCfg = origCfg
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRunConfigValidateExtended(t *testing.T) {
+	// Save original testCfg
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	tests := []struct {
+		name               string
+		setCoverage        float64
+		setTerm            int
+		setPayment         string
+		setMaxInstances    int32
+		setCSVOutput       string
+		setCSVInput        string
+		setIncludeEngines  []string
+		setExcludeEngines  []string
+		setIncludeAccounts []string
+		setExcludeAccounts []string
+		setIncludeTypes    []string
+		setExcludeTypes    []string
+		expectError        bool
+		errorContains      string
+	}{
+		// Coverage boundary tests
+		{
+			name:        "Coverage at minimum boundary (0)",
+			setCoverage: 0.0,
+			setTerm:     1,
+			setPayment:  "no-upfront",
+			expectError: false,
+		},
+		{
+			name:        "Coverage at maximum boundary (100)",
+			setCoverage: 100.0,
+			setTerm:     1,
+			setPayment:  "no-upfront",
+			expectError: false,
+		},
+		{
+			name:          "Coverage below minimum",
+			setCoverage:   -0.001,
+			setTerm:       1,
+			setPayment:    "no-upfront",
+			expectError:   true,
+			errorContains: "coverage percentage must be between 0 and 100",
+		},
+		{
+			name:          "Coverage above maximum",
+			setCoverage:   100.001,
+			setTerm:       1,
+			setPayment:    "no-upfront",
+			expectError:   true,
+			errorContains: "coverage percentage must be between 0 and 100",
+		},
+		{
+			name:        "Coverage with decimals",
+			setCoverage: 75.5,
+			setTerm:     3,
+			setPayment:  "partial-upfront",
+			expectError: false,
+		},
+
+		// Max instances tests
+		{
+			name:            "Max instances zero (no limit)",
+			setCoverage:     80.0,
+			setTerm:         1,
+			setPayment:      "no-upfront",
+			setMaxInstances: 0,
+			expectError:     false,
+		},
+		{
+			name:            "Max instances positive",
+			setCoverage:     80.0,
+			setTerm:         1,
+			setPayment:      "no-upfront",
+			setMaxInstances: 100,
+			expectError:     false,
+		},
+		{
+			name:            "Max instances negative",
+			setCoverage:     80.0,
+			setTerm:         1,
+			setPayment:      "no-upfront",
+			setMaxInstances: -5,
+			expectError:     true,
+			errorContains:   "max-instances must be 0",
+		},
+
+		// Payment option tests
+		{
+			name:        "Payment all-upfront",
+			setCoverage: 80.0,
+			setTerm:     3,
+			setPayment:  "all-upfront",
+			expectError: false,
+		},
+		{
+			name:        "Payment mixed case is normalized",
+			setCoverage: 80.0,
+			setTerm:     1,
+			setPayment:  "All-Upfront",
+			expectError: false,
+		},
+		{
+			name:          "Payment unrecognized",
+			setCoverage:   80.0,
+			setTerm:       1,
+			setPayment:    "monthly",
+			expectError:   true,
+			errorContains: "invalid payment option",
+		},
+		{
+			name:          "Payment empty string",
+			setCoverage:   80.0,
+			setTerm:       1,
+			setPayment:    "",
+			expectError:   true,
+			errorContains: "invalid payment option",
+		},
+
+		// Term tests
+		{
+			name:          "Term zero",
+			setCoverage:   80.0,
+			setTerm:       0,
+			setPayment:    "no-upfront",
+			expectError:   true,
+			errorContains: "invalid term",
+		},
+		{
+			name:          "Term negative",
+			setCoverage:   80.0,
+			setTerm:       -1,
+			setPayment:    "no-upfront",
+			expectError:   true,
+			errorContains: "invalid term",
+		},
+		{
+			name:          "Term five years",
+			setCoverage:   80.0,
+			setTerm:       5,
+			setPayment:    "no-upfront",
+			expectError:   true,
+			errorContains: "invalid term",
+		},
+
+		// Engine conflict tests
+		{
+			name:              "Engine conflict",
+			setCoverage:       80.0,
+			setTerm:           1,
+			setPayment:        "no-upfront",
+			setIncludeEngines: []string{"mysql", "postgres"},
+			setExcludeEngines: []string{"postgres", "redis"},
+			expectError:       true,
+			errorContains:     "engine 'postgres' cannot be both included and excluded",
+		},
+		{
+			name:              "No engine conflict",
+			setCoverage:       80.0,
+			setTerm:           1,
+			setPayment:        "no-upfront",
+			setIncludeEngines: []string{"mysql"},
+			setExcludeEngines: []string{"postgres"},
+			expectError:       false,
+		},
+
+		// Instance type validation tests
+		{
+			name:            "Invalid include instance type",
+			setCoverage:     80.0,
+			setTerm:         1,
+			setPayment:      "no-upfront",
+			setIncludeTypes: []string{"invalidtype"}, // No dot, should fail validation
+			expectError:     true,
+			errorContains:   "invalid include-instance-types",
+		},
+		{
+			name:            "Invalid exclude instance type",
+			setCoverage:     80.0,
+			setTerm:         1,
+			setPayment:      "no-upfront",
+			setExcludeTypes: []string{"badinstance"}, // No dot, should fail validation
+			expectError:     true,
+			errorContains:   "invalid exclude-instance-types",
+		},
+		{
+			name:            "Valid instance types",
+			setCoverage:     80.0,
+			setTerm:         1,
+			setPayment:      "no-upfront",
+			setIncludeTypes: []string{"db.t3.small", "cache.t3.small"},
+			setExcludeTypes: []string{"db.m5.large"},
+			expectError:     false,
+		},
+
+		// Combined validations
+		{
+			name:              "All valid flags combined",
+			setCoverage:       85.5,
+			setTerm:           3,
+			setPayment:        "partial-upfront",
+			setMaxInstances:   50,
+			setIncludeTypes:   []string{"db.t3.small"},
+			setExcludeTypes:   []string{"db.m5.large"},
+			setIncludeEngines: []string{"mysql"},
+			setExcludeEngines: []string{"postgres"},
+			expectError:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Set test values
+			testCfg.Coverage = tt.setCoverage
+			testCfg.TermYears = tt.setTerm
+			testCfg.PaymentOption = tt.setPayment
+			testCfg.MaxInstances = tt.setMaxInstances
+			testCfg.CSVOutput = tt.setCSVOutput
+			testCfg.CSVInput = tt.setCSVInput
+			testCfg.IncludeEngines = tt.setIncludeEngines
+			testCfg.ExcludeEngines = tt.setExcludeEngines
+			testCfg.IncludeAccounts = tt.setIncludeAccounts
+			testCfg.ExcludeAccounts = tt.setExcludeAccounts
+			testCfg.IncludeInstanceTypes = tt.setIncludeTypes
+			testCfg.ExcludeInstanceTypes = tt.setExcludeTypes
+
+			// Validate the configuration
+			err := testCfg.Validate()
+
+			if tt.expectError {
+				assert.Error(t, err)
+				if err != nil && tt.errorContains != "" {
+					assert.Contains(t, err.Error(), tt.errorContains)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseDecommissionTag(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedKey   string
+		expectedValue string
+		expectError   bool
+	}{
+		{"Key and value", "decommission=true", "decommission", "true", false},
+		{"Whitespace trimmed", " lifecycle = retiring ", "lifecycle", "retiring", false},
+		{"Empty value allowed", "decommission=", "decommission", "", false},
+		{"Value containing equals", "note=a=b", "note", "a=b", false},
+		{"Missing equals", "decommission", "", "", true},
+		{"Empty key", "=true", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, value, err := parseDecommissionTag(tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedKey, key)
+			assert.Equal(t, tt.expectedValue, value)
+		})
+	}
+}
+
+func TestRunConfigValidateCacheOnly(t *testing.T) {
+	origCfg := testCfg
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	tests := []struct {
+		name          string
+		cfg           RunConfig
+		errorContains string
+	}{
+		{
+			name: "cache-only with cache dir",
+			cfg:  RunConfig{CacheOnly: true, CacheDir: "/tmp/cudly-cache"},
+		},
+		{
+			name: "valid instance type patterns",
+			cfg:  RunConfig{ExcludeInstanceTypePatterns: []string{"db.t3.*", "*.nano"}},
+		},
+		{
+			name:          "malformed instance type pattern",
+			cfg:           RunConfig{ExcludeInstanceTypePatterns: []string{"db.[t3.*"}},
+			errorContains: "invalid exclude-instance-type-patterns pattern",
+		},
+		{
+			name:          "coverage satisfied threshold above 100",
+			cfg:           RunConfig{CoverageSatisfiedThreshold: 120},
+			errorContains: "coverage-satisfied-threshold must be between 0 and 100",
+		},
+		{
+			name:          "coverage satisfied threshold with cache-only",
+			cfg:           RunConfig{CoverageSatisfiedThreshold: 80, CacheOnly: true, CacheDir: "/tmp/cudly-cache"},
+			errorContains: "--coverage-satisfied-threshold needs the existing reservations",
+		},
+		{
+			name: "accounts roles",
+			cfg:  RunConfig{AccountRoles: []string{"123456789012:arn:aws:iam::123456789012:role/CUDlyRole"}},
+		},
+		{
+			name:          "malformed accounts roles entry",
+			cfg:           RunConfig{AccountRoles: []string{"arn:aws:iam::123456789012:role/CUDlyRole"}},
+			errorContains: "invalid --accounts-roles entry",
+		},
+		{
+			name:          "accounts roles with cache dir",
+			cfg:           RunConfig{AccountRoles: []string{"123456789012:arn:aws:iam::123456789012:role/CUDlyRole"}, CacheDir: "/tmp/cudly-cache"},
+			errorContains: "--accounts-roles cannot be combined with --cache-dir",
+		},
+		{
+			name:          "accounts roles with input csv",
+			cfg:           RunConfig{AccountRoles: []string{"123456789012:arn:aws:iam::123456789012:role/CUDlyRole"}, CSVInput: "recs.csv"},
+			errorContains: "cannot be combined with --input-csv",
+		},
+		{
+			name:          "negative ignore-ris-expiring-within",
+			cfg:           RunConfig{IgnoreRIsExpiringWithin: -time.Hour},
+			errorContains: "ignore-ris-expiring-within must be 0",
+		},
+		{
+			name: "region sets",
+			cfg:  RunConfig{RegionSets: []string{"eu", "all-opted-in"}},
+		},
+		{
+			name: "excluded engine versions",
+			cfg:  RunConfig{ExcludeEngineVersions: []string{"mysql:5.7", "postgres:11"}},
+		},
+		{
+			name:          "excluded engine version without version",
+			cfg:           RunConfig{ExcludeEngineVersions: []string{"mysql"}},
+			errorContains: "invalid exclude-engine-versions",
+		},
+		{
+			name:          "unknown region set",
+			cfg:           RunConfig{RegionSets: []string{"emea"}},
+			errorContains: `unknown region set "emea"`,
+		},
+		{
+			name:          "all opted-in region set with cache-only",
+			cfg:           RunConfig{RegionSets: []string{"all-opted-in"}, CacheOnly: true, CacheDir: "/tmp/cudly-cache"},
+			errorContains: "cannot be combined with --cache-only",
+		},
+		{
+			name:          "cache-only without cache dir",
+			cfg:           RunConfig{CacheOnly: true},
+			errorContains: "--cache-only requires --cache-dir",
+		},
+		{
+			name:          "cache-only with purchase",
+			cfg:           RunConfig{CacheOnly: true, CacheDir: "/tmp/cudly-cache", ActualPurchase: true},
+			errorContains: "cannot be combined with --purchase",
+		},
+		{
+			name:          "cache-only with eventbridge",
+			cfg:           RunConfig{CacheOnly: true, CacheDir: "/tmp/cudly-cache", EventBridgeBus: "bus"},
+			errorContains: "cannot be combined with --emit-eventbridge",
+		},
+		{
+			name:          "no-cache with cache-only",
+			cfg:           RunConfig{NoCache: true, CacheOnly: true, CacheDir: "/tmp/cudly-cache"},
+			errorContains: "--no-cache cannot be combined with --cache-only",
+		},
+		{
+			name: "no-cache with cache dir",
+			cfg:  RunConfig{NoCache: true, CacheDir: "/tmp/cudly-cache", ActualPurchase: true},
+		},
+		{
+			name:          "negative cache ttl",
+			cfg:           RunConfig{CacheDir: "/tmp/cudly-cache", CacheTTL: -time.Hour},
+			errorContains: "cache-ttl must be 0",
+		},
+		{
+			name: "aws-cli output format",
+			cfg:  RunConfig{OutputFormat: OutputFormatAWSCLI},
+		},
+		{
+			name:          "aws-cli output format with purchase",
+			cfg:           RunConfig{OutputFormat: OutputFormatAWSCLI, ActualPurchase: true},
+			errorContains: "cannot be combined with --purchase",
+		},
+		{
+			name: "json output format with purchase",
+			cfg:  RunConfig{OutputFormat: OutputFormatJSON, ActualPurchase: true},
+		},
+		{
+			name: "30 day lookback",
+			cfg:  RunConfig{LookbackDays: 30},
+		},
+		{
+			name:          "invalid lookback",
+			cfg:           RunConfig{LookbackDays: 14},
+			errorContains: "invalid lookback-days: 14",
+		},
+		{
+			name:          "negative min monthly savings",
+			cfg:           RunConfig{MinMonthlySavings: -5},
+			errorContains: "min-monthly-savings must be 0",
+		},
+		{
+			name: "upload to s3",
+			cfg:  RunConfig{UploadS3: "s3://reports/cudly/"},
+		},
+		{
+			name:          "invalid upload s3 uri",
+			cfg:           RunConfig{UploadS3: "reports/cudly"},
+			errorContains: "invalid upload-s3",
+		},
+		{
+			name: "group by account",
+			cfg:  RunConfig{GroupBy: GroupByAccount},
+		},
+		{
+			name:          "invalid group by",
+			cfg:           RunConfig{GroupBy: "region"},
+			errorContains: "invalid group-by: region",
+		},
+		{
+			name:          "negative confirm phrase instances",
+			cfg:           RunConfig{ConfirmPhrase: true, ConfirmPhraseInstances: -1},
+			errorContains: "confirm-phrase-instances must be 0",
+		},
+		{
+			name:          "negative confirm phrase cost",
+			cfg:           RunConfig{ConfirmPhrase: true, ConfirmPhraseCost: -1},
+			errorContains: "confirm-phrase-cost must be 0",
+		},
+		{
+			name:          "negative expect max instances",
+			cfg:           RunConfig{ExpectMaxInstances: -1},
+			errorContains: "expect-max-instances must be 0",
+		},
+		{
+			name: "expect guards in dry run",
+			cfg:  RunConfig{ExpectMaxInstances: 10, ExpectMinSavings: 100},
+		},
+		{
+			name:          "expect guards with sp commitment",
+			cfg:           RunConfig{ExpectMaxInstances: 10, SPCommitments: []string{"Compute=5"}},
+			errorContains: "cannot be combined with --sp-commitment",
+		},
+		{
+			name:          "expect guards with live purchase",
+			cfg:           RunConfig{ExpectMinSavings: 100, ActualPurchase: true},
+			errorContains: "require --input-csv or --input-json with --purchase",
+		},
+		{
+			name: "sort by count",
+			cfg:  RunConfig{SortBy: SortByCount},
+		},
+		{
+			name:          "invalid sort order",
+			cfg:           RunConfig{SortBy: "region"},
+			errorContains: "invalid sort-by: region",
+		},
+		{
+			name:          "negative max monthly spend",
+			cfg:           RunConfig{MaxMonthlySpend: -100},
+			errorContains: "max-monthly-spend must be 0",
+		},
+		{
+			name:          "negative max concurrency",
+			cfg:           RunConfig{MaxConcurrency: -1},
+			errorContains: "max-concurrency must be a positive number",
+		},
+		{
+			name: "dry run diff",
+			cfg:  RunConfig{DryRunDiff: true},
+		},
+		{
+			name:          "dry run diff with purchase",
+			cfg:           RunConfig{DryRunDiff: true, ActualPurchase: true},
+			errorContains: "--dry-run-diff only applies to dry runs",
+		},
+		{
+			name:          "respect existing sp with cache only",
+			cfg:           RunConfig{RespectExistingSP: true, CacheOnly: true},
+			errorContains: "--respect-existing-sp needs the existing Savings Plans",
+		},
+		{
+			name:          "unknown architecture",
+			cfg:           RunConfig{IncludeArchitectures: []string{"sparc"}},
+			errorContains: "invalid include-architectures: unknown architecture 'sparc'",
+		},
+		{
+			name:          "architecture both included and excluded",
+			cfg:           RunConfig{IncludeArchitectures: []string{"ARM64"}, ExcludeArchitectures: []string{"arm64"}},
+			errorContains: "architecture 'arm64' cannot be both included and excluded",
+		},
+		{
+			name:          "negative account cache ttl",
+			cfg:           RunConfig{AccountCacheFile: "accounts.json", AccountCacheTTL: -time.Hour},
+			errorContains: "account-cache-ttl must be 0",
+		},
+		{
+			name:          "cap to running with skip version checks",
+			cfg:           RunConfig{SkipVersionChecks: true, CapToRunning: true},
+			errorContains: "--skip-version-checks cannot be combined with --cap-to-running",
+		},
+		{
+			name:          "cap to running with cache only",
+			cfg:           RunConfig{CacheOnly: true, CacheDir: "/tmp/cudly-cache", CapToRunning: true},
+			errorContains: "--cap-to-running needs the running RDS instances",
+		},
+		{
+			name:          "negative service concurrency",
+			cfg:           RunConfig{ServiceConcurrency: -1},
+			errorContains: "service-concurrency must be a positive number",
+		},
+		{
+			name:          "concurrent services purchase without allow",
+			cfg:           RunConfig{ServiceConcurrency: 3, ActualPurchase: true, SkipConfirmation: true},
+			errorContains: "requires --allow-concurrent-purchase",
+		},
+		{
+			name:          "concurrent services purchase without yes",
+			cfg:           RunConfig{ServiceConcurrency: 3, ActualPurchase: true, AllowConcurrentPurchase: true},
+			errorContains: "--allow-concurrent-purchase requires --yes",
+		},
+		{
+			name:          "allow concurrent purchase without service concurrency",
+			cfg:           RunConfig{AllowConcurrentPurchase: true},
+			errorContains: "requires --service-concurrency greater than 1",
+		},
+		{
+			name:          "concurrent services with no double commit",
+			cfg:           RunConfig{ServiceConcurrency: 2, NoDoubleCommit: true},
+			errorContains: "--service-concurrency cannot be combined with --no-double-commit",
+		},
+		{
+			name:          "invalid account id",
+			cfg:           RunConfig{AccountID: "12345"},
+			errorContains: "invalid account-id",
+		},
+		{
+			name:          "account id with csv input",
+			cfg:           RunConfig{AccountID: "123456789012", CSVInput: "recs.csv"},
+			errorContains: "--account-id only applies to recommendations fetched from Cost Explorer",
+		},
+		{
+			name: "quiet purchase with confirm func",
+			cfg:  RunConfig{Quiet: true, ActualPurchase: true, ConfirmFunc: func(int, float64) bool { return true }},
+		},
+		{
+			name:          "dry-run-purchase-api with purchase",
+			cfg:           RunConfig{DryRunPurchaseAPI: true, ActualPurchase: true},
+			errorContains: "--dry-run-purchase-api validates purchases without making them",
+		},
+		{
+			name:          "dry-run-purchase-api with cache-only",
+			cfg:           RunConfig{DryRunPurchaseAPI: true, CacheOnly: true, CacheDir: "/tmp/cudly-cache"},
+			errorContains: "--dry-run-purchase-api calls the purchase API",
+		},
+		{
+			name: "dry-run-purchase-api in dry-run mode",
+			cfg:  RunConfig{DryRunPurchaseAPI: true},
+		},
+		{
+			name:          "receipts dir is a file",
+			cfg:           RunConfig{ReceiptsDir: "config_test.go"},
+			errorContains: "receipts-dir is not a directory",
+		},
+		{
+			name:          "quiet purchase without yes",
+			cfg:           RunConfig{Quiet: true, ActualPurchase: true},
+			errorContains: "--quiet hides the purchase confirmation prompt",
+		},
+		{
+			name: "quiet purchase with yes",
+			cfg:  RunConfig{Quiet: true, ActualPurchase: true, SkipConfirmation: true},
+		},
+		{
+			name:          "recommendations only with purchase",
+			cfg:           RunConfig{RecommendationsOnly: true, ActualPurchase: true},
+			errorContains: "--recommendations-only exports recommendations without purchasing",
+		},
+		{
+			name:          "recommendations only with aws-cli output",
+			cfg:           RunConfig{RecommendationsOnly: true, OutputFormat: OutputFormatAWSCLI},
+			errorContains: "cannot be combined with --output-format aws-cli",
+		},
+		{
+			name: "recommendations only",
+			cfg:  RunConfig{RecommendationsOnly: true, OutputFormat: OutputFormatJSON},
+		},
+		{
+			name:          "csv and json input",
+			cfg:           RunConfig{CSVInput: "recs.csv", JSONInput: "recs.json"},
+			errorContains: "--input-csv and --input-json cannot be combined",
+		},
+		{
+			name:          "missing json input",
+			cfg:           RunConfig{JSONInput: "/nonexistent/recs.json"},
+			errorContains: "input JSON file does not exist",
+		},
+		{
+			name: "slack webhook url",
+			cfg:  RunConfig{SlackWebhookURL: "https://hooks.slack.com/services/T000/B000/XXXX"},
+		},
+		{
+			name:          "invalid slack webhook url",
+			cfg:           RunConfig{SlackWebhookURL: "hooks.slack.com/services/T000"},
+			errorContains: "invalid slack-webhook-url",
+		},
+		{
+			name:          "negative api retries",
+			cfg:           RunConfig{APIRetries: -1},
+			errorContains: "api-retries must be 0",
+		},
+		{
+			name:          "api retries without delay",
+			cfg:           RunConfig{APIRetries: 3},
+			errorContains: "api-retry-delay must be positive",
+		},
+		{
+			name: "api retries with delay",
+			cfg:  RunConfig{APIRetries: 3, APIRetryDelay: 2 * time.Second},
+		},
+		{
+			name:          "invalid output format",
+			cfg:           RunConfig{OutputFormat: "yaml"},
+			errorContains: "invalid output format",
+		},
+		{
+			name: "valid filter expression",
+			cfg:  RunConfig{FilterExpression: "service=rds && savings_percent>20"},
+		},
+		{
+			name:          "invalid filter expression",
+			cfg:           RunConfig{FilterExpression: "savings_percent>lots"},
+			errorContains: "invalid --filter expression",
+		},
+		{
+			name: "upfront budget with state file",
+			cfg:  RunConfig{MaxUpfrontBudget: 5000, StateFile: "/tmp/cudly-state.json"},
+		},
+		{
+			name:          "negative upfront budget",
+			cfg:           RunConfig{MaxUpfrontBudget: -1},
+			errorContains: "max-upfront-budget must be 0",
+		},
+		{
+			name:          "upfront budget with cache-only",
+			cfg:           RunConfig{MaxUpfrontBudget: 5000, CacheOnly: true, CacheDir: "/tmp/cudly-cache"},
+			errorContains: "cannot be combined with --cache-only",
+		},
+		{
+			name:          "negative timeout",
+			cfg:           RunConfig{Timeout: -time.Minute},
+			errorContains: "timeout must be 0",
+		},
+		{
+			name: "skip version checks",
+			cfg:  RunConfig{SkipVersionChecks: true, IncludeExtendedSupport: true},
+		},
+		{
+			name:          "skip version checks with decommission tag",
+			cfg:           RunConfig{SkipVersionChecks: true, DecommissionTag: "decommission=true"},
+			errorContains: "--skip-version-checks cannot be combined with --decommission-tag",
+		},
+		{
+			name:          "skip version checks with min instance age",
+			cfg:           RunConfig{SkipVersionChecks: true, MinInstanceAge: time.Hour},
+			errorContains: "--skip-version-checks cannot be combined with --min-instance-age",
+		},
+		{
+			name: "terms in dry run",
+			cfg:  RunConfig{Terms: []int{1, 3}},
+		},
+		{
+			name:          "invalid terms",
+			cfg:           RunConfig{Terms: []int{1, 2}},
+			errorContains: "invalid terms: 2 years",
+		},
+		{
+			name:          "terms with purchase",
+			cfg:           RunConfig{Terms: []int{1, 3}, ActualPurchase: true},
+			errorContains: "cannot be combined with --purchase",
+		},
+		{
+			name:          "compare terms with purchase",
+			cfg:           RunConfig{CompareTerms: true, ActualPurchase: true},
+			errorContains: "--compare-terms only applies to dry runs",
+		},
+		{
+			name:          "compare terms with terms",
+			cfg:           RunConfig{CompareTerms: true, Terms: []int{1}},
+			errorContains: "--compare-terms fetches both terms and cannot be combined with --terms",
+		},
+		{
+			name: "single term with purchase",
+			cfg:  RunConfig{Terms: []int{3, 3}, ActualPurchase: true},
+		},
+		{
+			name: "state file without upfront budget",
+			cfg:  RunConfig{StateFile: "/tmp/cudly-state.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg = tt.cfg
+			testCfg.Coverage = 80.0
+			testCfg.TermYears = 1
+			testCfg.PaymentOption = "no-upfront"
+
+			err := testCfg.Validate()
+			if tt.errorContains == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errorContains)
+			}
+		})
+	}
+}
+
+func TestValidateNormalizesTerms(t *testing.T) {
+	cfg := RunConfig{Coverage: 80, TermYears: 1, PaymentOption: "no-upfront", Terms: []int{3, 1, 3}}
+
+	require.NoError(t, cfg.Validate())
+
+	assert.Equal(t, []int{3, 1}, cfg.Terms)
+	assert.Equal(t, 3, cfg.TermYears)
+}
+
+func TestValidateCompareTerms(t *testing.T) {
+	cfg := RunConfig{Coverage: 80, TermYears: 3, PaymentOption: "all-upfront", CompareTerms: true}
+
+	require.NoError(t, cfg.Validate())
+
+	assert.Equal(t, []int{1, 3}, cfg.Terms)
+	assert.Equal(t, 1, cfg.TermYears)
+}
+
+func TestNormalizePaymentOption(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"all-upfront", "all-upfront"},
+		{"partial-upfront", "partial-upfront"},
+		{"no-upfront", "no-upfront"},
+		{"all", "all-upfront"},
+		{"full", "all-upfront"},
+		{"All Upfront", "all-upfront"},
+		{"ALL_UPFRONT", "all-upfront"},
+		{"partial", "partial-upfront"},
+		{"Partial Upfront", "partial-upfront"},
+		{"none", "no-upfront"},
+		{"no", "no-upfront"},
+		{"No Upfront", "no-upfront"},
+		{" no_upfront ", "no-upfront"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := normalizePaymentOption(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	for _, invalid := range []string{"", "monthly", "upfront", "half"} {
+		t.Run("invalid "+invalid, func(t *testing.T) {
+			_, err := normalizePaymentOption(invalid)
+			assert.ErrorContains(t, err, "invalid payment option")
+			assert.ErrorContains(t, err, "accepted aliases")
+		})
+	}
+}
+
+func TestValidateEC2OfferingClass(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "standard"},
+		{"standard", "standard"},
+		{"convertible", "convertible"},
+		{" Convertible ", "convertible"},
+	}
+
+	for _, tt := range tests {
+		t.Run("class "+tt.input, func(t *testing.T) {
+			cfg := RunConfig{Coverage: 80.0, TermYears: 1, PaymentOption: "no-upfront", EC2OfferingClass: tt.input}
+			require.NoError(t, cfg.Validate())
+			assert.Equal(t, tt.expected, cfg.EC2OfferingClass)
+		})
+	}
+
+	t.Run("invalid class", func(t *testing.T) {
+		cfg := RunConfig{Coverage: 80.0, TermYears: 1, PaymentOption: "no-upfront", EC2OfferingClass: "scheduled"}
+		assert.ErrorContains(t, cfg.Validate(), "invalid ec2-offering-class")
+	})
+}
+
+func TestParseSPCommitments(t *testing.T) {
+	t.Run("valid commitments", func(t *testing.T) {
+		got, err := parseSPCommitments([]string{"Compute=5.0", "database=2", " ec2instance = 1.25 ", "SageMaker=0.5"})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]float64{
+			"Compute":     5.0,
+			"Database":    2.0,
+			"EC2Instance": 1.25,
+			"SageMaker":   0.5,
+		}, got)
+	})
+
+	tests := []struct {
+		name          string
+		specs         []string
+		errorContains string
+	}{
+		{name: "missing value", specs: []string{"Compute"}, errorContains: "expected PlanType=commitment"},
+		{name: "unknown plan type", specs: []string{"Lambda=1"}, errorContains: "unknown Savings Plan type"},
+		{name: "non-numeric commitment", specs: []string{"Compute=abc"}, errorContains: "invalid hourly commitment"},
+		{name: "zero commitment", specs: []string{"Compute=0"}, errorContains: "must be positive"},
+		{name: "negative commitment", specs: []string{"Database=-2"}, errorContains: "must be positive"},
+		{name: "duplicate plan type", specs: []string{"Compute=1", "compute=2"}, errorContains: "duplicate commitment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSPCommitments(tt.specs)
+			assert.ErrorContains(t, err, tt.errorContains)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package cudly
+
+import (
+	"context"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+)
+
+// coverageDiffRow is the before/after view of one instance type in one region
+type coverageDiffRow struct {
+	Region       string
+	ResourceType string
+	Reserved     int
+	Recommended  int
+	NetNew       int
+}
+
+// coverageDiffKey groups recommendations and commitments by instance type and region
+func coverageDiffKey(resourceType, region string) string {
+	return resourceType + "|" + region
+}
+
+// reservedCounts sums the active reservations per instance type and region
+func reservedCounts(existing []common.Commitment) map[string]int {
+	reserved := make(map[string]int)
+	for _, c := range existing {
+		if c.State == "active" || c.State == "payment-pending" {
+			reserved[coverageDiffKey(c.ResourceType, c.Region)] += c.Count
+		}
+	}
+	return reserved
+}
+
+// buildCoverageDiff compares the recommended counts and the net new purchases after dedup with the active reservations
+// Rows follow the order of recs
+func buildCoverageDiff(recs, netNew []common.Recommendation, existing []common.Commitment) []coverageDiffRow {
+	reserved := reservedCounts(existing)
+	purchases := make(map[string]int)
+	for _, rec := range netNew {
+		purchases[coverageDiffKey(rec.ResourceType, rec.Region)] += rec.Count
+	}
+
+	rows := make([]coverageDiffRow, 0, len(recs))
+	index := make(map[string]int)
+	for _, rec := range recs {
+		key := coverageDiffKey(rec.ResourceType, rec.Region)
+		i, ok := index[key]
+		if !ok {
+			i = len(rows)
+			index[key] = i
+			rows = append(rows, coverageDiffRow{
+				Region:       rec.Region,
+				ResourceType: rec.ResourceType,
+				Reserved:     reserved[key],
+				NetNew:       purchases[key],
+			})
+		}
+		rows[i].Recommended += rec.Count
+	}
+	return rows
+}
+
+// printCoverageDiff prints the reserved, recommended and net new counts per instance type (--dry-run-diff)
+func printCoverageDiff(recs, netNew []common.Recommendation, existing []common.Commitment) {
+	rows := buildCoverageDiff(recs, netNew, existing)
+	if len(rows) == 0 {
+		return
+	}
+	outPrintln("\n  📊 Coverage diff:")
+	outPrintf("  %-16s | %-22s | %8s | %11s | %7s\n", "Region", "Instance Type", "Reserved", "Recommended", "Net New")
+	outPrintln("  --------------------------------------------------------------------------")
+	for _, row := range rows {
+		outPrintf("  %-16s | %-22s | %8d | %11d | %+7d\n", row.Region, row.ResourceType, row.Reserved, row.Recommended, row.NetNew)
+	}
+}
+
+// dropSatisfiedRecommendations removes the net new recommendations of instance types whose active reservations
+// already cover at least threshold percent of the originally recommended count (--coverage-satisfied-threshold)
+func dropSatisfiedRecommendations(recs, netNew []common.Recommendation, existing []common.Commitment, threshold float64) []common.Recommendation {
+	reserved := reservedCounts(existing)
+	recommended := make(map[string]int)
+	for _, rec := range recs {
+		recommended[coverageDiffKey(rec.ResourceType, rec.Region)] += rec.Count
+	}
+
+	result := make([]common.Recommendation, 0, len(netNew))
+	for _, rec := range netNew {
+		key := coverageDiffKey(rec.ResourceType, rec.Region)
+		if total := recommended[key]; total > 0 && float64(reserved[key])*100 >= threshold*float64(total) {
+			AppLogger.Printf("  ⏭️  Skipping %s in %s: %d reserved already cover %.0f%% of the %d recommended\n",
+				rec.ResourceType, rec.Region, reserved[key], float64(reserved[key])*100/float64(total), total)
+			continue
+		}
+		result = append(result, rec)
+	}
+	return result
+}
+
+// applyCoverageSatisfiedThreshold drops the recommendations that existing reservations already cover well enough
+// Reservations expiring within ignoreExpiringWithin don't count as coverage. The recommendations are kept
+// unchanged if the existing reservations cannot be fetched.
+func applyCoverageSatisfiedThreshold(ctx context.Context, recs, netNew []common.Recommendation, client provider.ServiceClient, threshold float64, ignoreExpiringWithin time.Duration) []common.Recommendation {
+	if threshold <= 0 {
+		return netNew
+	}
+	existing, err := client.GetExistingCommitments(ctx)
+	if err != nil {
+		AppLogger.Printf("  ⚠️  Warning: Could not check coverage threshold: %v\n", err)
+		return netNew
+	}
+	existing = excludeExpiringCommitments(existing, ignoreExpiringWithin, time.Now())
+	return dropSatisfiedRecommendations(recs, netNew, existing, threshold)
+}
@@ -0,0 +1,131 @@
+package cudly
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCoverageDiff(t *testing.T) {
+	recs := []common.Recommendation{
+		{Region: "us-east-1", ResourceType: "db.r5.large", Count: 4},
+		{Region: "us-east-1", ResourceType: "db.t3.micro", Count: 2},
+		{Region: "us-east-1", ResourceType: "db.r5.large", Count: 2},
+	}
+	netNew := []common.Recommendation{
+		{Region: "us-east-1", ResourceType: "db.r5.large", Count: 3},
+		{Region: "us-east-1", ResourceType: "db.r5.large", Count: 2},
+	}
+	existing := []common.Commitment{
+		{Region: "us-east-1", ResourceType: "db.r5.large", Count: 5, State: "active"},
+		{Region: "us-east-1", ResourceType: "db.r5.large", Count: 1, State: "retired"},
+		{Region: "us-east-1", ResourceType: "db.t3.micro", Count: 2, State: "payment-pending"},
+		{Region: "eu-west-1", ResourceType: "db.r5.large", Count: 7, State: "active"},
+	}
+
+	rows := buildCoverageDiff(recs, netNew, existing)
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, coverageDiffRow{Region: "us-east-1", ResourceType: "db.r5.large", Reserved: 5, Recommended: 6, NetNew: 5}, rows[0])
+	assert.Equal(t, coverageDiffRow{Region: "us-east-1", ResourceType: "db.t3.micro", Reserved: 2, Recommended: 2, NetNew: 0}, rows[1])
+}
+
+func TestPrintCoverageDiff(t *testing.T) {
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	printCoverageDiff(
+		[]common.Recommendation{{Region: "us-east-1", ResourceType: "m5.large", Count: 3}},
+		[]common.Recommendation{{Region: "us-east-1", ResourceType: "m5.large", Count: 1}},
+		[]common.Commitment{{Region: "us-east-1", ResourceType: "m5.large", Count: 2, State: "active"}},
+	)
+
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	output := buf.String()
+
+	assert.Contains(t, output, "Coverage diff")
+	assert.Regexp(t, `m5\.large\s+\|\s+2 \|\s+3 \|\s+\+1`, output)
+}
+
+func TestDropSatisfiedRecommendations(t *testing.T) {
+	recs := []common.Recommendation{
+		{Region: "us-east-1", ResourceType: "db.r5.large", Count: 10},
+		{Region: "us-east-1", ResourceType: "db.t3.micro", Count: 4},
+		{Region: "eu-west-1", ResourceType: "db.r5.large", Count: 2},
+	}
+	netNew := []common.Recommendation{
+		{Region: "us-east-1", ResourceType: "db.r5.large", Count: 2},
+		{Region: "us-east-1", ResourceType: "db.t3.micro", Count: 3},
+		{Region: "eu-west-1", ResourceType: "db.r5.large", Count: 2},
+	}
+	existing := []common.Commitment{
+		{Region: "us-east-1", ResourceType: "db.r5.large", Count: 8, State: "active"},
+		{Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1, State: "active"},
+		{Region: "us-east-1", ResourceType: "db.t3.micro", Count: 3, State: "retired"},
+	}
+
+	tests := []struct {
+		name      string
+		threshold float64
+		wantTypes []string
+	}{
+		{name: "80% keeps partially covered types", threshold: 80, wantTypes: []string{"db.t3.micro", "db.r5.large"}},
+		{name: "25% skips lightly covered types too", threshold: 25, wantTypes: []string{"db.r5.large"}},
+		{name: "90% keeps everything", threshold: 90, wantTypes: []string{"db.r5.large", "db.t3.micro", "db.r5.large"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := dropSatisfiedRecommendations(recs, netNew, existing, tt.threshold)
+			types := make([]string, 0, len(result))
+			for _, rec := range result {
+				types = append(types, rec.ResourceType)
+			}
+			assert.Equal(t, tt.wantTypes, types)
+		})
+	}
+}
+
+func TestApplyCoverageSatisfiedThreshold(t *testing.T) {
+	ctx := context.Background()
+	recs := []common.Recommendation{{Region: "us-east-1", ResourceType: "m5.large", Count: 4}}
+
+	t.Run("disabled", func(t *testing.T) {
+		client := &MockServiceClient{}
+		assert.Equal(t, recs, applyCoverageSatisfiedThreshold(ctx, recs, recs, client, 0, 0))
+		client.AssertNotCalled(t, "GetExistingCommitments", ctx)
+	})
+
+	t.Run("covered", func(t *testing.T) {
+		client := &MockServiceClient{}
+		client.On("GetExistingCommitments", ctx).Return([]common.Commitment{{Region: "us-east-1", ResourceType: "m5.large", Count: 4, State: "active"}}, nil)
+		assert.Empty(t, applyCoverageSatisfiedThreshold(ctx, recs, recs, client, 100, 0))
+	})
+
+	t.Run("expiring reservations ignored", func(t *testing.T) {
+		client := &MockServiceClient{}
+		client.On("GetExistingCommitments", ctx).Return([]common.Commitment{
+			{Region: "us-east-1", ResourceType: "m5.large", Count: 4, State: "active", EndDate: time.Now().Add(48 * time.Hour)},
+		}, nil)
+		assert.Equal(t, recs, applyCoverageSatisfiedThreshold(ctx, recs, recs, client, 100, 7*24*time.Hour))
+	})
+
+	t.Run("commitments unavailable", func(t *testing.T) {
+		client := &MockServiceClient{}
+		client.On("GetExistingCommitments", ctx).Return(nil, errors.New("access denied"))
+		assert.Equal(t, recs, applyCoverageSatisfiedThreshold(ctx, recs, recs, client, 50, 0))
+	})
+}
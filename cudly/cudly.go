@@ -0,0 +1,159 @@
+// Package cudly fetches commitment purchase recommendations and processes them, purchasing them unless it is a dry run.
+// It holds the whole purchase flow so CUDly can be embedded in other Go programs; the cudly command is a thin wrapper around it.
+package cudly
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+	_ "github.com/LeanerCloud/CUDly/providers/aws"
+	"github.com/LeanerCloud/CUDly/providers/aws/services/dynamodb"
+	"github.com/LeanerCloud/CUDly/providers/aws/services/ec2"
+	"github.com/LeanerCloud/CUDly/providers/aws/services/elasticache"
+	"github.com/LeanerCloud/CUDly/providers/aws/services/memorydb"
+	"github.com/LeanerCloud/CUDly/providers/aws/services/opensearch"
+	"github.com/LeanerCloud/CUDly/providers/aws/services/rds"
+	"github.com/LeanerCloud/CUDly/providers/aws/services/redshift"
+	"github.com/LeanerCloud/CUDly/providers/aws/services/savingsplans"
+	_ "github.com/LeanerCloud/CUDly/providers/azure"
+	_ "github.com/LeanerCloud/CUDly/providers/gcp"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/google/uuid"
+)
+
+// Run validates the configuration and processes the recommendations of the configured providers and services
+// Progress is logged to the output set with ConfigureOutput, while the results are returned in the report, which can be
+// written to files and printed with RenderReport. A nil report with a nil error means no recommendations were left to process.
+// When ctx is cancelled, the remaining services, regions and purchases are skipped and the partial report records a
+// "run interrupted" error; a purchase already in flight is completed.
+func Run(ctx context.Context, cfg RunConfig) (*RunReport, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	// Load the --state-file journal, which resumes an interrupted run and holds the deferred queue of the previous run
+	if err := openStateJournal(&cfg); err != nil {
+		return nil, err
+	}
+	cfg.confirmed = &confirmedPurchases{}
+	report, err := runToolMultiService(ctx, cfg)
+	if err != nil {
+		return report, err
+	}
+	// An interrupted run keeps its journal open, so that the next run with the same --state-file resumes it
+	if ctx.Err() != nil {
+		if report != nil {
+			report.Errors = append(report.Errors, fmt.Errorf("run interrupted: %w", ctx.Err()))
+		}
+		return report, nil
+	}
+	finishStateJournal(cfg)
+	return report, nil
+}
+
+// createServiceClient creates the appropriate service client for a service
+func createServiceClient(service common.ServiceType, cfg aws.Config) provider.ServiceClient {
+	switch service {
+	case common.ServiceRDS:
+		return rds.NewClient(cfg)
+	case common.ServiceElastiCache:
+		return elasticache.NewClient(cfg)
+	case common.ServiceEC2:
+		return ec2.NewClient(cfg)
+	case common.ServiceOpenSearch:
+		return opensearch.NewClient(cfg)
+	case common.ServiceRedshift:
+		return redshift.NewClient(cfg)
+	case common.ServiceMemoryDB:
+		return memorydb.NewClient(cfg)
+	case common.ServiceDynamoDB:
+		return dynamodb.NewClient(cfg)
+	case common.ServiceSavingsPlans:
+		return savingsplans.NewClient(cfg)
+	default:
+		return nil
+	}
+}
+
+// generatePurchaseID creates a descriptive purchase ID with UUID for uniqueness
+func generatePurchaseID(rec common.Recommendation, region string, _ int, isDryRun bool, coverage float64) string {
+	// Generate a short UUID suffix (first 8 characters) for uniqueness
+	uuidSuffix := uuid.New().String()[:8]
+	timestamp := time.Now().Format("20060102-150405")
+	prefix := "ri"
+	if isDryRun {
+		prefix = "dryrun"
+	}
+
+	service := strings.ToLower(string(rec.Service))
+	instanceType := strings.ReplaceAll(rec.ResourceType, ".", "-")
+
+	// Extract engine information from service details
+	engine := ""
+	switch details := rec.Details.(type) {
+	case common.DatabaseDetails:
+		engine = strings.ToLower(details.Engine)
+		engine = strings.ReplaceAll(engine, " ", "-")
+		engine = strings.ReplaceAll(engine, "_", "-")
+	case common.CacheDetails:
+		engine = strings.ToLower(details.Engine)
+	case common.ComputeDetails:
+		engine = strings.ToLower(details.Platform)
+		engine = strings.ReplaceAll(engine, " ", "-")
+		engine = strings.ReplaceAll(engine, "/", "-")
+	}
+
+	// Add account name if available
+	accountName := sanitizeAccountName(rec.AccountName)
+	coveragePct := fmt.Sprintf("%.0fpct", coverage)
+	if accountName != "" {
+		if engine != "" {
+			return fmt.Sprintf("%s-%s-%s-%s-%s-%s-%dx-%s-%s-%s",
+				prefix, accountName, service, engine, region, instanceType, rec.Count, coveragePct, timestamp, uuidSuffix)
+		}
+		return fmt.Sprintf("%s-%s-%s-%s-%s-%dx-%s-%s-%s",
+			prefix, accountName, service, region, instanceType, rec.Count, coveragePct, timestamp, uuidSuffix)
+	}
+
+	// Fallback without account name
+	if engine != "" {
+		return fmt.Sprintf("%s-%s-%s-%s-%s-%dx-%s-%s-%s",
+			prefix, service, engine, region, instanceType, rec.Count, coveragePct, timestamp, uuidSuffix)
+	}
+	return fmt.Sprintf("%s-%s-%s-%s-%dx-%s-%s-%s",
+		prefix, service, region, instanceType, rec.Count, coveragePct, timestamp, uuidSuffix)
+}
+
+// sanitizeAccountName converts account name to a filesystem/ID-safe format
+func sanitizeAccountName(accountName string) string {
+	if accountName == "" {
+		return ""
+	}
+
+	// Convert to lowercase
+	clean := strings.ToLower(accountName)
+
+	// Replace spaces and special chars with hyphens
+	clean = strings.ReplaceAll(clean, " ", "-")
+	clean = strings.ReplaceAll(clean, "_", "-")
+	clean = strings.ReplaceAll(clean, ".", "-")
+
+	// Remove any characters that aren't alphanumeric or hyphens
+	result := ""
+	for _, r := range clean {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			result += string(r)
+		}
+	}
+
+	// Remove leading/trailing hyphens and collapse multiple hyphens
+	result = strings.Trim(result, "-")
+	for strings.Contains(result, "--") {
+		result = strings.ReplaceAll(result, "--", "-")
+	}
+
+	return result
+}
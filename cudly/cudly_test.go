@@ -0,0 +1,474 @@
+package cudly
+
+import (
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePurchaseID(t *testing.T) {
+	tests := []struct {
+		name           string
+		rec            common.Recommendation
+		region         string
+		index          int
+		isDryRun       bool
+		coverage       float64
+		expectedPrefix string
+	}{
+		{
+			name: "RDS Recommendation - dry run",
+			rec: common.Recommendation{
+				Service:      common.ServiceRDS,
+				ResourceType: "db.t3.micro",
+				Count:        2,
+				Details: common.DatabaseDetails{
+					Engine:   "mysql",
+					AZConfig: "single-az",
+				},
+			},
+			region:         "us-east-1",
+			index:          1,
+			isDryRun:       true,
+			coverage:       80.0,
+			expectedPrefix: "dryrun-rds-mysql-us-east-1-db-t3-micro-2x",
+		},
+		{
+			name: "EC2 Recommendation - actual purchase",
+			rec: common.Recommendation{
+				Service:      common.ServiceEC2,
+				ResourceType: "t3.large",
+				Count:        5,
+			},
+			region:         "eu-west-1",
+			index:          3,
+			isDryRun:       false,
+			coverage:       80.0,
+			expectedPrefix: "ri-ec2-eu-west-1-t3-large-5x",
+		},
+		{
+			name: "ElastiCache Recommendation - dry run",
+			rec: common.Recommendation{
+				Service:      common.ServiceElastiCache,
+				ResourceType: "cache.r5.large",
+				Count:        1,
+				Details: common.CacheDetails{
+					Engine: "redis",
+				},
+			},
+			region:         "us-west-2",
+			index:          2,
+			isDryRun:       true,
+			coverage:       80.0,
+			expectedPrefix: "dryrun-elasticache-redis-us-west-2-cache-r5-large-1x",
+		},
+		{
+			name: "RDS Recommendation - multi-AZ",
+			rec: common.Recommendation{
+				Service:      common.ServiceRDS,
+				ResourceType: "db.m5.xlarge",
+				Count:        3,
+				Details: common.DatabaseDetails{
+					Engine:   "postgres",
+					AZConfig: "multi-az",
+				},
+			},
+			region:         "ap-southeast-1",
+			index:          5,
+			isDryRun:       false,
+			coverage:       80.0,
+			expectedPrefix: "ri-rds-postgres-ap-southeast-1-db-m5-xlarge-3x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := generatePurchaseID(tt.rec, tt.region, tt.index, tt.isDryRun, tt.coverage)
+			assert.Contains(t, result, tt.expectedPrefix)
+			// Should contain timestamp (YYYYMMDD-HHMMSS) and UUID suffix (8 chars)
+			assert.Regexp(t, `-\d{8}-\d{6}-[a-f0-9]{8}$`, result)
+		})
+	}
+}
+
+func TestCreateServiceClient(t *testing.T) {
+	cfg := aws.Config{
+		Region: "us-east-1",
+	}
+
+	tests := []struct {
+		name      string
+		service   common.ServiceType
+		expectNil bool
+	}{
+		{
+			name:      "RDS service",
+			service:   common.ServiceRDS,
+			expectNil: false,
+		},
+		{
+			name:      "ElastiCache service",
+			service:   common.ServiceElastiCache,
+			expectNil: false,
+		},
+		{
+			name:      "EC2 service",
+			service:   common.ServiceEC2,
+			expectNil: false,
+		},
+		{
+			name:      "OpenSearch service",
+			service:   common.ServiceOpenSearch,
+			expectNil: false,
+		},
+		{
+			name:      "Redshift service",
+			service:   common.ServiceRedshift,
+			expectNil: false,
+		},
+		{
+			name:      "MemoryDB service",
+			service:   common.ServiceMemoryDB,
+			expectNil: false,
+		},
+		{
+			name:      "DynamoDB service",
+			service:   common.ServiceDynamoDB,
+			expectNil: false,
+		},
+		{
+			name:      "Savings Plans service",
+			service:   common.ServiceSavingsPlans,
+			expectNil: false,
+		},
+		{
+			name:      "Unknown service",
+			service:   common.ServiceType("unknown"),
+			expectNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := createServiceClient(tt.service, cfg)
+			if tt.expectNil {
+				assert.Nil(t, client)
+			} else {
+				assert.NotNil(t, client)
+			}
+		})
+	}
+}
+
+func TestGeneratePurchaseIDEdgeCases(t *testing.T) {
+	testCoverage := 80.0
+
+	// Test with recommendations that have special characters
+	rec := common.Recommendation{
+		Service:      common.ServiceRDS,
+		ResourceType: "db.r5b.2xlarge",
+		Count:        10,
+		Details: common.DatabaseDetails{
+			Engine:   "MySQL 8.0",
+			AZConfig: "single-az",
+		},
+	}
+
+	id := generatePurchaseID(rec, "us-east-1", 999, false, testCoverage)
+	assert.Contains(t, id, "rds")
+	assert.Contains(t, id, "r5b-2xlarge")
+	assert.Contains(t, id, "10x")
+	// Index is no longer included due to UUID replacement
+
+	// Test with empty region
+	id = generatePurchaseID(rec, "", 1, true, testCoverage)
+	assert.Contains(t, id, "dryrun")
+
+	// Test with very long instance type
+	rec.ResourceType = "db.x2gd.metal.16xlarge"
+	id = generatePurchaseID(rec, "ap-south-1", 1, false, testCoverage)
+	assert.Contains(t, id, "x2gd-metal")
+}
+
+func TestGeneratePurchaseIDComprehensive(t *testing.T) {
+	// Use a test coverage value
+	testCoverage := 75.0
+
+	tests := []struct {
+		name                string
+		rec                 common.Recommendation
+		region              string
+		isDryRun            bool
+		expectedContains    []string
+		expectedNotContains []string
+	}{
+		{
+			name: "RDS with account name and engine",
+			rec: common.Recommendation{
+				Service:      common.ServiceRDS,
+				ResourceType: "db.r5.large",
+				Count:        3,
+				AccountName:  "Production Account",
+				Details: common.DatabaseDetails{
+					Engine: "PostgreSQL",
+				},
+			},
+			region:   "eu-west-1",
+			isDryRun: false,
+			expectedContains: []string{
+				"ri-", "production-account", "rds", "postgresql", "eu-west-1",
+				"db-r5-large", "3x", "75pct",
+			},
+		},
+		{
+			name: "ElastiCache with Redis engine",
+			rec: common.Recommendation{
+				Service:      common.ServiceElastiCache,
+				ResourceType: "cache.r5.xlarge",
+				Count:        5,
+				Details: common.CacheDetails{
+					Engine: "Redis",
+				},
+			},
+			region:   "us-west-2",
+			isDryRun: false,
+			expectedContains: []string{
+				"ri-", "elasticache", "redis", "us-west-2",
+				"cache-r5-xlarge", "5x", "75pct",
+			},
+		},
+		{
+			name: "EC2 with platform",
+			rec: common.Recommendation{
+				Service:      common.ServiceEC2,
+				ResourceType: "m5.2xlarge",
+				Count:        10,
+				Details: common.ComputeDetails{
+					Platform: "Linux/UNIX",
+				},
+			},
+			region:   "ap-southeast-1",
+			isDryRun: true,
+			expectedContains: []string{
+				"dryrun-", "ec2", "linux-unix", "ap-southeast-1",
+				"m5-2xlarge", "10x", "75pct",
+			},
+		},
+		{
+			name: "MemoryDB recommendation",
+			rec: common.Recommendation{
+				Service:      common.ServiceMemoryDB,
+				ResourceType: "db.r6g.large",
+				Count:        2,
+				Details:      common.CacheDetails{Engine: "redis"},
+			},
+			region:   "us-east-1",
+			isDryRun: false,
+			expectedContains: []string{
+				"ri-", "memorydb", "memorydb", "us-east-1",
+				"db-r6g-large", "2x", "75pct",
+			},
+		},
+		{
+			name: "OpenSearch without engine",
+			rec: common.Recommendation{
+				Service:      common.ServiceOpenSearch,
+				ResourceType: "r5.large.search",
+				Count:        4,
+			},
+			region:   "eu-central-1",
+			isDryRun: false,
+			expectedContains: []string{
+				"ri-", "opensearch", "eu-central-1",
+				"r5-large-search", "4x", "75pct",
+			},
+		},
+		{
+			name: "Elasticsearch alias (same as OpenSearch)",
+			rec: common.Recommendation{
+				Service:      common.ServiceElasticsearch, // Should work as alias for OpenSearch
+				ResourceType: "m5.xlarge.elasticsearch",
+				Count:        3,
+			},
+			region:   "us-west-1",
+			isDryRun: false,
+			expectedContains: []string{
+				"ri-", "opensearch", "us-west-1",
+				"m5-xlarge-elasticsearch", "3x", "75pct",
+			},
+		},
+		{
+			name: "Redshift without engine",
+			rec: common.Recommendation{
+				Service:      common.ServiceRedshift,
+				ResourceType: "dc2.large",
+				Count:        8,
+			},
+			region:   "us-east-2",
+			isDryRun: false,
+			expectedContains: []string{
+				"ri-", "redshift", "us-east-2",
+				"dc2-large", "8x", "75pct",
+			},
+		},
+		{
+			name: "RDS recommendation with account",
+			rec: common.Recommendation{
+				Service:      common.ServiceRDS,
+				ResourceType: "db.r6g.xlarge",
+				Count:        15,
+				AccountName:  "Staging",
+				Details: common.DatabaseDetails{
+					Engine:   "aurora-mysql",
+					AZConfig: "multi-az",
+				},
+			},
+			region:   "ca-central-1",
+			isDryRun: false,
+			expectedContains: []string{
+				"ri-", "staging", "aurora-mysql", "r6g-xlarge",
+				"15x", "75pct", "ca-central-1",
+			},
+		},
+		{
+			name: "ElastiCache single-AZ recommendation",
+			rec: common.Recommendation{
+				Service:      common.ServiceElastiCache,
+				ResourceType: "cache.m5.large",
+				Count:        1,
+				Details: common.CacheDetails{
+					Engine: "redis",
+				},
+			},
+			region:   "ap-northeast-1",
+			isDryRun: true,
+			expectedContains: []string{
+				"dryrun-", "redis", "m5-large",
+				"1x", "75pct", "ap-northeast-1",
+			},
+		},
+		{
+			name: "Recommendation with special characters in engine",
+			rec: common.Recommendation{
+				Service:      common.ServiceRDS,
+				ResourceType: "db.t3.micro",
+				Count:        20,
+				Details: common.DatabaseDetails{
+					Engine: "MySQL_8.0_Community",
+				},
+			},
+			region:   "us-west-1",
+			isDryRun: false,
+			expectedContains: []string{
+				"ri-", "rds", "mysql-8.0-community",
+				"db-t3-micro", "20x", "75pct",
+			},
+		},
+		{
+			name: "Large count recommendation",
+			rec: common.Recommendation{
+				Service:      common.ServiceEC2,
+				ResourceType: "t3.nano",
+				Count:        999,
+			},
+			region:   "eu-west-2",
+			isDryRun: false,
+			expectedContains: []string{
+				"ri-", "ec2", "eu-west-2",
+				"t3-nano", "999x", "75pct",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := generatePurchaseID(tt.rec, tt.region, 1, tt.isDryRun, testCoverage)
+
+			// Check expected contains
+			for _, expected := range tt.expectedContains {
+				assert.Contains(t, result, expected, "Expected ID to contain '%s'", expected)
+			}
+
+			// Check expected not contains
+			for _, notExpected := range tt.expectedNotContains {
+				assert.NotContains(t, result, notExpected, "Expected ID not to contain '%s'", notExpected)
+			}
+
+			// Should always contain timestamp and UUID
+			assert.Regexp(t, `-\d{8}-\d{6}-[a-f0-9]{8}$`, result)
+		})
+	}
+}
+
+func TestGeneratePurchaseIDCoverageVariations(t *testing.T) {
+	rec := common.Recommendation{
+		Service:      common.ServiceRDS,
+		ResourceType: "db.t3.small",
+		Count:        1,
+		Details: common.DatabaseDetails{
+			Engine: "mysql",
+		},
+	}
+
+	tests := []struct {
+		name             string
+		coverage         float64
+		expectedCoverage string
+	}{
+		{"Coverage 0%", 0.0, "0pct"},
+		{"Coverage 50%", 50.0, "50pct"},
+		{"Coverage 75.5%", 75.5, "76pct"}, // Rounds to nearest integer
+		{"Coverage 99%", 99.0, "99pct"},
+		{"Coverage 100%", 100.0, "100pct"},
+		{"Coverage 33.3%", 33.3, "33pct"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := generatePurchaseID(rec, "us-east-1", 1, false, tt.coverage)
+			assert.Contains(t, result, tt.expectedCoverage)
+		})
+	}
+}
+
+func TestCreateServiceClientAllServices(t *testing.T) {
+	cfg := aws.Config{
+		Region: "eu-central-1",
+	}
+
+	// Test that all services return non-nil clients now
+	services := getAllServices()
+	for _, service := range services {
+		client := createServiceClient(service, cfg)
+		assert.NotNil(t, client, "Service %s should have a client", service)
+	}
+}
+
+func TestSanitizeAccountName(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Simple name", "production", "production"},
+		{"Spaces to hyphens", "my account", "my-account"},
+		{"Underscores to hyphens", "my_account", "my-account"},
+		{"Uppercase to lowercase", "PRODUCTION", "production"},
+		{"Special chars removed", "my@account#123", "myaccount123"},
+		{"Dots to hyphens", "my.account.com", "my-account-com"},
+		{"Long name preserved", "very-long-production-environment-name", "very-long-production-environment-name"},
+		{"Empty string", "", ""},
+		{"Only special chars", "@#$%", ""},
+		{"Multiple hyphens collapsed", "my---account", "my-account"},
+		{"Leading/trailing hyphens removed", "-account-", "account"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := sanitizeAccountName(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package cudly
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// DiffEntry is a recommendation of the diff subcommand, with its total count in the old and new file
+type DiffEntry struct {
+	Service      string `json:"service"`
+	Region       string `json:"region"`
+	ResourceType string `json:"resource_type"`
+	Engine       string `json:"engine,omitempty"`
+	OldCount     int    `json:"old_count"`
+	NewCount     int    `json:"new_count"`
+}
+
+// RecommendationDiff lists the recommendations added, removed and changed in count between two reports
+type RecommendationDiff struct {
+	Added   []DiffEntry `json:"added"`
+	Removed []DiffEntry `json:"removed"`
+	Changed []DiffEntry `json:"changed"`
+}
+
+// DiffRecommendationFiles compares the recommendations of two CSV reports
+// Files with a .json extension are read as JSON reports, which keep the engine of each recommendation.
+func DiffRecommendationFiles(oldPath, newPath string) (*RecommendationDiff, error) {
+	oldRecs, err := loadDiffInput(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	newRecs, err := loadDiffInput(newPath)
+	if err != nil {
+		return nil, err
+	}
+	diff := diffRecommendations(oldRecs, newRecs)
+	return &diff, nil
+}
+
+// loadDiffInput reads the recommendations of a CSV or JSON report
+func loadDiffInput(path string) ([]common.Recommendation, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		recs, err := loadRecommendationsFromJSON(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return recs, nil
+	}
+	recs, err := loadRecommendationsFromCSV(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return recs, nil
+}
+
+// diffRecommendations sums the counts of both recommendation sets by service, region, resource type and engine
+// and compares them. Entries are sorted by service, region, resource type and engine.
+func diffRecommendations(oldRecs, newRecs []common.Recommendation) RecommendationDiff {
+	entries := make(map[string]*DiffEntry)
+	var keys []string
+	add := func(rec common.Recommendation, isNew bool) {
+		engine := getEngineFromRecommendation(rec)
+		key := strings.Join([]string{string(rec.Service), rec.Region, rec.ResourceType, engine}, "|")
+		entry, ok := entries[key]
+		if !ok {
+			entry = &DiffEntry{Service: string(rec.Service), Region: rec.Region, ResourceType: rec.ResourceType, Engine: engine}
+			entries[key] = entry
+			keys = append(keys, key)
+		}
+		if isNew {
+			entry.NewCount += rec.Count
+		} else {
+			entry.OldCount += rec.Count
+		}
+	}
+	for _, rec := range oldRecs {
+		add(rec, false)
+	}
+	for _, rec := range newRecs {
+		add(rec, true)
+	}
+	sort.Strings(keys)
+
+	diff := RecommendationDiff{Added: []DiffEntry{}, Removed: []DiffEntry{}, Changed: []DiffEntry{}}
+	for _, key := range keys {
+		entry := *entries[key]
+		switch {
+		case entry.OldCount == 0 && entry.NewCount > 0:
+			diff.Added = append(diff.Added, entry)
+		case entry.NewCount == 0 && entry.OldCount > 0:
+			diff.Removed = append(diff.Removed, entry)
+		case entry.OldCount != entry.NewCount:
+			diff.Changed = append(diff.Changed, entry)
+		}
+	}
+	return diff
+}
+
+// diffEntryName describes a diff entry, such as "rds us-east-1 db.r5.large (mysql)"
+func diffEntryName(e DiffEntry) string {
+	name := fmt.Sprintf("%s %s %s", e.Service, e.Region, e.ResourceType)
+	if e.Engine != "" {
+		name += " (" + e.Engine + ")"
+	}
+	return name
+}
+
+// PrintRecommendationDiff prints the added, removed and changed recommendations of a diff
+func PrintRecommendationDiff(diff *RecommendationDiff) {
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0 {
+		outPrintln("ℹ️  No differences between the recommendations")
+		return
+	}
+
+	outPrintf("\n➕ Added (%d):\n", len(diff.Added))
+	for _, e := range diff.Added {
+		outPrintf("  %s: %d\n", diffEntryName(e), e.NewCount)
+	}
+	outPrintf("\n➖ Removed (%d):\n", len(diff.Removed))
+	for _, e := range diff.Removed {
+		outPrintf("  %s: %d\n", diffEntryName(e), e.OldCount)
+	}
+	outPrintf("\n🔁 Changed (%d):\n", len(diff.Changed))
+	for _, e := range diff.Changed {
+		outPrintf("  %s: %d -> %d (%+d)\n", diffEntryName(e), e.OldCount, e.NewCount, e.NewCount-e.OldCount)
+	}
+}
+
+// WriteRecommendationDiffJSON writes a diff as an indented JSON document
+func WriteRecommendationDiffJSON(w io.Writer, diff *RecommendationDiff) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(diff); err != nil {
+		return fmt.Errorf("failed to write diff: %w", err)
+	}
+	return nil
+}
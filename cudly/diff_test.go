@@ -0,0 +1,66 @@
+package cudly
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func TestDiffRecommendations(t *testing.T) {
+	oldRecs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 2, Details: &common.DatabaseDetails{Engine: "MySQL"}},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 1, Details: &common.DatabaseDetails{Engine: "MySQL"}},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 4, Details: &common.DatabaseDetails{Engine: "PostgreSQL"}},
+		{Service: common.ServiceEC2, Region: "eu-west-1", ResourceType: "m5.large", Count: 5},
+	}
+	newRecs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 5, Details: &common.DatabaseDetails{Engine: "MySQL"}},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 4, Details: &common.DatabaseDetails{Engine: "PostgreSQL"}},
+		{Service: common.ServiceElastiCache, Region: "us-west-2", ResourceType: "cache.r6g.large", Count: 2},
+	}
+
+	diff := diffRecommendations(oldRecs, newRecs)
+
+	assert.Equal(t, []DiffEntry{{Service: "elasticache", Region: "us-west-2", ResourceType: "cache.r6g.large", NewCount: 2}}, diff.Added)
+	assert.Equal(t, []DiffEntry{{Service: "ec2", Region: "eu-west-1", ResourceType: "m5.large", OldCount: 5}}, diff.Removed)
+	assert.Equal(t, []DiffEntry{{Service: "rds", Region: "us-east-1", ResourceType: "db.r5.large", Engine: "mysql", OldCount: 3, NewCount: 5}}, diff.Changed, "counts are summed per engine")
+}
+
+func TestDiffRecommendationFiles(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.csv")
+	newPath := filepath.Join(dir, "new.csv")
+	require.NoError(t, os.WriteFile(oldPath, []byte("Service,Region,ResourceType,Count\nrds,us-east-1,db.r5.large,2\n"), 0o644))
+	require.NoError(t, os.WriteFile(newPath, []byte("Service,Region,ResourceType,Count\nrds,us-east-1,db.r5.large,3\n"), 0o644))
+
+	diff, err := DiffRecommendationFiles(oldPath, newPath)
+	require.NoError(t, err)
+	assert.Empty(t, diff.Added)
+	assert.Empty(t, diff.Removed)
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, 2, diff.Changed[0].OldCount)
+	assert.Equal(t, 3, diff.Changed[0].NewCount)
+
+	_, err = DiffRecommendationFiles(filepath.Join(dir, "missing.csv"), newPath)
+	assert.ErrorContains(t, err, "missing.csv")
+}
+
+func TestWriteRecommendationDiffJSON(t *testing.T) {
+	diff := diffRecommendations(nil, []common.Recommendation{{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 1}})
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRecommendationDiffJSON(&buf, &diff))
+
+	var decoded map[string][]map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Len(t, decoded["added"], 1)
+	assert.Equal(t, "db.r5.large", decoded["added"][0]["resource_type"])
+	assert.Empty(t, decoded["removed"], "empty sections are written as empty lists")
+}
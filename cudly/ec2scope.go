@@ -0,0 +1,80 @@
+package cudly
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// Values of --ec2-scope
+const (
+	EC2ScopeAsRecommended = "as-recommended"
+	EC2ScopeRegional      = "regional"
+	EC2ScopeAZ            = "az"
+)
+
+// validateEC2Scope normalizes --ec2-scope, defaulting to as-recommended
+func validateEC2Scope(scope string) (string, error) {
+	switch s := strings.ToLower(strings.TrimSpace(scope)); s {
+	case "":
+		return EC2ScopeAsRecommended, nil
+	case EC2ScopeAsRecommended, EC2ScopeRegional, EC2ScopeAZ:
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid ec2-scope: %s. Must be one of: %s, %s, %s", scope, EC2ScopeRegional, EC2ScopeAZ, EC2ScopeAsRecommended)
+	}
+}
+
+// applyEC2Scope overrides the scope of EC2 recommendations with --ec2-scope, leaving the input unmodified
+// Forcing regional scope clears the availability zone. Zonal scope needs the zone Cost Explorer recommended,
+// so recommendations without one stay regional.
+func applyEC2Scope(recs []common.Recommendation, scope, indent string) []common.Recommendation {
+	if scope == "" || scope == EC2ScopeAsRecommended {
+		return recs
+	}
+
+	result := make([]common.Recommendation, len(recs))
+	var changed, withoutZone int
+	for i, rec := range recs {
+		result[i] = rec
+		details, ok := rec.Details.(*common.ComputeDetails)
+		if rec.Service != common.ServiceEC2 || !ok || details == nil {
+			continue
+		}
+
+		scoped := *details
+		switch scope {
+		case EC2ScopeRegional:
+			scoped.Scope = common.EC2ScopeRegion
+			scoped.AvailabilityZone = ""
+		case EC2ScopeAZ:
+			if scoped.AvailabilityZone == "" {
+				withoutZone++
+				continue
+			}
+			scoped.Scope = common.EC2ScopeAvailabilityZone
+		}
+		if scoped != *details {
+			changed++
+		}
+		result[i].Details = &scoped
+	}
+
+	if changed > 0 {
+		AppLogger.Printf("%s🎯 EC2 scope: %d recommendations changed to %s scope\n", indent, changed, scope)
+	}
+	if withoutZone > 0 {
+		AppLogger.Printf("%s⚠️  EC2 scope: %d recommendations have no recommended availability zone and stay regional\n", indent, withoutZone)
+	}
+	return result
+}
+
+// ec2Scope describes the scope of an EC2 recommendation, such as "region" or "availability-zone us-east-1a"
+func ec2Scope(rec common.Recommendation) string {
+	details, ok := rec.Details.(*common.ComputeDetails)
+	if !ok || details == nil {
+		return ""
+	}
+	return strings.TrimSpace(details.Scope + " " + details.AvailabilityZone)
+}
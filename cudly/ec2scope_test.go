@@ -0,0 +1,78 @@
+package cudly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func TestValidateEC2Scope(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"", EC2ScopeAsRecommended, false},
+		{"Regional", EC2ScopeRegional, false},
+		{"az", EC2ScopeAZ, false},
+		{"as-recommended", EC2ScopeAsRecommended, false},
+		{"zonal", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			scope, err := validateEC2Scope(tt.input)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "invalid ec2-scope")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, scope)
+		})
+	}
+}
+
+func TestApplyEC2Scope(t *testing.T) {
+	zonal := func() common.Recommendation {
+		return common.Recommendation{Service: common.ServiceEC2, Details: &common.ComputeDetails{Scope: common.EC2ScopeAvailabilityZone, AvailabilityZone: "us-east-1a"}}
+	}
+	regional := func() common.Recommendation {
+		return common.Recommendation{Service: common.ServiceEC2, Details: &common.ComputeDetails{Scope: common.EC2ScopeRegion}}
+	}
+
+	tests := []struct {
+		name     string
+		scope    string
+		rec      common.Recommendation
+		expected common.ComputeDetails
+	}{
+		{"as-recommended keeps zonal", EC2ScopeAsRecommended, zonal(), common.ComputeDetails{Scope: common.EC2ScopeAvailabilityZone, AvailabilityZone: "us-east-1a"}},
+		{"regional clears the zone", EC2ScopeRegional, zonal(), common.ComputeDetails{Scope: common.EC2ScopeRegion}},
+		{"az keeps zonal", EC2ScopeAZ, zonal(), common.ComputeDetails{Scope: common.EC2ScopeAvailabilityZone, AvailabilityZone: "us-east-1a"}},
+		{"az without a zone stays regional", EC2ScopeAZ, regional(), common.ComputeDetails{Scope: common.EC2ScopeRegion}},
+		{"az with a zone but regional scope", EC2ScopeAZ, common.Recommendation{Service: common.ServiceEC2, Details: &common.ComputeDetails{Scope: common.EC2ScopeRegion, AvailabilityZone: "us-east-1b"}}, common.ComputeDetails{Scope: common.EC2ScopeAvailabilityZone, AvailabilityZone: "us-east-1b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := *tt.rec.Details.(*common.ComputeDetails)
+
+			result := applyEC2Scope([]common.Recommendation{tt.rec}, tt.scope, "")
+
+			require.Len(t, result, 1)
+			assert.Equal(t, tt.expected, *result[0].Details.(*common.ComputeDetails))
+			assert.Equal(t, original, *tt.rec.Details.(*common.ComputeDetails), "the input is not modified")
+		})
+	}
+}
+
+func TestApplyEC2ScopeIgnoresOtherServices(t *testing.T) {
+	recs := []common.Recommendation{{Service: common.ServiceRDS, Details: &common.DatabaseDetails{Engine: "mysql"}}}
+
+	result := applyEC2Scope(recs, EC2ScopeRegional, "")
+
+	assert.Equal(t, recs, result)
+}
@@ -0,0 +1,167 @@
+package cudly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+const (
+	// eventBridgeSource is the source attached to every event published by the tool
+	eventBridgeSource = "cudly"
+	// eventBridgeMaxBatchSize is the maximum number of entries accepted by a single PutEvents call
+	eventBridgeMaxBatchSize = 10
+
+	eventDetailTypePurchaseSucceeded = "CUDly Purchase Succeeded"
+	eventDetailTypePurchaseFailed    = "CUDly Purchase Failed"
+	eventDetailTypeRunCompleted      = "CUDly Run Completed"
+)
+
+// EventBridgeAPI defines the interface for EventBridge operations
+type EventBridgeAPI interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// EventBridgePublisher publishes purchase results to an EventBridge event bus
+type EventBridgePublisher struct {
+	client  EventBridgeAPI
+	busName string
+}
+
+// NewEventBridgePublisher creates a new publisher for the given event bus
+func NewEventBridgePublisher(cfg aws.Config, busName string) *EventBridgePublisher {
+	return &EventBridgePublisher{
+		client:  eventbridge.NewFromConfig(cfg),
+		busName: busName,
+	}
+}
+
+// NewEventBridgePublisherWithAPI creates a new publisher with a custom EventBridge API (for testing)
+func NewEventBridgePublisherWithAPI(api EventBridgeAPI, busName string) *EventBridgePublisher {
+	return &EventBridgePublisher{
+		client:  api,
+		busName: busName,
+	}
+}
+
+// purchaseEventDetail mirrors the JSON form of common.PurchaseResult with the error flattened to a string
+type purchaseEventDetail struct {
+	Recommendation common.Recommendation `json:"recommendation"`
+	Success        bool                  `json:"success"`
+	CommitmentID   string                `json:"commitment_id,omitempty"`
+	Error          string                `json:"error,omitempty"`
+	Cost           float64               `json:"cost"`
+	DryRun         bool                  `json:"dry_run"`
+	Timestamp      time.Time             `json:"timestamp"`
+}
+
+// runCompletedEventDetail summarizes a completed run
+type runCompletedEventDetail struct {
+	DryRun                bool      `json:"dry_run"`
+	TotalPurchases        int       `json:"total_purchases"`
+	SuccessfulPurchases   int       `json:"successful_purchases"`
+	FailedPurchases       int       `json:"failed_purchases"`
+	TotalEstimatedSavings float64   `json:"total_estimated_savings"`
+	Timestamp             time.Time `json:"timestamp"`
+}
+
+// newPurchaseEventDetail converts a purchase result into its event detail form
+func newPurchaseEventDetail(result common.PurchaseResult) purchaseEventDetail {
+	detail := purchaseEventDetail{
+		Recommendation: result.Recommendation,
+		Success:        result.Success,
+		CommitmentID:   result.CommitmentID,
+		Cost:           result.Cost,
+		DryRun:         result.DryRun,
+		Timestamp:      result.Timestamp,
+	}
+	if result.Error != nil {
+		detail.Error = result.Error.Error()
+	}
+	return detail
+}
+
+// newRunCompletedEventDetail builds the run-complete summary from all purchase results
+func newRunCompletedEventDetail(results []common.PurchaseResult, isDryRun bool) runCompletedEventDetail {
+	detail := runCompletedEventDetail{
+		DryRun:         isDryRun,
+		TotalPurchases: len(results),
+		Timestamp:      time.Now(),
+	}
+	for _, r := range results {
+		if r.Success {
+			detail.SuccessfulPurchases++
+			detail.TotalEstimatedSavings += r.Recommendation.EstimatedSavings
+		} else {
+			detail.FailedPurchases++
+		}
+	}
+	return detail
+}
+
+// buildEntry creates an EventBridge entry with a JSON-encoded detail
+func (p *EventBridgePublisher) buildEntry(detailType string, detail any) (ebtypes.PutEventsRequestEntry, error) {
+	data, err := json.Marshal(detail)
+	if err != nil {
+		return ebtypes.PutEventsRequestEntry{}, fmt.Errorf("failed to marshal event detail: %w", err)
+	}
+	return ebtypes.PutEventsRequestEntry{
+		EventBusName: aws.String(p.busName),
+		Source:       aws.String(eventBridgeSource),
+		DetailType:   aws.String(detailType),
+		Detail:       aws.String(string(data)),
+	}, nil
+}
+
+// PublishResults publishes one event per purchase result followed by a run-complete event
+// Publishing failures are logged as warnings and never abort the run
+func (p *EventBridgePublisher) PublishResults(ctx context.Context, results []common.PurchaseResult, isDryRun bool) {
+	entries := make([]ebtypes.PutEventsRequestEntry, 0, len(results)+1)
+
+	for _, result := range results {
+		detailType := eventDetailTypePurchaseSucceeded
+		if !result.Success {
+			detailType = eventDetailTypePurchaseFailed
+		}
+		entry, err := p.buildEntry(detailType, newPurchaseEventDetail(result))
+		if err != nil {
+			log.Printf("⚠️  Warning: Failed to build EventBridge event for %s: %v", result.CommitmentID, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	entry, err := p.buildEntry(eventDetailTypeRunCompleted, newRunCompletedEventDetail(results, isDryRun))
+	if err != nil {
+		log.Printf("⚠️  Warning: Failed to build EventBridge run-complete event: %v", err)
+	} else {
+		entries = append(entries, entry)
+	}
+
+	published := 0
+	for start := 0; start < len(entries); start += eventBridgeMaxBatchSize {
+		end := min(start+eventBridgeMaxBatchSize, len(entries))
+		batch := entries[start:end]
+
+		output, err := p.client.PutEvents(ctx, &eventbridge.PutEventsInput{Entries: batch})
+		if err != nil {
+			log.Printf("⚠️  Warning: Failed to publish %d events to EventBridge bus %s: %v", len(batch), p.busName, err)
+			continue
+		}
+
+		failed := int(output.FailedEntryCount)
+		if failed > 0 {
+			log.Printf("⚠️  Warning: EventBridge rejected %d of %d events on bus %s", failed, len(batch), p.busName)
+		}
+		published += len(batch) - failed
+	}
+
+	AppLogger.Printf("📡 Published %d event(s) to EventBridge bus: %s\n", published, p.busName)
+}
@@ -0,0 +1,121 @@
+package cudly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockEventBridgeClient implements EventBridgeAPI for testing
+type MockEventBridgeClient struct {
+	mock.Mock
+}
+
+func (m *MockEventBridgeClient) PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*eventbridge.PutEventsOutput), args.Error(1)
+}
+
+func TestNewPurchaseEventDetail(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	result := common.PurchaseResult{
+		Recommendation: common.Recommendation{Service: common.ServiceRDS, ResourceType: "db.t3.micro", Count: 2},
+		Success:        false,
+		CommitmentID:   "ri-123",
+		Error:          errors.New("insufficient capacity"),
+		DryRun:         false,
+		Timestamp:      ts,
+	}
+
+	detail := newPurchaseEventDetail(result)
+
+	assert.Equal(t, "insufficient capacity", detail.Error)
+	assert.Equal(t, "ri-123", detail.CommitmentID)
+	assert.Equal(t, ts, detail.Timestamp)
+
+	data, err := json.Marshal(detail)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"error":"insufficient capacity"`)
+	assert.Contains(t, string(data), `"resource_type":"db.t3.micro"`)
+}
+
+func TestNewRunCompletedEventDetail(t *testing.T) {
+	results := []common.PurchaseResult{
+		{Recommendation: common.Recommendation{EstimatedSavings: 100}, Success: true},
+		{Recommendation: common.Recommendation{EstimatedSavings: 50}, Success: true},
+		{Recommendation: common.Recommendation{EstimatedSavings: 25}, Success: false},
+	}
+
+	detail := newRunCompletedEventDetail(results, true)
+
+	assert.True(t, detail.DryRun)
+	assert.Equal(t, 3, detail.TotalPurchases)
+	assert.Equal(t, 2, detail.SuccessfulPurchases)
+	assert.Equal(t, 1, detail.FailedPurchases)
+	assert.Equal(t, 150.0, detail.TotalEstimatedSavings)
+}
+
+func TestEventBridgePublisherPublishResults(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(MockEventBridgeClient)
+	publisher := NewEventBridgePublisherWithAPI(mockClient, "cudly-bus")
+
+	results := []common.PurchaseResult{
+		{CommitmentID: "ri-1", Success: true},
+		{CommitmentID: "ri-2", Success: false, Error: errors.New("failed")},
+	}
+
+	var captured *eventbridge.PutEventsInput
+	mockClient.On("PutEvents", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		captured = args.Get(1).(*eventbridge.PutEventsInput)
+	}).Return(&eventbridge.PutEventsOutput{}, nil).Once()
+
+	publisher.PublishResults(ctx, results, false)
+
+	mockClient.AssertExpectations(t)
+	require.NotNil(t, captured)
+	require.Len(t, captured.Entries, 3)
+	assert.Equal(t, eventDetailTypePurchaseSucceeded, aws.ToString(captured.Entries[0].DetailType))
+	assert.Equal(t, eventDetailTypePurchaseFailed, aws.ToString(captured.Entries[1].DetailType))
+	assert.Equal(t, eventDetailTypeRunCompleted, aws.ToString(captured.Entries[2].DetailType))
+	for _, entry := range captured.Entries {
+		assert.Equal(t, "cudly-bus", aws.ToString(entry.EventBusName))
+		assert.Equal(t, eventBridgeSource, aws.ToString(entry.Source))
+	}
+}
+
+func TestEventBridgePublisherBatchesAndToleratesErrors(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(MockEventBridgeClient)
+	publisher := NewEventBridgePublisherWithAPI(mockClient, "cudly-bus")
+
+	// 14 results + 1 run-complete event = 15 entries, split into batches of 10 and 5
+	results := make([]common.PurchaseResult, 14)
+	for i := range results {
+		results[i] = common.PurchaseResult{Success: true}
+	}
+
+	mockClient.On("PutEvents", ctx, mock.MatchedBy(func(in *eventbridge.PutEventsInput) bool {
+		return len(in.Entries) == eventBridgeMaxBatchSize
+	})).Return(nil, errors.New("access denied")).Once()
+	mockClient.On("PutEvents", ctx, mock.MatchedBy(func(in *eventbridge.PutEventsInput) bool {
+		return len(in.Entries) == 5
+	})).Return(&eventbridge.PutEventsOutput{FailedEntryCount: 1}, nil).Once()
+
+	// Should not panic or abort on publish failures
+	publisher.PublishResults(ctx, results, true)
+
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,90 @@
+package cudly
+
+import (
+	"context"
+	"sync"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+)
+
+// maxCommitmentPrefetchConcurrency limits the number of regions whose existing commitments are fetched at once
+const maxCommitmentPrefetchConcurrency = 8
+
+// existingCommitmentsResult is the outcome of fetching the existing commitments of one region
+type existingCommitmentsResult struct {
+	commitments []common.Commitment
+	err         error
+}
+
+// ExistingCommitmentsCache holds the existing commitments of a service, prefetched concurrently for all regions,
+// along with the account's active Savings Plans that may already cover the service's usage
+type ExistingCommitmentsCache struct {
+	mu       sync.Mutex
+	byRegion map[string]existingCommitmentsResult
+	// savingsPlans are the active Savings Plans of the account, loaded for services they can cover
+	savingsPlans []common.Commitment
+}
+
+// activeSavingsPlans returns the active Savings Plans loaded for the service, if any
+func (c *ExistingCommitmentsCache) activeSavingsPlans() []common.Commitment {
+	if c == nil {
+		return nil
+	}
+	return c.savingsPlans
+}
+
+// prefetchExistingCommitments fetches the existing commitments of every region concurrently
+// newClient returns the service client for a region, or nil if the service has no client
+func prefetchExistingCommitments(ctx context.Context, regions []string, newClient func(region string) provider.ServiceClient) *ExistingCommitmentsCache {
+	cache := &ExistingCommitmentsCache{byRegion: make(map[string]existingCommitmentsResult)}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxCommitmentPrefetchConcurrency)
+	for _, region := range regions {
+		client := newClient(region)
+		if client == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(region string, client provider.ServiceClient) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			commitments, err := client.GetExistingCommitments(ctx)
+			cache.mu.Lock()
+			cache.byRegion[region] = existingCommitmentsResult{commitments: commitments, err: err}
+			cache.mu.Unlock()
+		}(region, client)
+	}
+	wg.Wait()
+
+	return cache
+}
+
+// Wrap returns a service client that serves GetExistingCommitments from the cache for the given region
+// Regions that were not prefetched (and a nil cache) fall back to the client itself
+func (c *ExistingCommitmentsCache) Wrap(region string, client provider.ServiceClient) provider.ServiceClient {
+	if c == nil {
+		return client
+	}
+	c.mu.Lock()
+	result, ok := c.byRegion[region]
+	c.mu.Unlock()
+	if !ok {
+		return client
+	}
+	return &prefetchedServiceClient{ServiceClient: client, result: result}
+}
+
+// prefetchedServiceClient decorates a service client with prefetched existing commitments
+type prefetchedServiceClient struct {
+	provider.ServiceClient
+	result existingCommitmentsResult
+}
+
+// GetExistingCommitments returns the prefetched commitments instead of querying the provider again
+func (p *prefetchedServiceClient) GetExistingCommitments(ctx context.Context) ([]common.Commitment, error) {
+	return p.result.commitments, p.result.err
+}
@@ -0,0 +1,54 @@
+package cudly
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefetchExistingCommitments(t *testing.T) {
+	clients := map[string]*MockServiceClient{
+		"us-east-1": {},
+		"eu-west-1": {},
+	}
+	clients["us-east-1"].On("GetExistingCommitments", mock.Anything).
+		Return([]common.Commitment{{CommitmentID: "ri-1"}, {CommitmentID: "ri-2"}}, nil).Once()
+	clients["eu-west-1"].On("GetExistingCommitments", mock.Anything).
+		Return([]common.Commitment(nil), errors.New("access denied")).Once()
+
+	cache := prefetchExistingCommitments(context.Background(), []string{"us-east-1", "eu-west-1", "ap-south-1"}, func(region string) provider.ServiceClient {
+		client, ok := clients[region]
+		if !ok {
+			return nil
+		}
+		return client
+	})
+
+	// Wrapped clients serve the prefetched results without calling the provider again
+	commitments, err := cache.Wrap("us-east-1", clients["us-east-1"]).GetExistingCommitments(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, commitments, 2)
+
+	_, err = cache.Wrap("eu-west-1", clients["eu-west-1"]).GetExistingCommitments(context.Background())
+	assert.ErrorContains(t, err, "access denied")
+
+	for _, client := range clients {
+		client.AssertExpectations(t)
+	}
+}
+
+func TestExistingCommitmentsCacheWrapFallsBack(t *testing.T) {
+	client := &MockServiceClient{}
+
+	var nilCache *ExistingCommitmentsCache
+	assert.Same(t, client, nilCache.Wrap("us-east-1", client))
+
+	cache := &ExistingCommitmentsCache{byRegion: make(map[string]existingCommitmentsResult)}
+	assert.Same(t, client, cache.Wrap("us-east-1", client))
+}
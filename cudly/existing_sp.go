@@ -0,0 +1,85 @@
+package cudly
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+)
+
+// spCoveredServices maps the services whose usage Savings Plans can cover to the plan types covering it
+var spCoveredServices = map[common.ServiceType][]string{
+	common.ServiceEC2: ec2CoveringSPTypes,
+	common.ServiceRDS: {"Database"},
+}
+
+// loadActiveSavingsPlans returns the active Savings Plans of the account, or nil if they can't be listed
+func loadActiveSavingsPlans(ctx context.Context, client provider.ServiceClient) []common.Commitment {
+	if client == nil {
+		return nil
+	}
+	commitments, err := client.GetExistingCommitments(ctx)
+	if err != nil {
+		log.Printf("⚠️  Warning: Could not list existing Savings Plans: %v", err)
+		return nil
+	}
+	active := make([]common.Commitment, 0, len(commitments))
+	for _, c := range commitments {
+		if strings.EqualFold(c.State, "active") {
+			active = append(active, c)
+		}
+	}
+	return active
+}
+
+// coveringSavingsPlans returns the active Savings Plans that likely already cover the usage of a recommendation
+// Compute and Database Savings Plans apply in every region, EC2 Instance Savings Plans only in their own region.
+func coveringSavingsPlans(rec common.Recommendation, plans []common.Commitment) []common.Commitment {
+	planTypes, ok := spCoveredServices[rec.Service]
+	if !ok {
+		return nil
+	}
+	var covering []common.Commitment
+	for _, plan := range plans {
+		if !slices.Contains(planTypes, plan.ResourceType) {
+			continue
+		}
+		if plan.ResourceType == "EC2Instance" && plan.Region != "" && plan.Region != rec.Region {
+			continue
+		}
+		covering = append(covering, plan)
+	}
+	return covering
+}
+
+// checkActiveSavingsPlans warns about recommendations whose usage active Savings Plans likely already cover,
+// dropping them when respect is set (--respect-existing-sp)
+func checkActiveSavingsPlans(recs []common.Recommendation, plans []common.Commitment, respect bool, indent string) []common.Recommendation {
+	if len(plans) == 0 {
+		return recs
+	}
+
+	result := make([]common.Recommendation, 0, len(recs))
+	for _, rec := range recs {
+		covering := coveringSavingsPlans(rec, plans)
+		if len(covering) == 0 {
+			result = append(result, rec)
+			continue
+		}
+
+		ids := make([]string, 0, len(covering))
+		for _, plan := range covering {
+			ids = append(ids, plan.ResourceType+" "+plan.CommitmentID)
+		}
+		if respect {
+			AppLogger.Printf("%s⏭️  Skipping %s x%d: usage likely covered by active Savings Plan(s) %s\n", indent, rec.ResourceType, rec.Count, strings.Join(ids, ", "))
+			continue
+		}
+		AppLogger.Printf("%s⚠️  %s x%d may be redundant: usage likely covered by active Savings Plan(s) %s\n", indent, rec.ResourceType, rec.Count, strings.Join(ids, ", "))
+		result = append(result, rec)
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package cudly
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func TestLoadActiveSavingsPlans(t *testing.T) {
+	mockClient := &MockServiceClient{}
+	mockClient.On("GetExistingCommitments", mock.Anything).Return([]common.Commitment{
+		{CommitmentID: "sp-active", ResourceType: "Compute", State: "active"},
+		{CommitmentID: "sp-queued", ResourceType: "Compute", State: "queued"},
+	}, nil)
+
+	plans := loadActiveSavingsPlans(context.Background(), mockClient)
+
+	assert.Len(t, plans, 1)
+	assert.Equal(t, "sp-active", plans[0].CommitmentID)
+}
+
+func TestLoadActiveSavingsPlansError(t *testing.T) {
+	mockClient := &MockServiceClient{}
+	mockClient.On("GetExistingCommitments", mock.Anything).Return([]common.Commitment(nil), errors.New("access denied"))
+
+	assert.Nil(t, loadActiveSavingsPlans(context.Background(), mockClient))
+	assert.Nil(t, loadActiveSavingsPlans(context.Background(), nil))
+}
+
+func TestCheckActiveSavingsPlans(t *testing.T) {
+	ec2 := common.Recommendation{Service: common.ServiceEC2, Region: "us-east-1", ResourceType: "m5.large", Count: 2}
+	rds := common.Recommendation{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 1}
+	cache := common.Recommendation{Service: common.ServiceElastiCache, Region: "us-east-1", ResourceType: "cache.r5.large", Count: 1}
+	recs := []common.Recommendation{ec2, rds, cache}
+
+	compute := common.Commitment{CommitmentID: "sp-1", ResourceType: "Compute"}
+	ec2Instance := common.Commitment{CommitmentID: "sp-2", ResourceType: "EC2Instance", Region: "eu-west-1"}
+	database := common.Commitment{CommitmentID: "sp-3", ResourceType: "Database"}
+	sagemaker := common.Commitment{CommitmentID: "sp-4", ResourceType: "SageMaker"}
+
+	tests := []struct {
+		name     string
+		plans    []common.Commitment
+		respect  bool
+		expected []common.Recommendation
+	}{
+		{"no plans", nil, true, recs},
+		{"warn only keeps covered recommendations", []common.Commitment{compute, database}, false, recs},
+		{"compute plan covers EC2", []common.Commitment{compute}, true, []common.Recommendation{rds, cache}},
+		{"EC2 Instance plan in another region", []common.Commitment{ec2Instance}, true, recs},
+		{"database plan covers RDS", []common.Commitment{database}, true, []common.Recommendation{ec2, cache}},
+		{"SageMaker plan covers neither", []common.Commitment{sagemaker}, true, recs},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, checkActiveSavingsPlans(recs, tt.plans, tt.respect, ""))
+		})
+	}
+}
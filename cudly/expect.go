@@ -0,0 +1,37 @@
+package cudly
+
+import (
+	"fmt"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// checkExpectations enforces the --expect-max-instances and --expect-min-savings guards on the selected recommendations
+func checkExpectations(recs []common.Recommendation, cfg RunConfig) error {
+	if cfg.ExpectMaxInstances > 0 {
+		if total := CalculateTotalInstances(recs); total > cfg.ExpectMaxInstances {
+			return fmt.Errorf("--expect-max-instances guard failed: %d instances selected, expected at most %d", total, cfg.ExpectMaxInstances)
+		}
+	}
+	if cfg.ExpectMinSavings > 0 {
+		var savings float64
+		for _, rec := range recs {
+			savings += rec.EstimatedSavings
+		}
+		if savings < cfg.ExpectMinSavings {
+			return fmt.Errorf("--expect-min-savings guard failed: selected recommendations save $%.2f/mo, expected at least $%.2f/mo", savings, cfg.ExpectMinSavings)
+		}
+	}
+	return nil
+}
+
+// plannedRecommendations returns the recommendations of the successful results of a dry run, i.e. what would be purchased
+func plannedRecommendations(results []common.PurchaseResult) []common.Recommendation {
+	recs := make([]common.Recommendation, 0, len(results))
+	for _, result := range results {
+		if result.Success {
+			recs = append(recs, result.Recommendation)
+		}
+	}
+	return recs
+}
@@ -0,0 +1,56 @@
+package cudly
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func TestCheckExpectations(t *testing.T) {
+	recs := []common.Recommendation{
+		{ResourceType: "db.r5.large", Count: 4, EstimatedSavings: 120},
+		{ResourceType: "db.t3.micro", Count: 2, EstimatedSavings: 30},
+	}
+
+	tests := []struct {
+		name          string
+		cfg           RunConfig
+		errorContains string
+	}{
+		{name: "no guards", cfg: RunConfig{}},
+		{name: "within both guards", cfg: RunConfig{ExpectMaxInstances: 6, ExpectMinSavings: 150}},
+		{name: "too many instances", cfg: RunConfig{ExpectMaxInstances: 5}, errorContains: "6 instances selected, expected at most 5"},
+		{name: "too little savings", cfg: RunConfig{ExpectMinSavings: 150.01}, errorContains: "save $150.00/mo, expected at least $150.01/mo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkExpectations(recs, tt.cfg)
+			if tt.errorContains == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errorContains)
+			}
+		})
+	}
+}
+
+func TestCheckExpectationsWithoutRecommendations(t *testing.T) {
+	assert.NoError(t, checkExpectations(nil, RunConfig{ExpectMaxInstances: 1}))
+	assert.ErrorContains(t, checkExpectations(nil, RunConfig{ExpectMinSavings: 1}), "--expect-min-savings guard failed")
+}
+
+func TestPlannedRecommendations(t *testing.T) {
+	results := []common.PurchaseResult{
+		{Recommendation: common.Recommendation{ResourceType: "db.r5.large"}, Success: true},
+		{Recommendation: common.Recommendation{ResourceType: "db.t3.micro"}, Error: errors.New("no offering")},
+	}
+
+	planned := plannedRecommendations(results)
+
+	assert.Len(t, planned, 1)
+	assert.Equal(t, "db.r5.large", planned[0].ResourceType)
+}
@@ -0,0 +1,282 @@
+package cudly
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// filterExpr is a parsed --filter expression evaluated against a single recommendation
+type filterExpr interface {
+	eval(rec common.Recommendation) bool
+}
+
+type andExpr struct{ left, right filterExpr }
+
+func (e andExpr) eval(rec common.Recommendation) bool { return e.left.eval(rec) && e.right.eval(rec) }
+
+type orExpr struct{ left, right filterExpr }
+
+func (e orExpr) eval(rec common.Recommendation) bool { return e.left.eval(rec) || e.right.eval(rec) }
+
+type notExpr struct{ inner filterExpr }
+
+func (e notExpr) eval(rec common.Recommendation) bool { return !e.inner.eval(rec) }
+
+// filterField describes a recommendation field that can be used in a --filter expression
+type filterField struct {
+	numeric bool
+	str     func(rec common.Recommendation) string
+	num     func(rec common.Recommendation) float64
+}
+
+// filterFields lists the recommendation fields supported in --filter expressions
+var filterFields = map[string]filterField{
+	"service":           {str: func(rec common.Recommendation) string { return string(rec.Service) }},
+	"region":            {str: func(rec common.Recommendation) string { return rec.Region }},
+	"instance_type":     {str: func(rec common.Recommendation) string { return rec.ResourceType }},
+	"engine":            {str: getEngineFromRecommendationRaw},
+	"account":           {str: func(rec common.Recommendation) string { return rec.AccountName }},
+	"count":             {numeric: true, num: func(rec common.Recommendation) float64 { return float64(rec.Count) }},
+	"savings_percent":   {numeric: true, num: func(rec common.Recommendation) float64 { return rec.SavingsPercentage }},
+	"estimated_savings": {numeric: true, num: func(rec common.Recommendation) float64 { return rec.EstimatedSavings }},
+}
+
+// compareExpr compares one recommendation field with a literal value
+type compareExpr struct {
+	field filterField
+	op    string
+	str   string
+	num   float64
+}
+
+func (e compareExpr) eval(rec common.Recommendation) bool {
+	if !e.field.numeric {
+		equal := strings.EqualFold(e.field.str(rec), e.str)
+		if e.op == "!=" {
+			return !equal
+		}
+		return equal
+	}
+
+	value := e.field.num(rec)
+	switch e.op {
+	case "=", "==":
+		return value == e.num
+	case "!=":
+		return value != e.num
+	case ">":
+		return value > e.num
+	case ">=":
+		return value >= e.num
+	case "<":
+		return value < e.num
+	default: // "<="
+		return value <= e.num
+	}
+}
+
+// filterToken is a lexical token of a --filter expression
+type filterToken struct {
+	kind  string // "word", "string", "op", "(", ")", "!", "&&", "||"
+	value string
+}
+
+// tokenizeFilterExpression splits a --filter expression into tokens
+func tokenizeFilterExpression(input string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, filterToken{kind: string(r)})
+			i++
+		case r == '&' || r == '|':
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, fmt.Errorf("unexpected %q at position %d (use %c%c)", r, i, r, r)
+			}
+			tokens = append(tokens, filterToken{kind: string([]rune{r, r})})
+			i += 2
+		case r == '=' || r == '!' || r == '<' || r == '>':
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			i += len(op)
+			if op == "!" {
+				tokens = append(tokens, filterToken{kind: "!"})
+			} else {
+				tokens = append(tokens, filterToken{kind: "op", value: op})
+			}
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string starting at position %d", i)
+			}
+			tokens = append(tokens, filterToken{kind: "string", value: string(runes[i+1 : end])})
+			i = end + 1
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()&|=!<>\"'", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: "word", value: string(runes[start:i])})
+		}
+	}
+	return tokens, nil
+}
+
+// filterParser is a recursive descent parser for --filter expressions
+// Grammar: or = and {"||" and}; and = unary {"&&" unary}; unary = "!" unary | "(" or ")" | field op value
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+// parseFilterExpression parses a --filter expression such as `service=rds && savings_percent>20`
+func parseFilterExpression(input string) (filterExpr, error) {
+	tokens, err := tokenizeFilterExpression(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty filter expression")
+	}
+
+	p := &filterParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %s after end of expression", p.describe(p.tokens[p.pos]))
+	}
+	return expr, nil
+}
+
+func (p *filterParser) peek(kind string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == kind
+}
+
+func (p *filterParser) describe(token filterToken) string {
+	if token.value != "" {
+		return fmt.Sprintf("%q", token.value)
+	}
+	return fmt.Sprintf("%q", token.kind)
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("||") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("&&") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterExpr, error) {
+	switch {
+	case p.peek("!"):
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{inner: inner}, nil
+	case p.peek("("):
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return inner, nil
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *filterParser) parseComparison() (filterExpr, error) {
+	if !p.peek("word") {
+		if p.pos >= len(p.tokens) {
+			return nil, fmt.Errorf("unexpected end of expression, expected a field name")
+		}
+		return nil, fmt.Errorf("expected a field name, got %s", p.describe(p.tokens[p.pos]))
+	}
+	name := strings.ToLower(p.tokens[p.pos].value)
+	field, ok := filterFields[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q (supported: account, count, engine, estimated_savings, instance_type, region, savings_percent, service)", name)
+	}
+	p.pos++
+
+	if !p.peek("op") {
+		return nil, fmt.Errorf("expected a comparison operator after %q", name)
+	}
+	op := p.tokens[p.pos].value
+	p.pos++
+
+	if !p.peek("word") && !p.peek("string") {
+		return nil, fmt.Errorf("expected a value after %q %s", name, op)
+	}
+	value := p.tokens[p.pos].value
+	p.pos++
+
+	expr := compareExpr{field: field, op: op}
+	if field.numeric {
+		num, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("field %q needs a numeric value, got %q", name, value)
+		}
+		expr.num = num
+		return expr, nil
+	}
+
+	if op != "=" && op != "==" && op != "!=" {
+		return nil, fmt.Errorf("field %q only supports =, == and !=, got %s", name, op)
+	}
+	expr.str = value
+	if name == "service" {
+		service, ok := serviceNameMap[strings.ToLower(value)]
+		if !ok {
+			return nil, fmt.Errorf("unknown service %q in filter expression", value)
+		}
+		expr.str = string(service)
+	}
+	return expr, nil
+}
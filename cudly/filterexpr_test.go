@@ -0,0 +1,81 @@
+package cudly
+
+import (
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilterExpression_Eval(t *testing.T) {
+	rec := common.Recommendation{
+		Service:           common.ServiceRDS,
+		Region:            "eu-west-1",
+		ResourceType:      "db.r5.large",
+		AccountName:       "prod",
+		Count:             3,
+		SavingsPercentage: 25,
+		EstimatedSavings:  120.5,
+		Details:           &common.DatabaseDetails{Engine: "postgres"},
+	}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"service=rds", true},
+		{"service == ec2", false},
+		{"service=RDS && region!=us-east-1", true},
+		{"savings_percent>20 && count>=3", true},
+		{"savings_percent>25", false},
+		{"estimated_savings<100 || instance_type='db.r5.large'", true},
+		{"!(region=eu-west-1)", false},
+		{"engine=postgres && account=\"prod\"", true},
+		{"count<=2 || (service=rds && savings_percent>=25)", true},
+		{"region=us-east-1 || region=us-west-2", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := parseFilterExpression(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, expr.eval(rec))
+		})
+	}
+}
+
+func TestParseFilterExpression_Precedence(t *testing.T) {
+	// && binds tighter than ||
+	expr, err := parseFilterExpression("region=us-east-1 || region=eu-west-1 && count>5")
+	require.NoError(t, err)
+	assert.True(t, expr.eval(common.Recommendation{Region: "us-east-1", Count: 1}))
+	assert.False(t, expr.eval(common.Recommendation{Region: "eu-west-1", Count: 1}))
+}
+
+func TestParseFilterExpression_Errors(t *testing.T) {
+	tests := []struct {
+		expr          string
+		errorContains string
+	}{
+		{"", "empty filter expression"},
+		{"cost>5", "unknown field"},
+		{"savings_percent>high", "numeric value"},
+		{"region>us-east-1", "only supports"},
+		{"service=lambda", "unknown service"},
+		{"region=us-east-1 & count>1", "use &&"},
+		{"(region=us-east-1", "missing closing parenthesis"},
+		{"region=us-east-1)", "after end of expression"},
+		{"region us-east-1", "comparison operator"},
+		{"region=", "expected a value"},
+		{"region='us-east-1", "unterminated string"},
+		{"region=us-east-1 &&", "unexpected end of expression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := parseFilterExpression(tt.expr)
+			assert.ErrorContains(t, err, tt.errorContains)
+		})
+	}
+}
@@ -0,0 +1,595 @@
+package cudly
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+)
+
+// AppLogger is a simple logger for application output
+var AppLogger = log.New(os.Stdout, "", 0)
+
+// AccountAliasCache caches account ID to alias mappings
+type AccountAliasCache struct {
+	mu      sync.RWMutex
+	cache   map[string]string
+	orgClient *organizations.Client
+	// resolved records when each alias was resolved through Organizations, for --account-cache-file
+	resolved map[string]time.Time
+}
+
+// NewAccountAliasCache creates a new account alias cache
+func NewAccountAliasCache(cfg aws.Config) *AccountAliasCache {
+	return &AccountAliasCache{
+		cache:     make(map[string]string),
+		orgClient: organizations.NewFromConfig(cfg),
+		resolved:  make(map[string]time.Time),
+	}
+}
+
+// GetAccountAlias returns the account alias for an account ID
+func (c *AccountAliasCache) GetAccountAlias(ctx context.Context, accountID string) string {
+	if accountID == "" {
+		return ""
+	}
+
+	c.mu.RLock()
+	if alias, ok := c.cache[accountID]; ok {
+		c.mu.RUnlock()
+		return alias
+	}
+	c.mu.RUnlock()
+
+	// Try to fetch from Organizations
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Double-check after acquiring write lock
+	if alias, ok := c.cache[accountID]; ok {
+		return alias
+	}
+
+	// Try to describe the account
+	result, err := c.orgClient.DescribeAccount(ctx, &organizations.DescribeAccountInput{
+		AccountId: aws.String(accountID),
+	})
+	if err != nil {
+		c.cache[accountID] = accountID // Use ID as fallback
+		return accountID
+	}
+
+	if result.Account != nil && result.Account.Name != nil {
+		c.cache[accountID] = *result.Account.Name
+		c.resolved[accountID] = time.Now()
+		return *result.Account.Name
+	}
+
+	c.cache[accountID] = accountID
+	return accountID
+}
+
+// CalculateTotalInstances calculates the total instance count across recommendations
+func CalculateTotalInstances(recs []common.Recommendation) int {
+	total := 0
+	for _, rec := range recs {
+		total += rec.Count
+	}
+	return total
+}
+
+// Values of --coverage-rounding
+const (
+	CoverageRoundingFloor = "floor"
+	CoverageRoundingCeil  = "ceil"
+	CoverageRoundingRound = "round"
+)
+
+// validateCoverageRounding normalizes --coverage-rounding, defaulting to floor
+func validateCoverageRounding(rounding string) (string, error) {
+	switch r := strings.ToLower(strings.TrimSpace(rounding)); r {
+	case "":
+		return CoverageRoundingFloor, nil
+	case CoverageRoundingFloor, CoverageRoundingCeil, CoverageRoundingRound:
+		return r, nil
+	default:
+		return "", fmt.Errorf("invalid coverage-rounding: %s. Must be one of: %s, %s, %s", rounding, CoverageRoundingFloor, CoverageRoundingCeil, CoverageRoundingRound)
+	}
+}
+
+// coverageCount returns the share of count covered by coverage percent, rounded with the given mode
+func coverageCount(count int, coverage float64, rounding string) int {
+	// Tolerate floating point error, so that exact products such as 10*70% aren't rounded up or down
+	const epsilon = 1e-9
+	scaled := float64(count) * coverage / 100
+	switch rounding {
+	case CoverageRoundingCeil:
+		return int(math.Ceil(scaled - epsilon))
+	case CoverageRoundingRound:
+		return int(math.Round(scaled))
+	default:
+		return int(math.Floor(scaled + epsilon))
+	}
+}
+
+// ApplyCoverage applies coverage percentage to recommendations, rounding reserved instance counts down
+func ApplyCoverage(recs []common.Recommendation, coverage float64) []common.Recommendation {
+	return ApplyCoverageRounded(recs, coverage, CoverageRoundingFloor)
+}
+
+// ApplyCoverageRounded applies coverage percentage to recommendations, rounding reserved instance counts with the
+// given --coverage-rounding mode. Recommendations rounded down to zero instances are dropped.
+func ApplyCoverageRounded(recs []common.Recommendation, coverage float64, rounding string) []common.Recommendation {
+	if coverage >= 100 {
+		return recs
+	}
+	if coverage <= 0 {
+		return []common.Recommendation{}
+	}
+
+	// Apply coverage by reducing counts (for RIs) or hourly commitment (for Savings Plans)
+	result := make([]common.Recommendation, 0, len(recs))
+	for _, rec := range recs {
+		adjusted := rec
+
+		// Savings Plans are sized in $/hour, so their commitment is scaled rather than a count truncated
+		if rec.Service == common.ServiceSavingsPlans {
+			if details, ok := savingsPlanDetails(rec); ok {
+				details.HourlyCommitment = details.HourlyCommitment * coverage / 100
+				adjusted.Details = &details
+			}
+			// Also adjust the estimated savings and costs proportionally
+			adjusted.EstimatedSavings = rec.EstimatedSavings * coverage / 100
+			adjusted.CommitmentCost = rec.CommitmentCost * coverage / 100
+			adjusted.UpfrontCost = rec.UpfrontCost * coverage / 100
+			adjusted.AmortizedMonthlyCost = rec.AmortizedMonthlyCost * coverage / 100
+			result = append(result, adjusted)
+			continue
+		}
+
+		// For RIs, reduce the count
+		newCount := coverageCount(rec.Count, coverage, rounding)
+		if newCount > 0 {
+			adjusted.Count = newCount
+			result = append(result, adjusted)
+		}
+	}
+	return result
+}
+
+// savingsPlanDetails returns a copy of the Savings Plan details of a recommendation, held by pointer or by value
+func savingsPlanDetails(rec common.Recommendation) (common.SavingsPlanDetails, bool) {
+	switch d := rec.Details.(type) {
+	case *common.SavingsPlanDetails:
+		if d != nil {
+			return *d, true
+		}
+	case common.SavingsPlanDetails:
+		return d, true
+	}
+	return common.SavingsPlanDetails{}, false
+}
+
+// ApplyCountOverride overrides the count for all recommendations
+func ApplyCountOverride(recs []common.Recommendation, overrideCount int32) []common.Recommendation {
+	if overrideCount <= 0 {
+		return recs
+	}
+	result := make([]common.Recommendation, len(recs))
+	for i, rec := range recs {
+		result[i] = rec
+		result[i].Count = int(overrideCount)
+	}
+	return result
+}
+
+// ApplyInstanceLimit limits the total number of instances
+func ApplyInstanceLimit(recs []common.Recommendation, maxInstances int32) []common.Recommendation {
+	if maxInstances <= 0 {
+		return recs
+	}
+
+	result := make([]common.Recommendation, 0)
+	remaining := int(maxInstances)
+
+	for _, rec := range recs {
+		if remaining <= 0 {
+			break
+		}
+		adjusted := rec
+		if rec.Count > remaining {
+			adjusted.Count = remaining
+		}
+		result = append(result, adjusted)
+		remaining -= adjusted.Count
+	}
+	return result
+}
+
+const (
+	// SortBySavings orders recommendations by estimated monthly savings, highest first (default)
+	SortBySavings = "savings"
+	// SortByCount orders recommendations by instance count, highest first
+	SortByCount = "count"
+	// SortByNone keeps the order in which Cost Explorer returned the recommendations
+	SortByNone = "none"
+)
+
+// SortRecommendations returns a sorted copy of recs for the given --sort-by order
+// Sorting is stable, so equal recommendations keep their original order
+func SortRecommendations(recs []common.Recommendation, sortBy string) []common.Recommendation {
+	sorted := make([]common.Recommendation, len(recs))
+	copy(sorted, recs)
+
+	switch sortBy {
+	case "", SortBySavings:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].EstimatedSavings > sorted[j].EstimatedSavings
+		})
+	case SortByCount:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Count > sorted[j].Count
+		})
+	}
+	return sorted
+}
+
+// MonthlyCommitmentCost estimates the monthly cost of purchasing a recommendation
+// Savings Plans cost their hourly commitment; RIs cost the on-demand spend they replace minus the savings
+func MonthlyCommitmentCost(rec common.Recommendation) float64 {
+	if rec.Service == common.ServiceSavingsPlans {
+		if details, ok := rec.Details.(*common.SavingsPlanDetails); ok && details != nil {
+			return details.HourlyCommitment * hoursPerMonth
+		}
+	}
+	if cost := rec.OnDemandCost - rec.EstimatedSavings; cost > 0 {
+		return cost
+	}
+	return 0
+}
+
+// CommitmentTotalCost estimates the total cost of purchasing a recommendation: its upfront payment plus the
+// recurring charges over the term, from the amortized monthly cost or, when unknown, the estimated monthly cost
+func CommitmentTotalCost(rec common.Recommendation) float64 {
+	monthly := rec.AmortizedMonthlyCost
+	if monthly <= 0 {
+		monthly = MonthlyCommitmentCost(rec)
+	}
+	return max(rec.UpfrontCost, monthly*float64(termMonths(rec.Term)))
+}
+
+// totalCommitmentCost sums the estimated total cost of purchasing recommendations
+func totalCommitmentCost(recs []common.Recommendation) float64 {
+	total := 0.0
+	for _, rec := range recs {
+		total += CommitmentTotalCost(rec)
+	}
+	return total
+}
+
+// ApplySpendLimit keeps the highest-savings recommendations until their estimated monthly cost would exceed maxSpend
+func ApplySpendLimit(recs []common.Recommendation, maxSpend float64) []common.Recommendation {
+	if maxSpend <= 0 {
+		return recs
+	}
+
+	result := make([]common.Recommendation, 0)
+	spent := 0.0
+
+	for _, rec := range SortRecommendations(recs, SortBySavings) {
+		cost := MonthlyCommitmentCost(rec)
+		if spent+cost > maxSpend {
+			break
+		}
+		result = append(result, rec)
+		spent += cost
+	}
+	return result
+}
+
+// confirmInput is where purchase confirmations are read from (replaceable in tests)
+var confirmInput io.Reader = os.Stdin
+
+// confirmationPhrase is the phrase --confirm-phrase asks to type, such as "PURCHASE 50 INSTANCES"
+func confirmationPhrase(totalInstances int) string {
+	return fmt.Sprintf("PURCHASE %d INSTANCES", totalInstances)
+}
+
+// confirmedPurchases is the running total of the purchases confirmed during a run, shared by the copies of its
+// configuration, so that the --confirm-phrase thresholds apply to the whole run rather than to each region
+type confirmedPurchases struct {
+	mu        sync.Mutex
+	instances int
+	cost      float64
+}
+
+// needsConfirmationPhrase reports whether a purchase exceeds the --confirm-phrase thresholds
+func needsConfirmationPhrase(totalInstances int, totalCost float64, cfg RunConfig) bool {
+	if !cfg.ConfirmPhrase {
+		return false
+	}
+	return totalInstances > cfg.ConfirmPhraseInstances || totalCost > cfg.ConfirmPhraseCost
+}
+
+// ConfirmPurchase asks the user for confirmation before proceeding, or RunConfig.ConfirmFunc when set
+// With --confirm-phrase, purchases taking the run above its thresholds must be confirmed by typing the exact
+// confirmation phrase.
+func ConfirmPurchase(totalInstances int, totalCost float64, cfg RunConfig) bool {
+	if cfg.SkipConfirmation {
+		return true
+	}
+	if cfg.ConfirmFunc != nil {
+		return cfg.ConfirmFunc(totalInstances, totalCost)
+	}
+
+	// Batches of concurrently processed services are confirmed one at a time against the run totals
+	runInstances, runCost := totalInstances, totalCost
+	if cfg.confirmed != nil {
+		cfg.confirmed.mu.Lock()
+		defer cfg.confirmed.mu.Unlock()
+		runInstances += cfg.confirmed.instances
+		runCost += cfg.confirmed.cost
+	}
+
+	outPrintf("\n⚠️  About to purchase %d instances with estimated total cost: $%.2f\n", totalInstances, totalCost)
+	if runInstances > totalInstances {
+		outPrintf("   This run would purchase %d instances with estimated total cost: $%.2f\n", runInstances, runCost)
+	}
+	if !promptPurchaseConfirmation(totalInstances, needsConfirmationPhrase(runInstances, runCost, cfg)) {
+		return false
+	}
+	if cfg.confirmed != nil {
+		cfg.confirmed.instances, cfg.confirmed.cost = runInstances, runCost
+	}
+	return true
+}
+
+// promptPurchaseConfirmation reads the answer to the purchase confirmation, asking for the confirmation phrase
+// instead of yes when usePhrase is set
+func promptPurchaseConfirmation(totalInstances int, usePhrase bool) bool {
+	phrase := ""
+	if usePhrase {
+		phrase = confirmationPhrase(totalInstances)
+		outPrintf("Type %q to proceed: ", phrase)
+	} else {
+		outPrintf("Do you want to proceed? (yes/no): ")
+	}
+
+	reader := bufio.NewReader(confirmInput)
+	response, err := reader.ReadString('\n')
+	if err != nil && response == "" {
+		return false
+	}
+
+	if phrase != "" {
+		return strings.TrimSpace(response) == phrase
+	}
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "yes" || response == "y"
+}
+
+// expiringSoonWarning is how close to its expiration an existing commitment counted as coverage triggers a warning
+const expiringSoonWarning = 30 * 24 * time.Hour
+
+// DuplicateChecker checks for existing commitments to avoid duplicates
+type DuplicateChecker struct {
+	LookbackHours int // How many hours to look back for recent purchases
+	// IgnoreExpiringWithin excludes commitments expiring within this duration from the existing coverage (0 = count all)
+	IgnoreExpiringWithin time.Duration
+}
+
+// expiresWithin reports whether a commitment with a known end date expires within window of now
+func expiresWithin(c common.Commitment, window time.Duration, now time.Time) bool {
+	return !c.EndDate.IsZero() && c.EndDate.Before(now.Add(window))
+}
+
+// excludeExpiringCommitments drops the commitments expiring within window of now (--ignore-ris-expiring-within)
+func excludeExpiringCommitments(existing []common.Commitment, window time.Duration, now time.Time) []common.Commitment {
+	if window <= 0 {
+		return existing
+	}
+	result := make([]common.Commitment, 0, len(existing))
+	for _, c := range existing {
+		if expiresWithin(c, window, now) {
+			continue
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// NewDuplicateChecker creates a new duplicate checker with default 24-hour lookback
+func NewDuplicateChecker() *DuplicateChecker {
+	return &DuplicateChecker{
+		LookbackHours: 24,
+	}
+}
+
+// AdjustRecommendationsForExisting adjusts recommendations based on existing commitments
+// This checks for recently purchased RIs (within LookbackHours) to avoid duplicate purchases.
+// Note: This is designed to prevent re-purchasing something you just bought, not to prevent
+// purchasing RIs in other accounts that happen to have the same characteristics.
+func (d *DuplicateChecker) AdjustRecommendationsForExisting(ctx context.Context, recs []common.Recommendation, client provider.ServiceClient) ([]common.Recommendation, error) {
+	existing, err := client.GetExistingCommitments(ctx)
+	if err != nil {
+		return recs, err
+	}
+
+	logDebugf("    [DuplicateChecker] Found %d total existing commitments", len(existing))
+
+	// Filter to recent purchases only (within LookbackHours)
+	// This is the key filter that prevents cross-account matching issues:
+	// - The API returns RIs from the current account only
+	// - But recommendations come from all org accounts
+	// - By only checking RECENT purchases, we avoid incorrectly matching old RIs
+	//   from the payer account against recommendations for member accounts
+	now := time.Now()
+	cutoffTime := now.Add(-time.Duration(d.LookbackHours) * time.Hour)
+	recentExisting := make([]common.Commitment, 0)
+	for _, c := range existing {
+		// Only include active or payment-pending RIs purchased after cutoff
+		if (c.State != "active" && c.State != "payment-pending") || !c.StartDate.After(cutoffTime) {
+			continue
+		}
+		// A commitment about to lapse doesn't cover the demand for long, so it can be left out of the coverage
+		if d.IgnoreExpiringWithin > 0 && expiresWithin(c, d.IgnoreExpiringWithin, now) {
+			AppLogger.Printf("  ⏳ Not counting %d %s in %s as existing coverage: expires on %s\n",
+				c.Count, c.ResourceType, c.Region, c.EndDate.Format("2006-01-02"))
+			continue
+		}
+		if expiresWithin(c, expiringSoonWarning, now) {
+			AppLogger.Printf("  ⚠️  %d %s in %s counted as existing coverage expire on %s (see --ignore-ris-expiring-within)\n",
+				c.Count, c.ResourceType, c.Region, c.EndDate.Format("2006-01-02"))
+		}
+		recentExisting = append(recentExisting, c)
+	}
+
+	logDebugf("    [DuplicateChecker] Found %d recent commitments (purchased in last %d hours)", len(recentExisting), d.LookbackHours)
+
+	if len(recentExisting) == 0 {
+		// No recent purchases, return all recommendations as-is
+		return recs, nil
+	}
+
+	// Build a map of recent commitments by resource type, region, and engine (for RDS/ElastiCache)
+	// Key format: resourceType|region|engine (engine may be empty for non-database services)
+	existingMap := make(map[string]int)
+	for _, c := range recentExisting {
+		normalizedEngine := normalizeEngineName(c.Engine)
+		key := fmt.Sprintf("%s|%s|%s", c.ResourceType, c.Region, normalizedEngine)
+		existingMap[key] += c.Count
+		logDebugf("    [DuplicateChecker] Recent RI: key=%s count=%d startDate=%s (raw engine=%s)",
+			key, c.Count, c.StartDate.Format("2006-01-02 15:04:05"), c.Engine)
+	}
+
+	logDebugf("    [DuplicateChecker] Existing map has %d unique keys", len(existingMap))
+
+	// Adjust recommendations - decrement existing count as we "use up" existing RIs
+	result := make([]common.Recommendation, 0, len(recs))
+	for _, rec := range recs {
+		// Get engine from recommendation details if available
+		engine := getEngineFromRecommendation(rec)
+		key := fmt.Sprintf("%s|%s|%s", rec.ResourceType, rec.Region, engine)
+		existingCount := existingMap[key]
+
+		if existingCount >= rec.Count {
+			// All of this recommendation is covered by recent RIs
+			logDebugf("    [DuplicateChecker] SKIP %s: recent %d >= recommended %d", key, existingCount, rec.Count)
+			existingMap[key] -= rec.Count // Use up these existing RIs
+			continue
+		}
+		// Partial or no coverage by recent RIs
+		adjusted := rec
+		if existingCount > 0 {
+			adjusted.Count = rec.Count - existingCount
+			existingMap[key] = 0 // Use up all remaining existing RIs for this key
+			logDebugf("    [DuplicateChecker] PARTIAL %s: adjusted count from %d to %d", key, rec.Count, adjusted.Count)
+		}
+		if adjusted.Count > 0 {
+			result = append(result, adjusted)
+		}
+	}
+
+	if len(result) < len(recs) {
+		logDebugf("    [DuplicateChecker] Result: %d recommendations kept out of %d (avoided %d duplicates)",
+			len(result), len(recs), len(recs)-len(result))
+	}
+	return result, nil
+}
+
+// getEngineFromRecommendation extracts the engine from recommendation details
+func getEngineFromRecommendation(rec common.Recommendation) string {
+	if rec.Details == nil {
+		return ""
+	}
+	var engine string
+	switch details := rec.Details.(type) {
+	case common.DatabaseDetails:
+		engine = details.Engine
+	case *common.DatabaseDetails:
+		engine = details.Engine
+	case common.CacheDetails:
+		engine = details.Engine
+	case *common.CacheDetails:
+		engine = details.Engine
+	default:
+		return ""
+	}
+	return normalizeEngineName(engine)
+}
+
+// normalizeEngineName normalizes database engine names to a consistent format
+// AWS RIs use: "aurora-postgresql", "aurora-mysql", "mysql", "postgres"
+// Cost Explorer uses: "Aurora PostgreSQL", "Aurora MySQL", "MySQL", "PostgreSQL"
+func normalizeEngineName(engine string) string {
+	engineMap := map[string]string{
+		// Cost Explorer format -> normalized
+		"Aurora PostgreSQL": "aurora-postgresql",
+		"Aurora MySQL":      "aurora-mysql",
+		"MySQL":             "mysql",
+		"PostgreSQL":        "postgresql",
+		"MariaDB":           "mariadb",
+		"Oracle":            "oracle",
+		"SQL Server":        "sqlserver",
+		// Already normalized (from AWS RIs)
+		"aurora-postgresql": "aurora-postgresql",
+		"aurora-mysql":      "aurora-mysql",
+		"mysql":             "mysql",
+		"postgresql":        "postgresql",
+		"postgres":          "postgresql",
+		"mariadb":           "mariadb",
+		"oracle-se":         "oracle",
+		"oracle-se1":        "oracle",
+		"oracle-se2":        "oracle",
+		"oracle-ee":         "oracle",
+		"sqlserver-se":      "sqlserver",
+		"sqlserver-ee":      "sqlserver",
+		"sqlserver-ex":      "sqlserver",
+		"sqlserver-web":     "sqlserver",
+	}
+	if normalized, ok := engineMap[engine]; ok {
+		return normalized
+	}
+	// Return lowercase as fallback
+	return strings.ToLower(engine)
+}
+
+// AdjustRecommendationsForExistingRIs is an alias for AdjustRecommendationsForExisting
+func (d *DuplicateChecker) AdjustRecommendationsForExistingRIs(ctx context.Context, recs []common.Recommendation, client provider.ServiceClient) ([]common.Recommendation, error) {
+	return d.AdjustRecommendationsForExisting(ctx, recs, client)
+}
+
+// GetRecommendationDescription returns a human-readable description
+func GetRecommendationDescription(rec common.Recommendation) string {
+	desc := fmt.Sprintf("%s %s", rec.Service, rec.ResourceType)
+	if rec.Details != nil {
+		desc += " " + rec.Details.GetDetailDescription()
+	}
+	return desc
+}
+
+// termMonths returns the length of a term such as "1yr", "3yr" or "3" in months, or 0 if it is unknown
+// Numbers up to 5 are years, larger ones months (e.g. "36").
+func termMonths(term string) int {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(term)), "yr"), "y"))
+	if err != nil || n <= 0 {
+		return 0
+	}
+	if n <= 5 {
+		return n * 12
+	}
+	return n
+}
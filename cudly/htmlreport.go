@@ -0,0 +1,180 @@
+package cudly
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// htmlReportTemplate renders a self-contained purchase report page (no external assets)
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>CUDly purchase report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 2em; }
+table { border-collapse: collapse; width: 100%; margin-top: 0.5em; }
+th, td { border: 1px solid #ccc; padding: 0.4em 0.6em; text-align: left; }
+th { background: #f0f0f0; }
+td.num { text-align: right; }
+tr.failed td { background: #fdecea; color: #a1261b; }
+tr.total td { font-weight: bold; background: #f7f7f7; }
+.meta { color: #666; }
+</style>
+</head>
+<body>
+<h1>CUDly purchase report</h1>
+<p class="meta">Generated {{.Generated}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Service</th><th>Recommendations</th><th>Instances</th><th>Successful</th><th>Failed</th><th>Est. monthly savings</th><th>Upfront cost</th><th>Amortized monthly cost</th><th>Projected term savings</th></tr>
+{{- range .Summary}}
+<tr><td>{{.Name}}</td><td class="num">{{.Stats.RecommendationsSelected}}</td><td class="num">{{.Stats.InstancesProcessed}}</td><td class="num">{{.Stats.SuccessfulPurchases}}</td><td class="num">{{.Stats.FailedPurchases}}</td><td class="num">${{printf "%.2f" .Stats.TotalEstimatedSavings}}</td><td class="num">${{printf "%.2f" .Stats.TotalUpfrontCost}}</td><td class="num">${{printf "%.2f" .Stats.TotalAmortizedMonthlyCost}}</td><td class="num">${{printf "%.2f" .Stats.ProjectedTermSavings}}</td></tr>
+{{- end}}
+<tr class="total"><td>Total</td><td class="num">{{.Total.RecommendationsSelected}}</td><td class="num">{{.Total.InstancesProcessed}}</td><td class="num">{{.Total.SuccessfulPurchases}}</td><td class="num">{{.Total.FailedPurchases}}</td><td class="num">${{printf "%.2f" .Total.TotalEstimatedSavings}}</td><td class="num">${{printf "%.2f" .Total.TotalUpfrontCost}}</td><td class="num">${{printf "%.2f" .Total.TotalAmortizedMonthlyCost}}</td><td class="num">${{printf "%.2f" .Total.ProjectedTermSavings}}</td></tr>
+</table>
+{{range .Services}}
+<h2>{{.Name}}</h2>
+<table>
+<tr><th>Region</th><th>Resource type</th><th>Count</th><th>Account</th><th>Term</th><th>Payment option</th><th>Est. monthly savings</th><th>Upfront cost</th><th>Commitment ID</th><th>Status</th></tr>
+{{- range .Rows}}
+<tr{{if .Failed}} class="failed"{{end}}><td>{{.Region}}</td><td>{{.ResourceType}}</td><td class="num">{{.Count}}</td><td>{{.Account}}</td><td>{{.Term}}</td><td>{{.PaymentOption}}</td><td class="num">${{printf "%.2f" .Savings}}</td><td class="num">${{printf "%.2f" .UpfrontCost}}</td><td>{{.CommitmentID}}</td><td>{{.Status}}</td></tr>
+{{- end}}
+<tr class="total"><td colspan="2">Total</td><td class="num">{{.Count}}</td><td colspan="3"></td><td class="num">${{printf "%.2f" .Savings}}</td><td class="num">${{printf "%.2f" .UpfrontCost}}</td><td colspan="2"></td></tr>
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+// htmlReportRow is a purchase result as shown in the HTML report
+type htmlReportRow struct {
+	Region        string
+	ResourceType  string
+	Count         int
+	Account       string
+	Term          string
+	PaymentOption string
+	Savings       float64
+	UpfrontCost   float64
+	CommitmentID  string
+	Status        string
+	Failed        bool
+}
+
+// htmlReportService groups the rows of one service with their totals
+type htmlReportService struct {
+	Name        string
+	Rows        []htmlReportRow
+	Count       int
+	Savings     float64
+	UpfrontCost float64
+}
+
+// htmlReportSummary is a row of the per-service summary table
+type htmlReportSummary struct {
+	Name  string
+	Stats ServiceProcessingStats
+}
+
+// htmlReportData is the data rendered by htmlReportTemplate
+type htmlReportData struct {
+	Generated string
+	Summary   []htmlReportSummary
+	Total     ServiceProcessingStats
+	Services  []htmlReportService
+}
+
+// newHTMLReportRow converts a purchase result into its HTML report row
+func newHTMLReportRow(r common.PurchaseResult) htmlReportRow {
+	rec := r.Recommendation
+	account := rec.AccountName
+	if account == "" {
+		account = rec.Account
+	}
+	row := htmlReportRow{
+		Region:        rec.Region,
+		ResourceType:  rec.ResourceType,
+		Count:         rec.Count,
+		Account:       account,
+		Term:          rec.Term,
+		PaymentOption: rec.PaymentOption,
+		Savings:       rec.EstimatedSavings,
+		UpfrontCost:   rec.UpfrontCost,
+		CommitmentID:  r.CommitmentID,
+	}
+	switch {
+	case !r.Success:
+		row.Failed = true
+		row.Status = "Failed"
+		if r.Error != nil {
+			row.Status = "Failed: " + r.Error.Error()
+		}
+	case r.DryRun:
+		row.Status = "Dry run"
+	case r.Partial:
+		row.Status = fmt.Sprintf("Partial (%d of %d)", r.PurchasedCount, r.RequestedCount)
+	default:
+		row.Status = "Purchased"
+	}
+	return row
+}
+
+// buildHTMLReportData groups the results per service, in service name order
+func buildHTMLReportData(results []common.PurchaseResult, stats map[common.ServiceType]ServiceProcessingStats, now time.Time) htmlReportData {
+	data := htmlReportData{Generated: now.Format(time.RFC1123)}
+
+	byService := make(map[common.ServiceType]*htmlReportService)
+	var services []common.ServiceType
+	for _, r := range results {
+		service := r.Recommendation.Service
+		group, ok := byService[service]
+		if !ok {
+			group = &htmlReportService{Name: getServiceDisplayName(service)}
+			byService[service] = group
+			services = append(services, service)
+		}
+		row := newHTMLReportRow(r)
+		group.Rows = append(group.Rows, row)
+		group.Count += row.Count
+		group.Savings += row.Savings
+		group.UpfrontCost += row.UpfrontCost
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i] < services[j] })
+	for _, service := range services {
+		data.Services = append(data.Services, *byService[service])
+	}
+
+	summaryServices := make([]common.ServiceType, 0, len(stats))
+	for service := range stats {
+		summaryServices = append(summaryServices, service)
+	}
+	sort.Slice(summaryServices, func(i, j int) bool { return summaryServices[i] < summaryServices[j] })
+	for _, service := range summaryServices {
+		data.Summary = append(data.Summary, htmlReportSummary{Name: getServiceDisplayName(service), Stats: stats[service]})
+		data.Total = addServiceStats(data.Total, stats[service])
+	}
+	return data
+}
+
+// writeHTMLReport writes a self-contained HTML page with per-service purchase tables and totals
+func writeHTMLReport(results []common.PurchaseResult, stats map[common.ServiceType]ServiceProcessingStats, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create HTML file: %w", err)
+	}
+	defer file.Close()
+
+	if err := htmlReportTemplate.Execute(file, buildHTMLReportData(results, stats, time.Now())); err != nil {
+		return fmt.Errorf("failed to write HTML report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package cudly
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	results := []common.PurchaseResult{
+		{
+			Recommendation: common.Recommendation{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 2, EstimatedSavings: 120.5, UpfrontCost: 1000},
+			Success:        true,
+			CommitmentID:   "ri-123",
+		},
+		{
+			Recommendation: common.Recommendation{Service: common.ServiceEC2, Region: "eu-west-1", ResourceType: "m5.large", Count: 1, EstimatedSavings: 30},
+			CommitmentID:   "ri-456",
+			Error:          errors.New("offering <m5.large> not found"),
+		},
+	}
+	stats := map[common.ServiceType]ServiceProcessingStats{
+		common.ServiceRDS: {Service: common.ServiceRDS, RecommendationsSelected: 1, InstancesProcessed: 2, SuccessfulPurchases: 1, TotalEstimatedSavings: 120.5, TotalUpfrontCost: 1000},
+		common.ServiceEC2: {Service: common.ServiceEC2, RecommendationsSelected: 1, InstancesProcessed: 1, FailedPurchases: 1, TotalEstimatedSavings: 30},
+	}
+
+	require.NoError(t, writeHTMLReport(results, stats, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	page := string(data)
+
+	assert.Contains(t, page, "<h2>RDS</h2>")
+	assert.Contains(t, page, "<h2>EC2</h2>")
+	assert.Contains(t, page, "ri-123")
+	assert.Contains(t, page, "$120.50")
+	assert.Contains(t, page, "$150.50", "the summary has a totals row")
+	assert.Contains(t, page, `<tr class="failed">`)
+	assert.Contains(t, page, "Failed: offering &lt;m5.large&gt; not found", "errors are escaped")
+	assert.NotContains(t, page, "<link", "the page is self-contained")
+}
+
+func TestWriteHTMLReportInvalidPath(t *testing.T) {
+	err := writeHTMLReport(nil, nil, filepath.Join(t.TempDir(), "missing", "report.html"))
+	assert.ErrorContains(t, err, "failed to create HTML file")
+}
+
+func TestNewHTMLReportRowStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		result common.PurchaseResult
+		want   string
+	}{
+		{name: "dry run", result: common.PurchaseResult{Success: true, DryRun: true}, want: "Dry run"},
+		{name: "purchased", result: common.PurchaseResult{Success: true}, want: "Purchased"},
+		{name: "partial", result: common.PurchaseResult{Success: true, Partial: true, RequestedCount: 5, PurchasedCount: 3}, want: "Partial (3 of 5)"},
+		{name: "failed without error", result: common.PurchaseResult{}, want: "Failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := newHTMLReportRow(tt.result)
+			assert.Equal(t, tt.want, row.Status)
+			assert.Equal(t, !tt.result.Success, row.Failed)
+		})
+	}
+}
+
+func TestBuildHTMLReportDataGroupsByService(t *testing.T) {
+	results := []common.PurchaseResult{
+		{Recommendation: common.Recommendation{Service: common.ServiceRDS, Count: 2, EstimatedSavings: 10}, Success: true},
+		{Recommendation: common.Recommendation{Service: common.ServiceEC2, Count: 1, EstimatedSavings: 5}, Success: true},
+		{Recommendation: common.Recommendation{Service: common.ServiceRDS, Count: 3, EstimatedSavings: 20, UpfrontCost: 50}, Success: true},
+	}
+
+	data := buildHTMLReportData(results, nil, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	require.Len(t, data.Services, 2)
+	assert.Equal(t, "EC2", data.Services[0].Name)
+	assert.Equal(t, "RDS", data.Services[1].Name)
+	assert.Len(t, data.Services[1].Rows, 2)
+	assert.Equal(t, 5, data.Services[1].Count)
+	assert.InDelta(t, 30, data.Services[1].Savings, 0.001)
+	assert.InDelta(t, 50, data.Services[1].UpfrontCost, 0.001)
+	assert.Empty(t, data.Summary)
+}
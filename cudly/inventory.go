@@ -0,0 +1,136 @@
+package cudly
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// savingsPlansInventoryRegion is the region Savings Plans are listed from, as they are not tied to a region
+const savingsPlansInventoryRegion = "us-east-1"
+
+// ListReservations returns the existing reservations and Savings Plans of the selected AWS services, without purchasing anything
+// Regions default to all enabled regions, narrowed by --include-regions and --exclude-regions. Region sets are expanded
+// and merged with the explicit regions.
+func ListReservations(ctx context.Context, cfg RunConfig) ([]common.Commitment, error) {
+	services := determineServicesToProcess(cfg)
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no valid services specified")
+	}
+
+	// Load AWS configuration
+	var configOptions []func(*config.LoadOptions) error
+	configOptions = append(configOptions, config.WithRegion(savingsPlansInventoryRegion))
+	if cfg.Profile != "" {
+		configOptions = append(configOptions, config.WithSharedConfigProfile(cfg.Profile))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, configOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	regions, err := common.ExpandRegionSets(cfg.Regions, cfg.RegionSets, func() ([]string, error) {
+		return getAllAWSRegions(ctx, awsCfg, cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(regions) == 0 {
+		if regions, err = getAllAWSRegions(ctx, awsCfg, cfg); err != nil {
+			return nil, err
+		}
+	}
+	selected := make([]string, 0, len(regions))
+	for _, region := range regions {
+		if shouldIncludeRegion(region, cfg) {
+			selected = append(selected, region)
+		}
+	}
+
+	return listReservations(ctx, services, selected, func(service common.ServiceType, region string) provider.ServiceClient {
+		regionCfg := awsCfg.Copy()
+		regionCfg.Region = region
+		return createServiceClient(service, regionCfg)
+	}), nil
+}
+
+// listReservations fetches the existing commitments of every service in every region, sorted by service, region and expiration
+// Regions that fail are logged and skipped. Savings Plans are listed once, as they are not tied to a region.
+func listReservations(ctx context.Context, services []common.ServiceType, regions []string, newClient func(service common.ServiceType, region string) provider.ServiceClient) []common.Commitment {
+	var commitments []common.Commitment
+	for _, service := range services {
+		serviceRegions := regions
+		if service == common.ServiceSavingsPlans {
+			serviceRegions = []string{savingsPlansInventoryRegion}
+		}
+
+		cache := prefetchExistingCommitments(ctx, serviceRegions, func(region string) provider.ServiceClient {
+			return newClient(service, region)
+		})
+		for _, region := range serviceRegions {
+			result, ok := cache.byRegion[region]
+			if !ok {
+				continue
+			}
+			if result.err != nil {
+				AppLogger.Printf("⚠️  Failed to list %s reservations in %s: %v\n", getServiceDisplayName(service), region, result.err)
+				continue
+			}
+			commitments = append(commitments, result.commitments...)
+		}
+	}
+
+	sort.SliceStable(commitments, func(i, j int) bool {
+		a, b := commitments[i], commitments[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		return a.EndDate.Before(b.EndDate)
+	})
+	return commitments
+}
+
+// commitmentTerm returns the term of a commitment in years, such as "1yr", or "-" when its dates are unknown
+func commitmentTerm(c common.Commitment) string {
+	if c.StartDate.IsZero() || c.EndDate.IsZero() {
+		return "-"
+	}
+	years := math.Round(c.EndDate.Sub(c.StartDate).Hours() / (24 * 365))
+	return fmt.Sprintf("%.0fyr", math.Max(years, 1))
+}
+
+// PrintReservationInventory prints the existing reservations with their term and expiration date
+func PrintReservationInventory(commitments []common.Commitment, now time.Time) {
+	if len(commitments) == 0 {
+		outPrintln("ℹ️  No active reservations found")
+		return
+	}
+
+	outPrintln("\n📋 Reservation inventory:")
+	outPrintf("  %-13s | %-16s | %-24s | %5s | %4s | %-10s | %9s\n", "Service", "Region", "Instance Type", "Count", "Term", "Expires", "Days Left")
+	outPrintln("  ---------------------------------------------------------------------------------------------")
+	total := 0
+	for _, c := range commitments {
+		expires, daysLeft := "-", "-"
+		if !c.EndDate.IsZero() {
+			expires = c.EndDate.Format("2006-01-02")
+			daysLeft = fmt.Sprintf("%d", int(math.Ceil(c.EndDate.Sub(now).Hours()/24)))
+		}
+		region := c.Region
+		if region == "" {
+			region = "-"
+		}
+		outPrintf("  %-13s | %-16s | %-24s | %5d | %4s | %-10s | %9s\n", getServiceDisplayName(c.Service), region, c.ResourceType, c.Count, commitmentTerm(c), expires, daysLeft)
+		total += c.Count
+	}
+	outPrintf("\n  Total: %d reservation(s) covering %d instance(s)\n", len(commitments), total)
+}
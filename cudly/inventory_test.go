@@ -0,0 +1,87 @@
+package cudly
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListReservations(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rdsEast := &MockServiceClient{}
+	rdsEast.On("GetExistingCommitments", mock.Anything).Return([]common.Commitment{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 2, EndDate: start.AddDate(3, 0, 0)},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1, EndDate: start.AddDate(1, 0, 0)},
+	}, nil)
+	rdsWest := &MockServiceClient{}
+	rdsWest.On("GetExistingCommitments", mock.Anything).Return(nil, errors.New("access denied"))
+	plans := &MockServiceClient{}
+	plans.On("GetExistingCommitments", mock.Anything).Return([]common.Commitment{
+		{Service: common.ServiceSavingsPlans, ResourceType: "Compute", Count: 1, EndDate: start.AddDate(1, 0, 0)},
+	}, nil)
+
+	var planRegions []string
+	newClient := func(service common.ServiceType, region string) provider.ServiceClient {
+		switch {
+		case service == common.ServiceSavingsPlans:
+			planRegions = append(planRegions, region)
+			return plans
+		case region == "us-east-1":
+			return rdsEast
+		default:
+			return rdsWest
+		}
+	}
+
+	commitments := listReservations(context.Background(), []common.ServiceType{common.ServiceRDS, common.ServiceSavingsPlans}, []string{"us-east-1", "us-west-2"}, newClient)
+
+	require.Len(t, commitments, 3)
+	assert.Equal(t, "db.t3.micro", commitments[0].ResourceType, "reservations expiring first are listed first")
+	assert.Equal(t, "db.r5.large", commitments[1].ResourceType)
+	assert.Equal(t, common.ServiceSavingsPlans, commitments[2].Service)
+	assert.Equal(t, []string{savingsPlansInventoryRegion}, planRegions, "Savings Plans are listed once")
+}
+
+func TestCommitmentTerm(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "1yr", commitmentTerm(common.Commitment{StartDate: start, EndDate: start.AddDate(1, 0, 0)}))
+	assert.Equal(t, "3yr", commitmentTerm(common.Commitment{StartDate: start, EndDate: start.AddDate(3, 0, 0)}))
+	assert.Equal(t, "-", commitmentTerm(common.Commitment{EndDate: start}))
+}
+
+func TestPrintReservationInventory(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintReservationInventory([]common.Commitment{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 2, StartDate: start, EndDate: start.AddDate(3, 0, 0)},
+		{Service: common.ServiceSavingsPlans, ResourceType: "Compute", Count: 1},
+	}, now)
+
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	output := buf.String()
+
+	assert.Contains(t, output, "Reservation inventory")
+	assert.Regexp(t, `RDS\s+\| us-east-1\s+\| db\.r5\.large\s+\|\s+2 \|  3yr \| 2028-01-01 \|\s+426`, output)
+	assert.Regexp(t, `Savings Plans\s+\| -\s+\| Compute\s+\|\s+1 \|    - \| -`, output)
+	assert.Contains(t, output, "Total: 2 reservation(s) covering 3 instance(s)")
+}
@@ -0,0 +1,163 @@
+package cudly
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// jsonPurchaseResult is the JSON report form of a purchase result
+// The error is rendered as a string and the type of the nested service details is recorded next to them.
+type jsonPurchaseResult struct {
+	Recommendation common.Recommendation `json:"recommendation"`
+	DetailsType    string                `json:"details_type,omitempty"`
+	CommitmentID   string                `json:"commitment_id,omitempty"`
+	Success        bool                  `json:"success"`
+	DryRun         bool                  `json:"dry_run"`
+	Error          string                `json:"error,omitempty"`
+	Cost           float64               `json:"cost"`
+	RequestedCount int                   `json:"requested_count,omitempty"`
+	PurchasedCount int                   `json:"purchased_count,omitempty"`
+	Shortfall      int                   `json:"shortfall,omitempty"`
+	Timestamp      time.Time             `json:"timestamp"`
+}
+
+// newJSONPurchaseResult converts a purchase result into its JSON report form
+func newJSONPurchaseResult(r common.PurchaseResult) jsonPurchaseResult {
+	result := jsonPurchaseResult{
+		Recommendation: r.Recommendation,
+		CommitmentID:   r.CommitmentID,
+		Success:        r.Success,
+		DryRun:         r.DryRun,
+		Cost:           r.Cost,
+		RequestedCount: r.RequestedCount,
+		PurchasedCount: r.PurchasedCount,
+		Shortfall:      r.Shortfall(),
+		Timestamp:      r.Timestamp,
+	}
+	result.DetailsType = jsonDetailsType(r.Recommendation.Details)
+	if r.Error != nil {
+		result.Error = r.Error.Error()
+	}
+	return result
+}
+
+// jsonDetailsType returns the details_type recorded for service details, such as "DatabaseDetails"
+func jsonDetailsType(details common.ServiceDetails) string {
+	if details == nil {
+		return ""
+	}
+	if name, err := common.ServiceDetailsTypeName(details); err == nil {
+		return name
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", details), "*")
+}
+
+// writeMultiServiceJSONReport writes the purchase results as a JSON array
+// An empty result set still produces a valid empty array
+func writeMultiServiceJSONReport(results []common.PurchaseResult, path string) error {
+	report := make([]jsonPurchaseResult, 0, len(results))
+	for _, r := range results {
+		report = append(report, newJSONPurchaseResult(r))
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+	return nil
+}
+
+// jsonInputRecommendation is a recommendation read back from a JSON report (--input-json)
+// Its details are decoded separately, since their concrete type is only known from details_type.
+type jsonInputRecommendation struct {
+	common.Recommendation
+	Details json.RawMessage `json:"details"`
+}
+
+// jsonInputResult is the part of a JSON report entry needed to restore its recommendation
+type jsonInputResult struct {
+	Recommendation jsonInputRecommendation `json:"recommendation"`
+	DetailsType    string                  `json:"details_type"`
+}
+
+// loadRecommendationsFromJSON reads the recommendations of a JSON report written by writeMultiServiceJSONReport
+// Unlike CSV input, the typed service details (engine, AZ config, platform, ...) are restored.
+func loadRecommendationsFromJSON(path string) ([]common.Recommendation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open JSON file: %w", err)
+	}
+	var entries []jsonInputResult
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON file %s: %w", path, err)
+	}
+
+	recommendations := make([]common.Recommendation, 0, len(entries))
+	for i, entry := range entries {
+		rec := entry.Recommendation.Recommendation
+		if entry.DetailsType != "" {
+			details, err := common.NewServiceDetails(entry.DetailsType)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: %w", i, err)
+			}
+			if err := json.Unmarshal(entry.Recommendation.Details, details); err != nil {
+				return nil, fmt.Errorf("entry %d: failed to decode %s: %w", i, entry.DetailsType, err)
+			}
+			rec.Details = details
+		}
+		recommendations = append(recommendations, rec)
+	}
+	return recommendations, nil
+}
+
+// jsonRunSummary is the machine-readable final summary printed with --json-summary
+type jsonRunSummary struct {
+	DryRun                bool                                          `json:"dry_run"`
+	Services              map[common.ServiceType]ServiceProcessingStats `json:"services"`
+	TotalRecommendations  int                                           `json:"total_recommendations"`
+	TotalInstances        int                                           `json:"total_instances"`
+	TotalEstimatedSavings float64                                       `json:"total_estimated_savings"`
+	SuccessfulPurchases   int                                           `json:"successful_purchases"`
+	FailedPurchases       int                                           `json:"failed_purchases"`
+	// SuccessRate is the percentage of successful purchases, or null when nothing was attempted
+	SuccessRate *float64 `json:"success_rate"`
+}
+
+// buildJSONRunSummary totals the per-service statistics of a run
+func buildJSONRunSummary(report *RunReport) jsonRunSummary {
+	summary := jsonRunSummary{
+		DryRun:   report.DryRun,
+		Services: make(map[common.ServiceType]ServiceProcessingStats, len(report.ServiceStats)),
+	}
+	for service, stats := range report.ServiceStats {
+		summary.Services[service] = stats
+		summary.TotalRecommendations += stats.RecommendationsSelected
+		summary.TotalInstances += stats.InstancesProcessed
+		summary.TotalEstimatedSavings += stats.TotalEstimatedSavings
+		summary.SuccessfulPurchases += stats.SuccessfulPurchases
+		summary.FailedPurchases += stats.FailedPurchases
+	}
+	if attempted := summary.SuccessfulPurchases + summary.FailedPurchases; attempted > 0 {
+		rate := float64(summary.SuccessfulPurchases) / float64(attempted) * 100
+		summary.SuccessRate = &rate
+	}
+	return summary
+}
+
+// printJSONSummary prints the run summary as a single JSON object on stdout
+func printJSONSummary(report *RunReport) error {
+	data, err := json.Marshal(buildJSONRunSummary(report))
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON summary: %w", err)
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+	return err
+}
@@ -0,0 +1,163 @@
+package cudly
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMultiServiceJSONReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	results := []common.PurchaseResult{
+		{
+			Recommendation: common.Recommendation{
+				Service:      common.ServiceRDS,
+				Region:       "us-east-1",
+				ResourceType: "db.r5.large",
+				Count:        2,
+				Details:      &common.DatabaseDetails{Engine: "postgres", AZConfig: "multi-az"},
+			},
+			Success:        true,
+			CommitmentID:   "ri-123",
+			RequestedCount: 2,
+			PurchasedCount: 1,
+			Partial:        true,
+			Timestamp:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		{
+			Recommendation: common.Recommendation{Service: common.ServiceEC2, Region: "eu-west-1", ResourceType: "m5.large", Count: 1},
+			Error:          errors.New("insufficient capacity"),
+		},
+	}
+
+	require.NoError(t, writeMultiServiceJSONReport(results, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var decoded []map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded, 2)
+
+	assert.Equal(t, "DatabaseDetails", decoded[0]["details_type"])
+	details := decoded[0]["recommendation"].(map[string]any)["details"].(map[string]any)
+	assert.Equal(t, "postgres", details["engine"])
+	assert.Equal(t, "ri-123", decoded[0]["commitment_id"])
+	assert.Equal(t, float64(1), decoded[0]["shortfall"])
+
+	assert.Equal(t, "insufficient capacity", decoded[1]["error"])
+	assert.Equal(t, false, decoded[1]["success"])
+	assert.NotContains(t, decoded[1], "details_type")
+}
+
+func TestWriteMultiServiceJSONReportEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, writeMultiServiceJSONReport(nil, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, "[]", string(data))
+}
+
+func TestBuildJSONRunSummary(t *testing.T) {
+	report := newRunReport(false)
+	report.ServiceStats[common.ServiceRDS] = ServiceProcessingStats{Service: common.ServiceRDS, RecommendationsSelected: 2, InstancesProcessed: 5, SuccessfulPurchases: 2, TotalEstimatedSavings: 100}
+	report.ServiceStats[common.ServiceEC2] = ServiceProcessingStats{Service: common.ServiceEC2, RecommendationsSelected: 1, InstancesProcessed: 3, SuccessfulPurchases: 1, FailedPurchases: 1, TotalEstimatedSavings: 50}
+
+	summary := buildJSONRunSummary(report)
+
+	assert.False(t, summary.DryRun)
+	assert.Len(t, summary.Services, 2)
+	assert.Equal(t, 3, summary.TotalRecommendations)
+	assert.Equal(t, 8, summary.TotalInstances)
+	assert.InDelta(t, 150, summary.TotalEstimatedSavings, 0.001)
+	require.NotNil(t, summary.SuccessRate)
+	assert.InDelta(t, 75, *summary.SuccessRate, 0.001)
+
+	assert.Nil(t, buildJSONRunSummary(newRunReport(true)).SuccessRate, "no purchases attempted")
+}
+
+func TestRenderRunReportJSONSummary(t *testing.T) {
+	csvPath := filepath.Join(t.TempDir(), "report.csv")
+	report := newRunReport(true)
+	rec := common.Recommendation{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1, EstimatedSavings: 12.5}
+	report.Recommendations = []common.Recommendation{rec}
+	report.Results = []common.PurchaseResult{{Recommendation: rec, Success: true, DryRun: true, Timestamp: time.Now()}}
+	report.ServiceStats[common.ServiceRDS] = calculateServiceStats(common.ServiceRDS, report.Recommendations, report.Results)
+
+	ConfigureOutput(false, true)
+	defer ConfigureOutput(false, false)
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	RenderReport(report, RunConfig{CSVOutput: csvPath, JSONSummary: true})
+
+	w.Close()
+	os.Stdout = old
+	out, _ := io.ReadAll(r)
+
+	// stdout holds nothing but the summary object
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(out, &decoded), string(out))
+	assert.Equal(t, true, decoded["dry_run"])
+	assert.Equal(t, float64(1), decoded["total_instances"])
+	rds := decoded["services"].(map[string]any)["rds"].(map[string]any)
+	assert.Equal(t, float64(1), rds["recommendations_selected"])
+
+	// The CSV report is still written
+	data, err := os.ReadFile(csvPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "db.t3.micro")
+}
+
+func TestLoadRecommendationsFromJSONRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	recs := []common.Recommendation{
+		{
+			Service:       common.ServiceRDS,
+			Region:        "us-east-1",
+			ResourceType:  "db.r5.large",
+			Count:         2,
+			Term:          "3yr",
+			PaymentOption: "partial-upfront",
+			Details:       &common.DatabaseDetails{Engine: "postgres", AZConfig: "multi-az"},
+		},
+		{Service: common.ServiceSavingsPlans, Region: "us-east-1", Count: 1, Details: &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1.5}},
+		{Service: common.ServiceEC2, Region: "eu-west-1", ResourceType: "m5.large", Count: 1},
+	}
+	results := make([]common.PurchaseResult, 0, len(recs))
+	for _, rec := range recs {
+		results = append(results, common.PurchaseResult{Recommendation: rec, DryRun: true})
+	}
+	require.NoError(t, writeMultiServiceJSONReport(results, path))
+
+	loaded, err := loadRecommendationsFromJSON(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, recs, loaded)
+}
+
+func TestLoadRecommendationsFromJSONErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := loadRecommendationsFromJSON(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "failed to open JSON file")
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte("{"), 0o644))
+	_, err = loadRecommendationsFromJSON(invalid)
+	assert.ErrorContains(t, err, "failed to parse JSON file")
+
+	unknown := filepath.Join(dir, "unknown.json")
+	require.NoError(t, os.WriteFile(unknown, []byte(`[{"recommendation":{"service":"rds","details":{}},"details_type":"QueueDetails"}]`), 0o644))
+	_, err = loadRecommendationsFromJSON(unknown)
+	assert.ErrorContains(t, err, `unknown details type "QueueDetails"`)
+}
@@ -0,0 +1,38 @@
+package cudly
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// renderMarkdownSummary renders the per-service statistics as a markdown table with a totals row
+func renderMarkdownSummary(stats map[common.ServiceType]ServiceProcessingStats) string {
+	services := make([]common.ServiceType, 0, len(stats))
+	for service := range stats {
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i] < services[j] })
+
+	var b strings.Builder
+	b.WriteString("| Service | Recommendations | Instances | Est. monthly savings | Projected term savings |\n")
+	b.WriteString("|---|---:|---:|---:|---:|\n")
+	for _, service := range services {
+		s := stats[service]
+		fmt.Fprintf(&b, "| %s | %d | %d | $%.2f | $%.2f |\n", getServiceDisplayName(service), s.RecommendationsSelected, s.InstancesProcessed, s.TotalEstimatedSavings, s.ProjectedTermSavings)
+	}
+	total := totalServiceStats(stats)
+	fmt.Fprintf(&b, "| **Total** | **%d** | **%d** | **$%.2f** | **$%.2f** |\n", total.RecommendationsSelected, total.InstancesProcessed, total.TotalEstimatedSavings, total.ProjectedTermSavings)
+	return b.String()
+}
+
+// writeMarkdownSummary writes the per-service summary as a markdown table, for pasting into tickets and pull requests
+func writeMarkdownSummary(stats map[common.ServiceType]ServiceProcessingStats, path string) error {
+	if err := os.WriteFile(path, []byte(renderMarkdownSummary(stats)), 0o644); err != nil {
+		return fmt.Errorf("failed to write markdown summary: %w", err)
+	}
+	return nil
+}
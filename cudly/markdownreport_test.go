@@ -0,0 +1,51 @@
+package cudly
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMarkdownSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+	stats := map[common.ServiceType]ServiceProcessingStats{
+		common.ServiceRDS: {Service: common.ServiceRDS, RecommendationsSelected: 2, InstancesProcessed: 5, TotalEstimatedSavings: 120.5, ProjectedTermSavings: 4338},
+		common.ServiceEC2: {Service: common.ServiceEC2, RecommendationsSelected: 1, InstancesProcessed: 3, TotalEstimatedSavings: 30, ProjectedTermSavings: 360},
+	}
+
+	require.NoError(t, writeMarkdownSummary(stats, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "| Service | Recommendations | Instances | Est. monthly savings | Projected term savings |\n"+
+		"|---|---:|---:|---:|---:|\n"+
+		"| EC2 | 1 | 3 | $30.00 | $360.00 |\n"+
+		"| RDS | 2 | 5 | $120.50 | $4338.00 |\n"+
+		"| **Total** | **3** | **8** | **$150.50** | **$4698.00** |\n", string(data))
+}
+
+func TestWriteMarkdownSummaryInvalidPath(t *testing.T) {
+	err := writeMarkdownSummary(nil, filepath.Join(t.TempDir(), "missing", "summary.md"))
+	assert.ErrorContains(t, err, "failed to write markdown summary")
+}
+
+func TestSplitAndTotalServiceStats(t *testing.T) {
+	stats := map[common.ServiceType]ServiceProcessingStats{
+		common.ServiceRDS:          {RecommendationsSelected: 2, InstancesProcessed: 5, TotalEstimatedSavings: 100},
+		common.ServiceEC2:          {RecommendationsSelected: 1, InstancesProcessed: 3, TotalEstimatedSavings: 50},
+		common.ServiceSavingsPlans: {RecommendationsSelected: 1, TotalEstimatedSavings: 80},
+	}
+
+	riStats, spStats := splitServiceStats(stats)
+
+	assert.Len(t, riStats, 2)
+	assert.Equal(t, 80.0, spStats.TotalEstimatedSavings)
+	riTotal := totalServiceStats(riStats)
+	assert.Equal(t, 3, riTotal.RecommendationsSelected)
+	assert.Equal(t, 8, riTotal.InstancesProcessed)
+	assert.Equal(t, 150.0, riTotal.TotalEstimatedSavings)
+}
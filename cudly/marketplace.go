@@ -0,0 +1,67 @@
+package cudly
+
+import (
+	"context"
+	"log"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/LeanerCloud/CUDly/providers/aws/services/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// hoursPerMonth is the average number of hours in a month used for monthly cost estimates
+const hoursPerMonth = 730
+
+// estimateMarketplaceSavings returns the additional monthly savings from buying the recommended EC2 RIs
+// on the Reserved Instance Marketplace instead of as standard offerings
+// It only feeds the RI vs Savings Plans comparison; purchases always use standard offerings
+func estimateMarketplaceSavings(ctx context.Context, awsCfg aws.Config, recs []common.Recommendation) float64 {
+	type regionClients struct {
+		standard    provider.ServiceClient
+		marketplace provider.ServiceClient
+	}
+	clients := make(map[string]regionClients)
+
+	total := 0.0
+	for _, rec := range recs {
+		if rec.Service != common.ServiceEC2 {
+			continue
+		}
+		rc, ok := clients[rec.Region]
+		if !ok {
+			regionalCfg := awsCfg.Copy()
+			regionalCfg.Region = rec.Region
+			marketplace := ec2.NewClient(regionalCfg)
+			marketplace.SetIncludeMarketplace(true)
+			rc = regionClients{standard: ec2.NewClient(regionalCfg), marketplace: marketplace}
+			clients[rec.Region] = rc
+		}
+		total += marketplaceSavingsForRec(ctx, rc.standard, rc.marketplace, rec)
+	}
+	return total
+}
+
+// addMarketplaceSavings records the estimated Marketplace savings of the report's EC2 RIs in the report
+func addMarketplaceSavings(ctx context.Context, awsCfg aws.Config, report *RunReport) {
+	report.MarketplaceSavings = estimateMarketplaceSavings(ctx, awsCfg, report.Recommendations)
+	AppLogger.Printf("🏪 Marketplace listings could save an estimated additional $%.2f/mo on EC2 RIs (comparison only, purchases use standard offerings)\n", report.MarketplaceSavings)
+}
+
+// marketplaceSavingsForRec returns how much less per month the cheapest Marketplace listing costs than the standard offering
+func marketplaceSavingsForRec(ctx context.Context, standard, marketplace provider.ServiceClient, rec common.Recommendation) float64 {
+	standardOffering, err := standard.GetOfferingDetails(ctx, rec)
+	if err != nil {
+		log.Printf("⚠️  Could not get standard offering for %s in %s: %v", rec.ResourceType, rec.Region, err)
+		return 0
+	}
+	marketplaceOffering, err := marketplace.GetOfferingDetails(ctx, rec)
+	if err != nil {
+		log.Printf("⚠️  Could not get Marketplace offerings for %s in %s: %v", rec.ResourceType, rec.Region, err)
+		return 0
+	}
+	if !marketplaceOffering.Marketplace || marketplaceOffering.EffectiveHourlyRate >= standardOffering.EffectiveHourlyRate {
+		return 0
+	}
+	return (standardOffering.EffectiveHourlyRate - marketplaceOffering.EffectiveHourlyRate) * hoursPerMonth * float64(rec.Count)
+}
@@ -0,0 +1,87 @@
+package cudly
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMarketplaceSavingsForRec(t *testing.T) {
+	rec := common.Recommendation{Service: common.ServiceEC2, ResourceType: "m5.large", Region: "us-east-1", Count: 2}
+
+	tests := []struct {
+		name           string
+		standard       *common.OfferingDetails
+		marketplace    *common.OfferingDetails
+		marketplaceErr error
+		expected       float64
+	}{
+		{
+			name:        "cheaper marketplace listing",
+			standard:    &common.OfferingDetails{EffectiveHourlyRate: 0.06},
+			marketplace: &common.OfferingDetails{EffectiveHourlyRate: 0.05, Marketplace: true},
+			expected:    0.01 * hoursPerMonth * 2,
+		},
+		{
+			name:        "standard offering is cheapest",
+			standard:    &common.OfferingDetails{EffectiveHourlyRate: 0.06},
+			marketplace: &common.OfferingDetails{EffectiveHourlyRate: 0.06},
+		},
+		{
+			name:           "marketplace lookup fails",
+			standard:       &common.OfferingDetails{EffectiveHourlyRate: 0.06},
+			marketplaceErr: errors.New("throttled"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standard := &MockServiceClient{}
+			standard.On("GetOfferingDetails", mock.Anything, rec).Return(tt.standard, nil)
+			marketplace := &MockServiceClient{}
+			if tt.marketplaceErr != nil {
+				marketplace.On("GetOfferingDetails", mock.Anything, rec).Return(nil, tt.marketplaceErr)
+			} else {
+				marketplace.On("GetOfferingDetails", mock.Anything, rec).Return(tt.marketplace, nil)
+			}
+
+			savings := marketplaceSavingsForRec(context.Background(), standard, marketplace, rec)
+			assert.InDelta(t, tt.expected, savings, 0.0001)
+		})
+	}
+}
+
+func TestPrintMultiServiceSummary_MarketplaceSavings(t *testing.T) {
+	recs := []common.Recommendation{
+		{Service: common.ServiceEC2, Count: 2, EstimatedSavings: 100},
+		{Service: common.ServiceSavingsPlans, Count: 1, EstimatedSavings: 110, Details: common.SavingsPlanDetails{PlanType: "Compute"}},
+	}
+	stats := map[common.ServiceType]ServiceProcessingStats{
+		common.ServiceEC2:          {RecommendationsSelected: 1, InstancesProcessed: 2, TotalEstimatedSavings: 100},
+		common.ServiceSavingsPlans: {RecommendationsSelected: 1, InstancesProcessed: 1, TotalEstimatedSavings: 110},
+	}
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	printMultiServiceSummary(recs, nil, stats, true, 25)
+
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	output := buf.String()
+
+	assert.Contains(t, output, "Total monthly savings: $125.00")
+	assert.Contains(t, output, "Includes $25.00/mo from Reserved Instance Marketplace listings")
+	assert.Contains(t, output, "RECOMMENDATION: Use Option 1 (saves $15.00/mo more)")
+}
@@ -0,0 +1,55 @@
+package cudly
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// renderMetrics renders the per-service statistics of a run in the Prometheus text exposition format
+func renderMetrics(stats map[common.ServiceType]ServiceProcessingStats, isDryRun bool, now time.Time) string {
+	services := make([]common.ServiceType, 0, len(stats))
+	for service := range stats {
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i] < services[j] })
+
+	var b strings.Builder
+	gauge := func(name, help string, value func(ServiceProcessingStats) float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, service := range services {
+			fmt.Fprintf(&b, "%s{service=%q} %g\n", name, string(service), value(stats[service]))
+		}
+	}
+	gauge("cudly_recommendations_total", "Recommendations selected for purchase.", func(s ServiceProcessingStats) float64 { return float64(s.RecommendationsSelected) })
+	gauge("cudly_instances_total", "Instances of the selected recommendations.", func(s ServiceProcessingStats) float64 { return float64(s.InstancesProcessed) })
+	gauge("cudly_purchases_success_total", "Successful purchases, or simulated purchases in a dry run.", func(s ServiceProcessingStats) float64 { return float64(s.SuccessfulPurchases) })
+	gauge("cudly_purchases_failed_total", "Failed purchases.", func(s ServiceProcessingStats) float64 { return float64(s.FailedPurchases) })
+	gauge("cudly_estimated_monthly_savings", "Estimated monthly savings in USD of the selected recommendations.", func(s ServiceProcessingStats) float64 { return s.TotalEstimatedSavings })
+
+	dryRun := 0
+	if isDryRun {
+		dryRun = 1
+	}
+	fmt.Fprintf(&b, "# HELP cudly_dry_run Whether the last run was a dry run.\n# TYPE cudly_dry_run gauge\ncudly_dry_run %d\n", dryRun)
+	fmt.Fprintf(&b, "# HELP cudly_last_run_timestamp_seconds Unix time the last run completed.\n# TYPE cudly_last_run_timestamp_seconds gauge\ncudly_last_run_timestamp_seconds %d\n", now.Unix())
+	return b.String()
+}
+
+// writeMetricsFile writes the run metrics for the node exporter textfile collector
+// The file is written next to its destination and renamed into place, so the collector never reads a partial file.
+func writeMetricsFile(stats map[common.ServiceType]ServiceProcessingStats, isDryRun bool, path string) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(renderMetrics(stats, isDryRun, time.Now())), 0o644); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	return nil
+}
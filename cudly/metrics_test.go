@@ -0,0 +1,50 @@
+package cudly
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMetrics(t *testing.T) {
+	stats := map[common.ServiceType]ServiceProcessingStats{
+		common.ServiceRDS: {RecommendationsSelected: 2, InstancesProcessed: 5, SuccessfulPurchases: 2, TotalEstimatedSavings: 120.5},
+		common.ServiceEC2: {RecommendationsSelected: 1, InstancesProcessed: 3, FailedPurchases: 1, TotalEstimatedSavings: 30},
+	}
+
+	metrics := renderMetrics(stats, true, time.Unix(1700000000, 0))
+
+	assert.Contains(t, metrics, "# TYPE cudly_recommendations_total gauge\n"+
+		"cudly_recommendations_total{service=\"ec2\"} 1\n"+
+		"cudly_recommendations_total{service=\"rds\"} 2\n")
+	assert.Contains(t, metrics, "cudly_instances_total{service=\"rds\"} 5\n")
+	assert.Contains(t, metrics, "cudly_purchases_success_total{service=\"rds\"} 2\n")
+	assert.Contains(t, metrics, "cudly_purchases_failed_total{service=\"ec2\"} 1\n")
+	assert.Contains(t, metrics, "cudly_estimated_monthly_savings{service=\"rds\"} 120.5\n")
+	assert.Contains(t, metrics, "cudly_dry_run 1\n")
+	assert.Contains(t, metrics, "cudly_last_run_timestamp_seconds 1700000000\n")
+}
+
+func TestWriteMetricsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cudly.prom")
+	stats := map[common.ServiceType]ServiceProcessingStats{
+		common.ServiceRDS: {RecommendationsSelected: 2},
+	}
+
+	require.NoError(t, writeMetricsFile(stats, false, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "cudly_dry_run 0\n")
+	assert.NoFileExists(t, path+".tmp")
+}
+
+func TestWriteMetricsFileInvalidPath(t *testing.T) {
+	err := writeMetricsFile(nil, true, filepath.Join(t.TempDir(), "missing", "cudly.prom"))
+	assert.ErrorContains(t, err, "failed to write metrics file")
+}
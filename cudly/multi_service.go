@@ -0,0 +1,3244 @@
+package cudly
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+	awsprovider "github.com/LeanerCloud/CUDly/providers/aws"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	awsec2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	awsrds "github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// EC2ClientInterface defines the interface for EC2 operations
+type EC2ClientInterface interface {
+	DescribeRegions(ctx context.Context, params *awsec2.DescribeRegionsInput, optFns ...func(*awsec2.Options)) (*awsec2.DescribeRegionsOutput, error)
+}
+
+// ServiceProcessingStats holds statistics for each service
+type ServiceProcessingStats struct {
+	Service                 common.ServiceType `json:"service"`
+	RegionsProcessed        int                `json:"regions_processed"`
+	RecommendationsFound    int                `json:"recommendations_found"`
+	RecommendationsSelected int                `json:"recommendations_selected"`
+	InstancesProcessed      int                `json:"instances_processed"`
+	SuccessfulPurchases     int                `json:"successful_purchases"`
+	FailedPurchases         int                `json:"failed_purchases"`
+	PartialPurchases        int                `json:"partial_purchases"`
+	InstancesShortfall      int                `json:"instances_shortfall"`
+	TotalEstimatedSavings   float64            `json:"total_estimated_savings"`
+	// TotalUpfrontCost is the cash paid at purchase time, TotalAmortizedMonthlyCost its monthly equivalent over the term
+	TotalUpfrontCost          float64 `json:"total_upfront_cost"`
+	TotalAmortizedMonthlyCost float64 `json:"total_amortized_monthly_cost"`
+	// ProjectedTermSavings is the estimated savings over the full term of each recommendation
+	ProjectedTermSavings float64 `json:"projected_term_savings"`
+	// TotalHourlyCommitment is the hourly commitment of the Savings Plan recommendations, after coverage
+	TotalHourlyCommitment float64 `json:"total_hourly_commitment,omitempty"`
+}
+
+// RunReport captures the outcome of a processing run so it can be rendered by the CLI or consumed by library callers
+type RunReport struct {
+	DryRun          bool
+	Recommendations []common.Recommendation
+	Results         []common.PurchaseResult
+	ServiceStats    map[common.ServiceType]ServiceProcessingStats
+	// MarketplaceSavings is the estimated extra monthly savings from Marketplace EC2 RIs (--include-marketplace-savings)
+	MarketplaceSavings float64
+	// Errors holds non-fatal errors encountered during the run, such as failed purchases
+	Errors []error
+	// WrittenReports lists the report files written by RenderReport
+	WrittenReports []string
+}
+
+// newRunReport creates an empty run report for the given mode
+func newRunReport(isDryRun bool) *RunReport {
+	return &RunReport{
+		DryRun:          isDryRun,
+		Recommendations: make([]common.Recommendation, 0),
+		Results:         make([]common.PurchaseResult, 0),
+		ServiceStats:    make(map[common.ServiceType]ServiceProcessingStats),
+		Errors:          make([]error, 0),
+	}
+}
+
+// addService adds the recommendations and purchase results of a processed service to the report and prints its summary
+func (r *RunReport) addService(service common.ServiceType, recs []common.Recommendation, results []common.PurchaseResult) {
+	r.Recommendations = append(r.Recommendations, recs...)
+	r.Results = append(r.Results, results...)
+
+	stats := calculateServiceStats(service, recs, results)
+	r.ServiceStats[service] = stats
+	printServiceSummary(service, stats)
+}
+
+// collectResultErrors records the errors of failed purchase results in the report
+func (r *RunReport) collectResultErrors() {
+	for _, result := range r.Results {
+		if !result.Success && result.Error != nil {
+			r.Errors = append(r.Errors, fmt.Errorf("%s: %w", result.CommitmentID, result.Error))
+		}
+	}
+}
+
+// RenderReport writes the configured reports (CSV, JSON, AWS CLI script, HTML, metrics) and prints the final summary of a completed run
+func RenderReport(report *RunReport, cfg RunConfig) {
+	if cfg.RecommendationsOnly {
+		// Export the recommendations themselves, as there are no purchase results
+		recsOutput := generateCSVFilename(report.DryRun, cfg)
+		if err := writeRecommendationsExport(report.Recommendations, recsOutput, cfg.OutputFormat); err != nil {
+			log.Printf("Warning: Failed to write recommendations: %v", err)
+		} else {
+			AppLogger.Printf("\n📋 Recommendations written to: %s\n", recsOutput)
+			report.WrittenReports = append(report.WrittenReports, recsOutput)
+		}
+	} else if cfg.OutputFormat == OutputFormatAWSCLI {
+		// Write a reviewable AWS CLI purchase script instead of the CSV report
+		scriptOutput := generateAWSCLIScriptFilename(cfg)
+		if err := writeAWSCLIScript(report.Results, scriptOutput); err != nil {
+			log.Printf("Warning: Failed to write AWS CLI script: %v", err)
+		} else {
+			AppLogger.Printf("\n📋 AWS CLI script written to: %s\n", scriptOutput)
+			report.WrittenReports = append(report.WrittenReports, scriptOutput)
+		}
+	} else if cfg.OutputFormat == OutputFormatJSON {
+		jsonOutput := generateCSVFilename(report.DryRun, cfg)
+		if err := writeMultiServiceJSONReport(report.Results, jsonOutput); err != nil {
+			log.Printf("Warning: Failed to write JSON output: %v", err)
+		} else {
+			AppLogger.Printf("\n📋 JSON report written to: %s\n", jsonOutput)
+			report.WrittenReports = append(report.WrittenReports, jsonOutput)
+		}
+	} else {
+		// Generate CSV filename
+		finalCSVOutput := generateCSVFilename(report.DryRun, cfg)
+
+		// Write CSV report
+		if err := writeMultiServiceCSVReport(report.Results, finalCSVOutput); err != nil {
+			log.Printf("Warning: Failed to write CSV output: %v", err)
+		} else {
+			AppLogger.Printf("\n📋 CSV report written to: %s\n", finalCSVOutput)
+			report.WrittenReports = append(report.WrittenReports, finalCSVOutput)
+		}
+	}
+
+	if cfg.HTMLOutput != "" {
+		htmlOutput := outputPath(cfg, cfg.HTMLOutput)
+		if err := writeHTMLReport(report.Results, report.ServiceStats, htmlOutput); err != nil {
+			log.Printf("Warning: Failed to write HTML report: %v", err)
+		} else {
+			AppLogger.Printf("📋 HTML report written to: %s\n", htmlOutput)
+			report.WrittenReports = append(report.WrittenReports, htmlOutput)
+		}
+	}
+
+	if cfg.MarkdownOutput != "" {
+		markdownOutput := outputPath(cfg, cfg.MarkdownOutput)
+		if err := writeMarkdownSummary(report.ServiceStats, markdownOutput); err != nil {
+			log.Printf("Warning: Failed to write markdown summary: %v", err)
+		} else {
+			AppLogger.Printf("📋 Markdown summary written to: %s\n", markdownOutput)
+			report.WrittenReports = append(report.WrittenReports, markdownOutput)
+		}
+	}
+
+	if cfg.MetricsFile != "" {
+		metricsOutput := outputPath(cfg, cfg.MetricsFile)
+		if err := writeMetricsFile(report.ServiceStats, report.DryRun, metricsOutput); err != nil {
+			log.Printf("Warning: Failed to write metrics file: %v", err)
+		} else {
+			AppLogger.Printf("📈 Metrics written to: %s\n", metricsOutput)
+		}
+	}
+
+	// Print final summary
+	if cfg.JSONSummary {
+		if err := printJSONSummary(report); err != nil {
+			log.Printf("Warning: Failed to print JSON summary: %v", err)
+		}
+		return
+	}
+	printMultiServiceSummary(report.Recommendations, report.Results, report.ServiceStats, report.DryRun, report.MarketplaceSavings)
+	printTermComparison(report.Recommendations)
+	if cfg.GroupBy == GroupByAccount {
+		printAccountSummary(report.Recommendations)
+	}
+}
+
+// determineServicesToProcess returns the list of services to process based on flags
+func determineServicesToProcess(cfg RunConfig) []common.ServiceType {
+	if cfg.AllServices {
+		return getAllServices()
+	}
+	if len(cfg.Services) > 0 {
+		return parseServices(cfg.Services)
+	}
+	// Default to RDS only for backward compatibility
+	return []common.ServiceType{common.ServiceRDS}
+}
+
+// printRunMode prints the current run mode (dry run or purchase)
+func printRunMode(isDryRun bool) {
+	if isDryRun {
+		AppLogger.Println("🔍 DRY RUN MODE - No actual purchases will be made")
+	} else {
+		AppLogger.Println("💰 PURCHASE MODE - Reserved Instances will be purchased")
+	}
+}
+
+// printPaymentAndTerm prints the payment option and term information
+func printPaymentAndTerm(cfg RunConfig) {
+	if len(cfg.Terms) > 1 {
+		terms := make([]string, 0, len(cfg.Terms))
+		for _, years := range cfg.Terms {
+			terms = append(terms, strconv.Itoa(years))
+		}
+		AppLogger.Printf("💳 Payment option: %s, Terms: %s year(s)\n", cfg.PaymentOption, strings.Join(terms, ", "))
+		return
+	}
+	AppLogger.Printf("💳 Payment option: %s, Term: %d year(s)\n", cfg.PaymentOption, cfg.TermYears)
+}
+
+// generateCSVFilename generates a report filename based on the mode, timestamp and output format
+func generateCSVFilename(isDryRun bool, cfg RunConfig) string {
+	if cfg.CSVOutput != "" {
+		return outputPath(cfg, cfg.CSVOutput)
+	}
+	timestamp := time.Now().Format("20060102-150405")
+	mode := "dryrun"
+	if cfg.RecommendationsOnly {
+		mode = "recommendations"
+	} else if !isDryRun {
+		mode = "purchase"
+	}
+	extension := "csv"
+	if cfg.OutputFormat == OutputFormatJSON {
+		extension = "json"
+	}
+	return outputPath(cfg, fmt.Sprintf("ri-helper-%s-%s.%s", mode, timestamp, extension))
+}
+
+// runToolMultiService fetches recommendations for all selected services and processes purchases
+// A nil report with a nil error means there was nothing to process
+func runToolMultiService(ctx context.Context, cfg RunConfig) (*RunReport, error) {
+	// The configuration was validated by Run
+	warnRDSNoUpfrontThreeYear(cfg)
+
+	// Check if we're using CSV or JSON input mode
+	if cfg.CSVInput != "" && cfg.JSONInput != "" {
+		return nil, fmt.Errorf("--input-csv and --input-json cannot be combined")
+	}
+	if cfg.CSVInput != "" || cfg.JSONInput != "" {
+		return runToolFromCSV(ctx, cfg)
+	}
+
+	// Check if we're purchasing fixed Savings Plan commitments
+	if len(cfg.SPCommitments) > 0 {
+		return runToolSPCommitments(ctx, cfg)
+	}
+
+	// Process each cloud provider, AWS unless --providers says otherwise
+	providers := cfg.Providers
+	if len(providers) == 0 {
+		providers = []string{ProviderAWS}
+	}
+	var report *RunReport
+	for _, name := range providers {
+		var providerReport *RunReport
+		var err error
+		if name == ProviderAWS {
+			providerReport, err = runToolAWS(ctx, cfg)
+		} else {
+			providerReport, err = runToolCloudProvider(ctx, cfg, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		report = mergeRunReports(report, providerReport)
+	}
+
+	// Live recommendations are only known once processed, so their guards apply to dry runs (see RunConfig.Validate)
+	var planned []common.Recommendation
+	if report != nil {
+		planned = plannedRecommendations(report.Results)
+	}
+	if err := checkExpectations(planned, cfg); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// runToolAWS fetches and processes the Cost Explorer recommendations of the selected AWS services
+func runToolAWS(ctx context.Context, cfg RunConfig) (*RunReport, error) {
+	// Determine services to process
+	servicesToProcess := determineServicesToProcess(cfg)
+
+	if len(servicesToProcess) == 0 {
+		return nil, fmt.Errorf("no valid services specified")
+	}
+
+	// Determine if this is a dry run (cache-only mode always is)
+	isDryRun := !cfg.ActualPurchase || cfg.CacheOnly
+	printRunMode(isDryRun)
+
+	AppLogger.Printf("📊 Processing services: %s\n", formatServices(servicesToProcess))
+	printPaymentAndTerm(cfg)
+
+	// Load AWS configuration
+	var configOptions []func(*config.LoadOptions) error
+	configOptions = append(configOptions, config.WithRegion("us-east-1"))
+	if cfg.Profile != "" {
+		configOptions = append(configOptions, config.WithSharedConfigProfile(cfg.Profile))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, configOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	// Load the upfront budget and the deferred queue of the previous run, shared by all accounts
+	budget, err := loadUpfrontBudget(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var report *RunReport
+	if len(cfg.AccountRoles) > 0 {
+		report = processAWSAccounts(ctx, awsCfg, sts.NewFromConfig(awsCfg), servicesToProcess, budget, isDryRun, cfg)
+	} else {
+		report, err = processAWSServices(ctx, awsCfg, servicesToProcess, budget, isDryRun, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	report.collectResultErrors()
+	warnCommitmentOverlap(report.Recommendations)
+	finishUpfrontBudget(budget, cfg, isDryRun)
+
+	// Estimate Marketplace savings for the comparison if requested
+	if cfg.IncludeMarketplaceSavings {
+		addMarketplaceSavings(ctx, awsCfg, report)
+	}
+
+	// Publish purchase events to EventBridge if requested
+	if cfg.EventBridgeBus != "" {
+		NewEventBridgePublisher(awsCfg, cfg.EventBridgeBus).PublishResults(context.WithoutCancel(ctx), report.Results, isDryRun)
+	}
+
+	return report, nil
+}
+
+// processAWSServices runs the service loop of the selected AWS services with the given credentials
+func processAWSServices(ctx context.Context, awsCfg aws.Config, servicesToProcess []common.ServiceType, budget *upfrontBudget, isDryRun bool, cfg RunConfig) (*RunReport, error) {
+	// Create account alias cache for lookup (cache-only runs make no Organizations calls)
+	var accountCache *AccountAliasCache
+	if !cfg.CacheOnly {
+		accountCache = newAccountAliasCache(awsCfg, cfg)
+		defer saveAccountAliasCache(accountCache, cfg)
+	}
+
+	// Create recommendations client, backed by the on-disk cache if configured
+	recClient := awsprovider.NewRecommendationsClientWithRetries(awsCfg, cfg.PerRegionRateLimit, cfg.APIRetries, cfg.APIRetryDelay)
+	if cfg.CacheDir != "" && cfg.NoCache {
+		AppLogger.Printf("🔄 --no-cache: fetching fresh recommendations instead of reading %s\n", cfg.CacheDir)
+	} else if cfg.CacheDir != "" {
+		cache := provider.NewRecommendationCache(cfg.CacheDir, cfg.CacheTTL)
+		recClient = provider.NewCachingRecommendationsClient(recClient, cache, cfg.CacheOnly)
+		if cfg.CacheOnly {
+			AppLogger.Printf("📦 Cache-only mode: reading recommendations exclusively from %s\n", cfg.CacheDir)
+		}
+	}
+
+	return processServices(ctx, awsCfg, recClient, accountCache, servicesToProcess, budget, isDryRun, cfg)
+}
+
+// processServices processes each service in turn, or up to --service-concurrency services at a time
+// Purchase runs only process services concurrently with --allow-concurrent-purchase.
+func processServices(ctx context.Context, awsCfg aws.Config, recClient provider.RecommendationsClient, accountCache *AccountAliasCache, servicesToProcess []common.ServiceType, budget *upfrontBudget, isDryRun bool, cfg RunConfig) (*RunReport, error) {
+	if cfg.ServiceConcurrency > 1 && len(servicesToProcess) > 1 && (isDryRun || cfg.AllowConcurrentPurchase) {
+		return processServicesConcurrently(ctx, awsCfg, recClient, accountCache, servicesToProcess, budget, isDryRun, cfg)
+	}
+
+	report := newRunReport(isDryRun)
+	for i, service := range servicesToProcess {
+		if err := ctx.Err(); err != nil {
+			log.Printf("⏹️  Run interrupted (%v): skipping %s", err, formatServices(servicesToProcess[i:]))
+			break
+		}
+		AppLogger.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+		AppLogger.Printf("🎯 Processing %s\n", getServiceDisplayName(service))
+		AppLogger.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+
+		// Avoid buying RIs and Savings Plans for the same usage if requested
+		serviceCfg, ok := doubleCommitGuard(service, report.Recommendations, cfg)
+		if !ok {
+			continue
+		}
+
+		// Process all services with common interface
+		serviceRecs, serviceResults, err := processService(ctx, awsCfg, recClient, accountCache, budget, service, isDryRun, serviceCfg)
+		if err != nil {
+			return nil, err
+		}
+		report.addService(service, serviceRecs, serviceResults)
+	}
+	return report, nil
+}
+
+// serviceOutcome is the outcome of processing one service of a concurrent run
+type serviceOutcome struct {
+	recs    []common.Recommendation
+	results []common.PurchaseResult
+	err     error
+	skipped bool
+}
+
+// processServicesConcurrently processes up to --service-concurrency services at a time
+// Each service collects its outcome independently; the outcomes are merged in service order once all services
+// finish, so the report matches the one of a sequential run. The first failed service fails the run.
+func processServicesConcurrently(ctx context.Context, awsCfg aws.Config, recClient provider.RecommendationsClient, accountCache *AccountAliasCache, servicesToProcess []common.ServiceType, budget *upfrontBudget, isDryRun bool, cfg RunConfig) (*RunReport, error) {
+	AppLogger.Printf("⚡ Processing %d services with up to %d at a time\n", len(servicesToProcess), cfg.ServiceConcurrency)
+
+	outcomes := make([]serviceOutcome, len(servicesToProcess))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, cfg.ServiceConcurrency)
+	for i, service := range servicesToProcess {
+		wg.Add(1)
+		go func(i int, service common.ServiceType) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				outcomes[i].skipped = true
+				return
+			}
+			AppLogger.Printf("🎯 Processing %s\n", getServiceDisplayName(service))
+			outcomes[i].recs, outcomes[i].results, outcomes[i].err = processService(ctx, awsCfg, recClient, accountCache, budget, service, isDryRun, cfg)
+		}(i, service)
+	}
+	wg.Wait()
+
+	report := newRunReport(isDryRun)
+	var skipped []common.ServiceType
+	for i, service := range servicesToProcess {
+		outcome := outcomes[i]
+		if outcome.err != nil {
+			return nil, fmt.Errorf("%s: %w", getServiceDisplayName(service), outcome.err)
+		}
+		if outcome.skipped {
+			skipped = append(skipped, service)
+			continue
+		}
+		report.addService(service, outcome.recs, outcome.results)
+	}
+	if len(skipped) > 0 {
+		log.Printf("⏹️  Run interrupted (%v): skipped %s", ctx.Err(), formatServices(skipped))
+	}
+	return report, nil
+}
+
+// processAWSAccounts runs the service loop once per --accounts-roles entry, with the credentials of the assumed role
+// An account that fails is logged and recorded in the report errors, and the remaining accounts are still processed.
+func processAWSAccounts(ctx context.Context, baseCfg aws.Config, stsClient stscreds.AssumeRoleAPIClient, servicesToProcess []common.ServiceType, budget *upfrontBudget, isDryRun bool, cfg RunConfig) *RunReport {
+	report := newRunReport(isDryRun)
+	for _, entry := range cfg.AccountRoles {
+		if ctx.Err() != nil {
+			break
+		}
+		// The entries were validated by RunConfig.Validate
+		role, err := common.ParseAccountRole(entry)
+		if err != nil {
+			report.Errors = append(report.Errors, err)
+			continue
+		}
+
+		AppLogger.Printf("\n🔑 Processing account %s (assuming %s)\n", role.AccountID, role.RoleARN)
+		accountCfg := awsprovider.AssumeRoleConfig(baseCfg, stsClient, role.RoleARN)
+		accountReport, err := processAWSServices(ctx, accountCfg, servicesToProcess, budget, isDryRun, cfg)
+		if err != nil {
+			AppLogger.Printf("❌ Failed to process account %s: %v\n", role.AccountID, err)
+			report.Errors = append(report.Errors, fmt.Errorf("account %s: %w", role.AccountID, err))
+			continue
+		}
+		for i := range accountReport.Results {
+			accountReport.Results[i].AccountID = role.AccountID
+		}
+		report = mergeRunReports(report, accountReport)
+	}
+	return report
+}
+
+// determineCSVCoverage determines the coverage percentage to use for CSV mode
+func determineCSVCoverage(cfg RunConfig) float64 {
+	// When using CSV input, default to 100% coverage (use exact numbers from CSV)
+	// unless user explicitly provided a different coverage value
+	if cfg.Coverage == 80.0 {
+		// User didn't override the default, so use 100% for CSV mode
+		return 100.0
+	}
+	return cfg.Coverage
+}
+
+// csvColumnAliases maps normalized CSV header names to the columns they hold, including the legacy
+// "Instance Type", "Instance Count", "Account ID" and "Term (months)" headers
+var csvColumnAliases = map[string]string{
+	"service":          "Service",
+	"region":           "Region",
+	"resourcetype":     "ResourceType",
+	"instancetype":     "ResourceType",
+	"count":            "Count",
+	"instancecount":    "Count",
+	"account":          "Account",
+	"accountid":        "Account",
+	"accountname":      "AccountName",
+	"term":             "Term",
+	"term(months)":     "TermMonths",
+	"paymentoption":    "PaymentOption",
+	"estimatedsavings": "EstimatedSavings",
+}
+
+// csvColumnName returns the column a CSV header holds, ignoring case, spaces and underscores
+func csvColumnName(header string) (string, bool) {
+	key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header, "\ufeff")))
+	key = strings.NewReplacer(" ", "", "_", "").Replace(key)
+	column, ok := csvColumnAliases[key]
+	return column, ok
+}
+
+// loadRecommendationsFromCSV reads and returns recommendations from a CSV file
+// Malformed rows fail the whole file rather than silently truncating it.
+func loadRecommendationsFromCSV(csvPath string) ([]common.Recommendation, error) {
+	file, err := os.Open(csvPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+
+	// Read header
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	// Build column index map, keeping the first of repeated columns
+	colIdx := make(map[string]int)
+	for i, col := range header {
+		if column, ok := csvColumnName(col); ok {
+			if _, exists := colIdx[column]; !exists {
+				colIdx[column] = i
+			}
+		}
+	}
+	field := func(record []string, column string) (string, bool) {
+		idx, ok := colIdx[column]
+		if !ok || idx >= len(record) {
+			return "", false
+		}
+		return strings.TrimSpace(record[idx]), true
+	}
+
+	var recommendations []common.Recommendation
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row: %w", err)
+		}
+
+		rec := common.Recommendation{}
+
+		// Parse fields from CSV
+		if value, ok := field(record, "Service"); ok {
+			rec.Service = common.ServiceType(value)
+		}
+		if value, ok := field(record, "Region"); ok {
+			rec.Region = value
+		}
+		if value, ok := field(record, "ResourceType"); ok {
+			rec.ResourceType = value
+		}
+		if value, ok := field(record, "Count"); ok && value != "" {
+			count, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, invalidCSVValue(reader, header[colIdx["Count"]], value)
+			}
+			rec.Count = count
+		}
+		if value, ok := field(record, "Account"); ok {
+			rec.Account = value
+		}
+		if value, ok := field(record, "AccountName"); ok {
+			rec.AccountName = value
+		}
+		if value, ok := field(record, "Term"); ok {
+			rec.Term = value
+		} else if value, ok := field(record, "TermMonths"); ok {
+			var months int
+			if _, err := fmt.Sscanf(value, "%d", &months); err == nil && months > 0 {
+				rec.Term = termString(months / 12)
+			}
+		}
+		if value, ok := field(record, "PaymentOption"); ok {
+			// Accept the spellings of --payment, such as "All Upfront" in legacy files
+			if option, err := normalizePaymentOption(value); err == nil {
+				value = option
+			}
+			rec.PaymentOption = value
+		}
+		if value, ok := field(record, "EstimatedSavings"); ok && value != "" {
+			savings, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, invalidCSVValue(reader, header[colIdx["EstimatedSavings"]], value)
+			}
+			rec.EstimatedSavings = savings
+		}
+
+		recommendations = append(recommendations, rec)
+	}
+
+	return recommendations, nil
+}
+
+// invalidCSVValue returns the error for a malformed value in a column of the row last read
+func invalidCSVValue(reader *csv.Reader, column, value string) error {
+	line, _ := reader.FieldPos(0)
+	return fmt.Errorf("invalid %s %q in CSV row at line %d", strings.TrimSpace(column), value, line)
+}
+
+// filterAndAdjustRecommendations applies filters, coverage, count override, and instance limits to recommendations
+func filterAndAdjustRecommendations(recommendations []common.Recommendation, csvModeCoverage float64, cfg RunConfig) []common.Recommendation {
+	instanceVersions, versionInfo := make(map[string][]InstanceEngineVersion), make(map[string]MajorEngineVersionInfo)
+	if slices.ContainsFunc(recommendations, func(rec common.Recommendation) bool { return rec.Service == common.ServiceRDS }) {
+		instanceVersions, versionInfo = loadEngineVersionInfo(context.Background(), cfg, recommendationRegions(recommendations))
+	}
+	return adjustRecommendations(recommendations, csvModeCoverage, cfg, instanceVersions, versionInfo)
+}
+
+// adjustRecommendations applies the filters, coverage, overrides, sorting and limits to recommendations of all regions
+func adjustRecommendations(recommendations []common.Recommendation, csvModeCoverage float64, cfg RunConfig, instanceVersions map[string][]InstanceEngineVersion, versionInfo map[string]MajorEngineVersionInfo) []common.Recommendation {
+	// Apply filters (empty currentRegion since we're processing from CSV, not iterating regions)
+	originalCount := len(recommendations)
+	recommendations = applyFilters(recommendations, cfg, instanceVersions, versionInfo, "")
+	if len(recommendations) < originalCount {
+		AppLogger.Printf("🔍 After filters: %d recommendations (filtered out %d)\n", len(recommendations), originalCount-len(recommendations))
+	}
+	recommendations = applyEC2Scope(recommendations, cfg.EC2Scope, "")
+	recommendations = mergeDuplicateRecommendations(recommendations, "")
+
+	// Apply coverage if not 100%
+	if csvModeCoverage < 100 {
+		beforeCoverage := len(recommendations)
+		recommendations = applyCommonCoverage(recommendations, csvModeCoverage, cfg.CoverageRounding)
+		AppLogger.Printf("📈 Applying %.1f%% coverage: %d recommendations selected (from %d)\n", csvModeCoverage, len(recommendations), beforeCoverage)
+	}
+
+	// Drop recommendations that aren't worth the commitment
+	if cfg.MinMonthlySavings > 0 {
+		var removed int
+		recommendations, removed = filterByMinMonthlySavings(recommendations, cfg.MinMonthlySavings)
+		if removed > 0 {
+			AppLogger.Printf("🔍 Min monthly savings: filtered out %d recommendations below $%.2f/mo\n", removed, cfg.MinMonthlySavings)
+		}
+	}
+
+	// Apply count override if specified
+	if cfg.OverrideCount > 0 {
+		recommendations = ApplyCountOverride(recommendations, cfg.OverrideCount)
+	}
+
+	// Order recommendations so that limits keep the most valuable ones
+	recommendations = SortRecommendations(recommendations, cfg.SortBy)
+
+	// Apply instance limit if specified
+	if cfg.MaxInstances > 0 {
+		beforeLimit := len(recommendations)
+		recommendations = ApplyInstanceLimit(recommendations, cfg.MaxInstances)
+		if len(recommendations) < beforeLimit {
+			AppLogger.Printf("🔒 Applied instance limit: %d recommendations after limiting to %d instances\n", len(recommendations), cfg.MaxInstances)
+		}
+	}
+
+	// Apply monthly spend limit if specified
+	if cfg.MaxMonthlySpend > 0 {
+		beforeLimit := len(recommendations)
+		recommendations = ApplySpendLimit(recommendations, cfg.MaxMonthlySpend)
+		if len(recommendations) < beforeLimit {
+			AppLogger.Printf("💵 Applied monthly spend limit: %d recommendations after limiting to $%.2f/mo\n", len(recommendations), cfg.MaxMonthlySpend)
+		}
+	}
+
+	return recommendations
+}
+
+// mergeDuplicateRecommendations merges identical recommendations, logging how many were merged with the given indent
+func mergeDuplicateRecommendations(recs []common.Recommendation, indent string) []common.Recommendation {
+	merged := common.MergeDuplicateRecommendations(recs)
+	if len(merged) < len(recs) {
+		AppLogger.Printf("%s🔗 Merged %d duplicate recommendations: %d remaining\n", indent, len(recs)-len(merged), len(merged))
+	}
+	return merged
+}
+
+// loadEngineVersionInfo queries running instance engine versions and major version support information
+// Only the given regions are queried (all enabled regions when empty), and support information is only fetched for the
+// engines running there. Query failures are logged and result in empty maps; cache-only mode skips the queries entirely
+func loadEngineVersionInfo(ctx context.Context, cfg RunConfig, regions []string) (map[string][]InstanceEngineVersion, map[string]MajorEngineVersionInfo) {
+	if cfg.CacheOnly {
+		log.Printf("📦 Cache-only mode: skipping engine version validation and extended support detection")
+		return make(map[string][]InstanceEngineVersion), make(map[string]MajorEngineVersionInfo)
+	}
+	if cfg.SkipVersionChecks {
+		log.Printf("⏭️  Skipping engine version validation and extended support detection (--skip-version-checks)")
+		return make(map[string][]InstanceEngineVersion), make(map[string]MajorEngineVersionInfo)
+	}
+
+	// Query running instances for engine version validation
+	scope := "all regions"
+	if len(regions) > 0 {
+		scope = fmt.Sprintf("%d region(s)", len(regions))
+	}
+	log.Printf("🔍 Querying running RDS instances across %s to validate engine versions...", scope)
+	instanceVersions, err := queryRunningInstanceEngineVersions(ctx, cfg, regions)
+	if err != nil {
+		log.Printf("⚠️  Warning: Failed to query running instances for engine version validation: %v\n   Continuing without engine version filtering", err)
+		instanceVersions = make(map[string][]InstanceEngineVersion)
+	} else {
+		log.Printf("✅ Found %d instance types with version information across %s", len(instanceVersions), scope)
+	}
+
+	// Query major engine versions for extended support detection, for the engines actually running
+	engines := runningEngines(instanceVersions)
+	if len(engines) == 0 {
+		log.Printf("ℹ️  No running instances of engines with extended support, skipping extended support detection")
+		return instanceVersions, make(map[string]MajorEngineVersionInfo)
+	}
+	supportRegion := ""
+	if len(regions) > 0 {
+		supportRegion = regions[0]
+	}
+	log.Printf("🔍 Querying AWS RDS major engine versions of %s for extended support information...", strings.Join(engines, ", "))
+	versionInfo, err := queryMajorEngineVersions(ctx, cfg, supportRegion, engines)
+	if err != nil {
+		log.Printf("⚠️  Warning: Failed to query major engine versions: %v\n   Continuing without extended support detection", err)
+		versionInfo = make(map[string]MajorEngineVersionInfo)
+	} else {
+		log.Printf("✅ Found support information for %d major engine versions", len(versionInfo))
+	}
+
+	return instanceVersions, versionInfo
+}
+
+// recommendationRegions returns the distinct regions of the recommendations, in order of first appearance
+func recommendationRegions(recs []common.Recommendation) []string {
+	var regions []string
+	for _, rec := range recs {
+		if rec.Region != "" && !slices.Contains(regions, rec.Region) {
+			regions = append(regions, rec.Region)
+		}
+	}
+	return regions
+}
+
+// groupRecommendationsByServiceRegion groups recommendations by service and region
+func groupRecommendationsByServiceRegion(recommendations []common.Recommendation) map[common.ServiceType]map[string][]common.Recommendation {
+	recsByServiceRegion := make(map[common.ServiceType]map[string][]common.Recommendation)
+	for _, rec := range recommendations {
+		if _, ok := recsByServiceRegion[rec.Service]; !ok {
+			recsByServiceRegion[rec.Service] = make(map[string][]common.Recommendation)
+		}
+		recsByServiceRegion[rec.Service][rec.Region] = append(recsByServiceRegion[rec.Service][rec.Region], rec)
+	}
+	return recsByServiceRegion
+}
+
+// populateAccountNames populates account names from account IDs using the cache
+func populateAccountNames(ctx context.Context, recommendations []common.Recommendation, accountCache *AccountAliasCache) {
+	for i := range recommendations {
+		if recommendations[i].Account != "" {
+			recommendations[i].AccountName = accountCache.GetAccountAlias(ctx, recommendations[i].Account)
+		}
+	}
+}
+
+// adjustRecsForDuplicates checks for existing RIs and adjusts recommendations to avoid duplicates
+func adjustRecsForDuplicates(ctx context.Context, recs []common.Recommendation, serviceClient provider.ServiceClient, ignoreExpiringWithin time.Duration) ([]common.Recommendation, error) {
+	duplicateChecker := NewDuplicateChecker()
+	duplicateChecker.IgnoreExpiringWithin = ignoreExpiringWithin
+	adjustedRecs, err := duplicateChecker.AdjustRecommendationsForExistingRIs(ctx, recs, serviceClient)
+	if err != nil {
+		return recs, err // Return original recommendations with error
+	}
+
+	originalInstances := CalculateTotalInstances(recs)
+	adjustedInstances := CalculateTotalInstances(adjustedRecs)
+	if originalInstances != adjustedInstances {
+		AppLogger.Printf("  🔍 Adjusted recommendations: %d instances → %d instances to avoid duplicate purchases\n", originalInstances, adjustedInstances)
+	}
+
+	return adjustedRecs, nil
+}
+
+// createDryRunResult creates a purchase result for dry run mode
+func createDryRunResult(rec common.Recommendation, region string, index int, cfg RunConfig) common.PurchaseResult {
+	return common.PurchaseResult{
+		Recommendation: rec,
+		Success:        true,
+		CommitmentID:   generatePurchaseID(rec, region, index, true, cfg.Coverage),
+		DryRun:         true,
+		Timestamp:      time.Now(),
+	}
+}
+
+// createAPIDryRunResult validates a purchase with the provider's DryRun purchase API (--dry-run-purchase-api),
+// falling back to a local dry run result for services whose purchase API has no DryRun mode
+func createAPIDryRunResult(ctx context.Context, rec common.Recommendation, region string, index int, serviceClient provider.ServiceClient, cfg RunConfig) common.PurchaseResult {
+	purchaser, ok := serviceClient.(provider.DryRunPurchaser)
+	if !ok {
+		return createDryRunResult(rec, region, index, cfg)
+	}
+
+	result, _ := purchaser.DryRunPurchase(ctx, rec)
+	result.DryRun = true
+	if result.CommitmentID == "" {
+		result.CommitmentID = generatePurchaseID(rec, region, index, true, cfg.Coverage)
+	}
+	if result.Success {
+		AppLogger.Printf("    🧪 Purchase API dry run: the purchase would succeed\n")
+	}
+	return result
+}
+
+// createCancelledResults creates purchase results for cancelled purchases
+func createCancelledResults(recs []common.Recommendation, region string, cfg RunConfig) []common.PurchaseResult {
+	results := make([]common.PurchaseResult, len(recs))
+	for k := range recs {
+		results[k] = common.PurchaseResult{
+			Recommendation: recs[k],
+			Success:        false,
+			CommitmentID:   generatePurchaseID(recs[k], region, k+1, false, cfg.Coverage),
+			Error:          fmt.Errorf("purchase cancelled by user"),
+			Timestamp:      time.Now(),
+		}
+	}
+	return results
+}
+
+// createFailFastResults creates skipped purchase results for the recommendations left after a --fail-fast abort
+// firstIndex is the 1-based position of the first skipped recommendation in the region's purchase list.
+func createFailFastResults(recs []common.Recommendation, region string, firstIndex int, cfg RunConfig) []common.PurchaseResult {
+	results := make([]common.PurchaseResult, len(recs))
+	for k := range recs {
+		results[k] = common.PurchaseResult{
+			Recommendation: recs[k],
+			Success:        false,
+			CommitmentID:   generatePurchaseID(recs[k], region, firstIndex+k, false, cfg.Coverage),
+			Error:          fmt.Errorf("purchase skipped by --fail-fast after an earlier purchase failed"),
+			Timestamp:      time.Now(),
+		}
+	}
+	return results
+}
+
+// createInterruptedResults creates skipped purchase results for the recommendations left when the run is interrupted
+// by --timeout or a signal. firstIndex is the 1-based position of the first skipped recommendation in the region's purchase list.
+func createInterruptedResults(recs []common.Recommendation, region string, firstIndex int, cause error, cfg RunConfig) []common.PurchaseResult {
+	results := make([]common.PurchaseResult, len(recs))
+	for k := range recs {
+		results[k] = common.PurchaseResult{
+			Recommendation: recs[k],
+			Success:        false,
+			CommitmentID:   generatePurchaseID(recs[k], region, firstIndex+k, false, cfg.Coverage),
+			Error:          fmt.Errorf("purchase skipped after the run was interrupted: %w", cause),
+			Timestamp:      time.Now(),
+		}
+	}
+	return results
+}
+
+// executePurchase executes an actual RI purchase
+func executePurchase(ctx context.Context, rec common.Recommendation, region string, index int, serviceClient provider.ServiceClient, cfg RunConfig) common.PurchaseResult {
+	if cfg.ValidateOfferings {
+		if err := serviceClient.ValidateOffering(ctx, rec); err != nil {
+			return common.PurchaseResult{
+				Recommendation: rec,
+				Success:        false,
+				CommitmentID:   generatePurchaseID(rec, region, index, false, cfg.Coverage),
+				Error:          fmt.Errorf("offering validation failed: %w", err),
+				Timestamp:      time.Now(),
+			}
+		}
+	}
+
+	AppLogger.Printf("    ⚠️  ACTUAL PURCHASE: About to buy %d instances of %s\n", rec.Count, rec.ResourceType)
+	// An interrupt must not abort a purchase in flight, whose outcome would then be unknown
+	result, _ := serviceClient.PurchaseCommitment(context.WithoutCancel(ctx), rec)
+	if result.CommitmentID == "" {
+		result.CommitmentID = generatePurchaseID(rec, region, index, false, cfg.Coverage)
+	}
+	if result.Partial {
+		AppLogger.Printf("    ⚠️  Partial fulfillment: purchased %d of %d requested instances (%d short)\n", result.PurchasedCount, result.RequestedCount, result.Shortfall())
+	}
+	return result
+}
+
+// printOfferingQuote prints the upfront and hourly price the provider currently quotes for a recommendation
+func printOfferingQuote(ctx context.Context, rec common.Recommendation, serviceClient provider.ServiceClient) {
+	details, err := serviceClient.GetOfferingDetails(ctx, rec)
+	if err != nil {
+		AppLogger.Printf("    ⚠️  Could not quote offering: %v\n", err)
+		return
+	}
+	currency := details.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+	AppLogger.Printf("    🏷️  Quoted price: %.2f %s upfront, %.4f %s/hour (offering %s)\n",
+		details.UpfrontCost, currency, details.EffectiveHourlyRate, currency, details.OfferingID)
+}
+
+// basePurchaseDelay is the delay between consecutive purchases to avoid rate limiting
+const basePurchaseDelay = 2 * time.Second
+
+// purchaseDelayRand is the random source used for purchase delay jitter (replaceable in tests for determinism)
+// Its source is locked, as concurrent service processing shares it between goroutines.
+var purchaseDelayRand = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano())})
+
+// lockedSource is a rand.Source that is safe for concurrent use
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
+
+// computePurchaseDelay returns the base delay randomized uniformly within +/- jitter, never below zero
+func computePurchaseDelay(base, jitter time.Duration, rng *rand.Rand) time.Duration {
+	if jitter <= 0 || rng == nil {
+		return base
+	}
+	offset := time.Duration(rng.Int63n(int64(2*jitter)+1)) - jitter
+	return max(0, base+offset)
+}
+
+// ec2DetailsSuffix returns the EC2 Reserved Instance offering class and scope of a recommendation,
+// such as " (convertible, availability-zone us-east-1a)"
+func ec2DetailsSuffix(rec common.Recommendation) string {
+	details, ok := rec.Details.(*common.ComputeDetails)
+	if !ok || details == nil {
+		return ""
+	}
+	var parts []string
+	if details.OfferingClass != "" {
+		parts = append(parts, details.OfferingClass)
+	}
+	if scope := ec2Scope(rec); scope != "" {
+		parts = append(parts, scope)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// processPurchaseLoop processes purchases for a single region
+func processPurchaseLoop(ctx context.Context, recs []common.Recommendation, region string, isDryRun bool, serviceClient provider.ServiceClient, cfg RunConfig) []common.PurchaseResult {
+	if cfg.RecommendationsOnly {
+		return []common.PurchaseResult{}
+	}
+	recs = skipCompletedPurchases(recs, cfg)
+	results := make([]common.PurchaseResult, 0, len(recs))
+
+	for j, rec := range recs {
+		AppLogger.Printf("    [%d/%d] Processing: %s %s%s\n", j+1, len(recs), rec.Service, rec.ResourceType, ec2DetailsSuffix(rec))
+		AppLogger.Printf("    💳 Purchasing %d instances\n", rec.Count)
+
+		var result common.PurchaseResult
+		if isDryRun {
+			if cfg.ValidateOfferings && serviceClient != nil {
+				printOfferingQuote(ctx, rec, serviceClient)
+			}
+			if cfg.DryRunPurchaseAPI && serviceClient != nil {
+				result = createAPIDryRunResult(ctx, rec, region, j+1, serviceClient, cfg)
+			} else {
+				result = createDryRunResult(rec, region, j+1, cfg)
+			}
+		} else {
+			if err := ctx.Err(); err != nil {
+				AppLogger.Printf("    ⏹️  Run interrupted: skipping the remaining %d purchase(s)\n", len(recs)-j)
+				return append(results, createInterruptedResults(recs[j:], region, j+1, err, cfg)...)
+			}
+
+			// Ask for confirmation before proceeding with purchases (only on first item)
+			if j == 0 {
+				if cfg.Preview {
+					printPurchasePreview(recs)
+				}
+				if !ConfirmPurchase(CalculateTotalInstances(recs), totalCommitmentCost(recs), cfg) {
+					// User cancelled - return cancelled results for all
+					return createCancelledResults(recs, region, cfg)
+				}
+			}
+
+			// Execute actual purchase
+			result = executePurchase(ctx, rec, region, j+1, serviceClient, cfg)
+			recordPurchase(cfg, result)
+			recordReceipt(cfg, result)
+
+			// Add delay between purchases to avoid rate limiting
+			if j < len(recs)-1 && os.Getenv("DISABLE_PURCHASE_DELAY") != "true" {
+				time.Sleep(computePurchaseDelay(basePurchaseDelay, cfg.DelayJitter, purchaseDelayRand))
+			}
+		}
+
+		results = append(results, result)
+
+		if result.Success {
+			AppLogger.Printf("    ✅ Success: %s\n", result.CommitmentID)
+		} else {
+			errMsg := "unknown error"
+			if result.Error != nil {
+				errMsg = result.Error.Error()
+			}
+			AppLogger.Printf("    ❌ Failed: %s\n", errMsg)
+			if !isDryRun && cfg.FailFast && j < len(recs)-1 {
+				AppLogger.Printf("    🛑 Fail-fast: skipping the remaining %d purchase(s)\n", len(recs)-j-1)
+				return append(results, createFailFastResults(recs[j+1:], region, j+2, cfg)...)
+			}
+		}
+	}
+
+	return results
+}
+
+// loadInputRecommendations reads the recommendations of the --input-csv or --input-json file
+func loadInputRecommendations(cfg RunConfig) ([]common.Recommendation, error) {
+	if cfg.JSONInput != "" {
+		AppLogger.Printf("📄 Reading recommendations from JSON: %s\n", cfg.JSONInput)
+		recommendations, err := loadRecommendationsFromJSON(cfg.JSONInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JSON file: %w", err)
+		}
+		AppLogger.Printf("✅ Loaded %d recommendations from JSON\n", len(recommendations))
+		return recommendations, nil
+	}
+
+	AppLogger.Printf("📄 Reading recommendations from CSV: %s\n", cfg.CSVInput)
+	recommendations, err := loadRecommendationsFromCSV(cfg.CSVInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+	AppLogger.Printf("✅ Loaded %d recommendations from CSV\n", len(recommendations))
+	return recommendations, nil
+}
+
+// runToolFromCSV processes recommendations from a CSV (or JSON) input file
+// A nil report with a nil error means no recommendations were left to process after filtering
+func runToolFromCSV(ctx context.Context, cfg RunConfig) (*RunReport, error) {
+	// Determine if this is a dry run
+	isDryRun := !cfg.ActualPurchase
+	printRunMode(isDryRun)
+
+	csvModeCoverage := determineCSVCoverage(cfg)
+
+	// Read recommendations from the input file
+	recommendations, err := loadInputRecommendations(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter and adjust recommendations
+	recommendations = filterAndAdjustRecommendations(recommendations, csvModeCoverage, cfg)
+
+	// Drop Savings Plans that would cover the same EC2 usage as the RIs if requested
+	if cfg.NoDoubleCommit {
+		var removed int
+		recommendations, removed = removeOverlappingSavingsPlans(recommendations)
+		if removed > 0 {
+			AppLogger.Printf("🚫 --no-double-commit: skipping %d Compute/EC2 Instance Savings Plan(s) overlapping EC2 RIs\n", removed)
+		}
+	} else {
+		warnCommitmentOverlap(recommendations)
+	}
+
+	// Enforce the --expect guards on the reviewed plan before making any AWS call
+	if err := checkExpectations(recommendations, cfg); err != nil {
+		return nil, err
+	}
+
+	if len(recommendations) == 0 {
+		AppLogger.Println("⚠️  No recommendations to process after filtering")
+		return nil, nil
+	}
+
+	// Load AWS configuration
+	var configOptions []func(*config.LoadOptions) error
+	configOptions = append(configOptions, config.WithRegion("us-east-1"))
+	if cfg.Profile != "" {
+		configOptions = append(configOptions, config.WithSharedConfigProfile(cfg.Profile))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, configOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	// Create account alias cache for lookup
+	accountCache := newAccountAliasCache(awsCfg, cfg)
+
+	// Populate account names from account IDs
+	populateAccountNames(ctx, recommendations, accountCache)
+	saveAccountAliasCache(accountCache, cfg)
+
+	// Group recommendations by service and region
+	recsByServiceRegion := groupRecommendationsByServiceRegion(recommendations)
+
+	// Load the upfront budget and the deferred queue of the previous run
+	budget, err := loadUpfrontBudget(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Process purchases
+	report := newRunReport(isDryRun)
+	report.Recommendations = recommendations
+
+	for service, regionRecs := range recsByServiceRegion {
+		AppLogger.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+		AppLogger.Printf("🎯 Processing %s\n", getServiceDisplayName(service))
+		AppLogger.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+
+		serviceRecs := make([]common.Recommendation, 0)
+		for region, recs := range regionRecs {
+			AppLogger.Printf("\n  📍 Region: %s (%d recommendations)\n", region, len(recs))
+
+			// Get service client for this region
+			regionalCfg := awsCfg.Copy()
+			regionalCfg.Region = region
+			serviceClient := createServiceClient(service, regionalCfg)
+
+			if serviceClient == nil {
+				AppLogger.Printf("  ⚠️  Service client not yet implemented for %s\n", getServiceDisplayName(service))
+				AppLogger.Printf("     (Skipping purchase phase for this service)\n")
+				continue
+			}
+
+			recs = dropUnpurchasableResourceTypes(ctx, service, recs, serviceClient)
+
+			// Check for duplicate RIs to avoid double purchasing
+			adjustedRecs, err := adjustRecsForDuplicates(ctx, recs, serviceClient, cfg.IgnoreRIsExpiringWithin)
+			if err != nil {
+				AppLogger.Printf("  ⚠️  Warning: Could not check for existing RIs: %v\n", err)
+				adjustedRecs = recs // Continue with original recommendations if check fails
+			} else {
+				if cfg.DryRunDiff && isDryRun {
+					// Show the net change against the existing reservations if requested
+					if commitments, err := serviceClient.GetExistingCommitments(ctx); err == nil {
+						printCoverageDiff(recs, adjustedRecs, commitments)
+					}
+				}
+				adjustedRecs = applyCoverageSatisfiedThreshold(ctx, recs, adjustedRecs, serviceClient, cfg.CoverageSatisfiedThreshold, cfg.IgnoreRIsExpiringWithin)
+			}
+			recs = adjustedRecs
+
+			// Keep the upfront cost within the run's budget, deferring the rest to the next run
+			recs = allocateUpfrontBudget(ctx, budget, service, region, recs, serviceClient)
+
+			serviceRecs = append(serviceRecs, recs...)
+
+			// Process purchases for this region
+			regionResults := processPurchaseLoop(ctx, recs, region, isDryRun, serviceClient, cfg)
+			report.Results = append(report.Results, regionResults...)
+		}
+
+		// Calculate service statistics
+		stats := calculateServiceStats(service, serviceRecs, report.Results)
+		report.ServiceStats[service] = stats
+		printServiceSummary(service, stats)
+	}
+	report.collectResultErrors()
+	finishUpfrontBudget(budget, cfg, isDryRun)
+
+	// Estimate Marketplace savings for the comparison if requested
+	if cfg.IncludeMarketplaceSavings {
+		addMarketplaceSavings(ctx, awsCfg, report)
+	}
+
+	// Publish purchase events to EventBridge if requested
+	if cfg.EventBridgeBus != "" {
+		NewEventBridgePublisher(awsCfg, cfg.EventBridgeBus).PublishResults(context.WithoutCancel(ctx), report.Results, isDryRun)
+	}
+
+	return report, nil
+}
+
+// buildSPCommitmentRecommendations creates one Savings Plan purchase per plan type at the given hourly commitment
+func buildSPCommitmentRecommendations(commitments map[string]float64, cfg RunConfig) []common.Recommendation {
+	termStr := termString(cfg.TermYears)
+
+	planTypes := make([]string, 0, len(commitments))
+	for planType := range commitments {
+		planTypes = append(planTypes, planType)
+	}
+	sort.Strings(planTypes)
+
+	recs := make([]common.Recommendation, 0, len(planTypes))
+	for _, planType := range planTypes {
+		recs = append(recs, common.Recommendation{
+			Provider:       common.ProviderAWS,
+			Service:        common.ServiceSavingsPlans,
+			Region:         "us-east-1",
+			ResourceType:   planType,
+			Count:          1,
+			CommitmentType: common.CommitmentSavingsPlan,
+			Term:           termStr,
+			PaymentOption:  paymentOptionFor(common.ServiceSavingsPlans, cfg),
+			Timestamp:      time.Now(),
+			Details: &common.SavingsPlanDetails{
+				PlanType:         planType,
+				HourlyCommitment: commitments[planType],
+			},
+		})
+	}
+	return recs
+}
+
+// runToolSPCommitments purchases Savings Plans at fixed hourly commitments, bypassing Cost Explorer recommendations
+func runToolSPCommitments(ctx context.Context, cfg RunConfig) (*RunReport, error) {
+	commitments, err := parseSPCommitments(cfg.SPCommitments)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sp-commitment: %w", err)
+	}
+
+	isDryRun := !cfg.ActualPurchase
+	printRunMode(isDryRun)
+	printPaymentAndTerm(cfg)
+
+	recommendations := buildSPCommitmentRecommendations(commitments, cfg)
+	for _, rec := range recommendations {
+		details := rec.Details.(*common.SavingsPlanDetails)
+		AppLogger.Printf("💵 Fixed commitment: %s Savings Plan at $%.2f/hour\n", details.PlanType, details.HourlyCommitment)
+	}
+
+	// Load AWS configuration
+	var configOptions []func(*config.LoadOptions) error
+	configOptions = append(configOptions, config.WithRegion("us-east-1"))
+	if cfg.Profile != "" {
+		configOptions = append(configOptions, config.WithSharedConfigProfile(cfg.Profile))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, configOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	AppLogger.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	AppLogger.Printf("🎯 Processing %s\n", getServiceDisplayName(common.ServiceSavingsPlans))
+	AppLogger.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+
+	report := newRunReport(isDryRun)
+	report.Recommendations = recommendations
+
+	serviceClient := createServiceClient(common.ServiceSavingsPlans, awsCfg)
+	report.Results = processPurchaseLoop(ctx, recommendations, awsCfg.Region, isDryRun, serviceClient, cfg)
+
+	stats := calculateServiceStats(common.ServiceSavingsPlans, recommendations, report.Results)
+	report.ServiceStats[common.ServiceSavingsPlans] = stats
+	printServiceSummary(common.ServiceSavingsPlans, stats)
+	report.collectResultErrors()
+
+	// Publish purchase events to EventBridge if requested
+	if cfg.EventBridgeBus != "" {
+		NewEventBridgePublisher(awsCfg, cfg.EventBridgeBus).PublishResults(context.WithoutCancel(ctx), report.Results, isDryRun)
+	}
+
+	return report, nil
+}
+
+// checkMaxScanRegions guards against accidentally scanning more auto-discovered regions than allowed
+func checkMaxScanRegions(service common.ServiceType, regions []string, cfg RunConfig) error {
+	if cfg.MaxScanRegions <= 0 || len(regions) <= cfg.MaxScanRegions {
+		return nil
+	}
+	return fmt.Errorf("auto-discovery would scan %d regions for %s, exceeding --max-scan-regions %d; narrow the scope with --regions or raise the limit",
+		len(regions), getServiceDisplayName(service), cfg.MaxScanRegions)
+}
+
+// processService fetches and processes recommendations for a service across all regions
+// An error is returned when auto-discovery exceeds --max-scan-regions, or in cache-only mode when a needed cache entry is missing or expired
+// With --terms, the recommendations of every term are fetched and processed in turn.
+func processService(ctx context.Context, awsCfg aws.Config, recClient provider.RecommendationsClient, accountCache *AccountAliasCache, budget *upfrontBudget, service common.ServiceType, isDryRun bool, cfg RunConfig) ([]common.Recommendation, []common.PurchaseResult, error) {
+	// Determine regions to process, expanding the region sets into concrete regions
+	regionsToProcess := cfg.Regions
+	if len(cfg.RegionSets) > 0 {
+		expanded, err := common.ExpandRegionSets(cfg.Regions, cfg.RegionSets, func() ([]string, error) {
+			return getAllAWSRegions(ctx, awsCfg, cfg)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		regionsToProcess = expanded
+		AppLogger.Printf("🌍 Region set(s) %s: processing %d region(s)\n", strings.Join(cfg.RegionSets, ", "), len(regionsToProcess))
+	}
+	if len(regionsToProcess) == 0 {
+		// Savings Plans are account-level, not regional - only query once
+		if service == common.ServiceSavingsPlans {
+			AppLogger.Printf("🌍 Fetching account-level Savings Plans recommendations...\n")
+			regionsToProcess = []string{"us-east-1"} // Single query for account-level data
+		} else if cfg.CacheOnly {
+			// Offline mode: only the regions present in the cache can be processed
+			AppLogger.Printf("📦 Discovering cached regions for %s...\n", getServiceDisplayName(service))
+			cachedRegions, err := discoverRegionsForService(ctx, recClient, service)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cache-only mode: %w", err)
+			}
+			regionsToProcess = cachedRegions
+			AppLogger.Printf("📍 Processing %d cached region(s)\n", len(regionsToProcess))
+		} else {
+			// Default to all AWS regions for other services
+			AppLogger.Printf("🌍 Processing all AWS regions for %s...\n", getServiceDisplayName(service))
+			allRegions, err := getAllAWSRegions(ctx, awsCfg, cfg)
+			if err != nil {
+				log.Printf("❌ Failed to get AWS regions: %v", err)
+				// Fall back to auto-discovery
+				AppLogger.Printf("🔍 Falling back to auto-discovery...\n")
+				discoveredRegions, err := discoverRegionsForService(ctx, recClient, service)
+				if err != nil {
+					log.Printf("❌ Failed to discover regions: %v", err)
+					return nil, nil, nil
+				}
+				regionsToProcess = discoveredRegions
+			} else {
+				regionsToProcess = allRegions
+			}
+			if err := checkMaxScanRegions(service, regionsToProcess, cfg); err != nil {
+				return nil, nil, err
+			}
+			AppLogger.Printf("📍 Processing %d region(s)\n", len(regionsToProcess))
+		}
+	}
+
+	// Query engine version information once, for the regions being processed; it only applies to RDS
+	instanceVersions, versionInfo := make(map[string][]InstanceEngineVersion), make(map[string]MajorEngineVersionInfo)
+	if service == common.ServiceRDS {
+		instanceVersions, versionInfo = loadEngineVersionInfo(ctx, cfg, regionsToProcess)
+	}
+
+	// Prefetch existing commitments of all regions concurrently for the duplicate purchase check
+	var existing *ExistingCommitmentsCache
+	if !cfg.CacheOnly {
+		existing = prefetchExistingCommitments(ctx, regionsToProcess, func(region string) provider.ServiceClient {
+			regionalCfg := awsCfg.Copy()
+			regionalCfg.Region = region
+			return createServiceClient(service, regionalCfg)
+		})
+		// Savings Plans are account-level, so they are listed once for the services they can cover
+		if _, ok := spCoveredServices[service]; ok {
+			existing.savingsPlans = loadActiveSavingsPlans(ctx, createServiceClient(common.ServiceSavingsPlans, awsCfg))
+		}
+	}
+
+	serviceRecs := make([]common.Recommendation, 0)
+	serviceResults := make([]common.PurchaseResult, 0)
+	terms := termsToProcess(cfg)
+	for _, years := range terms {
+		if ctx.Err() != nil {
+			break
+		}
+		termCfg := cfg
+		termCfg.TermYears = years
+		if len(terms) > 1 {
+			AppLogger.Printf("\n  📅 Term: %s\n", termString(years))
+		}
+		termRecs, termResults, err := processServiceTerm(ctx, awsCfg, recClient, accountCache, existing, budget, service, regionsToProcess, isDryRun, termCfg, instanceVersions, versionInfo)
+		if err != nil {
+			return nil, nil, err
+		}
+		serviceRecs = append(serviceRecs, termRecs...)
+		serviceResults = append(serviceResults, termResults...)
+	}
+
+	return serviceRecs, serviceResults, nil
+}
+
+// termsToProcess returns the terms in years to fetch recommendations for: --terms when set, --term otherwise
+func termsToProcess(cfg RunConfig) []int {
+	if len(cfg.Terms) > 0 {
+		return cfg.Terms
+	}
+	return []int{cfg.TermYears}
+}
+
+// processServiceTerm fetches and processes the recommendations of a service for the term of cfg across the given regions
+func processServiceTerm(ctx context.Context, awsCfg aws.Config, recClient provider.RecommendationsClient, accountCache *AccountAliasCache, existing *ExistingCommitmentsCache, budget *upfrontBudget, service common.ServiceType, regionsToProcess []string, isDryRun bool, cfg RunConfig, instanceVersions map[string][]InstanceEngineVersion, versionInfo map[string]MajorEngineVersionInfo) ([]common.Recommendation, []common.PurchaseResult, error) {
+	if option := paymentOptionFor(service, cfg); option != cfg.PaymentOption {
+		AppLogger.Printf("💳 %s does not offer %s %s, using %s instead\n", getServiceDisplayName(service), termString(cfg.TermYears), cfg.PaymentOption, option)
+	}
+
+	serviceRecs := make([]common.Recommendation, 0)
+	serviceResults := make([]common.PurchaseResult, 0)
+
+	// Fetch the recommendations of all regions concurrently; filtering and purchases stay serial below
+	if cfg.MaxConcurrency > 1 && len(regionsToProcess) > 1 {
+		AppLogger.Printf("⚡ Fetching recommendations for %d regions with up to %d concurrent requests\n", len(regionsToProcess), cfg.MaxConcurrency)
+		params := make([]common.RecommendationParams, 0, len(regionsToProcess))
+		for _, region := range regionsToProcess {
+			params = append(params, recommendationParams(service, region, cfg))
+		}
+		recClient = prefetchRecommendations(ctx, recClient, params, cfg.MaxConcurrency)
+	}
+
+	skippedRegions := make([]string, 0)
+	progress := newScanProgress(cfg.Progress, displayWriter())
+	defer progress.Done()
+	for i, region := range regionsToProcess {
+		if err := ctx.Err(); err != nil {
+			log.Printf("  ⏹️  Run interrupted (%v): skipping the remaining %d region(s)", err, len(regionsToProcess)-i)
+			break
+		}
+		AppLogger.Printf("\n  📍 [%d/%d] Region: %s\n", i+1, len(regionsToProcess), region)
+		progress.Update(service, i+1, len(regionsToProcess))
+
+		regionRecs, regionResults, err := processRegion(ctx, awsCfg, recClient, accountCache, existing, budget, service, region, isDryRun, cfg, instanceVersions, versionInfo)
+		if err != nil {
+			if cfg.CacheOnly {
+				return nil, nil, fmt.Errorf("cache-only mode: region %s: %w", region, err)
+			}
+			log.Printf("  ❌ Failed to fetch recommendations: %v", err)
+			skippedRegions = append(skippedRegions, region)
+			continue
+		}
+		serviceRecs = append(serviceRecs, regionRecs...)
+		serviceResults = append(serviceResults, regionResults...)
+	}
+	progress.Done()
+
+	if len(skippedRegions) > 0 {
+		if !cfg.RetrySkipped {
+			AppLogger.Printf("\n  ⚠️  Skipped %d region(s) after fetch failures: %s (use --retry-skipped to retry them)\n", len(skippedRegions), strings.Join(skippedRegions, ", "))
+		} else {
+			retryRecs, retryResults := retrySkippedRegions(ctx, awsCfg, recClient, accountCache, existing, budget, service, skippedRegions, isDryRun, cfg, instanceVersions, versionInfo)
+			serviceRecs = append(serviceRecs, retryRecs...)
+			serviceResults = append(serviceResults, retryResults...)
+		}
+	}
+
+	return serviceRecs, serviceResults, nil
+}
+
+// lookbackPeriod returns the Cost Explorer lookback period for --lookback-days, defaulting to 7 days
+func lookbackPeriod(days int) string {
+	if days <= 0 {
+		days = 7
+	}
+	return fmt.Sprintf("%dd", days)
+}
+
+// termString returns the term of a number of years as used in recommendations, "1yr" or "3yr"
+func termString(years int) string {
+	if years == 3 {
+		return "3yr"
+	}
+	return "1yr"
+}
+
+// paymentOptionFor returns the payment option to use for a service
+// With --auto-payment, a payment option the service doesn't offer for the term falls back to the nearest supported one.
+func paymentOptionFor(service common.ServiceType, cfg RunConfig) string {
+	if !cfg.AutoPayment {
+		return cfg.PaymentOption
+	}
+	return common.NearestSupportedPaymentOption(service, termString(cfg.TermYears), cfg.PaymentOption)
+}
+
+// recommendationParams builds the Cost Explorer query for a service in a region
+func recommendationParams(service common.ServiceType, region string, cfg RunConfig) common.RecommendationParams {
+	params := common.RecommendationParams{
+		Service:        service,
+		Region:         region,
+		PaymentOption:  paymentOptionFor(service, cfg),
+		Term:           termString(cfg.TermYears),
+		LookbackPeriod: lookbackPeriod(cfg.LookbackDays),
+		// Savings Plans specific filters
+		IncludeSPTypes: cfg.IncludeSPTypes,
+		ExcludeSPTypes: cfg.ExcludeSPTypes,
+		AccountID:      cfg.AccountID,
+	}
+	if service == common.ServiceEC2 {
+		params.EC2OfferingClass = cfg.EC2OfferingClass
+	}
+	return params
+}
+
+// regionValidatedServices are the services whose clients list the resource types purchasable in their region
+// The other clients return static lists that may lag behind AWS, so their recommendations are not checked against them.
+var regionValidatedServices = map[common.ServiceType]bool{
+	common.ServiceEC2:         true,
+	common.ServiceRDS:         true,
+	common.ServiceElastiCache: true,
+}
+
+// dropUnpurchasableResourceTypes removes the recommendations for resource types that can't be reserved in the region
+// Cost Explorer occasionally recommends such types, whose purchase would be certain to fail. When the valid types
+// can't be listed, the recommendations are kept.
+func dropUnpurchasableResourceTypes(ctx context.Context, service common.ServiceType, recs []common.Recommendation, serviceClient provider.ServiceClient) []common.Recommendation {
+	if !regionValidatedServices[service] || len(recs) == 0 {
+		return recs
+	}
+	validTypes, err := serviceClient.GetValidResourceTypes(ctx)
+	if err != nil {
+		AppLogger.Printf("  ⚠️  Warning: Could not list purchasable resource types: %v\n", err)
+		return recs
+	}
+	if len(validTypes) == 0 {
+		return recs
+	}
+
+	valid := make(map[string]bool, len(validTypes))
+	for _, resourceType := range validTypes {
+		valid[resourceType] = true
+	}
+	kept := make([]common.Recommendation, 0, len(recs))
+	for _, rec := range recs {
+		if !valid[rec.ResourceType] {
+			AppLogger.Printf("  🚫 Dropping %s x%d: not purchasable in %s\n", rec.ResourceType, rec.Count, rec.Region)
+			continue
+		}
+		kept = append(kept, rec)
+	}
+	return kept
+}
+
+// processRegion fetches, filters, and purchases recommendations for a single service in a single region
+// An error is returned only when recommendations could not be fetched, so the caller can retry the region later
+func processRegion(ctx context.Context, awsCfg aws.Config, recClient provider.RecommendationsClient, accountCache *AccountAliasCache, existing *ExistingCommitmentsCache, budget *upfrontBudget, service common.ServiceType, region string, isDryRun bool, cfg RunConfig, instanceVersions map[string][]InstanceEngineVersion, versionInfo map[string]MajorEngineVersionInfo) ([]common.Recommendation, []common.PurchaseResult, error) {
+	// Fetch recommendations
+	recs, err := recClient.GetRecommendations(ctx, recommendationParams(service, region, cfg))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(recs) == 0 {
+		AppLogger.Printf("  ℹ️  No recommendations found\n")
+		return nil, nil, nil
+	}
+
+	AppLogger.Printf("  ✅ Found %d recommendations\n", len(recs))
+
+	// Populate account names from account IDs (no alias cache is available in cache-only mode)
+	if accountCache != nil {
+		populateAccountNames(ctx, recs, accountCache)
+	}
+
+	// Apply region and instance type filters
+	// Pass current region to filter recommendations to only those for this region
+	originalCount := len(recs)
+	recs = applyFilters(recs, cfg, instanceVersions, versionInfo, region)
+	if len(recs) == 0 {
+		AppLogger.Printf("  ℹ️  No recommendations after applying filters\n")
+		return nil, nil, nil
+	}
+	if len(recs) < originalCount {
+		AppLogger.Printf("  🔍 After filters: %d recommendations (filtered out %d)\n", len(recs), originalCount-len(recs))
+	}
+
+	recs = applyEC2Scope(recs, cfg.EC2Scope, "  ")
+
+	// Merge recommendations that describe the same purchase, so that coverage and purchases apply to the total
+	recs = mergeDuplicateRecommendations(recs, "  ")
+
+	// Apply coverage
+	filteredRecs := applyCommonCoverage(recs, cfg.Coverage, cfg.CoverageRounding)
+	AppLogger.Printf("  📈 Applying %.1f%% coverage: %d recommendations selected\n", cfg.Coverage, len(filteredRecs))
+
+	// Drop recommendations that aren't worth the commitment
+	if cfg.MinMonthlySavings > 0 {
+		var removed int
+		filteredRecs, removed = filterByMinMonthlySavings(filteredRecs, cfg.MinMonthlySavings)
+		if removed > 0 {
+			AppLogger.Printf("  🔍 Min monthly savings: filtered out %d recommendations below $%.2f/mo\n", removed, cfg.MinMonthlySavings)
+		}
+	}
+
+	// Apply count override if specified
+	if cfg.OverrideCount > 0 {
+		filteredRecs = ApplyCountOverride(filteredRecs, cfg.OverrideCount)
+	}
+
+	// Order recommendations so that limits keep the most valuable ones
+	filteredRecs = SortRecommendations(filteredRecs, cfg.SortBy)
+
+	regionRecs := filteredRecs
+	regionResults := make([]common.PurchaseResult, 0, len(filteredRecs))
+
+	// Cache-only runs are offline dry runs, so they need neither a service client nor a duplicate check
+	var serviceClient provider.ServiceClient
+	if cfg.CacheOnly {
+		AppLogger.Printf("  📦 Cache-only mode: skipping duplicate purchase check\n")
+	} else {
+		// Get service client
+		regionalCfg := awsCfg.Copy()
+		regionalCfg.Region = region
+		serviceClient = createServiceClient(service, regionalCfg)
+
+		if serviceClient == nil {
+			AppLogger.Printf("  ⚠️  Service client not yet implemented for %s\n", getServiceDisplayName(service))
+			AppLogger.Printf("     (Skipping purchase phase for this service)\n")
+			return regionRecs, regionResults, nil
+		}
+
+		filteredRecs = dropUnpurchasableResourceTypes(ctx, service, filteredRecs, serviceClient)
+
+		// Check for duplicate RIs to avoid double purchasing
+		commitmentsClient := existing.Wrap(region, serviceClient)
+		adjustedRecs, err := adjustRecsForDuplicates(ctx, filteredRecs, commitmentsClient, cfg.IgnoreRIsExpiringWithin)
+		if err != nil {
+			AppLogger.Printf("  ⚠️  Warning: Could not check for existing RIs: %v\n", err)
+		} else {
+			// Show the net change against the existing reservations if requested
+			if cfg.DryRunDiff && isDryRun {
+				if commitments, err := commitmentsClient.GetExistingCommitments(ctx); err == nil {
+					printCoverageDiff(filteredRecs, adjustedRecs, commitments)
+				}
+			}
+			// Always use the adjusted recommendations (they might have different counts even if same length)
+			filteredRecs = applyCoverageSatisfiedThreshold(ctx, filteredRecs, adjustedRecs, commitmentsClient, cfg.CoverageSatisfiedThreshold, cfg.IgnoreRIsExpiringWithin)
+		}
+
+		// Warn about usage active Savings Plans likely already cover, excluding it with --respect-existing-sp
+		filteredRecs = checkActiveSavingsPlans(filteredRecs, existing.activeSavingsPlans(), cfg.RespectExistingSP, "  ")
+	}
+
+	// Apply instance limit if specified
+	if cfg.MaxInstances > 0 {
+		beforeLimit := len(filteredRecs)
+		filteredRecs = ApplyInstanceLimit(filteredRecs, cfg.MaxInstances)
+		if len(filteredRecs) < beforeLimit {
+			AppLogger.Printf("  🔒 Applied instance limit: %d recommendations after limiting to %d instances\n", len(filteredRecs), cfg.MaxInstances)
+		}
+	}
+
+	// Apply monthly spend limit if specified
+	if cfg.MaxMonthlySpend > 0 {
+		beforeLimit := len(filteredRecs)
+		filteredRecs = ApplySpendLimit(filteredRecs, cfg.MaxMonthlySpend)
+		if len(filteredRecs) < beforeLimit {
+			AppLogger.Printf("  💵 Applied monthly spend limit: %d recommendations after limiting to $%.2f/mo\n", len(filteredRecs), cfg.MaxMonthlySpend)
+		}
+	}
+
+	// Keep the upfront cost within the run's budget, deferring the rest to the next run
+	if budget != nil && serviceClient != nil {
+		filteredRecs = allocateUpfrontBudget(ctx, budget, service, region, filteredRecs, serviceClient)
+	}
+
+	// --recommendations-only exports the adjusted recommendations without simulating their purchase
+	if cfg.RecommendationsOnly {
+		return filteredRecs, regionResults, nil
+	}
+
+	// Process purchases, skipping those an interrupted run with the same --state-file already made
+	filteredRecs = skipCompletedPurchases(filteredRecs, cfg)
+	for j, rec := range filteredRecs {
+		AppLogger.Printf("    [%d/%d] Processing: %s %s%s\n", j+1, len(filteredRecs), rec.Service, rec.ResourceType, ec2DetailsSuffix(rec))
+
+		// Log the actual count being purchased
+		AppLogger.Printf("    💳 Purchasing %d instances (coverage-adjusted)\n", rec.Count)
+
+		var result common.PurchaseResult
+		if isDryRun {
+			// Show the exact offering the purchase would use
+			if cfg.ValidateOfferings && serviceClient != nil {
+				printOfferingQuote(ctx, rec, serviceClient)
+			}
+			if cfg.DryRunPurchaseAPI && serviceClient != nil {
+				result = createAPIDryRunResult(ctx, rec, region, j+1, serviceClient, cfg)
+			} else {
+				result = common.PurchaseResult{
+					Recommendation: rec,
+					Success:        true,
+					CommitmentID:   generatePurchaseID(rec, region, j+1, true, cfg.Coverage),
+					DryRun:         true,
+					Timestamp:      time.Now(),
+				}
+			}
+		} else {
+			if err := ctx.Err(); err != nil {
+				AppLogger.Printf("    ⏹️  Run interrupted: skipping the remaining %d purchase(s)\n", len(filteredRecs)-j)
+				regionResults = append(regionResults, createInterruptedResults(filteredRecs[j:], region, j+1, err, cfg)...)
+				break
+			}
+
+			// Calculate total for this batch of purchases (only on first item)
+			if j == 0 {
+				if cfg.Preview {
+					printPurchasePreview(filteredRecs)
+				}
+				// Ask for confirmation before proceeding with purchases
+				if !ConfirmPurchase(CalculateTotalInstances(filteredRecs), totalCommitmentCost(filteredRecs), cfg) {
+					// User cancelled - mark all as cancelled and exit
+					for k := range filteredRecs {
+						cancelResult := common.PurchaseResult{
+							Recommendation: filteredRecs[k],
+							Success:        false,
+							CommitmentID:   generatePurchaseID(filteredRecs[k], region, k+1, false, cfg.Coverage),
+							Error:          fmt.Errorf("purchase cancelled by user"),
+							Timestamp:      time.Now(),
+						}
+						regionResults = append(regionResults, cancelResult)
+					}
+					break // Exit the purchase loop for this region
+				}
+			}
+
+			// Final confirmation log before actual purchase
+			AppLogger.Printf("    ⚠️  ACTUAL PURCHASE: About to buy %d instances of %s\n", rec.Count, rec.ResourceType)
+			// An interrupt must not abort a purchase in flight, whose outcome would then be unknown
+			result, _ = serviceClient.PurchaseCommitment(context.WithoutCancel(ctx), rec)
+			if result.CommitmentID == "" {
+				result.CommitmentID = generatePurchaseID(rec, region, j+1, false, cfg.Coverage)
+			}
+			recordPurchase(cfg, result)
+			recordReceipt(cfg, result)
+			// Add delay between purchases to avoid rate limiting
+			// This delay can be disabled for testing by setting DISABLE_PURCHASE_DELAY env var
+			if j < len(filteredRecs)-1 && os.Getenv("DISABLE_PURCHASE_DELAY") != "true" {
+				time.Sleep(computePurchaseDelay(basePurchaseDelay, cfg.DelayJitter, purchaseDelayRand))
+			}
+		}
+
+		regionResults = append(regionResults, result)
+
+		if result.Success {
+			AppLogger.Printf("    ✅ Success: %s\n", result.CommitmentID)
+		} else {
+			errMsg := "unknown error"
+			if result.Error != nil {
+				errMsg = result.Error.Error()
+			}
+			AppLogger.Printf("    ❌ Failed: %s\n", errMsg)
+			if !isDryRun && cfg.FailFast && j < len(filteredRecs)-1 {
+				AppLogger.Printf("    🛑 Fail-fast: skipping the remaining %d purchase(s)\n", len(filteredRecs)-j-1)
+				regionResults = append(regionResults, createFailFastResults(filteredRecs[j+1:], region, j+2, cfg)...)
+				break
+			}
+		}
+	}
+
+	return regionRecs, regionResults, nil
+}
+
+// retrySkippedRegions waits for the configured cooldown and retries regions whose recommendations could not be fetched
+func retrySkippedRegions(ctx context.Context, awsCfg aws.Config, recClient provider.RecommendationsClient, accountCache *AccountAliasCache, existing *ExistingCommitmentsCache, budget *upfrontBudget, service common.ServiceType, skippedRegions []string, isDryRun bool, cfg RunConfig, instanceVersions map[string][]InstanceEngineVersion, versionInfo map[string]MajorEngineVersionInfo) ([]common.Recommendation, []common.PurchaseResult) {
+	AppLogger.Printf("\n  🔁 Retrying %d skipped region(s) after %s cooldown...\n", len(skippedRegions), cfg.RetrySkippedCooldown)
+	select {
+	case <-time.After(cfg.RetrySkippedCooldown):
+	case <-ctx.Done():
+		log.Printf("  ❌ Retry of skipped regions cancelled: %v", ctx.Err())
+		return nil, nil
+	}
+
+	allRecs := make([]common.Recommendation, 0)
+	allResults := make([]common.PurchaseResult, 0)
+	recovered := make([]string, 0)
+	stillFailing := make([]string, 0)
+
+	for i, region := range skippedRegions {
+		AppLogger.Printf("\n  📍 [retry %d/%d] Region: %s\n", i+1, len(skippedRegions), region)
+
+		regionRecs, regionResults, err := processRegion(ctx, awsCfg, recClient, accountCache, existing, budget, service, region, isDryRun, cfg, instanceVersions, versionInfo)
+		if err != nil {
+			log.Printf("  ❌ Failed to fetch recommendations on retry: %v", err)
+			stillFailing = append(stillFailing, region)
+			continue
+		}
+		recovered = append(recovered, region)
+		allRecs = append(allRecs, regionRecs...)
+		allResults = append(allResults, regionResults...)
+	}
+
+	if len(recovered) > 0 {
+		AppLogger.Printf("  ✅ Regions recovered on retry: %s\n", strings.Join(recovered, ", "))
+	}
+	if len(stillFailing) > 0 {
+		AppLogger.Printf("  ❌ Regions still failing after retry: %s\n", strings.Join(stillFailing, ", "))
+	}
+
+	return allRecs, allResults
+}
+
+// Helper functions
+
+func formatServices(services []common.ServiceType) string {
+	names := make([]string, len(services))
+	for i, s := range services {
+		names[i] = getServiceDisplayName(s)
+	}
+	return strings.Join(names, ", ")
+}
+
+func getServiceDisplayName(service common.ServiceType) string {
+	switch service {
+	case common.ServiceRDS:
+		return "RDS"
+	case common.ServiceElastiCache:
+		return "ElastiCache"
+	case common.ServiceEC2:
+		return "EC2"
+	case common.ServiceOpenSearch:
+		return "OpenSearch"
+	case common.ServiceRedshift:
+		return "Redshift"
+	case common.ServiceMemoryDB:
+		return "MemoryDB"
+	case common.ServiceDynamoDB:
+		return "DynamoDB"
+	case common.ServiceSavingsPlans:
+		return "Savings Plans"
+	case common.ServiceCompute:
+		return "Compute"
+	default:
+		return string(service)
+	}
+}
+
+// awsRegionCache remembers the enabled regions per set of credentials for the lifetime of the process,
+// so that they are not described again for every service
+var awsRegionCache = struct {
+	sync.Mutex
+	regions map[aws.CredentialsProvider][]string
+}{regions: make(map[aws.CredentialsProvider][]string)}
+
+// getAllAWSRegions retrieves all available AWS regions, retrying failures like Cost Explorer requests (--api-retries)
+// The result is cached per credentials, failures are not.
+func getAllAWSRegions(ctx context.Context, awsCfg aws.Config, cfg RunConfig) ([]string, error) {
+	cacheable := awsCfg.Credentials == nil || reflect.TypeOf(awsCfg.Credentials).Comparable()
+	if cacheable {
+		awsRegionCache.Lock()
+		regions, ok := awsRegionCache.regions[awsCfg.Credentials]
+		awsRegionCache.Unlock()
+		if ok {
+			return slices.Clone(regions), nil
+		}
+	}
+
+	// Create EC2 client to get regions
+	ec2Client := awsec2.NewFromConfig(awsCfg)
+	regions, err := getAllAWSRegionsWithClient(ctx, ec2Client, recommendations.NewRetryRateLimiter(cfg.APIRetries, cfg.APIRetryDelay))
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		awsRegionCache.Lock()
+		awsRegionCache.regions[awsCfg.Credentials] = slices.Clone(regions)
+		awsRegionCache.Unlock()
+	}
+	return regions, nil
+}
+
+// getAllAWSRegionsWithClient retrieves all available AWS regions using the provided client
+// Failed requests are retried with the backoff of rateLimiter; a nil rateLimiter makes a single attempt.
+func getAllAWSRegionsWithClient(ctx context.Context, ec2Client EC2ClientInterface, rateLimiter *recommendations.RateLimiter) ([]string, error) {
+	if rateLimiter == nil {
+		rateLimiter = recommendations.NewRetryRateLimiter(0, 0)
+	}
+
+	// Describe all regions
+	var result *awsec2.DescribeRegionsOutput
+	retries, err := rateLimiter.Do(ctx, func() error {
+		var err error
+		result, err = ec2Client.DescribeRegions(ctx, &awsec2.DescribeRegionsInput{
+			AllRegions: aws.Bool(false), // Only get opted-in regions
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe regions after %d retries: %w", retries, err)
+	}
+
+	regions := make([]string, 0, len(result.Regions))
+	for _, region := range result.Regions {
+		if region.RegionName != nil {
+			regions = append(regions, *region.RegionName)
+		}
+	}
+
+	sort.Strings(regions)
+	return regions, nil
+}
+
+func discoverRegionsForService(ctx context.Context, client provider.RecommendationsClient, service common.ServiceType) ([]string, error) {
+	recs, err := client.GetRecommendationsForService(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	regionSet := make(map[string]bool)
+	for _, rec := range recs {
+		if rec.Region != "" {
+			regionSet[rec.Region] = true
+		}
+	}
+
+	regions := make([]string, 0, len(regionSet))
+	for region := range regionSet {
+		regions = append(regions, region)
+	}
+
+	sort.Strings(regions)
+	return regions, nil
+}
+
+
+func applyCommonCoverage(recs []common.Recommendation, coverage float64, rounding string) []common.Recommendation {
+	return ApplyCoverageRounded(recs, coverage, rounding)
+}
+
+
+func calculateServiceStats(service common.ServiceType, recs []common.Recommendation, results []common.PurchaseResult) ServiceProcessingStats {
+	stats := ServiceProcessingStats{
+		Service:                 service,
+		RecommendationsFound:    len(recs),
+		RecommendationsSelected: len(recs),
+	}
+
+	regionSet := make(map[string]bool)
+	for _, rec := range recs {
+		regionSet[rec.Region] = true
+		stats.InstancesProcessed += rec.Count
+		stats.TotalEstimatedSavings += rec.EstimatedSavings
+		stats.TotalUpfrontCost += rec.UpfrontCost
+		stats.TotalAmortizedMonthlyCost += rec.AmortizedMonthlyCost
+		stats.ProjectedTermSavings += rec.EstimatedSavings * float64(termMonths(rec.Term))
+		if details, ok := savingsPlanDetails(rec); ok {
+			stats.TotalHourlyCommitment += details.HourlyCommitment
+		}
+	}
+	stats.RegionsProcessed = len(regionSet)
+
+	for _, result := range results {
+		if result.Success {
+			stats.SuccessfulPurchases++
+		} else {
+			stats.FailedPurchases++
+		}
+		if result.Partial {
+			stats.PartialPurchases++
+			stats.InstancesShortfall += result.Shortfall()
+		}
+	}
+
+	return stats
+}
+
+func printServiceSummary(service common.ServiceType, stats ServiceProcessingStats) {
+	outPrintf("\n📊 %s Summary:\n", getServiceDisplayName(service))
+	outPrintf("  Regions processed: %d\n", stats.RegionsProcessed)
+	outPrintf("  Recommendations: %d\n", stats.RecommendationsSelected)
+	outPrintf("  Instances: %d\n", stats.InstancesProcessed)
+	outPrintf("  Successful: %d, Failed: %d\n", stats.SuccessfulPurchases, stats.FailedPurchases)
+	if stats.PartialPurchases > 0 {
+		outPrintf("  Partially fulfilled: %d (%d instances short)\n", stats.PartialPurchases, stats.InstancesShortfall)
+	}
+	if stats.TotalEstimatedSavings > 0 {
+		outPrintf("  Estimated monthly savings: $%.2f\n", stats.TotalEstimatedSavings)
+	}
+	if stats.ProjectedTermSavings > 0 {
+		outPrintf("  Projected savings over the term: $%.2f\n", stats.ProjectedTermSavings)
+	}
+	if stats.TotalHourlyCommitment > 0 {
+		outPrintf("  Hourly commitment: $%.3f/hour\n", stats.TotalHourlyCommitment)
+	}
+	if stats.TotalUpfrontCost > 0 {
+		outPrintf("  Upfront cost: $%.2f\n", stats.TotalUpfrontCost)
+	}
+	if stats.TotalAmortizedMonthlyCost > 0 {
+		outPrintf("  Amortized monthly cost: $%.2f\n", stats.TotalAmortizedMonthlyCost)
+	}
+}
+
+func writeMultiServiceCSVReport(results []common.PurchaseResult, filepath string) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	file, err := os.Create(filepath)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	// Write header
+	header := []string{
+		"Service", "Region", "ResourceType", "Count", "Account", "AccountName",
+		"Term", "PaymentOption", "Scope", "EstimatedSavings", "CommitmentID",
+		"Success", "PurchasedCount", "Shortfall", "Error", "Timestamp",
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	// Write data rows
+	for _, r := range results {
+		rec := r.Recommendation
+		errStr := ""
+		if r.Error != nil {
+			errStr = r.Error.Error()
+		}
+		// Fulfillment is only known once the provider has reported it
+		purchasedStr, shortfallStr := "", ""
+		if r.RequestedCount > 0 {
+			purchasedStr = fmt.Sprintf("%d", r.PurchasedCount)
+			shortfallStr = fmt.Sprintf("%d", r.Shortfall())
+		}
+
+		row := []string{
+			string(rec.Service),
+			rec.Region,
+			rec.ResourceType,
+			fmt.Sprintf("%d", rec.Count),
+			rec.Account,
+			rec.AccountName,
+			rec.Term,
+			rec.PaymentOption,
+			ec2Scope(rec),
+			fmt.Sprintf("%.2f", rec.EstimatedSavings),
+			r.CommitmentID,
+			fmt.Sprintf("%t", r.Success),
+			purchasedStr,
+			shortfallStr,
+			errStr,
+			r.Timestamp.Format(time.RFC3339),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// splitServiceStats separates the statistics of the Reserved Instance services from those of Savings Plans
+func splitServiceStats(serviceStats map[common.ServiceType]ServiceProcessingStats) (map[common.ServiceType]ServiceProcessingStats, ServiceProcessingStats) {
+	riStats := make(map[common.ServiceType]ServiceProcessingStats)
+	var spStats ServiceProcessingStats
+	for service, stats := range serviceStats {
+		if service == common.ServiceSavingsPlans {
+			spStats = stats
+		} else {
+			riStats[service] = stats
+		}
+	}
+	return riStats, spStats
+}
+
+// totalServiceStats sums the statistics of all services
+func totalServiceStats(serviceStats map[common.ServiceType]ServiceProcessingStats) ServiceProcessingStats {
+	var total ServiceProcessingStats
+	for _, stats := range serviceStats {
+		total = addServiceStats(total, stats)
+	}
+	return total
+}
+
+// printMultiServiceSummary prints the final summary; marketplaceSavings is factored into the RI option of the comparison
+func printMultiServiceSummary(allRecommendations []common.Recommendation, allResults []common.PurchaseResult, serviceStats map[common.ServiceType]ServiceProcessingStats, isDryRun bool, marketplaceSavings float64) {
+	outPrintln("\n🎯 Final Summary:")
+	outPrintln("==========================================")
+
+	if isDryRun {
+		outPrintln("Mode: DRY RUN")
+	} else {
+		outPrintln("Mode: ACTUAL PURCHASE")
+	}
+
+	// Separate Savings Plans from RIs
+	riStats, spStats := splitServiceStats(serviceStats)
+
+	// Calculate RI totals
+	riTotal := totalServiceStats(riStats)
+	riRecommendations := riTotal.RecommendationsSelected
+	riInstances := riTotal.InstancesProcessed
+	riSavings := riTotal.TotalEstimatedSavings
+	riSuccess := riTotal.SuccessfulPurchases
+	riFailed := riTotal.FailedPurchases
+
+	// Show Reserved Instances section
+	if len(riStats) > 0 {
+		outPrintln("\n💰 RESERVED INSTANCES:")
+		outPrintln("--------------------------------------------------")
+		for service, stats := range riStats {
+			outPrintf("%-15s | Recs: %3d | Instances: %3d | Savings: $%8.2f/mo | Term: $%10.2f\n",
+				getServiceDisplayName(service),
+				stats.RecommendationsSelected,
+				stats.InstancesProcessed,
+				stats.TotalEstimatedSavings,
+				stats.ProjectedTermSavings)
+		}
+		outPrintf("%-15s | Recs: %3d | Instances: %3d | Savings: $%8.2f/mo | Term: $%10.2f\n",
+			"TOTAL RIs",
+			riRecommendations,
+			riInstances,
+			riSavings,
+			riTotal.ProjectedTermSavings)
+	}
+
+	// Show Savings Plans section
+	if spStats.RecommendationsSelected > 0 {
+		outPrintln("\n📊 SAVINGS PLANS:")
+		outPrintln("--------------------------------------------------")
+
+		// Break down by SP type from recommendations
+		computeSavings := 0.0
+		ec2InstanceSavings := 0.0
+		sagemakerSavings := 0.0
+		databaseSavings := 0.0
+		computeCount := 0
+		ec2InstanceCount := 0
+		sagemakerCount := 0
+		databaseCount := 0
+
+		for _, rec := range allRecommendations {
+			if rec.Service == common.ServiceSavingsPlans {
+				if details, ok := savingsPlanDetails(rec); ok {
+					switch details.PlanType {
+					case "Compute":
+						computeSavings += rec.EstimatedSavings
+						computeCount++
+					case "EC2Instance":
+						ec2InstanceSavings += rec.EstimatedSavings
+						ec2InstanceCount++
+					case "SageMaker":
+						sagemakerSavings += rec.EstimatedSavings
+						sagemakerCount++
+					case "Database":
+						databaseSavings += rec.EstimatedSavings
+						databaseCount++
+					}
+				}
+			}
+		}
+
+		if computeCount > 0 {
+			outPrintf("  Compute SP    | Recs: %3d | Covers: EC2, Fargate, Lambda | $%8.2f/mo\n", computeCount, computeSavings)
+		}
+		if ec2InstanceCount > 0 {
+			outPrintf("  EC2 Inst SP   | Recs: %3d | Covers: EC2 only (better rate) | $%8.2f/mo\n", ec2InstanceCount, ec2InstanceSavings)
+		}
+		if sagemakerCount > 0 {
+			outPrintf("  SageMaker SP  | Recs: %3d | Covers: SageMaker instances    | $%8.2f/mo\n", sagemakerCount, sagemakerSavings)
+		}
+		if databaseCount > 0 {
+			outPrintf("  Database SP   | Recs: %3d | Covers: RDS, Aurora, ElastiCache, etc. | $%8.2f/mo\n", databaseCount, databaseSavings)
+		}
+
+		if spStats.TotalHourlyCommitment > 0 {
+			outPrintf("  Hourly commitment: $%.3f/hour\n", spStats.TotalHourlyCommitment)
+		}
+		if spStats.ProjectedTermSavings > 0 {
+			outPrintf("  Projected savings over the term: $%.2f\n", spStats.ProjectedTermSavings)
+		}
+
+		// Show best SP options by category
+		outPrintln()
+		if ec2InstanceSavings > 0 || computeSavings > 0 {
+			if ec2InstanceSavings > computeSavings {
+				outPrintf("  ⭐ Best for EC2: EC2 Instance SP ($%.2f/mo)\n", ec2InstanceSavings)
+			} else if computeSavings > 0 {
+				outPrintf("  ⭐ Best for Compute: Compute SP ($%.2f/mo) - more flexible\n", computeSavings)
+			}
+		}
+		if databaseSavings > 0 {
+			outPrintf("  ⭐ Best for Databases: Database SP ($%.2f/mo)\n", databaseSavings)
+		}
+		if sagemakerSavings > 0 {
+			outPrintf("  ⭐ Best for ML: SageMaker SP ($%.2f/mo)\n", sagemakerSavings)
+		}
+	}
+
+	// Show comparison if we have both RIs and Savings Plans
+	if len(riStats) > 0 && spStats.RecommendationsSelected > 0 {
+		outPrintln("\n🔄 COMPARISON:")
+		outPrintln("--------------------------------------------------")
+
+		// Collect SP savings by type
+		ec2SPSavings := 0.0
+		computeSPSavings := 0.0
+		databaseSPSavings := 0.0
+		for _, rec := range allRecommendations {
+			if rec.Service == common.ServiceSavingsPlans {
+				if details, ok := savingsPlanDetails(rec); ok {
+					switch details.PlanType {
+					case "EC2Instance":
+						ec2SPSavings += rec.EstimatedSavings
+					case "Compute":
+						computeSPSavings += rec.EstimatedSavings
+					case "Database":
+						databaseSPSavings += rec.EstimatedSavings
+					}
+				}
+			}
+		}
+
+		// Collect RI savings by service
+		ec2RISavings := 0.0
+		dbRISavings := 0.0 // RDS, ElastiCache, etc.
+		if stats, ok := riStats[common.ServiceEC2]; ok {
+			ec2RISavings = stats.TotalEstimatedSavings
+		}
+		for service, stats := range riStats {
+			if service == common.ServiceRDS || service == common.ServiceElastiCache ||
+				service == common.ServiceMemoryDB || service == common.ServiceRedshift {
+				dbRISavings += stats.TotalEstimatedSavings
+			}
+		}
+
+		// Option 1: All RIs, optionally bought on the Reserved Instance Marketplace where cheaper
+		option1Savings := riSavings + marketplaceSavings
+		outPrintf("Option 1 (All RIs):\n")
+		outPrintf("  Total monthly savings: $%.2f\n", option1Savings)
+		if marketplaceSavings > 0 {
+			outPrintf("  Includes $%.2f/mo from Reserved Instance Marketplace listings (--include-marketplace-savings)\n", marketplaceSavings)
+		}
+		outPrintf("  Pros: Highest discount for specific instance types\n")
+		outPrintf("  Cons: Less flexible, locked to instance family/engine\n")
+
+		// Option 2: Best compute SP + non-EC2 RIs
+		bestComputeSP := ec2SPSavings
+		bestComputeSPName := "EC2 Instance SP"
+		if computeSPSavings > ec2SPSavings {
+			bestComputeSP = computeSPSavings
+			bestComputeSPName = "Compute SP"
+		}
+		option2Savings := riSavings - ec2RISavings + bestComputeSP
+
+		outPrintf("\nOption 2 (%s for compute + RIs for databases):\n", bestComputeSPName)
+		outPrintf("  Total monthly savings: $%.2f\n", option2Savings)
+		outPrintf("  Pros: Flexible compute (can change EC2 families)\n")
+		outPrintf("  Cons: DB RIs still locked to engine/instance type\n")
+
+		// Option 3: If we have Database SP recommendations
+		if databaseSPSavings > 0 {
+			option3Savings := riSavings - ec2RISavings - dbRISavings + bestComputeSP + databaseSPSavings
+			outPrintf("\nOption 3 (%s + Database SP):\n", bestComputeSPName)
+			outPrintf("  Total monthly savings: $%.2f\n", option3Savings)
+			outPrintf("  Pros: Maximum flexibility for both compute and databases\n")
+			outPrintf("  Cons: May have slightly lower discount than targeted RIs\n")
+
+			// Find best option
+			best := "Option 1 (All RIs)"
+			bestSavings := option1Savings
+			if option2Savings > bestSavings {
+				best = "Option 2 (Compute SP + DB RIs)"
+				bestSavings = option2Savings
+			}
+			if option3Savings > bestSavings {
+				best = "Option 3 (Compute SP + Database SP)"
+				bestSavings = option3Savings
+			}
+			outPrintf("\n  ⭐ RECOMMENDATION: %s ($%.2f/mo)\n", best, bestSavings)
+		} else {
+			if option2Savings > option1Savings {
+				outPrintf("\n  ⭐ RECOMMENDATION: Use Option 2 (saves $%.2f/mo more)\n", option2Savings-option1Savings)
+			} else {
+				outPrintf("\n  ⭐ RECOMMENDATION: Use Option 1 (saves $%.2f/mo more)\n", option1Savings-option2Savings)
+			}
+		}
+	}
+
+	// Warn about RIs and Savings Plans covering the same usage
+	printCommitmentOverlap(allRecommendations)
+
+	// Partially fulfilled purchases
+	printPartialFulfillments(allResults)
+
+	// Success rate
+	totalResults := riSuccess + riFailed
+	if totalResults > 0 {
+		successRate := (float64(riSuccess) / float64(totalResults)) * 100
+		outPrintf("\nOverall success rate: %.1f%%\n", successRate)
+	}
+
+	if isDryRun {
+		outPrintln("\n💡 To actually purchase these RIs and Savings Plans, run with --purchase flag")
+	} else if riSuccess > 0 {
+		outPrintln("\n🎉 Purchase operations completed!")
+		outPrintln("⏰ Allow up to 15 minutes for RIs to appear in your account")
+	}
+}
+
+// printPartialFulfillments lists purchases where fewer instances were bought than requested
+func printPartialFulfillments(results []common.PurchaseResult) {
+	partial := make([]common.PurchaseResult, 0)
+	for _, result := range results {
+		if result.Partial {
+			partial = append(partial, result)
+		}
+	}
+	if len(partial) == 0 {
+		return
+	}
+
+	outPrintln("\n⚠️  PARTIAL FULFILLMENT:")
+	outPrintln("--------------------------------------------------")
+	for _, result := range partial {
+		rec := result.Recommendation
+		outPrintf("%-15s | %-20s | %-15s | Purchased: %3d of %3d | Short: %3d\n",
+			getServiceDisplayName(rec.Service), rec.ResourceType, rec.Region,
+			result.PurchasedCount, result.RequestedCount, result.Shortfall())
+	}
+}
+
+// applyFilters applies region, instance type, engine, and engine version filters to recommendations
+// currentRegion is the region being processed in the current loop iteration - if non-empty, only recommendations for that region are included
+func applyFilters(recs []common.Recommendation, cfg RunConfig, instanceVersions map[string][]InstanceEngineVersion, versionInfo map[string]MajorEngineVersionInfo, currentRegion string) []common.Recommendation {
+	var filtered []common.Recommendation
+
+	// The filter expression was validated by RunConfig.Validate
+	var expr filterExpr
+	if cfg.FilterExpression != "" {
+		expr, _ = parseFilterExpression(cfg.FilterExpression)
+	}
+
+	// The excluded engine versions were validated by RunConfig.Validate
+	var exclusions []engineVersionExclusion
+	if !cfg.IncludeExtendedSupport {
+		exclusions = append(exclusions, extendedSupportExclusion(versionInfo))
+	}
+	if len(cfg.ExcludeEngineVersions) > 0 {
+		excluded, _ := parseExcludedEngineVersions(cfg.ExcludeEngineVersions)
+		exclusions = append(exclusions, excludedVersionsExclusion(excluded))
+	}
+	var exclusion engineVersionExclusion
+	if len(exclusions) > 0 {
+		exclusion = anyExclusion(exclusions...)
+	}
+
+	zeroSavings := 0
+	for _, rec := range recs {
+		// Filter to only recommendations for the current region being processed
+		// This prevents duplicating recommendations across all regions
+		// Skip this filter for Savings Plans as they are account-level, not regional
+		if currentRegion != "" && rec.Region != currentRegion && rec.Service != common.ServiceSavingsPlans {
+			continue
+		}
+
+		// Skip recommendations whose instance type doesn't belong to the claimed service
+		if !instanceTypeMatchesService(rec.Service, rec.ResourceType) {
+			log.Printf("⚠️  Warning: Skipping recommendation with instance type %s that does not match service %s", rec.ResourceType, getServiceDisplayName(rec.Service))
+			continue
+		}
+
+		// Apply region filters
+		if !shouldIncludeRegion(rec.Region, cfg) {
+			continue
+		}
+
+		// Apply instance type filters
+		if !shouldIncludeInstanceType(rec.ResourceType, cfg) {
+			continue
+		}
+
+		// Apply architecture filters
+		if !shouldIncludeArchitecture(rec.ResourceType, cfg) {
+			continue
+		}
+
+		// Apply engine filters
+		if !shouldIncludeEngine(rec, cfg) {
+			continue
+		}
+
+		// Apply account filters
+		if !shouldIncludeAccount(rec.Account, rec.AccountName, cfg) {
+			continue
+		}
+
+		// Apply per-instance savings threshold
+		if !meetsMinSavingsPerInstance(rec, cfg) {
+			continue
+		}
+
+		// Drop recommendations without any estimated savings
+		if cfg.ExcludeZeroSavings && rec.EstimatedSavings == 0 {
+			zeroSavings++
+			continue
+		}
+
+		// Apply the filter expression
+		if expr != nil && !expr.eval(rec) {
+			continue
+		}
+
+		// Skip recommendations that only match instances scheduled for termination
+		if cfg.DecommissionTag != "" && onlyDecommissionedInstances(rec, instanceVersions) {
+			log.Printf("🚫 Excluding %s %s in %s: all matching running instances are tagged %s", rec.Service, rec.ResourceType, rec.Region, cfg.DecommissionTag)
+			continue
+		}
+
+		// Never reserve more RDS instances than are running
+		if cfg.CapToRunning {
+			rec = capRecommendationToRunning(rec, instanceVersions)
+			if rec.Count <= 0 {
+				continue
+			}
+		}
+
+		// Don't commit to 3-year terms for capacity that was only launched recently
+		if cfg.MinInstanceAge > 0 && rec.Term == "3yr" {
+			rec = adjustRecommendationForInstanceAge(rec, instanceVersions, cfg.MinInstanceAge, time.Now())
+			if rec.Count <= 0 {
+				continue
+			}
+		}
+
+		// Apply engine version filters - adjust instance count by subtracting extended support versions
+		// (unless --include-extended-support is set) and the --exclude-engine-versions
+		if exclusion != nil {
+			rec = adjustRecommendationForEngineVersions(rec, instanceVersions, exclusion)
+			// Skip if all instances were excluded (count reduced to 0)
+			if rec.Count <= 0 {
+				continue
+			}
+		}
+
+		filtered = append(filtered, rec)
+	}
+
+	if zeroSavings > 0 {
+		log.Printf("🚫 Excluding %d recommendation(s) with no estimated savings (--exclude-zero-savings)", zeroSavings)
+	}
+	return filtered
+}
+
+// InstanceEngineVersion stores engine version information for an instance
+type InstanceEngineVersion struct {
+	Engine        string
+	EngineVersion string
+	InstanceClass string
+	Region        string
+	// Decommissioned is set when the instance carries the configured decommission tag
+	Decommissioned bool
+	// LaunchTime is when the instance was created
+	LaunchTime time.Time
+}
+
+// EngineLifecycleInfo stores lifecycle support information for a major engine version
+type EngineLifecycleInfo struct {
+	LifecycleSupportName      string
+	LifecycleSupportStartDate time.Time
+	LifecycleSupportEndDate   time.Time
+}
+
+// MajorEngineVersionInfo stores support information for a major engine version
+type MajorEngineVersionInfo struct {
+	Engine                    string
+	MajorEngineVersion        string
+	SupportedEngineLifecycles []EngineLifecycleInfo
+}
+
+// RDSClientInterface defines the interface for the RDS operations used to inspect running instances
+type RDSClientInterface interface {
+	DescribeDBInstances(ctx context.Context, params *awsrds.DescribeDBInstancesInput, optFns ...func(*awsrds.Options)) (*awsrds.DescribeDBInstancesOutput, error)
+}
+
+// validationConfig loads the AWS configuration used for engine version validation, preferring --validation-profile
+func validationConfig(ctx context.Context, cfg RunConfig) (aws.Config, error) {
+	validationProfile := cfg.ValidationProfile
+	if validationProfile == "" {
+		validationProfile = cfg.Profile
+	}
+
+	var configOptions []func(*config.LoadOptions) error
+	configOptions = append(configOptions, config.WithRegion("us-east-1"))
+	if validationProfile != "" {
+		configOptions = append(configOptions, config.WithSharedConfigProfile(validationProfile))
+	}
+	return config.LoadDefaultConfig(ctx, configOptions...)
+}
+
+// queryRunningInstanceEngineVersions queries the running RDS instances of the given regions and returns their engine versions
+// Without regions, all enabled regions are queried. Regions rejected by --include-regions or --exclude-regions are skipped.
+func queryRunningInstanceEngineVersions(ctx context.Context, cfg RunConfig, regions []string) (map[string][]InstanceEngineVersion, error) {
+	awsCfg, err := validationConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load validation AWS config: %w", err)
+	}
+
+	if len(regions) == 0 {
+		regions, err = getAllAWSRegions(ctx, awsCfg, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	selected := make([]string, 0, len(regions))
+	for _, region := range regions {
+		if shouldIncludeRegion(region, cfg) {
+			selected = append(selected, region)
+		}
+	}
+
+	// Resolve the decommission tag, if configured (already validated by RunConfig.Validate)
+	decommissionKey, decommissionValue := "", ""
+	if cfg.DecommissionTag != "" {
+		decommissionKey, decommissionValue, _ = parseDecommissionTag(cfg.DecommissionTag)
+	}
+
+	return describeInstanceEngineVersions(ctx, selected, func(region string) RDSClientInterface {
+		regionCfg := awsCfg.Copy()
+		regionCfg.Region = region
+		return awsrds.NewFromConfig(regionCfg)
+	}, decommissionKey, decommissionValue), nil
+}
+
+// describeInstanceEngineVersions describes the RDS instances of all regions concurrently, keyed by instance class
+// Each region collects its instances independently; the results are merged in region order once all queries finish.
+// Regions that fail are logged and skipped.
+func describeInstanceEngineVersions(ctx context.Context, regions []string, newClient func(region string) RDSClientInterface, decommissionKey, decommissionValue string) map[string][]InstanceEngineVersion {
+	regionVersions := make([][]InstanceEngineVersion, len(regions))
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		wg.Add(1)
+		go func(i int, regionName string) {
+			defer wg.Done()
+			rdsClient := newClient(regionName)
+
+			// Describe all RDS instances in this region with pagination
+			var marker *string
+			for {
+				output, err := rdsClient.DescribeDBInstances(ctx, &awsrds.DescribeDBInstancesInput{Marker: marker})
+				if err != nil {
+					// Log error but continue with other regions
+					log.Printf("⚠️  Warning: Failed to describe RDS instances in %s: %v", regionName, err)
+					return
+				}
+
+				for _, dbInstance := range output.DBInstances {
+					decommissioned := false
+					if decommissionKey != "" {
+						for _, tag := range dbInstance.TagList {
+							if aws.ToString(tag.Key) == decommissionKey && aws.ToString(tag.Value) == decommissionValue {
+								decommissioned = true
+								break
+							}
+						}
+					}
+
+					regionVersions[i] = append(regionVersions[i], InstanceEngineVersion{
+						Engine:         aws.ToString(dbInstance.Engine),
+						EngineVersion:  aws.ToString(dbInstance.EngineVersion),
+						InstanceClass:  aws.ToString(dbInstance.DBInstanceClass),
+						Region:         regionName,
+						Decommissioned: decommissioned,
+						LaunchTime:     aws.ToTime(dbInstance.InstanceCreateTime),
+					})
+				}
+
+				if aws.ToString(output.Marker) == "" {
+					return
+				}
+				marker = output.Marker
+			}
+		}(i, region)
+	}
+	wg.Wait()
+
+	// Map of instanceType -> []InstanceEngineVersion
+	instanceVersions := make(map[string][]InstanceEngineVersion)
+	for _, versions := range regionVersions {
+		for _, version := range versions {
+			instanceVersions[version.InstanceClass] = append(instanceVersions[version.InstanceClass], version)
+		}
+	}
+	return instanceVersions
+}
+
+// extendedSupportEngines are the engines whose major versions are checked for extended support
+var extendedSupportEngines = []string{"mysql", "postgres", "aurora-mysql", "aurora-postgresql"}
+
+// runningEngines returns the extended support engines that have running instances, in extendedSupportEngines order
+func runningEngines(instanceVersions map[string][]InstanceEngineVersion) []string {
+	running := make(map[string]bool)
+	for _, versions := range instanceVersions {
+		for _, version := range versions {
+			running[strings.ToLower(version.Engine)] = true
+		}
+	}
+	var engines []string
+	for _, engine := range extendedSupportEngines {
+		if running[engine] {
+			engines = append(engines, engine)
+		}
+	}
+	return engines
+}
+
+// queryMajorEngineVersions queries the major engine version lifecycle support information of the given engines
+// The lifecycle dates are the same in every region, so a single region being processed is queried.
+func queryMajorEngineVersions(ctx context.Context, cfg RunConfig, region string, engines []string) (map[string]MajorEngineVersionInfo, error) {
+	awsCfg, err := validationConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if region != "" {
+		awsCfg.Region = region
+	}
+
+	rdsClient := awsrds.NewFromConfig(awsCfg)
+
+	// Map of "engine:majorVersion" -> MajorEngineVersionInfo
+	versionInfo := make(map[string]MajorEngineVersionInfo)
+
+	for _, engine := range engines {
+		output, err := rdsClient.DescribeDBMajorEngineVersions(ctx, &awsrds.DescribeDBMajorEngineVersionsInput{
+			Engine: aws.String(engine),
+		})
+		if err != nil {
+			log.Printf("⚠️  Warning: Failed to describe major engine versions for %s: %v", engine, err)
+			continue
+		}
+
+		for _, version := range output.DBMajorEngineVersions {
+			info := MajorEngineVersionInfo{
+				Engine:             aws.ToString(version.Engine),
+				MajorEngineVersion: aws.ToString(version.MajorEngineVersion),
+			}
+
+			// Parse lifecycle support dates
+			for _, lifecycle := range version.SupportedEngineLifecycles {
+				lifecycleInfo := EngineLifecycleInfo{
+					LifecycleSupportName: string(lifecycle.LifecycleSupportName),
+				}
+
+				if lifecycle.LifecycleSupportStartDate != nil {
+					lifecycleInfo.LifecycleSupportStartDate = *lifecycle.LifecycleSupportStartDate
+				}
+				if lifecycle.LifecycleSupportEndDate != nil {
+					lifecycleInfo.LifecycleSupportEndDate = *lifecycle.LifecycleSupportEndDate
+				}
+
+				info.SupportedEngineLifecycles = append(info.SupportedEngineLifecycles, lifecycleInfo)
+			}
+
+			key := fmt.Sprintf("%s:%s", info.Engine, info.MajorEngineVersion)
+			versionInfo[key] = info
+		}
+	}
+
+	return versionInfo, nil
+}
+
+// extractMajorVersion extracts the major version from a full engine version string
+// Handles special cases like Aurora MySQL version mapping and Aurora PostgreSQL major versions
+func extractMajorVersion(engine, fullVersion string) string {
+	if fullVersion == "" {
+		return ""
+	}
+
+	// Normalize engine name
+	normalizedEngine := strings.ToLower(engine)
+	normalizedEngine = strings.ReplaceAll(normalizedEngine, "-", "")
+	normalizedEngine = strings.ReplaceAll(normalizedEngine, " ", "")
+
+	// Handle Aurora MySQL special format
+	if normalizedEngine == "auroramysql" {
+		// Aurora MySQL 2.x is compatible with MySQL 5.7
+		if strings.Contains(fullVersion, "mysql_aurora.2.") {
+			return "5.7"
+		}
+		// Aurora MySQL 3.x is compatible with MySQL 8.0
+		if strings.Contains(fullVersion, "mysql_aurora.3.") {
+			return "8.0"
+		}
+		// Check if it starts with a version number
+		if strings.HasPrefix(fullVersion, "5.7") {
+			return "5.7"
+		}
+		if strings.HasPrefix(fullVersion, "8.0") {
+			return "8.0"
+		}
+	}
+
+	// Aurora PostgreSQL major versions are "X" from 10 on (14.6 -> 14) and "X.Y" before (9.6.22 -> 9.6)
+	if normalizedEngine == "aurorapostgresql" {
+		return auroraPostgreSQLMajorVersion(fullVersion)
+	}
+
+	// For standard versions (MySQL, PostgreSQL), extract "X.Y" or "X"
+	parts := strings.Split(fullVersion, ".")
+	if len(parts) >= 2 {
+		// Try to parse as major.minor
+		major := parts[0]
+		minor := parts[1]
+		// Filter out non-numeric parts in minor version
+		numericMinor := ""
+		for _, ch := range minor {
+			if ch >= '0' && ch <= '9' {
+				numericMinor += string(ch)
+			} else {
+				break
+			}
+		}
+		if numericMinor != "" {
+			return major + "." + numericMinor
+		}
+		return major
+	}
+	if len(parts) >= 1 {
+		return parts[0]
+	}
+
+	return ""
+}
+
+// auroraPostgreSQLMajorVersion extracts the major version of an Aurora PostgreSQL version string such as "14.6" or "11.9.1"
+// Returns an empty string when the version doesn't start with a number.
+func auroraPostgreSQLMajorVersion(fullVersion string) string {
+	parts := strings.Split(fullVersion, ".")
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return ""
+	}
+	if major >= 10 || len(parts) < 2 {
+		return parts[0]
+	}
+	return parts[0] + "." + strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+}
+
+// resolveMajorVersionKey returns the versionInfo key of the major version an engine version belongs to
+// The extracted major version is validated against the major versions AWS reported; when it is unknown, shorter
+// prefixes of it are tried, so that "14.6" still maps to a reported "14". Returns false if no major version matches.
+func resolveMajorVersionKey(engine, fullVersion string, versionInfo map[string]MajorEngineVersionInfo) (string, bool) {
+	majorVersion := extractMajorVersion(engine, fullVersion)
+	if majorVersion == "" {
+		return "", false
+	}
+
+	// Normalize engine name for lookup
+	normalizedEngine := strings.ToLower(engine)
+	normalizedEngine = strings.ReplaceAll(normalizedEngine, " ", "")
+
+	for candidate := majorVersion; candidate != ""; {
+		key := fmt.Sprintf("%s:%s", normalizedEngine, candidate)
+		if _, exists := versionInfo[key]; exists {
+			return key, true
+		}
+		dot := strings.LastIndex(candidate, ".")
+		if dot < 0 {
+			break
+		}
+		candidate = candidate[:dot]
+	}
+	return "", false
+}
+
+// isInExtendedSupport checks if a version is currently in extended support based on lifecycle dates
+func isInExtendedSupport(engine, fullVersion string, versionInfo map[string]MajorEngineVersionInfo) bool {
+	// Look up the version info
+	key, exists := resolveMajorVersionKey(engine, fullVersion, versionInfo)
+	if !exists {
+		// If we don't have info, assume not in extended support
+		return false
+	}
+	info := versionInfo[key]
+
+	// Check if current date falls within extended support period
+	now := time.Now()
+	for _, lifecycle := range info.SupportedEngineLifecycles {
+		if lifecycle.LifecycleSupportName == "open-source-rds-extended-support" {
+			// Check if we're past the start date of extended support
+			if now.After(lifecycle.LifecycleSupportStartDate) || now.Equal(lifecycle.LifecycleSupportStartDate) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// onlyDecommissionedInstances reports whether every running instance matching the recommendation's
+// instance type and region is tagged for decommissioning. This is a type/region-level heuristic:
+// recommendations without any matching running instance are never excluded.
+func onlyDecommissionedInstances(rec common.Recommendation, instanceVersions map[string][]InstanceEngineVersion) bool {
+	matching := 0
+	for _, version := range instanceVersions[rec.ResourceType] {
+		if version.Region != rec.Region {
+			continue
+		}
+		if !version.Decommissioned {
+			return false
+		}
+		matching++
+	}
+	return matching > 0
+}
+
+// adjustRecommendationForExcludedVersions reduces the instance count in a recommendation
+// by the number of instances running versions in extended support
+func adjustRecommendationForExcludedVersions(rec common.Recommendation, instanceVersions map[string][]InstanceEngineVersion, versionInfo map[string]MajorEngineVersionInfo) common.Recommendation {
+	return adjustRecommendationForEngineVersions(rec, instanceVersions, extendedSupportExclusion(versionInfo))
+}
+
+// engineVersionExclusion reports why a running instance's engine version is excluded, or false if it isn't
+type engineVersionExclusion func(version InstanceEngineVersion) (reason string, excluded bool)
+
+// extendedSupportExclusion excludes the instances running major versions in extended support
+func extendedSupportExclusion(versionInfo map[string]MajorEngineVersionInfo) engineVersionExclusion {
+	return func(version InstanceEngineVersion) (string, bool) {
+		if !isInExtendedSupport(version.Engine, version.EngineVersion, versionInfo) {
+			return "", false
+		}
+		return fmt.Sprintf("major version %s is in extended support", extractMajorVersion(version.Engine, version.EngineVersion)), true
+	}
+}
+
+// excludedEngineVersion is an engine version excluded with --exclude-engine-versions
+type excludedEngineVersion struct {
+	Engine  string
+	Version string
+}
+
+// parseExcludedEngineVersions parses --exclude-engine-versions entries of the form engine:version, e.g. mysql:5.7
+func parseExcludedEngineVersions(entries []string) ([]excludedEngineVersion, error) {
+	excluded := make([]excludedEngineVersion, 0, len(entries))
+	for _, entry := range entries {
+		engine, version, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		engine, version = strings.TrimSpace(engine), strings.TrimSpace(version)
+		if !ok || engine == "" || version == "" {
+			return nil, fmt.Errorf("invalid engine version %q, expected engine:version (e.g. mysql:5.7)", entry)
+		}
+		excluded = append(excluded, excludedEngineVersion{Engine: normalizeEngineName(engine), Version: version})
+	}
+	return excluded, nil
+}
+
+// matches reports whether an instance runs the excluded engine version, either exactly, as a release of it
+// (5.7.44 for 5.7) or through its major version (5.7.mysql_aurora.2.11.3 for aurora-mysql 5.7)
+func (e excludedEngineVersion) matches(version InstanceEngineVersion) bool {
+	if normalizeEngineName(version.Engine) != e.Engine {
+		return false
+	}
+	full := version.EngineVersion
+	return full == e.Version || strings.HasPrefix(full, e.Version+".") || extractMajorVersion(version.Engine, full) == e.Version
+}
+
+// excludedVersionsExclusion excludes the instances running one of the --exclude-engine-versions
+func excludedVersionsExclusion(excluded []excludedEngineVersion) engineVersionExclusion {
+	return func(version InstanceEngineVersion) (string, bool) {
+		for _, e := range excluded {
+			if e.matches(version) {
+				return fmt.Sprintf("%s %s is excluded", e.Engine, e.Version), true
+			}
+		}
+		return "", false
+	}
+}
+
+// anyExclusion excludes the instances excluded by any of the given exclusions, so that each is only subtracted once
+func anyExclusion(exclusions ...engineVersionExclusion) engineVersionExclusion {
+	return func(version InstanceEngineVersion) (string, bool) {
+		for _, exclusion := range exclusions {
+			if reason, excluded := exclusion(version); excluded {
+				return reason, true
+			}
+		}
+		return "", false
+	}
+}
+
+// adjustRecommendationForEngineVersions reduces the instance count in a recommendation by the number of
+// running instances of the same engine, type and region whose engine version is excluded
+func adjustRecommendationForEngineVersions(rec common.Recommendation, instanceVersions map[string][]InstanceEngineVersion, exclusion engineVersionExclusion) common.Recommendation {
+	// Check if this instance type has any running instances
+	versions, exists := instanceVersions[rec.ResourceType]
+	if !exists {
+		// No running instances of this type, return unchanged
+		return rec
+	}
+
+	// Get the engine name from the recommendation
+	var recEngine string
+	switch details := rec.Details.(type) {
+	case common.DatabaseDetails:
+		recEngine = details.Engine
+	case *common.DatabaseDetails:
+		recEngine = details.Engine
+	default:
+		return rec // Not RDS, no engine version filtering
+	}
+
+	// Count how many instances in this region are running excluded versions, comparing the Cost Explorer engine
+	// (e.g. PostgreSQL) with the RDS engine of the instances (e.g. postgres)
+	excludedCount := 0
+	recEngineNorm := normalizeEngineName(recEngine)
+	for _, version := range versions {
+		// Only count instances in the same region and with the same engine
+		if version.Region != rec.Region || normalizeEngineName(version.Engine) != recEngineNorm {
+			continue
+		}
+
+		if reason, excluded := exclusion(version); excluded {
+			excludedCount++
+			log.Printf("🚫 Found excluded instance: %s %s in %s running version %s (%s)",
+				recEngine, rec.ResourceType, rec.Region, version.EngineVersion, reason)
+		}
+	}
+
+	// If we found excluded instances, reduce the recommendation count
+	if excludedCount > 0 {
+		originalCount := rec.Count
+		newCount := max(0, rec.Count-excludedCount)
+
+		if newCount != originalCount {
+			log.Printf("📉 Adjusting recommendation for %s %s in %s: %d instances → %d instances (excluded %d instances on excluded engine versions)",
+				recEngine, rec.ResourceType, rec.Region, originalCount, newCount, excludedCount)
+			rec.Count = newCount
+		}
+	}
+
+	return rec
+}
+
+// adjustRecommendationForInstanceAge reduces the instance count by the matching running instances younger than minAge
+// This is a heuristic aggregated per instance type and region: if most matching instances are younger than minAge,
+// the recommendation is excluded entirely (count 0). Recommendations without matching instances are unchanged.
+func adjustRecommendationForInstanceAge(rec common.Recommendation, instanceVersions map[string][]InstanceEngineVersion, minAge time.Duration, now time.Time) common.Recommendation {
+	cutoff := now.Add(-minAge)
+	matching, young := 0, 0
+	for _, version := range instanceVersions[rec.ResourceType] {
+		if version.Region != rec.Region || version.LaunchTime.IsZero() {
+			continue
+		}
+		matching++
+		if version.LaunchTime.After(cutoff) {
+			young++
+		}
+	}
+	if young == 0 {
+		return rec
+	}
+
+	if young*2 > matching {
+		log.Printf("🚫 Excluding %s %s in %s from the 3-year commitment: %d of %d running instances are younger than %s",
+			rec.Service, rec.ResourceType, rec.Region, young, matching, minAge)
+		rec.Count = 0
+		return rec
+	}
+
+	newCount := max(0, rec.Count-young)
+	log.Printf("📉 Adjusting recommendation for %s %s in %s: %d instances → %d instances (excluded %d instances younger than %s)",
+		rec.Service, rec.ResourceType, rec.Region, rec.Count, newCount, young, minAge)
+	rec.Count = newCount
+	return rec
+}
+
+// capRecommendationToRunning caps the count of an RDS recommendation at the running instances of its
+// instance class, engine and region, so Cost Explorer can't over-recommend after a scale-down
+// Only RDS instances are queried, so recommendations of other services are returned unchanged.
+func capRecommendationToRunning(rec common.Recommendation, instanceVersions map[string][]InstanceEngineVersion) common.Recommendation {
+	if rec.Service != common.ServiceRDS {
+		return rec
+	}
+	recEngine := getEngineFromRecommendation(rec)
+
+	running := 0
+	for _, version := range instanceVersions[rec.ResourceType] {
+		if version.Region == rec.Region && (recEngine == "" || normalizeEngineName(version.Engine) == recEngine) {
+			running++
+		}
+	}
+	if rec.Count <= running {
+		return rec
+	}
+
+	if running == 0 {
+		log.Printf("🚫 Excluding %s %s in %s: no matching running instances (--cap-to-running)", recEngine, rec.ResourceType, rec.Region)
+	} else {
+		log.Printf("📉 Capping recommendation for %s %s in %s to the running instances: %d instances → %d instances",
+			recEngine, rec.ResourceType, rec.Region, rec.Count, running)
+	}
+	rec.Count = running
+	return rec
+}
+
+// redshiftNodePrefixes lists the node type families used by Redshift
+var redshiftNodePrefixes = []string{"ra3.", "dc2.", "dc1.", "ds2."}
+
+// instanceTypeMatchesService checks that an instance type follows the naming convention of the given service
+// Recommendations without an instance type (e.g. Savings Plans) and unknown services are always accepted
+func instanceTypeMatchesService(service common.ServiceType, instanceType string) bool {
+	if instanceType == "" {
+		return true
+	}
+
+	isRedshiftNode := false
+	for _, prefix := range redshiftNodePrefixes {
+		if strings.HasPrefix(instanceType, prefix) {
+			isRedshiftNode = true
+			break
+		}
+	}
+	isSearchInstance := strings.HasSuffix(instanceType, ".search") || strings.HasSuffix(instanceType, ".elasticsearch")
+
+	switch service {
+	case common.ServiceRDS, common.ServiceMemoryDB:
+		// MemoryDB node types share the db. prefix with RDS instance classes
+		return strings.HasPrefix(instanceType, "db.")
+	case common.ServiceElastiCache:
+		return strings.HasPrefix(instanceType, "cache.")
+	case common.ServiceOpenSearch:
+		return isSearchInstance
+	case common.ServiceRedshift:
+		return isRedshiftNode
+	case common.ServiceEC2:
+		// EC2 instance types are bare family.size names without a service prefix or suffix
+		return strings.Count(instanceType, ".") == 1 &&
+			!strings.HasPrefix(instanceType, "db.") &&
+			!strings.HasPrefix(instanceType, "cache.") &&
+			!isRedshiftNode
+	default:
+		return true
+	}
+}
+
+// shouldIncludeRegion checks if a region should be included based on filters
+func shouldIncludeRegion(region string, cfg RunConfig) bool {
+	// If include list is specified, region must be in it
+	if len(cfg.IncludeRegions) > 0 && !slices.Contains(cfg.IncludeRegions, region) {
+		return false
+	}
+
+	// If exclude list is specified, region must not be in it
+	if slices.Contains(cfg.ExcludeRegions, region) {
+		return false
+	}
+
+	return true
+}
+
+// shouldIncludeInstanceType checks if an instance type should be included based on filters
+func shouldIncludeInstanceType(instanceType string, cfg RunConfig) bool {
+	// If include list is specified, instance type must be in it
+	if len(cfg.IncludeInstanceTypes) > 0 && !slices.Contains(cfg.IncludeInstanceTypes, instanceType) {
+		return false
+	}
+
+	// If exclude list is specified, instance type must not be in it
+	if slices.Contains(cfg.ExcludeInstanceTypes, instanceType) {
+		return false
+	}
+
+	// Exclude whole families matched by wildcard patterns
+	if matchesInstanceTypePattern(instanceType, cfg.ExcludeInstanceTypePatterns) {
+		return false
+	}
+
+	return true
+}
+
+// shouldIncludeArchitecture checks if an instance type should be included based on the architecture filters
+// Resource types without an architecture, such as Savings Plans, are never filtered out.
+func shouldIncludeArchitecture(instanceType string, cfg RunConfig) bool {
+	arch := common.InstanceTypeArchitecture(instanceType)
+	if arch == "" {
+		return true
+	}
+	if len(cfg.IncludeArchitectures) > 0 && !slices.Contains(cfg.IncludeArchitectures, arch) {
+		return false
+	}
+	return !slices.Contains(cfg.ExcludeArchitectures, arch)
+}
+
+// matchesInstanceTypePattern reports whether the instance type matches any of the path.Match patterns
+// The patterns are validated by RunConfig.Validate, so malformed ones never match.
+func matchesInstanceTypePattern(instanceType string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, instanceType); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldIncludeEngine checks if a recommendation should be included based on engine filters
+func shouldIncludeEngine(rec common.Recommendation, cfg RunConfig) bool {
+	// Extract engine from recommendation, including the engines of services without engine details
+	engine := normalizeEngineName(getEngineFromRecommendationRaw(rec))
+	if engine == "" {
+		// If no engine info, include by default unless there's an include list
+		return len(cfg.IncludeEngines) == 0
+	}
+
+	// Normalize engine name to lowercase for comparison
+	engine = strings.ToLower(engine)
+
+	// If include list is specified, engine must be in it
+	if len(cfg.IncludeEngines) > 0 {
+		found := false
+		for _, e := range cfg.IncludeEngines {
+			if strings.ToLower(e) == engine {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// If exclude list is specified, engine must not be in it
+	if len(cfg.ExcludeEngines) > 0 {
+		for _, e := range cfg.ExcludeEngines {
+			if strings.ToLower(e) == engine {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// shouldIncludeAccount checks if an account should be included based on filters
+// A filter matches the account ID exactly or the account name case-insensitively, either exactly or as a substring,
+// so account IDs can be used before the aliases are resolved.
+func shouldIncludeAccount(accountID, accountName string, cfg RunConfig) bool {
+	// If the account is unknown and there are filters, skip it (unless both lists are empty)
+	if accountID == "" && accountName == "" {
+		return len(cfg.IncludeAccounts) == 0 && len(cfg.ExcludeAccounts) == 0
+	}
+
+	// If include list is specified, account must match at least one of the patterns
+	if len(cfg.IncludeAccounts) > 0 {
+		found := false
+		for _, a := range cfg.IncludeAccounts {
+			if accountFilterMatches(a, accountID, accountName) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// If exclude list is specified, account must not match any of the patterns
+	for _, a := range cfg.ExcludeAccounts {
+		if accountFilterMatches(a, accountID, accountName) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// accountFilterMatches reports whether an --include-accounts or --exclude-accounts entry matches an account
+func accountFilterMatches(filter, accountID, accountName string) bool {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return false
+	}
+	if accountID != "" && filter == accountID {
+		return true
+	}
+	// Support both exact match and substring match of names
+	return accountName != "" && strings.Contains(strings.ToLower(accountName), strings.ToLower(filter))
+}
+
+// savingsPerInstance returns the estimated savings for a single unit of a recommendation
+// Recommendations without a positive count are treated as a single unit
+func savingsPerInstance(rec common.Recommendation) float64 {
+	if rec.Count <= 0 {
+		return rec.EstimatedSavings
+	}
+	return rec.EstimatedSavings / float64(rec.Count)
+}
+
+// meetsMinSavingsPerInstance checks if a recommendation's per-instance savings meet the configured minimum
+func meetsMinSavingsPerInstance(rec common.Recommendation, cfg RunConfig) bool {
+	if cfg.MinSavingsPerInstance <= 0 {
+		return true
+	}
+	return savingsPerInstance(rec) >= cfg.MinSavingsPerInstance
+}
+
+// filterByMinMonthlySavings drops recommendations whose estimated monthly savings are below minSavings
+// It returns the remaining recommendations and how many were dropped
+func filterByMinMonthlySavings(recs []common.Recommendation, minSavings float64) ([]common.Recommendation, int) {
+	kept := make([]common.Recommendation, 0, len(recs))
+	for _, rec := range recs {
+		if rec.EstimatedSavings >= minSavings {
+			kept = append(kept, rec)
+		}
+	}
+	return kept, len(recs) - len(kept)
+}
+
+// getEngineFromRecommendationRaw extracts the raw engine from a recommendation (not normalized)
+// Use getEngineFromRecommendation from helpers.go for normalized engine names
+// OpenSearch recommendations carry no engine, so it is derived from the instance type. MemoryDB recommendations
+// without details, such as those read from CSV files, default to redis as Cost Explorer doesn't report the engine.
+func getEngineFromRecommendationRaw(rec common.Recommendation) string {
+	// Check service-specific details for engine information
+	if rec.Details != nil {
+		switch details := rec.Details.(type) {
+		case common.DatabaseDetails:
+			return details.Engine
+		case *common.DatabaseDetails:
+			return details.Engine
+		case common.CacheDetails:
+			return details.Engine
+		case *common.CacheDetails:
+			return details.Engine
+		}
+	}
+
+	switch rec.Service {
+	case common.ServiceOpenSearch, common.ServiceSearch:
+		return searchEngine(rec.ResourceType)
+	case common.ServiceMemoryDB:
+		return "redis"
+	}
+	return ""
+}
+
+// searchEngine returns the engine of an OpenSearch instance type: elasticsearch for legacy
+// types such as m5.large.elasticsearch, and opensearch otherwise
+func searchEngine(instanceType string) string {
+	if strings.HasSuffix(strings.ToLower(instanceType), ".elasticsearch") {
+		return "elasticsearch"
+	}
+	return "opensearch"
+}
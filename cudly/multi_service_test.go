@@ -0,0 +1,4818 @@
+package cudly
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ==================== Mock Implementations ====================
+
+// MockEC2Client for testing getAllAWSRegions
+type MockEC2Client struct {
+	mock.Mock
+}
+
+func (m *MockEC2Client) DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ec2.DescribeRegionsOutput), args.Error(1)
+}
+
+// MockRecommendationsClient for testing
+type MockRDSClient struct {
+	mock.Mock
+}
+
+func (m *MockRDSClient) DescribeDBInstances(ctx context.Context, params *rds.DescribeDBInstancesInput, optFns ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+	args := m.Called(ctx, aws.ToString(params.Marker))
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*rds.DescribeDBInstancesOutput), args.Error(1)
+}
+
+type MockRecommendationsClient struct {
+	mock.Mock
+}
+
+func (m *MockRecommendationsClient) GetRecommendations(ctx context.Context, params common.RecommendationParams) ([]common.Recommendation, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]common.Recommendation), args.Error(1)
+}
+
+func (m *MockRecommendationsClient) GetRecommendationsForService(ctx context.Context, service common.ServiceType) ([]common.Recommendation, error) {
+	args := m.Called(ctx, service)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]common.Recommendation), args.Error(1)
+}
+
+func (m *MockRecommendationsClient) GetAllRecommendations(ctx context.Context) ([]common.Recommendation, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]common.Recommendation), args.Error(1)
+}
+
+// MockServiceClient implements provider.ServiceClient for testing
+type MockServiceClient struct {
+	mock.Mock
+}
+
+func (m *MockServiceClient) GetServiceType() common.ServiceType {
+	args := m.Called()
+	return args.Get(0).(common.ServiceType)
+}
+
+func (m *MockServiceClient) GetRegion() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockServiceClient) GetRecommendations(ctx context.Context, params common.RecommendationParams) ([]common.Recommendation, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]common.Recommendation), args.Error(1)
+}
+
+func (m *MockServiceClient) PurchaseCommitment(ctx context.Context, rec common.Recommendation) (common.PurchaseResult, error) {
+	args := m.Called(ctx, rec)
+	return args.Get(0).(common.PurchaseResult), args.Error(1)
+}
+
+func (m *MockServiceClient) ValidateOffering(ctx context.Context, rec common.Recommendation) error {
+	args := m.Called(ctx, rec)
+	return args.Error(0)
+}
+
+func (m *MockServiceClient) GetOfferingDetails(ctx context.Context, rec common.Recommendation) (*common.OfferingDetails, error) {
+	args := m.Called(ctx, rec)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*common.OfferingDetails), args.Error(1)
+}
+
+func (m *MockServiceClient) GetExistingCommitments(ctx context.Context) ([]common.Commitment, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]common.Commitment), args.Error(1)
+}
+
+func (m *MockServiceClient) GetValidResourceTypes(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// ==================== Test Helpers ====================
+
+// globalVarsSnapshot captures the testCfg for tests
+type globalVarsSnapshot struct {
+	cfg RunConfig
+}
+
+// saveGlobalVars captures current testCfg state
+func saveGlobalVars() *globalVarsSnapshot {
+	return &globalVarsSnapshot{
+		cfg: testCfg,
+	}
+}
+
+// restoreGlobalVars restores testCfg state from snapshot
+func (s *globalVarsSnapshot) restore() {
+	testCfg = s.cfg
+}
+
+// ==================== Core Function Tests ====================
+
+func TestRunToolMultiService_Validation(t *testing.T) {
+	// Save original values
+	origCfg := testCfg
+
+	// Restore after test
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	tests := []struct {
+		name          string
+		setupVars     func()
+		expectPanic   bool
+	}{
+		{
+			name: "Valid input - all services",
+			setupVars: func() {
+				testCfg.Coverage = 75.0
+				testCfg.PaymentOption = "partial-upfront"
+				testCfg.TermYears = 3
+				testCfg.AllServices = true
+				testCfg.Services = nil
+			},
+			expectPanic: false,
+		},
+		{
+			name: "Valid input - specific services",
+			setupVars: func() {
+				testCfg.Coverage = 50.0
+				testCfg.PaymentOption = "no-upfront"
+				testCfg.TermYears = 1
+				testCfg.AllServices = false
+				testCfg.Services = []string{"rds", "ec2"}
+			},
+			expectPanic: false,
+		},
+		{
+			name: "Invalid coverage - too high",
+			setupVars: func() {
+				testCfg.Coverage = 150.0
+				testCfg.PaymentOption = "partial-upfront"
+				testCfg.TermYears = 3
+			},
+			expectPanic: true,
+		},
+		{
+			name: "Invalid coverage - negative",
+			setupVars: func() {
+				testCfg.Coverage = -10.0
+				testCfg.PaymentOption = "all-upfront"
+				testCfg.TermYears = 1
+			},
+			expectPanic: true,
+		},
+		{
+			name: "Invalid payment option",
+			setupVars: func() {
+				testCfg.Coverage = 80.0
+				testCfg.PaymentOption = "invalid-payment"
+				testCfg.TermYears = 3
+			},
+			expectPanic: true,
+		},
+		{
+			name: "Invalid term years",
+			setupVars: func() {
+				testCfg.Coverage = 80.0
+				testCfg.PaymentOption = "partial-upfront"
+				testCfg.TermYears = 2 // Only 1 or 3 allowed
+			},
+			expectPanic: true,
+		},
+		{
+			name: "Default to RDS when no services",
+			setupVars: func() {
+				testCfg.Coverage = 80.0
+				testCfg.PaymentOption = "all-upfront"
+				testCfg.TermYears = 3
+				testCfg.AllServices = false
+				testCfg.Services = nil
+			},
+			expectPanic: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupVars()
+
+			if tt.expectPanic {
+				// Can't easily test log.Fatalf, so we skip execution
+				// In production code, would use dependency injection
+				return
+			}
+
+			// For non-panic tests, verify the setup is valid
+			assert.GreaterOrEqual(t, testCfg.Coverage, 0.0)
+			assert.LessOrEqual(t, testCfg.Coverage, 100.0)
+			assert.Contains(t, []string{"all-upfront", "partial-upfront", "no-upfront"}, testCfg.PaymentOption)
+			assert.Contains(t, []int{1, 3}, testCfg.TermYears)
+		})
+	}
+}
+
+func TestGetAllAWSRegions(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		mockOutput    *ec2.DescribeRegionsOutput
+		mockError     error
+		expectRegions []string
+		expectError   bool
+	}{
+		{
+			name: "Success with multiple regions",
+			mockOutput: &ec2.DescribeRegionsOutput{
+				Regions: []types.Region{
+					{RegionName: aws.String("us-east-1")},
+					{RegionName: aws.String("eu-west-1")},
+					{RegionName: aws.String("ap-south-1")},
+				},
+			},
+			expectRegions: []string{"ap-south-1", "eu-west-1", "us-east-1"}, // Sorted
+			expectError:   false,
+		},
+		{
+			name:          "Error from AWS API",
+			mockOutput:    nil,
+			mockError:     errors.New("AWS API error"),
+			expectRegions: nil,
+			expectError:   true,
+		},
+		{
+			name: "Empty regions list",
+			mockOutput: &ec2.DescribeRegionsOutput{
+				Regions: []types.Region{},
+			},
+			expectRegions: []string{},
+			expectError:   false,
+		},
+		{
+			name: "Regions with nil names",
+			mockOutput: &ec2.DescribeRegionsOutput{
+				Regions: []types.Region{
+					{RegionName: aws.String("us-east-1")},
+					{RegionName: nil},
+					{RegionName: aws.String("eu-west-1")},
+				},
+			},
+			expectRegions: []string{"eu-west-1", "us-east-1"}, // Sorted, nil excluded
+			expectError:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockEC2 := &MockEC2Client{}
+			mockEC2.On("DescribeRegions", ctx, mock.Anything).Return(tt.mockOutput, tt.mockError)
+
+			// Use the new interface-based function
+			regions, err := getAllAWSRegionsWithClient(ctx, mockEC2, nil)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, regions)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectRegions, regions)
+			}
+
+			mockEC2.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Integration test", func(t *testing.T) {
+		// This test requires actual AWS credentials
+		if testing.Short() {
+			t.Skip("Skipping integration test")
+		}
+
+		cfg := aws.Config{Region: "us-east-1"}
+		regions, err := getAllAWSRegions(ctx, cfg, RunConfig{})
+
+		if err == nil {
+			assert.NotNil(t, regions)
+			assert.Greater(t, len(regions), 0)
+
+			// Verify regions are sorted
+			for i := 1; i < len(regions); i++ {
+				assert.LessOrEqual(t, regions[i-1], regions[i])
+			}
+		}
+	})
+}
+
+func TestGetAllAWSRegionsWithClientRetriesTransientErrors(t *testing.T) {
+	ctx := context.Background()
+	output := &ec2.DescribeRegionsOutput{
+		Regions: []types.Region{{RegionName: aws.String("us-west-2")}, {RegionName: aws.String("us-east-1")}},
+	}
+
+	t.Run("transient error is retried", func(t *testing.T) {
+		mockEC2 := &MockEC2Client{}
+		mockEC2.On("DescribeRegions", ctx, mock.Anything).Return(nil, errors.New("RequestLimitExceeded")).Once()
+		mockEC2.On("DescribeRegions", ctx, mock.Anything).Return(output, nil).Once()
+
+		regions, err := getAllAWSRegionsWithClient(ctx, mockEC2, recommendations.NewRetryRateLimiter(2, time.Millisecond))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"us-east-1", "us-west-2"}, regions)
+		mockEC2.AssertNumberOfCalls(t, "DescribeRegions", 2)
+	})
+
+	t.Run("persistent error is returned after the retries", func(t *testing.T) {
+		mockEC2 := &MockEC2Client{}
+		mockEC2.On("DescribeRegions", ctx, mock.Anything).Return(nil, errors.New("UnauthorizedOperation"))
+
+		regions, err := getAllAWSRegionsWithClient(ctx, mockEC2, recommendations.NewRetryRateLimiter(2, time.Millisecond))
+		assert.ErrorContains(t, err, "failed to describe regions after 2 retries")
+		assert.Nil(t, regions)
+		mockEC2.AssertNumberOfCalls(t, "DescribeRegions", 3)
+	})
+}
+
+func TestGetAllAWSRegionsUsesCache(t *testing.T) {
+	creds := aws.AnonymousCredentials{}
+	awsRegionCache.Lock()
+	awsRegionCache.regions[creds] = []string{"eu-west-1"}
+	awsRegionCache.Unlock()
+	t.Cleanup(func() {
+		awsRegionCache.Lock()
+		delete(awsRegionCache.regions, creds)
+		awsRegionCache.Unlock()
+	})
+
+	// No request is made: the cached regions are returned
+	regions, err := getAllAWSRegions(context.Background(), aws.Config{Region: "us-east-1", Credentials: creds}, RunConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eu-west-1"}, regions)
+}
+
+func TestDiscoverRegionsForService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		service         common.ServiceType
+		mockReturns     []common.Recommendation
+		expectedRegions []string
+		expectError     bool
+	}{
+		{
+			name:    "Multiple unique regions",
+			service: common.ServiceRDS,
+			mockReturns: []common.Recommendation{
+				{Region: "us-east-1", ResourceType: "db.t3.micro"},
+				{Region: "us-west-2", ResourceType: "db.t3.small"},
+				{Region: "eu-west-1", ResourceType: "db.t3.medium"},
+			},
+			expectedRegions: []string{"eu-west-1", "us-east-1", "us-west-2"},
+		},
+		{
+			name:    "Duplicate regions",
+			service: common.ServiceEC2,
+			mockReturns: []common.Recommendation{
+				{Region: "us-east-1", ResourceType: "t3.micro"},
+				{Region: "us-east-1", ResourceType: "t3.small"},
+				{Region: "us-west-2", ResourceType: "t3.medium"},
+			},
+			expectedRegions: []string{"us-east-1", "us-west-2"},
+		},
+		{
+			name:            "No recommendations",
+			service:         common.ServiceElastiCache,
+			mockReturns:     []common.Recommendation{},
+			expectedRegions: []string{},
+		},
+		{
+			name:    "Recommendations with empty regions filtered",
+			service: common.ServiceRedshift,
+			mockReturns: []common.Recommendation{
+				{Region: "us-east-1", ResourceType: "ra3.xlplus"},
+				{Region: "", ResourceType: "ra3.4xlarge"},
+				{Region: "us-west-2", ResourceType: "ra3.16xlarge"},
+			},
+			expectedRegions: []string{"us-east-1", "us-west-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockRecommendationsClient{}
+			mockClient.On("GetRecommendationsForService", ctx, tt.service).Return(tt.mockReturns, nil)
+
+			// Now we can use the actual function directly since it accepts an interface
+			regions, err := discoverRegionsForService(ctx, mockClient, tt.service)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedRegions, regions)
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCalculateServiceStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  common.ServiceType
+		recs     []common.Recommendation
+		results  []common.PurchaseResult
+		expected ServiceProcessingStats
+	}{
+		{
+			name:    "Empty inputs",
+			service: common.ServiceRDS,
+			recs:    []common.Recommendation{},
+			results: []common.PurchaseResult{},
+			expected: ServiceProcessingStats{
+				Service:                 common.ServiceRDS,
+				RegionsProcessed:        0,
+				RecommendationsFound:    0,
+				RecommendationsSelected: 0,
+				InstancesProcessed:      0,
+				SuccessfulPurchases:     0,
+				FailedPurchases:         0,
+				TotalEstimatedSavings:   0,
+			},
+		},
+		{
+			name:    "Multiple regions with mixed results",
+			service: common.ServiceEC2,
+			recs: []common.Recommendation{
+				{Region: "us-east-1", Count: 2, EstimatedSavings: 100},
+				{Region: "us-west-2", Count: 3, EstimatedSavings: 200},
+				{Region: "eu-west-1", Count: 1, EstimatedSavings: 50},
+			},
+			results: []common.PurchaseResult{
+				{Success: true},
+				{Success: true},
+				{Success: false},
+			},
+			expected: ServiceProcessingStats{
+				Service:                 common.ServiceEC2,
+				RegionsProcessed:        3,
+				RecommendationsFound:    3,
+				RecommendationsSelected: 3,
+				InstancesProcessed:      6,
+				SuccessfulPurchases:     2,
+				FailedPurchases:         1,
+				TotalEstimatedSavings:   350,
+			},
+		},
+		{
+			name:    "Same region multiple recommendations",
+			service: common.ServiceElastiCache,
+			recs: []common.Recommendation{
+				{Region: "us-east-1", Count: 1, EstimatedSavings: 100},
+				{Region: "us-east-1", Count: 2, EstimatedSavings: 200},
+				{Region: "us-east-1", Count: 3, EstimatedSavings: 300},
+			},
+			results: []common.PurchaseResult{
+				{Success: true},
+				{Success: true},
+				{Success: true},
+			},
+			expected: ServiceProcessingStats{
+				Service:                 common.ServiceElastiCache,
+				RegionsProcessed:        1,
+				RecommendationsFound:    3,
+				RecommendationsSelected: 3,
+				InstancesProcessed:      6,
+				SuccessfulPurchases:     3,
+				FailedPurchases:         0,
+				TotalEstimatedSavings:   600,
+			},
+		},
+		{
+			name:    "Partially fulfilled purchase",
+			service: common.ServiceRDS,
+			recs: []common.Recommendation{
+				{Region: "us-east-1", Count: 10, EstimatedSavings: 500},
+			},
+			results: []common.PurchaseResult{
+				{Success: true, RequestedCount: 10, PurchasedCount: 7, Partial: true},
+			},
+			expected: ServiceProcessingStats{
+				Service:                 common.ServiceRDS,
+				RegionsProcessed:        1,
+				RecommendationsFound:    1,
+				RecommendationsSelected: 1,
+				InstancesProcessed:      10,
+				SuccessfulPurchases:     1,
+				PartialPurchases:        1,
+				InstancesShortfall:      3,
+				TotalEstimatedSavings:   500,
+			},
+		},
+		{
+			name:    "Upfront and amortized costs",
+			service: common.ServiceRDS,
+			recs: []common.Recommendation{
+				{Region: "us-east-1", Count: 1, EstimatedSavings: 50, UpfrontCost: 1200, AmortizedMonthlyCost: 100},
+				{Region: "us-east-1", Count: 2, EstimatedSavings: 80, UpfrontCost: 600, AmortizedMonthlyCost: 75},
+			},
+			results: []common.PurchaseResult{
+				{Success: true},
+				{Success: true},
+			},
+			expected: ServiceProcessingStats{
+				Service:                   common.ServiceRDS,
+				RegionsProcessed:          1,
+				RecommendationsFound:      2,
+				RecommendationsSelected:   2,
+				InstancesProcessed:        3,
+				SuccessfulPurchases:       2,
+				TotalEstimatedSavings:     130,
+				TotalUpfrontCost:          1800,
+				TotalAmortizedMonthlyCost: 175,
+			},
+		},
+		{
+			name:    "Projected savings over mixed terms",
+			service: common.ServiceRDS,
+			recs: []common.Recommendation{
+				{Region: "us-east-1", Count: 1, EstimatedSavings: 50, Term: "1yr"},
+				{Region: "us-east-1", Count: 1, EstimatedSavings: 100, Term: "3yr"},
+			},
+			results: []common.PurchaseResult{
+				{Success: true},
+				{Success: true},
+			},
+			expected: ServiceProcessingStats{
+				Service:                 common.ServiceRDS,
+				RegionsProcessed:        1,
+				RecommendationsFound:    2,
+				RecommendationsSelected: 2,
+				InstancesProcessed:      2,
+				SuccessfulPurchases:     2,
+				TotalEstimatedSavings:   150,
+				ProjectedTermSavings:    50*12 + 100*36,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculateServiceStats(tt.service, tt.recs, tt.results)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestPrintServiceSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		service common.ServiceType
+		stats   ServiceProcessingStats
+	}{
+		{
+			name:    "With savings",
+			service: common.ServiceRDS,
+			stats: ServiceProcessingStats{
+				Service:                 common.ServiceRDS,
+				RegionsProcessed:        2,
+				RecommendationsSelected: 5,
+				InstancesProcessed:      10,
+				SuccessfulPurchases:     4,
+				FailedPurchases:         1,
+				TotalEstimatedSavings:   1500.50,
+			},
+		},
+		{
+			name:    "With upfront cost",
+			service: common.ServiceElastiCache,
+			stats: ServiceProcessingStats{
+				Service:                   common.ServiceElastiCache,
+				RegionsProcessed:          1,
+				RecommendationsSelected:   2,
+				InstancesProcessed:        4,
+				TotalEstimatedSavings:     200,
+				TotalUpfrontCost:          3600,
+				TotalAmortizedMonthlyCost: 150.25,
+			},
+		},
+		{
+			name:    "Without savings",
+			service: common.ServiceEC2,
+			stats: ServiceProcessingStats{
+				Service:                 common.ServiceEC2,
+				RegionsProcessed:        1,
+				RecommendationsSelected: 0,
+				InstancesProcessed:      0,
+				SuccessfulPurchases:     0,
+				FailedPurchases:         0,
+				TotalEstimatedSavings:   0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Capture stdout
+			old := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			printServiceSummary(tt.service, tt.stats)
+
+			w.Close()
+			os.Stdout = old
+
+			var buf bytes.Buffer
+			io.Copy(&buf, r)
+			output := buf.String()
+
+			// Verify output contains expected information
+			assert.Contains(t, output, getServiceDisplayName(tt.service))
+			assert.Contains(t, output, fmt.Sprintf("Regions processed: %d", tt.stats.RegionsProcessed))
+			assert.Contains(t, output, fmt.Sprintf("Recommendations: %d", tt.stats.RecommendationsSelected))
+			assert.Contains(t, output, fmt.Sprintf("Instances: %d", tt.stats.InstancesProcessed))
+
+			if tt.stats.TotalEstimatedSavings > 0 {
+				assert.Contains(t, output, fmt.Sprintf("$%.2f", tt.stats.TotalEstimatedSavings))
+			}
+			if tt.stats.TotalUpfrontCost > 0 {
+				assert.Contains(t, output, fmt.Sprintf("Upfront cost: $%.2f", tt.stats.TotalUpfrontCost))
+				assert.Contains(t, output, fmt.Sprintf("Amortized monthly cost: $%.2f", tt.stats.TotalAmortizedMonthlyCost))
+			} else {
+				assert.NotContains(t, output, "Upfront cost")
+			}
+		})
+	}
+}
+
+func TestWriteMultiServiceCSVReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []common.PurchaseResult
+		filepath string
+		wantErr  bool
+	}{
+		{
+			name: "RDS results",
+			results: []common.PurchaseResult{
+				{
+					Recommendation: common.Recommendation{
+						Service:           common.ServiceRDS,
+						Region:            "us-east-1",
+						ResourceType:      "db.t3.micro",
+						Count:             2,
+						Term:              "3yr",
+						PaymentOption:     "partial-upfront",
+						EstimatedSavings:  100,
+						SavingsPercentage: 30,
+						Timestamp:         time.Now(),
+						Details: common.DatabaseDetails{
+							Engine:   "mysql",
+							AZConfig: "multi-az",
+						},
+					},
+					Success:      true,
+					CommitmentID: "test-001",
+					Timestamp:    time.Now(),
+				},
+			},
+			filepath: "/tmp/test-rds.csv",
+			wantErr:  false,
+		},
+		{
+			name: "ElastiCache results",
+			results: []common.PurchaseResult{
+				{
+					Recommendation: common.Recommendation{
+						Service:      common.ServiceElastiCache,
+						Region:       "us-west-2",
+						ResourceType: "cache.t3.micro",
+						Count:        1,
+						Term:         "1yr",
+						Details: common.CacheDetails{
+							Engine:   "redis",
+							NodeType: "cache.t3.micro",
+						},
+					},
+					Success:      true,
+					CommitmentID: "test-002",
+					Timestamp:    time.Now(),
+				},
+			},
+			filepath: "/tmp/test-cache.csv",
+			wantErr:  false,
+		},
+		{
+			name: "EC2 results",
+			results: []common.PurchaseResult{
+				{
+					Recommendation: common.Recommendation{
+						Service:      common.ServiceEC2,
+						Region:       "eu-west-1",
+						ResourceType: "t3.medium",
+						Count:        5,
+						Term:         "3yr",
+						Details: common.ComputeDetails{
+							Platform: "Linux/UNIX",
+							Tenancy:  "shared",
+							Scope:    "region",
+						},
+					},
+					Success:      false,
+					CommitmentID: "test-003",
+					Error:        errors.New("Insufficient capacity"),
+					Timestamp:    time.Now(),
+				},
+			},
+			filepath: "/tmp/test-ec2.csv",
+			wantErr:  false,
+		},
+		{
+			name:     "Empty results",
+			results:  []common.PurchaseResult{},
+			filepath: "/tmp/test-empty.csv",
+			wantErr:  false,
+		},
+		{
+			name: "Unknown service type",
+			results: []common.PurchaseResult{
+				{
+					Recommendation: common.Recommendation{
+						Service:      common.ServiceType("unknown"),
+						Region:       "us-east-1",
+						ResourceType: "unknown.large",
+						Count:        1,
+						Term:         "3yr",
+					},
+					Success: true,
+				},
+			},
+			filepath: "/tmp/test-unknown.csv",
+			wantErr:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := writeMultiServiceCSVReport(tt.results, tt.filepath)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// Clean up test files
+			os.Remove(tt.filepath)
+		})
+	}
+}
+
+func TestPrintMultiServiceSummary(t *testing.T) {
+	tests := []struct {
+		name       string
+		recs       []common.Recommendation
+		results    []common.PurchaseResult
+		stats      map[common.ServiceType]ServiceProcessingStats
+		isDryRun   bool
+	}{
+		{
+			name: "Dry run with multiple services",
+			recs: []common.Recommendation{
+				{Service: common.ServiceRDS, Count: 2},
+				{Service: common.ServiceEC2, Count: 3},
+			},
+			results: []common.PurchaseResult{
+				{Success: true, Recommendation: common.Recommendation{Count: 2}},
+				{Success: false, Recommendation: common.Recommendation{Count: 3}},
+			},
+			stats: map[common.ServiceType]ServiceProcessingStats{
+				common.ServiceRDS: {
+					Service:                 common.ServiceRDS,
+					RecommendationsSelected: 1,
+					InstancesProcessed:      2,
+					SuccessfulPurchases:     1,
+					TotalEstimatedSavings:   500.0,
+				},
+				common.ServiceEC2: {
+					Service:                 common.ServiceEC2,
+					RecommendationsSelected: 1,
+					InstancesProcessed:      3,
+					FailedPurchases:         1,
+					TotalEstimatedSavings:   300.0,
+				},
+			},
+			isDryRun: true,
+		},
+		{
+			name: "Actual purchase with success",
+			recs: []common.Recommendation{
+				{Service: common.ServiceElastiCache, Count: 5},
+			},
+			results: []common.PurchaseResult{
+				{Success: true, Recommendation: common.Recommendation{Count: 5}},
+			},
+			stats: map[common.ServiceType]ServiceProcessingStats{
+				common.ServiceElastiCache: {
+					Service:                 common.ServiceElastiCache,
+					RecommendationsSelected: 1,
+					InstancesProcessed:      5,
+					SuccessfulPurchases:     1,
+					TotalEstimatedSavings:   1000.0,
+				},
+			},
+			isDryRun: false,
+		},
+		{
+			name:     "Empty results",
+			recs:     []common.Recommendation{},
+			results:  []common.PurchaseResult{},
+			stats:    map[common.ServiceType]ServiceProcessingStats{},
+			isDryRun: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Capture stdout
+			old := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			printMultiServiceSummary(tt.recs, tt.results, tt.stats, tt.isDryRun, 0)
+
+			w.Close()
+			os.Stdout = old
+
+			var buf bytes.Buffer
+			io.Copy(&buf, r)
+			output := buf.String()
+
+			// Verify output contains expected information
+			assert.Contains(t, output, "Final Summary")
+			if tt.isDryRun {
+				assert.Contains(t, output, "DRY RUN")
+			} else {
+				assert.Contains(t, output, "ACTUAL PURCHASE")
+			}
+
+			if len(tt.stats) > 0 {
+				assert.Contains(t, output, "RESERVED INSTANCES:")
+			}
+
+			if len(tt.results) > 0 {
+				assert.Contains(t, output, "success rate")
+			}
+		})
+	}
+}
+
+func TestFormatServices(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []common.ServiceType
+		expected string
+	}{
+		{
+			name:     "Empty list",
+			services: []common.ServiceType{},
+			expected: "",
+		},
+		{
+			name:     "Single service",
+			services: []common.ServiceType{common.ServiceRDS},
+			expected: "RDS",
+		},
+		{
+			name:     "Multiple services",
+			services: []common.ServiceType{common.ServiceRDS, common.ServiceEC2, common.ServiceElastiCache},
+			expected: "RDS, EC2, ElastiCache",
+		},
+		{
+			name:     "All services",
+			services: getAllServices(),
+			expected: "RDS, ElastiCache, EC2, OpenSearch, Redshift, MemoryDB, Savings Plans",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatServices(tt.services)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGetServiceDisplayName(t *testing.T) {
+	tests := []struct {
+		service  common.ServiceType
+		expected string
+	}{
+		{common.ServiceRDS, "RDS"},
+		{common.ServiceElastiCache, "ElastiCache"},
+		{common.ServiceEC2, "EC2"},
+		{common.ServiceOpenSearch, "OpenSearch"},
+		{common.ServiceElasticsearch, "OpenSearch"},
+		{common.ServiceRedshift, "Redshift"},
+		{common.ServiceMemoryDB, "MemoryDB"},
+		{common.ServiceType("custom"), "custom"},
+		{common.ServiceType(""), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.service), func(t *testing.T) {
+			result := getServiceDisplayName(tt.service)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestApplyCommonCoverage(t *testing.T) {
+	recs := []common.Recommendation{
+		{Count: 10, EstimatedSavings: 100},
+		{Count: 5, EstimatedSavings: 50},
+		{Count: 2, EstimatedSavings: 20},
+	}
+
+	tests := []struct {
+		name              string
+		coverage          float64
+		expectedCount     int
+		expectedInstances []int
+	}{
+		{
+			name:              "100% coverage",
+			coverage:          100.0,
+			expectedCount:     3,
+			expectedInstances: []int{10, 5, 2},
+		},
+		{
+			name:              "50% coverage",
+			coverage:          50.0,
+			expectedCount:     3,
+			expectedInstances: []int{5, 2, 1}, // Using floor: 10*0.5=5, 5*0.5=2.5→2, 2*0.5=1
+		},
+		{
+			name:              "0% coverage",
+			coverage:          0.0,
+			expectedCount:     0,
+			expectedInstances: []int{},
+		},
+		{
+			name:              "75% coverage",
+			coverage:          75.0,
+			expectedCount:     3,
+			expectedInstances: []int{7, 3, 1}, // Using floor: 10*0.75=7.5→7, 5*0.75=3.75→3, 2*0.75=1.5→1
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := applyCommonCoverage(recs, tt.coverage, CoverageRoundingFloor)
+			assert.Equal(t, tt.expectedCount, len(result))
+
+			for i, rec := range result {
+				if i < len(tt.expectedInstances) {
+					assert.Equal(t, tt.expectedInstances[i], rec.Count)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyCoverageRounding(t *testing.T) {
+	recs := []common.Recommendation{
+		{Count: 10, EstimatedSavings: 100},
+		{Count: 5, EstimatedSavings: 50},
+		{Count: 2, EstimatedSavings: 20},
+		{Count: 1, EstimatedSavings: 10},
+	}
+
+	tests := []struct {
+		name              string
+		rounding          string
+		coverage          float64
+		expectedInstances []int
+	}{
+		{"floor 50%", CoverageRoundingFloor, 50, []int{5, 2, 1}},
+		{"ceil 50%", CoverageRoundingCeil, 50, []int{5, 3, 1, 1}},
+		{"round 50%", CoverageRoundingRound, 50, []int{5, 3, 1, 1}},
+		{"floor 75%", CoverageRoundingFloor, 75, []int{7, 3, 1}},
+		{"ceil 75%", CoverageRoundingCeil, 75, []int{8, 4, 2, 1}},
+		{"round 75%", CoverageRoundingRound, 75, []int{8, 4, 2, 1}},
+		{"round 30%", CoverageRoundingRound, 30, []int{3, 2, 1}},
+		{"ceil exact product", CoverageRoundingCeil, 70, []int{7, 4, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := applyCommonCoverage(recs, tt.coverage, tt.rounding)
+
+			counts := make([]int, 0, len(result))
+			for _, rec := range result {
+				counts = append(counts, rec.Count)
+			}
+			assert.Equal(t, tt.expectedInstances, counts)
+		})
+	}
+}
+
+func TestValidateCoverageRounding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"", CoverageRoundingFloor, false},
+		{"Ceil", CoverageRoundingCeil, false},
+		{"round", CoverageRoundingRound, false},
+		{"nearest", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			rounding, err := validateCoverageRounding(tt.input)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "invalid coverage-rounding")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rounding)
+		})
+	}
+}
+
+func TestApplyCoverageScalesSavingsPlanCosts(t *testing.T) {
+	recs := []common.Recommendation{{
+		Service:              common.ServiceSavingsPlans,
+		EstimatedSavings:     100,
+		UpfrontCost:          8760,
+		AmortizedMonthlyCost: 730,
+		Details:              &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1},
+	}}
+
+	result := applyCommonCoverage(recs, 50, CoverageRoundingFloor)
+
+	require.Len(t, result, 1)
+	assert.Equal(t, 4380.0, result[0].UpfrontCost)
+	assert.Equal(t, 365.0, result[0].AmortizedMonthlyCost)
+	assert.Equal(t, 8760.0, recs[0].UpfrontCost, "the input is not modified")
+}
+
+func TestApplyCoverageScalesSavingsPlanCommitment(t *testing.T) {
+	tests := []struct {
+		name    string
+		details common.ServiceDetails
+	}{
+		{"pointer details", &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 2}},
+		{"value details", common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs := []common.Recommendation{{Service: common.ServiceSavingsPlans, EstimatedSavings: 100, Details: tt.details}}
+
+			result := applyCommonCoverage(recs, 25, CoverageRoundingFloor)
+
+			require.Len(t, result, 1)
+			details, ok := savingsPlanDetails(result[0])
+			require.True(t, ok)
+			assert.Equal(t, 0.5, details.HourlyCommitment)
+			assert.Equal(t, 25.0, result[0].EstimatedSavings)
+			original, _ := savingsPlanDetails(recs[0])
+			assert.Equal(t, 2.0, original.HourlyCommitment, "the input is not modified")
+		})
+	}
+}
+
+func TestCalculateServiceStatsHourlyCommitment(t *testing.T) {
+	recs := []common.Recommendation{
+		{Service: common.ServiceSavingsPlans, Region: "us-east-1", Details: &common.SavingsPlanDetails{HourlyCommitment: 1.5}},
+		{Service: common.ServiceSavingsPlans, Region: "us-east-1", Details: common.SavingsPlanDetails{HourlyCommitment: 0.25}},
+	}
+
+	stats := calculateServiceStats(common.ServiceSavingsPlans, recs, nil)
+	assert.Equal(t, 1.75, stats.TotalHourlyCommitment)
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	printServiceSummary(common.ServiceSavingsPlans, stats)
+
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Contains(t, buf.String(), "Hourly commitment: $1.750/hour")
+}
+
+func TestProcessService_EdgeCases(t *testing.T) {
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	// Set test values
+	testCfg.PaymentOption = "partial-upfront"
+	testCfg.TermYears = 3
+
+	tests := []struct {
+		name       string
+		setupFunc  func()
+		service    common.ServiceType
+		isDryRun   bool
+		expectRecs int
+	}{
+		{
+			name: "With explicit regions",
+			setupFunc: func() {
+				testCfg.Regions = []string{"us-east-1"}
+				testCfg.Coverage = 100.0
+			},
+			service:    common.ServiceRDS,
+			isDryRun:   true,
+			expectRecs: 0, // Would need mock to return actual recs
+		},
+		{
+			name: "No regions triggers discovery",
+			setupFunc: func() {
+				testCfg.Regions = []string{}
+				testCfg.Coverage = 75.0
+			},
+			service:    common.ServiceEC2,
+			isDryRun:   false,
+			expectRecs: 0, // Would need mock
+		},
+		{
+			name: "Zero coverage",
+			setupFunc: func() {
+				testCfg.Regions = []string{"us-west-2"}
+				testCfg.Coverage = 0.0
+			},
+			service:    common.ServiceElastiCache,
+			isDryRun:   true,
+			expectRecs: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupFunc()
+
+			// Note: This would fail without AWS credentials
+			// For unit tests, we'd need to inject a mock client
+			// This test structure shows the approach
+
+			// Would call: processService(ctx, awsCfg, recClient, accountCache, nil, tt.service, tt.isDryRun, testCfg)
+			// And verify results
+
+			assert.Equal(t, tt.service, tt.service) // Placeholder assertion
+		})
+	}
+}
+
+// TestProcessServiceWithMocks tests the processService function using mocks
+func TestProcessServiceWithMocks(t *testing.T) {
+	ctx := context.Background()
+	awsCfg := aws.Config{Region: "us-east-1"}
+
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	tests := []struct {
+		name        string
+		service     common.ServiceType
+		isDryRun    bool
+		testRegions []string
+		mockRecs    []common.Recommendation
+		setupFunc   func()
+	}{
+		{
+			name:        "RDS dry run with recommendations",
+			service:     common.ServiceRDS,
+			isDryRun:    true,
+			testRegions: []string{"us-east-1"},
+			mockRecs: []common.Recommendation{
+				{ResourceType: "db.t3.micro", Count: 2, Region: "us-east-1", EstimatedSavings: 100},
+				{ResourceType: "db.t3.small", Count: 1, Region: "us-east-1", EstimatedSavings: 200},
+			},
+			setupFunc: func() {
+				testCfg.Coverage = 100.0
+				testCfg.PaymentOption = "partial-upfront"
+				testCfg.TermYears = 3
+			},
+		},
+		{
+			name:        "EC2 with no recommendations",
+			service:     common.ServiceEC2,
+			isDryRun:    true,
+			testRegions: []string{"us-west-2"},
+			mockRecs:    []common.Recommendation{},
+			setupFunc: func() {
+				testCfg.Coverage = 80.0
+				testCfg.PaymentOption = "no-upfront"
+				testCfg.TermYears = 1
+			},
+		},
+		{
+			name:        "ElastiCache with 50% coverage",
+			service:     common.ServiceElastiCache,
+			isDryRun:    false,
+			testRegions: []string{"eu-west-1"},
+			mockRecs: []common.Recommendation{
+				{ResourceType: "cache.t3.micro", Count: 3, Region: "eu-west-1", EstimatedSavings: 150},
+				{ResourceType: "cache.t3.small", Count: 2, Region: "eu-west-1", EstimatedSavings: 250},
+			},
+			setupFunc: func() {
+				testCfg.Coverage = 50.0
+				testCfg.PaymentOption = "all-upfront"
+				testCfg.TermYears = 3
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupFunc()
+
+			// Create mock client
+			mockClient := &MockRecommendationsClient{}
+
+			// Setup expectations
+			termStr := "1yr"
+			if testCfg.TermYears == 3 {
+				termStr = "3yr"
+			}
+			for _, region := range tt.testRegions {
+				params := common.RecommendationParams{
+					Service:        tt.service,
+					Region:         region,
+					PaymentOption:  testCfg.PaymentOption,
+					Term:           termStr,
+					LookbackPeriod: "7d",
+					IncludeSPTypes: testCfg.IncludeSPTypes,
+					ExcludeSPTypes: testCfg.ExcludeSPTypes,
+				}
+				mockClient.On("GetRecommendations", ctx, params).Return(tt.mockRecs, nil)
+			}
+
+			// Set regions in testCfg for this test
+			testCfg.Regions = tt.testRegions
+
+			// Now we can use the actual function directly since it accepts an interface
+			accountCache := NewAccountAliasCache(awsCfg)
+			recs, results, err := processService(ctx, awsCfg, mockClient, accountCache, nil, tt.service, tt.isDryRun, testCfg)
+			require.NoError(t, err)
+
+			if len(tt.mockRecs) > 0 {
+				// Should have recommendations based on coverage
+				expectedCount := int(float64(len(tt.mockRecs)) * testCfg.Coverage / 100.0)
+				if expectedCount > 0 {
+					assert.NotEmpty(t, recs)
+					assert.LessOrEqual(t, len(recs), len(tt.mockRecs))
+				} else {
+					assert.Empty(t, recs)
+				}
+
+				// Check results for dry run
+				if tt.isDryRun && len(recs) > 0 {
+					assert.Equal(t, len(recs), len(results))
+					for _, result := range results {
+						assert.True(t, result.Success)
+						assert.Nil(t, result.Error) // Dry runs are successful, so no error
+						assert.True(t, result.DryRun)
+					}
+				}
+			} else {
+				assert.Empty(t, recs)
+				assert.Empty(t, results)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProcessServiceRetrySkippedRegions(t *testing.T) {
+	ctx := context.Background()
+	awsCfg := aws.Config{Region: "us-east-1"}
+
+	cfg := RunConfig{
+		Regions:              []string{"us-east-1", "us-west-2"},
+		Coverage:             100.0,
+		PaymentOption:        "partial-upfront",
+		TermYears:            3,
+		RetrySkipped:         true,
+		RetrySkippedCooldown: 0,
+	}
+
+	paramsFor := func(region string) common.RecommendationParams {
+		return common.RecommendationParams{
+			Service:        common.ServiceRDS,
+			Region:         region,
+			PaymentOption:  cfg.PaymentOption,
+			Term:           "3yr",
+			LookbackPeriod: "7d",
+		}
+	}
+
+	mockClient := &MockRecommendationsClient{}
+	mockClient.On("GetRecommendations", ctx, paramsFor("us-east-1")).Return([]common.Recommendation{
+		{Service: common.ServiceRDS, ResourceType: "db.t3.micro", Count: 1, Region: "us-east-1"},
+	}, nil).Once()
+	// us-west-2 is throttled on the first pass and succeeds on retry
+	mockClient.On("GetRecommendations", ctx, paramsFor("us-west-2")).Return(nil, errors.New("ThrottlingException")).Once()
+	mockClient.On("GetRecommendations", ctx, paramsFor("us-west-2")).Return([]common.Recommendation{
+		{Service: common.ServiceRDS, ResourceType: "db.t3.small", Count: 2, Region: "us-west-2"},
+	}, nil).Once()
+
+	accountCache := NewAccountAliasCache(awsCfg)
+	recs, results, err := processService(ctx, awsCfg, mockClient, accountCache, nil, common.ServiceRDS, true, cfg)
+	require.NoError(t, err)
+
+	assert.Len(t, recs, 2)
+	assert.Len(t, results, 2)
+	regions := []string{recs[0].Region, recs[1].Region}
+	assert.ElementsMatch(t, []string{"us-east-1", "us-west-2"}, regions)
+	mockClient.AssertExpectations(t)
+}
+
+func TestProcessServiceSkippedRegionsWithoutRetry(t *testing.T) {
+	ctx := context.Background()
+	awsCfg := aws.Config{Region: "us-east-1"}
+
+	cfg := RunConfig{
+		Regions:       []string{"us-west-2"},
+		Coverage:      100.0,
+		PaymentOption: "partial-upfront",
+		TermYears:     3,
+	}
+
+	mockClient := &MockRecommendationsClient{}
+	mockClient.On("GetRecommendations", ctx, mock.Anything).Return(nil, errors.New("ThrottlingException")).Once()
+
+	accountCache := NewAccountAliasCache(awsCfg)
+	recs, results, err := processService(ctx, awsCfg, mockClient, accountCache, nil, common.ServiceRDS, true, cfg)
+	require.NoError(t, err)
+
+	assert.Empty(t, recs)
+	assert.Empty(t, results)
+	mockClient.AssertNumberOfCalls(t, "GetRecommendations", 1)
+}
+
+func TestProcessServiceTerms(t *testing.T) {
+	ctx := context.Background()
+	awsCfg := aws.Config{Region: "us-east-1"}
+
+	cfg := RunConfig{
+		Regions:       []string{"us-east-1"},
+		Coverage:      100.0,
+		PaymentOption: "partial-upfront",
+		TermYears:     1,
+		Terms:         []int{1, 3},
+	}
+
+	paramsFor := func(term string) common.RecommendationParams {
+		return common.RecommendationParams{
+			Service:        common.ServiceRDS,
+			Region:         "us-east-1",
+			PaymentOption:  cfg.PaymentOption,
+			Term:           term,
+			LookbackPeriod: "7d",
+		}
+	}
+
+	mockClient := &MockRecommendationsClient{}
+	mockClient.On("GetRecommendations", ctx, paramsFor("1yr")).Return([]common.Recommendation{
+		{Service: common.ServiceRDS, ResourceType: "db.t3.micro", Count: 1, Region: "us-east-1", Term: "1yr", EstimatedSavings: 10},
+	}, nil).Once()
+	mockClient.On("GetRecommendations", ctx, paramsFor("3yr")).Return([]common.Recommendation{
+		{Service: common.ServiceRDS, ResourceType: "db.t3.micro", Count: 1, Region: "us-east-1", Term: "3yr", EstimatedSavings: 15},
+	}, nil).Once()
+
+	recs, results, err := processService(ctx, awsCfg, mockClient, nil, nil, common.ServiceRDS, true, cfg)
+	require.NoError(t, err)
+
+	require.Len(t, recs, 2)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "1yr", recs[0].Term)
+	assert.Equal(t, "3yr", recs[1].Term)
+	mockClient.AssertExpectations(t)
+}
+
+func TestProcessServiceInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	awsCfg := aws.Config{Region: "us-east-1"}
+	cfg := RunConfig{
+		Regions:       []string{"us-east-1", "us-west-2"},
+		Coverage:      100.0,
+		PaymentOption: "partial-upfront",
+		TermYears:     3,
+		CacheOnly:     true,
+	}
+
+	mockClient := &MockRecommendationsClient{}
+	recs, results, err := processService(ctx, awsCfg, mockClient, nil, nil, common.ServiceRDS, true, cfg)
+	require.NoError(t, err)
+
+	assert.Empty(t, recs)
+	assert.Empty(t, results)
+	mockClient.AssertNotCalled(t, "GetRecommendations", mock.Anything, mock.Anything)
+}
+
+func TestProcessServiceLookbackDays(t *testing.T) {
+	ctx := context.Background()
+	awsCfg := aws.Config{Region: "us-east-1"}
+
+	cfg := RunConfig{
+		Regions:       []string{"us-east-1"},
+		Coverage:      100.0,
+		PaymentOption: "partial-upfront",
+		TermYears:     1,
+		LookbackDays:  60,
+		CacheOnly:     true,
+	}
+
+	mockClient := &MockRecommendationsClient{}
+	mockClient.On("GetRecommendations", ctx, mock.MatchedBy(func(params common.RecommendationParams) bool {
+		return params.LookbackPeriod == "60d"
+	})).Return([]common.Recommendation(nil), nil).Once()
+
+	_, _, err := processService(ctx, awsCfg, mockClient, nil, nil, common.ServiceRDS, true, cfg)
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+	assert.Equal(t, "7d", lookbackPeriod(0))
+}
+
+func TestProcessServiceCacheOnly(t *testing.T) {
+	ctx := context.Background()
+	awsCfg := aws.Config{Region: "us-east-1"}
+
+	cfg := RunConfig{
+		Coverage:      100.0,
+		PaymentOption: "partial-upfront",
+		TermYears:     3,
+		CacheDir:      t.TempDir(),
+		CacheOnly:     true,
+	}
+
+	cache := provider.NewRecommendationCache(cfg.CacheDir, time.Hour)
+	require.NoError(t, cache.Put(common.RecommendationParams{
+		Service:        common.ServiceRDS,
+		Region:         "eu-west-1",
+		PaymentOption:  cfg.PaymentOption,
+		Term:           "3yr",
+		LookbackPeriod: "7d",
+	}, []common.Recommendation{
+		{Service: common.ServiceRDS, ResourceType: "db.r6g.large", Count: 2, Region: "eu-west-1", Account: "123456789012"},
+	}))
+
+	// The live client must never be called in cache-only mode
+	liveClient := &MockRecommendationsClient{}
+	recClient := provider.NewCachingRecommendationsClient(liveClient, cache, true)
+
+	recs, results, err := processService(ctx, awsCfg, recClient, nil, nil, common.ServiceRDS, true, cfg)
+	require.NoError(t, err)
+
+	require.Len(t, recs, 1)
+	assert.Equal(t, "eu-west-1", recs[0].Region)
+	assert.Empty(t, recs[0].AccountName)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].DryRun)
+	liveClient.AssertNotCalled(t, "GetRecommendations", mock.Anything, mock.Anything)
+	liveClient.AssertNotCalled(t, "GetRecommendationsForService", mock.Anything, mock.Anything)
+}
+
+func TestProcessServiceCacheOnlyMissingEntry(t *testing.T) {
+	ctx := context.Background()
+	awsCfg := aws.Config{Region: "us-east-1"}
+
+	cfg := RunConfig{
+		Regions:       []string{"us-west-2"},
+		Coverage:      100.0,
+		PaymentOption: "partial-upfront",
+		TermYears:     3,
+		CacheDir:      t.TempDir(),
+		CacheOnly:     true,
+	}
+
+	cache := provider.NewRecommendationCache(cfg.CacheDir, time.Hour)
+	recClient := provider.NewCachingRecommendationsClient(nil, cache, true)
+
+	_, _, err := processService(ctx, awsCfg, recClient, nil, nil, common.ServiceRDS, true, cfg)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, provider.ErrCacheMiss)
+	assert.Contains(t, err.Error(), "us-west-2")
+}
+
+func TestGeneratePurchaseID_EdgeCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		rec      common.Recommendation
+		region   string
+		index    int
+		isDryRun bool
+	}{
+		{
+			name: "RDS dry run",
+			rec: common.Recommendation{
+				Service:      common.ServiceRDS,
+				ResourceType: "db.t3.micro",
+				Count:        2,
+			},
+			region:   "us-east-1",
+			index:    1,
+			isDryRun: true,
+		},
+		{
+			name: "EC2 actual purchase",
+			rec: common.Recommendation{
+				Service:      common.ServiceEC2,
+				ResourceType: "t3.large",
+				Count:        5,
+			},
+			region:   "eu-west-1",
+			index:    99,
+			isDryRun: false,
+		},
+		{
+			name: "ElastiCache with dots in instance type",
+			rec: common.Recommendation{
+				Service:      common.ServiceElastiCache,
+				ResourceType: "cache.r6g.2xlarge",
+				Count:        1,
+			},
+			region:   "ap-southeast-1",
+			index:    1000,
+			isDryRun: false,
+		},
+		{
+			name: "Unknown service",
+			rec: common.Recommendation{
+				Service:      common.ServiceType("future-service"),
+				ResourceType: "unknown.large",
+				Count:        10,
+			},
+			region:   "us-west-2",
+			index:    1,
+			isDryRun: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCoverage := 80.0
+			id := generatePurchaseID(tt.rec, tt.region, tt.index, tt.isDryRun, testCoverage)
+
+			// Verify ID contains expected parts
+			if tt.isDryRun {
+				assert.Contains(t, id, "dryrun")
+			} else {
+				assert.Contains(t, id, "ri")
+			}
+
+			assert.Contains(t, id, tt.region)
+			assert.Contains(t, id, strings.ReplaceAll(tt.rec.ResourceType, ".", "-"))
+			assert.Contains(t, id, fmt.Sprintf("%dx", tt.rec.Count))
+			// Should contain timestamp (YYYYMMDD-HHMMSS) and UUID suffix (8 chars)
+			assert.Regexp(t, `-\d{8}-\d{6}-[a-f0-9]{8}$`, id)
+		})
+	}
+}
+
+// ==================== Helper Function Tests ====================
+
+func TestCalculateTotalInstances(t *testing.T) {
+	tests := []struct {
+		name     string
+		recs     []common.Recommendation
+		expected int
+	}{
+		{
+			name: "multiple recommendations",
+			recs: []common.Recommendation{
+				{Count: 5},
+				{Count: 3},
+				{Count: 2},
+			},
+			expected: 10,
+		},
+		{
+			name:     "empty recommendations",
+			recs:     []common.Recommendation{},
+			expected: 0,
+		},
+		{
+			name: "single recommendation",
+			recs: []common.Recommendation{
+				{Count: 7},
+			},
+			expected: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total := calculateTotalInstances(tt.recs)
+			assert.Equal(t, tt.expected, total)
+		})
+	}
+}
+
+func TestApplyCoverageToRecommendations(t *testing.T) {
+	tests := []struct {
+		name         string
+		recs         []common.Recommendation
+		coverage     float64
+		expectedRecs int
+	}{
+		{
+			name: "50% coverage of 4 recommendations",
+			recs: []common.Recommendation{
+				{ResourceType: "type1", Count: 2},
+				{ResourceType: "type2", Count: 3},
+				{ResourceType: "type3", Count: 1},
+				{ResourceType: "type4", Count: 4},
+			},
+			coverage:     0.5,
+			expectedRecs: 2,
+		},
+		{
+			name: "100% coverage",
+			recs: []common.Recommendation{
+				{ResourceType: "type1", Count: 2},
+				{ResourceType: "type2", Count: 3},
+			},
+			coverage:     1.0,
+			expectedRecs: 2,
+		},
+		{
+			name: "0% coverage",
+			recs: []common.Recommendation{
+				{ResourceType: "type1", Count: 2},
+				{ResourceType: "type2", Count: 3},
+			},
+			coverage:     0.0,
+			expectedRecs: 0,
+		},
+		{
+			name: "75% coverage of 3 recommendations",
+			recs: []common.Recommendation{
+				{ResourceType: "type1", Count: 2},
+				{ResourceType: "type2", Count: 2},
+				{ResourceType: "type3", Count: 2},
+			},
+			coverage:     0.75,
+			expectedRecs: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := applyCoverageToRecommendations(tt.recs, tt.coverage)
+			assert.Equal(t, tt.expectedRecs, len(result))
+		})
+	}
+}
+
+func TestServiceProcessingOrder(t *testing.T) {
+	// Test that services are processed in a consistent order
+	services := []common.ServiceType{
+		common.ServiceRDS,
+		common.ServiceElastiCache,
+		common.ServiceEC2,
+		common.ServiceOpenSearch,
+		common.ServiceRedshift,
+		common.ServiceMemoryDB,
+	}
+
+	// Verify all expected services are present
+	expectedServices := map[common.ServiceType]bool{
+		common.ServiceRDS:         false,
+		common.ServiceElastiCache: false,
+		common.ServiceEC2:         false,
+		common.ServiceOpenSearch:  false,
+		common.ServiceRedshift:    false,
+		common.ServiceMemoryDB:    false,
+	}
+
+	for _, service := range services {
+		expectedServices[service] = true
+	}
+
+	// Check all services were found
+	for service, found := range expectedServices {
+		assert.True(t, found, "Service %s should be in processing list", service)
+	}
+}
+
+func TestGenerateCSVFilenameHelper(t *testing.T) {
+	tests := []struct {
+		name        string
+		service     common.ServiceType
+		payment     string
+		term        int
+		dryRun      bool
+		expectParts []string
+	}{
+		{
+			name:        "RDS dry run",
+			service:     common.ServiceRDS,
+			payment:     "no-upfront",
+			term:        36,
+			dryRun:      true,
+			expectParts: []string{"rds", "no-upfront", "dryrun"},
+		},
+		{
+			name:        "EC2 actual purchase",
+			service:     common.ServiceEC2,
+			payment:     "all-upfront",
+			term:        12,
+			dryRun:      false,
+			expectParts: []string{"ec2", "all-upfront", "purchase"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := generateCSVFilenameTestHelper(tt.service, tt.payment, tt.term, tt.dryRun)
+
+			for _, part := range tt.expectParts {
+				assert.Contains(t, filename, part)
+			}
+
+			// Should end with .csv
+			assert.Contains(t, filename, ".csv")
+		})
+	}
+}
+
+func TestMultiServiceConfig(t *testing.T) {
+	cfg := MultiServiceConfig{
+		Services: map[common.ServiceType]ServiceConfig{
+			common.ServiceRDS: {
+				Enabled:  true,
+				Coverage: 0.5,
+			},
+			common.ServiceElastiCache: {
+				Enabled:  true,
+				Coverage: 0.8,
+			},
+			common.ServiceEC2: {
+				Enabled:  false,
+				Coverage: 0.0,
+			},
+		},
+		PaymentOption: "no-upfront",
+		TermYears:     3,
+		DryRun:        true,
+	}
+
+	// Test enabled services count
+	enabledCount := 0
+	for _, svcConfig := range cfg.Services {
+		if svcConfig.Enabled {
+			enabledCount++
+		}
+	}
+	assert.Equal(t, 2, enabledCount)
+
+	// Test coverage values
+	assert.Equal(t, 0.5, cfg.Services[common.ServiceRDS].Coverage)
+	assert.Equal(t, 0.8, cfg.Services[common.ServiceElastiCache].Coverage)
+}
+
+// ==================== Benchmark Tests ====================
+
+func BenchmarkCalculateTotalInstances(b *testing.B) {
+	recs := make([]common.Recommendation, 100)
+	for i := range recs {
+		recs[i] = common.Recommendation{Count: i%10 + 1}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = calculateTotalInstances(recs)
+	}
+}
+
+func BenchmarkApplyCoverageToRecommendations(b *testing.B) {
+	recs := make([]common.Recommendation, 100)
+	for i := range recs {
+		recs[i] = common.Recommendation{
+			ResourceType: "type",
+			Count:        i%5 + 1,
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = applyCoverageToRecommendations(recs, 0.5)
+	}
+}
+
+// ==================== Helper Functions for Tests ====================
+
+func calculateTotalInstances(recs []common.Recommendation) int {
+	var total int
+	for _, rec := range recs {
+		total += rec.Count
+	}
+	return total
+}
+
+func applyCoverageToRecommendations(recs []common.Recommendation, coverage float64) []common.Recommendation {
+	if coverage <= 0 {
+		return []common.Recommendation{}
+	}
+	if coverage >= 1.0 {
+		return recs
+	}
+
+	targetCount := int(float64(len(recs)) * coverage)
+	if targetCount == 0 && coverage > 0 && len(recs) > 0 {
+		targetCount = 1
+	}
+
+	if targetCount >= len(recs) {
+		return recs
+	}
+
+	return recs[:targetCount]
+}
+
+func generateCSVFilenameTestHelper(service common.ServiceType, payment string, term int, dryRun bool) string {
+	mode := "purchase"
+	if dryRun {
+		mode = "dryrun"
+	}
+
+	serviceStr := ""
+	switch service {
+	case common.ServiceRDS:
+		serviceStr = "rds"
+	case common.ServiceElastiCache:
+		serviceStr = "elasticache"
+	case common.ServiceEC2:
+		serviceStr = "ec2"
+	case common.ServiceOpenSearch:
+		serviceStr = "opensearch"
+	case common.ServiceRedshift:
+		serviceStr = "redshift"
+	case common.ServiceMemoryDB:
+		serviceStr = "memorydb"
+	default:
+		serviceStr = "unknown"
+	}
+
+	return serviceStr + "-" + payment + "-" + mode + ".csv"
+}
+
+// Test types
+type MultiServiceConfig struct {
+	Services      map[common.ServiceType]ServiceConfig
+	PaymentOption string
+	TermYears     int
+	DryRun        bool
+}
+
+type ServiceConfig struct {
+	Enabled  bool
+	Coverage float64
+}
+
+// ==================== Filter Function Tests ====================
+
+func TestApplyFilters(t *testing.T) {
+	// Save original values
+	origCfg := testCfg
+
+	// Restore after test
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	tests := []struct {
+		name                 string
+		recommendations      []common.Recommendation
+		includeRegions       []string
+		excludeRegions       []string
+		includeInstanceTypes []string
+		excludeInstanceTypes []string
+		expectedCount        int
+	}{
+		{
+			name: "No filters - all pass through",
+			recommendations: []common.Recommendation{
+				{Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1},
+				{Region: "us-west-2", ResourceType: "db.t3.small", Count: 1},
+			},
+			includeRegions:       []string{},
+			excludeRegions:       []string{},
+			includeInstanceTypes: []string{},
+			excludeInstanceTypes: []string{},
+			expectedCount:        2,
+		},
+		{
+			name: "Include specific regions only",
+			recommendations: []common.Recommendation{
+				{Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1},
+				{Region: "us-west-2", ResourceType: "db.t3.small", Count: 1},
+				{Region: "eu-west-1", ResourceType: "db.t3.medium", Count: 1},
+			},
+			includeRegions:       []string{"us-east-1", "eu-west-1"},
+			excludeRegions:       []string{},
+			includeInstanceTypes: []string{},
+			excludeInstanceTypes: []string{},
+			expectedCount:        2,
+		},
+		{
+			name: "Exclude specific regions",
+			recommendations: []common.Recommendation{
+				{Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1},
+				{Region: "us-west-2", ResourceType: "db.t3.small", Count: 1},
+			},
+			includeRegions:       []string{},
+			excludeRegions:       []string{"us-west-2"},
+			includeInstanceTypes: []string{},
+			excludeInstanceTypes: []string{},
+			expectedCount:        1,
+		},
+		{
+			name: "Include specific instance types",
+			recommendations: []common.Recommendation{
+				{Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1},
+				{Region: "us-west-2", ResourceType: "db.t3.small", Count: 1},
+				{Region: "eu-west-1", ResourceType: "db.t3.micro", Count: 1},
+			},
+			includeRegions:       []string{},
+			excludeRegions:       []string{},
+			includeInstanceTypes: []string{"db.t3.micro"},
+			excludeInstanceTypes: []string{},
+			expectedCount:        2,
+		},
+		{
+			name: "Combined filters",
+			recommendations: []common.Recommendation{
+				{Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1},
+				{Region: "us-east-1", ResourceType: "db.t3.small", Count: 1},
+				{Region: "us-west-2", ResourceType: "db.t3.micro", Count: 1},
+			},
+			includeRegions:       []string{"us-east-1"},
+			excludeRegions:       []string{},
+			includeInstanceTypes: []string{},
+			excludeInstanceTypes: []string{"db.t3.micro"},
+			expectedCount:        1, // Only us-east-1 with db.t3.small
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Set testCfg fields
+			testCfg.IncludeRegions = tt.includeRegions
+			testCfg.ExcludeRegions = tt.excludeRegions
+			testCfg.IncludeInstanceTypes = tt.includeInstanceTypes
+			testCfg.ExcludeInstanceTypes = tt.excludeInstanceTypes
+
+			// Apply filters with RunConfig (empty currentRegion for test)
+			result := applyFilters(tt.recommendations, testCfg, make(map[string][]InstanceEngineVersion), make(map[string]MajorEngineVersionInfo), "")
+
+			// Check count
+			assert.Equal(t, tt.expectedCount, len(result))
+		})
+	}
+}
+
+func TestShouldIncludeRegion(t *testing.T) {
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	tests := []struct {
+		name           string
+		region         string
+		includeRegions []string
+		excludeRegions []string
+		expected       bool
+	}{
+		{
+			name:           "No filters - should include",
+			region:         "us-east-1",
+			includeRegions: []string{},
+			excludeRegions: []string{},
+			expected:       true,
+		},
+		{
+			name:           "In include list",
+			region:         "us-east-1",
+			includeRegions: []string{"us-east-1", "us-west-2"},
+			excludeRegions: []string{},
+			expected:       true,
+		},
+		{
+			name:           "Not in include list",
+			region:         "eu-west-1",
+			includeRegions: []string{"us-east-1"},
+			excludeRegions: []string{},
+			expected:       false,
+		},
+		{
+			name:           "In exclude list",
+			region:         "us-east-1",
+			includeRegions: []string{},
+			excludeRegions: []string{"us-east-1"},
+			expected:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg.IncludeRegions = tt.includeRegions
+			testCfg.ExcludeRegions = tt.excludeRegions
+
+			result := shouldIncludeRegion(tt.region, testCfg)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestShouldIncludeInstanceType(t *testing.T) {
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	tests := []struct {
+		name                 string
+		instanceType         string
+		includeInstanceTypes []string
+		excludeInstanceTypes []string
+		expected             bool
+	}{
+		{
+			name:                 "No filters - should include",
+			instanceType:         "db.t3.micro",
+			includeInstanceTypes: []string{},
+			excludeInstanceTypes: []string{},
+			expected:             true,
+		},
+		{
+			name:                 "In include list",
+			instanceType:         "cache.t3.micro",
+			includeInstanceTypes: []string{"cache.t3.micro"},
+			excludeInstanceTypes: []string{},
+			expected:             true,
+		},
+		{
+			name:                 "In exclude list",
+			instanceType:         "db.t3.large",
+			includeInstanceTypes: []string{},
+			excludeInstanceTypes: []string{"db.t3.large"},
+			expected:             false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg.IncludeInstanceTypes = tt.includeInstanceTypes
+			testCfg.ExcludeInstanceTypes = tt.excludeInstanceTypes
+
+			result := shouldIncludeInstanceType(tt.instanceType, testCfg)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestShouldIncludeInstanceTypePatterns(t *testing.T) {
+	cfg := RunConfig{ExcludeInstanceTypePatterns: []string{"db.t3.*", "*.nano"}}
+
+	tests := []struct {
+		instanceType string
+		expected     bool
+	}{
+		{instanceType: "db.t3.micro", expected: false},
+		{instanceType: "db.t3.2xlarge", expected: false},
+		{instanceType: "t3.nano", expected: false},
+		{instanceType: "db.t4g.micro", expected: true},
+		{instanceType: "cache.t3.micro", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.instanceType, func(t *testing.T) {
+			assert.Equal(t, tt.expected, shouldIncludeInstanceType(tt.instanceType, cfg))
+		})
+	}
+}
+
+func TestShouldIncludeArchitecture(t *testing.T) {
+	tests := []struct {
+		name         string
+		instanceType string
+		cfg          RunConfig
+		expected     bool
+	}{
+		{"no filters", "m6g.large", RunConfig{}, true},
+		{"arm64 included", "db.r7g.large", RunConfig{IncludeArchitectures: []string{"arm64"}}, true},
+		{"x86_64 not included", "db.r5.large", RunConfig{IncludeArchitectures: []string{"arm64"}}, false},
+		{"arm64 excluded", "cache.t4g.micro", RunConfig{ExcludeArchitectures: []string{"arm64"}}, false},
+		{"x86_64 not excluded", "m5.large", RunConfig{ExcludeArchitectures: []string{"arm64"}}, true},
+		{"savings plans are not filtered", "Compute", RunConfig{IncludeArchitectures: []string{"arm64"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, shouldIncludeArchitecture(tt.instanceType, tt.cfg))
+		})
+	}
+}
+
+func TestShouldIncludeEngine(t *testing.T) {
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	tests := []struct {
+		name           string
+		recommendation common.Recommendation
+		includeEngines []string
+		excludeEngines []string
+		expected       bool
+	}{
+		{
+			name: "ElastiCache Redis - no filters",
+			recommendation: common.Recommendation{
+				Service: common.ServiceElastiCache,
+				Details: &common.CacheDetails{
+					Engine: "redis",
+				},
+			},
+			includeEngines: []string{},
+			excludeEngines: []string{},
+			expected:       true,
+		},
+		{
+			name: "ElastiCache Redis - in include list",
+			recommendation: common.Recommendation{
+				Service: common.ServiceElastiCache,
+				Details: &common.CacheDetails{
+					Engine: "redis",
+				},
+			},
+			includeEngines: []string{"redis"},
+			excludeEngines: []string{},
+			expected:       true,
+		},
+		{
+			name: "ElastiCache Valkey - not in include list",
+			recommendation: common.Recommendation{
+				Service: common.ServiceElastiCache,
+				Details: &common.CacheDetails{
+					Engine: "valkey",
+				},
+			},
+			includeEngines: []string{"redis"},
+			excludeEngines: []string{},
+			expected:       false,
+		},
+		{
+			name: "ElastiCache Redis - in exclude list",
+			recommendation: common.Recommendation{
+				Service: common.ServiceElastiCache,
+				Details: &common.CacheDetails{
+					Engine: "redis",
+				},
+			},
+			includeEngines: []string{},
+			excludeEngines: []string{"redis"},
+			expected:       false,
+		},
+		{
+			name: "RDS MySQL - with ServiceDetails",
+			recommendation: common.Recommendation{
+				Service: common.ServiceRDS,
+				Details: &common.DatabaseDetails{
+					Engine: "mysql",
+				},
+			},
+			includeEngines: []string{"mysql", "postgresql"},
+			excludeEngines: []string{},
+			expected:       true,
+		},
+		{
+			name: "Case insensitive matching",
+			recommendation: common.Recommendation{
+				Service: common.ServiceElastiCache,
+				Details: &common.CacheDetails{
+					Engine: "Redis",
+				},
+			},
+			includeEngines: []string{"REDIS"},
+			excludeEngines: []string{},
+			expected:       true,
+		},
+		{
+			name: "OpenSearch - in include list",
+			recommendation: common.Recommendation{
+				Service:      common.ServiceOpenSearch,
+				ResourceType: "r6g.large.search",
+				Details:      &common.SearchDetails{InstanceType: "r6g.large.search"},
+			},
+			includeEngines: []string{"opensearch"},
+			excludeEngines: []string{},
+			expected:       true,
+		},
+		{
+			name: "Legacy Elasticsearch - in exclude list",
+			recommendation: common.Recommendation{
+				Service:      common.ServiceOpenSearch,
+				ResourceType: "m5.large.elasticsearch",
+			},
+			includeEngines: []string{},
+			excludeEngines: []string{"elasticsearch"},
+			expected:       false,
+		},
+		{
+			name: "MemoryDB without details - not in include list",
+			recommendation: common.Recommendation{
+				Service:      common.ServiceMemoryDB,
+				ResourceType: "db.r6g.large",
+			},
+			includeEngines: []string{"mysql"},
+			excludeEngines: []string{},
+			expected:       false,
+		},
+		{
+			name: "MemoryDB Valkey - in include list",
+			recommendation: common.Recommendation{
+				Service: common.ServiceMemoryDB,
+				Details: &common.CacheDetails{Engine: "valkey"},
+			},
+			includeEngines: []string{"valkey"},
+			excludeEngines: []string{},
+			expected:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg.IncludeEngines = tt.includeEngines
+			testCfg.ExcludeEngines = tt.excludeEngines
+
+			result := shouldIncludeEngine(tt.recommendation, testCfg)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestShouldIncludeAccount(t *testing.T) {
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	tests := []struct {
+		name            string
+		accountID       string
+		accountName     string
+		includeAccounts []string
+		excludeAccounts []string
+		expected        bool
+	}{
+		{
+			name:            "No filters - should include",
+			accountID:       "123456789012",
+			includeAccounts: []string{},
+			excludeAccounts: []string{},
+			expected:        true,
+		},
+		{
+			name:            "In include list",
+			accountID:       "123456789012",
+			includeAccounts: []string{"123456789012", "210987654321"},
+			excludeAccounts: []string{},
+			expected:        true,
+		},
+		{
+			name:            "Not in include list",
+			accountID:       "999888777666",
+			includeAccounts: []string{"123456789012"},
+			excludeAccounts: []string{},
+			expected:        false,
+		},
+		{
+			name:            "In exclude list",
+			accountID:       "123456789012",
+			includeAccounts: []string{},
+			excludeAccounts: []string{"123456789012"},
+			expected:        false,
+		},
+		{
+			name:            "Not in exclude list",
+			accountID:       "999888777666",
+			includeAccounts: []string{},
+			excludeAccounts: []string{"123456789012"},
+			expected:        true,
+		},
+		{
+			name:            "ID matches before the alias is resolved",
+			accountID:       "123456789012",
+			includeAccounts: []string{"production", "123456789012"},
+			expected:        true,
+		},
+		{
+			name:            "Name substring matches in a list with IDs",
+			accountID:       "999888777666",
+			accountName:     "Production-EU",
+			includeAccounts: []string{"123456789012", "production"},
+			expected:        true,
+		},
+		{
+			name:            "IDs are not matched as substrings",
+			accountID:       "123456789012",
+			accountName:     "staging",
+			includeAccounts: []string{"1234", "production"},
+			expected:        false,
+		},
+		{
+			name:            "Excluded by ID although the name is included",
+			accountID:       "123456789012",
+			accountName:     "production",
+			includeAccounts: []string{"prod"},
+			excludeAccounts: []string{"123456789012"},
+			expected:        false,
+		},
+		{
+			name:            "Excluded by name although the ID is included",
+			accountID:       "123456789012",
+			accountName:     "sandbox",
+			includeAccounts: []string{"123456789012"},
+			excludeAccounts: []string{"Sandbox"},
+			expected:        false,
+		},
+		{
+			name:            "Unknown account with filters",
+			includeAccounts: []string{"123456789012"},
+			expected:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg.IncludeAccounts = tt.includeAccounts
+			testCfg.ExcludeAccounts = tt.excludeAccounts
+
+			result := shouldIncludeAccount(tt.accountID, tt.accountName, testCfg)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestMeetsMinSavingsPerInstance(t *testing.T) {
+	tests := []struct {
+		name      string
+		rec       common.Recommendation
+		threshold float64
+		expected  bool
+	}{
+		{
+			name:      "No threshold - always included",
+			rec:       common.Recommendation{Count: 100, EstimatedSavings: 1.0},
+			threshold: 0,
+			expected:  true,
+		},
+		{
+			name:      "High count with low per-instance savings - excluded",
+			rec:       common.Recommendation{Count: 100, EstimatedSavings: 200.0},
+			threshold: 5.0,
+			expected:  false,
+		},
+		{
+			name:      "Low count with high per-instance savings - included",
+			rec:       common.Recommendation{Count: 2, EstimatedSavings: 200.0},
+			threshold: 5.0,
+			expected:  true,
+		},
+		{
+			name:      "Exactly at threshold - included",
+			rec:       common.Recommendation{Count: 4, EstimatedSavings: 20.0},
+			threshold: 5.0,
+			expected:  true,
+		},
+		{
+			name:      "Zero count treated as single unit",
+			rec:       common.Recommendation{Count: 0, EstimatedSavings: 10.0},
+			threshold: 5.0,
+			expected:  true,
+		},
+		{
+			name:      "Zero count with low savings - excluded",
+			rec:       common.Recommendation{Count: 0, EstimatedSavings: 1.0},
+			threshold: 5.0,
+			expected:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := RunConfig{MinSavingsPerInstance: tt.threshold}
+			assert.Equal(t, tt.expected, meetsMinSavingsPerInstance(tt.rec, cfg))
+		})
+	}
+}
+
+func TestApplyFiltersMinSavingsPerInstance(t *testing.T) {
+	recs := []common.Recommendation{
+		{Region: "us-east-1", ResourceType: "db.t3.micro", Count: 100, EstimatedSavings: 150.0},
+		{Region: "us-east-1", ResourceType: "db.r5.large", Count: 2, EstimatedSavings: 120.0},
+	}
+	cfg := RunConfig{MinSavingsPerInstance: 10.0, IncludeExtendedSupport: true}
+
+	result := applyFilters(recs, cfg, make(map[string][]InstanceEngineVersion), make(map[string]MajorEngineVersionInfo), "")
+
+	assert.Len(t, result, 1)
+	assert.Equal(t, "db.r5.large", result[0].ResourceType)
+}
+
+func TestInstanceTypeMatchesService(t *testing.T) {
+	tests := []struct {
+		name         string
+		service      common.ServiceType
+		instanceType string
+		expected     bool
+	}{
+		{"RDS db. prefix", common.ServiceRDS, "db.r6g.large", true},
+		{"RDS with cache type", common.ServiceRDS, "cache.r6g.large", false},
+		{"ElastiCache cache. prefix", common.ServiceElastiCache, "cache.t3.micro", true},
+		{"ElastiCache with db type", common.ServiceElastiCache, "db.t3.micro", false},
+		{"MemoryDB db. prefix", common.ServiceMemoryDB, "db.r6gd.xlarge", true},
+		{"MemoryDB with cache type", common.ServiceMemoryDB, "cache.r6g.large", false},
+		{"EC2 bare family", common.ServiceEC2, "m5.large", true},
+		{"EC2 with db type", common.ServiceEC2, "db.m5.large", false},
+		{"EC2 with Redshift node", common.ServiceEC2, "ra3.xlplus", false},
+		{"EC2 with search type", common.ServiceEC2, "r6g.large.search", false},
+		{"OpenSearch .search suffix", common.ServiceOpenSearch, "r6g.large.search", true},
+		{"OpenSearch legacy .elasticsearch suffix", common.ServiceOpenSearch, "m5.large.elasticsearch", true},
+		{"OpenSearch with EC2 type", common.ServiceOpenSearch, "m5.large", false},
+		{"Redshift ra3", common.ServiceRedshift, "ra3.4xlarge", true},
+		{"Redshift dc2", common.ServiceRedshift, "dc2.large", true},
+		{"Redshift with EC2 type", common.ServiceRedshift, "m5.large", false},
+		{"Savings Plans without instance type", common.ServiceSavingsPlans, "", true},
+		{"Unknown service accepted", common.ServiceType("unknown"), "anything.large", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, instanceTypeMatchesService(tt.service, tt.instanceType))
+		})
+	}
+}
+
+func TestFilterByMinMonthlySavings(t *testing.T) {
+	recs := []common.Recommendation{
+		{ResourceType: "db.t3.micro", EstimatedSavings: 4.5},
+		{ResourceType: "db.r5.large", EstimatedSavings: 50},
+		{ResourceType: "db.r5.xlarge", EstimatedSavings: 10},
+	}
+
+	tests := []struct {
+		name        string
+		minSavings  float64
+		wantTypes   []string
+		wantRemoved int
+	}{
+		{name: "threshold below all", minSavings: 1, wantTypes: []string{"db.t3.micro", "db.r5.large", "db.r5.xlarge"}},
+		{name: "threshold is inclusive", minSavings: 10, wantTypes: []string{"db.r5.large", "db.r5.xlarge"}, wantRemoved: 1},
+		{name: "threshold above all", minSavings: 100, wantTypes: []string{}, wantRemoved: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, removed := filterByMinMonthlySavings(recs, tt.minSavings)
+			types := make([]string, 0, len(kept))
+			for _, rec := range kept {
+				types = append(types, rec.ResourceType)
+			}
+			assert.Equal(t, tt.wantTypes, types)
+			assert.Equal(t, tt.wantRemoved, removed)
+		})
+	}
+}
+
+func TestSortRecommendations(t *testing.T) {
+	recs := []common.Recommendation{
+		{ResourceType: "a", Count: 1, EstimatedSavings: 10},
+		{ResourceType: "b", Count: 5, EstimatedSavings: 30},
+		{ResourceType: "c", Count: 5, EstimatedSavings: 10},
+		{ResourceType: "d", Count: 2, EstimatedSavings: 30},
+	}
+
+	tests := []struct {
+		sortBy string
+		want   []string
+	}{
+		{sortBy: SortBySavings, want: []string{"b", "d", "a", "c"}},
+		{sortBy: "", want: []string{"b", "d", "a", "c"}},
+		{sortBy: SortByCount, want: []string{"b", "c", "d", "a"}},
+		{sortBy: SortByNone, want: []string{"a", "b", "c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			sorted := SortRecommendations(recs, tt.sortBy)
+			types := make([]string, 0, len(sorted))
+			for _, rec := range sorted {
+				types = append(types, rec.ResourceType)
+			}
+			assert.Equal(t, tt.want, types)
+			assert.Equal(t, "a", recs[0].ResourceType, "the caller's slice is not reordered")
+		})
+	}
+}
+
+func TestSortRecommendationsBeforeInstanceLimit(t *testing.T) {
+	recs := []common.Recommendation{
+		{ResourceType: "small", Count: 3, EstimatedSavings: 5},
+		{ResourceType: "large", Count: 3, EstimatedSavings: 90},
+	}
+
+	limited := ApplyInstanceLimit(SortRecommendations(recs, SortBySavings), 3)
+	require.Len(t, limited, 1)
+	assert.Equal(t, "large", limited[0].ResourceType)
+}
+
+func TestApplySpendLimit(t *testing.T) {
+	recs := []common.Recommendation{
+		{ResourceType: "cheap", OnDemandCost: 150, EstimatedSavings: 50},
+		{ResourceType: "best", OnDemandCost: 700, EstimatedSavings: 300},
+		{ResourceType: "middle", OnDemandCost: 400, EstimatedSavings: 100},
+		{
+			Service:          common.ServiceSavingsPlans,
+			ResourceType:     "Compute",
+			EstimatedSavings: 20,
+			Details:          &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 0.1},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		maxSpend float64
+		want     []string
+	}{
+		{name: "no limit", maxSpend: 0, want: []string{"cheap", "best", "middle", "Compute"}},
+		{name: "stops at the first recommendation over the cap", maxSpend: 750, want: []string{"best", "middle"}},
+		{name: "exact fit", maxSpend: 873, want: []string{"best", "middle", "cheap", "Compute"}},
+		{name: "nothing fits", maxSpend: 100, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limited := ApplySpendLimit(recs, tt.maxSpend)
+			types := make([]string, 0, len(limited))
+			for _, rec := range limited {
+				types = append(types, rec.ResourceType)
+			}
+			assert.Equal(t, tt.want, types)
+		})
+	}
+}
+
+func TestConfirmPurchase(t *testing.T) {
+	phraseCfg := RunConfig{ConfirmPhrase: true, ConfirmPhraseInstances: 20, ConfirmPhraseCost: 5000}
+
+	tests := []struct {
+		name      string
+		cfg       RunConfig
+		instances int
+		cost      float64
+		input     string
+		want      bool
+	}{
+		{name: "yes", cfg: RunConfig{}, instances: 50, cost: 100, input: "yes\n", want: true},
+		{name: "no", cfg: RunConfig{}, instances: 50, cost: 100, input: "n\n", want: false},
+		{name: "skip confirmation", cfg: RunConfig{SkipConfirmation: true, ConfirmPhrase: true}, instances: 50, cost: 100, input: "", want: true},
+		{name: "below the thresholds accepts yes", cfg: phraseCfg, instances: 20, cost: 5000, input: "y\n", want: true},
+		{name: "instances above the threshold reject yes", cfg: phraseCfg, instances: 21, cost: 100, input: "yes\n", want: false},
+		{name: "cost above the threshold needs the phrase", cfg: phraseCfg, instances: 2, cost: 5000.01, input: "PURCHASE 2 INSTANCES\n", want: true},
+		{name: "phrase is case sensitive", cfg: phraseCfg, instances: 50, cost: 100, input: "purchase 50 instances\n", want: false},
+		{name: "phrase with the wrong count", cfg: phraseCfg, instances: 50, cost: 100, input: "PURCHASE 5 INSTANCES\n", want: false},
+		{name: "phrase without a trailing newline", cfg: phraseCfg, instances: 50, cost: 100, input: "PURCHASE 50 INSTANCES", want: true},
+		{name: "confirm func approves", cfg: RunConfig{ConfirmFunc: func(n int, cost float64) bool { return n <= 10 && cost < 1000 }}, instances: 10, cost: 999, input: "no\n", want: true},
+		{name: "confirm func rejects", cfg: RunConfig{ConfirmFunc: func(n int, cost float64) bool { return n <= 10 }}, instances: 11, cost: 100, input: "yes\n", want: false},
+		{name: "skip confirmation bypasses confirm func", cfg: RunConfig{SkipConfirmation: true, ConfirmFunc: func(int, float64) bool { return false }}, instances: 1, cost: 1, input: "", want: true},
+	}
+
+	original := confirmInput
+	defer func() { confirmInput = original }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			confirmInput = strings.NewReader(tt.input)
+			assert.Equal(t, tt.want, ConfirmPurchase(tt.instances, tt.cost, tt.cfg))
+		})
+	}
+}
+
+func TestApplyFiltersSkipsServiceMismatch(t *testing.T) {
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "cache.t3.micro", Count: 1},
+		{Service: common.ServiceElastiCache, Region: "us-east-1", ResourceType: "cache.t3.micro", Count: 1},
+	}
+	cfg := RunConfig{IncludeExtendedSupport: true}
+
+	result := applyFilters(recs, cfg, make(map[string][]InstanceEngineVersion), make(map[string]MajorEngineVersionInfo), "")
+
+	assert.Len(t, result, 2)
+	for _, rec := range result {
+		assert.True(t, instanceTypeMatchesService(rec.Service, rec.ResourceType))
+	}
+}
+
+// ==================== New Extracted Function Tests ====================
+
+func TestCreateDryRunResult(t *testing.T) {
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	testCfg.Coverage = 75.0
+
+	rec := common.Recommendation{
+		Service:      common.ServiceRDS,
+		ResourceType: "db.t3.small",
+		Count:        5,
+		Region:       "us-east-1",
+	}
+
+	result := createDryRunResult(rec, "us-east-1", 1, testCfg)
+
+	assert.True(t, result.Success)
+	assert.Equal(t, rec, result.Recommendation)
+	assert.Nil(t, result.Error) // Dry runs are successful, so no error
+	assert.True(t, result.DryRun)
+	assert.Contains(t, result.CommitmentID, "dryrun")
+	assert.NotEmpty(t, result.Timestamp)
+}
+
+func TestCreateCancelledResults(t *testing.T) {
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	testCfg.Coverage = 80.0
+
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, ResourceType: "db.t3.small", Count: 2},
+		{Service: common.ServiceRDS, ResourceType: "db.t3.medium", Count: 3},
+		{Service: common.ServiceRDS, ResourceType: "db.t3.large", Count: 1},
+	}
+
+	results := createCancelledResults(recs, "us-west-2", testCfg)
+
+	assert.Len(t, results, 3)
+	for i, result := range results {
+		assert.False(t, result.Success)
+		assert.Equal(t, recs[i], result.Recommendation)
+		assert.NotNil(t, result.Error)
+		assert.Contains(t, result.Error.Error(), "cancelled")
+		assert.Contains(t, result.CommitmentID, "us-west-2")
+	}
+}
+
+func TestExecutePurchase(t *testing.T) {
+	ctx := context.Background()
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	testCfg.Coverage = 90.0
+
+	rec := common.Recommendation{
+		Service:      common.ServiceEC2,
+		ResourceType: "t3.medium",
+		Count:        10,
+	}
+
+	mockClient := &MockServiceClient{}
+	expectedResult := common.PurchaseResult{
+		Recommendation: rec,
+		Success:        true,
+		CommitmentID:   "test-purchase-id-123",
+		Error:          nil,
+		Timestamp:      time.Now(),
+	}
+	mockClient.On("PurchaseCommitment", mock.Anything, rec).Return(expectedResult, nil)
+
+	result := executePurchase(ctx, rec, "eu-west-1", 5, mockClient, testCfg)
+
+	assert.True(t, result.Success)
+	assert.Equal(t, "test-purchase-id-123", result.CommitmentID)
+	assert.Nil(t, result.Error)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestExecutePurchaseWithEmptyPurchaseID(t *testing.T) {
+	ctx := context.Background()
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	testCfg.Coverage = 85.0
+
+	rec := common.Recommendation{
+		Service:      common.ServiceElastiCache,
+		ResourceType: "cache.r5.large",
+		Count:        3,
+	}
+
+	mockClient := &MockServiceClient{}
+	// Return result without PurchaseID
+	expectedResult := common.PurchaseResult{
+		Recommendation: rec,
+		Success:        true,
+		CommitmentID:   "", // Empty ID - should be generated
+		Error:          nil,
+		Timestamp:      time.Now(),
+	}
+	mockClient.On("PurchaseCommitment", mock.Anything, rec).Return(expectedResult, nil)
+
+	// Logger output disabled for testing
+
+	result := executePurchase(ctx, rec, "ap-southeast-1", 2, mockClient, testCfg)
+
+	assert.True(t, result.Success)
+	assert.NotEmpty(t, result.CommitmentID) // Should have generated ID
+	assert.Contains(t, result.CommitmentID, "ap-southeast-1")
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestExecutePurchaseValidateOfferings(t *testing.T) {
+	ctx := context.Background()
+	rec := common.Recommendation{Service: common.ServiceEC2, Region: "eu-west-1", ResourceType: "m5.large", Count: 2}
+	cfg := RunConfig{Coverage: 80, ValidateOfferings: true}
+
+	t.Run("invalid offering is not purchased", func(t *testing.T) {
+		mockClient := &MockServiceClient{}
+		mockClient.On("ValidateOffering", ctx, rec).Return(errors.New("no offering found for m5.large"))
+
+		result := executePurchase(ctx, rec, "eu-west-1", 1, mockClient, cfg)
+
+		assert.False(t, result.Success)
+		assert.ErrorContains(t, result.Error, "offering validation failed: no offering found")
+		assert.Equal(t, rec, result.Recommendation)
+		assert.NotEmpty(t, result.CommitmentID)
+		mockClient.AssertNotCalled(t, "PurchaseCommitment", mock.Anything, mock.Anything)
+	})
+
+	t.Run("valid offering is purchased", func(t *testing.T) {
+		mockClient := &MockServiceClient{}
+		mockClient.On("ValidateOffering", ctx, rec).Return(nil)
+		mockClient.On("PurchaseCommitment", mock.Anything, rec).Return(common.PurchaseResult{Recommendation: rec, Success: true, CommitmentID: "ri-123"}, nil)
+
+		result := executePurchase(ctx, rec, "eu-west-1", 1, mockClient, cfg)
+
+		assert.True(t, result.Success)
+		assert.Equal(t, "ri-123", result.CommitmentID)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestProcessPurchaseLoopDryRunQuotesOfferings(t *testing.T) {
+	ctx := context.Background()
+	recs := []common.Recommendation{
+		{Service: common.ServiceEC2, ResourceType: "m5.large", Count: 2},
+		{Service: common.ServiceEC2, ResourceType: "c5.large", Count: 1},
+	}
+
+	mockClient := &MockServiceClient{}
+	mockClient.On("GetOfferingDetails", ctx, recs[0]).Return(&common.OfferingDetails{OfferingID: "offer-1", UpfrontCost: 500, EffectiveHourlyRate: 0.06}, nil)
+	mockClient.On("GetOfferingDetails", ctx, recs[1]).Return(nil, errors.New("offering not found"))
+
+	results := processPurchaseLoop(ctx, recs, "us-east-1", true, mockClient, RunConfig{ValidateOfferings: true})
+
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.True(t, result.DryRun)
+		assert.True(t, result.Success, "a failed quote does not fail the dry run")
+	}
+	mockClient.AssertExpectations(t)
+	mockClient.AssertNotCalled(t, "ValidateOffering", mock.Anything, mock.Anything)
+}
+
+// MockDryRunServiceClient is a MockServiceClient whose purchase API supports DryRun
+type MockDryRunServiceClient struct {
+	MockServiceClient
+}
+
+func (m *MockDryRunServiceClient) DryRunPurchase(ctx context.Context, rec common.Recommendation) (common.PurchaseResult, error) {
+	args := m.Called(ctx, rec)
+	return args.Get(0).(common.PurchaseResult), args.Error(1)
+}
+
+func TestProcessPurchaseLoopDryRunPurchaseAPI(t *testing.T) {
+	ctx := context.Background()
+	recs := []common.Recommendation{
+		{Service: common.ServiceEC2, ResourceType: "m5.large", Count: 2},
+		{Service: common.ServiceEC2, ResourceType: "c5.large", Count: 1},
+	}
+	denied := errors.New("dry run purchase of EC2 RI failed: UnauthorizedOperation")
+
+	mockClient := &MockDryRunServiceClient{}
+	mockClient.On("DryRunPurchase", ctx, recs[0]).Return(common.PurchaseResult{Recommendation: recs[0], Success: true, DryRun: true}, nil)
+	mockClient.On("DryRunPurchase", ctx, recs[1]).Return(common.PurchaseResult{Recommendation: recs[1], Error: denied, DryRun: true}, denied)
+
+	results := processPurchaseLoop(ctx, recs, "us-east-1", true, mockClient, RunConfig{DryRunPurchaseAPI: true, FailFast: true})
+
+	require.Len(t, results, 2, "a failed dry run does not trigger --fail-fast")
+	assert.True(t, results[0].Success)
+	assert.True(t, results[0].DryRun)
+	assert.Contains(t, results[0].CommitmentID, "dryrun")
+	assert.False(t, results[1].Success)
+	assert.True(t, results[1].DryRun)
+	assert.ErrorIs(t, results[1].Error, denied)
+	mockClient.AssertExpectations(t)
+	mockClient.AssertNotCalled(t, "PurchaseCommitment", mock.Anything, mock.Anything)
+}
+
+func TestProcessPurchaseLoopDryRunPurchaseAPIUnsupported(t *testing.T) {
+	recs := []common.Recommendation{{Service: common.ServiceRDS, ResourceType: "db.r5.large", Count: 1}}
+	mockClient := &MockServiceClient{}
+
+	results := processPurchaseLoop(context.Background(), recs, "us-east-1", true, mockClient, RunConfig{DryRunPurchaseAPI: true})
+
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Success, "services without a DryRun purchase API are simulated locally")
+	assert.True(t, results[0].DryRun)
+	mockClient.AssertNotCalled(t, "PurchaseCommitment", mock.Anything, mock.Anything)
+}
+
+func TestProcessPurchaseLoopInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	recs := []common.Recommendation{
+		{Service: common.ServiceEC2, ResourceType: "m5.large", Count: 2},
+		{Service: common.ServiceEC2, ResourceType: "c5.large", Count: 1},
+	}
+
+	mockClient := &MockServiceClient{}
+	results := processPurchaseLoop(ctx, recs, "us-east-1", false, mockClient, RunConfig{Coverage: 80})
+
+	require.Len(t, results, 2)
+	for i, result := range results {
+		assert.Equal(t, recs[i], result.Recommendation)
+		assert.False(t, result.Success)
+		assert.ErrorIs(t, result.Error, context.Canceled)
+		assert.ErrorContains(t, result.Error, "run was interrupted")
+	}
+	mockClient.AssertNotCalled(t, "PurchaseCommitment", mock.Anything, mock.Anything)
+}
+
+func TestProcessPurchaseLoopDryRun(t *testing.T) {
+	ctx := context.Background()
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	testCfg.Coverage = 75.0
+
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, ResourceType: "db.t3.small", Count: 2, SourceRecommendation: "Test 1"},
+		{Service: common.ServiceRDS, ResourceType: "db.t3.medium", Count: 3, SourceRecommendation: "Test 2"},
+	}
+
+	mockClient := &MockServiceClient{}
+
+	// Logger output disabled for testing
+
+	results := processPurchaseLoop(ctx, recs, "us-east-1", true, mockClient, testCfg)
+
+	assert.Len(t, results, 2)
+	for _, result := range results {
+		assert.True(t, result.Success)
+		assert.Nil(t, result.Error) // Dry runs are successful, so no error
+		assert.True(t, result.DryRun)
+		assert.Contains(t, result.CommitmentID, "dryrun")
+	}
+
+	// Mock should not be called in dry run mode
+	mockClient.AssertNotCalled(t, "PurchaseCommitment")
+}
+
+func TestProcessPurchaseLoopActualPurchase(t *testing.T) {
+	ctx := context.Background()
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	testCfg.Coverage = 80.0
+	testCfg.SkipConfirmation = true // Skip confirmation for testing
+
+	recs := []common.Recommendation{
+		{Service: common.ServiceEC2, ResourceType: "t3.small", Count: 1, SourceRecommendation: "EC2 Test 1", EstimatedSavings: 100},
+		{Service: common.ServiceEC2, ResourceType: "t3.medium", Count: 2, SourceRecommendation: "EC2 Test 2", EstimatedSavings: 200},
+	}
+
+	mockClient := &MockServiceClient{}
+	for i, rec := range recs {
+		result := common.PurchaseResult{
+			Recommendation: rec,
+			Success:        true,
+			CommitmentID:   fmt.Sprintf("purchase-id-%d", i),
+			Error:          nil,
+			Timestamp:      time.Now(),
+		}
+		mockClient.On("PurchaseCommitment", mock.Anything, rec).Return(result, nil)
+	}
+
+	// Logger output disabled for testing
+
+	// Disable purchase delay for testing
+	os.Setenv("DISABLE_PURCHASE_DELAY", "true")
+	defer os.Unsetenv("DISABLE_PURCHASE_DELAY")
+
+	results := processPurchaseLoop(ctx, recs, "eu-west-1", false, mockClient, testCfg)
+
+	assert.Len(t, results, 2)
+	for i, result := range results {
+		assert.True(t, result.Success)
+		assert.Equal(t, fmt.Sprintf("purchase-id-%d", i), result.CommitmentID)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestProcessPurchaseLoopFailFast(t *testing.T) {
+	ctx := context.Background()
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, ResourceType: "db.t3.small", Count: 1},
+		{Service: common.ServiceRDS, ResourceType: "db.t3.medium", Count: 2},
+		{Service: common.ServiceRDS, ResourceType: "db.t3.large", Count: 3},
+	}
+
+	os.Setenv("DISABLE_PURCHASE_DELAY", "true")
+	defer os.Unsetenv("DISABLE_PURCHASE_DELAY")
+
+	tests := []struct {
+		name          string
+		failFast      bool
+		expectedCalls int
+	}{
+		{name: "continues after a failure by default", failFast: false, expectedCalls: 3},
+		{name: "fail-fast skips the remaining purchases", failFast: true, expectedCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockServiceClient{}
+			for _, rec := range recs {
+				mockClient.On("PurchaseCommitment", mock.Anything, rec).
+					Return(common.PurchaseResult{Recommendation: rec, Error: fmt.Errorf("expired credentials")}, nil).Maybe()
+			}
+
+			cfg := RunConfig{Coverage: 80.0, SkipConfirmation: true, FailFast: tt.failFast}
+			results := processPurchaseLoop(ctx, recs, "us-east-1", false, mockClient, cfg)
+
+			require.Len(t, results, 3)
+			mockClient.AssertNumberOfCalls(t, "PurchaseCommitment", tt.expectedCalls)
+			assert.ErrorContains(t, results[0].Error, "expired credentials")
+			for i, result := range results[1:] {
+				assert.False(t, result.Success)
+				assert.Equal(t, recs[i+1], result.Recommendation)
+				if tt.failFast {
+					assert.ErrorContains(t, result.Error, "skipped by --fail-fast")
+				} else {
+					assert.ErrorContains(t, result.Error, "expired credentials")
+				}
+			}
+		})
+	}
+}
+
+func TestCheckMaxScanRegions(t *testing.T) {
+	regions := []string{"us-east-1", "us-west-2", "eu-west-1"}
+
+	tests := []struct {
+		name        string
+		limit       int
+		expectError bool
+	}{
+		{name: "unlimited", limit: 0},
+		{name: "under limit", limit: 5},
+		{name: "at limit", limit: 3},
+		{name: "over limit", limit: 2, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMaxScanRegions(common.ServiceRDS, regions, RunConfig{MaxScanRegions: tt.limit})
+			if tt.expectError {
+				assert.ErrorContains(t, err, "would scan 3 regions")
+				assert.ErrorContains(t, err, "--max-scan-regions 2")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuildSPCommitmentRecommendations(t *testing.T) {
+	cfg := RunConfig{TermYears: 3, PaymentOption: "no-upfront"}
+
+	recs := buildSPCommitmentRecommendations(map[string]float64{"Database": 2.0, "Compute": 5.0}, cfg)
+
+	assert.Len(t, recs, 2)
+	// Plan types are sorted for deterministic purchase order
+	assert.Equal(t, "Compute", recs[0].ResourceType)
+	assert.Equal(t, "Database", recs[1].ResourceType)
+	for _, rec := range recs {
+		assert.Equal(t, common.ServiceSavingsPlans, rec.Service)
+		assert.Equal(t, common.CommitmentSavingsPlan, rec.CommitmentType)
+		assert.Equal(t, 1, rec.Count)
+		assert.Equal(t, "3yr", rec.Term)
+		assert.Equal(t, "no-upfront", rec.PaymentOption)
+	}
+	assert.Equal(t, &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 5.0}, recs[0].Details)
+	assert.Equal(t, &common.SavingsPlanDetails{PlanType: "Database", HourlyCommitment: 2.0}, recs[1].Details)
+}
+
+func TestSPCommitmentPurchaseUsesServiceClient(t *testing.T) {
+	ctx := context.Background()
+	cfg := RunConfig{TermYears: 1, PaymentOption: "all-upfront", SkipConfirmation: true}
+
+	recs := buildSPCommitmentRecommendations(map[string]float64{"Compute": 5.0}, cfg)
+
+	mockClient := &MockServiceClient{}
+	mockClient.On("PurchaseCommitment", mock.Anything, mock.MatchedBy(func(rec common.Recommendation) bool {
+		details, ok := rec.Details.(*common.SavingsPlanDetails)
+		return ok && details.PlanType == "Compute" && details.HourlyCommitment == 5.0
+	})).Return(common.PurchaseResult{Success: true, CommitmentID: "sp-123"}, nil).Once()
+
+	results := processPurchaseLoop(ctx, recs, "us-east-1", false, mockClient, cfg)
+
+	assert.Len(t, results, 1)
+	assert.True(t, results[0].Success)
+	assert.Equal(t, "sp-123", results[0].CommitmentID)
+	mockClient.AssertExpectations(t)
+}
+
+func TestComputePurchaseDelay(t *testing.T) {
+	t.Run("Zero jitter returns base delay", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1))
+		assert.Equal(t, 2*time.Second, computePurchaseDelay(2*time.Second, 0, rng))
+	})
+
+	t.Run("Jittered delay stays within range", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(42))
+		for i := 0; i < 100; i++ {
+			delay := computePurchaseDelay(2*time.Second, 500*time.Millisecond, rng)
+			assert.GreaterOrEqual(t, delay, 1500*time.Millisecond)
+			assert.LessOrEqual(t, delay, 2500*time.Millisecond)
+		}
+	})
+
+	t.Run("Same seed produces same delays", func(t *testing.T) {
+		rng1 := rand.New(rand.NewSource(7))
+		rng2 := rand.New(rand.NewSource(7))
+		for i := 0; i < 10; i++ {
+			assert.Equal(t,
+				computePurchaseDelay(2*time.Second, time.Second, rng1),
+				computePurchaseDelay(2*time.Second, time.Second, rng2))
+		}
+	})
+
+	t.Run("Jitter larger than base never goes negative", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(3))
+		for i := 0; i < 100; i++ {
+			assert.GreaterOrEqual(t, computePurchaseDelay(time.Second, 5*time.Second, rng), time.Duration(0))
+		}
+	})
+
+	t.Run("Shared source is safe for concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					delay := computePurchaseDelay(2*time.Second, time.Second, purchaseDelayRand)
+					assert.GreaterOrEqual(t, delay, time.Second)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestProcessPurchaseLoopWithConfirmation(t *testing.T) {
+	ctx := context.Background()
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	testCfg.Coverage = 80.0
+	testCfg.SkipConfirmation = true // Skip confirmation to proceed with purchase
+
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, ResourceType: "db.r5.large", Count: 5, SourceRecommendation: "Expensive", EstimatedSavings: 1000},
+	}
+
+	mockClient := &MockServiceClient{}
+	// Mock the purchase since skipConfirmation=true will proceed
+	result := common.PurchaseResult{
+		Recommendation: recs[0],
+		Success:        true,
+		CommitmentID:   "confirmed-purchase-123",
+		Error:          nil,
+		Timestamp:      time.Now(),
+	}
+	mockClient.On("PurchaseCommitment", mock.Anything, recs[0]).Return(result, nil)
+
+	// Logger output disabled for testing
+
+	// Disable purchase delay for testing
+	os.Setenv("DISABLE_PURCHASE_DELAY", "true")
+	defer os.Unsetenv("DISABLE_PURCHASE_DELAY")
+
+	results := processPurchaseLoop(ctx, recs, "us-west-2", false, mockClient, testCfg)
+
+	assert.Len(t, results, 1)
+	assert.True(t, results[0].Success)
+	assert.Equal(t, "confirmed-purchase-123", results[0].CommitmentID)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestProcessPurchaseLoopConfirmPhraseRunTotals(t *testing.T) {
+	os.Setenv("DISABLE_PURCHASE_DELAY", "true")
+	defer os.Unsetenv("DISABLE_PURCHASE_DELAY")
+	original := confirmInput
+	defer func() { confirmInput = original }()
+
+	cfg := RunConfig{ConfirmPhrase: true, ConfirmPhraseInstances: 20, ConfirmPhraseCost: 5000, confirmed: &confirmedPurchases{}}
+	mockClient := &MockServiceClient{}
+	mockClient.On("PurchaseCommitment", mock.Anything, mock.Anything).Return(common.PurchaseResult{Success: true, CommitmentID: "ri-1"}, nil)
+
+	// Each region stays under the thresholds, while the third one takes the run over 20 instances
+	for i, region := range []string{"us-east-1", "us-west-2", "eu-west-1"} {
+		recs := []common.Recommendation{{Service: common.ServiceRDS, Region: region, ResourceType: "db.t3.small", Count: 10, Term: "1yr", AmortizedMonthlyCost: 10}}
+		confirmInput = strings.NewReader("yes\n")
+
+		results := processPurchaseLoop(context.Background(), recs, region, false, mockClient, cfg)
+
+		require.Len(t, results, 1)
+		assert.Equal(t, i < 2, results[0].Success, region)
+	}
+	assert.Equal(t, 20, cfg.confirmed.instances, "the cancelled batch is not counted")
+
+	// The phrase confirms the batch taking the run over the threshold
+	confirmInput = strings.NewReader("PURCHASE 10 INSTANCES\n")
+	recs := []common.Recommendation{{Service: common.ServiceRDS, Region: "eu-west-1", ResourceType: "db.t3.small", Count: 10, Term: "1yr"}}
+	results := processPurchaseLoop(context.Background(), recs, "eu-west-1", false, mockClient, cfg)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Success)
+	assert.Equal(t, 30, cfg.confirmed.instances)
+}
+
+func TestProcessPurchaseLoopConfirmPhraseCommitmentCost(t *testing.T) {
+	original := confirmInput
+	defer func() { confirmInput = original }()
+	confirmInput = strings.NewReader("yes\n")
+
+	// A large upfront payment with modest savings is above the cost threshold
+	cfg := RunConfig{ConfirmPhrase: true, ConfirmPhraseInstances: 20, ConfirmPhraseCost: 5000, confirmed: &confirmedPurchases{}}
+	recs := []common.Recommendation{{Service: common.ServiceRDS, ResourceType: "db.r6g.4xlarge", Count: 1, Term: "1yr", UpfrontCost: 6000, EstimatedSavings: 50}}
+	mockClient := &MockServiceClient{}
+
+	results := processPurchaseLoop(context.Background(), recs, "us-east-1", false, mockClient, cfg)
+
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Success)
+	mockClient.AssertNotCalled(t, "PurchaseCommitment", mock.Anything, mock.Anything)
+}
+
+func TestCommitmentTotalCost(t *testing.T) {
+	tests := []struct {
+		name string
+		rec  common.Recommendation
+		want float64
+	}{
+		{name: "amortized cost over the term", rec: common.Recommendation{Term: "1yr", UpfrontCost: 2400, AmortizedMonthlyCost: 230}, want: 2760},
+		{name: "all upfront", rec: common.Recommendation{Term: "3yr", UpfrontCost: 3600, AmortizedMonthlyCost: 100}, want: 3600},
+		{name: "savings plan commitment", rec: common.Recommendation{Service: common.ServiceSavingsPlans, Term: "1yr", Details: &common.SavingsPlanDetails{HourlyCommitment: 1}}, want: hoursPerMonth * 12},
+		{name: "on-demand spend minus savings", rec: common.Recommendation{Term: "1yr", OnDemandCost: 300, EstimatedSavings: 100}, want: 2400},
+		{name: "unknown term keeps the upfront payment", rec: common.Recommendation{UpfrontCost: 500, AmortizedMonthlyCost: 50}, want: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, CommitmentTotalCost(tt.rec), 0.001)
+		})
+	}
+}
+
+func TestAdjustRecsForDuplicates(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name               string
+		inputRecs          []common.Recommendation
+		existingRIs        []common.Commitment
+		expectedCount      int
+		expectedError      bool
+	}{
+		{
+			name: "No duplicates",
+			inputRecs: []common.Recommendation{
+				{ResourceType: "db.t3.small", Count: 5},
+				{ResourceType: "db.t3.medium", Count: 3},
+			},
+			existingRIs:   []common.Commitment{},
+			expectedCount: 2,
+			expectedError: false,
+		},
+		{
+			name: "With duplicates - adjusts count",
+			inputRecs: []common.Recommendation{
+				{ResourceType: "db.t3.small", Count: 10},
+			},
+			existingRIs: []common.Commitment{
+				{ResourceType: "db.t3.small", Count: 3},
+			},
+			expectedCount: 1, // Should still have 1 recommendation but with adjusted count
+			expectedError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockServiceClient{}
+			mockClient.On("GetExistingCommitments", ctx).Return(tt.existingRIs, nil)
+
+			// Suppress logger output (no return value from SetEnabled)
+			// Logger output disabled for testing
+
+			results, err := adjustRecsForDuplicates(ctx, tt.inputRecs, mockClient, 0)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.LessOrEqual(t, len(results), len(tt.inputRecs))
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdjustRecsForDuplicatesError(t *testing.T) {
+	ctx := context.Background()
+
+	recs := []common.Recommendation{
+		{ResourceType: "db.t3.small", Count: 5},
+	}
+
+	mockClient := &MockServiceClient{}
+	mockClient.On("GetExistingCommitments", ctx).Return([]common.Commitment(nil), errors.New("API error"))
+
+	// Logger output disabled for testing
+
+	results, err := adjustRecsForDuplicates(ctx, recs, mockClient, 0)
+
+	// Should return original recommendations with error (error is propagated)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "API error")
+	assert.Equal(t, recs, results) // Still returns original recommendations
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestAdjustRecsForDuplicatesIgnoresExpiring(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	recs := []common.Recommendation{{ResourceType: "db.t3.small", Region: "us-east-1", Count: 5}}
+	existing := []common.Commitment{
+		{ResourceType: "db.t3.small", Region: "us-east-1", Count: 2, State: "active", StartDate: now.Add(-time.Hour), EndDate: now.Add(72 * time.Hour)},
+		{ResourceType: "db.t3.small", Region: "us-east-1", Count: 1, State: "active", StartDate: now.Add(-time.Hour), EndDate: now.AddDate(1, 0, 0)},
+	}
+
+	tests := []struct {
+		name           string
+		ignoreExpiring time.Duration
+		expectedCount  int
+	}{
+		{name: "expiring reservations count by default", expectedCount: 2},
+		{name: "reservations expiring within the window are ignored", ignoreExpiring: 7 * 24 * time.Hour, expectedCount: 4},
+		{name: "window shorter than the remaining term", ignoreExpiring: 24 * time.Hour, expectedCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockServiceClient{}
+			mockClient.On("GetExistingCommitments", ctx).Return(existing, nil)
+
+			results, err := adjustRecsForDuplicates(ctx, recs, mockClient, tt.ignoreExpiring)
+
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, tt.expectedCount, results[0].Count)
+		})
+	}
+}
+
+func TestGroupRecommendationsByServiceRegion(t *testing.T) {
+	tests := []struct {
+		name           string
+		recommendations []common.Recommendation
+		expectedGroups  map[common.ServiceType]map[string]int // service -> region -> count
+	}{
+		{
+			name: "Single service single region",
+			recommendations: []common.Recommendation{
+				{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.small", Count: 5},
+				{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.medium", Count: 3},
+			},
+			expectedGroups: map[common.ServiceType]map[string]int{
+				common.ServiceRDS: {"us-east-1": 2},
+			},
+		},
+		{
+			name: "Single service multiple regions",
+			recommendations: []common.Recommendation{
+				{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.small", Count: 5},
+				{Service: common.ServiceRDS, Region: "us-west-2", ResourceType: "db.t3.medium", Count: 3},
+				{Service: common.ServiceRDS, Region: "eu-west-1", ResourceType: "db.t3.large", Count: 2},
+			},
+			expectedGroups: map[common.ServiceType]map[string]int{
+				common.ServiceRDS: {"us-east-1": 1, "us-west-2": 1, "eu-west-1": 1},
+			},
+		},
+		{
+			name: "Multiple services multiple regions",
+			recommendations: []common.Recommendation{
+				{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.small", Count: 5},
+				{Service: common.ServiceRDS, Region: "us-west-2", ResourceType: "db.t3.medium", Count: 3},
+				{Service: common.ServiceElastiCache, Region: "us-east-1", ResourceType: "cache.t3.small", Count: 2},
+				{Service: common.ServiceElastiCache, Region: "eu-west-1", ResourceType: "cache.t3.medium", Count: 4},
+				{Service: common.ServiceEC2, Region: "us-east-1", ResourceType: "m5.large", Count: 10},
+			},
+			expectedGroups: map[common.ServiceType]map[string]int{
+				common.ServiceRDS:         {"us-east-1": 1, "us-west-2": 1},
+				common.ServiceElastiCache: {"us-east-1": 1, "eu-west-1": 1},
+				common.ServiceEC2:         {"us-east-1": 1},
+			},
+		},
+		{
+			name:            "Empty recommendations",
+			recommendations: []common.Recommendation{},
+			expectedGroups:  map[common.ServiceType]map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := groupRecommendationsByServiceRegion(tt.recommendations)
+
+			// Verify the structure matches expected
+			assert.Equal(t, len(tt.expectedGroups), len(result))
+
+			for service, regions := range tt.expectedGroups {
+				assert.Contains(t, result, service)
+				assert.Equal(t, len(regions), len(result[service]))
+
+				for region, expectedCount := range regions {
+					assert.Contains(t, result[service], region)
+					assert.Equal(t, expectedCount, len(result[service][region]))
+				}
+			}
+		})
+	}
+}
+
+func TestLoadEngineVersionInfoSkipVersionChecks(t *testing.T) {
+	instanceVersions, versionInfo := loadEngineVersionInfo(context.Background(), RunConfig{SkipVersionChecks: true}, []string{"us-east-1"})
+
+	assert.Empty(t, instanceVersions)
+	assert.Empty(t, versionInfo)
+}
+
+func TestFilterAndAdjustRecommendations(t *testing.T) {
+	// Save and restore ALL global variables
+	saved := saveGlobalVars()
+	defer saved.restore()
+
+	tests := []struct {
+		name            string
+		recommendations []common.Recommendation
+		coverage        float64
+		setupFilters    func()
+		expectedMin     int // minimum expected recommendations
+		expectedMax     int // maximum expected recommendations
+	}{
+		{
+			name: "100% coverage no filters",
+			recommendations: []common.Recommendation{
+				{Service: common.ServiceRDS, ResourceType: "db.t3.small", Count: 5},
+				{Service: common.ServiceRDS, ResourceType: "db.t3.medium", Count: 3},
+			},
+			coverage:    100.0,
+			setupFilters: func() {
+				testCfg.MaxInstances = 0
+				testCfg.OverrideCount = 0
+			},
+			expectedMin: 2,
+			expectedMax: 2,
+		},
+		{
+			name: "50% coverage",
+			recommendations: []common.Recommendation{
+				{Service: common.ServiceRDS, ResourceType: "db.t3.small", Count: 10},
+				{Service: common.ServiceRDS, ResourceType: "db.t3.medium", Count: 6},
+			},
+			coverage: 50.0,
+			setupFilters: func() {
+				testCfg.MaxInstances = 0
+				testCfg.OverrideCount = 0
+			},
+			expectedMin: 1,
+			expectedMax: 2,
+		},
+		{
+			name: "Instance limit applied",
+			recommendations: []common.Recommendation{
+				{Service: common.ServiceRDS, ResourceType: "db.t3.small", Count: 10},
+				{Service: common.ServiceRDS, ResourceType: "db.t3.medium", Count: 10},
+				{Service: common.ServiceRDS, ResourceType: "db.t3.large", Count: 10},
+			},
+			coverage: 100.0,
+			setupFilters: func() {
+				testCfg.MaxInstances = 15
+				testCfg.OverrideCount = 0
+			},
+			expectedMin: 1,
+			expectedMax: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup filters
+			tt.setupFilters()
+
+			// Suppress logger
+			// Logger output disabled for testing
+
+			result := filterAndAdjustRecommendations(tt.recommendations, tt.coverage, testCfg)
+
+			// Verify result is within expected range
+			assert.GreaterOrEqual(t, len(result), tt.expectedMin)
+			assert.LessOrEqual(t, len(result), tt.expectedMax)
+
+			// Verify all results have count > 0
+			for _, rec := range result {
+				assert.Positive(t, rec.Count)
+			}
+		})
+	}
+}
+
+func TestRunToolFromCSV(t *testing.T) {
+	// Save original values
+	origCfg := testCfg
+
+	defer func() {
+		testCfg = origCfg
+	}()
+
+	// Create a temporary CSV file for testing
+	tmpFile, err := os.CreateTemp("", "test_recommendations_*.csv")
+	assert.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	// Write test CSV data
+	csvData := `Service,Region,Engine,Instance Type,Payment Option,Term (months),Instance Count,Account ID
+rds,us-east-1,postgres,db.t3.small,All Upfront,12,2,123456789012
+elasticache,us-west-2,redis,cache.t3.micro,All Upfront,12,1,123456789012
+`
+	_, err = tmpFile.WriteString(csvData)
+	assert.NoError(t, err)
+	tmpFile.Close()
+
+	tests := []struct {
+		name         string
+		setupConfig  func()
+		expectPanic  bool
+		validateFunc func(t *testing.T)
+	}{
+		{
+			name: "Dry run mode",
+			setupConfig: func() {
+				testCfg.CSVInput = tmpFile.Name()
+				testCfg.ActualPurchase = false
+				testCfg.Coverage = 100.0
+				testCfg.MaxInstances = 0
+			},
+			expectPanic: false,
+		},
+		{
+			name: "With coverage adjustment",
+			setupConfig: func() {
+				testCfg.CSVInput = tmpFile.Name()
+				testCfg.ActualPurchase = false
+				testCfg.Coverage = 50.0
+				testCfg.MaxInstances = 0
+			},
+			expectPanic: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupConfig()
+
+			// Suppress logger
+			// Logger output disabled for testing
+
+			ctx := context.Background()
+
+			if tt.expectPanic {
+				assert.Panics(t, func() {
+					runToolFromCSV(ctx, testCfg)
+				})
+			} else {
+				// Just verify it doesn't panic - actual purchase testing requires AWS mocks
+				assert.NotPanics(t, func() {
+					runToolFromCSV(ctx, testCfg)
+				})
+			}
+		})
+	}
+}
+
+func TestRunToolFromCSVNothingToProcess(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test_empty_recommendations_*.csv")
+	assert.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString("Service,Region,ResourceType,Count\n")
+	assert.NoError(t, err)
+	tmpFile.Close()
+
+	cfg := RunConfig{CSVInput: tmpFile.Name(), Coverage: 100.0, IncludeExtendedSupport: true}
+	report, err := runToolFromCSV(context.Background(), cfg)
+
+	assert.NoError(t, err)
+	assert.Nil(t, report)
+}
+
+func TestRunToolFromCSVMissingFile(t *testing.T) {
+	cfg := RunConfig{CSVInput: "/nonexistent/recommendations.csv"}
+	report, err := runToolFromCSV(context.Background(), cfg)
+
+	assert.Error(t, err)
+	assert.Nil(t, report)
+}
+
+func TestLoadRecommendationsFromCSVHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		csvData string
+	}{
+		{
+			name: "report columns",
+			csvData: "Service,Region,ResourceType,Count,Account,Term,PaymentOption,EstimatedSavings\n" +
+				"rds,us-east-1,db.t3.small,2,123456789012,1yr,all-upfront,12.5\n",
+		},
+		{
+			name: "reordered, quoted and differently cased columns",
+			csvData: "\"estimated savings\", PaymentOption ,TERM,account,count,resource_type,REGION,\"Service\"\n" +
+				"12.5,all-upfront,1yr,123456789012,2,db.t3.small,us-east-1,rds\n",
+		},
+		{
+			name: "legacy columns",
+			csvData: "Service,Region,Engine,Instance Type,Payment Option,Term (months),Instance Count,Account ID,Estimated Savings\n" +
+				"rds,us-east-1,postgres,db.t3.small,All Upfront,12,2,123456789012,12.5\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "recs.csv")
+			require.NoError(t, os.WriteFile(path, []byte(tt.csvData), 0o600))
+
+			recs, err := loadRecommendationsFromCSV(path)
+			require.NoError(t, err)
+
+			assert.Equal(t, []common.Recommendation{{
+				Service:          common.ServiceRDS,
+				Region:           "us-east-1",
+				ResourceType:     "db.t3.small",
+				Count:            2,
+				Account:          "123456789012",
+				Term:             "1yr",
+				PaymentOption:    "all-upfront",
+				EstimatedSavings: 12.5,
+			}}, recs)
+		})
+	}
+}
+
+func TestLoadRecommendationsFromCSVMalformedRow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recs.csv")
+	csvData := "Service,Region,ResourceType,Count\n" +
+		"rds,us-east-1,db.t3.small,2\n" +
+		"rds,us-east-1,\"db.t3.medium,1\n" +
+		"rds,us-west-2,db.t3.large,1\n"
+	require.NoError(t, os.WriteFile(path, []byte(csvData), 0o600))
+
+	recs, err := loadRecommendationsFromCSV(path)
+
+	assert.ErrorContains(t, err, "failed to read CSV row")
+	assert.Nil(t, recs)
+}
+
+func TestLoadRecommendationsFromCSVMalformedNumbers(t *testing.T) {
+	tests := []struct {
+		name    string
+		csvData string
+		wantErr string
+	}{
+		{
+			name: "malformed count",
+			csvData: "Service,Region,ResourceType,Count,Estimated Savings\n" +
+				"rds,us-east-1,db.t3.small,2,10.5\n" +
+				"rds,us-east-1,db.t3.medium,two,12\n",
+			wantErr: `invalid Count "two" in CSV row at line 3`,
+		},
+		{
+			name: "count with trailing text",
+			csvData: "Service,Count\n" +
+				"rds,3x\n",
+			wantErr: `invalid Count "3x" in CSV row at line 2`,
+		},
+		{
+			name: "malformed estimated savings",
+			csvData: "Service,Count,Estimated Savings\n" +
+				"rds,1,$12\n",
+			wantErr: `invalid Estimated Savings "$12" in CSV row at line 2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "recs.csv")
+			require.NoError(t, os.WriteFile(path, []byte(tt.csvData), 0o600))
+
+			recs, err := loadRecommendationsFromCSV(path)
+
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Nil(t, recs)
+		})
+	}
+}
+
+func TestRunToolMultiServiceRejectsBothInputs(t *testing.T) {
+	cfg := RunConfig{CSVInput: "recs.csv", JSONInput: "recs.json"}
+	report, err := runToolMultiService(context.Background(), cfg)
+
+	assert.ErrorContains(t, err, "--input-csv and --input-json cannot be combined")
+	assert.Nil(t, report)
+}
+
+func TestRunReportCollectResultErrors(t *testing.T) {
+	report := newRunReport(false)
+	report.Results = []common.PurchaseResult{
+		{CommitmentID: "ri-1", Success: true},
+		{CommitmentID: "ri-2", Success: false, Error: errors.New("insufficient capacity")},
+		{CommitmentID: "ri-3", Success: false},
+	}
+
+	report.collectResultErrors()
+
+	assert.False(t, report.DryRun)
+	assert.Len(t, report.Errors, 1)
+	assert.Contains(t, report.Errors[0].Error(), "ri-2")
+	assert.Contains(t, report.Errors[0].Error(), "insufficient capacity")
+}
+
+func TestRenderRunReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	csvPath := tmpDir + "/report.csv"
+
+	report := newRunReport(true)
+	rec := common.Recommendation{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1}
+	report.Recommendations = []common.Recommendation{rec}
+	report.Results = []common.PurchaseResult{{Recommendation: rec, Success: true, DryRun: true, Timestamp: time.Now()}}
+	report.ServiceStats[common.ServiceRDS] = calculateServiceStats(common.ServiceRDS, report.Recommendations, report.Results)
+
+	RenderReport(report, RunConfig{CSVOutput: csvPath})
+
+	data, err := os.ReadFile(csvPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "db.t3.micro")
+	assert.Equal(t, []string{csvPath}, report.WrittenReports)
+}
+
+// ==================== Tests for adjustRecommendationForExcludedVersions ====================
+
+// Helper to create test version info with extended support dates
+func createTestVersionInfo() map[string]MajorEngineVersionInfo {
+	now := time.Now()
+	pastDate := now.AddDate(0, -6, 0) // 6 months ago
+	futureDate := now.AddDate(3, 0, 0) // 3 years from now
+
+	return map[string]MajorEngineVersionInfo{
+		"aurora-mysql:5.7": {
+			Engine:             "aurora-mysql",
+			MajorEngineVersion: "5.7",
+			SupportedEngineLifecycles: []EngineLifecycleInfo{
+				{
+					LifecycleSupportName:      "open-source-rds-standard-support",
+					LifecycleSupportStartDate: now.AddDate(-5, 0, 0),
+					LifecycleSupportEndDate:   pastDate,
+				},
+				{
+					LifecycleSupportName:      "open-source-rds-extended-support",
+					LifecycleSupportStartDate: pastDate,
+					LifecycleSupportEndDate:   futureDate,
+				},
+			},
+		},
+		"aurora-mysql:8.0": {
+			Engine:             "aurora-mysql",
+			MajorEngineVersion: "8.0",
+			SupportedEngineLifecycles: []EngineLifecycleInfo{
+				{
+					LifecycleSupportName:      "open-source-rds-standard-support",
+					LifecycleSupportStartDate: now.AddDate(-2, 0, 0),
+					LifecycleSupportEndDate:   futureDate,
+				},
+			},
+		},
+	}
+}
+
+func TestAdjustRecommendationForExcludedVersions(t *testing.T) {
+	tests := []struct {
+		name             string
+		recommendation   common.Recommendation
+		versionInfo      map[string]MajorEngineVersionInfo
+		instanceVersions map[string][]InstanceEngineVersion
+		expectedCount    int
+		expectedAdjusted bool
+	}{
+		{
+			name: "No running instances - recommendation unchanged",
+			recommendation: common.Recommendation{
+				Service:      common.ServiceRDS,
+				Region:       "us-east-1",
+				ResourceType: "db.r5.large",
+				Count:        10,
+				Details: &common.DatabaseDetails{
+					Engine: "Aurora MySQL",
+				},
+			},
+			versionInfo:      createTestVersionInfo(),
+			instanceVersions: map[string][]InstanceEngineVersion{},
+			expectedCount:    10,
+			expectedAdjusted: false,
+		},
+		{
+			name: "Exclude 1 MySQL 5.7 instance in extended support",
+			recommendation: common.Recommendation{
+				Service:      common.ServiceRDS,
+				Region:       "us-east-1",
+				ResourceType: "db.r5.large",
+				Count:        10,
+				Details: &common.DatabaseDetails{
+					Engine: "Aurora MySQL",
+				},
+			},
+			versionInfo: createTestVersionInfo(),
+			instanceVersions: map[string][]InstanceEngineVersion{
+				"db.r5.large": {
+					{Engine: "aurora-mysql", EngineVersion: "5.7.mysql_aurora.2.11.1", InstanceClass: "db.r5.large", Region: "us-east-1"},
+					{Engine: "aurora-mysql", EngineVersion: "8.0.mysql_aurora.3.04.0", InstanceClass: "db.r5.large", Region: "us-east-1"},
+					{Engine: "aurora-mysql", EngineVersion: "8.0.mysql_aurora.3.04.0", InstanceClass: "db.r5.large", Region: "us-east-1"},
+				},
+			},
+			expectedCount:    9, // 10 - 1 MySQL 5.7 instance in extended support
+			expectedAdjusted: true,
+		},
+		{
+			name: "Exclude all MySQL 5.7 instances in extended support",
+			recommendation: common.Recommendation{
+				Service:      common.ServiceRDS,
+				Region:       "eu-west-2",
+				ResourceType: "db.t3.small",
+				Count:        2,
+				Details: &common.DatabaseDetails{
+					Engine: "Aurora MySQL",
+				},
+			},
+			versionInfo: createTestVersionInfo(),
+			instanceVersions: map[string][]InstanceEngineVersion{
+				"db.t3.small": {
+					{Engine: "aurora-mysql", EngineVersion: "5.7.mysql_aurora.2.11.2", InstanceClass: "db.t3.small", Region: "eu-west-2"},
+					{Engine: "aurora-mysql", EngineVersion: "5.7.mysql_aurora.2.11.2", InstanceClass: "db.t3.small", Region: "eu-west-2"},
+				},
+			},
+			expectedCount:    0, // All excluded (both in extended support)
+			expectedAdjusted: true,
+		},
+		{
+			name: "Different engine - no adjustment",
+			recommendation: common.Recommendation{
+				Service:      common.ServiceRDS,
+				Region:       "us-east-1",
+				ResourceType: "db.r5.large",
+				Count:        5,
+				Details: &common.DatabaseDetails{
+					Engine: "Aurora PostgreSQL",
+				},
+			},
+			versionInfo: createTestVersionInfo(),
+			instanceVersions: map[string][]InstanceEngineVersion{
+				"db.r5.large": {
+					{Engine: "aurora-mysql", EngineVersion: "5.7.mysql_aurora.2.11.1", InstanceClass: "db.r5.large", Region: "us-east-1"},
+				},
+			},
+			expectedCount:    5, // Different engine, no adjustment
+			expectedAdjusted: false,
+		},
+		{
+			name: "Different region - no adjustment",
+			recommendation: common.Recommendation{
+				Service:      common.ServiceRDS,
+				Region:       "us-east-1",
+				ResourceType: "db.r5.large",
+				Count:        5,
+				Details: &common.DatabaseDetails{
+					Engine: "Aurora MySQL",
+				},
+			},
+			versionInfo: createTestVersionInfo(),
+			instanceVersions: map[string][]InstanceEngineVersion{
+				"db.r5.large": {
+					{Engine: "aurora-mysql", EngineVersion: "5.7.mysql_aurora.2.11.1", InstanceClass: "db.r5.large", Region: "eu-west-2"},
+				},
+			},
+			expectedCount:    5, // Different region, no adjustment
+			expectedAdjusted: false,
+		},
+		{
+			name: "MySQL (not Aurora) with standard mysql engine name",
+			recommendation: common.Recommendation{
+				Service:      common.ServiceRDS,
+				Region:       "eu-west-2",
+				ResourceType: "db.r5.4xlarge",
+				Count:        8,
+				Details: &common.DatabaseDetails{
+					Engine: "MySQL",
+				},
+			},
+			versionInfo: map[string]MajorEngineVersionInfo{
+				"mysql:5.7": {
+					Engine:             "mysql",
+					MajorEngineVersion: "5.7",
+					SupportedEngineLifecycles: []EngineLifecycleInfo{
+						{
+							LifecycleSupportName:      "open-source-rds-extended-support",
+							LifecycleSupportStartDate: time.Now().AddDate(0, -6, 0),
+							LifecycleSupportEndDate:   time.Now().AddDate(3, 0, 0),
+						},
+					},
+				},
+			},
+			instanceVersions: map[string][]InstanceEngineVersion{
+				"db.r5.4xlarge": {
+					{Engine: "mysql", EngineVersion: "5.7.44", InstanceClass: "db.r5.4xlarge", Region: "eu-west-2"},
+					{Engine: "mysql", EngineVersion: "8.0.35", InstanceClass: "db.r5.4xlarge", Region: "eu-west-2"},
+				},
+			},
+			expectedCount:    7, // 8 - 1 MySQL 5.7 instance in extended support
+			expectedAdjusted: true,
+		},
+		{
+			name: "Engine name normalization - spaces vs hyphens",
+			recommendation: common.Recommendation{
+				Service:      common.ServiceRDS,
+				Region:       "us-west-2",
+				ResourceType: "db.r6g.large",
+				Count:        3,
+				Details: &common.DatabaseDetails{
+					Engine: "Aurora MySQL", // Space in name
+				},
+			},
+			versionInfo: createTestVersionInfo(),
+			instanceVersions: map[string][]InstanceEngineVersion{
+				"db.r6g.large": {
+					{Engine: "aurora-mysql", EngineVersion: "5.7.mysql_aurora.2.12.0", InstanceClass: "db.r6g.large", Region: "us-west-2"}, // Hyphen in name
+				},
+			},
+			expectedCount:    2, // Should match despite space vs hyphen
+			expectedAdjusted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := adjustRecommendationForExcludedVersions(tt.recommendation, tt.instanceVersions, tt.versionInfo)
+
+			assert.Equal(t, tt.expectedCount, result.Count, "Instance count mismatch")
+
+			if tt.expectedAdjusted {
+				assert.NotEqual(t, tt.recommendation.Count, result.Count, "Count should have been adjusted")
+			} else {
+				assert.Equal(t, tt.recommendation.Count, result.Count, "Count should not have been adjusted")
+			}
+		})
+	}
+}
+
+func TestAdjustRecommendationForExcludedVersions_MultipleVersionsInExtendedSupport(t *testing.T) {
+	recommendation := common.Recommendation{
+		Service:      common.ServiceRDS,
+		Region:       "us-east-1",
+		ResourceType: "db.r5.large",
+		Count:        10,
+		Details: &common.DatabaseDetails{
+			Engine: "Aurora MySQL",
+		},
+	}
+
+	instanceVersions := map[string][]InstanceEngineVersion{
+		"db.r5.large": {
+			{Engine: "aurora-mysql", EngineVersion: "5.6.mysql_aurora.1.22.5", InstanceClass: "db.r5.large", Region: "us-east-1"},
+			{Engine: "aurora-mysql", EngineVersion: "5.7.mysql_aurora.2.11.1", InstanceClass: "db.r5.large", Region: "us-east-1"},
+			{Engine: "aurora-mysql", EngineVersion: "8.0.mysql_aurora.3.04.0", InstanceClass: "db.r5.large", Region: "us-east-1"},
+		},
+	}
+
+	// Version info with both 5.6 and 5.7 in extended support
+	versionInfo := map[string]MajorEngineVersionInfo{
+		"aurora-mysql:5.6": {
+			Engine:             "aurora-mysql",
+			MajorEngineVersion: "5.6",
+			SupportedEngineLifecycles: []EngineLifecycleInfo{
+				{
+					LifecycleSupportName:      "open-source-rds-extended-support",
+					LifecycleSupportStartDate: time.Now().AddDate(0, -12, 0),
+					LifecycleSupportEndDate:   time.Now().AddDate(2, 0, 0),
+				},
+			},
+		},
+		"aurora-mysql:5.7": {
+			Engine:             "aurora-mysql",
+			MajorEngineVersion: "5.7",
+			SupportedEngineLifecycles: []EngineLifecycleInfo{
+				{
+					LifecycleSupportName:      "open-source-rds-extended-support",
+					LifecycleSupportStartDate: time.Now().AddDate(0, -6, 0),
+					LifecycleSupportEndDate:   time.Now().AddDate(3, 0, 0),
+				},
+			},
+		},
+	}
+
+	result := adjustRecommendationForExcludedVersions(recommendation, instanceVersions, versionInfo)
+
+	assert.Equal(t, 8, result.Count, "Should exclude 2 instances (5.6 and 5.7 both in extended support)")
+}
+
+func TestOnlyDecommissionedInstances(t *testing.T) {
+	instanceVersions := map[string][]InstanceEngineVersion{
+		"db.r5.large": {
+			{InstanceClass: "db.r5.large", Region: "us-east-1", Decommissioned: true},
+			{InstanceClass: "db.r5.large", Region: "us-east-1", Decommissioned: true},
+			{InstanceClass: "db.r5.large", Region: "us-west-2", Decommissioned: false},
+		},
+		"db.t3.micro": {
+			{InstanceClass: "db.t3.micro", Region: "us-east-1", Decommissioned: true},
+			{InstanceClass: "db.t3.micro", Region: "us-east-1", Decommissioned: false},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		rec      common.Recommendation
+		expected bool
+	}{
+		{"All matching instances decommissioned", common.Recommendation{ResourceType: "db.r5.large", Region: "us-east-1"}, true},
+		{"Same type in another region still in use", common.Recommendation{ResourceType: "db.r5.large", Region: "us-west-2"}, false},
+		{"Mix of decommissioned and active instances", common.Recommendation{ResourceType: "db.t3.micro", Region: "us-east-1"}, false},
+		{"No matching running instances", common.Recommendation{ResourceType: "db.m5.large", Region: "us-east-1"}, false},
+		{"No matching instances in region", common.Recommendation{ResourceType: "db.t3.micro", Region: "eu-west-1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, onlyDecommissionedInstances(tt.rec, instanceVersions))
+		})
+	}
+}
+
+func TestApplyFiltersDecommissionTag(t *testing.T) {
+	instanceVersions := map[string][]InstanceEngineVersion{
+		"db.r5.large": {{InstanceClass: "db.r5.large", Region: "us-east-1", Decommissioned: true}},
+	}
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 1},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1},
+	}
+
+	// Without the tag configured nothing is excluded
+	result := applyFilters(recs, RunConfig{IncludeExtendedSupport: true}, instanceVersions, make(map[string]MajorEngineVersionInfo), "")
+	assert.Len(t, result, 2)
+
+	cfg := RunConfig{IncludeExtendedSupport: true, DecommissionTag: "decommission=true"}
+	result = applyFilters(recs, cfg, instanceVersions, make(map[string]MajorEngineVersionInfo), "")
+	assert.Len(t, result, 1)
+	assert.Equal(t, "db.t3.micro", result[0].ResourceType)
+}
+
+func TestParseExcludedEngineVersions(t *testing.T) {
+	excluded, err := parseExcludedEngineVersions([]string{"mysql:5.7", " Aurora-MySQL : 8.0 "})
+	require.NoError(t, err)
+	assert.Equal(t, []excludedEngineVersion{{Engine: "mysql", Version: "5.7"}, {Engine: "aurora-mysql", Version: "8.0"}}, excluded)
+
+	for _, entry := range []string{"mysql", "mysql:", ":5.7"} {
+		_, err := parseExcludedEngineVersions([]string{entry})
+		assert.ErrorContains(t, err, "expected engine:version", entry)
+	}
+}
+
+func TestExcludedEngineVersionMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		excluded excludedEngineVersion
+		version  InstanceEngineVersion
+		expected bool
+	}{
+		{"MySQL release of the major version", excludedEngineVersion{"mysql", "5.7"}, InstanceEngineVersion{Engine: "mysql", EngineVersion: "5.7.44"}, true},
+		{"MySQL other major version", excludedEngineVersion{"mysql", "5.7"}, InstanceEngineVersion{Engine: "mysql", EngineVersion: "8.0.35"}, false},
+		{"PostgreSQL major version", excludedEngineVersion{"postgresql", "11"}, InstanceEngineVersion{Engine: "postgres", EngineVersion: "11.22"}, true},
+		{"PostgreSQL prefix is not a version", excludedEngineVersion{"postgresql", "1"}, InstanceEngineVersion{Engine: "postgres", EngineVersion: "11.22"}, false},
+		{"Aurora MySQL compatible version", excludedEngineVersion{"aurora-mysql", "5.7"}, InstanceEngineVersion{Engine: "aurora-mysql", EngineVersion: "5.7.mysql_aurora.2.11.3"}, true},
+		{"Oracle edition", excludedEngineVersion{"oracle", "19"}, InstanceEngineVersion{Engine: "oracle-ee", EngineVersion: "19.0.0.0.ru-2024-01.rur-2024-01.r1"}, true},
+		{"SQL Server edition", excludedEngineVersion{"sqlserver", "15.00"}, InstanceEngineVersion{Engine: "sqlserver-se", EngineVersion: "15.00.4345.5.v1"}, true},
+		{"different engine", excludedEngineVersion{"mysql", "5.7"}, InstanceEngineVersion{Engine: "aurora-mysql", EngineVersion: "5.7.mysql_aurora.2.11.3"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.excluded.matches(tt.version))
+		})
+	}
+}
+
+func TestApplyFiltersExcludeEngineVersions(t *testing.T) {
+	instanceVersions := map[string][]InstanceEngineVersion{
+		"db.r5.large": {
+			{Engine: "mysql", EngineVersion: "5.7.44", InstanceClass: "db.r5.large", Region: "us-east-1"},
+			{Engine: "mysql", EngineVersion: "5.7.44", InstanceClass: "db.r5.large", Region: "us-east-1"},
+			{Engine: "mysql", EngineVersion: "8.0.35", InstanceClass: "db.r5.large", Region: "us-east-1"},
+		},
+	}
+	// MySQL 5.7 is also in extended support
+	versionInfo := map[string]MajorEngineVersionInfo{
+		"mysql:5.7": {
+			Engine: "mysql", MajorEngineVersion: "5.7",
+			SupportedEngineLifecycles: []EngineLifecycleInfo{{LifecycleSupportName: "open-source-rds-extended-support", LifecycleSupportStartDate: time.Now().AddDate(-1, 0, 0)}},
+		},
+	}
+	rec := common.Recommendation{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 3, Details: &common.DatabaseDetails{Engine: "mysql"}}
+
+	tests := []struct {
+		name     string
+		cfg      RunConfig
+		expected int
+	}{
+		{"nothing excluded", RunConfig{IncludeExtendedSupport: true}, 3},
+		{"excluded version regardless of support status", RunConfig{IncludeExtendedSupport: true, ExcludeEngineVersions: []string{"mysql:5.7"}}, 1},
+		{"instances excluded twice are subtracted once", RunConfig{ExcludeEngineVersions: []string{"mysql:5.7"}}, 1},
+		{"both versions excluded", RunConfig{IncludeExtendedSupport: true, ExcludeEngineVersions: []string{"mysql:5.7", "mysql:8.0"}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := applyFilters([]common.Recommendation{rec}, tt.cfg, instanceVersions, versionInfo, "")
+			if tt.expected == 0 {
+				assert.Empty(t, result)
+				return
+			}
+			require.Len(t, result, 1)
+			assert.Equal(t, tt.expected, result[0].Count)
+		})
+	}
+}
+
+func TestApplyFiltersExcludeEngineVersionsPostgreSQL(t *testing.T) {
+	instanceVersions := map[string][]InstanceEngineVersion{
+		"db.r5.large": {
+			{Engine: "postgres", EngineVersion: "11.22", InstanceClass: "db.r5.large", Region: "us-east-1"},
+			{Engine: "postgres", EngineVersion: "16.3", InstanceClass: "db.r5.large", Region: "us-east-1"},
+		},
+	}
+	// Cost Explorer names the engine PostgreSQL, RDS names it postgres
+	rec := common.Recommendation{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 2, Details: &common.DatabaseDetails{Engine: "PostgreSQL"}}
+	cfg := RunConfig{IncludeExtendedSupport: true, ExcludeEngineVersions: []string{"postgres:11"}}
+
+	result := applyFilters([]common.Recommendation{rec}, cfg, instanceVersions, make(map[string]MajorEngineVersionInfo), "")
+
+	require.Len(t, result, 1)
+	assert.Equal(t, 1, result[0].Count)
+}
+
+func TestAdjustRecommendationForInstanceAge(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-365 * 24 * time.Hour)
+	recent := now.Add(-24 * time.Hour)
+	instanceVersions := map[string][]InstanceEngineVersion{
+		"db.r5.large": {
+			{Region: "us-east-1", LaunchTime: old},
+			{Region: "us-east-1", LaunchTime: old},
+			{Region: "us-east-1", LaunchTime: recent},
+			{Region: "us-west-2", LaunchTime: recent},
+			{Region: "us-west-2", LaunchTime: recent},
+			{Region: "us-west-2", LaunchTime: old},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		rec      common.Recommendation
+		expected int
+	}{
+		{"Mostly old instances are down-weighted", common.Recommendation{ResourceType: "db.r5.large", Region: "us-east-1", Count: 3}, 2},
+		{"Mostly young instances are excluded", common.Recommendation{ResourceType: "db.r5.large", Region: "us-west-2", Count: 3}, 0},
+		{"No matching running instances", common.Recommendation{ResourceType: "db.m5.large", Region: "us-east-1", Count: 3}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := adjustRecommendationForInstanceAge(tt.rec, instanceVersions, 90*24*time.Hour, now)
+			assert.Equal(t, tt.expected, result.Count)
+		})
+	}
+}
+
+func TestApplyFiltersMinInstanceAgeOnlyForThreeYearTerms(t *testing.T) {
+	instanceVersions := map[string][]InstanceEngineVersion{
+		"db.r5.large": {{Region: "us-east-1", LaunchTime: time.Now().Add(-time.Hour)}},
+	}
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 1, Term: "1yr"},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 1, Term: "3yr"},
+	}
+
+	cfg := RunConfig{IncludeExtendedSupport: true, MinInstanceAge: 30 * 24 * time.Hour}
+	result := applyFilters(recs, cfg, instanceVersions, make(map[string]MajorEngineVersionInfo), "")
+	require.Len(t, result, 1)
+	assert.Equal(t, "1yr", result[0].Term)
+}
+
+func TestCapRecommendationToRunning(t *testing.T) {
+	instanceVersions := map[string][]InstanceEngineVersion{
+		"db.r5.large": {
+			{Engine: "mysql", Region: "us-east-1"},
+			{Engine: "mysql", Region: "us-east-1"},
+			{Engine: "postgres", Region: "us-east-1"},
+			{Engine: "oracle-ee", Region: "us-east-1"},
+			{Engine: "mysql", Region: "us-west-2"},
+		},
+	}
+	rds := func(engine, region string, count int) common.Recommendation {
+		return common.Recommendation{Service: common.ServiceRDS, ResourceType: "db.r5.large", Region: region, Count: count, Details: &common.DatabaseDetails{Engine: engine}}
+	}
+
+	tests := []struct {
+		name     string
+		rec      common.Recommendation
+		expected int
+	}{
+		{"Capped at the running instances", rds("mysql", "us-east-1", 5), 2},
+		{"Below the running instances", rds("mysql", "us-east-1", 1), 1},
+		{"Cost Explorer engine names", rds("MySQL", "us-west-2", 3), 1},
+		{"Running engine names are normalized", rds("PostgreSQL", "us-east-1", 3), 1},
+		{"Engine editions count as the engine", rds("Oracle", "us-east-1", 2), 1},
+		{"No matching running instances", rds("mariadb", "us-east-1", 2), 0},
+		{"Other services are unchanged", common.Recommendation{Service: common.ServiceEC2, ResourceType: "m5.large", Region: "us-east-1", Count: 4, Details: &common.ComputeDetails{}}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := capRecommendationToRunning(tt.rec, instanceVersions)
+			assert.Equal(t, tt.expected, result.Count)
+		})
+	}
+}
+
+func TestApplyFiltersExcludeZeroSavings(t *testing.T) {
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 1, EstimatedSavings: 25},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.m5.large", Count: 1},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1, EstimatedSavings: 0.01},
+	}
+
+	tests := []struct {
+		name     string
+		exclude  bool
+		expected []string
+	}{
+		{"disabled keeps zero savings", false, []string{"db.r5.large", "db.m5.large", "db.t3.micro"}},
+		{"enabled drops only zero savings", true, []string{"db.r5.large", "db.t3.micro"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := RunConfig{IncludeExtendedSupport: true, ExcludeZeroSavings: tt.exclude}
+			result := applyFilters(recs, cfg, nil, make(map[string]MajorEngineVersionInfo), "")
+
+			var types []string
+			for _, rec := range result {
+				types = append(types, rec.ResourceType)
+			}
+			assert.Equal(t, tt.expected, types)
+		})
+	}
+}
+
+func TestApplyFiltersCapToRunning(t *testing.T) {
+	instanceVersions := map[string][]InstanceEngineVersion{
+		"db.r5.large": {{Engine: "mysql", Region: "us-east-1"}},
+	}
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 3, Details: &common.DatabaseDetails{Engine: "mysql"}},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.m5.large", Count: 2, Details: &common.DatabaseDetails{Engine: "mysql"}},
+	}
+
+	cfg := RunConfig{IncludeExtendedSupport: true, CapToRunning: true}
+	result := applyFilters(recs, cfg, instanceVersions, make(map[string]MajorEngineVersionInfo), "")
+	require.Len(t, result, 1)
+	assert.Equal(t, "db.r5.large", result[0].ResourceType)
+	assert.Equal(t, 1, result[0].Count)
+}
+
+func TestApplyFiltersFilterExpression(t *testing.T) {
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 1, SavingsPercentage: 30},
+		{Service: common.ServiceRDS, Region: "eu-west-1", ResourceType: "db.r5.large", Count: 1, SavingsPercentage: 30},
+		{Service: common.ServiceRDS, Region: "eu-west-1", ResourceType: "db.r5.xlarge", Count: 1, SavingsPercentage: 10},
+	}
+
+	cfg := RunConfig{IncludeExtendedSupport: true, FilterExpression: "region!=us-east-1 && savings_percent>20"}
+	result := applyFilters(recs, cfg, nil, make(map[string]MajorEngineVersionInfo), "")
+	require.Len(t, result, 1)
+	assert.Equal(t, "eu-west-1", result[0].Region)
+	assert.Equal(t, "db.r5.large", result[0].ResourceType)
+}
+
+func TestAdjustRecommendationForExcludedVersions_NonRDSService(t *testing.T) {
+	recommendation := common.Recommendation{
+		Service:        common.ServiceEC2,
+		Region:         "us-east-1",
+		ResourceType:   "m5.large",
+		Count:          5,
+		Details: nil, // Not RDS
+	}
+
+	instanceVersions := map[string][]InstanceEngineVersion{}
+	versionInfo := createTestVersionInfo()
+
+	result := adjustRecommendationForExcludedVersions(recommendation, instanceVersions, versionInfo)
+
+	assert.Equal(t, 5, result.Count, "Non-RDS services should not be adjusted")
+}
+
+// ==================== generateCSVFilename Tests ====================
+
+func TestGenerateCSVFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		isDryRun bool
+		cfg      RunConfig
+		check    func(t *testing.T, filename string)
+	}{
+		{
+			name:     "Dry run mode generates dryrun filename",
+			isDryRun: true,
+			cfg:      RunConfig{},
+			check: func(t *testing.T, filename string) {
+				assert.Contains(t, filename, "ri-helper-dryrun-")
+				assert.Contains(t, filename, ".csv")
+			},
+		},
+		{
+			name:     "Purchase mode generates purchase filename",
+			isDryRun: false,
+			cfg:      RunConfig{},
+			check: func(t *testing.T, filename string) {
+				assert.Contains(t, filename, "ri-helper-purchase-")
+				assert.Contains(t, filename, ".csv")
+			},
+		},
+		{
+			name:     "Custom output overrides default",
+			isDryRun: true,
+			cfg:      RunConfig{CSVOutput: "custom-output.csv"},
+			check: func(t *testing.T, filename string) {
+				assert.Equal(t, "custom-output.csv", filename)
+			},
+		},
+		{
+			name:     "JSON output format uses json extension",
+			isDryRun: true,
+			cfg:      RunConfig{OutputFormat: OutputFormatJSON},
+			check: func(t *testing.T, filename string) {
+				assert.Contains(t, filename, "ri-helper-dryrun-")
+				assert.True(t, strings.HasSuffix(filename, ".json"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := generateCSVFilename(tt.isDryRun, tt.cfg)
+			tt.check(t, result)
+		})
+	}
+}
+
+// ==================== printRunMode Tests ====================
+
+func TestPrintRunMode(t *testing.T) {
+	// Capture output by disabling logger
+	// Logger output disabled for testing
+
+	// Just ensure no panic - the function primarily prints
+	printRunMode(true)
+	printRunMode(false)
+}
+
+// ==================== printPaymentAndTerm Tests ====================
+
+func TestPrintPaymentAndTerm(t *testing.T) {
+	// Capture output by disabling logger
+	// Logger output disabled for testing
+
+	cfg := RunConfig{
+		PaymentOption: "partial-upfront",
+		TermYears:     3,
+	}
+
+	// Just ensure no panic - the function primarily prints
+	printPaymentAndTerm(cfg)
+}
+
+// ==================== extractMajorVersion Tests ====================
+
+func TestExtractMajorVersion_Additional(t *testing.T) {
+	tests := []struct {
+		name     string
+		engine   string
+		version  string
+		expected string
+	}{
+		{
+			name:     "MySQL 5.7.44 extracts 5.7",
+			engine:   "mysql",
+			version:  "5.7.44",
+			expected: "5.7",
+		},
+		{
+			name:     "MySQL 8.0.35 extracts 8.0",
+			engine:   "mysql",
+			version:  "8.0.35",
+			expected: "8.0",
+		},
+		{
+			name:     "PostgreSQL 13.10 extracts 13.10",
+			engine:   "postgres",
+			version:  "13.10",
+			expected: "13.10",
+		},
+		{
+			name:     "PostgreSQL 15.4 extracts 15.4",
+			engine:   "postgres",
+			version:  "15.4",
+			expected: "15.4",
+		},
+		{
+			name:     "Aurora MySQL compatible 5.7.mysql_aurora.2.11.3",
+			engine:   "aurora-mysql",
+			version:  "5.7.mysql_aurora.2.11.3",
+			expected: "5.7",
+		},
+		{
+			name:     "Aurora PostgreSQL 14.6",
+			engine:   "aurora-postgresql",
+			version:  "14.6",
+			expected: "14",
+		},
+		{
+			name:     "Empty version",
+			engine:   "mysql",
+			version:  "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := extractMajorVersion(tt.engine, tt.version)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestAuroraPostgreSQLExtendedSupport(t *testing.T) {
+	now := time.Now()
+	extendedSupport := func(engine, major string, start time.Time) MajorEngineVersionInfo {
+		return MajorEngineVersionInfo{
+			Engine:             engine,
+			MajorEngineVersion: major,
+			SupportedEngineLifecycles: []EngineLifecycleInfo{
+				{LifecycleSupportName: "open-source-rds-extended-support", LifecycleSupportStartDate: start, LifecycleSupportEndDate: start.AddDate(3, 0, 0)},
+			},
+		}
+	}
+	// Shaped like the DescribeDBMajorEngineVersions output for aurora-postgresql
+	versionInfo := map[string]MajorEngineVersionInfo{
+		"aurora-postgresql:11":  extendedSupport("aurora-postgresql", "11", now.AddDate(-1, 0, 0)),
+		"aurora-postgresql:12":  extendedSupport("aurora-postgresql", "12", now.AddDate(0, -1, 0)),
+		"aurora-postgresql:13":  extendedSupport("aurora-postgresql", "13", now.AddDate(1, 0, 0)),
+		"aurora-postgresql:16":  {Engine: "aurora-postgresql", MajorEngineVersion: "16"},
+		"aurora-postgresql:9.6": extendedSupport("aurora-postgresql", "9.6", now.AddDate(-3, 0, 0)),
+	}
+
+	tests := []struct {
+		name              string
+		version           string
+		expectedMajor     string
+		expectedExtended  bool
+		expectedKeyExists bool
+	}{
+		{name: "11.9 is in extended support", version: "11.9", expectedMajor: "11", expectedExtended: true, expectedKeyExists: true},
+		{name: "11.21 is in extended support", version: "11.21", expectedMajor: "11", expectedExtended: true, expectedKeyExists: true},
+		{name: "patch release 11.9.1", version: "11.9.1", expectedMajor: "11", expectedExtended: true, expectedKeyExists: true},
+		{name: "12.16 entered extended support", version: "12.16", expectedMajor: "12", expectedExtended: true, expectedKeyExists: true},
+		{name: "13.12 before extended support starts", version: "13.12", expectedMajor: "13", expectedKeyExists: true},
+		{name: "16.1 in standard support", version: "16.1", expectedMajor: "16", expectedKeyExists: true},
+		{name: "legacy 9.6.22 keeps minor", version: "9.6.22", expectedMajor: "9.6", expectedExtended: true, expectedKeyExists: true},
+		{name: "15.4 unknown to AWS output", version: "15.4", expectedMajor: "15"},
+		{name: "non-numeric version", version: "aurora-pg", expectedMajor: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedMajor, extractMajorVersion("aurora-postgresql", tt.version))
+			_, exists := resolveMajorVersionKey("aurora-postgresql", tt.version, versionInfo)
+			assert.Equal(t, tt.expectedKeyExists, exists)
+			assert.Equal(t, tt.expectedExtended, isInExtendedSupport("aurora-postgresql", tt.version, versionInfo))
+		})
+	}
+}
+
+func TestResolveMajorVersionKeyFallsBackToReportedMajor(t *testing.T) {
+	// The PostgreSQL parser keeps the minor version; the reported major version is found by its prefix
+	versionInfo := map[string]MajorEngineVersionInfo{"postgres:13": {Engine: "postgres", MajorEngineVersion: "13"}}
+	key, ok := resolveMajorVersionKey("postgres", "13.10", versionInfo)
+	assert.True(t, ok)
+	assert.Equal(t, "postgres:13", key)
+
+	_, ok = resolveMajorVersionKey("postgres", "14.1", versionInfo)
+	assert.False(t, ok)
+}
+
+// ==================== determineServicesToProcess Tests ====================
+
+func TestDetermineServicesToProcess_AllServices(t *testing.T) {
+	cfg := RunConfig{
+		AllServices: true,
+	}
+
+	result := determineServicesToProcess(cfg)
+
+	// Should contain all supported services
+	assert.Contains(t, result, common.ServiceRDS)
+	assert.Contains(t, result, common.ServiceElastiCache)
+	assert.Contains(t, result, common.ServiceEC2)
+	assert.Contains(t, result, common.ServiceOpenSearch)
+	assert.Contains(t, result, common.ServiceRedshift)
+	assert.Contains(t, result, common.ServiceMemoryDB)
+}
+
+func TestDetermineServicesToProcess_SpecificServices(t *testing.T) {
+	cfg := RunConfig{
+		AllServices: false,
+		Services:    []string{"rds", "elasticache"},
+	}
+
+	result := determineServicesToProcess(cfg)
+
+	assert.Equal(t, 2, len(result))
+	assert.Contains(t, result, common.ServiceRDS)
+	assert.Contains(t, result, common.ServiceElastiCache)
+}
+
+// ==================== determineCSVCoverage Tests ====================
+
+func TestDetermineCSVCoverage_Additional(t *testing.T) {
+	tests := []struct {
+		name             string
+		cfg              RunConfig
+		expectedCoverage float64
+	}{
+		{
+			name: "Coverage from config at 75%",
+			cfg: RunConfig{
+				Coverage: 75.0,
+			},
+			expectedCoverage: 75.0,
+		},
+		{
+			name: "Coverage at 100%",
+			cfg: RunConfig{
+				Coverage: 100.0,
+			},
+			expectedCoverage: 100.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := determineCSVCoverage(tt.cfg)
+			assert.Equal(t, tt.expectedCoverage, result)
+		})
+	}
+}
+
+func TestDescribeInstanceEngineVersions(t *testing.T) {
+	ctx := context.Background()
+	launched := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	east := &MockRDSClient{}
+	east.On("DescribeDBInstances", ctx, "").Return(&rds.DescribeDBInstancesOutput{
+		DBInstances: []rdstypes.DBInstance{
+			{DBInstanceClass: aws.String("db.r5.large"), Engine: aws.String("mysql"), EngineVersion: aws.String("5.7.44"), InstanceCreateTime: &launched},
+		},
+		Marker: aws.String("page-2"),
+	}, nil)
+	east.On("DescribeDBInstances", ctx, "page-2").Return(&rds.DescribeDBInstancesOutput{
+		DBInstances: []rdstypes.DBInstance{
+			{
+				DBInstanceClass: aws.String("db.r5.large"), Engine: aws.String("postgres"), EngineVersion: aws.String("11.22"),
+				TagList: []rdstypes.Tag{{Key: aws.String("lifecycle"), Value: aws.String("decommission")}},
+			},
+		},
+	}, nil)
+	west := &MockRDSClient{}
+	west.On("DescribeDBInstances", ctx, "").Return(&rds.DescribeDBInstancesOutput{
+		DBInstances: []rdstypes.DBInstance{
+			{DBInstanceClass: aws.String("db.t3.micro"), Engine: aws.String("mysql"), EngineVersion: aws.String("8.0.35")},
+		},
+	}, nil)
+	failing := &MockRDSClient{}
+	failing.On("DescribeDBInstances", ctx, "").Return(nil, errors.New("access denied"))
+
+	clients := map[string]*MockRDSClient{"us-east-1": east, "us-west-2": west, "eu-west-1": failing}
+	var queried []string
+	var mu sync.Mutex
+	got := describeInstanceEngineVersions(ctx, []string{"us-east-1", "eu-west-1", "us-west-2"}, func(region string) RDSClientInterface {
+		mu.Lock()
+		defer mu.Unlock()
+		queried = append(queried, region)
+		return clients[region]
+	}, "lifecycle", "decommission")
+
+	assert.ElementsMatch(t, []string{"us-east-1", "eu-west-1", "us-west-2"}, queried)
+	assert.Equal(t, map[string][]InstanceEngineVersion{
+		"db.r5.large": {
+			{Engine: "mysql", EngineVersion: "5.7.44", InstanceClass: "db.r5.large", Region: "us-east-1", LaunchTime: launched},
+			{Engine: "postgres", EngineVersion: "11.22", InstanceClass: "db.r5.large", Region: "us-east-1", Decommissioned: true},
+		},
+		"db.t3.micro": {
+			{Engine: "mysql", EngineVersion: "8.0.35", InstanceClass: "db.t3.micro", Region: "us-west-2"},
+		},
+	}, got)
+	east.AssertExpectations(t)
+	west.AssertExpectations(t)
+	failing.AssertExpectations(t)
+}
+
+func TestRunningEngines(t *testing.T) {
+	instanceVersions := map[string][]InstanceEngineVersion{
+		"db.r5.large": {{Engine: "postgres"}, {Engine: "MySQL"}},
+		"db.t3.micro": {{Engine: "mysql"}, {Engine: "sqlserver-se"}},
+	}
+	assert.Equal(t, []string{"mysql", "postgres"}, runningEngines(instanceVersions))
+	assert.Empty(t, runningEngines(nil))
+}
+
+func TestRecommendationRegions(t *testing.T) {
+	recs := []common.Recommendation{
+		{Region: "eu-west-1"},
+		{Region: "us-east-1"},
+		{Region: ""},
+		{Region: "eu-west-1"},
+	}
+	assert.Equal(t, []string{"eu-west-1", "us-east-1"}, recommendationRegions(recs))
+	assert.Empty(t, recommendationRegions(nil))
+}
+
+func TestRecommendationParamsAutoPayment(t *testing.T) {
+	tests := []struct {
+		name        string
+		service     common.ServiceType
+		termYears   int
+		autoPayment bool
+		expected    string
+	}{
+		{name: "RDS 3yr no-upfront without auto payment", service: common.ServiceRDS, termYears: 3, expected: "no-upfront"},
+		{name: "RDS 3yr no-upfront falls back to partial-upfront", service: common.ServiceRDS, termYears: 3, autoPayment: true, expected: "partial-upfront"},
+		{name: "RDS 1yr no-upfront is kept", service: common.ServiceRDS, termYears: 1, autoPayment: true, expected: "no-upfront"},
+		{name: "other services keep no-upfront", service: common.ServiceElastiCache, termYears: 3, autoPayment: true, expected: "no-upfront"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := RunConfig{PaymentOption: "no-upfront", TermYears: tt.termYears, AutoPayment: tt.autoPayment}
+			params := recommendationParams(tt.service, "us-east-1", cfg)
+			assert.Equal(t, tt.expected, params.PaymentOption)
+			assert.Equal(t, termString(tt.termYears), params.Term)
+		})
+	}
+}
+
+func TestRecommendationParamsEC2OfferingClass(t *testing.T) {
+	cfg := RunConfig{PaymentOption: "all-upfront", TermYears: 1, EC2OfferingClass: "convertible"}
+
+	assert.Equal(t, "convertible", recommendationParams(common.ServiceEC2, "us-east-1", cfg).EC2OfferingClass)
+	assert.Empty(t, recommendationParams(common.ServiceRDS, "us-east-1", cfg).EC2OfferingClass)
+}
+
+func TestRecommendationParamsAccountID(t *testing.T) {
+	cfg := RunConfig{PaymentOption: "all-upfront", TermYears: 1, AccountID: "123456789012"}
+
+	assert.Equal(t, "123456789012", recommendationParams(common.ServiceRDS, "us-east-1", cfg).AccountID)
+	assert.Equal(t, "123456789012", recommendationParams(common.ServiceSavingsPlans, "us-east-1", cfg).AccountID)
+}
+
+func TestEC2DetailsSuffix(t *testing.T) {
+	assert.Equal(t, " (convertible)", ec2DetailsSuffix(common.Recommendation{Details: &common.ComputeDetails{OfferingClass: "convertible"}}))
+	assert.Equal(t, " (standard, availability-zone us-east-1a)", ec2DetailsSuffix(common.Recommendation{Details: &common.ComputeDetails{OfferingClass: "standard", Scope: "availability-zone", AvailabilityZone: "us-east-1a"}}))
+	assert.Empty(t, ec2DetailsSuffix(common.Recommendation{Details: &common.ComputeDetails{}}))
+	assert.Empty(t, ec2DetailsSuffix(common.Recommendation{Details: &common.DatabaseDetails{Engine: "mysql"}}))
+}
+
+func TestDropUnpurchasableResourceTypes(t *testing.T) {
+	ctx := context.Background()
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "eu-west-1", ResourceType: "db.r6g.large", Count: 2},
+		{Service: common.ServiceRDS, Region: "eu-west-1", ResourceType: "db.x2g.large", Count: 1},
+	}
+
+	tests := []struct {
+		name       string
+		service    common.ServiceType
+		validTypes []string
+		err        error
+		expected   []common.Recommendation
+		queried    bool
+	}{
+		{name: "drops types missing from the region", service: common.ServiceRDS, validTypes: []string{"db.r6g.large", "db.r6g.xlarge"}, expected: recs[:1], queried: true},
+		{name: "keeps all when listing fails", service: common.ServiceRDS, err: fmt.Errorf("access denied"), expected: recs, queried: true},
+		{name: "keeps all when no types are listed", service: common.ServiceRDS, validTypes: []string{}, expected: recs, queried: true},
+		{name: "skips services with static lists", service: common.ServiceMemoryDB, expected: recs},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockServiceClient{}
+			if tt.queried {
+				var validTypes any
+				if tt.validTypes != nil {
+					validTypes = tt.validTypes
+				}
+				mockClient.On("GetValidResourceTypes", ctx).Return(validTypes, tt.err).Once()
+			}
+
+			assert.Equal(t, tt.expected, dropUnpurchasableResourceTypes(ctx, tt.service, recs, mockClient))
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTermMonths(t *testing.T) {
+	tests := []struct {
+		term     string
+		expected int
+	}{
+		{"1yr", 12},
+		{"3yr", 36},
+		{"3", 36},
+		{"36", 36},
+		{"", 0},
+		{"unknown", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			assert.Equal(t, tt.expected, termMonths(tt.term))
+		})
+	}
+}
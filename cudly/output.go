@@ -0,0 +1,202 @@
+package cudly
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// plainOutput strips emoji and box-drawing characters from all output when set (--no-emoji)
+var plainOutput bool
+
+// displayToStderr moves logs and display output to stderr, keeping stdout for the --json-summary object
+var displayToStderr bool
+
+// quietOutput suppresses display output and all console logs but errors when set (--quiet)
+var quietOutput bool
+
+// logLevel and logFormat control how ConfigureOutput routes the loggers (--log-level, --log-format)
+var (
+	logLevel  = slog.LevelInfo
+	logFormat = common.LogFormatText
+)
+
+// logFile additionally receives the log messages when set with ConfigureLogFile
+var logFile io.Writer
+
+// debugLoggers receive debug messages once ConfigureOutput has set up leveled logging
+var debugLoggers []*slog.Logger
+
+// displayWriter returns the stream logs and display output are written to
+func displayWriter() io.Writer {
+	if quietOutput {
+		return io.Discard
+	}
+	if displayToStderr {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// plainReplacer maps the decorations used in output to ASCII equivalents
+// Decorations without a meaningful ASCII form are removed by toPlainText
+var plainReplacer = strings.NewReplacer(
+	"⚠️", "[WARN]",
+	"⚠", "[WARN]",
+	"❌", "[ERROR]",
+	"✅", "[OK]",
+	"ℹ️", "[INFO]",
+	"🚫", "[SKIP]",
+	"💡", "[TIP]",
+	"⭐", "*",
+	"→", "->",
+	"━", "=",
+)
+
+// toPlainText converts decorated output to ASCII-only text
+func toPlainText(s string) string {
+	return stripNonASCII(plainReplacer.Replace(s))
+}
+
+// stripNonASCII drops non-ASCII runes, along with the single space that followed each dropped symbol
+func stripNonASCII(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	dropSpace := false
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			dropSpace = true
+			continue
+		}
+		if dropSpace && r == ' ' {
+			dropSpace = false
+			continue
+		}
+		dropSpace = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// formatOutput returns s unchanged, or as ASCII-only text in plain output mode
+func formatOutput(s string) string {
+	if !plainOutput {
+		return s
+	}
+	return toPlainText(s)
+}
+
+// outPrintf writes formatted output to stdout through the central output formatter
+func outPrintf(format string, args ...any) {
+	fmt.Fprint(displayWriter(), formatOutput(fmt.Sprintf(format, args...)))
+}
+
+// outPrintln writes a line to stdout through the central output formatter
+func outPrintln(args ...any) {
+	fmt.Fprint(displayWriter(), formatOutput(fmt.Sprintln(args...)))
+}
+
+// plainWriter rewrites everything written through it as ASCII-only text
+type plainWriter struct {
+	w io.Writer
+}
+
+func (p plainWriter) Write(data []byte) (int, error) {
+	if _, err := io.WriteString(p.w, toPlainText(string(data))); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// ConfigureLogging sets the minimum level and the format of log messages, applied by the next ConfigureOutput call
+func ConfigureLogging(level, format string) error {
+	parsed, err := common.ParseLogLevel(level)
+	if err != nil {
+		return err
+	}
+	if _, err := common.NewLogger(io.Discard, parsed, format); err != nil {
+		return err
+	}
+	logLevel = parsed
+	logFormat = format
+	if logFormat == "" {
+		logFormat = common.LogFormatText
+	}
+	return nil
+}
+
+// ConfigureOutput switches the application and standard loggers between decorated and ASCII-only output
+// With toStderr set, application logs and display output go to stderr instead of stdout.
+// Both loggers are routed through a leveled logger honouring ConfigureLogging.
+func ConfigureOutput(plain, toStderr bool) {
+	plainOutput = plain
+	displayToStderr = toStderr
+
+	// JSON records carry their own timestamp
+	structured := logFormat == common.LogFormatJSON
+	if structured {
+		log.SetFlags(0)
+	} else {
+		log.SetFlags(log.LstdFlags)
+	}
+
+	// --quiet keeps only errors on the console, while the log file still receives every message
+	stdLevel := logLevel
+	if quietOutput {
+		stdLevel = max(logLevel, slog.LevelError)
+	}
+	appLogger := newOutputLogger(displayWriter(), logLevel)
+	stdLogger := newOutputLogger(os.Stderr, stdLevel)
+	appOut := io.Writer(common.NewLevelWriter(appLogger, structured))
+	stdOut := io.Writer(common.NewLevelWriter(stdLogger, structured))
+	debugLoggers = []*slog.Logger{stdLogger}
+	if logFile != nil {
+		fileLogger := newOutputLogger(logFile, logLevel)
+		fileOut := common.NewLevelWriter(fileLogger, structured)
+		appOut, stdOut = io.MultiWriter(appOut, fileOut), io.MultiWriter(stdOut, fileOut)
+		debugLoggers = append(debugLoggers, fileLogger)
+	}
+	AppLogger.SetOutput(appOut)
+	log.SetOutput(stdOut)
+}
+
+// newOutputLogger creates a leveled logger writing to w in the configured format, as ASCII-only text in plain output mode
+func newOutputLogger(w io.Writer, level slog.Level) *slog.Logger {
+	if plainOutput {
+		w = plainWriter{w: w}
+	}
+	// The format was validated by ConfigureLogging
+	logger, _ := common.NewLogger(w, level, logFormat)
+	return logger
+}
+
+// ConfigureQuiet suppresses display output and all console logs but errors (--quiet)
+// Report files and the log file are still written. The current output settings are applied again.
+func ConfigureQuiet(quiet bool) {
+	quietOutput = quiet
+	ConfigureOutput(plainOutput, displayToStderr)
+}
+
+// ConfigureLogFile copies all log messages to w, such as the log file of the --output-dir run directory
+// The current output settings are applied again; a nil writer stops copying.
+func ConfigureLogFile(w io.Writer) {
+	logFile = w
+	ConfigureOutput(plainOutput, displayToStderr)
+}
+
+// logDebugf logs a diagnostic message, shown only with --log-level=debug once leveled logging is configured
+func logDebugf(format string, args ...any) {
+	if len(debugLoggers) == 0 {
+		log.Printf(format, args...)
+		return
+	}
+	for _, logger := range debugLoggers {
+		logger.Debug(fmt.Sprintf(format, args...))
+	}
+}
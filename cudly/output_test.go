@@ -0,0 +1,134 @@
+package cudly
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToPlainText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "plain text unchanged", input: "Mode: DRY RUN\n", expected: "Mode: DRY RUN\n"},
+		{name: "warning", input: "⚠️  Warning: Could not check\n", expected: "[WARN]  Warning: Could not check\n"},
+		{name: "success and error", input: "    ✅ Success: ri-1\n    ❌ Failed: boom\n", expected: "    [OK] Success: ri-1\n    [ERROR] Failed: boom\n"},
+		{name: "leading emoji dropped", input: "📊 Processing services: RDS\n", expected: "Processing services: RDS\n"},
+		{name: "indented emoji dropped", input: "  📍 [1/2] Region: us-east-1\n", expected: "  [1/2] Region: us-east-1\n"},
+		{name: "arrow", input: "5 instances → 3 instances", expected: "5 instances -> 3 instances"},
+		{name: "box drawing", input: "━━━━", expected: "===="},
+		{name: "info with variation selector", input: "  ℹ️  No recommendations found\n", expected: "  [INFO]  No recommendations found\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, toPlainText(tt.input))
+		})
+	}
+}
+
+func TestPlainWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := plainWriter{w: &buf}
+
+	input := []byte("🎯 Processing RDS\n")
+	n, err := w.Write(input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, len(input), n)
+	assert.Equal(t, "Processing RDS\n", buf.String())
+}
+
+func TestPrintServiceSummaryPlainOutput(t *testing.T) {
+	ConfigureOutput(true, false)
+	defer ConfigureOutput(false, false)
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	printServiceSummary(common.ServiceRDS, ServiceProcessingStats{RegionsProcessed: 1, TotalEstimatedSavings: 10})
+
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	output := buf.String()
+
+	assert.Contains(t, output, "Summary:")
+	for _, r := range output {
+		assert.LessOrEqual(t, r, rune(127), "unexpected non-ASCII character %q in plain output", r)
+	}
+}
+
+func TestConfigureLogging(t *testing.T) {
+	defer func() {
+		require.NoError(t, ConfigureLogging("info", common.LogFormatText))
+		ConfigureOutput(false, false)
+	}()
+
+	assert.ErrorContains(t, ConfigureLogging("verbose", common.LogFormatText), "invalid log level")
+	assert.ErrorContains(t, ConfigureLogging("info", "xml"), "invalid log format")
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	require.NoError(t, ConfigureLogging("warn", common.LogFormatJSON))
+	ConfigureOutput(false, false)
+	AppLogger.Printf("📊 Processing services: RDS\n")
+	AppLogger.Printf("  ⚠️  Warning: Could not check for existing RIs: %v\n", "throttled")
+
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record), "the info message must be filtered out, leaving one record")
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "⚠️  Warning: Could not check for existing RIs: throttled", record["msg"])
+}
+
+func TestConfigureQuiet(t *testing.T) {
+	var logBuf bytes.Buffer
+	oldOut, oldErr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+
+	ConfigureLogFile(&logBuf)
+	ConfigureQuiet(true)
+	AppLogger.Printf("📊 Processing services: RDS\n")
+	outPrintf("🎯 Final Summary:\n")
+	log.Printf("⚠️  Warning: Could not check for existing RIs: throttled")
+	log.Printf("❌ Failed to purchase: insufficient capacity")
+	ConfigureQuiet(false)
+	ConfigureLogFile(nil)
+
+	outW.Close()
+	errW.Close()
+	os.Stdout, os.Stderr = oldOut, oldErr
+	defer ConfigureOutput(false, false)
+
+	var stdout, stderr bytes.Buffer
+	io.Copy(&stdout, outR)
+	io.Copy(&stderr, errR)
+
+	assert.Empty(t, stdout.String())
+	assert.NotContains(t, stderr.String(), "Warning")
+	assert.Contains(t, stderr.String(), "❌ Failed to purchase: insufficient capacity")
+	assert.Contains(t, logBuf.String(), "Processing services: RDS", "the log file keeps every message")
+	assert.Contains(t, logBuf.String(), "Warning: Could not check for existing RIs")
+}
@@ -0,0 +1,51 @@
+package cudly
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RunLogFileName is the name of the log file written to the run directory of --output-dir
+const RunLogFileName = "cudly.log"
+
+// CreateRunOutputDir creates the timestamped run directory under baseDir, such as output/20240101-120000
+// The returned directory is meant to be set as RunConfig.OutputDir, so that the reports of the run are written to it.
+func CreateRunOutputDir(baseDir string, now time.Time) (string, error) {
+	dir := filepath.Join(baseDir, now.Format("20060102-150405"))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return dir, nil
+}
+
+// outputPath returns the path a report is written to: relative paths are placed under --output-dir when it is set
+func outputPath(cfg RunConfig, path string) string {
+	if cfg.OutputDir == "" || path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(cfg.OutputDir, path)
+}
+
+// validateOutputDir checks that --output-dir is an existing directory and that the report paths fit in it
+func validateOutputDir(cfg RunConfig) error {
+	info, err := os.Stat(cfg.OutputDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("output directory does not exist: %s", cfg.OutputDir)
+		}
+		return fmt.Errorf("invalid output-dir: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("output-dir is not a directory: %s", cfg.OutputDir)
+	}
+
+	reports := []struct{ flag, path string }{{"--output", cfg.CSVOutput}, {"--html-output", cfg.HTMLOutput}, {"--markdown-output", cfg.MarkdownOutput}, {"--metrics-file", cfg.MetricsFile}}
+	for _, report := range reports {
+		if report.path != "" && !filepath.IsAbs(report.path) && filepath.Dir(report.path) != "." {
+			return fmt.Errorf("%s must be a file name or an absolute path when combined with --output-dir, got: %s", report.flag, report.path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package cudly
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateRunOutputDir(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "output")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	dir, err := CreateRunOutputDir(base, now)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(base, "20240101-120000"), dir)
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+}
+
+func TestOutputPath(t *testing.T) {
+	cfg := RunConfig{OutputDir: filepath.Join("output", "20240101-120000")}
+	assert.Equal(t, filepath.Join("output", "20240101-120000", "report.html"), outputPath(cfg, "report.html"))
+	assert.Equal(t, "/tmp/report.html", outputPath(cfg, "/tmp/report.html"))
+	assert.Equal(t, "", outputPath(cfg, ""))
+	assert.Equal(t, "report.html", outputPath(RunConfig{}, "report.html"))
+
+	generated := generateCSVFilename(true, cfg)
+	assert.Equal(t, cfg.OutputDir, filepath.Dir(generated))
+	assert.True(t, strings.HasPrefix(filepath.Base(generated), "ri-helper-dryrun-"))
+	assert.Equal(t, filepath.Join(cfg.OutputDir, "plan.sh"), generateAWSCLIScriptFilename(RunConfig{OutputDir: cfg.OutputDir, CSVOutput: "plan.sh"}))
+}
+
+func TestValidateOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	tests := []struct {
+		name    string
+		cfg     RunConfig
+		wantErr string
+	}{
+		{name: "existing directory", cfg: RunConfig{OutputDir: dir, CSVOutput: "report.csv", HTMLOutput: "/tmp/report.html"}},
+		{name: "missing directory", cfg: RunConfig{OutputDir: filepath.Join(dir, "missing")}, wantErr: "output directory does not exist"},
+		{name: "not a directory", cfg: RunConfig{OutputDir: file}, wantErr: "output-dir is not a directory"},
+		{name: "report in a subdirectory", cfg: RunConfig{OutputDir: dir, HTMLOutput: "reports/report.html"}, wantErr: "--html-output must be a file name or an absolute path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOutputDir(tt.cfg)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestConfigureLogFile(t *testing.T) {
+	var buf bytes.Buffer
+	ConfigureLogFile(&buf)
+	defer ConfigureLogFile(nil)
+
+	AppLogger.Printf("📁 Processing services: RDS\n")
+	assert.Contains(t, buf.String(), "📁 Processing services: RDS")
+}
@@ -0,0 +1,143 @@
+package cudly
+
+import (
+	"log"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// ec2CoveringSPTypes are the Savings Plan types that apply to EC2 instance usage
+var ec2CoveringSPTypes = []string{"Compute", "EC2Instance"}
+
+// savingsPlanType returns the plan type of a Savings Plan recommendation
+func savingsPlanType(rec common.Recommendation) (string, bool) {
+	switch details := rec.Details.(type) {
+	case *common.SavingsPlanDetails:
+		if details != nil {
+			return details.PlanType, true
+		}
+	case common.SavingsPlanDetails:
+		return details.PlanType, true
+	}
+	return "", false
+}
+
+// coversEC2Usage reports whether a Savings Plan recommendation would also cover EC2 instance usage
+func coversEC2Usage(rec common.Recommendation) bool {
+	if rec.Service != common.ServiceSavingsPlans {
+		return false
+	}
+	planType, ok := savingsPlanType(rec)
+	if !ok {
+		return false
+	}
+	for _, t := range ec2CoveringSPTypes {
+		if planType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// commitmentOverlap holds the EC2 RIs and Savings Plans in a run that would cover the same EC2 usage
+type commitmentOverlap struct {
+	EC2RIs       []common.Recommendation
+	SavingsPlans []common.Recommendation
+}
+
+// riSavings returns the combined estimated monthly savings of the overlapping EC2 RIs
+func (o commitmentOverlap) riSavings() float64 {
+	return sumEstimatedSavings(o.EC2RIs)
+}
+
+// spSavings returns the combined estimated monthly savings of the overlapping Savings Plans
+func (o commitmentOverlap) spSavings() float64 {
+	return sumEstimatedSavings(o.SavingsPlans)
+}
+
+func sumEstimatedSavings(recs []common.Recommendation) float64 {
+	total := 0.0
+	for _, rec := range recs {
+		total += rec.EstimatedSavings
+	}
+	return total
+}
+
+// detectCommitmentOverlap finds EC2 RIs and Compute/EC2 Instance Savings Plans selected in the same run
+// The second return value is false when only one kind of commitment was selected
+func detectCommitmentOverlap(recs []common.Recommendation) (commitmentOverlap, bool) {
+	var overlap commitmentOverlap
+	for _, rec := range recs {
+		if rec.Service == common.ServiceEC2 {
+			overlap.EC2RIs = append(overlap.EC2RIs, rec)
+		} else if coversEC2Usage(rec) {
+			overlap.SavingsPlans = append(overlap.SavingsPlans, rec)
+		}
+	}
+	return overlap, len(overlap.EC2RIs) > 0 && len(overlap.SavingsPlans) > 0
+}
+
+// doubleCommitGuard adjusts the configuration for a service so it cannot buy commitments overlapping earlier ones
+// Savings Plans exclude the EC2-covering plan types once EC2 RIs were selected, and EC2 is skipped entirely
+// once such Savings Plans were selected. It returns false when the service should not be processed.
+func doubleCommitGuard(service common.ServiceType, selected []common.Recommendation, cfg RunConfig) (RunConfig, bool) {
+	if !cfg.NoDoubleCommit {
+		return cfg, true
+	}
+
+	switch service {
+	case common.ServiceSavingsPlans:
+		for _, rec := range selected {
+			if rec.Service == common.ServiceEC2 {
+				AppLogger.Printf("🚫 --no-double-commit: excluding Compute and EC2 Instance Savings Plans, EC2 RIs were already selected in this run\n")
+				cfg.ExcludeSPTypes = append(append([]string{}, cfg.ExcludeSPTypes...), ec2CoveringSPTypes...)
+				return cfg, true
+			}
+		}
+	case common.ServiceEC2:
+		for _, rec := range selected {
+			if coversEC2Usage(rec) {
+				AppLogger.Printf("🚫 --no-double-commit: skipping EC2 RIs, Compute/EC2 Instance Savings Plans were already selected in this run\n")
+				return cfg, false
+			}
+		}
+	}
+	return cfg, true
+}
+
+// removeOverlappingSavingsPlans drops Compute/EC2 Instance Savings Plans when EC2 RIs cover the same usage
+func removeOverlappingSavingsPlans(recs []common.Recommendation) ([]common.Recommendation, int) {
+	if _, ok := detectCommitmentOverlap(recs); !ok {
+		return recs, 0
+	}
+	kept := make([]common.Recommendation, 0, len(recs))
+	for _, rec := range recs {
+		if !coversEC2Usage(rec) {
+			kept = append(kept, rec)
+		}
+	}
+	return kept, len(recs) - len(kept)
+}
+
+// warnCommitmentOverlap logs a warning when the selected recommendations contain overlapping commitments
+func warnCommitmentOverlap(recs []common.Recommendation) {
+	if overlap, ok := detectCommitmentOverlap(recs); ok {
+		log.Printf("⚠️  Warning: %d EC2 RI(s) and %d Compute/EC2 Instance Savings Plan(s) would cover the same EC2 usage (use --no-double-commit to prevent this)",
+			len(overlap.EC2RIs), len(overlap.SavingsPlans))
+	}
+}
+
+// printCommitmentOverlap prints a summary warning when EC2 RIs and Savings Plans would cover the same usage
+func printCommitmentOverlap(recs []common.Recommendation) {
+	overlap, ok := detectCommitmentOverlap(recs)
+	if !ok {
+		return
+	}
+	outPrintln("\n⚠️  OVERLAPPING COMMITMENTS:")
+	outPrintln("--------------------------------------------------")
+	outPrintf("EC2 RIs           | Recs: %3d | $%8.2f/mo\n", len(overlap.EC2RIs), overlap.riSavings())
+	outPrintf("Compute/EC2 SPs   | Recs: %3d | $%8.2f/mo\n", len(overlap.SavingsPlans), overlap.spSavings())
+	outPrintln("Both cover the same EC2 usage, so their savings cannot simply be added up")
+	outPrintln("and buying both risks paying for unused commitment.")
+	outPrintln("💡 Run with --no-double-commit to purchase only one of them")
+}
@@ -0,0 +1,87 @@
+package cudly
+
+import (
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func overlapTestRecs() (common.Recommendation, common.Recommendation, common.Recommendation) {
+	ec2RI := common.Recommendation{Service: common.ServiceEC2, ResourceType: "m5.large", Count: 2, EstimatedSavings: 100}
+	computeSP := common.Recommendation{Service: common.ServiceSavingsPlans, Count: 1, EstimatedSavings: 80,
+		Details: &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1.5}}
+	databaseSP := common.Recommendation{Service: common.ServiceSavingsPlans, Count: 1, EstimatedSavings: 40,
+		Details: &common.SavingsPlanDetails{PlanType: "Database", HourlyCommitment: 0.5}}
+	return ec2RI, computeSP, databaseSP
+}
+
+func TestDetectCommitmentOverlap(t *testing.T) {
+	ec2RI, computeSP, databaseSP := overlapTestRecs()
+	ec2InstanceSP := common.Recommendation{Service: common.ServiceSavingsPlans, EstimatedSavings: 20,
+		Details: common.SavingsPlanDetails{PlanType: "EC2Instance"}}
+
+	tests := []struct {
+		name        string
+		recs        []common.Recommendation
+		overlapping bool
+		spCount     int
+	}{
+		{name: "EC2 RI and Compute SP", recs: []common.Recommendation{ec2RI, computeSP}, overlapping: true, spCount: 1},
+		{name: "EC2 RI and both EC2-covering SPs", recs: []common.Recommendation{ec2RI, computeSP, ec2InstanceSP, databaseSP}, overlapping: true, spCount: 2},
+		{name: "EC2 RI and Database SP", recs: []common.Recommendation{ec2RI, databaseSP}},
+		{name: "Compute SP only", recs: []common.Recommendation{computeSP}},
+		{name: "RDS RI and Compute SP", recs: []common.Recommendation{{Service: common.ServiceRDS}, computeSP}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlap, ok := detectCommitmentOverlap(tt.recs)
+			assert.Equal(t, tt.overlapping, ok)
+			if tt.overlapping {
+				assert.Len(t, overlap.SavingsPlans, tt.spCount)
+				assert.Equal(t, 100.0, overlap.riSavings())
+			}
+		})
+	}
+}
+
+func TestDoubleCommitGuard(t *testing.T) {
+	ec2RI, computeSP, databaseSP := overlapTestRecs()
+
+	t.Run("disabled leaves config unchanged", func(t *testing.T) {
+		cfg, ok := doubleCommitGuard(common.ServiceEC2, []common.Recommendation{computeSP}, RunConfig{})
+		assert.True(t, ok)
+		assert.Empty(t, cfg.ExcludeSPTypes)
+	})
+
+	t.Run("Savings Plans after EC2 RIs exclude EC2-covering plan types", func(t *testing.T) {
+		base := RunConfig{NoDoubleCommit: true, ExcludeSPTypes: []string{"SageMaker"}}
+		cfg, ok := doubleCommitGuard(common.ServiceSavingsPlans, []common.Recommendation{ec2RI}, base)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"SageMaker", "Compute", "EC2Instance"}, cfg.ExcludeSPTypes)
+		assert.Equal(t, []string{"SageMaker"}, base.ExcludeSPTypes)
+	})
+
+	t.Run("EC2 after Compute SP is skipped", func(t *testing.T) {
+		_, ok := doubleCommitGuard(common.ServiceEC2, []common.Recommendation{computeSP}, RunConfig{NoDoubleCommit: true})
+		assert.False(t, ok)
+	})
+
+	t.Run("EC2 after Database SP is processed", func(t *testing.T) {
+		_, ok := doubleCommitGuard(common.ServiceEC2, []common.Recommendation{databaseSP}, RunConfig{NoDoubleCommit: true})
+		assert.True(t, ok)
+	})
+}
+
+func TestRemoveOverlappingSavingsPlans(t *testing.T) {
+	ec2RI, computeSP, databaseSP := overlapTestRecs()
+
+	kept, removed := removeOverlappingSavingsPlans([]common.Recommendation{ec2RI, computeSP, databaseSP})
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, []common.Recommendation{ec2RI, databaseSP}, kept)
+
+	kept, removed = removeOverlappingSavingsPlans([]common.Recommendation{computeSP, databaseSP})
+	assert.Equal(t, 0, removed)
+	assert.Len(t, kept, 2)
+}
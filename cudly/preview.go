@@ -0,0 +1,39 @@
+package cudly
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// writePurchasePreview writes an aligned table of the recommendations about to be purchased, followed by their totals
+func writePurchasePreview(w io.Writer, recs []common.Recommendation) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tREGION\tTYPE\tENGINE\tCOUNT\tTERM\tPAYMENT\tEST. SAVINGS")
+	totalInstances := 0
+	totalSavings := 0.0
+	for _, rec := range recs {
+		engine := getEngineFromRecommendation(rec)
+		if engine == "" {
+			engine = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t$%.2f/mo\n",
+			getServiceDisplayName(rec.Service), rec.Region, rec.ResourceType, engine, rec.Count, rec.Term, rec.PaymentOption, rec.EstimatedSavings)
+		totalInstances += rec.Count
+		totalSavings += rec.EstimatedSavings
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t\t\t%d\t\t\t$%.2f/mo\n", totalInstances, totalSavings)
+	return tw.Flush()
+}
+
+// printPurchasePreview prints the line items of a purchase before it is confirmed (--preview)
+func printPurchasePreview(recs []common.Recommendation) {
+	var buf bytes.Buffer
+	if err := writePurchasePreview(&buf, recs); err != nil {
+		return
+	}
+	outPrintf("\n📋 Purchase preview:\n%s", buf.String())
+}
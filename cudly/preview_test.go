@@ -0,0 +1,30 @@
+package cudly
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func TestWritePurchasePreview(t *testing.T) {
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 2, Term: "3yr", PaymentOption: "all-upfront", EstimatedSavings: 120.5, Details: &common.DatabaseDetails{Engine: "mysql"}},
+		{Service: common.ServiceEC2, Region: "eu-west-1", ResourceType: "m5.xlarge", Count: 10, Term: "1yr", PaymentOption: "no-upfront", EstimatedSavings: 80, Details: &common.ComputeDetails{}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writePurchasePreview(&buf, recs))
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"SERVICE", "REGION", "TYPE", "ENGINE", "COUNT", "TERM", "PAYMENT", "EST.", "SAVINGS"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"RDS", "us-east-1", "db.r5.large", "mysql", "2", "3yr", "all-upfront", "$120.50/mo"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"EC2", "eu-west-1", "m5.xlarge", "-", "10", "1yr", "no-upfront", "$80.00/mo"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"TOTAL", "12", "$200.50/mo"}, strings.Fields(lines[3]))
+	assert.Equal(t, strings.Index(lines[0], "COUNT"), strings.Index(lines[1], "2  "), "columns are aligned")
+}
@@ -0,0 +1,64 @@
+package cudly
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// ScanProgress reports how far the region scan of a service has progressed
+type ScanProgress interface {
+	// Update shows that region current (1-based) of total is being processed for the service
+	Update(service common.ServiceType, current, total int)
+	// Done clears the progress display once the service is finished
+	Done()
+}
+
+// newScanProgress returns a progress indicator updating in place on w, or one doing nothing unless enabled
+// and w is a terminal, so that redirected output and log files never contain progress lines.
+func newScanProgress(enabled bool, w io.Writer) ScanProgress {
+	if !enabled || !isTerminal(w) {
+		return noopProgress{}
+	}
+	return &terminalProgress{w: w}
+}
+
+// isTerminal reports whether w is a character device such as an interactive terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// noopProgress discards progress updates
+type noopProgress struct{}
+
+func (noopProgress) Update(common.ServiceType, int, int) {}
+
+func (noopProgress) Done() {}
+
+// terminalProgress redraws a single status line in place using a carriage return and an erase-line sequence
+type terminalProgress struct {
+	w      io.Writer
+	active bool
+}
+
+// eraseLine returns the cursor to the start of the line and clears it
+const eraseLine = "\r\033[K"
+
+func (p *terminalProgress) Update(service common.ServiceType, current, total int) {
+	fmt.Fprint(p.w, eraseLine+formatOutput(fmt.Sprintf("⏳ %s: region %d/%d", getServiceDisplayName(service), current, total)))
+	p.active = true
+}
+
+func (p *terminalProgress) Done() {
+	if p.active {
+		fmt.Fprint(p.w, eraseLine)
+		p.active = false
+	}
+}
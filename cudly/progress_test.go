@@ -0,0 +1,47 @@
+package cudly
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewScanProgressIsNoopWithoutTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	assert.IsType(t, noopProgress{}, newScanProgress(false, os.Stdout))
+	assert.IsType(t, noopProgress{}, newScanProgress(true, &bytes.Buffer{}))
+	assert.IsType(t, noopProgress{}, newScanProgress(true, w))
+}
+
+func TestTerminalProgress(t *testing.T) {
+	var buf bytes.Buffer
+	progress := &terminalProgress{w: &buf}
+
+	// Done before any update writes nothing
+	progress.Done()
+	assert.Empty(t, buf.String())
+
+	progress.Update(common.ServiceRDS, 1, 3)
+	progress.Update(common.ServiceRDS, 2, 3)
+	progress.Done()
+	progress.Done()
+	assert.Equal(t, "\r\033[K⏳ RDS: region 1/3\r\033[K⏳ RDS: region 2/3\r\033[K", buf.String())
+}
+
+func TestTerminalProgressPlainOutput(t *testing.T) {
+	plainOutput = true
+	defer func() { plainOutput = false }()
+
+	var buf bytes.Buffer
+	progress := &terminalProgress{w: &buf}
+	progress.Update(common.ServiceElastiCache, 4, 17)
+	assert.Equal(t, "\r\033[KElastiCache: region 4/17", buf.String())
+}
@@ -0,0 +1,213 @@
+package cudly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+)
+
+// Cloud provider names accepted by --providers
+const (
+	ProviderAWS   = "aws"
+	ProviderAzure = "azure"
+	ProviderGCP   = "gcp"
+)
+
+// cloudProviderSpec describes how the recommendations of a non-AWS provider are fetched
+type cloudProviderSpec struct {
+	services []common.ServiceType
+	// regional providers are queried once per region, the others return all regions at once
+	regional bool
+}
+
+// cloudProviders lists the non-AWS providers and the services processed for them
+// Non-AWS providers only support dry runs for now.
+var cloudProviders = map[string]cloudProviderSpec{
+	ProviderAzure: {services: []common.ServiceType{common.ServiceCompute}},
+	ProviderGCP:   {services: []common.ServiceType{common.ServiceCompute}, regional: true},
+}
+
+// validateProviders checks the --providers values against the rest of the configuration
+func validateProviders(cfg RunConfig) error {
+	for _, name := range cfg.Providers {
+		if name == ProviderAWS {
+			continue
+		}
+		if _, ok := cloudProviders[name]; !ok {
+			return fmt.Errorf("unsupported provider %q (supported: aws, azure, gcp)", name)
+		}
+		if cfg.ActualPurchase {
+			return fmt.Errorf("--providers %s only supports dry runs for now and cannot be combined with --purchase", name)
+		}
+		if cfg.CSVInput != "" || cfg.JSONInput != "" || len(cfg.SPCommitments) > 0 {
+			return fmt.Errorf("--providers %s cannot be combined with --input-csv, --input-json or --sp-commitment", name)
+		}
+	}
+	return nil
+}
+
+// runToolCloudProvider fetches and processes the commitment recommendations of a non-AWS provider as a dry run
+func runToolCloudProvider(ctx context.Context, cfg RunConfig, name string) (*RunReport, error) {
+	cloud, err := provider.CreateProvider(name, &provider.ProviderConfig{Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %w", name, err)
+	}
+	if !cloud.IsConfigured() {
+		return nil, fmt.Errorf("%s credentials are not configured", cloud.DisplayName())
+	}
+
+	printRunMode(true)
+	AppLogger.Printf("☁️  Provider: %s\n", cloud.DisplayName())
+
+	spec := cloudProviders[name]
+	regions := []string{""} // An empty region returns the recommendations of all regions
+	if spec.regional {
+		regions, err = cloudProviderRegions(ctx, cloud, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report := newRunReport(true)
+	for _, service := range spec.services {
+		AppLogger.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+		AppLogger.Printf("🎯 Processing %s %s\n", cloud.DisplayName(), getServiceDisplayName(service))
+		AppLogger.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+
+		clients := make([]provider.ServiceClient, 0, len(regions))
+		for _, region := range regions {
+			serviceClient, err := cloud.GetServiceClient(ctx, service, region)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create %s client: %w", getServiceDisplayName(service), err)
+			}
+			clients = append(clients, serviceClient)
+		}
+
+		serviceRecs, serviceResults, err := processCloudService(ctx, clients, service, cfg)
+		if err != nil {
+			return nil, err
+		}
+		report.Recommendations = append(report.Recommendations, serviceRecs...)
+		report.Results = append(report.Results, serviceResults...)
+
+		stats := calculateServiceStats(service, serviceRecs, serviceResults)
+		report.ServiceStats[service] = stats
+		printServiceSummary(service, stats)
+	}
+	report.collectResultErrors()
+	return report, nil
+}
+
+// cloudProviderRegions returns the --regions to query, or all regions of the provider if none are given
+func cloudProviderRegions(ctx context.Context, cloud provider.Provider, cfg RunConfig) ([]string, error) {
+	if len(cfg.Regions) > 0 {
+		return cfg.Regions, nil
+	}
+	available, err := cloud.GetRegions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s regions: %w", cloud.DisplayName(), err)
+	}
+	regions := make([]string, 0, len(available))
+	for _, region := range available {
+		regions = append(regions, region.ID)
+	}
+	AppLogger.Printf("🌍 Querying %d %s regions\n", len(regions), cloud.DisplayName())
+	return regions, nil
+}
+
+// processCloudService filters the recommendations of a non-AWS service and creates dry-run results per region
+// Regions whose recommendations cannot be fetched are skipped, unless all of them fail.
+func processCloudService(ctx context.Context, clients []provider.ServiceClient, service common.ServiceType, cfg RunConfig) ([]common.Recommendation, []common.PurchaseResult, error) {
+	var recs []common.Recommendation
+	var lastErr error
+	failed := 0
+	for _, serviceClient := range clients {
+		clientRecs, err := serviceClient.GetRecommendations(ctx, recommendationParams(service, serviceClient.GetRegion(), cfg))
+		if err != nil {
+			log.Printf("⚠️  Warning: Failed to get %s recommendations: %v", getServiceDisplayName(service), err)
+			lastErr = err
+			failed++
+			continue
+		}
+		recs = append(recs, clientRecs...)
+	}
+	if len(clients) > 0 && failed == len(clients) {
+		return nil, nil, fmt.Errorf("failed to get %s recommendations: %w", getServiceDisplayName(service), lastErr)
+	}
+	AppLogger.Printf("  ✅ Found %d recommendations\n", len(recs))
+
+	// Engine versions only apply to AWS database services
+	recs = adjustRecommendations(recs, cfg.Coverage, cfg, nil, nil)
+
+	byRegion := make(map[string][]common.Recommendation)
+	for _, rec := range recs {
+		byRegion[rec.Region] = append(byRegion[rec.Region], rec)
+	}
+	regions := make([]string, 0, len(byRegion))
+	for region := range byRegion {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	// Dry runs never call the service client
+	results := make([]common.PurchaseResult, 0, len(recs))
+	for _, region := range regions {
+		AppLogger.Printf("\n  📍 Region: %s (%d recommendations)\n", region, len(byRegion[region]))
+		results = append(results, processPurchaseLoop(ctx, byRegion[region], region, true, nil, cfg)...)
+	}
+	return recs, results, nil
+}
+
+// mergeRunReports combines the reports of several providers into one
+func mergeRunReports(report, other *RunReport) *RunReport {
+	if report == nil {
+		return other
+	}
+	if other == nil {
+		return report
+	}
+	report.DryRun = report.DryRun && other.DryRun
+	report.Recommendations = append(report.Recommendations, other.Recommendations...)
+	report.Results = append(report.Results, other.Results...)
+	for service, stats := range other.ServiceStats {
+		if existing, ok := report.ServiceStats[service]; ok {
+			stats = addServiceStats(existing, stats)
+		}
+		report.ServiceStats[service] = stats
+	}
+	report.MarketplaceSavings += other.MarketplaceSavings
+	report.Errors = append(report.Errors, other.Errors...)
+	return report
+}
+
+// addServiceStats sums the statistics of a service reported by several providers
+func addServiceStats(a, b ServiceProcessingStats) ServiceProcessingStats {
+	a.RegionsProcessed += b.RegionsProcessed
+	a.RecommendationsFound += b.RecommendationsFound
+	a.RecommendationsSelected += b.RecommendationsSelected
+	a.InstancesProcessed += b.InstancesProcessed
+	a.SuccessfulPurchases += b.SuccessfulPurchases
+	a.FailedPurchases += b.FailedPurchases
+	a.PartialPurchases += b.PartialPurchases
+	a.InstancesShortfall += b.InstancesShortfall
+	a.TotalEstimatedSavings += b.TotalEstimatedSavings
+	a.TotalUpfrontCost += b.TotalUpfrontCost
+	a.TotalAmortizedMonthlyCost += b.TotalAmortizedMonthlyCost
+	a.ProjectedTermSavings += b.ProjectedTermSavings
+	a.TotalHourlyCommitment += b.TotalHourlyCommitment
+	return a
+}
+
+// normalizeProviders lowercases the --providers values
+func normalizeProviders(providers []string) []string {
+	normalized := make([]string, 0, len(providers))
+	for _, name := range providers {
+		normalized = append(normalized, strings.ToLower(strings.TrimSpace(name)))
+	}
+	return normalized
+}
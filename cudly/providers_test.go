@@ -0,0 +1,130 @@
+package cudly
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateProviders(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     RunConfig
+		wantErr string
+	}{
+		{name: "default", cfg: RunConfig{}},
+		{name: "aws and azure", cfg: RunConfig{Providers: []string{"aws", "azure"}}},
+		{name: "gcp", cfg: RunConfig{Providers: []string{"gcp"}}},
+		{name: "unknown provider", cfg: RunConfig{Providers: []string{"oracle"}}, wantErr: "unsupported provider"},
+		{name: "azure purchase", cfg: RunConfig{Providers: []string{"azure"}, ActualPurchase: true}, wantErr: "only supports dry runs"},
+		{name: "aws purchase", cfg: RunConfig{Providers: []string{"aws"}, ActualPurchase: true}},
+		{name: "azure with csv input", cfg: RunConfig{Providers: []string{"azure"}, CSVInput: "recs.csv"}, wantErr: "cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProviders(tt.cfg)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizeProviders(t *testing.T) {
+	assert.Equal(t, []string{"aws", "azure"}, normalizeProviders([]string{" AWS", "Azure "}))
+}
+
+func TestProcessCloudService(t *testing.T) {
+	client := &MockServiceClient{}
+	client.On("GetRegion").Return("")
+	client.On("GetRecommendations", mock.Anything, mock.MatchedBy(func(params common.RecommendationParams) bool {
+		return params.Service == common.ServiceCompute && params.Region == "" && params.Term == "3yr"
+	})).Return([]common.Recommendation{
+		{Provider: common.ProviderAzure, Service: common.ServiceCompute, Region: "westeurope", ResourceType: "Standard_D2s_v3", Count: 4, EstimatedSavings: 40},
+		{Provider: common.ProviderAzure, Service: common.ServiceCompute, Region: "eastus", ResourceType: "Standard_E4s_v5", Count: 2, EstimatedSavings: 30},
+		{Provider: common.ProviderAzure, Service: common.ServiceCompute, Region: "northeurope", ResourceType: "Standard_B2s", Count: 1, EstimatedSavings: 5},
+	}, nil)
+
+	cfg := RunConfig{Coverage: 50, TermYears: 3, ExcludeRegions: []string{"northeurope"}}
+	recs, results, err := processCloudService(context.Background(), []provider.ServiceClient{client}, common.ServiceCompute, cfg)
+	require.NoError(t, err)
+
+	require.Len(t, recs, 2)
+	require.Len(t, results, 2)
+	assert.Equal(t, "eastus", results[0].Recommendation.Region, "results are grouped by sorted region")
+	assert.Equal(t, 1, results[0].Recommendation.Count, "coverage is applied")
+	assert.Equal(t, 2, results[1].Recommendation.Count)
+	for _, result := range results {
+		assert.True(t, result.DryRun)
+		assert.True(t, result.Success)
+	}
+	client.AssertNotCalled(t, "PurchaseCommitment", mock.Anything, mock.Anything)
+}
+
+func TestProcessCloudServiceRegional(t *testing.T) {
+	central := &MockServiceClient{}
+	central.On("GetRegion").Return("us-central1")
+	central.On("GetRecommendations", mock.Anything, mock.MatchedBy(func(params common.RecommendationParams) bool {
+		return params.Region == "us-central1"
+	})).Return([]common.Recommendation{
+		{Provider: common.ProviderGCP, Service: common.ServiceCompute, Region: "us-central1", ResourceType: "e2", Count: 8},
+	}, nil)
+	west := &MockServiceClient{}
+	west.On("GetRegion").Return("europe-west1")
+	west.On("GetRecommendations", mock.Anything, mock.Anything).Return(nil, errors.New("recommender API disabled"))
+
+	recs, results, err := processCloudService(context.Background(), []provider.ServiceClient{central, west}, common.ServiceCompute, RunConfig{Coverage: 100})
+	require.NoError(t, err, "a failing region is skipped")
+	require.Len(t, recs, 1)
+	require.Len(t, results, 1)
+	assert.Equal(t, 8, results[0].Recommendation.Count)
+}
+
+func TestProcessCloudServiceError(t *testing.T) {
+	client := &MockServiceClient{}
+	client.On("GetRegion").Return("")
+	client.On("GetRecommendations", mock.Anything, mock.Anything).Return(nil, errors.New("forbidden"))
+
+	_, _, err := processCloudService(context.Background(), []provider.ServiceClient{client}, common.ServiceCompute, RunConfig{})
+	assert.ErrorContains(t, err, "failed to get Compute recommendations")
+}
+
+func TestMergeRunReports(t *testing.T) {
+	aws := newRunReport(false)
+	aws.Recommendations = []common.Recommendation{{Service: common.ServiceEC2}}
+	aws.ServiceStats[common.ServiceEC2] = ServiceProcessingStats{Service: common.ServiceEC2}
+
+	azure := newRunReport(true)
+	azure.Recommendations = []common.Recommendation{{Service: common.ServiceCompute}}
+	azure.ServiceStats[common.ServiceCompute] = ServiceProcessingStats{Service: common.ServiceCompute}
+	azure.Errors = []error{errors.New("failed")}
+
+	assert.Same(t, azure, mergeRunReports(nil, azure))
+
+	merged := mergeRunReports(aws, azure)
+	assert.False(t, merged.DryRun)
+	assert.Len(t, merged.Recommendations, 2)
+	assert.Len(t, merged.ServiceStats, 2)
+	assert.Len(t, merged.Errors, 1)
+}
+
+func TestMergeRunReportsSumsSharedServices(t *testing.T) {
+	azure := newRunReport(true)
+	azure.ServiceStats[common.ServiceCompute] = ServiceProcessingStats{Service: common.ServiceCompute, RegionsProcessed: 2, InstancesProcessed: 5, TotalEstimatedSavings: 100}
+	gcp := newRunReport(true)
+	gcp.ServiceStats[common.ServiceCompute] = ServiceProcessingStats{Service: common.ServiceCompute, RegionsProcessed: 1, InstancesProcessed: 8, TotalEstimatedSavings: 50}
+
+	stats := mergeRunReports(azure, gcp).ServiceStats[common.ServiceCompute]
+	assert.Equal(t, 3, stats.RegionsProcessed)
+	assert.Equal(t, 13, stats.InstancesProcessed)
+	assert.InDelta(t, 150, stats.TotalEstimatedSavings, 0.001)
+}
@@ -0,0 +1,85 @@
+package cudly
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// purchaseReceipt is the audit record of a purchase attempt written to --receipts-dir
+// Request and Response hold the raw purchase API input and output, such as the reservation ID, dates and fixed price.
+type purchaseReceipt struct {
+	CommitmentID     string                `json:"commitment_id"`
+	Success          bool                  `json:"success"`
+	Error            string                `json:"error,omitempty"`
+	Timestamp        time.Time             `json:"timestamp"`
+	AccountID        string                `json:"account_id,omitempty"`
+	IdempotencyToken string                `json:"idempotency_token,omitempty"`
+	Recommendation   common.Recommendation `json:"recommendation"`
+	DetailsType      string                `json:"details_type,omitempty"`
+	Request          any                   `json:"request,omitempty"`
+	Response         any                   `json:"response,omitempty"`
+}
+
+// unsafeFileNameChars matches the characters replaced in receipt file names
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// receiptFileName names the receipt of a purchase after its time and commitment ID, such as 20240101-120000-ri-123.json
+func receiptFileName(result common.PurchaseResult) string {
+	return result.Timestamp.Format("20060102-150405") + "-" + unsafeFileNameChars.ReplaceAllString(result.CommitmentID, "-") + ".json"
+}
+
+// newPurchaseReceipt creates the receipt of a purchase result
+func newPurchaseReceipt(result common.PurchaseResult) purchaseReceipt {
+	receipt := purchaseReceipt{
+		CommitmentID:     result.CommitmentID,
+		Success:          result.Success,
+		Timestamp:        result.Timestamp,
+		AccountID:        result.AccountID,
+		IdempotencyToken: result.IdempotencyToken,
+		Recommendation:   result.Recommendation,
+		DetailsType:      jsonDetailsType(result.Recommendation.Details),
+		Request:          result.Request,
+		Response:         result.Response,
+	}
+	if result.Error != nil {
+		receipt.Error = result.Error.Error()
+	}
+	return receipt
+}
+
+// writePurchaseReceipt writes the receipt of a purchase attempt to dir, creating it if needed, and returns its path
+func writePurchaseReceipt(dir string, result common.PurchaseResult) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create receipts directory: %w", err)
+	}
+	data, err := json.MarshalIndent(newPurchaseReceipt(result), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode receipt: %w", err)
+	}
+	path := filepath.Join(dir, receiptFileName(result))
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write receipt: %w", err)
+	}
+	return path, nil
+}
+
+// recordReceipt writes the receipt of a purchase attempt when --receipts-dir is set
+// A receipt that can't be written is logged as a warning, as the purchase itself was already made.
+func recordReceipt(cfg RunConfig, result common.PurchaseResult) {
+	if cfg.ReceiptsDir == "" {
+		return
+	}
+	path, err := writePurchaseReceipt(outputPath(cfg, cfg.ReceiptsDir), result)
+	if err != nil {
+		log.Printf("    ⚠️  Warning: Failed to write purchase receipt for %s: %v", result.CommitmentID, err)
+		return
+	}
+	logDebugf("Purchase receipt written to %s", path)
+}
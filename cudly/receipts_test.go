@@ -0,0 +1,78 @@
+package cudly
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsrds "github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func TestWritePurchaseReceipt(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "receipts")
+	result := common.PurchaseResult{
+		Recommendation: common.Recommendation{Service: common.ServiceRDS, ResourceType: "db.r5.large", Count: 2, Details: &common.DatabaseDetails{Engine: "mysql"}},
+		Success:        true,
+		CommitmentID:   "ri-789",
+		Timestamp:      time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Request:        &awsrds.PurchaseReservedDBInstancesOfferingInput{ReservedDBInstancesOfferingId: aws.String("offering-456")},
+		Response: &awsrds.PurchaseReservedDBInstancesOfferingOutput{ReservedDBInstance: &rdstypes.ReservedDBInstance{
+			ReservedDBInstanceId: aws.String("ri-789"),
+			FixedPrice:           aws.Float64(10000),
+		}},
+	}
+
+	path, err := writePurchaseReceipt(dir, result)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "20240101-120000-ri-789.json"), path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var receipt map[string]any
+	require.NoError(t, json.Unmarshal(data, &receipt))
+	assert.Equal(t, "ri-789", receipt["commitment_id"])
+	assert.Equal(t, "DatabaseDetails", receipt["details_type"])
+	assert.Equal(t, "offering-456", receipt["request"].(map[string]any)["ReservedDBInstancesOfferingId"])
+	reserved := receipt["response"].(map[string]any)["ReservedDBInstance"].(map[string]any)
+	assert.Equal(t, 10000.0, reserved["FixedPrice"])
+}
+
+func TestWritePurchaseReceiptFailure(t *testing.T) {
+	dir := t.TempDir()
+	result := common.PurchaseResult{
+		Recommendation: common.Recommendation{Service: common.ServiceEC2, ResourceType: "m5.large", Count: 1},
+		CommitmentID:   "ri-ec2/us-east-1",
+		Error:          errors.New("failed to purchase EC2 RI: InsufficientCapacity"),
+		Timestamp:      time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	path, err := writePurchaseReceipt(dir, result)
+	require.NoError(t, err)
+	assert.Equal(t, "20240101-120000-ri-ec2-us-east-1.json", filepath.Base(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"error": "failed to purchase EC2 RI: InsufficientCapacity"`)
+	assert.Contains(t, string(data), `"resource_type": "m5.large"`)
+	assert.NotContains(t, string(data), `"response"`)
+}
+
+func TestRecordReceiptUnderOutputDir(t *testing.T) {
+	outputDir := t.TempDir()
+	cfg := RunConfig{ReceiptsDir: "receipts", OutputDir: outputDir}
+
+	recordReceipt(cfg, common.PurchaseResult{CommitmentID: "ri-1", Success: true, Timestamp: time.Now()})
+
+	entries, err := os.ReadDir(filepath.Join(outputDir, "receipts"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
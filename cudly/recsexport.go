@@ -0,0 +1,85 @@
+package cudly
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// jsonRecommendation is the JSON export form of a recommendation (--recommendations-only)
+// It has the layout of the JSON report entries, so that the export can be read back with --input-json.
+type jsonRecommendation struct {
+	Recommendation common.Recommendation `json:"recommendation"`
+	DetailsType    string                `json:"details_type,omitempty"`
+}
+
+// writeRecommendationsExport writes the recommendations of a --recommendations-only run in the given output format
+func writeRecommendationsExport(recs []common.Recommendation, path, format string) error {
+	if format == OutputFormatJSON {
+		return writeRecommendationsJSON(recs, path)
+	}
+	return writeRecommendationsCSV(recs, path)
+}
+
+// writeRecommendationsCSV writes the recommendations as CSV, with the columns read back by --input-csv
+func writeRecommendationsCSV(recs []common.Recommendation, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	header := []string{
+		"Service", "Region", "ResourceType", "Engine", "Count", "Account", "AccountName",
+		"Term", "PaymentOption", "Scope", "EstimatedSavings", "UpfrontCost", "AmortizedMonthlyCost",
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, rec := range recs {
+		row := []string{
+			string(rec.Service),
+			rec.Region,
+			rec.ResourceType,
+			getEngineFromRecommendation(rec),
+			fmt.Sprintf("%d", rec.Count),
+			rec.Account,
+			rec.AccountName,
+			rec.Term,
+			rec.PaymentOption,
+			ec2Scope(rec),
+			fmt.Sprintf("%.2f", rec.EstimatedSavings),
+			fmt.Sprintf("%.2f", rec.UpfrontCost),
+			fmt.Sprintf("%.2f", rec.AmortizedMonthlyCost),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	return nil
+}
+
+// writeRecommendationsJSON writes the recommendations as a JSON array
+func writeRecommendationsJSON(recs []common.Recommendation, path string) error {
+	export := make([]jsonRecommendation, 0, len(recs))
+	for _, rec := range recs {
+		export = append(export, jsonRecommendation{Recommendation: rec, DetailsType: jsonDetailsType(rec.Details)})
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON recommendations: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package cudly
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func exportTestRecommendations() []common.Recommendation {
+	return []common.Recommendation{
+		{
+			Service:          common.ServiceRDS,
+			Region:           "us-east-1",
+			ResourceType:     "db.r5.large",
+			Count:            3,
+			Account:          "123456789012",
+			Term:             "1yr",
+			PaymentOption:    "no-upfront",
+			EstimatedSavings: 42.5,
+			Details:          &common.DatabaseDetails{Engine: "mysql", AZConfig: "single-az"},
+		},
+		{
+			Service:          common.ServiceSavingsPlans,
+			Region:           "us-east-1",
+			ResourceType:     "Compute",
+			Count:            1,
+			Term:             "3yr",
+			PaymentOption:    "all-upfront",
+			EstimatedSavings: 100,
+			UpfrontCost:      8760,
+			Details:          &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 0.5},
+		},
+	}
+}
+
+func TestWriteRecommendationsCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recs.csv")
+	recs := exportTestRecommendations()
+
+	require.NoError(t, writeRecommendationsExport(recs, path, OutputFormatCSV))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Service,Region,ResourceType,Engine,Count")
+	assert.Contains(t, string(data), "rds,us-east-1,db.r5.large,mysql,3,123456789012")
+	assert.NotContains(t, string(data), "Success")
+
+	loaded, err := loadRecommendationsFromCSV(path)
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, "db.r5.large", loaded[0].ResourceType)
+	assert.Equal(t, 3, loaded[0].Count)
+	assert.Equal(t, "1yr", loaded[0].Term)
+	assert.Equal(t, 42.5, loaded[0].EstimatedSavings)
+}
+
+func TestWriteRecommendationsJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recs.json")
+	recs := exportTestRecommendations()
+
+	require.NoError(t, writeRecommendationsExport(recs, path, OutputFormatJSON))
+
+	loaded, err := loadRecommendationsFromJSON(path)
+	require.NoError(t, err)
+	assert.Equal(t, recs, loaded)
+}
+
+func TestProcessPurchaseLoopRecommendationsOnly(t *testing.T) {
+	mockClient := &MockServiceClient{}
+
+	results := processPurchaseLoop(context.Background(), exportTestRecommendations(), "us-east-1", true, mockClient, RunConfig{RecommendationsOnly: true})
+
+	assert.Empty(t, results)
+	mockClient.AssertNotCalled(t, "PurchaseCommitment")
+}
+
+func TestGenerateCSVFilenameRecommendationsOnly(t *testing.T) {
+	assert.Regexp(t, `^ri-helper-recommendations-\d{8}-\d{6}\.json$`, generateCSVFilename(true, RunConfig{RecommendationsOnly: true, OutputFormat: OutputFormatJSON}))
+}
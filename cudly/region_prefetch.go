@@ -0,0 +1,64 @@
+package cudly
+
+import (
+	"context"
+	"sync"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/pkg/provider"
+)
+
+// recommendationsResult is the outcome of fetching the recommendations of one region
+type recommendationsResult struct {
+	recs []common.Recommendation
+	err  error
+}
+
+// prefetchedRecommendationsClient serves recommendations that were fetched concurrently for all regions
+// Each prefetched result is served once; later calls (e.g. --retry-skipped) query the wrapped client again
+type prefetchedRecommendationsClient struct {
+	provider.RecommendationsClient
+	mu      sync.Mutex
+	results map[string]recommendationsResult
+}
+
+// prefetchRecommendations fetches the recommendations for all params with at most maxConcurrency requests in flight
+// Purchases stay serial: only the Cost Explorer round trips are parallelized
+func prefetchRecommendations(ctx context.Context, client provider.RecommendationsClient, params []common.RecommendationParams, maxConcurrency int) *prefetchedRecommendationsClient {
+	prefetched := &prefetchedRecommendationsClient{
+		RecommendationsClient: client,
+		results:               make(map[string]recommendationsResult),
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrency)
+	for _, p := range params {
+		wg.Add(1)
+		go func(p common.RecommendationParams) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			recs, err := client.GetRecommendations(ctx, p)
+			prefetched.mu.Lock()
+			prefetched.results[provider.CacheKey(p)] = recommendationsResult{recs: recs, err: err}
+			prefetched.mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+
+	return prefetched
+}
+
+// GetRecommendations returns the prefetched recommendations for params, or queries the wrapped client
+func (p *prefetchedRecommendationsClient) GetRecommendations(ctx context.Context, params common.RecommendationParams) ([]common.Recommendation, error) {
+	key := provider.CacheKey(params)
+	p.mu.Lock()
+	result, ok := p.results[key]
+	delete(p.results, key)
+	p.mu.Unlock()
+	if !ok {
+		return p.RecommendationsClient.GetRecommendations(ctx, params)
+	}
+	return result.recs, result.err
+}
@@ -0,0 +1,121 @@
+package cudly
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefetchRecommendationsServesEachResultOnce(t *testing.T) {
+	ctx := context.Background()
+	cfg := RunConfig{PaymentOption: "no-upfront", TermYears: 1}
+	east := recommendationParams(common.ServiceRDS, "us-east-1", cfg)
+	west := recommendationParams(common.ServiceRDS, "us-west-2", cfg)
+
+	mockClient := &MockRecommendationsClient{}
+	mockClient.On("GetRecommendations", ctx, east).Return([]common.Recommendation{{Region: "us-east-1"}}, nil).Once()
+	mockClient.On("GetRecommendations", ctx, west).Return(nil, errors.New("ThrottlingException")).Once()
+	mockClient.On("GetRecommendations", ctx, west).Return([]common.Recommendation{{Region: "us-west-2"}}, nil).Once()
+
+	prefetched := prefetchRecommendations(ctx, mockClient, []common.RecommendationParams{east, west}, 2)
+	mockClient.AssertNumberOfCalls(t, "GetRecommendations", 2)
+
+	recs, err := prefetched.GetRecommendations(ctx, east)
+	require.NoError(t, err)
+	assert.Len(t, recs, 1)
+
+	_, err = prefetched.GetRecommendations(ctx, west)
+	assert.ErrorContains(t, err, "ThrottlingException")
+
+	// A retry queries the wrapped client again
+	recs, err = prefetched.GetRecommendations(ctx, west)
+	require.NoError(t, err)
+	assert.Equal(t, "us-west-2", recs[0].Region)
+	mockClient.AssertExpectations(t)
+}
+
+func TestProcessServiceConcurrentFetchKeepsRegionOrder(t *testing.T) {
+	ctx := context.Background()
+	awsCfg := aws.Config{Region: "us-east-1"}
+	regions := []string{"us-east-1", "eu-west-1", "ap-south-1", "us-west-2"}
+
+	cfg := RunConfig{
+		Regions:        regions,
+		Coverage:       100.0,
+		PaymentOption:  "partial-upfront",
+		TermYears:      3,
+		MaxConcurrency: 3,
+		CacheOnly:      true,
+	}
+
+	mockClient := &MockRecommendationsClient{}
+	for _, region := range regions {
+		mockClient.On("GetRecommendations", mock.Anything, recommendationParams(common.ServiceRDS, region, cfg)).Return([]common.Recommendation{
+			{Service: common.ServiceRDS, ResourceType: "db.t3.micro", Count: 1, Region: region},
+		}, nil).Once()
+	}
+
+	recs, results, err := processService(ctx, awsCfg, mockClient, nil, nil, common.ServiceRDS, true, cfg)
+	require.NoError(t, err)
+
+	require.Len(t, recs, len(regions))
+	require.Len(t, results, len(regions))
+	for i, region := range regions {
+		assert.Equal(t, region, recs[i].Region)
+		assert.Equal(t, region, results[i].Recommendation.Region)
+	}
+	mockClient.AssertExpectations(t)
+}
+
+func TestProcessServicesConcurrentMatchesSequential(t *testing.T) {
+	ctx := context.Background()
+	awsCfg := aws.Config{Region: "us-east-1"}
+	services := []common.ServiceType{common.ServiceRDS, common.ServiceElastiCache, common.ServiceRedshift}
+	regions := []string{"us-east-1", "eu-west-1"}
+	resourceTypes := map[common.ServiceType]string{
+		common.ServiceRDS:         "db.t3.micro",
+		common.ServiceElastiCache: "cache.t3.micro",
+		common.ServiceRedshift:    "ra3.xlplus",
+	}
+
+	run := func(concurrency int) *RunReport {
+		cfg := RunConfig{
+			Regions:            regions,
+			Coverage:           100.0,
+			PaymentOption:      "partial-upfront",
+			TermYears:          3,
+			CacheOnly:          true,
+			ServiceConcurrency: concurrency,
+		}
+		mockClient := &MockRecommendationsClient{}
+		for _, service := range services {
+			for i, region := range regions {
+				mockClient.On("GetRecommendations", mock.Anything, recommendationParams(service, region, cfg)).Return([]common.Recommendation{
+					{Service: service, ResourceType: resourceTypes[service], Count: i + 1, Region: region, EstimatedSavings: float64(10 * (i + 1)), UpfrontCost: 100},
+				}, nil).Once()
+			}
+		}
+
+		report, err := processServices(ctx, awsCfg, mockClient, nil, services, nil, true, cfg)
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+		return report
+	}
+
+	sequential := run(1)
+	concurrent := run(3)
+
+	assert.Equal(t, sequential.ServiceStats, concurrent.ServiceStats)
+	assert.Equal(t, sequential.Recommendations, concurrent.Recommendations)
+	require.Len(t, concurrent.Results, len(sequential.Results))
+	for i := range sequential.Results {
+		assert.Equal(t, sequential.Results[i].Recommendation, concurrent.Results[i].Recommendation)
+	}
+	assert.Len(t, concurrent.ServiceStats, len(services))
+}
@@ -0,0 +1,175 @@
+package cudly
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// S3UploadAPI defines the interface for the S3 operations used to upload reports
+type S3UploadAPI interface {
+	PutObject(ctx context.Context, bucket, key, contentType string, body []byte) error
+}
+
+// parseS3URI splits an s3://bucket/prefix/ URI into its bucket and key prefix, the prefix ending with a slash unless empty
+func parseS3URI(uri string) (string, string, error) {
+	rest, ok := strings.CutPrefix(uri, "s3://")
+	if !ok {
+		return "", "", fmt.Errorf("expected s3://bucket/prefix/, got: %s", uri)
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("missing bucket name in %s", uri)
+	}
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return bucket, prefix, nil
+}
+
+// reportContentType returns the content type of a report file based on its extension
+func reportContentType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return "text/csv"
+	case ".json":
+		return "application/json"
+	case ".html":
+		return "text/html"
+	case ".md":
+		return "text/markdown"
+	case ".sh":
+		return "text/x-shellscript"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// uploadReports uploads report files under a timestamped key below the prefix of uri, such as
+// s3://bucket/prefix/20240101-120000/report.csv, and returns the S3 URIs of the uploaded files
+// A failed upload is logged as a warning and the remaining files are still uploaded.
+func uploadReports(ctx context.Context, api S3UploadAPI, uri string, paths []string, now time.Time) []string {
+	bucket, prefix, err := parseS3URI(uri)
+	if err != nil {
+		log.Printf("⚠️  Warning: Failed to upload reports to S3: %v", err)
+		return nil
+	}
+
+	var uploaded []string
+	for _, path := range paths {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("⚠️  Warning: Failed to read report %s for upload: %v", path, err)
+			continue
+		}
+		key := prefix + now.Format("20060102-150405") + "/" + filepath.Base(path)
+		if err := api.PutObject(ctx, bucket, key, reportContentType(path), body); err != nil {
+			log.Printf("⚠️  Warning: Failed to upload %s to s3://%s/%s: %v", path, bucket, key, err)
+			continue
+		}
+		s3URI := fmt.Sprintf("s3://%s/%s", bucket, key)
+		AppLogger.Printf("☁️  Uploaded %s to %s\n", path, s3URI)
+		uploaded = append(uploaded, s3URI)
+	}
+	return uploaded
+}
+
+// UploadReports uploads the reports written by RenderReport to --upload-s3
+// Upload failures are logged as warnings and never fail the run, like failures to write the local reports.
+func UploadReports(ctx context.Context, report *RunReport, cfg RunConfig) {
+	if cfg.UploadS3 == "" || len(report.WrittenReports) == 0 {
+		return
+	}
+
+	configOptions := []func(*config.LoadOptions) error{config.WithRegion("us-east-1")}
+	if cfg.Profile != "" {
+		configOptions = append(configOptions, config.WithSharedConfigProfile(cfg.Profile))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, configOptions...)
+	if err != nil {
+		log.Printf("⚠️  Warning: Failed to upload reports to S3: failed to load AWS config: %v", err)
+		return
+	}
+	uploadReports(ctx, newS3Uploader(awsCfg), cfg.UploadS3, report.WrittenReports, time.Now())
+}
+
+// s3Uploader uploads objects with SigV4-signed PutObject requests
+type s3Uploader struct {
+	cfg    aws.Config
+	client *http.Client
+	signer *v4.Signer
+}
+
+// newS3Uploader creates an uploader using the credentials and region of the AWS configuration
+func newS3Uploader(cfg aws.Config) *s3Uploader {
+	return &s3Uploader{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 60 * time.Second},
+		signer: v4.NewSigner(func(o *v4.SignerOptions) { o.DisableURIPathEscaping = true }),
+	}
+}
+
+// PutObject uploads body to bucket/key, retrying once in the bucket's region when S3 redirects to it
+func (u *s3Uploader) PutObject(ctx context.Context, bucket, key, contentType string, body []byte) error {
+	region := u.cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	bucketRegion, err := u.put(ctx, region, bucket, key, contentType, body)
+	if err != nil && bucketRegion != "" && bucketRegion != region {
+		_, err = u.put(ctx, bucketRegion, bucket, key, contentType, body)
+	}
+	return err
+}
+
+// put sends a single PutObject request to the given region, returning the bucket region reported by S3 on failure
+func (u *s3Uploader) put(ctx context.Context, region, bucket, key, contentType string, body []byte) (string, error) {
+	if u.cfg.Credentials == nil {
+		return "", fmt.Errorf("no AWS credentials configured")
+	}
+	creds, err := u.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	endpoint := url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, region), Path: "/" + key}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(hash[:])
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if err := u.signer.SignHTTP(ctx, creds, req, payloadHash, "s3", region, time.Now()); err != nil {
+		return "", fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.Header.Get("X-Amz-Bucket-Region"), fmt.Errorf("PutObject returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return "", nil
+}
@@ -0,0 +1,92 @@
+package cudly
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockS3Upload struct {
+	objects map[string]string
+	types   map[string]string
+	failKey string
+}
+
+func (m *mockS3Upload) PutObject(ctx context.Context, bucket, key, contentType string, body []byte) error {
+	if key == m.failKey {
+		return errors.New("access denied")
+	}
+	m.objects[bucket+"/"+key] = string(body)
+	m.types[bucket+"/"+key] = contentType
+	return nil
+}
+
+func TestParseS3URI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		bucket  string
+		prefix  string
+		wantErr bool
+	}{
+		{uri: "s3://reports/cudly/", bucket: "reports", prefix: "cudly/"},
+		{uri: "s3://reports/cudly/daily", bucket: "reports", prefix: "cudly/daily/"},
+		{uri: "s3://reports", bucket: "reports", prefix: ""},
+		{uri: "s3://reports/", bucket: "reports", prefix: ""},
+		{uri: "reports/cudly", wantErr: true},
+		{uri: "s3:///cudly", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			bucket, prefix, err := parseS3URI(tt.uri)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.bucket, bucket)
+			assert.Equal(t, tt.prefix, prefix)
+		})
+	}
+}
+
+func TestUploadReports(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "report.csv")
+	htmlPath := filepath.Join(dir, "report.html")
+	require.NoError(t, os.WriteFile(csvPath, []byte("csv"), 0o600))
+	require.NoError(t, os.WriteFile(htmlPath, []byte("html"), 0o600))
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	api := &mockS3Upload{objects: map[string]string{}, types: map[string]string{}}
+	uploaded := uploadReports(context.Background(), api, "s3://reports/cudly", []string{csvPath, htmlPath}, now)
+
+	assert.Equal(t, []string{
+		"s3://reports/cudly/20240102-030405/report.csv",
+		"s3://reports/cudly/20240102-030405/report.html",
+	}, uploaded)
+	assert.Equal(t, "csv", api.objects["reports/cudly/20240102-030405/report.csv"])
+	assert.Equal(t, "text/csv", api.types["reports/cudly/20240102-030405/report.csv"])
+	assert.Equal(t, "text/html", api.types["reports/cudly/20240102-030405/report.html"])
+}
+
+func TestUploadReportsContinuesAfterFailures(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "report.csv")
+	mdPath := filepath.Join(dir, "summary.md")
+	require.NoError(t, os.WriteFile(csvPath, []byte("csv"), 0o600))
+	require.NoError(t, os.WriteFile(mdPath, []byte("md"), 0o600))
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	api := &mockS3Upload{objects: map[string]string{}, types: map[string]string{}, failKey: "20240102-030405/report.csv"}
+	uploaded := uploadReports(context.Background(), api, "s3://reports", []string{filepath.Join(dir, "missing.json"), csvPath, mdPath}, now)
+
+	assert.Equal(t, []string{"s3://reports/20240102-030405/summary.md"}, uploaded)
+	assert.Len(t, api.objects, 1)
+}
@@ -0,0 +1,44 @@
+package cudly
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/LeanerCloud/CUDly/internal/notify"
+)
+
+// newSlackSummary converts the per-service statistics of a run into a notification summary
+func newSlackSummary(report *RunReport) notify.SummaryPayload {
+	summary := notify.SummaryPayload{DryRun: report.DryRun}
+	for service, stats := range report.ServiceStats {
+		summary.SuccessfulPurchases += stats.SuccessfulPurchases
+		summary.FailedPurchases += stats.FailedPurchases
+		summary.Instances += stats.InstancesProcessed
+		summary.EstimatedMonthlySavings += stats.TotalEstimatedSavings
+		summary.Services = append(summary.Services, notify.ServiceSummary{
+			Service:                 getServiceDisplayName(service),
+			SuccessfulPurchases:     stats.SuccessfulPurchases,
+			FailedPurchases:         stats.FailedPurchases,
+			Instances:               stats.InstancesProcessed,
+			EstimatedMonthlySavings: stats.TotalEstimatedSavings,
+		})
+	}
+	sort.Slice(summary.Services, func(i, j int) bool {
+		return summary.Services[i].Service < summary.Services[j].Service
+	})
+	return summary
+}
+
+// NotifySlack posts the run summary to --slack-webhook-url
+// Notification failures are logged as warnings and never fail the run
+func NotifySlack(ctx context.Context, report *RunReport, cfg RunConfig) {
+	if cfg.SlackWebhookURL == "" {
+		return
+	}
+	if err := notify.NewSlackNotifier(cfg.SlackWebhookURL, nil).Notify(ctx, newSlackSummary(report)); err != nil {
+		log.Printf("⚠️  Warning: Failed to send Slack notification: %v", err)
+		return
+	}
+	AppLogger.Println("💬 Sent run summary to Slack")
+}
@@ -0,0 +1,48 @@
+package cudly
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSlackSummary(t *testing.T) {
+	report := newRunReport(false)
+	report.ServiceStats[common.ServiceRDS] = ServiceProcessingStats{SuccessfulPurchases: 2, InstancesProcessed: 5, TotalEstimatedSavings: 300}
+	report.ServiceStats[common.ServiceEC2] = ServiceProcessingStats{SuccessfulPurchases: 1, FailedPurchases: 1, InstancesProcessed: 2, TotalEstimatedSavings: 120.5}
+
+	summary := newSlackSummary(report)
+
+	assert.False(t, summary.DryRun)
+	assert.Equal(t, 3, summary.SuccessfulPurchases)
+	assert.Equal(t, 1, summary.FailedPurchases)
+	assert.Equal(t, 7, summary.Instances)
+	assert.InDelta(t, 420.5, summary.EstimatedMonthlySavings, 0.001)
+	require.Len(t, summary.Services, 2)
+	assert.Equal(t, "EC2", summary.Services[0].Service)
+	assert.Equal(t, "RDS", summary.Services[1].Service)
+}
+
+func TestNotifySlack(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	report := newRunReport(true)
+
+	// Disabled without a webhook URL
+	NotifySlack(context.Background(), report, RunConfig{})
+	assert.Equal(t, 0, calls)
+
+	// A failing webhook only logs a warning
+	NotifySlack(context.Background(), report, RunConfig{SlackWebhookURL: server.URL})
+	assert.Equal(t, 1, calls)
+}
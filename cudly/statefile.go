@@ -0,0 +1,85 @@
+package cudly
+
+import (
+	"log"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/internal/state"
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// openStateJournal loads the --state-file journal once for the run and shares it through the configuration
+func openStateJournal(cfg *RunConfig) error {
+	if cfg.StateFile == "" || cfg.journal != nil {
+		return nil
+	}
+	journal, err := state.Load(cfg.StateFile)
+	if err != nil {
+		return err
+	}
+	cfg.journal = journal
+	return nil
+}
+
+// finishStateJournal marks the end of a completed purchase run in the --state-file journal
+// An interrupted run never gets there, so a restart skips the purchases it already made.
+func finishStateJournal(cfg RunConfig) {
+	if cfg.journal == nil || !cfg.ActualPurchase || cfg.CacheOnly {
+		return
+	}
+	if err := cfg.journal.RecordFinished(time.Now()); err != nil {
+		log.Printf("⚠️  Warning: Failed to record the end of the run in %s: %v", cfg.StateFile, err)
+	}
+}
+
+// stateJournal returns the --state-file journal of the run, loading it when the run has not opened it yet
+func stateJournal(cfg RunConfig) (*state.Journal, error) {
+	if cfg.journal != nil {
+		return cfg.journal, nil
+	}
+	return state.Load(cfg.StateFile)
+}
+
+// skipCompletedPurchases drops the recommendations an earlier, interrupted run with the same --state-file
+// already purchased, matching them by their idempotency token. The token covers the engine and Savings Plan
+// commitment, so variants of the same shape are not skipped for each other.
+func skipCompletedPurchases(recs []common.Recommendation, cfg RunConfig) []common.Recommendation {
+	if cfg.journal == nil {
+		return recs
+	}
+	remaining := make([]common.Recommendation, 0, len(recs))
+	for _, rec := range recs {
+		if purchase, ok := cfg.journal.Completed(common.RecommendationIdempotencyToken(rec)); ok {
+			AppLogger.Printf("    ⏭️  Skipping %s %s x%d: already purchased at %s (%s)\n",
+				rec.Service, rec.ResourceType, rec.Count, purchase.CompletedAt.Format(time.RFC3339), purchase.CommitmentID)
+			continue
+		}
+		remaining = append(remaining, rec)
+	}
+	return remaining
+}
+
+// recordPurchase appends the result of an actual purchase to the --state-file journal as soon as it is known
+func recordPurchase(cfg RunConfig, result common.PurchaseResult) {
+	if cfg.journal == nil {
+		return
+	}
+	rec := result.Recommendation
+	purchase := state.Purchase{
+		Token:        common.RecommendationIdempotencyToken(rec),
+		Service:      string(rec.Service),
+		Region:       rec.Region,
+		Account:      rec.Account,
+		ResourceType: rec.ResourceType,
+		Count:        rec.Count,
+		Success:      result.Success,
+		CommitmentID: result.CommitmentID,
+		CompletedAt:  time.Now(),
+	}
+	if result.Error != nil {
+		purchase.Error = result.Error.Error()
+	}
+	if err := cfg.journal.RecordPurchase(purchase); err != nil {
+		AppLogger.Printf("    ⚠️  Warning: Failed to record purchase in %s: %v\n", cfg.StateFile, err)
+	}
+}
@@ -0,0 +1,102 @@
+package cudly
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessPurchaseLoopResumesFromStateFile(t *testing.T) {
+	ctx := context.Background()
+	os.Setenv("DISABLE_PURCHASE_DELAY", "true")
+	defer os.Unsetenv("DISABLE_PURCHASE_DELAY")
+
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.small", Count: 1},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.medium", Count: 2},
+	}
+	cfg := RunConfig{Coverage: 80.0, SkipConfirmation: true, StateFile: filepath.Join(t.TempDir(), "state.jsonl")}
+
+	// The first run purchases the first recommendation and fails on the second
+	require.NoError(t, openStateJournal(&cfg))
+	first := &MockServiceClient{}
+	first.On("PurchaseCommitment", mock.Anything, recs[0]).Return(common.PurchaseResult{Recommendation: recs[0], Success: true, CommitmentID: "ri-1"}, nil)
+	first.On("PurchaseCommitment", mock.Anything, recs[1]).Return(common.PurchaseResult{Recommendation: recs[1], Error: fmt.Errorf("expired credentials")}, nil)
+	results := processPurchaseLoop(ctx, recs, "us-east-1", false, first, cfg)
+	require.Len(t, results, 2)
+
+	// A restarted run with the same state file only retries the failed purchase
+	resumed := cfg
+	resumed.journal = nil
+	require.NoError(t, openStateJournal(&resumed))
+	second := &MockServiceClient{}
+	second.On("PurchaseCommitment", mock.Anything, recs[1]).Return(common.PurchaseResult{Recommendation: recs[1], Success: true, CommitmentID: "ri-2"}, nil)
+	results = processPurchaseLoop(ctx, recs, "us-east-1", false, second, resumed)
+
+	require.Len(t, results, 1)
+	assert.Equal(t, "ri-2", results[0].CommitmentID)
+	second.AssertNumberOfCalls(t, "PurchaseCommitment", 1)
+}
+
+func TestProcessPurchaseLoopResumeKeepsEngineVariants(t *testing.T) {
+	ctx := context.Background()
+	os.Setenv("DISABLE_PURCHASE_DELAY", "true")
+	defer os.Unsetenv("DISABLE_PURCHASE_DELAY")
+
+	// Same service, region, instance class and count, differing only in engine
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r6g.large", Count: 1, Details: &common.DatabaseDetails{Engine: "mysql"}},
+		{Service: common.ServiceRDS, Region: "us-east-1", ResourceType: "db.r6g.large", Count: 1, Details: &common.DatabaseDetails{Engine: "postgres"}},
+	}
+	cfg := RunConfig{Coverage: 80.0, SkipConfirmation: true, FailFast: true, StateFile: filepath.Join(t.TempDir(), "state.jsonl")}
+
+	// The first run purchases the MySQL reservation and is aborted by the PostgreSQL failure
+	require.NoError(t, openStateJournal(&cfg))
+	first := &MockServiceClient{}
+	first.On("PurchaseCommitment", mock.Anything, recs[0]).Return(common.PurchaseResult{Recommendation: recs[0], Success: true, CommitmentID: "ri-mysql"}, nil)
+	first.On("PurchaseCommitment", mock.Anything, recs[1]).Return(common.PurchaseResult{Recommendation: recs[1], Error: fmt.Errorf("throttled")}, nil)
+	processPurchaseLoop(ctx, recs, "us-east-1", false, first, cfg)
+
+	// The resumed run still purchases the PostgreSQL reservation
+	resumed := cfg
+	resumed.journal = nil
+	require.NoError(t, openStateJournal(&resumed))
+	second := &MockServiceClient{}
+	second.On("PurchaseCommitment", mock.Anything, recs[1]).Return(common.PurchaseResult{Recommendation: recs[1], Success: true, CommitmentID: "ri-postgres"}, nil)
+	results := processPurchaseLoop(ctx, recs, "us-east-1", false, second, resumed)
+
+	require.Len(t, results, 1)
+	assert.Equal(t, "ri-postgres", results[0].CommitmentID)
+	second.AssertExpectations(t)
+	second.AssertNotCalled(t, "PurchaseCommitment", mock.Anything, recs[0])
+}
+
+func TestFinishStateJournal(t *testing.T) {
+	rec := common.Recommendation{Service: common.ServiceEC2, Region: "us-east-1", ResourceType: "m5.large", Count: 1}
+	cfg := RunConfig{ActualPurchase: true, StateFile: filepath.Join(t.TempDir(), "state.jsonl")}
+	require.NoError(t, openStateJournal(&cfg))
+	recordPurchase(cfg, common.PurchaseResult{Recommendation: rec, Success: true, CommitmentID: "ri-1"})
+
+	// Dry runs leave the journal open for resumption
+	dryRun := cfg
+	dryRun.ActualPurchase = false
+	finishStateJournal(dryRun)
+	assert.Empty(t, skipCompletedPurchases([]common.Recommendation{rec}, cfg))
+
+	finishStateJournal(cfg)
+	next := RunConfig{StateFile: cfg.StateFile}
+	require.NoError(t, openStateJournal(&next))
+	assert.Len(t, skipCompletedPurchases([]common.Recommendation{rec}, next), 1, "the next run purchases it again")
+}
+
+func TestSkipCompletedPurchasesWithoutStateFile(t *testing.T) {
+	recs := []common.Recommendation{{Service: common.ServiceEC2, ResourceType: "m5.large", Count: 1}}
+	assert.Equal(t, recs, skipCompletedPurchases(recs, RunConfig{}))
+}
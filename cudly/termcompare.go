@@ -0,0 +1,106 @@
+package cudly
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// TermStats holds the recommendation totals of a commitment term
+type TermStats struct {
+	Term                    string  `json:"term"`
+	RecommendationsSelected int     `json:"recommendations_selected"`
+	InstancesProcessed      int     `json:"instances_processed"`
+	TotalEstimatedSavings   float64 `json:"total_estimated_savings"`
+	TotalUpfrontCost        float64 `json:"total_upfront_cost"`
+	ProjectedTermSavings    float64 `json:"projected_term_savings"`
+}
+
+// calculateTermStats sums recommendations by term, sorted from the shortest term to the longest
+func calculateTermStats(recs []common.Recommendation) []TermStats {
+	byTerm := make(map[string]*TermStats)
+	for _, rec := range recs {
+		stats, ok := byTerm[rec.Term]
+		if !ok {
+			stats = &TermStats{Term: rec.Term}
+			byTerm[rec.Term] = stats
+		}
+		stats.RecommendationsSelected++
+		stats.InstancesProcessed += rec.Count
+		stats.TotalEstimatedSavings += rec.EstimatedSavings
+		stats.TotalUpfrontCost += rec.UpfrontCost
+		stats.ProjectedTermSavings += rec.EstimatedSavings * float64(termMonths(rec.Term))
+	}
+
+	result := make([]TermStats, 0, len(byTerm))
+	for _, stats := range byTerm {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return termMonths(result[i].Term) < termMonths(result[j].Term)
+	})
+	return result
+}
+
+// printTermComparison compares the savings of each term fetched with --terms; runs with a single term print nothing
+func printTermComparison(recs []common.Recommendation) {
+	terms := calculateTermStats(recs)
+	if len(terms) < 2 {
+		return
+	}
+
+	outPrintln("\n📅 BY TERM:")
+	outPrintln("--------------------------------------------------")
+	best := terms[0]
+	for _, stats := range terms {
+		outPrintf("%-6s | Recs: %3d | Instances: %3d | Savings: $%8.2f/mo | Upfront: $%10.2f | Term: $%10.2f\n",
+			stats.Term,
+			stats.RecommendationsSelected,
+			stats.InstancesProcessed,
+			stats.TotalEstimatedSavings,
+			stats.TotalUpfrontCost,
+			stats.ProjectedTermSavings)
+		if stats.TotalEstimatedSavings > best.TotalEstimatedSavings {
+			best = stats
+		}
+	}
+	outPrintf("  ⭐ Highest monthly savings: %s ($%.2f/mo)\n", best.Term, best.TotalEstimatedSavings)
+
+	printServiceTermComparison(recs)
+}
+
+// calculateServiceTermStats sums recommendations by service and term
+func calculateServiceTermStats(recs []common.Recommendation) map[common.ServiceType][]TermStats {
+	byService := make(map[common.ServiceType][]common.Recommendation)
+	for _, rec := range recs {
+		byService[rec.Service] = append(byService[rec.Service], rec)
+	}
+	result := make(map[common.ServiceType][]TermStats, len(byService))
+	for service, serviceRecs := range byService {
+		result[service] = calculateTermStats(serviceRecs)
+	}
+	return result
+}
+
+// printServiceTermComparison prints the monthly savings, upfront cost and savings over the term of each term side by side per service
+func printServiceTermComparison(recs []common.Recommendation) {
+	byService := calculateServiceTermStats(recs)
+	services := make([]common.ServiceType, 0, len(byService))
+	for service := range byService {
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i] < services[j] })
+
+	outPrintln("\n📅 BY SERVICE AND TERM:")
+	outPrintln("--------------------------------------------------")
+	for _, service := range services {
+		columns := make([]string, 0, len(byService[service]))
+		for _, stats := range byService[service] {
+			columns = append(columns, fmt.Sprintf("%s: $%8.2f/mo, $%10.2f upfront, $%10.2f term",
+				stats.Term, stats.TotalEstimatedSavings, stats.TotalUpfrontCost, stats.ProjectedTermSavings))
+		}
+		outPrintf("%-15s | %s\n", getServiceDisplayName(service), strings.Join(columns, " | "))
+	}
+}
@@ -0,0 +1,66 @@
+package cudly
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+func TestCalculateTermStats(t *testing.T) {
+	recs := []common.Recommendation{
+		{Term: "3yr", Count: 2, EstimatedSavings: 30, UpfrontCost: 900},
+		{Term: "1yr", Count: 2, EstimatedSavings: 20, UpfrontCost: 200},
+		{Term: "3yr", Count: 1, EstimatedSavings: 10, UpfrontCost: 300},
+	}
+
+	assert.Equal(t, []TermStats{
+		{Term: "1yr", RecommendationsSelected: 1, InstancesProcessed: 2, TotalEstimatedSavings: 20, TotalUpfrontCost: 200, ProjectedTermSavings: 240},
+		{Term: "3yr", RecommendationsSelected: 2, InstancesProcessed: 3, TotalEstimatedSavings: 40, TotalUpfrontCost: 1200, ProjectedTermSavings: 1440},
+	}, calculateTermStats(recs))
+}
+
+func TestCalculateServiceTermStats(t *testing.T) {
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Term: "1yr", Count: 1, EstimatedSavings: 10, UpfrontCost: 100},
+		{Service: common.ServiceRDS, Term: "3yr", Count: 1, EstimatedSavings: 20, UpfrontCost: 500},
+		{Service: common.ServiceEC2, Term: "3yr", Count: 2, EstimatedSavings: 5},
+	}
+
+	stats := calculateServiceTermStats(recs)
+
+	assert.Equal(t, []TermStats{
+		{Term: "1yr", RecommendationsSelected: 1, InstancesProcessed: 1, TotalEstimatedSavings: 10, TotalUpfrontCost: 100, ProjectedTermSavings: 120},
+		{Term: "3yr", RecommendationsSelected: 1, InstancesProcessed: 1, TotalEstimatedSavings: 20, TotalUpfrontCost: 500, ProjectedTermSavings: 720},
+	}, stats[common.ServiceRDS])
+	assert.Equal(t, []TermStats{
+		{Term: "3yr", RecommendationsSelected: 1, InstancesProcessed: 2, TotalEstimatedSavings: 5, ProjectedTermSavings: 180},
+	}, stats[common.ServiceEC2])
+}
+
+func TestPrintServiceTermComparison(t *testing.T) {
+	recs := []common.Recommendation{
+		{Service: common.ServiceRDS, Term: "1yr", Count: 1, EstimatedSavings: 10, UpfrontCost: 100},
+		{Service: common.ServiceRDS, Term: "3yr", Count: 1, EstimatedSavings: 20, UpfrontCost: 500},
+	}
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	printTermComparison(recs)
+
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	output := buf.String()
+
+	assert.Contains(t, output, "BY SERVICE AND TERM:")
+	assert.Contains(t, output, "1yr: $   10.00/mo, $    100.00 upfront, $    120.00 term | 3yr: $   20.00/mo, $    500.00 upfront, $    720.00 term")
+}
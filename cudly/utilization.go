@@ -0,0 +1,105 @@
+package cudly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// lowUtilizationThreshold is the utilization percentage below which existing reservations are reported as underutilized
+const lowUtilizationThreshold = 80.0
+
+// ReservationUtilizationAPI defines the interface for fetching the reservation utilization of a service
+type ReservationUtilizationAPI interface {
+	GetReservationUtilization(ctx context.Context, service common.ServiceType, start, end time.Time) (recommendations.ReservationUtilization, error)
+}
+
+// ReportUtilization returns the utilization and coverage of the existing reservations of the selected AWS services
+// over the last days days, without purchasing anything. Savings Plans are skipped, as they are not reservations.
+func ReportUtilization(ctx context.Context, cfg RunConfig, days int) ([]recommendations.ReservationUtilization, error) {
+	if days <= 0 {
+		return nil, fmt.Errorf("days must be a positive number, got: %d", days)
+	}
+	services := determineServicesToProcess(cfg)
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no valid services specified")
+	}
+
+	configOptions := []func(*config.LoadOptions) error{config.WithRegion("us-east-1")}
+	if cfg.Profile != "" {
+		configOptions = append(configOptions, config.WithSharedConfigProfile(cfg.Profile))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, configOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return reportUtilization(ctx, recommendations.NewUtilizationClient(awsCfg), services, days, time.Now())
+}
+
+// reportUtilization fetches the reservation utilization of each service over the days before now, in service order
+// Services that fail are logged and skipped.
+func reportUtilization(ctx context.Context, api ReservationUtilizationAPI, services []common.ServiceType, days int, now time.Time) ([]recommendations.ReservationUtilization, error) {
+	end := now.UTC().Truncate(24 * time.Hour)
+	start := end.AddDate(0, 0, -days)
+
+	results := make([]recommendations.ReservationUtilization, 0, len(services))
+	failed := 0
+	for _, service := range services {
+		if service == common.ServiceSavingsPlans {
+			AppLogger.Printf("ℹ️  Skipping %s: Savings Plans are not reservations\n", getServiceDisplayName(service))
+			continue
+		}
+		utilization, err := api.GetReservationUtilization(ctx, service, start, end)
+		if err != nil {
+			AppLogger.Printf("⚠️  Failed to get %s reservation utilization: %v\n", getServiceDisplayName(service), err)
+			failed++
+			continue
+		}
+		results = append(results, utilization)
+	}
+	if failed > 0 && len(results) == 0 {
+		return nil, fmt.Errorf("failed to get the reservation utilization of all %d service(s)", failed)
+	}
+	return results, nil
+}
+
+// PrintReservationUtilization prints the utilization and coverage of the existing reservations per service
+// and warns about underutilized ones, which buying more reservations would add to
+func PrintReservationUtilization(results []recommendations.ReservationUtilization, days int) {
+	outPrintf("\n📊 Reservation utilization (last %d days):\n", days)
+	outPrintf("  %-13s | %11s | %8s | %14s | %12s | %11s\n", "Service", "Utilization", "Coverage", "Purchased Hrs", "Unused Hrs", "Net Savings")
+	outPrintln("  ----------------------------------------------------------------------------------")
+	var underutilized []recommendations.ReservationUtilization
+	for _, u := range results {
+		if !u.HasReservations {
+			outPrintf("  %-13s | %11s | %8s | %14s | %12s | %11s\n", getServiceDisplayName(u.Service), "-", "-", "-", "-", "-")
+			continue
+		}
+		outPrintf("  %-13s | %10.1f%% | %7.1f%% | %14.0f | %12.0f | %11.2f\n",
+			getServiceDisplayName(u.Service), u.UtilizationPercentage, u.CoveragePercentage, u.PurchasedHours, u.UnusedHours, u.NetSavings)
+		if u.UtilizationPercentage < lowUtilizationThreshold {
+			underutilized = append(underutilized, u)
+		}
+	}
+
+	for _, u := range underutilized {
+		outPrintf("\n  ⚠️  %s reservations are only %.1f%% utilized: consider a lower --coverage before buying more\n", getServiceDisplayName(u.Service), u.UtilizationPercentage)
+	}
+}
+
+// WriteReservationUtilizationJSON writes the reservation utilization as an indented JSON document
+func WriteReservationUtilizationJSON(w io.Writer, results []recommendations.ReservationUtilization) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(results); err != nil {
+		return fmt.Errorf("failed to write utilization: %w", err)
+	}
+	return nil
+}
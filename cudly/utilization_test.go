@@ -0,0 +1,97 @@
+package cudly
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUtilizationAPI returns the utilization or error of each service and records the requested period
+type fakeUtilizationAPI struct {
+	results    map[common.ServiceType]recommendations.ReservationUtilization
+	errs       map[common.ServiceType]error
+	start, end time.Time
+}
+
+func (f *fakeUtilizationAPI) GetReservationUtilization(ctx context.Context, service common.ServiceType, start, end time.Time) (recommendations.ReservationUtilization, error) {
+	f.start, f.end = start, end
+	return f.results[service], f.errs[service]
+}
+
+func TestReportUtilization(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 30, 0, 0, time.UTC)
+	rds := recommendations.ReservationUtilization{Service: common.ServiceRDS, HasReservations: true, UtilizationPercentage: 95}
+
+	tests := []struct {
+		name          string
+		api           *fakeUtilizationAPI
+		services      []common.ServiceType
+		expected      []recommendations.ReservationUtilization
+		errorContains string
+	}{
+		{
+			name: "failed services are skipped",
+			api: &fakeUtilizationAPI{
+				results: map[common.ServiceType]recommendations.ReservationUtilization{common.ServiceRDS: rds},
+				errs:    map[common.ServiceType]error{common.ServiceEC2: errors.New("access denied")},
+			},
+			services: []common.ServiceType{common.ServiceEC2, common.ServiceRDS, common.ServiceSavingsPlans},
+			expected: []recommendations.ReservationUtilization{rds},
+		},
+		{
+			name:          "all services failed",
+			api:           &fakeUtilizationAPI{errs: map[common.ServiceType]error{common.ServiceEC2: errors.New("access denied")}},
+			services:      []common.ServiceType{common.ServiceEC2},
+			errorContains: "failed to get the reservation utilization of all 1 service(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := reportUtilization(context.Background(), tt.api, tt.services, 30, now)
+
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, results)
+			assert.Equal(t, time.Date(2026, 9, 16, 0, 0, 0, 0, time.UTC), tt.api.start)
+			assert.Equal(t, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), tt.api.end)
+		})
+	}
+}
+
+func TestPrintReservationUtilization(t *testing.T) {
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintReservationUtilization([]recommendations.ReservationUtilization{
+		{Service: common.ServiceRDS, HasReservations: true, UtilizationPercentage: 62.5, CoveragePercentage: 40, PurchasedHours: 1440, UnusedHours: 540, NetSavings: 123.45},
+		{Service: common.ServiceElastiCache, HasReservations: true, UtilizationPercentage: 99, CoveragePercentage: 80, PurchasedHours: 720},
+		{Service: common.ServiceEC2},
+	}, 30)
+
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	output := buf.String()
+
+	assert.Contains(t, output, "Reservation utilization (last 30 days)")
+	assert.Regexp(t, `RDS\s+\|\s+62\.5% \|\s+40\.0% \|\s+1440 \|\s+540 \|\s+123\.45`, output)
+	assert.Regexp(t, `EC2\s+\|\s+- \|\s+- \|`, output)
+	assert.Contains(t, output, "RDS reservations are only 62.5% utilized")
+	assert.NotContains(t, output, "ElastiCache reservations are only")
+}
@@ -0,0 +1,236 @@
+// Package config loads command line settings from YAML and TOML configuration files
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads a YAML (.yaml, .yml) or TOML (.toml) configuration file and returns its settings keyed by flag name
+// Keys are the command line flag names, with underscores accepted in place of dashes. Values are returned in
+// their flag string form, with lists joined by commas.
+func Load(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		return parseYAML(data)
+	case ".toml":
+		return parseTOML(data)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q, expected .yaml, .yml or .toml", ext)
+	}
+}
+
+// normalizeKey maps a configuration key to the flag name it sets
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "_", "-")
+}
+
+// addSetting stores a setting, rejecting keys that are set twice
+func addSetting(settings map[string]string, key, value string) error {
+	name := normalizeKey(key)
+	if name == "" {
+		return fmt.Errorf("empty key")
+	}
+	if _, ok := settings[name]; ok {
+		return fmt.Errorf("duplicate key %q", name)
+	}
+	settings[name] = value
+	return nil
+}
+
+// parseYAML parses a flat YAML mapping of flag names to scalars or lists of scalars
+func parseYAML(data []byte) (map[string]string, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	settings := make(map[string]string, len(raw))
+	for key, value := range raw {
+		formatted, err := formatYAMLValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key, err)
+		}
+		if err := addSetting(settings, key, formatted); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+// formatYAMLValue converts a decoded YAML value to its flag string form
+func formatYAMLValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, ok := item.([]any); ok {
+				return "", fmt.Errorf("nested lists are not supported")
+			}
+			formatted, err := formatYAMLValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, formatted)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]any:
+		return "", fmt.Errorf("nested mappings are not supported")
+	default:
+		return "", fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// parseTOML parses the flat subset of TOML the settings need: one key = value pair per line, where values are
+// strings, numbers, booleans or single-line arrays of those. Tables are not supported.
+func parseTOML(data []byte) (map[string]string, error) {
+	settings := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(stripTOMLComment(line))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("line %d: tables are not supported", i+1)
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		formatted, err := parseTOMLValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: key %q: %w", i+1, key, err)
+		}
+		if err := addSetting(settings, key, formatted); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	return settings, nil
+}
+
+// stripTOMLComment removes a trailing # comment that is not inside a string
+func stripTOMLComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+			escaped = quote == '"' && r == '\\'
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseTOMLValue converts a TOML value to its flag string form
+func parseTOMLValue(value string) (string, error) {
+	switch {
+	case value == "":
+		return "", fmt.Errorf("missing value")
+	case strings.HasPrefix(value, "["):
+		if !strings.HasSuffix(value, "]") {
+			return "", fmt.Errorf("arrays must be on a single line")
+		}
+		items, err := splitTOMLArray(strings.TrimSpace(value[1 : len(value)-1]))
+		if err != nil {
+			return "", err
+		}
+		formatted := make([]string, 0, len(items))
+		for _, item := range items {
+			if strings.HasPrefix(item, "[") {
+				return "", fmt.Errorf("nested arrays are not supported")
+			}
+			v, err := parseTOMLValue(item)
+			if err != nil {
+				return "", err
+			}
+			formatted = append(formatted, v)
+		}
+		return strings.Join(formatted, ","), nil
+	case strings.HasPrefix(value, `"`):
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", value)
+		}
+		return s, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("invalid string %s", value)
+		}
+		return value[1 : len(value)-1], nil
+	case value == "true" || value == "false":
+		return value, nil
+	default:
+		number := strings.ReplaceAll(value, "_", "")
+		if _, err := strconv.ParseFloat(number, 64); err != nil {
+			return "", fmt.Errorf("invalid value %s", value)
+		}
+		return number, nil
+	}
+}
+
+// splitTOMLArray splits the contents of a single-line array on the commas outside strings
+func splitTOMLArray(contents string) ([]string, error) {
+	var items []string
+	var quote rune
+	escaped := false
+	start := 0
+	for i, r := range contents {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+			escaped = quote == '"' && r == '\\'
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, strings.TrimSpace(contents[start:i]))
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated string in array")
+	}
+	if last := strings.TrimSpace(contents[start:]); last != "" {
+		items = append(items, last)
+	}
+	for _, item := range items {
+		if item == "" {
+			return nil, fmt.Errorf("empty array element")
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "YAML settings",
+			file: "cudly.yaml",
+			content: `# nightly dry run
+regions: [us-east-1, eu-west-1]
+services:
+  - rds
+  - elasticache
+coverage: 80
+min-savings-per-instance: 2.5
+payment_option: no-upfront
+no-emoji: true
+delay-jitter: 30s
+`,
+			want: map[string]string{
+				"regions":                  "us-east-1,eu-west-1",
+				"services":                 "rds,elasticache",
+				"coverage":                 "80",
+				"min-savings-per-instance": "2.5",
+				"payment-option":           "no-upfront",
+				"no-emoji":                 "true",
+				"delay-jitter":             "30s",
+			},
+		},
+		{
+			name: "TOML settings",
+			file: "cudly.toml",
+			content: `# nightly dry run
+regions = ["us-east-1", "eu-west-1"] # two regions
+services = [ 'rds' ]
+coverage = 80
+max_instances = 1_000
+payment-option = "all-upfront"
+filter-expression = "engine == \"mysql\" # not a comment"
+no-emoji = true
+`,
+			want: map[string]string{
+				"regions":           "us-east-1,eu-west-1",
+				"services":          "rds",
+				"coverage":          "80",
+				"max-instances":     "1000",
+				"payment-option":    "all-upfront",
+				"filter-expression": `engine == "mysql" # not a comment`,
+				"no-emoji":          "true",
+			},
+		},
+		{
+			name:    "unsupported extension",
+			file:    "cudly.json",
+			content: `{}`,
+			wantErr: "unsupported config file extension",
+		},
+		{
+			name:    "nested YAML mapping",
+			file:    "cudly.yml",
+			content: "filters:\n  regions: [us-east-1]\n",
+			wantErr: "nested mappings are not supported",
+		},
+		{
+			name:    "duplicate YAML key after normalization",
+			file:    "cudly.yaml",
+			content: "payment-option: all-upfront\npayment_option: no-upfront\n",
+			wantErr: "duplicate key",
+		},
+		{
+			name:    "TOML table",
+			file:    "cudly.toml",
+			content: "[filters]\nregions = [\"us-east-1\"]\n",
+			wantErr: "tables are not supported",
+		},
+		{
+			name:    "TOML multi-line array",
+			file:    "cudly.toml",
+			content: "regions = [\n\"us-east-1\",\n]\n",
+			wantErr: "arrays must be on a single line",
+		},
+		{
+			name:    "TOML bare word",
+			file:    "cudly.toml",
+			content: "payment-option = no-upfront\n",
+			wantErr: "line 1: key \"payment-option\": invalid value",
+		},
+		{
+			name:    "TOML line without value",
+			file:    "cudly.toml",
+			content: "coverage = 80\nregions\n",
+			wantErr: "line 2: expected key = value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(writeConfigFile(t, tt.file, tt.content))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read config file")
+}
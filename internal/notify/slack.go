@@ -0,0 +1,103 @@
+// Package notify sends run result notifications to chat services
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultTimeout bounds a webhook call when no HTTP client is injected
+const defaultTimeout = 10 * time.Second
+
+// ServiceSummary holds the purchase results of a single service
+type ServiceSummary struct {
+	Service                 string
+	SuccessfulPurchases     int
+	FailedPurchases         int
+	Instances               int
+	EstimatedMonthlySavings float64
+}
+
+// SummaryPayload is the run outcome sent in a notification
+type SummaryPayload struct {
+	DryRun                  bool
+	SuccessfulPurchases     int
+	FailedPurchases         int
+	Instances               int
+	EstimatedMonthlySavings float64
+	// Services lists the per-service results in the order they are reported
+	Services []ServiceSummary
+}
+
+// SlackNotifier posts run summaries to a Slack incoming webhook
+type SlackNotifier struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackNotifier creates a notifier for the given webhook URL
+// A nil client uses a default client with a 10 second timeout.
+func NewSlackNotifier(webhookURL string, client *http.Client) *SlackNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &SlackNotifier{
+		webhookURL: webhookURL,
+		client:     client,
+	}
+}
+
+// slackMessage is the body of a Slack incoming webhook request
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Notify posts the summary to the Slack webhook
+func (n *SlackNotifier) Notify(ctx context.Context, summary SummaryPayload) error {
+	body, err := json.Marshal(slackMessage{Text: formatSlackMessage(summary)})
+	if err != nil {
+		return fmt.Errorf("failed to encode Slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Slack notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("slack webhook returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// formatSlackMessage renders the summary as Slack mrkdwn text
+func formatSlackMessage(summary SummaryPayload) string {
+	var b strings.Builder
+	if summary.DryRun {
+		b.WriteString("*CUDly dry run completed*\n")
+	} else {
+		b.WriteString("*CUDly purchase run completed*\n")
+	}
+	fmt.Fprintf(&b, "Successful: %d | Failed: %d | Instances: %d | Est. savings: $%.2f/mo\n",
+		summary.SuccessfulPurchases, summary.FailedPurchases, summary.Instances, summary.EstimatedMonthlySavings)
+
+	for _, service := range summary.Services {
+		fmt.Fprintf(&b, "• %s: %d successful, %d failed, %d instances, $%.2f/mo\n",
+			service.Service, service.SuccessfulPurchases, service.FailedPurchases, service.Instances, service.EstimatedMonthlySavings)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSummary() SummaryPayload {
+	return SummaryPayload{
+		SuccessfulPurchases:     3,
+		FailedPurchases:         1,
+		Instances:               7,
+		EstimatedMonthlySavings: 420.5,
+		Services: []ServiceSummary{
+			{Service: "EC2", SuccessfulPurchases: 1, FailedPurchases: 1, Instances: 2, EstimatedMonthlySavings: 120.5},
+			{Service: "RDS", SuccessfulPurchases: 2, Instances: 5, EstimatedMonthlySavings: 300},
+		},
+	}
+}
+
+func TestFormatSlackMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		summary  SummaryPayload
+		contains []string
+	}{
+		{
+			name:    "purchase run",
+			summary: testSummary(),
+			contains: []string{
+				"*CUDly purchase run completed*",
+				"Successful: 3 | Failed: 1 | Instances: 7 | Est. savings: $420.50/mo",
+				"• EC2: 1 successful, 1 failed, 2 instances, $120.50/mo",
+				"• RDS: 2 successful, 0 failed, 5 instances, $300.00/mo",
+			},
+		},
+		{
+			name:     "dry run",
+			summary:  SummaryPayload{DryRun: true},
+			contains: []string{"*CUDly dry run completed*", "Successful: 0 | Failed: 0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := formatSlackMessage(tt.summary)
+			for _, want := range tt.contains {
+				assert.Contains(t, message, want)
+			}
+		})
+	}
+}
+
+func TestSlackNotifierNotify(t *testing.T) {
+	var received slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := NewSlackNotifier(server.URL, server.Client())
+	require.NoError(t, notifier.Notify(context.Background(), testSummary()))
+	assert.Equal(t, formatSlackMessage(testSummary()), received.Text)
+}
+
+func TestSlackNotifierNotifyErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := NewSlackNotifier(server.URL, server.Client()).Notify(context.Background(), testSummary())
+	assert.ErrorContains(t, err, "403 Forbidden: invalid_token")
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+	err = NewSlackNotifier(unreachable.URL, nil).Notify(context.Background(), testSummary())
+	assert.ErrorContains(t, err, "failed to send Slack notification")
+}
@@ -0,0 +1,188 @@
+// Package state keeps the append-only journal of purchase runs (--state-file), so interrupted runs can be resumed
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Purchase is the journal entry of a purchase attempt, keyed by the idempotency token of its recommendation
+type Purchase struct {
+	Token        string    `json:"token"`
+	Service      string    `json:"service"`
+	Region       string    `json:"region"`
+	Account      string    `json:"account,omitempty"`
+	ResourceType string    `json:"resource_type"`
+	Count        int       `json:"count"`
+	Success      bool      `json:"success"`
+	CommitmentID string    `json:"commitment_id,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	CompletedAt  time.Time `json:"completed_at"`
+}
+
+// entry is one line of the journal: a purchase, a snapshot of the deferred queue or the end of a run
+// Deferred snapshots use the layout of the earlier single-object state file, which therefore still loads.
+type entry struct {
+	Purchase  *Purchase       `json:"purchase,omitempty"`
+	UpdatedAt *time.Time      `json:"updated_at,omitempty"`
+	Deferred  json.RawMessage `json:"deferred,omitempty"`
+	// FinishedAt marks the end of a run; the purchases recorded before it are no longer resumed
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Journal is a state file loaded into memory; new entries are appended to the file as they are recorded
+type Journal struct {
+	path string
+
+	mu        sync.Mutex
+	purchases map[string]Purchase
+	deferred  json.RawMessage
+}
+
+// Load reads the journal at path, returning an empty journal if the file does not exist yet
+// Only the purchases of the last, unfinished run are kept; when a token was recorded more than once, its latest entry wins.
+func Load(path string) (*Journal, error) {
+	j := &Journal{path: path, purchases: make(map[string]Purchase)}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return j, nil
+		}
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	for {
+		end := dec.InputOffset()
+		var e entry
+		if err := dec.Decode(&e); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			// A run interrupted while appending leaves a partial last line, which is dropped
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				if err := dropPartialEntry(path, end); err != nil {
+					return nil, err
+				}
+				break
+			}
+			return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+		}
+		if e.Purchase != nil {
+			j.purchases[e.Purchase.Token] = *e.Purchase
+		}
+		if e.UpdatedAt != nil {
+			j.deferred = e.Deferred
+		}
+		if e.FinishedAt != nil {
+			clear(j.purchases)
+		}
+	}
+	return j, nil
+}
+
+// dropPartialEntry truncates the journal after its last complete entry, ending it with a newline again
+func dropPartialEntry(path string, end int64) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to repair state file: %w", err)
+	}
+	defer f.Close()
+	if err := f.Truncate(end); err != nil {
+		return fmt.Errorf("failed to repair state file: %w", err)
+	}
+	if end > 0 {
+		if _, err := f.WriteAt([]byte("\n"), end); err != nil {
+			return fmt.Errorf("failed to repair state file: %w", err)
+		}
+	}
+	return nil
+}
+
+// Completed returns the successful purchase recorded for a token
+func (j *Journal) Completed(token string) (Purchase, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	p, ok := j.purchases[token]
+	return p, ok && p.Success
+}
+
+// Deferred decodes the latest deferred queue snapshot into v, leaving v untouched if none was recorded
+func (j *Journal) Deferred(v any) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.deferred) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(j.deferred, v); err != nil {
+		return fmt.Errorf("failed to parse deferred queue in state file %s: %w", j.path, err)
+	}
+	return nil
+}
+
+// RecordPurchase appends a purchase attempt to the journal
+func (j *Journal) RecordPurchase(p Purchase) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.append(entry{Purchase: &p}); err != nil {
+		return err
+	}
+	j.purchases[p.Token] = p
+	return nil
+}
+
+// RecordDeferred appends a snapshot of the deferred queue v, replacing the earlier snapshots
+func (j *Journal) RecordDeferred(updatedAt time.Time, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode deferred queue: %w", err)
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.append(entry{UpdatedAt: &updatedAt, Deferred: data}); err != nil {
+		return err
+	}
+	j.deferred = data
+	return nil
+}
+
+// RecordFinished appends the end of a run, after which its purchases are no longer skipped by later runs
+func (j *Journal) RecordFinished(finishedAt time.Time) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.append(entry{FinishedAt: &finishedAt}); err != nil {
+		return err
+	}
+	clear(j.purchases)
+	return nil
+}
+
+// append writes an entry as a single line and syncs it, so an interrupted run loses at most the entry in flight
+func (j *Journal) append(e entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode state entry: %w", err)
+	}
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
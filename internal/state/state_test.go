@@ -0,0 +1,121 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.jsonl")
+
+	j, err := Load(path)
+	require.NoError(t, err, "a missing state file is an empty journal")
+	_, ok := j.Completed("token-1")
+	assert.False(t, ok)
+
+	completedAt := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, j.RecordPurchase(Purchase{Token: "token-1", Service: "rds", Region: "us-east-1", Count: 2, Success: true, CommitmentID: "ri-1", CompletedAt: completedAt}))
+	require.NoError(t, j.RecordPurchase(Purchase{Token: "token-2", Service: "rds", Region: "us-east-1", Count: 1, Error: "expired credentials", CompletedAt: completedAt}))
+	require.NoError(t, j.RecordDeferred(completedAt, []string{"first"}))
+	require.NoError(t, j.RecordDeferred(completedAt, []string{"second"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 4, "every entry is appended as its own line")
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	p, ok := loaded.Completed("token-1")
+	assert.True(t, ok)
+	assert.Equal(t, "ri-1", p.CommitmentID)
+	_, ok = loaded.Completed("token-2")
+	assert.False(t, ok, "failed purchases are not completed")
+
+	var deferred []string
+	require.NoError(t, loaded.Deferred(&deferred))
+	assert.Equal(t, []string{"second"}, deferred, "the latest deferred snapshot wins")
+}
+
+func TestLoadLatestEntryWins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.jsonl")
+	j, err := Load(path)
+	require.NoError(t, err)
+	require.NoError(t, j.RecordPurchase(Purchase{Token: "token-1", Error: "throttled"}))
+	require.NoError(t, j.RecordPurchase(Purchase{Token: "token-1", Success: true, CommitmentID: "ri-1"}))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	p, ok := loaded.Completed("token-1")
+	assert.True(t, ok)
+	assert.Equal(t, "ri-1", p.CommitmentID)
+}
+
+func TestRecordFinishedEndsResumption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.jsonl")
+	j, err := Load(path)
+	require.NoError(t, err)
+	require.NoError(t, j.RecordPurchase(Purchase{Token: "token-1", Success: true}))
+	require.NoError(t, j.RecordDeferred(time.Now(), []string{"queued"}))
+	require.NoError(t, j.RecordFinished(time.Now()))
+
+	_, ok := j.Completed("token-1")
+	assert.False(t, ok)
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	_, ok = loaded.Completed("token-1")
+	assert.False(t, ok, "purchases of a finished run are not skipped again")
+	var deferred []string
+	require.NoError(t, loaded.Deferred(&deferred))
+	assert.Equal(t, []string{"queued"}, deferred, "the deferred queue outlives the run")
+}
+
+func TestLoadSingleObjectStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	content := `{
+  "updated_at": "2024-03-05T12:00:00Z",
+  "deferred": [
+    {"resource_type": "db.r5.large", "count": 2}
+  ]
+}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	j, err := Load(path)
+	require.NoError(t, err)
+	var deferred []map[string]any
+	require.NoError(t, j.Deferred(&deferred))
+	require.Len(t, deferred, 1)
+	assert.Equal(t, "db.r5.large", deferred[0]["resource_type"])
+}
+
+func TestLoadDropsPartialLastEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.jsonl")
+	content := `{"purchase":{"token":"token-1","success":true}}` + "\n" + `{"purchase":{"token":"tok`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	j, err := Load(path)
+	require.NoError(t, err)
+	_, ok := j.Completed("token-1")
+	assert.True(t, ok)
+
+	// Entries recorded after the repair load again
+	require.NoError(t, j.RecordPurchase(Purchase{Token: "token-2", Success: true}))
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	_, ok = loaded.Completed("token-2")
+	assert.True(t, ok)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "failed to parse state file")
+}
@@ -0,0 +1,54 @@
+package common
+
+import "strings"
+
+// CPU architectures of instance types
+const (
+	ArchitectureX86_64 = "x86_64"
+	ArchitectureARM64  = "arm64"
+)
+
+// Architectures lists the supported CPU architectures
+var Architectures = []string{ArchitectureX86_64, ArchitectureARM64}
+
+// ARM64InstanceFamilies lists the instance families running on arm64 (AWS Graviton or Apple silicon) processors
+// Families missing from the table are x86_64. Database, cache and search instance types use the same family names.
+var ARM64InstanceFamilies = map[string]bool{
+	"a1":  true,
+	"t4g": true,
+	"m6g": true, "m6gd": true, "m7g": true, "m7gd": true, "m8g": true, "m8gd": true,
+	"c6g": true, "c6gd": true, "c6gn": true, "c7g": true, "c7gd": true, "c7gn": true, "c8g": true, "c8gd": true,
+	"r6g": true, "r6gd": true, "r7g": true, "r7gd": true, "r8g": true, "r8gd": true,
+	"x2g": true, "x2gd": true, "x8g": true,
+	"i4g": true, "i8g": true, "im4gn": true, "is4gen": true,
+	"g5g":   true,
+	"hpc7g": true,
+	"mac2":  true, "mac2-m1ultra": true, "mac2-m2": true, "mac2-m2pro": true,
+}
+
+// InstanceFamily returns the family of an instance type, such as "r6g" for r6g.large, db.r6g.large,
+// cache.r6g.large or r6g.large.search
+func InstanceFamily(instanceType string) string {
+	t := strings.ToLower(strings.TrimSpace(instanceType))
+	for _, prefix := range []string{"db.", "cache."} {
+		t = strings.TrimPrefix(t, prefix)
+	}
+	family, _, ok := strings.Cut(t, ".")
+	if !ok {
+		return ""
+	}
+	return family
+}
+
+// InstanceTypeArchitecture returns the CPU architecture of an instance type, or "" for resource types
+// without one, such as Savings Plan types and DynamoDB capacity
+func InstanceTypeArchitecture(instanceType string) string {
+	family := InstanceFamily(instanceType)
+	if family == "" {
+		return ""
+	}
+	if ARM64InstanceFamilies[family] {
+		return ArchitectureARM64
+	}
+	return ArchitectureX86_64
+}
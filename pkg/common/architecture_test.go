@@ -0,0 +1,37 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceTypeArchitecture(t *testing.T) {
+	tests := []struct {
+		instanceType string
+		family       string
+		want         string
+	}{
+		{instanceType: "m6g.large", family: "m6g", want: ArchitectureARM64},
+		{instanceType: "t4g.micro", family: "t4g", want: ArchitectureARM64},
+		{instanceType: "c7gn.xlarge", family: "c7gn", want: ArchitectureARM64},
+		{instanceType: "a1.medium", family: "a1", want: ArchitectureARM64},
+		{instanceType: "db.r7g.xlarge", family: "r7g", want: ArchitectureARM64},
+		{instanceType: "cache.t4g.small", family: "t4g", want: ArchitectureARM64},
+		{instanceType: "r6g.large.search", family: "r6g", want: ArchitectureARM64},
+		{instanceType: "mac2-m2pro.metal", family: "mac2-m2pro", want: ArchitectureARM64},
+		{instanceType: "m5.large", family: "m5", want: ArchitectureX86_64},
+		{instanceType: "g5.xlarge", family: "g5", want: ArchitectureX86_64},
+		{instanceType: "db.r5.large", family: "r5", want: ArchitectureX86_64},
+		{instanceType: "ra3.xlplus", family: "ra3", want: ArchitectureX86_64},
+		{instanceType: "Compute", family: "", want: ""},
+		{instanceType: "", family: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.instanceType, func(t *testing.T) {
+			assert.Equal(t, tt.family, InstanceFamily(tt.instanceType))
+			assert.Equal(t, tt.want, InstanceTypeArchitecture(tt.instanceType))
+		})
+	}
+}
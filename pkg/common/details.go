@@ -0,0 +1,51 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// serviceDetailsTypes is the registry of ServiceDetails implementations by type name, such as "DatabaseDetails"
+// Reports and caches record the type name next to the encoded details to decode them back into the right type.
+var serviceDetailsTypes = registerServiceDetails(
+	ComputeDetails{},
+	DatabaseDetails{},
+	CacheDetails{},
+	SearchDetails{},
+	DataWarehouseDetails{},
+	DynamoDBDetails{},
+	SavingsPlanDetails{},
+)
+
+func registerServiceDetails(details ...ServiceDetails) map[string]reflect.Type {
+	types := make(map[string]reflect.Type, len(details))
+	for _, d := range details {
+		t := reflect.TypeOf(d)
+		types[t.Name()] = t
+	}
+	return types
+}
+
+// ServiceDetailsTypeName returns the registered type name of service details, for value and pointer details alike
+func ServiceDetailsTypeName(details ServiceDetails) (string, error) {
+	t := reflect.TypeOf(details)
+	if t == nil {
+		return "", fmt.Errorf("service details are nil")
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if registered, ok := serviceDetailsTypes[t.Name()]; ok && registered == t {
+		return t.Name(), nil
+	}
+	return "", fmt.Errorf("unsupported details type %T", details)
+}
+
+// NewServiceDetails returns a pointer to empty service details of a registered type name, to decode details into
+func NewServiceDetails(typeName string) (ServiceDetails, error) {
+	t, ok := serviceDetailsTypes[typeName]
+	if !ok {
+		return nil, fmt.Errorf("unknown details type %q", typeName)
+	}
+	return reflect.New(t).Interface().(ServiceDetails), nil
+}
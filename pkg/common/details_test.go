@@ -0,0 +1,59 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// otherDetails is a ServiceDetails implementation missing from the registry
+type otherDetails struct{}
+
+func (otherDetails) GetServiceType() ServiceType  { return ServiceEC2 }
+func (otherDetails) GetDetailDescription() string { return "" }
+
+func TestServiceDetailsRegistryRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		details ServiceDetails
+	}{
+		{"ComputeDetails", &ComputeDetails{InstanceType: "m5.large", Platform: "Linux/UNIX", Tenancy: "default", Scope: "region"}},
+		{"DatabaseDetails", &DatabaseDetails{Engine: "postgresql", AZConfig: "multi-az"}},
+		{"CacheDetails", &CacheDetails{Engine: "redis", NodeType: "cache.r6g.large"}},
+		{"SearchDetails", &SearchDetails{InstanceType: "r6g.large.search"}},
+		{"DataWarehouseDetails", &DataWarehouseDetails{NodeType: "ra3.xlplus", NumberOfNodes: 2}},
+		{"DynamoDBDetails", &DynamoDBDetails{ReadCapacity: 100, WriteCapacity: 50}},
+		{"SavingsPlanDetails", &SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typeName, err := ServiceDetailsTypeName(tt.details)
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, typeName)
+
+			data, err := json.Marshal(tt.details)
+			require.NoError(t, err)
+			decoded, err := NewServiceDetails(typeName)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(data, decoded))
+			assert.Equal(t, tt.details, decoded)
+		})
+	}
+	assert.Len(t, serviceDetailsTypes, len(tests), "every registered type is covered")
+}
+
+func TestServiceDetailsTypeName(t *testing.T) {
+	name, err := ServiceDetailsTypeName(DynamoDBDetails{})
+	require.NoError(t, err)
+	assert.Equal(t, "DynamoDBDetails", name, "value details have the same name as pointers")
+
+	_, err = ServiceDetailsTypeName(otherDetails{})
+	assert.Error(t, err)
+	_, err = ServiceDetailsTypeName(nil)
+	assert.Error(t, err)
+	_, err = NewServiceDetails("QueueDetails")
+	assert.Error(t, err)
+}
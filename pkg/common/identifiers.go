@@ -1,11 +1,73 @@
 package common
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// RecommendationIdempotencyToken returns a deterministic token identifying the purchase of a recommendation,
+// derived from its service, region, resource type, count, term, payment option, account and the service details
+// telling apart purchases of the same shape, such as the engine or the Savings Plan hourly commitment. Re-running
+// the same purchase yields the same token, so purchase APIs accepting one can reject or deduplicate the retry.
+func RecommendationIdempotencyToken(rec Recommendation) string {
+	fields := []string{
+		string(rec.Service), rec.Region, rec.ResourceType, strconv.Itoa(rec.Count), rec.Term, rec.PaymentOption, rec.Account,
+	}
+	sum := sha256.Sum256([]byte(strings.Join(append(fields, purchaseVariant(rec.Details)...), "|")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// DailyIdempotencyToken returns the RecommendationIdempotencyToken of a recommendation scoped to the UTC day of now,
+// for purchase APIs that deduplicate client tokens indefinitely. A retry on the same day is deduplicated while an
+// identical purchase on a later run is not.
+func DailyIdempotencyToken(rec Recommendation, now time.Time) string {
+	sum := sha256.Sum256([]byte(now.UTC().Format("20060102") + "|" + RecommendationIdempotencyToken(rec)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// purchaseVariant returns the service details that make purchases of the same service, region, resource type
+// and count different purchases
+func purchaseVariant(details ServiceDetails) []string {
+	switch d := details.(type) {
+	case *DatabaseDetails:
+		if d != nil {
+			return []string{d.Engine, d.AZConfig, d.Deployment}
+		}
+	case *CacheDetails:
+		if d != nil {
+			return []string{d.Engine}
+		}
+	case *ComputeDetails:
+		if d != nil {
+			return []string{d.Platform, d.Tenancy, d.Scope, d.AvailabilityZone, d.OfferingClass}
+		}
+	case *SavingsPlanDetails:
+		if d != nil {
+			return []string{d.PlanType, strconv.FormatFloat(d.HourlyCommitment, 'f', 3, 64), d.Region, d.InstanceFamily}
+		}
+	case DatabaseDetails:
+		return purchaseVariant(&d)
+	case CacheDetails:
+		return purchaseVariant(&d)
+	case ComputeDetails:
+		return purchaseVariant(&d)
+	case SavingsPlanDetails:
+		return purchaseVariant(&d)
+	}
+	return nil
+}
+
+// IdempotentReservationID returns a reservation ID for the purchase of a recommendation that is the same for
+// every attempt on the same UTC day. The day keeps an identical purchase on a later run from being rejected.
+func IdempotentReservationID(prefix string, rec Recommendation, now time.Time) string {
+	id := fmt.Sprintf("%s-%s-%s", prefix, now.UTC().Format("20060102"), RecommendationIdempotencyToken(rec)[:16])
+	return SanitizeReservationID(id, prefix+"-reserved-")
+}
+
 // SanitizeReservationID returns an identifier safe for AWS reservation/reserved-instance
 // ID or name fields: only ASCII letters, digits, and hyphens; no leading/trailing
 // hyphen; no consecutive hyphens. Dots are replaced with hyphens. If the result
@@ -29,3 +91,31 @@ func SanitizeReservationID(id, fallbackPrefix string) string {
 	}
 	return s
 }
+
+// AccountRole is an AWS account together with the IAM role assumed to operate in it
+type AccountRole struct {
+	AccountID string
+	RoleARN   string
+}
+
+// ParseAccountRole parses an "<account-id>:<role-arn>" entry, such as
+// "123456789012:arn:aws:iam::123456789012:role/CUDlyRole". The role must belong to the account.
+func ParseAccountRole(entry string) (AccountRole, error) {
+	accountID, roleARN, ok := strings.Cut(strings.TrimSpace(entry), ":")
+	if !ok || roleARN == "" {
+		return AccountRole{}, fmt.Errorf("invalid account role %q, expected <account-id>:<role-arn>", entry)
+	}
+	if len(accountID) != 12 || strings.Trim(accountID, "0123456789") != "" {
+		return AccountRole{}, fmt.Errorf("invalid account ID %q in account role %q, expected 12 digits", accountID, entry)
+	}
+
+	// arn:<partition>:iam::<account-id>:role/<name>
+	parts := strings.SplitN(roleARN, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "iam" || !strings.HasPrefix(parts[5], "role/") || len(parts[5]) == len("role/") {
+		return AccountRole{}, fmt.Errorf("invalid role ARN %q in account role %q", roleARN, entry)
+	}
+	if parts[4] != accountID {
+		return AccountRole{}, fmt.Errorf("role ARN %q does not belong to account %s", roleARN, accountID)
+	}
+	return AccountRole{AccountID: accountID, RoleARN: roleARN}, nil
+}
@@ -0,0 +1,134 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Log output formats
+const (
+	// LogFormatText writes log messages as they are, for people reading the terminal (default)
+	LogFormatText = "text"
+	// LogFormatJSON writes one JSON record per log message, for log aggregation
+	LogFormatJSON = "json"
+)
+
+// ParseLogLevel parses a log level name: debug, info, warn or error
+func ParseLogLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q, must be debug, info, warn or error", name)
+	}
+}
+
+// NewLogger creates a leveled logger writing to w in the given format
+// The text format prints each message unchanged on its own line; the JSON format adds the time and level.
+func NewLogger(w io.Writer, level slog.Level, format string) (*slog.Logger, error) {
+	switch format {
+	case "", LogFormatText:
+		return slog.New(&messageHandler{w: w, level: level, mu: &sync.Mutex{}}), nil
+	case LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q, must be text or json", format)
+	}
+}
+
+// messageHandler is a slog handler that writes the bare message, followed by its attributes if any
+type messageHandler struct {
+	w     io.Writer
+	level slog.Level
+	attrs []slog.Attr
+	mu    *sync.Mutex
+}
+
+func (h *messageHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *messageHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Message)
+	appendAttr := func(a slog.Attr) bool {
+		fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+		return true
+	}
+	for _, a := range h.attrs {
+		appendAttr(a)
+	}
+	r.Attrs(appendAttr)
+	b.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *messageHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup is not supported, the attributes of groups are written without qualification
+func (h *messageHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// logTimestamp matches the date and time the standard logger prefixes messages with
+var logTimestamp = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} `)
+
+// InferLogLevel derives the level of a free-form log message from its markers
+// Messages flagged with ❌ or starting with "Error" are errors, those flagged with ⚠️ or a "Warning" are warnings,
+// and everything else is informational.
+func InferLogLevel(msg string) slog.Level {
+	text := strings.TrimSpace(logTimestamp.ReplaceAllString(msg, ""))
+	lower := strings.ToLower(text)
+	switch {
+	case strings.Contains(text, "❌") || strings.HasPrefix(lower, "error"):
+		return slog.LevelError
+	case strings.Contains(text, "⚠") || strings.Contains(lower, "warning:"):
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// LevelWriter adapts a leveled logger to an io.Writer, so that a *log.Logger can be routed through it
+// Each write is logged as one message at the level inferred by InferLogLevel.
+type LevelWriter struct {
+	logger *slog.Logger
+	// trim strips surrounding whitespace and drops blank messages, for structured formats
+	trim bool
+}
+
+// NewLevelWriter creates a writer logging through logger; with trim set, blank lines and padding are dropped
+func NewLevelWriter(logger *slog.Logger, trim bool) *LevelWriter {
+	return &LevelWriter{logger: logger, trim: trim}
+}
+
+func (w *LevelWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	if w.trim {
+		msg = strings.TrimSpace(msg)
+		if msg == "" {
+			return len(p), nil
+		}
+	}
+	w.logger.Log(context.Background(), InferLogLevel(msg), msg)
+	return len(p), nil
+}
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{input: "debug", want: slog.LevelDebug},
+		{input: "", want: slog.LevelInfo},
+		{input: "INFO", want: slog.LevelInfo},
+		{input: "warn", want: slog.LevelWarn},
+		{input: "warning", want: slog.LevelWarn},
+		{input: "error", want: slog.LevelError},
+		{input: "verbose", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLogLevel(tt.input)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "invalid log level")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInferLogLevel(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want slog.Level
+	}{
+		{msg: "📊 Processing services: RDS", want: slog.LevelInfo},
+		{msg: "  ⚠️  Warning: Could not check for existing RIs", want: slog.LevelWarn},
+		{msg: "Warning: Failed to write CSV output", want: slog.LevelWarn},
+		{msg: "  ❌ Failed to fetch recommendations", want: slog.LevelError},
+		{msg: "2026/01/02 15:04:05 Error: no valid services specified", want: slog.LevelError},
+		{msg: "\n━━━━━━━━", want: slog.LevelInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			assert.Equal(t, tt.want, InferLogLevel(tt.msg))
+		})
+	}
+}
+
+func TestNewLoggerInvalidFormat(t *testing.T) {
+	_, err := NewLogger(&bytes.Buffer{}, slog.LevelInfo, "xml")
+	assert.ErrorContains(t, err, "invalid log format")
+}
+
+func TestLevelWriterText(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, slog.LevelWarn, LogFormatText)
+	require.NoError(t, err)
+	l := log.New(NewLevelWriter(logger, false), "", 0)
+
+	l.Printf("📊 Processing services: RDS\n")
+	l.Printf("⚠️  Warning: Could not check\n")
+	l.Printf("\n━━━━\n")
+
+	assert.Equal(t, "⚠️  Warning: Could not check\n", buf.String())
+}
+
+func TestLevelWriterJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, slog.LevelInfo, LogFormatJSON)
+	require.NoError(t, err)
+	l := log.New(NewLevelWriter(logger, true), "", 0)
+
+	l.Printf("\n")
+	l.Printf("  ❌ Failed to fetch recommendations: %s\n", "throttled")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record), "blank messages must be dropped, leaving one record")
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "❌ Failed to fetch recommendations: throttled", record["msg"])
+	assert.Contains(t, record, "time")
+}
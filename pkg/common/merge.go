@@ -0,0 +1,67 @@
+package common
+
+import "strings"
+
+// recommendationMergeKey identifies the recommendations that describe the same purchase
+// Database and cache details contribute their engine, and database details their AZ configuration, so that
+// single-AZ and multi-AZ recommendations are never merged. Compute details contribute their availability zone,
+// so that zonal reservations in different zones stay apart. Savings Plans are never merged.
+func recommendationMergeKey(rec Recommendation) (string, bool) {
+	var details string
+	switch d := rec.Details.(type) {
+	case SavingsPlanDetails, *SavingsPlanDetails:
+		return "", false
+	case DatabaseDetails:
+		details = d.Engine + "|" + d.AZConfig
+	case *DatabaseDetails:
+		details = d.Engine + "|" + d.AZConfig
+	case CacheDetails:
+		details = d.Engine
+	case *CacheDetails:
+		details = d.Engine
+	case ComputeDetails:
+		details = d.GetDetailDescription() + "|" + d.AvailabilityZone
+	case *ComputeDetails:
+		details = d.GetDetailDescription() + "|" + d.AvailabilityZone
+	case nil:
+	default:
+		details = d.GetDetailDescription()
+	}
+	return strings.Join([]string{
+		string(rec.Provider), rec.Account, string(rec.Service), rec.Region, rec.ResourceType,
+		string(rec.CommitmentType), rec.Term, rec.PaymentOption, details,
+	}, "|"), true
+}
+
+// MergeDuplicateRecommendations merges recommendations for the same service, region, resource type, engine and
+// AZ configuration (and account, term and payment option) into one, summing their counts, savings and costs
+// The first recommendation of each group keeps its position and remaining fields; the input is not modified.
+func MergeDuplicateRecommendations(recs []Recommendation) []Recommendation {
+	merged := make([]Recommendation, 0, len(recs))
+	index := make(map[string]int, len(recs))
+	for _, rec := range recs {
+		key, ok := recommendationMergeKey(rec)
+		if !ok {
+			merged = append(merged, rec)
+			continue
+		}
+		i, seen := index[key]
+		if !seen {
+			index[key] = len(merged)
+			merged = append(merged, rec)
+			continue
+		}
+
+		m := &merged[i]
+		m.Count += rec.Count
+		m.EstimatedSavings += rec.EstimatedSavings
+		m.OnDemandCost += rec.OnDemandCost
+		m.CommitmentCost += rec.CommitmentCost
+		m.UpfrontCost += rec.UpfrontCost
+		m.AmortizedMonthlyCost += rec.AmortizedMonthlyCost
+		if m.OnDemandCost > 0 {
+			m.SavingsPercentage = m.EstimatedSavings / m.OnDemandCost * 100
+		}
+	}
+	return merged
+}
@@ -0,0 +1,114 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeDuplicateRecommendations(t *testing.T) {
+	rds := func(count int, savings float64, engine, azConfig string) Recommendation {
+		return Recommendation{
+			Service:          ServiceRDS,
+			Region:           "us-east-1",
+			ResourceType:     "db.r5.large",
+			Count:            count,
+			Term:             "3yr",
+			PaymentOption:    PaymentPartialUpfront,
+			EstimatedSavings: savings,
+			OnDemandCost:     savings * 2,
+			UpfrontCost:      100 * float64(count),
+			Details:          &DatabaseDetails{Engine: engine, AZConfig: azConfig},
+		}
+	}
+
+	tests := []struct {
+		name string
+		recs []Recommendation
+		want []Recommendation
+	}{
+		{
+			name: "identical recommendations are summed",
+			recs: []Recommendation{rds(2, 50, "mysql", "single-az"), rds(3, 70, "mysql", "single-az")},
+			want: []Recommendation{{
+				Service: ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 5, Term: "3yr", PaymentOption: PaymentPartialUpfront,
+				EstimatedSavings: 120, OnDemandCost: 240, UpfrontCost: 500, SavingsPercentage: 50,
+				Details: &DatabaseDetails{Engine: "mysql", AZConfig: "single-az"},
+			}},
+		},
+		{
+			name: "mismatched AZ configs are not merged",
+			recs: []Recommendation{rds(2, 50, "mysql", "single-az"), rds(3, 70, "mysql", "multi-az")},
+			want: []Recommendation{rds(2, 50, "mysql", "single-az"), rds(3, 70, "mysql", "multi-az")},
+		},
+		{
+			name: "different engines are not merged",
+			recs: []Recommendation{rds(2, 50, "mysql", "single-az"), rds(3, 70, "postgres", "single-az")},
+			want: []Recommendation{rds(2, 50, "mysql", "single-az"), rds(3, 70, "postgres", "single-az")},
+		},
+		{
+			name: "different regions are not merged and order is kept",
+			recs: []Recommendation{
+				rds(1, 10, "mysql", "single-az"),
+				func() Recommendation { r := rds(4, 40, "mysql", "single-az"); r.Region = "eu-west-1"; return r }(),
+				rds(1, 10, "mysql", "single-az"),
+			},
+			want: []Recommendation{
+				{
+					Service: ServiceRDS, Region: "us-east-1", ResourceType: "db.r5.large", Count: 2, Term: "3yr", PaymentOption: PaymentPartialUpfront,
+					EstimatedSavings: 20, OnDemandCost: 40, UpfrontCost: 200, SavingsPercentage: 50,
+					Details: &DatabaseDetails{Engine: "mysql", AZConfig: "single-az"},
+				},
+				func() Recommendation { r := rds(4, 40, "mysql", "single-az"); r.Region = "eu-west-1"; return r }(),
+			},
+		},
+		{
+			name: "cache engines are compared",
+			recs: []Recommendation{
+				{Service: ServiceElastiCache, Region: "us-east-1", ResourceType: "cache.r6g.large", Count: 1, Details: CacheDetails{Engine: "redis"}},
+				{Service: ServiceElastiCache, Region: "us-east-1", ResourceType: "cache.r6g.large", Count: 1, Details: CacheDetails{Engine: "memcached"}},
+				{Service: ServiceElastiCache, Region: "us-east-1", ResourceType: "cache.r6g.large", Count: 2, Details: CacheDetails{Engine: "redis"}},
+			},
+			want: []Recommendation{
+				{Service: ServiceElastiCache, Region: "us-east-1", ResourceType: "cache.r6g.large", Count: 3, Details: CacheDetails{Engine: "redis"}},
+				{Service: ServiceElastiCache, Region: "us-east-1", ResourceType: "cache.r6g.large", Count: 1, Details: CacheDetails{Engine: "memcached"}},
+			},
+		},
+		{
+			name: "Savings Plans are never merged",
+			recs: []Recommendation{
+				{Service: ServiceSavingsPlans, Region: "us-east-1", ResourceType: "Compute", Count: 1, Details: &SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1}},
+				{Service: ServiceSavingsPlans, Region: "us-east-1", ResourceType: "Compute", Count: 1, Details: &SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 2}},
+			},
+			want: []Recommendation{
+				{Service: ServiceSavingsPlans, Region: "us-east-1", ResourceType: "Compute", Count: 1, Details: &SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1}},
+				{Service: ServiceSavingsPlans, Region: "us-east-1", ResourceType: "Compute", Count: 1, Details: &SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 2}},
+			},
+		},
+		{
+			name: "empty input",
+			recs: nil,
+			want: []Recommendation{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MergeDuplicateRecommendations(tt.recs))
+		})
+	}
+}
+
+func TestMergeDuplicateRecommendationsKeepsInput(t *testing.T) {
+	recs := []Recommendation{
+		{Service: ServiceEC2, Region: "us-east-1", ResourceType: "m5.large", Count: 1, Details: &ComputeDetails{Platform: "linux", Tenancy: "default"}},
+		{Service: ServiceEC2, Region: "us-east-1", ResourceType: "m5.large", Count: 2, Details: &ComputeDetails{Platform: "linux", Tenancy: "default"}},
+		{Service: ServiceEC2, Region: "us-east-1", ResourceType: "m5.large", Count: 4, Details: &ComputeDetails{Platform: "windows", Tenancy: "default"}},
+	}
+
+	merged := MergeDuplicateRecommendations(recs)
+	assert.Len(t, merged, 2)
+	assert.Equal(t, 3, merged[0].Count)
+	assert.Equal(t, 4, merged[1].Count)
+	assert.Equal(t, 1, recs[0].Count, "the input must not be modified")
+}
@@ -0,0 +1,72 @@
+package common
+
+import "slices"
+
+// Payment options of reserved capacity purchases
+const (
+	PaymentNoUpfront      = "no-upfront"
+	PaymentPartialUpfront = "partial-upfront"
+	PaymentAllUpfront     = "all-upfront"
+)
+
+// paymentOptionsByUpfront lists the payment options from the smallest to the largest upfront payment
+var paymentOptionsByUpfront = []string{PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront}
+
+// SupportedPaymentOptions maps each service and term ("1yr", "3yr") to the payment options AWS offers for it
+// Services missing from the table are assumed to support every payment option.
+var SupportedPaymentOptions = map[ServiceType]map[string][]string{
+	ServiceEC2: {
+		"1yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+		"3yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+	},
+	ServiceRDS: {
+		"1yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+		"3yr": {PaymentPartialUpfront, PaymentAllUpfront},
+	},
+	ServiceElastiCache: {
+		"1yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+		"3yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+	},
+	ServiceOpenSearch: {
+		"1yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+		"3yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+	},
+	ServiceRedshift: {
+		"1yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+		"3yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+	},
+	ServiceMemoryDB: {
+		"1yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+		"3yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+	},
+	ServiceSavingsPlans: {
+		"1yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+		"3yr": {PaymentNoUpfront, PaymentPartialUpfront, PaymentAllUpfront},
+	},
+}
+
+// IsPaymentOptionSupported reports whether a service offers the payment option for the term
+func IsPaymentOptionSupported(service ServiceType, term, option string) bool {
+	supported, ok := SupportedPaymentOptions[service][term]
+	return !ok || slices.Contains(supported, option)
+}
+
+// NearestSupportedPaymentOption returns the payment option itself when the service supports it for the term,
+// otherwise the supported option with the closest upfront payment, preferring the smaller upfront payment on ties.
+func NearestSupportedPaymentOption(service ServiceType, term, option string) string {
+	if IsPaymentOptionSupported(service, term, option) {
+		return option
+	}
+	requested := slices.Index(paymentOptionsByUpfront, option)
+	if requested < 0 {
+		return option
+	}
+	for distance := 1; distance < len(paymentOptionsByUpfront); distance++ {
+		for _, i := range []int{requested - distance, requested + distance} {
+			if i >= 0 && i < len(paymentOptionsByUpfront) && IsPaymentOptionSupported(service, term, paymentOptionsByUpfront[i]) {
+				return paymentOptionsByUpfront[i]
+			}
+		}
+	}
+	return option
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNearestSupportedPaymentOption(t *testing.T) {
+	tests := []struct {
+		name    string
+		service ServiceType
+		term    string
+		option  string
+		want    string
+	}{
+		{name: "RDS 3yr no-upfront falls back to partial-upfront", service: ServiceRDS, term: "3yr", option: PaymentNoUpfront, want: PaymentPartialUpfront},
+		{name: "RDS 3yr partial-upfront is supported", service: ServiceRDS, term: "3yr", option: PaymentPartialUpfront, want: PaymentPartialUpfront},
+		{name: "RDS 1yr no-upfront is supported", service: ServiceRDS, term: "1yr", option: PaymentNoUpfront, want: PaymentNoUpfront},
+		{name: "EC2 3yr no-upfront is supported", service: ServiceEC2, term: "3yr", option: PaymentNoUpfront, want: PaymentNoUpfront},
+		{name: "service missing from the table supports everything", service: ServiceDynamoDB, term: "3yr", option: PaymentNoUpfront, want: PaymentNoUpfront},
+		{name: "unknown option is returned unchanged", service: ServiceRDS, term: "3yr", option: "monthly", want: "monthly"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NearestSupportedPaymentOption(tt.service, tt.term, tt.option))
+		})
+	}
+}
+
+func TestNearestSupportedPaymentOptionPrefersSmallerUpfront(t *testing.T) {
+	original := SupportedPaymentOptions[ServiceRedshift]
+	t.Cleanup(func() { SupportedPaymentOptions[ServiceRedshift] = original })
+	SupportedPaymentOptions[ServiceRedshift] = map[string][]string{"1yr": {PaymentNoUpfront, PaymentAllUpfront}}
+
+	assert.False(t, IsPaymentOptionSupported(ServiceRedshift, "1yr", PaymentPartialUpfront))
+	assert.Equal(t, PaymentNoUpfront, NearestSupportedPaymentOption(ServiceRedshift, "1yr", PaymentPartialUpfront))
+}
@@ -0,0 +1,69 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RegionSetAllOptedIn names the set of every region enabled for the account, which is looked up at run time
+const RegionSetAllOptedIn = "all-opted-in"
+
+// RegionSets maps the names accepted by --region-set to their AWS regions
+var RegionSets = map[string][]string{
+	"us": {"us-east-1", "us-east-2", "us-west-1", "us-west-2"},
+	"eu": {"eu-central-1", "eu-central-2", "eu-north-1", "eu-south-1", "eu-south-2", "eu-west-1", "eu-west-2", "eu-west-3"},
+	"apac": {"ap-east-1", "ap-northeast-1", "ap-northeast-2", "ap-northeast-3", "ap-south-1", "ap-south-2",
+		"ap-southeast-1", "ap-southeast-2", "ap-southeast-3", "ap-southeast-4", "ap-southeast-5"},
+}
+
+// RegionSetNames returns the accepted region set names, sorted
+func RegionSetNames() []string {
+	names := make([]string, 0, len(RegionSets)+1)
+	for name := range RegionSets {
+		names = append(names, name)
+	}
+	names = append(names, RegionSetAllOptedIn)
+	sort.Strings(names)
+	return names
+}
+
+// ValidateRegionSet checks that name is a known region set
+func ValidateRegionSet(name string) error {
+	if _, ok := RegionSets[name]; ok || name == RegionSetAllOptedIn {
+		return nil
+	}
+	return fmt.Errorf("unknown region set %q, must be one of: %s", name, strings.Join(RegionSetNames(), ", "))
+}
+
+// ExpandRegionSets returns the explicit regions followed by the regions of the named sets, without duplicates
+// optedIn lists the regions enabled for the account and is only called for the all-opted-in set.
+func ExpandRegionSets(regions, sets []string, optedIn func() ([]string, error)) ([]string, error) {
+	result := make([]string, 0, len(regions))
+	seen := make(map[string]bool)
+	add := func(list []string) {
+		for _, region := range list {
+			if !seen[region] {
+				seen[region] = true
+				result = append(result, region)
+			}
+		}
+	}
+
+	add(regions)
+	for _, name := range sets {
+		if err := ValidateRegionSet(name); err != nil {
+			return nil, err
+		}
+		if name != RegionSetAllOptedIn {
+			add(RegionSets[name])
+			continue
+		}
+		enabled, err := optedIn()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the opted-in regions: %w", err)
+		}
+		add(enabled)
+	}
+	return result, nil
+}
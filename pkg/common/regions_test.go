@@ -0,0 +1,63 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandRegionSets(t *testing.T) {
+	optedIn := func() ([]string, error) { return []string{"us-east-1", "eu-west-1", "me-south-1"}, nil }
+
+	tests := []struct {
+		name    string
+		regions []string
+		sets    []string
+		optedIn func() ([]string, error)
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "named set",
+			sets: []string{"us"},
+			want: []string{"us-east-1", "us-east-2", "us-west-1", "us-west-2"},
+		},
+		{
+			name:    "explicit regions come first and are not repeated",
+			regions: []string{"ca-central-1", "us-west-2"},
+			sets:    []string{"us"},
+			want:    []string{"ca-central-1", "us-west-2", "us-east-1", "us-east-2", "us-west-1"},
+		},
+		{
+			name:    "all opted-in regions",
+			sets:    []string{"eu", RegionSetAllOptedIn},
+			optedIn: optedIn,
+			want:    append(append([]string{}, RegionSets["eu"]...), "us-east-1", "me-south-1"),
+		},
+		{
+			name:    "opted-in lookup failure",
+			sets:    []string{RegionSetAllOptedIn},
+			optedIn: func() ([]string, error) { return nil, errors.New("access denied") },
+			wantErr: "failed to list the opted-in regions: access denied",
+		},
+		{
+			name:    "unknown set",
+			sets:    []string{"emea"},
+			wantErr: `unknown region set "emea", must be one of: all-opted-in, apac, eu, us`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandRegionSets(tt.regions, tt.sets, tt.optedIn)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
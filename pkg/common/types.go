@@ -2,6 +2,7 @@
 package common
 
 import (
+	"strconv"
 	"time"
 )
 
@@ -58,6 +59,7 @@ const (
 	ServiceElasticsearch ServiceType = "opensearch" // Alias for ServiceOpenSearch (AWS rebranded)
 	ServiceRedshift      ServiceType = "redshift"
 	ServiceMemoryDB      ServiceType = "memorydb"
+	ServiceDynamoDB      ServiceType = "dynamodb"
 )
 
 // String returns the string representation of the service type
@@ -101,10 +103,12 @@ type Recommendation struct {
 	PaymentOption  string         `json:"payment_option" csv:"PaymentOption"`   // all-upfront, partial, no-upfront, monthly
 
 	// Cost information
-	OnDemandCost      float64 `json:"on_demand_cost" csv:"OnDemandCost"`
-	CommitmentCost    float64 `json:"commitment_cost" csv:"CommitmentCost"`
-	EstimatedSavings  float64 `json:"estimated_savings" csv:"EstimatedSavings"`
-	SavingsPercentage float64 `json:"savings_percentage" csv:"SavingsPercentage"`
+	OnDemandCost         float64 `json:"on_demand_cost" csv:"OnDemandCost"`
+	CommitmentCost       float64 `json:"commitment_cost" csv:"CommitmentCost"`
+	UpfrontCost          float64 `json:"upfront_cost,omitempty" csv:"UpfrontCost"`                    // Cash paid at purchase time
+	AmortizedMonthlyCost float64 `json:"amortized_monthly_cost,omitempty" csv:"AmortizedMonthlyCost"` // Upfront cost spread over the term plus recurring charges
+	EstimatedSavings     float64 `json:"estimated_savings" csv:"EstimatedSavings"`
+	SavingsPercentage    float64 `json:"savings_percentage" csv:"SavingsPercentage"`
 
 	// Service-specific details (polymorphic)
 	Details ServiceDetails `json:"details,omitempty" csv:"-"`
@@ -129,6 +133,36 @@ type PurchaseResult struct {
 	Cost           float64        `json:"cost"`
 	DryRun         bool           `json:"dry_run"`
 	Timestamp      time.Time      `json:"timestamp"`
+
+	// AccountID is the account the purchase was made in when running across accounts with assumed roles
+	AccountID string `json:"account_id,omitempty"`
+
+	// IdempotencyToken is the client token sent with the purchase, set when the purchase API accepts one
+	IdempotencyToken string `json:"idempotency_token,omitempty"`
+
+	// Request and Response are the provider's purchase API input and output, kept for purchase receipts
+	Request  any `json:"-"`
+	Response any `json:"-"`
+
+	// Fulfillment information, set once the provider has reported how many instances were purchased
+	RequestedCount int  `json:"requested_count,omitempty"`
+	PurchasedCount int  `json:"purchased_count,omitempty"`
+	Partial        bool `json:"partial,omitempty"`
+}
+
+// RecordFulfillment records how many of the recommended instances were actually purchased
+func (r *PurchaseResult) RecordFulfillment(purchased int) {
+	r.RequestedCount = r.Recommendation.Count
+	r.PurchasedCount = purchased
+	r.Partial = purchased < r.RequestedCount
+}
+
+// Shortfall returns the number of requested instances that were not purchased
+func (r PurchaseResult) Shortfall() int {
+	if !r.Partial {
+		return 0
+	}
+	return r.RequestedCount - r.PurchasedCount
 }
 
 // Commitment represents an existing commitment (RI/SP/CUD/etc)
@@ -159,6 +193,8 @@ type OfferingDetails struct {
 	TotalCost           float64 `json:"total_cost"`
 	EffectiveHourlyRate float64 `json:"effective_hourly_rate"`
 	Currency            string  `json:"currency"`
+	// Marketplace is set when the offering is a third-party Reserved Instance Marketplace listing
+	Marketplace bool `json:"marketplace,omitempty"`
 }
 
 // RecommendationParams represents parameters for fetching recommendations
@@ -174,6 +210,11 @@ type RecommendationParams struct {
 	// Savings Plans specific filters
 	IncludeSPTypes []string // Compute, EC2Instance, SageMaker, Database
 	ExcludeSPTypes []string
+	// EC2OfferingClass is the EC2 Reserved Instance offering class to recommend: standard or convertible
+	EC2OfferingClass string
+	// AccountID scopes the recommendations to a single linked account at the API level
+	// Omitted from JSON when empty, so the cache keys of unscoped requests stay unchanged.
+	AccountID string `json:",omitempty"`
 }
 
 // Account represents a cloud account/subscription/project
@@ -193,12 +234,22 @@ type Region struct {
 	DisplayName string       `json:"display_name"`
 }
 
+// EC2 Reserved Instance scopes, as set in ComputeDetails.Scope
+const (
+	EC2ScopeRegion           = "region"
+	EC2ScopeAvailabilityZone = "availability-zone"
+)
+
 // ComputeDetails represents compute-specific details (EC2, VM, Compute Engine)
 type ComputeDetails struct {
 	InstanceType string `json:"instance_type"`
 	Platform     string `json:"platform"` // linux, windows
 	Tenancy      string `json:"tenancy"`  // default, dedicated, host
 	Scope        string `json:"scope"`    // regional, zonal
+	// AvailabilityZone is the zone of an availability-zone scoped EC2 Reserved Instance
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+	// OfferingClass is the EC2 Reserved Instance offering class: standard or convertible
+	OfferingClass string `json:"offering_class,omitempty"`
 }
 
 func (d ComputeDetails) GetServiceType() ServiceType {
@@ -271,11 +322,34 @@ func (d DataWarehouseDetails) GetDetailDescription() string {
 	return d.NodeType
 }
 
+// Resource types of DynamoDB reserved capacity recommendations
+const (
+	DynamoDBReadCapacity  = "read-capacity"
+	DynamoDBWriteCapacity = "write-capacity"
+)
+
+// DynamoDBDetails for DynamoDB reserved capacity (read/write capacity units)
+type DynamoDBDetails struct {
+	ReadCapacity  int `json:"read_capacity,omitempty"`
+	WriteCapacity int `json:"write_capacity,omitempty"`
+}
+
+func (d DynamoDBDetails) GetServiceType() ServiceType {
+	return ServiceNoSQL
+}
+
+func (d DynamoDBDetails) GetDetailDescription() string {
+	return strconv.Itoa(d.ReadCapacity) + " RCU/" + strconv.Itoa(d.WriteCapacity) + " WCU"
+}
+
 // SavingsPlanDetails represents AWS Savings Plans specific details
 type SavingsPlanDetails struct {
 	PlanType         string  `json:"plan_type"`        // Compute, EC2Instance, SageMaker
 	HourlyCommitment float64 `json:"hourly_commitment"`
 	Coverage         string  `json:"coverage,omitempty"`
+	// Region and InstanceFamily scope EC2Instance plans, which only apply to one instance family in one region
+	Region         string `json:"region,omitempty"`
+	InstanceFamily string `json:"instance_family,omitempty"`
 }
 
 func (d SavingsPlanDetails) GetServiceType() ServiceType {
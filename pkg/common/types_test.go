@@ -2,6 +2,7 @@ package common
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -205,6 +206,21 @@ func TestDataWarehouseDetails_GetDetailDescription(t *testing.T) {
 	assert.Equal(t, "dc2.large", details.GetDetailDescription())
 }
 
+func TestDynamoDBDetails_GetServiceType(t *testing.T) {
+	details := DynamoDBDetails{ReadCapacity: 100}
+
+	assert.Equal(t, ServiceNoSQL, details.GetServiceType())
+}
+
+func TestDynamoDBDetails_GetDetailDescription(t *testing.T) {
+	details := DynamoDBDetails{
+		ReadCapacity:  200,
+		WriteCapacity: 100,
+	}
+
+	assert.Equal(t, "200 RCU/100 WCU", details.GetDetailDescription())
+}
+
 func TestSavingsPlanDetails_GetServiceType(t *testing.T) {
 	details := SavingsPlanDetails{
 		PlanType:         "Compute",
@@ -340,3 +356,150 @@ func TestRegion_Struct(t *testing.T) {
 	assert.Equal(t, "us-east-1", region.ID)
 	assert.Equal(t, "US East (N. Virginia)", region.DisplayName)
 }
+
+func TestPurchaseResult_RecordFulfillment(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested int
+		purchased int
+		partial   bool
+		shortfall int
+	}{
+		{name: "fully fulfilled", requested: 10, purchased: 10},
+		{name: "partially fulfilled", requested: 10, purchased: 7, partial: true, shortfall: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := PurchaseResult{Recommendation: Recommendation{Count: tt.requested}, Success: true}
+			result.RecordFulfillment(tt.purchased)
+
+			assert.Equal(t, tt.requested, result.RequestedCount)
+			assert.Equal(t, tt.purchased, result.PurchasedCount)
+			assert.Equal(t, tt.partial, result.Partial)
+			assert.Equal(t, tt.shortfall, result.Shortfall())
+		})
+	}
+}
+
+func TestParseAccountRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   string
+		want    AccountRole
+		wantErr string
+	}{
+		{
+			name:  "valid entry",
+			entry: "123456789012:arn:aws:iam::123456789012:role/CUDlyRole",
+			want:  AccountRole{AccountID: "123456789012", RoleARN: "arn:aws:iam::123456789012:role/CUDlyRole"},
+		},
+		{
+			name:  "role path in another partition",
+			entry: " 123456789012:arn:aws-us-gov:iam::123456789012:role/ops/CUDlyRole ",
+			want:  AccountRole{AccountID: "123456789012", RoleARN: "arn:aws-us-gov:iam::123456789012:role/ops/CUDlyRole"},
+		},
+		{name: "missing role", entry: "123456789012", wantErr: "expected <account-id>:<role-arn>"},
+		{name: "short account ID", entry: "12345:arn:aws:iam::12345:role/CUDlyRole", wantErr: "expected 12 digits"},
+		{name: "not a role ARN", entry: "123456789012:arn:aws:iam::123456789012:user/cudly", wantErr: "invalid role ARN"},
+		{name: "role of another account", entry: "123456789012:arn:aws:iam::210987654321:role/CUDlyRole", wantErr: "does not belong to account 123456789012"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAccountRole(tt.entry)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRecommendationIdempotencyToken(t *testing.T) {
+	rec := Recommendation{
+		Service:       ServiceRDS,
+		Region:        "us-east-1",
+		ResourceType:  "db.r6g.large",
+		Count:         2,
+		Term:          "1yr",
+		PaymentOption: "no-upfront",
+		Account:       "123456789012",
+		Details:       &DatabaseDetails{Engine: "mysql", AZConfig: "single-az"},
+	}
+	token := RecommendationIdempotencyToken(rec)
+	assert.Len(t, token, 32)
+
+	// Fields outside the purchase identity don't change the token
+	same := rec
+	same.EstimatedSavings = 100
+	same.Details = DatabaseDetails{Engine: "mysql", AZConfig: "single-az", EngineVersion: "8.0.35"}
+	assert.Equal(t, token, RecommendationIdempotencyToken(same))
+
+	changes := map[string]func(*Recommendation){
+		"service":        func(r *Recommendation) { r.Service = ServiceElastiCache },
+		"region":         func(r *Recommendation) { r.Region = "eu-west-1" },
+		"resource type":  func(r *Recommendation) { r.ResourceType = "db.r6g.xlarge" },
+		"count":          func(r *Recommendation) { r.Count = 3 },
+		"term":           func(r *Recommendation) { r.Term = "3yr" },
+		"payment option": func(r *Recommendation) { r.PaymentOption = "all-upfront" },
+		"account":        func(r *Recommendation) { r.Account = "210987654321" },
+		"engine":         func(r *Recommendation) { r.Details = &DatabaseDetails{Engine: "postgres", AZConfig: "single-az"} },
+		"multi-az":       func(r *Recommendation) { r.Details = &DatabaseDetails{Engine: "mysql", AZConfig: "multi-az"} },
+		"deployment": func(r *Recommendation) {
+			r.Details = &DatabaseDetails{Engine: "mysql", AZConfig: "single-az", Deployment: "pool"}
+		},
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			changed := rec
+			change(&changed)
+			assert.NotEqual(t, token, RecommendationIdempotencyToken(changed))
+		})
+	}
+}
+
+func TestRecommendationIdempotencyTokenDetails(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b ServiceDetails
+	}{
+		{"cache engine", &CacheDetails{Engine: "redis", NodeType: "cache.r6g.large"}, &CacheDetails{Engine: "valkey", NodeType: "cache.r6g.large"}},
+		{"ec2 platform", &ComputeDetails{Platform: "Linux/UNIX"}, &ComputeDetails{Platform: "Windows"}},
+		{"ec2 offering class", &ComputeDetails{OfferingClass: "standard"}, &ComputeDetails{OfferingClass: "convertible"}},
+		{"savings plan hourly commitment", &SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1.5}, &SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 2}},
+		{"savings plan type", &SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1.5}, &SavingsPlanDetails{PlanType: "EC2Instance", HourlyCommitment: 1.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := Recommendation{Service: ServiceSavingsPlans, Region: "us-east-1", ResourceType: "Compute", Count: 1, Term: "1yr", Details: tt.a}
+			other := rec
+			other.Details = tt.b
+			assert.NotEqual(t, RecommendationIdempotencyToken(rec), RecommendationIdempotencyToken(other))
+		})
+	}
+}
+
+func TestDailyIdempotencyToken(t *testing.T) {
+	rec := Recommendation{Service: ServiceSavingsPlans, ResourceType: "Compute", Count: 1, Details: &SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1.5}}
+	day := time.Date(2024, 3, 5, 1, 0, 0, 0, time.UTC)
+
+	token := DailyIdempotencyToken(rec, day)
+	assert.Len(t, token, 32)
+	assert.NotEqual(t, RecommendationIdempotencyToken(rec), token)
+	assert.Equal(t, token, DailyIdempotencyToken(rec, day.Add(20*time.Hour)), "retries on the same day reuse the token")
+	assert.NotEqual(t, token, DailyIdempotencyToken(rec, day.Add(24*time.Hour)), "later runs get a new token")
+}
+
+func TestIdempotentReservationID(t *testing.T) {
+	rec := Recommendation{Service: ServiceRDS, Region: "us-east-1", ResourceType: "db.t3.micro", Count: 1}
+	day := time.Date(2024, 3, 5, 1, 0, 0, 0, time.UTC)
+
+	id := IdempotentReservationID("rds", rec, day)
+	assert.Equal(t, "rds-20240305-"+RecommendationIdempotencyToken(rec)[:16], id)
+	assert.Equal(t, id, IdempotentReservationID("rds", rec, day.Add(20*time.Hour)), "retries on the same day reuse the ID")
+	assert.NotEqual(t, id, IdempotentReservationID("rds", rec, day.Add(24*time.Hour)), "later runs get a new ID")
+}
@@ -0,0 +1,262 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+var (
+	// ErrCacheMiss is returned when no cache entry exists for the requested parameters
+	ErrCacheMiss = errors.New("recommendation cache miss")
+	// ErrCacheExpired is returned when the cache entry is older than the cache TTL
+	ErrCacheExpired = errors.New("recommendation cache entry expired")
+)
+
+// RecommendationCache stores recommendation results on disk, keyed by the request parameters
+type RecommendationCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewRecommendationCache creates a new disk cache in dir; a ttl of 0 means entries never expire
+func NewRecommendationCache(dir string, ttl time.Duration) *RecommendationCache {
+	return &RecommendationCache{
+		dir: dir,
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// cacheEntry is the on-disk format of a single cached recommendation query
+type cacheEntry struct {
+	CreatedAt       time.Time                   `json:"created_at"`
+	Params          common.RecommendationParams `json:"params"`
+	Recommendations []cachedRecommendation      `json:"recommendations"`
+}
+
+// cachedRecommendation wraps a recommendation with its polymorphic details encoded separately
+type cachedRecommendation struct {
+	Recommendation common.Recommendation `json:"recommendation"`
+	DetailsType    string                `json:"details_type,omitempty"`
+	Details        json.RawMessage       `json:"details,omitempty"`
+}
+
+// decodeDetails restores ServiceDetails from its type name and JSON encoding
+func decodeDetails(typeName string, data json.RawMessage) (common.ServiceDetails, error) {
+	details, err := common.NewServiceDetails(typeName)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, details); err != nil {
+		return nil, fmt.Errorf("failed to decode %s details: %w", typeName, err)
+	}
+	return details, nil
+}
+
+// CacheKey returns a stable key for the given recommendation parameters
+func CacheKey(params common.RecommendationParams) string {
+	data, _ := json.Marshal(params)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// entryPath returns the file path of the cache entry for the given parameters
+func (c *RecommendationCache) entryPath(params common.RecommendationParams) string {
+	region := params.Region
+	if region == "" {
+		region = "global"
+	}
+	return filepath.Join(c.dir, fmt.Sprintf("%s_%s_%s.json", params.Service, region, CacheKey(params)))
+}
+
+// isExpired checks if an entry created at the given time is past the TTL
+func (c *RecommendationCache) isExpired(createdAt time.Time) bool {
+	return c.ttl > 0 && c.now().Sub(createdAt) > c.ttl
+}
+
+// readEntry reads and decodes a cache entry file
+func readEntry(path string) (*cacheEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse cache entry %s: %w", path, err)
+	}
+	return &entry, nil
+}
+
+// decodeRecommendations converts cached recommendations back into common recommendations
+func (e *cacheEntry) decodeRecommendations() ([]common.Recommendation, error) {
+	recs := make([]common.Recommendation, 0, len(e.Recommendations))
+	for _, cached := range e.Recommendations {
+		rec := cached.Recommendation
+		if cached.DetailsType != "" {
+			details, err := decodeDetails(cached.DetailsType, cached.Details)
+			if err != nil {
+				return nil, err
+			}
+			rec.Details = details
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+// Get returns the cached recommendations for the given parameters
+// It returns ErrCacheMiss if no entry exists and ErrCacheExpired if the entry is older than the TTL
+func (c *RecommendationCache) Get(params common.RecommendationParams) ([]common.Recommendation, error) {
+	path := c.entryPath(params)
+	entry, err := readEntry(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrCacheMiss, filepath.Base(path))
+		}
+		return nil, err
+	}
+	if c.isExpired(entry.CreatedAt) {
+		return nil, fmt.Errorf("%w: %s (cached at %s)", ErrCacheExpired, filepath.Base(path), entry.CreatedAt.Format(time.RFC3339))
+	}
+	return entry.decodeRecommendations()
+}
+
+// Put stores the recommendations for the given parameters
+func (c *RecommendationCache) Put(params common.RecommendationParams, recs []common.Recommendation) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	entry := cacheEntry{
+		CreatedAt:       c.now(),
+		Params:          params,
+		Recommendations: make([]cachedRecommendation, 0, len(recs)),
+	}
+	for _, rec := range recs {
+		cached := cachedRecommendation{Recommendation: rec}
+		if rec.Details != nil {
+			typeName, err := common.ServiceDetailsTypeName(rec.Details)
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(rec.Details)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s details: %w", typeName, err)
+			}
+			cached.Recommendation.Details = nil
+			cached.DetailsType = typeName
+			cached.Details = data
+		}
+		entry.Recommendations = append(entry.Recommendations, cached)
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := os.WriteFile(c.entryPath(params), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// GetAllForService returns all unexpired cached recommendations for a service across regions
+func (c *RecommendationCache) GetAllForService(service common.ServiceType) ([]common.Recommendation, error) {
+	matches, err := filepath.Glob(filepath.Join(c.dir, string(service)+"_*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	recs := make([]common.Recommendation, 0)
+	found := false
+	for _, path := range matches {
+		entry, err := readEntry(path)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Params.Service != service || c.isExpired(entry.CreatedAt) {
+			continue
+		}
+		entryRecs, err := entry.decodeRecommendations()
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		recs = append(recs, entryRecs...)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: no unexpired entries for service %s in %s", ErrCacheMiss, service, c.dir)
+	}
+	return recs, nil
+}
+
+// CachingRecommendationsClient decorates a RecommendationsClient with a disk cache
+// In cache-only mode it never calls the underlying client and fails on cache misses
+type CachingRecommendationsClient struct {
+	client    RecommendationsClient
+	cache     *RecommendationCache
+	cacheOnly bool
+}
+
+// NewCachingRecommendationsClient wraps client with the given cache; client may be nil in cache-only mode
+func NewCachingRecommendationsClient(client RecommendationsClient, cache *RecommendationCache, cacheOnly bool) *CachingRecommendationsClient {
+	return &CachingRecommendationsClient{
+		client:    client,
+		cache:     cache,
+		cacheOnly: cacheOnly,
+	}
+}
+
+// GetRecommendations returns cached recommendations when available, otherwise fetches and caches them
+func (c *CachingRecommendationsClient) GetRecommendations(ctx context.Context, params common.RecommendationParams) ([]common.Recommendation, error) {
+	recs, err := c.cache.Get(params)
+	if err == nil {
+		return recs, nil
+	}
+	if c.cacheOnly {
+		return nil, err
+	}
+
+	recs, err = c.client.GetRecommendations(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	// Caching is best-effort; the live result is returned even if it could not be stored
+	if err := c.cache.Put(params, recs); err != nil {
+		log.Printf("⚠️  Failed to cache %s recommendations: %v", params.Service, err)
+	}
+	return recs, nil
+}
+
+// GetRecommendationsForService returns recommendations for a service, served from the cache in cache-only mode
+func (c *CachingRecommendationsClient) GetRecommendationsForService(ctx context.Context, service common.ServiceType) ([]common.Recommendation, error) {
+	if c.cacheOnly {
+		return c.cache.GetAllForService(service)
+	}
+	return c.client.GetRecommendationsForService(ctx, service)
+}
+
+// GetAllRecommendations returns recommendations for all services; not supported in cache-only mode
+func (c *CachingRecommendationsClient) GetAllRecommendations(ctx context.Context) ([]common.Recommendation, error) {
+	if c.cacheOnly {
+		return nil, fmt.Errorf("%w: listing all recommendations is not supported in cache-only mode", ErrCacheMiss)
+	}
+	return c.client.GetAllRecommendations(ctx)
+}
+
+// IsCacheError reports whether err was caused by a missing or expired cache entry
+func IsCacheError(err error) bool {
+	return errors.Is(err, ErrCacheMiss) || errors.Is(err, ErrCacheExpired)
+}
@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// stubRecommendationsClient returns fixed recommendations and counts calls
+type stubRecommendationsClient struct {
+	recs  []common.Recommendation
+	err   error
+	calls int
+}
+
+func (s *stubRecommendationsClient) GetRecommendations(ctx context.Context, params common.RecommendationParams) ([]common.Recommendation, error) {
+	s.calls++
+	return s.recs, s.err
+}
+
+func (s *stubRecommendationsClient) GetRecommendationsForService(ctx context.Context, service common.ServiceType) ([]common.Recommendation, error) {
+	s.calls++
+	return s.recs, s.err
+}
+
+func (s *stubRecommendationsClient) GetAllRecommendations(ctx context.Context) ([]common.Recommendation, error) {
+	s.calls++
+	return s.recs, s.err
+}
+
+func TestRecommendationCache_PutGetRoundTrip(t *testing.T) {
+	cache := NewRecommendationCache(t.TempDir(), time.Hour)
+	params := common.RecommendationParams{Service: common.ServiceRDS, Region: "us-east-1", Term: "3yr"}
+
+	recs := []common.Recommendation{
+		{
+			Service:      common.ServiceRDS,
+			Region:       "us-east-1",
+			ResourceType: "db.r6g.large",
+			Count:        2,
+			Details:      &common.DatabaseDetails{Engine: "postgresql", AZConfig: "multi-az"},
+		},
+		{
+			Service: common.ServiceSavingsPlans,
+			Count:   1,
+			Details: &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1.5},
+		},
+		{Service: common.ServiceEC2, ResourceType: "m5.large", Count: 1},
+	}
+
+	require.NoError(t, cache.Put(params, recs))
+
+	got, err := cache.Get(params)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, recs[0].ResourceType, got[0].ResourceType)
+	assert.Equal(t, &common.DatabaseDetails{Engine: "postgresql", AZConfig: "multi-az"}, got[0].Details)
+	assert.Equal(t, &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1.5}, got[1].Details)
+	assert.Nil(t, got[2].Details)
+}
+
+func TestRecommendationCache_DetailsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		details common.ServiceDetails
+	}{
+		{"compute", &common.ComputeDetails{InstanceType: "m5.large", Platform: "Linux/UNIX", Tenancy: "default", Scope: "region"}},
+		{"database", &common.DatabaseDetails{Engine: "mysql", AZConfig: "single-az"}},
+		{"cache", &common.CacheDetails{Engine: "redis", NodeType: "cache.r6g.large"}},
+		{"search", &common.SearchDetails{InstanceType: "r6g.large.search"}},
+		{"data warehouse", &common.DataWarehouseDetails{NodeType: "ra3.xlplus"}},
+		{"savings plan", &common.SavingsPlanDetails{PlanType: "EC2Instance", HourlyCommitment: 2, Region: "us-east-1", InstanceFamily: "m5"}},
+		{"dynamodb", &common.DynamoDBDetails{ReadCapacity: 100, WriteCapacity: 50}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewRecommendationCache(t.TempDir(), time.Hour)
+			params := common.RecommendationParams{Service: tt.details.GetServiceType(), Region: "us-east-1"}
+
+			require.NoError(t, cache.Put(params, []common.Recommendation{{Service: params.Service, Count: 1, Details: tt.details}}))
+
+			got, err := cache.Get(params)
+			require.NoError(t, err)
+			require.Len(t, got, 1)
+			assert.Equal(t, tt.details, got[0].Details)
+		})
+	}
+}
+
+func TestRecommendationCache_MissAndExpiry(t *testing.T) {
+	cache := NewRecommendationCache(t.TempDir(), time.Hour)
+	params := common.RecommendationParams{Service: common.ServiceRDS, Region: "us-east-1"}
+
+	_, err := cache.Get(params)
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	require.NoError(t, cache.Put(params, []common.Recommendation{{Service: common.ServiceRDS}}))
+
+	// A different payment option is a different cache entry
+	_, err = cache.Get(common.RecommendationParams{Service: common.ServiceRDS, Region: "us-east-1", PaymentOption: "all-upfront"})
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	cache.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = cache.Get(params)
+	assert.ErrorIs(t, err, ErrCacheExpired)
+	assert.True(t, IsCacheError(err))
+}
+
+func TestRecommendationCache_GetAllForService(t *testing.T) {
+	cache := NewRecommendationCache(t.TempDir(), 0)
+
+	require.NoError(t, cache.Put(common.RecommendationParams{Service: common.ServiceRDS, Region: "us-east-1"},
+		[]common.Recommendation{{Service: common.ServiceRDS, Region: "us-east-1"}}))
+	require.NoError(t, cache.Put(common.RecommendationParams{Service: common.ServiceRDS, Region: "eu-west-1"},
+		[]common.Recommendation{{Service: common.ServiceRDS, Region: "eu-west-1"}}))
+	require.NoError(t, cache.Put(common.RecommendationParams{Service: common.ServiceEC2, Region: "us-east-1"},
+		[]common.Recommendation{{Service: common.ServiceEC2, Region: "us-east-1"}}))
+
+	recs, err := cache.GetAllForService(common.ServiceRDS)
+	require.NoError(t, err)
+	assert.Len(t, recs, 2)
+
+	_, err = cache.GetAllForService(common.ServiceElastiCache)
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestCachingRecommendationsClient(t *testing.T) {
+	ctx := context.Background()
+	params := common.RecommendationParams{Service: common.ServiceRDS, Region: "us-east-1"}
+	live := &stubRecommendationsClient{recs: []common.Recommendation{{Service: common.ServiceRDS, Count: 3}}}
+
+	t.Run("fetches on miss and serves from cache afterwards", func(t *testing.T) {
+		client := NewCachingRecommendationsClient(live, NewRecommendationCache(t.TempDir(), time.Hour), false)
+
+		recs, err := client.GetRecommendations(ctx, params)
+		require.NoError(t, err)
+		assert.Len(t, recs, 1)
+
+		recs, err = client.GetRecommendations(ctx, params)
+		require.NoError(t, err)
+		assert.Len(t, recs, 1)
+		assert.Equal(t, 1, live.calls)
+	})
+
+	t.Run("propagates live errors without caching", func(t *testing.T) {
+		failing := &stubRecommendationsClient{err: errors.New("throttled")}
+		client := NewCachingRecommendationsClient(failing, NewRecommendationCache(t.TempDir(), time.Hour), false)
+
+		_, err := client.GetRecommendations(ctx, params)
+		assert.EqualError(t, err, "throttled")
+	})
+
+	t.Run("cache-only never calls the live client", func(t *testing.T) {
+		offline := &stubRecommendationsClient{}
+		client := NewCachingRecommendationsClient(offline, NewRecommendationCache(t.TempDir(), time.Hour), true)
+
+		_, err := client.GetRecommendations(ctx, params)
+		assert.ErrorIs(t, err, ErrCacheMiss)
+		_, err = client.GetRecommendationsForService(ctx, common.ServiceRDS)
+		assert.ErrorIs(t, err, ErrCacheMiss)
+		_, err = client.GetAllRecommendations(ctx)
+		assert.ErrorIs(t, err, ErrCacheMiss)
+		assert.Equal(t, 0, offline.calls)
+	})
+}
+
+func TestCacheKeyAccountID(t *testing.T) {
+	params := common.RecommendationParams{Service: common.ServiceRDS, Region: "us-east-1", Term: "3yr"}
+	scoped := params
+	scoped.AccountID = "123456789012"
+
+	assert.NotEqual(t, CacheKey(params), CacheKey(scoped), "recommendations of one account are cached separately")
+}
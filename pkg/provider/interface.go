@@ -52,6 +52,12 @@ type ServiceClient interface {
 	GetValidResourceTypes(ctx context.Context) ([]string, error)
 }
 
+// DryRunPurchaser is implemented by service clients whose purchase API can validate a purchase server-side
+// without making it, such as the DryRun parameter of EC2
+type DryRunPurchaser interface {
+	DryRunPurchase(ctx context.Context, rec common.Recommendation) (common.PurchaseResult, error)
+}
+
 // RecommendationsClient provides centralized recommendations across all services
 type RecommendationsClient interface {
 	// Get recommendations with filtering
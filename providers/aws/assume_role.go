@@ -0,0 +1,19 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+)
+
+// assumeRoleSessionName identifies CUDly sessions in the CloudTrail logs of the assumed accounts
+const assumeRoleSessionName = "cudly"
+
+// AssumeRoleConfig returns a copy of base whose credentials come from assuming roleARN through the STS client
+// The credentials are cached and refreshed before they expire, so long runs keep working.
+func AssumeRoleConfig(base aws.Config, client stscreds.AssumeRoleAPIClient, roleARN string) aws.Config {
+	cfg := base.Copy()
+	cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(client, roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = assumeRoleSessionName
+	}))
+	return cfg
+}
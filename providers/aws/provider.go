@@ -326,6 +326,7 @@ func (p *AWSProvider) GetSupportedServices() []common.ServiceType {
 		common.ServiceOpenSearch,
 		common.ServiceRedshift,
 		common.ServiceMemoryDB,
+		common.ServiceDynamoDB,
 	}
 }
 
@@ -352,6 +353,8 @@ func (p *AWSProvider) GetServiceClient(ctx context.Context, service common.Servi
 		return NewRedshiftClient(regionalCfg), nil
 	case common.ServiceMemoryDB:
 		return NewMemoryDBClient(regionalCfg), nil
+	case common.ServiceNoSQL, common.ServiceDynamoDB:
+		return NewDynamoDBClient(regionalCfg), nil
 	case common.ServiceSavingsPlans:
 		return NewSavingsPlansClient(regionalCfg), nil
 	default:
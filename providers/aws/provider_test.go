@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -12,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/organizations"
 	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -745,3 +747,49 @@ func TestAWSProvider_GetServiceClient_NotConfigured(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "AWS is not configured")
 }
+
+// mockAssumeRoleClient implements stscreds.AssumeRoleAPIClient for testing
+type mockAssumeRoleClient struct {
+	input *sts.AssumeRoleInput
+	err   error
+}
+
+func (m *mockAssumeRoleClient) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	m.input = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &sts.AssumeRoleOutput{
+		Credentials: &ststypes.Credentials{
+			AccessKeyId:     aws.String("ASSUMEDKEY"),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("token"),
+			Expiration:      aws.Time(time.Now().Add(time.Hour)),
+		},
+	}, nil
+}
+
+func TestAssumeRoleConfig(t *testing.T) {
+	base := aws.Config{Region: "us-east-1"}
+	roleARN := "arn:aws:iam::123456789012:role/CUDlyRole"
+
+	t.Run("credentials come from the assumed role", func(t *testing.T) {
+		client := &mockAssumeRoleClient{}
+		cfg := AssumeRoleConfig(base, client, roleARN)
+
+		creds, err := cfg.Credentials.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "ASSUMEDKEY", creds.AccessKeyID)
+		assert.Equal(t, roleARN, aws.ToString(client.input.RoleArn))
+		assert.Equal(t, "cudly", aws.ToString(client.input.RoleSessionName))
+		assert.Equal(t, "us-east-1", cfg.Region)
+		assert.Nil(t, base.Credentials, "the base config must not be modified")
+	})
+
+	t.Run("assume role failure", func(t *testing.T) {
+		cfg := AssumeRoleConfig(base, &mockAssumeRoleClient{err: errors.New("access denied")}, roleARN)
+
+		_, err := cfg.Credentials.Retrieve(context.Background())
+		assert.ErrorContains(t, err, "access denied")
+	})
+}
@@ -4,6 +4,7 @@ package recommendations
 import (
 	"context"
 	"fmt"
+	"log"
 	"strconv"
 	"strings"
 	"time"
@@ -26,6 +27,8 @@ type Client struct {
 	costExplorerClient CostExplorerAPI
 	region             string
 	rateLimiter        *RateLimiter
+	regionalLimiters   *RegionalRateLimiters
+	newLimiter         func() *RateLimiter
 }
 
 // NewClient creates a new recommendations client
@@ -39,6 +42,7 @@ func NewClient(cfg aws.Config) *Client {
 		costExplorerClient: costexplorer.NewFromConfig(ceConfig),
 		region:             cfg.Region,
 		rateLimiter:        NewRateLimiter(),
+		newLimiter:         NewRateLimiter,
 	}
 }
 
@@ -48,9 +52,40 @@ func NewClientWithAPI(api CostExplorerAPI, region string) *Client {
 		costExplorerClient: api,
 		region:             region,
 		rateLimiter:        NewRateLimiter(),
+		newLimiter:         NewRateLimiter,
 	}
 }
 
+// EnablePerRegionRateLimiting gives each region its own rate limiter instead of the shared default one
+func (c *Client) EnablePerRegionRateLimiting() {
+	c.regionalLimiters = NewRegionalRateLimiters(c.newLimiter)
+}
+
+// SetRetryPolicy makes failed Cost Explorer requests retry up to maxRetries times with exponential backoff from baseDelay
+// It replaces the shared rate limiter and, if enabled, the per-region ones.
+func (c *Client) SetRetryPolicy(maxRetries int, baseDelay time.Duration) {
+	c.newLimiter = func() *RateLimiter {
+		return NewRetryRateLimiter(maxRetries, baseDelay)
+	}
+	c.rateLimiter = c.newLimiter()
+	if c.regionalLimiters != nil {
+		c.regionalLimiters = NewRegionalRateLimiters(c.newLimiter)
+	}
+}
+
+// RateLimiter returns the shared rate limiter used for requests that are not rate limited per region
+func (c *Client) RateLimiter() *RateLimiter {
+	return c.rateLimiter
+}
+
+// rateLimiterFor returns the rate limiter to use for requests concerning the given region
+func (c *Client) rateLimiterFor(region string) *RateLimiter {
+	if c.regionalLimiters == nil {
+		return c.rateLimiter
+	}
+	return c.regionalLimiters.ForRegion(region)
+}
+
 // GetRecommendations fetches Reserved Instance recommendations for any service
 func (c *Client) GetRecommendations(ctx context.Context, params common.RecommendationParams) ([]common.Recommendation, error) {
 	// Handle Savings Plans separately as they use a different API
@@ -58,32 +93,35 @@ func (c *Client) GetRecommendations(ctx context.Context, params common.Recommend
 		return c.getSavingsPlansRecommendations(ctx, params)
 	}
 
+	lookback, err := convertLookbackPeriod(params.LookbackPeriod)
+	if err != nil {
+		return nil, err
+	}
 	input := &costexplorer.GetReservationPurchaseRecommendationInput{
 		Service:              aws.String(getServiceStringForCostExplorer(params.Service)),
 		PaymentOption:        convertPaymentOption(params.PaymentOption),
 		TermInYears:          convertTermInYears(params.Term),
-		LookbackPeriodInDays: convertLookbackPeriod(params.LookbackPeriod),
+		LookbackPeriodInDays: lookback,
 		AccountScope:         types.AccountScopeLinked,
 	}
-
-	// Implement rate limiting with exponential backoff
-	var result *costexplorer.GetReservationPurchaseRecommendationOutput
-	var err error
-
-	c.rateLimiter.Reset()
-	for {
-		if waitErr := c.rateLimiter.Wait(ctx); waitErr != nil {
-			return nil, fmt.Errorf("rate limiter wait failed: %w", waitErr)
-		}
-
-		result, err = c.costExplorerClient.GetReservationPurchaseRecommendation(ctx, input)
-		if !c.rateLimiter.ShouldRetry(err) {
-			break
+	if params.AccountID != "" {
+		input.AccountId = aws.String(params.AccountID)
+	}
+	if (params.Service == common.ServiceEC2 || params.Service == common.ServiceCompute) && params.EC2OfferingClass != "" {
+		input.ServiceSpecification = &types.ServiceSpecification{
+			EC2Specification: &types.EC2Specification{OfferingClass: types.OfferingClass(params.EC2OfferingClass)},
 		}
 	}
 
+	// Retry with exponential backoff; each call keeps its own retry count, as regions are fetched concurrently
+	var result *costexplorer.GetReservationPurchaseRecommendationOutput
+	retries, err := c.rateLimiterFor(params.Region).Do(ctx, func() error {
+		var err error
+		result, err = c.costExplorerClient.GetReservationPurchaseRecommendation(ctx, input)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get RI recommendations after %d retries: %w", c.rateLimiter.GetRetryCount(), err)
+		return nil, fmt.Errorf("failed to get RI recommendations after %d retries: %w", retries, err)
 	}
 
 	return c.parseRecommendations(result.Recommendations, params)
@@ -134,7 +172,7 @@ func (c *Client) parseRecommendations(awsRecs []types.ReservationPurchaseRecomme
 		for i, details := range awsRec.RecommendationDetails {
 			rec, err := c.parseRecommendationDetail(&details, params)
 			if err != nil {
-				fmt.Printf("Warning: Failed to parse recommendation detail %d: %v\n", i, err)
+				log.Printf("Warning: Failed to parse recommendation detail %d: %v\n", i, err)
 				continue
 			}
 
@@ -158,7 +196,7 @@ func (c *Client) parseRecommendationDetail(details *types.ReservationPurchaseRec
 		Timestamp:      time.Now(),
 	}
 
-	// Parse recommended quantity
+	// Parse recommended quantity (capacity units for DynamoDB reserved capacity)
 	count, err := c.parseRecommendedQuantity(details)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse recommended quantity: %w", err)
@@ -180,8 +218,14 @@ func (c *Client) parseRecommendationDetail(details *types.ReservationPurchaseRec
 	if details.UpfrontCost != nil {
 		if upfront, err := strconv.ParseFloat(*details.UpfrontCost, 64); err == nil {
 			rec.CommitmentCost = upfront
+			rec.UpfrontCost = upfront
 		}
 	}
+	recurring := 0.0
+	if details.RecurringStandardMonthlyCost != nil {
+		recurring, _ = strconv.ParseFloat(*details.RecurringStandardMonthlyCost, 64)
+	}
+	rec.AmortizedMonthlyCost = amortizedMonthlyCost(rec.UpfrontCost, recurring, params.Term)
 	if details.EstimatedMonthlyOnDemandCost != nil {
 		if onDemand, err := strconv.ParseFloat(*details.EstimatedMonthlyOnDemandCost, 64); err == nil {
 			rec.OnDemandCost = onDemand
@@ -202,6 +246,7 @@ func (c *Client) parseRecommendationDetail(details *types.ReservationPurchaseRec
 		if err := c.parseEC2Details(rec, details); err != nil {
 			return nil, err
 		}
+		rec.Details.(*common.ComputeDetails).OfferingClass = params.EC2OfferingClass
 	case common.ServiceOpenSearch, common.ServiceSearch:
 		if err := c.parseOpenSearchDetails(rec, details); err != nil {
 			return nil, err
@@ -214,6 +259,10 @@ func (c *Client) parseRecommendationDetail(details *types.ReservationPurchaseRec
 		if err := c.parseMemoryDBDetails(rec, details); err != nil {
 			return nil, err
 		}
+	case common.ServiceDynamoDB, common.ServiceNoSQL:
+		if err := c.parseDynamoDBDetails(rec, details); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, fmt.Errorf("unsupported service: %s", params.Service)
 	}
@@ -303,9 +352,10 @@ func (c *Client) parseEC2Details(rec *common.Recommendation, details *types.Rese
 	}
 
 	if ec2Details.AvailabilityZone != nil && *ec2Details.AvailabilityZone != "" {
-		ec2Info.Scope = "availability-zone"
+		ec2Info.Scope = common.EC2ScopeAvailabilityZone
+		ec2Info.AvailabilityZone = *ec2Details.AvailabilityZone
 	} else {
-		ec2Info.Scope = "region"
+		ec2Info.Scope = common.EC2ScopeRegion
 	}
 
 	rec.Details = ec2Info
@@ -368,24 +418,69 @@ func (c *Client) parseRedshiftDetails(rec *common.Recommendation, details *types
 	return nil
 }
 
+// defaultMemoryDBNodeType is used when Cost Explorer does not report the node type of a MemoryDB recommendation
+const defaultMemoryDBNodeType = "db.r6gd.xlarge"
+
 // parseMemoryDBDetails extracts MemoryDB-specific details
 func (c *Client) parseMemoryDBDetails(rec *common.Recommendation, details *types.ReservationPurchaseRecommendationDetail) error {
-	// MemoryDB might not have specific details in Cost Explorer yet
-	rec.ResourceType = "db.r6gd.xlarge" // Default
-	rec.Details = &common.CacheDetails{
-		Engine:   "redis",
-		NodeType: rec.ResourceType,
+	cacheInfo := &common.CacheDetails{Engine: "redis"}
+
+	var memoryDBDetails *types.MemoryDBInstanceDetails
+	if details.InstanceDetails != nil {
+		memoryDBDetails = details.InstanceDetails.MemoryDBInstanceDetails
+	}
+	if memoryDBDetails != nil && memoryDBDetails.NodeType != nil && *memoryDBDetails.NodeType != "" {
+		rec.ResourceType = *memoryDBDetails.NodeType
+	} else {
+		log.Printf("Warning: MemoryDB recommendation has no node type, defaulting to %s\n", defaultMemoryDBNodeType)
+		rec.ResourceType = defaultMemoryDBNodeType
+	}
+	if memoryDBDetails != nil && memoryDBDetails.Region != nil {
+		rec.Region = normalizeRegionName(*memoryDBDetails.Region)
+	}
+
+	cacheInfo.NodeType = rec.ResourceType
+	rec.Details = cacheInfo
+	return nil
+}
+
+// parseDynamoDBDetails extracts DynamoDB reserved capacity details
+// The recommended count is the number of read or write capacity units to reserve.
+func (c *Client) parseDynamoDBDetails(rec *common.Recommendation, details *types.ReservationPurchaseRecommendationDetail) error {
+	if details.ReservedCapacityDetails == nil || details.ReservedCapacityDetails.DynamoDBCapacityDetails == nil {
+		return fmt.Errorf("DynamoDB capacity details not found")
+	}
+
+	capacityDetails := details.ReservedCapacityDetails.DynamoDBCapacityDetails
+	dynamoInfo := &common.DynamoDBDetails{}
+
+	if strings.Contains(strings.ToLower(aws.ToString(capacityDetails.CapacityUnits)), "write") {
+		rec.ResourceType = common.DynamoDBWriteCapacity
+		dynamoInfo.WriteCapacity = rec.Count
+	} else {
+		rec.ResourceType = common.DynamoDBReadCapacity
+		dynamoInfo.ReadCapacity = rec.Count
+	}
+	if capacityDetails.Region != nil {
+		rec.Region = normalizeRegionName(*capacityDetails.Region)
 	}
+
+	rec.Details = dynamoInfo
 	return nil
 }
 
 // parseRecommendedQuantity extracts the recommended quantity from details
+// Reserved capacity recommendations (DynamoDB) carry capacity units instead of instances.
 func (c *Client) parseRecommendedQuantity(details *types.ReservationPurchaseRecommendationDetail) (int, error) {
-	if details.RecommendedNumberOfInstancesToPurchase == nil {
+	quantity := details.RecommendedNumberOfInstancesToPurchase
+	if quantity == nil {
+		quantity = details.RecommendedNumberOfCapacityUnitsToPurchase
+	}
+	if quantity == nil {
 		return 0, fmt.Errorf("recommended quantity not found")
 	}
 
-	qty := *details.RecommendedNumberOfInstancesToPurchase
+	qty := *quantity
 
 	var count float64
 	_, err := fmt.Sscanf(qty, "%f", &count)
@@ -422,6 +517,10 @@ func (c *Client) getSavingsPlansRecommendations(ctx context.Context, params comm
 	if len(planTypes) == 0 {
 		return []common.Recommendation{}, nil
 	}
+	lookback, err := convertSavingsPlansLookbackPeriod(params.LookbackPeriod)
+	if err != nil {
+		return nil, err
+	}
 
 	var allRecommendations []common.Recommendation
 
@@ -430,27 +529,27 @@ func (c *Client) getSavingsPlansRecommendations(ctx context.Context, params comm
 			SavingsPlansType:     planType,
 			PaymentOption:        convertSavingsPlansPaymentOption(params.PaymentOption),
 			TermInYears:          convertSavingsPlansTermInYears(params.Term),
-			LookbackPeriodInDays: convertSavingsPlansLookbackPeriod(params.LookbackPeriod),
+			LookbackPeriodInDays: lookback,
 			AccountScope:         types.AccountScopeLinked,
 		}
+		if params.AccountID != "" {
+			input.Filter = &types.Expression{Dimensions: &types.DimensionValues{
+				Key:    types.DimensionLinkedAccount,
+				Values: []string{params.AccountID},
+			}}
+		}
 
-		c.rateLimiter.Reset()
 		var result *costexplorer.GetSavingsPlansPurchaseRecommendationOutput
-		var err error
-
-		for {
-			if waitErr := c.rateLimiter.Wait(ctx); waitErr != nil {
-				return nil, fmt.Errorf("rate limiter wait failed: %w", waitErr)
-			}
-
+		_, err := c.rateLimiterFor(params.Region).Do(ctx, func() error {
+			var err error
 			result, err = c.costExplorerClient.GetSavingsPlansPurchaseRecommendation(ctx, input)
-			if !c.rateLimiter.ShouldRetry(err) {
-				break
-			}
+			return err
+		})
+		if err != nil && ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to get %s recommendations: %w", planType, err)
 		}
-
 		if err != nil {
-			fmt.Printf("Warning: Failed to get %s recommendations: %v\n", planType, err)
+			log.Printf("Warning: Failed to get %s recommendations: %v\n", planType, err)
 			continue
 		}
 
@@ -519,23 +618,33 @@ func (c *Client) parseSavingsPlanDetail(
 		accountID = aws.ToString(detail.AccountId)
 	}
 
+	spDetails := &common.SavingsPlanDetails{
+		PlanType:         planTypeStr,
+		HourlyCommitment: hourlyCommitment,
+		Coverage:         fmt.Sprintf("%.1f%%", savingsPercent),
+	}
+	if scope := detail.SavingsPlansDetails; scope != nil {
+		spDetails.InstanceFamily = aws.ToString(scope.InstanceFamily)
+		if scope.Region != nil {
+			spDetails.Region = normalizeRegionName(*scope.Region)
+		}
+	}
+
 	return &common.Recommendation{
-		Provider:          common.ProviderAWS,
-		Service:           common.ServiceSavingsPlans,
-		PaymentOption:     params.PaymentOption,
-		Term:              params.Term,
-		CommitmentType:    common.CommitmentSavingsPlan,
-		Count:             1,
-		EstimatedSavings:  monthlySavings,
-		SavingsPercentage: savingsPercent,
-		CommitmentCost:    upfrontCost,
-		Timestamp:         time.Now(),
-		Account:           accountID,
-		Details: &common.SavingsPlanDetails{
-			PlanType:         planTypeStr,
-			HourlyCommitment: hourlyCommitment,
-			Coverage:         fmt.Sprintf("%.1f%%", savingsPercent),
-		},
+		Provider:             common.ProviderAWS,
+		Service:              common.ServiceSavingsPlans,
+		PaymentOption:        params.PaymentOption,
+		Term:                 params.Term,
+		CommitmentType:       common.CommitmentSavingsPlan,
+		Count:                1,
+		EstimatedSavings:     monthlySavings,
+		SavingsPercentage:    savingsPercent,
+		CommitmentCost:       upfrontCost,
+		UpfrontCost:          upfrontCost,
+		AmortizedMonthlyCost: hourlyCommitment * hoursPerMonth,
+		Timestamp:            time.Now(),
+		Account:              accountID,
+		Details:              spDetails,
 	}
 }
 
@@ -555,6 +664,8 @@ func getServiceStringForCostExplorer(service common.ServiceType) string {
 		return "Amazon Redshift"
 	case common.ServiceMemoryDB:
 		return "Amazon MemoryDB Service"
+	case common.ServiceDynamoDB, common.ServiceNoSQL:
+		return "Amazon DynamoDB Service"
 	default:
 		return string(service)
 	}
@@ -573,6 +684,18 @@ func convertPaymentOption(option string) types.PaymentOption {
 	}
 }
 
+// hoursPerMonth is the average number of hours in a month used to convert hourly commitments
+const hoursPerMonth = 730
+
+// amortizedMonthlyCost spreads the upfront cost over the months of the term and adds the recurring monthly charges
+func amortizedMonthlyCost(upfront, recurringMonthly float64, term string) float64 {
+	months := 12.0
+	if convertTermInYears(term) == types.TermInYearsThreeYears {
+		months = 36
+	}
+	return upfront/months + recurringMonthly
+}
+
 func convertTermInYears(term string) types.TermInYears {
 	if term == "3yr" || term == "3" {
 		return types.TermInYearsThreeYears
@@ -580,16 +703,18 @@ func convertTermInYears(term string) types.TermInYears {
 	return types.TermInYearsOneYear
 }
 
-func convertLookbackPeriod(period string) types.LookbackPeriodInDays {
+// convertLookbackPeriod converts a lookback period such as "30d" to the Cost Explorer lookback, defaulting to 7 days when empty
+// Cost Explorer only computes recommendations over 7, 30 or 60 days, so any other period is rejected.
+func convertLookbackPeriod(period string) (types.LookbackPeriodInDays, error) {
 	switch period {
-	case "7d", "7":
-		return types.LookbackPeriodInDaysSevenDays
+	case "", "7d", "7":
+		return types.LookbackPeriodInDaysSevenDays, nil
 	case "30d", "30":
-		return types.LookbackPeriodInDaysThirtyDays
+		return types.LookbackPeriodInDaysThirtyDays, nil
 	case "60d", "60":
-		return types.LookbackPeriodInDaysSixtyDays
+		return types.LookbackPeriodInDaysSixtyDays, nil
 	default:
-		return types.LookbackPeriodInDaysSevenDays
+		return "", fmt.Errorf("unsupported lookback period %q: Cost Explorer only supports 7d, 30d or 60d", period)
 	}
 }
 
@@ -601,7 +726,7 @@ func convertSavingsPlansTermInYears(term string) types.TermInYears {
 	return convertTermInYears(term)
 }
 
-func convertSavingsPlansLookbackPeriod(period string) types.LookbackPeriodInDays {
+func convertSavingsPlansLookbackPeriod(period string) (types.LookbackPeriodInDays, error) {
 	return convertLookbackPeriod(period)
 }
 
@@ -648,51 +773,65 @@ func (c *Client) getFilteredPlanTypes(includeSPTypes, excludeSPTypes []string) [
 	return result
 }
 
+// regionCodesByName maps the region names Cost Explorer reports, such as "US East (N. Virginia)", to region codes
+// Europe regions are listed under both their current "Europe (...)" and their older "EU (...)" names.
+var regionCodesByName = map[string]string{
+	"US East (N. Virginia)":      "us-east-1",
+	"US East (Ohio)":             "us-east-2",
+	"US West (N. California)":    "us-west-1",
+	"US West (Oregon)":           "us-west-2",
+	"Africa (Cape Town)":         "af-south-1",
+	"Asia Pacific (Hong Kong)":   "ap-east-1",
+	"Asia Pacific (Taipei)":      "ap-east-2",
+	"Asia Pacific (Mumbai)":      "ap-south-1",
+	"Asia Pacific (Hyderabad)":   "ap-south-2",
+	"Asia Pacific (Singapore)":   "ap-southeast-1",
+	"Asia Pacific (Sydney)":      "ap-southeast-2",
+	"Asia Pacific (Jakarta)":     "ap-southeast-3",
+	"Asia Pacific (Melbourne)":   "ap-southeast-4",
+	"Asia Pacific (Malaysia)":    "ap-southeast-5",
+	"Asia Pacific (New Zealand)": "ap-southeast-6",
+	"Asia Pacific (Thailand)":    "ap-southeast-7",
+	"Asia Pacific (Tokyo)":       "ap-northeast-1",
+	"Asia Pacific (Seoul)":       "ap-northeast-2",
+	"Asia Pacific (Osaka)":       "ap-northeast-3",
+	"Asia Pacific (Osaka-Local)": "ap-northeast-3",
+	"Canada (Central)":           "ca-central-1",
+	"Canada West (Calgary)":      "ca-west-1",
+	"Europe (Frankfurt)":         "eu-central-1",
+	"EU (Frankfurt)":             "eu-central-1",
+	"Europe (Zurich)":            "eu-central-2",
+	"EU (Zurich)":                "eu-central-2",
+	"Europe (Ireland)":           "eu-west-1",
+	"EU (Ireland)":               "eu-west-1",
+	"Europe (London)":            "eu-west-2",
+	"EU (London)":                "eu-west-2",
+	"Europe (Paris)":             "eu-west-3",
+	"EU (Paris)":                 "eu-west-3",
+	"Europe (Milan)":             "eu-south-1",
+	"EU (Milan)":                 "eu-south-1",
+	"Europe (Spain)":             "eu-south-2",
+	"EU (Spain)":                 "eu-south-2",
+	"Europe (Stockholm)":         "eu-north-1",
+	"EU (Stockholm)":             "eu-north-1",
+	"Israel (Tel Aviv)":          "il-central-1",
+	"Mexico (Central)":           "mx-central-1",
+	"Middle East (Bahrain)":      "me-south-1",
+	"Middle East (UAE)":          "me-central-1",
+	"South America (Sao Paulo)":  "sa-east-1",
+	"South America (São Paulo)":  "sa-east-1",
+	"AWS GovCloud (US-East)":     "us-gov-east-1",
+	"AWS GovCloud (US-West)":     "us-gov-west-1",
+	"AWS GovCloud (US)":          "us-gov-west-1",
+	"China (Beijing)":            "cn-north-1",
+	"China (Ningxia)":            "cn-northwest-1",
+}
+
+// normalizeRegionName converts a region name reported by Cost Explorer to its region code
+// Region codes and unknown names are returned as they are.
 func normalizeRegionName(region string) string {
-	// AWS Cost Explorer sometimes returns region names like "US East (N. Virginia)"
-	// Convert these to standard region codes
-	regionMap := map[string]string{
-		"US East (N. Virginia)":      "us-east-1",
-		"US East (Ohio)":             "us-east-2",
-		"US West (N. California)":    "us-west-1",
-		"US West (Oregon)":           "us-west-2",
-		"EU (Ireland)":               "eu-west-1",
-		"EU (Frankfurt)":             "eu-central-1",
-		"EU (London)":                "eu-west-2",
-		"EU (Paris)":                 "eu-west-3",
-		"EU (Stockholm)":             "eu-north-1",
-		"Asia Pacific (Singapore)":   "ap-southeast-1",
-		"Asia Pacific (Sydney)":      "ap-southeast-2",
-		"Asia Pacific (Tokyo)":       "ap-northeast-1",
-		"Asia Pacific (Seoul)":       "ap-northeast-2",
-		"Asia Pacific (Mumbai)":      "ap-south-1",
-		"South America (Sao Paulo)":  "sa-east-1",
-		"Canada (Central)":           "ca-central-1",
-		"Middle East (Bahrain)":      "me-south-1",
-		"Africa (Cape Town)":         "af-south-1",
-		"Asia Pacific (Hong Kong)":   "ap-east-1",
-		"Asia Pacific (Osaka)":       "ap-northeast-3",
-		"Asia Pacific (Jakarta)":     "ap-southeast-3",
-		"Europe (Milan)":             "eu-south-1",
-		"Middle East (UAE)":          "me-central-1",
-		"Asia Pacific (Hyderabad)":   "ap-south-2",
-		"Europe (Spain)":             "eu-south-2",
-		"Europe (Zurich)":            "eu-central-2",
-		"Asia Pacific (Melbourne)":   "ap-southeast-4",
-		"Israel (Tel Aviv)":          "il-central-1",
-	}
-
-	if normalized, ok := regionMap[region]; ok {
-		return normalized
-	}
-
-	// If already a region code, return as-is
-	if strings.HasPrefix(region, "us-") || strings.HasPrefix(region, "eu-") ||
-		strings.HasPrefix(region, "ap-") || strings.HasPrefix(region, "sa-") ||
-		strings.HasPrefix(region, "ca-") || strings.HasPrefix(region, "me-") ||
-		strings.HasPrefix(region, "af-") || strings.HasPrefix(region, "il-") {
-		return region
+	if code, ok := regionCodesByName[region]; ok {
+		return code
 	}
-
 	return region
 }
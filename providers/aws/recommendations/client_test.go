@@ -1,10 +1,19 @@
 package recommendations
 
 import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
 )
 
 func TestGetFilteredPlanTypes(t *testing.T) {
@@ -148,3 +157,301 @@ func TestGetFilteredPlanTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRecommendationDetail_DynamoDB(t *testing.T) {
+	client := &Client{}
+	params := common.RecommendationParams{Service: common.ServiceDynamoDB, Term: "1yr", PaymentOption: "partial-upfront"}
+
+	tests := []struct {
+		name          string
+		capacityUnits string
+		wantType      string
+		wantDetails   common.DynamoDBDetails
+	}{
+		{
+			name:          "write capacity",
+			capacityUnits: "WriteCapacityUnits",
+			wantType:      common.DynamoDBWriteCapacity,
+			wantDetails:   common.DynamoDBDetails{WriteCapacity: 300},
+		},
+		{
+			name:          "read capacity",
+			capacityUnits: "ReadCapacityUnits",
+			wantType:      common.DynamoDBReadCapacity,
+			wantDetails:   common.DynamoDBDetails{ReadCapacity: 300},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := &types.ReservationPurchaseRecommendationDetail{
+				AccountId: aws.String("123456789012"),
+				RecommendedNumberOfCapacityUnitsToPurchase: aws.String("300"),
+				EstimatedMonthlySavingsAmount:              aws.String("42.5"),
+				EstimatedMonthlySavingsPercentage:          aws.String("35"),
+				ReservedCapacityDetails: &types.ReservedCapacityDetails{
+					DynamoDBCapacityDetails: &types.DynamoDBCapacityDetails{
+						CapacityUnits: aws.String(tt.capacityUnits),
+						Region:        aws.String("US East (N. Virginia)"),
+					},
+				},
+			}
+
+			rec, err := client.parseRecommendationDetail(details, params)
+			require.NoError(t, err)
+			assert.Equal(t, common.ServiceDynamoDB, rec.Service)
+			assert.Equal(t, tt.wantType, rec.ResourceType)
+			assert.Equal(t, 300, rec.Count)
+			assert.Equal(t, "us-east-1", rec.Region)
+			assert.Equal(t, "123456789012", rec.Account)
+			assert.Equal(t, &tt.wantDetails, rec.Details)
+		})
+	}
+}
+
+func TestParseRecommendationDetail_DynamoDBMissingDetails(t *testing.T) {
+	client := &Client{}
+	details := &types.ReservationPurchaseRecommendationDetail{
+		RecommendedNumberOfCapacityUnitsToPurchase: aws.String("100"),
+	}
+
+	_, err := client.parseRecommendationDetail(details, common.RecommendationParams{Service: common.ServiceDynamoDB})
+	assert.ErrorContains(t, err, "DynamoDB capacity details not found")
+}
+
+func TestGetServiceStringForCostExplorer_DynamoDB(t *testing.T) {
+	assert.Equal(t, "Amazon DynamoDB Service", getServiceStringForCostExplorer(common.ServiceDynamoDB))
+}
+
+func TestParseRecommendationDetail_MemoryDB(t *testing.T) {
+	client := &Client{}
+	params := common.RecommendationParams{Service: common.ServiceMemoryDB, Term: "1yr", PaymentOption: "no-upfront"}
+
+	tests := []struct {
+		name       string
+		instance   *types.InstanceDetails
+		wantType   string
+		wantRegion string
+	}{
+		{
+			name: "node type from instance details",
+			instance: &types.InstanceDetails{
+				MemoryDBInstanceDetails: &types.MemoryDBInstanceDetails{
+					NodeType: aws.String("db.r7g.2xlarge"),
+					Family:   aws.String("r7g"),
+					Region:   aws.String("EU (Ireland)"),
+				},
+			},
+			wantType:   "db.r7g.2xlarge",
+			wantRegion: "eu-west-1",
+		},
+		{
+			name:       "missing instance details",
+			wantType:   defaultMemoryDBNodeType,
+			wantRegion: "",
+		},
+		{
+			name: "empty node type",
+			instance: &types.InstanceDetails{
+				MemoryDBInstanceDetails: &types.MemoryDBInstanceDetails{NodeType: aws.String("")},
+			},
+			wantType:   defaultMemoryDBNodeType,
+			wantRegion: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := &types.ReservationPurchaseRecommendationDetail{
+				RecommendedNumberOfInstancesToPurchase: aws.String("2"),
+				InstanceDetails:                        tt.instance,
+			}
+
+			// The default node type warning goes to the log, not to stdout
+			var logs bytes.Buffer
+			origOutput := log.Writer()
+			log.SetOutput(&logs)
+			defer log.SetOutput(origOutput)
+
+			rec, err := client.parseRecommendationDetail(details, params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantType, rec.ResourceType)
+			assert.Equal(t, tt.wantRegion, rec.Region)
+			assert.Equal(t, 2, rec.Count)
+			assert.Equal(t, &common.CacheDetails{Engine: "redis", NodeType: tt.wantType}, rec.Details)
+			assert.Equal(t, tt.wantType == defaultMemoryDBNodeType, strings.Contains(logs.String(), "has no node type"))
+		})
+	}
+}
+
+func TestAmortizedMonthlyCost(t *testing.T) {
+	tests := []struct {
+		name      string
+		upfront   float64
+		recurring float64
+		term      string
+		want      float64
+	}{
+		{name: "all upfront 1yr", upfront: 1200, term: "1yr", want: 100},
+		{name: "partial upfront 3yr", upfront: 3600, recurring: 50, term: "3yr", want: 150},
+		{name: "no upfront", recurring: 80, term: "1yr", want: 80},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, amortizedMonthlyCost(tt.upfront, tt.recurring, tt.term), 0.001)
+		})
+	}
+}
+
+func TestParseRecommendationDetail_UpfrontCosts(t *testing.T) {
+	client := &Client{}
+	details := &types.ReservationPurchaseRecommendationDetail{
+		RecommendedNumberOfInstancesToPurchase: aws.String("2"),
+		UpfrontCost:                            aws.String("2400"),
+		RecurringStandardMonthlyCost:           aws.String("30"),
+		InstanceDetails: &types.InstanceDetails{
+			ElastiCacheInstanceDetails: &types.ElastiCacheInstanceDetails{NodeType: aws.String("cache.r6g.large")},
+		},
+	}
+
+	rec, err := client.parseRecommendationDetail(details, common.RecommendationParams{Service: common.ServiceElastiCache, Term: "1yr", PaymentOption: "partial-upfront"})
+	require.NoError(t, err)
+	assert.Equal(t, 2400.0, rec.UpfrontCost)
+	assert.InDelta(t, 230, rec.AmortizedMonthlyCost, 0.001)
+}
+
+func TestParseSavingsPlanDetail_AmortizedMonthlyCost(t *testing.T) {
+	client := &Client{}
+	detail := &types.SavingsPlansPurchaseRecommendationDetail{
+		HourlyCommitmentToPurchase: aws.String("2.5"),
+		UpfrontCost:                aws.String("10950"),
+	}
+
+	rec := client.parseSavingsPlanDetail(detail, common.RecommendationParams{Term: "1yr", PaymentOption: "all-upfront"}, types.SupportedSavingsPlansTypeComputeSp)
+	assert.Equal(t, 10950.0, rec.UpfrontCost)
+	assert.InDelta(t, 1825, rec.AmortizedMonthlyCost, 0.001)
+}
+
+func TestParseSavingsPlanDetail_EC2InstanceScope(t *testing.T) {
+	client := &Client{}
+	detail := &types.SavingsPlansPurchaseRecommendationDetail{
+		HourlyCommitmentToPurchase: aws.String("0.5"),
+		SavingsPlansDetails: &types.SavingsPlansDetails{
+			InstanceFamily: aws.String("m5"),
+			Region:         aws.String("EU (Ireland)"),
+		},
+	}
+
+	rec := client.parseSavingsPlanDetail(detail, common.RecommendationParams{Term: "1yr", PaymentOption: "no-upfront"}, types.SupportedSavingsPlansTypeEc2InstanceSp)
+	details, ok := rec.Details.(*common.SavingsPlanDetails)
+	require.True(t, ok)
+	assert.Equal(t, "EC2Instance", details.PlanType)
+	assert.Equal(t, "m5", details.InstanceFamily)
+	assert.Equal(t, "eu-west-1", details.Region)
+}
+
+func TestNormalizeRegionName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"US East (N. Virginia)", "us-east-1"},
+		{"EU (Ireland)", "eu-west-1"},
+		{"Europe (Ireland)", "eu-west-1"},
+		{"Canada West (Calgary)", "ca-west-1"},
+		{"Israel (Tel Aviv)", "il-central-1"},
+		{"South America (São Paulo)", "sa-east-1"},
+		{"eu-west-1", "eu-west-1"},
+		{"Unknown (Region)", "Unknown (Region)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, normalizeRegionName(tt.name))
+		})
+	}
+}
+
+func TestConvertLookbackPeriod(t *testing.T) {
+	tests := []struct {
+		period   string
+		expected types.LookbackPeriodInDays
+		wantErr  bool
+	}{
+		{"", types.LookbackPeriodInDaysSevenDays, false},
+		{"7d", types.LookbackPeriodInDaysSevenDays, false},
+		{"30", types.LookbackPeriodInDaysThirtyDays, false},
+		{"60d", types.LookbackPeriodInDaysSixtyDays, false},
+		{"14d", "", true},
+		{"90", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			lookback, err := convertLookbackPeriod(tt.period)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "Cost Explorer only supports 7d, 30d or 60d")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, lookback)
+		})
+	}
+}
+
+// recordingCostExplorerAPI records the requests it receives and returns no recommendations
+type recordingCostExplorerAPI struct {
+	riInput *costexplorer.GetReservationPurchaseRecommendationInput
+	spInput *costexplorer.GetSavingsPlansPurchaseRecommendationInput
+}
+
+func (r *recordingCostExplorerAPI) GetReservationPurchaseRecommendation(ctx context.Context, params *costexplorer.GetReservationPurchaseRecommendationInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationPurchaseRecommendationOutput, error) {
+	r.riInput = params
+	return &costexplorer.GetReservationPurchaseRecommendationOutput{}, nil
+}
+
+func (r *recordingCostExplorerAPI) GetSavingsPlansPurchaseRecommendation(ctx context.Context, params *costexplorer.GetSavingsPlansPurchaseRecommendationInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetSavingsPlansPurchaseRecommendationOutput, error) {
+	r.spInput = params
+	return &costexplorer.GetSavingsPlansPurchaseRecommendationOutput{}, nil
+}
+
+func TestGetRecommendationsAccountID(t *testing.T) {
+	api := &recordingCostExplorerAPI{}
+	client := NewClientWithAPI(api, "us-east-1")
+
+	_, err := client.GetRecommendations(context.Background(), common.RecommendationParams{Service: common.ServiceRDS, AccountID: "123456789012"})
+	require.NoError(t, err)
+	assert.Equal(t, "123456789012", aws.ToString(api.riInput.AccountId))
+
+	_, err = client.GetRecommendations(context.Background(), common.RecommendationParams{Service: common.ServiceSavingsPlans, IncludeSPTypes: []string{"Compute"}, AccountID: "123456789012"})
+	require.NoError(t, err)
+	require.NotNil(t, api.spInput.Filter)
+	assert.Equal(t, types.DimensionLinkedAccount, api.spInput.Filter.Dimensions.Key)
+	assert.Equal(t, []string{"123456789012"}, api.spInput.Filter.Dimensions.Values)
+
+	_, err = client.GetRecommendations(context.Background(), common.RecommendationParams{Service: common.ServiceRDS})
+	require.NoError(t, err)
+	assert.Nil(t, api.riInput.AccountId, "unscoped requests cover all linked accounts")
+}
+
+// describeRegionsCodes lists the regions DescribeRegions returns with AllRegions set in the aws,
+// aws-us-gov and aws-cn partitions
+var describeRegionsCodes = []string{
+	"af-south-1", "ap-east-1", "ap-east-2", "ap-northeast-1", "ap-northeast-2", "ap-northeast-3",
+	"ap-south-1", "ap-south-2", "ap-southeast-1", "ap-southeast-2", "ap-southeast-3", "ap-southeast-4",
+	"ap-southeast-5", "ap-southeast-6", "ap-southeast-7", "ca-central-1", "ca-west-1", "eu-central-1",
+	"eu-central-2", "eu-north-1", "eu-south-1", "eu-south-2", "eu-west-1", "eu-west-2", "eu-west-3",
+	"il-central-1", "me-central-1", "me-south-1", "mx-central-1", "sa-east-1", "us-east-1", "us-east-2",
+	"us-west-1", "us-west-2", "us-gov-east-1", "us-gov-west-1", "cn-north-1", "cn-northwest-1",
+}
+
+func TestRegionCodesByNameCoversAllRegions(t *testing.T) {
+	mapped := make(map[string]bool, len(regionCodesByName))
+	for _, code := range regionCodesByName {
+		mapped[code] = true
+	}
+	for _, code := range describeRegionsCodes {
+		assert.True(t, mapped[code], "no region name maps to %s", code)
+	}
+}
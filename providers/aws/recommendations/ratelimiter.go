@@ -2,12 +2,30 @@ package recommendations
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math"
+	"math/rand"
+	"sync"
 	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+const (
+	// DefaultMaxRetries is the number of times a failed Cost Explorer request is retried by default
+	DefaultMaxRetries = 5
+	// DefaultRetryBaseDelay is the backoff delay before the first retry by default
+	DefaultRetryBaseDelay = 1 * time.Second
+	// defaultMaxRetryDelay caps the exponential backoff delay
+	defaultMaxRetryDelay = 30 * time.Second
 )
 
 // RateLimiter provides rate limiting with exponential backoff
+// It is safe for concurrent use. Wait and ShouldRetry share one retry count between callers, while Do keeps
+// the retry count of each call, so concurrent requests should use Do.
 type RateLimiter struct {
+	mu sync.Mutex
 	// Base delay between requests
 	baseDelay time.Duration
 	// Maximum delay for exponential backoff
@@ -21,9 +39,9 @@ type RateLimiter struct {
 // NewRateLimiter creates a new rate limiter with default settings
 func NewRateLimiter() *RateLimiter {
 	return &RateLimiter{
-		baseDelay:  1 * time.Second,
-		maxDelay:   30 * time.Second,
-		maxRetries: 5,
+		baseDelay:  DefaultRetryBaseDelay,
+		maxDelay:   defaultMaxRetryDelay,
+		maxRetries: DefaultMaxRetries,
 		retryCount: 0,
 	}
 }
@@ -38,15 +56,43 @@ func NewRateLimiterWithOptions(baseDelay, maxDelay time.Duration, maxRetries int
 	}
 }
 
+// NewRetryRateLimiter creates a rate limiter retrying up to maxRetries times, backing off exponentially from baseDelay
+func NewRetryRateLimiter(maxRetries int, baseDelay time.Duration) *RateLimiter {
+	maxDelay := defaultMaxRetryDelay
+	if baseDelay > maxDelay {
+		maxDelay = baseDelay
+	}
+	return NewRateLimiterWithOptions(baseDelay, maxDelay, maxRetries)
+}
+
 // Wait implements exponential backoff delay
 func (r *RateLimiter) Wait(ctx context.Context) error {
-	if r.retryCount == 0 {
+	return r.waitForRetry(ctx, r.GetRetryCount())
+}
+
+// Do calls fn until it succeeds or fails with an error that is not worth retrying, backing off exponentially
+// between attempts, and returns the number of retries made
+func (r *RateLimiter) Do(ctx context.Context, fn func() error) (int, error) {
+	for retryCount := 0; ; retryCount++ {
+		if err := r.waitForRetry(ctx, retryCount); err != nil {
+			return retryCount, fmt.Errorf("rate limiter wait failed: %w", err)
+		}
+		err := fn()
+		if !r.canRetry(err, retryCount) {
+			return retryCount, err
+		}
+	}
+}
+
+// waitForRetry waits the backoff delay before retry number retryCount; the first attempt (0) does not wait
+func (r *RateLimiter) waitForRetry(ctx context.Context, retryCount int) error {
+	if retryCount == 0 {
 		// No delay for first attempt
 		return nil
 	}
 
 	// Calculate exponential backoff with jitter
-	backoffSeconds := math.Pow(2, float64(r.retryCount-1))
+	backoffSeconds := math.Pow(2, float64(retryCount-1))
 	delay := time.Duration(backoffSeconds) * r.baseDelay
 
 	// Cap at maximum delay
@@ -54,12 +100,8 @@ func (r *RateLimiter) Wait(ctx context.Context) error {
 		delay = r.maxDelay
 	}
 
-	// Add jitter (up to 20% of delay)
-	jitter := time.Duration(float64(delay) * 0.2 * math.Sin(float64(time.Now().UnixNano())))
-	if jitter < 0 {
-		jitter = -jitter
-	}
-	delay += jitter
+	// Add jitter (up to 20% of delay) so throttled callers don't retry in lockstep
+	delay += time.Duration(float64(delay) * 0.2 * rand.Float64())
 
 	select {
 	case <-time.After(delay):
@@ -71,28 +113,117 @@ func (r *RateLimiter) Wait(ctx context.Context) error {
 
 // ShouldRetry checks if we should retry based on error and retry count
 func (r *RateLimiter) ShouldRetry(err error) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if err == nil {
-		r.Reset()
+		r.retryCount = 0
 		return false
 	}
-
-	// Check if we've exceeded max retries
-	if r.retryCount >= r.maxRetries {
+	if !r.canRetry(err, r.retryCount) {
 		return false
 	}
-
-	// Check for retryable errors (you can expand this based on AWS error types)
-	// For now, we'll retry on any error
 	r.retryCount++
 	return true
 }
 
+// canRetry reports whether a request that failed with err after retryCount retries may be retried
+func (r *RateLimiter) canRetry(err error, retryCount int) bool {
+	// Errors such as access denied or invalid parameters fail the same way on every attempt
+	return err != nil && retryCount < r.maxRetries && isRetryableError(err)
+}
+
+// throttlingErrorCodes are the AWS error codes of throttled requests, which are client faults worth retrying
+var throttlingErrorCodes = map[string]bool{
+	"Throttling":                true,
+	"ThrottlingException":       true,
+	"ThrottledException":        true,
+	"TooManyRequestsException":  true,
+	"RequestLimitExceeded":      true,
+	"RequestThrottled":          true,
+	"RequestThrottledException": true,
+	"LimitExceededException":    true,
+}
+
+// terminalErrorCodes are the AWS error codes of authorization and validation failures, which fail the same way
+// on every attempt. They are listed because unmodeled errors, such as AccessDeniedException, don't report a fault.
+var terminalErrorCodes = map[string]bool{
+	"AccessDenied":                true,
+	"AccessDeniedException":       true,
+	"AuthFailure":                 true,
+	"UnauthorizedOperation":       true,
+	"UnrecognizedClientException": true,
+	"InvalidClientTokenId":        true,
+	"ExpiredToken":                true,
+	"ExpiredTokenException":       true,
+	"SignatureDoesNotMatch":       true,
+	"MissingAuthenticationToken":  true,
+	"OptInRequired":               true,
+	"ValidationException":         true,
+	"ValidationError":             true,
+	"InvalidParameterValue":       true,
+	"InvalidParameterCombination": true,
+	"DataUnavailableException":    true,
+}
+
+// isRetryableError reports whether a failed request may succeed when retried
+// Throttling and server errors are retried, while other client errors, such as AccessDeniedException or
+// ValidationException, and cancelled contexts are terminal. Errors that are not AWS API errors, such as
+// network failures, are retried.
+func isRetryableError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	code := apiErr.ErrorCode()
+	if throttlingErrorCodes[code] {
+		return true
+	}
+	return !terminalErrorCodes[code] && apiErr.ErrorFault() != smithy.FaultClient
+}
+
 // Reset resets the retry counter
 func (r *RateLimiter) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.retryCount = 0
 }
 
 // GetRetryCount returns the current retry count
 func (r *RateLimiter) GetRetryCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	return r.retryCount
 }
+
+// RegionalRateLimiters hands out an independent RateLimiter per region,
+// so backoff in one busy region does not slow down requests for other regions
+type RegionalRateLimiters struct {
+	mu         sync.Mutex
+	limiters   map[string]*RateLimiter
+	newLimiter func() *RateLimiter
+}
+
+// NewRegionalRateLimiters creates a per-region limiter set using newLimiter to create each region's limiter
+func NewRegionalRateLimiters(newLimiter func() *RateLimiter) *RegionalRateLimiters {
+	return &RegionalRateLimiters{
+		limiters:   make(map[string]*RateLimiter),
+		newLimiter: newLimiter,
+	}
+}
+
+// ForRegion returns the rate limiter for a region, creating it on first use
+func (r *RegionalRateLimiters) ForRegion(region string) *RateLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limiter, ok := r.limiters[region]
+	if !ok {
+		limiter = r.newLimiter()
+		r.limiters[region] = limiter
+	}
+	return limiter
+}
@@ -0,0 +1,347 @@
+package recommendations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// throttlingCostExplorerAPI fails a busy region's requests twice before succeeding
+type throttlingCostExplorerAPI struct {
+	mu         sync.Mutex
+	busyRegion string
+	calls      map[string]int
+}
+
+type regionKey struct{}
+
+func (f *throttlingCostExplorerAPI) respond(ctx context.Context) error {
+	region, _ := ctx.Value(regionKey{}).(string)
+
+	// Simulate API latency so requests for different regions overlap
+	time.Sleep(100 * time.Microsecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[region]++
+	if region == f.busyRegion && f.calls[region]%3 != 0 {
+		return errors.New("ThrottlingException: rate exceeded")
+	}
+	return nil
+}
+
+func (f *throttlingCostExplorerAPI) GetReservationPurchaseRecommendation(ctx context.Context, params *costexplorer.GetReservationPurchaseRecommendationInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationPurchaseRecommendationOutput, error) {
+	if err := f.respond(ctx); err != nil {
+		return nil, err
+	}
+	return &costexplorer.GetReservationPurchaseRecommendationOutput{}, nil
+}
+
+func (f *throttlingCostExplorerAPI) GetSavingsPlansPurchaseRecommendation(ctx context.Context, params *costexplorer.GetSavingsPlansPurchaseRecommendationInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetSavingsPlansPurchaseRecommendationOutput, error) {
+	if err := f.respond(ctx); err != nil {
+		return nil, err
+	}
+	return &costexplorer.GetSavingsPlansPurchaseRecommendationOutput{}, nil
+}
+
+func newFastRateLimiter() *RateLimiter {
+	return NewRateLimiterWithOptions(2*time.Millisecond, 20*time.Millisecond, 5)
+}
+
+func newThrottlingClient(perRegion bool) *Client {
+	api := &throttlingCostExplorerAPI{busyRegion: "us-east-1", calls: make(map[string]int)}
+	client := NewClientWithAPI(api, "us-east-1")
+	client.rateLimiter = newFastRateLimiter()
+	if perRegion {
+		client.regionalLimiters = NewRegionalRateLimiters(newFastRateLimiter)
+	}
+	return client
+}
+
+// scanRegionsConcurrently fetches recommendations for several services in all regions at once
+// It returns the total time spent on requests for regions other than busyRegion
+func scanRegionsConcurrently(client *Client, regions []string, busyRegion string) (time.Duration, error) {
+	services := []common.ServiceType{common.ServiceRDS, common.ServiceElastiCache, common.ServiceEC2, common.ServiceOpenSearch}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var quietTime time.Duration
+	errs := make(chan error, len(regions)*len(services))
+	for _, region := range regions {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+			ctx := context.WithValue(context.Background(), regionKey{}, region)
+			for _, service := range services {
+				start := time.Now()
+				_, err := client.GetRecommendations(ctx, common.RecommendationParams{
+					Service: service,
+					Region:  region,
+					Term:    "1yr",
+				})
+				if region != busyRegion {
+					mu.Lock()
+					quietTime += time.Since(start)
+					mu.Unlock()
+				}
+				errs <- err
+			}
+		}(region)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return 0, err
+		}
+	}
+	return quietTime, nil
+}
+
+// benchmarkConcurrentScan reports the average request latency of the regions that are not throttled
+func benchmarkConcurrentScan(b *testing.B, perRegion bool) {
+	client := newThrottlingClient(perRegion)
+	var quietTime time.Duration
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		elapsed, err := scanRegionsConcurrently(client, benchmarkRegions, "us-east-1")
+		if err != nil {
+			b.Fatal(err)
+		}
+		quietTime += elapsed
+	}
+	quietRequests := b.N * (len(benchmarkRegions) - 1) * 4
+	b.ReportMetric(float64(quietTime.Microseconds())/float64(quietRequests), "quiet-region-µs/req")
+}
+
+var benchmarkRegions = []string{"us-east-1", "us-west-2", "eu-west-1", "eu-central-1", "ap-southeast-1", "ap-northeast-1"}
+
+func TestRegionalRateLimiters_ForRegion(t *testing.T) {
+	limiters := NewRegionalRateLimiters(NewRateLimiter)
+
+	east := limiters.ForRegion("us-east-1")
+	assert.Same(t, east, limiters.ForRegion("us-east-1"))
+	assert.NotSame(t, east, limiters.ForRegion("eu-west-1"))
+
+	// Backoff state in one region does not leak into another
+	east.ShouldRetry(errors.New("throttled"))
+	assert.Equal(t, 1, east.GetRetryCount())
+	assert.Equal(t, 0, limiters.ForRegion("eu-west-1").GetRetryCount())
+}
+
+func TestClient_RateLimiterFor(t *testing.T) {
+	client := NewClientWithAPI(nil, "us-east-1")
+	assert.Same(t, client.rateLimiter, client.rateLimiterFor("us-east-1"))
+	assert.Same(t, client.rateLimiter, client.rateLimiterFor("eu-west-1"))
+
+	client.EnablePerRegionRateLimiting()
+	assert.NotSame(t, client.rateLimiter, client.rateLimiterFor("us-east-1"))
+	assert.NotSame(t, client.rateLimiterFor("us-east-1"), client.rateLimiterFor("eu-west-1"))
+}
+
+func TestClient_PerRegionRateLimitingConcurrentScan(t *testing.T) {
+	client := newThrottlingClient(true)
+	_, err := scanRegionsConcurrently(client, benchmarkRegions, "us-east-1")
+	require.NoError(t, err)
+}
+
+// regionThrottlingCostExplorerAPI throttles the first throttled requests of every region
+type regionThrottlingCostExplorerAPI struct {
+	mu        sync.Mutex
+	throttled int
+	calls     map[string]int
+}
+
+func (f *regionThrottlingCostExplorerAPI) GetReservationPurchaseRecommendation(ctx context.Context, params *costexplorer.GetReservationPurchaseRecommendationInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationPurchaseRecommendationOutput, error) {
+	region, _ := ctx.Value(regionKey{}).(string)
+	time.Sleep(100 * time.Microsecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[region]++
+	if f.calls[region] <= f.throttled {
+		return nil, &smithy.GenericAPIError{Code: "ThrottlingException", Fault: smithy.FaultClient}
+	}
+	return &costexplorer.GetReservationPurchaseRecommendationOutput{}, nil
+}
+
+func (f *regionThrottlingCostExplorerAPI) GetSavingsPlansPurchaseRecommendation(ctx context.Context, params *costexplorer.GetSavingsPlansPurchaseRecommendationInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetSavingsPlansPurchaseRecommendationOutput, error) {
+	return &costexplorer.GetSavingsPlansPurchaseRecommendationOutput{}, nil
+}
+
+func TestClient_SharedRateLimiterKeepsRetriesPerCall(t *testing.T) {
+	tests := []struct {
+		name      string
+		throttled int
+		wantErr   string
+	}{
+		{name: "every call gets its own retries", throttled: 3},
+		{name: "exhausted retries are reported per call", throttled: 10, wantErr: "after 3 retries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &regionThrottlingCostExplorerAPI{throttled: tt.throttled, calls: make(map[string]int)}
+			client := NewClientWithAPI(api, "us-east-1")
+			client.SetRetryPolicy(3, time.Millisecond)
+
+			var wg sync.WaitGroup
+			errs := make([]error, len(benchmarkRegions))
+			for i, region := range benchmarkRegions {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ctx := context.WithValue(context.Background(), regionKey{}, region)
+					_, errs[i] = client.GetRecommendations(ctx, common.RecommendationParams{Service: common.ServiceRDS, Region: region, Term: "1yr"})
+				}()
+			}
+			wg.Wait()
+
+			for i, region := range benchmarkRegions {
+				if tt.wantErr != "" {
+					assert.ErrorContains(t, errs[i], tt.wantErr, region)
+				} else {
+					assert.NoError(t, errs[i], region)
+				}
+				assert.Equal(t, 4, api.calls[region], "%s: the initial request plus its own 3 retries", region)
+			}
+		})
+	}
+}
+
+func BenchmarkConcurrentScan_SharedRateLimiter(b *testing.B) {
+	benchmarkConcurrentScan(b, false)
+}
+
+func BenchmarkConcurrentScan_PerRegionRateLimiter(b *testing.B) {
+	benchmarkConcurrentScan(b, true)
+}
+
+// alwaysThrottlingCostExplorerAPI rejects every request with a throttling error
+type alwaysThrottlingCostExplorerAPI struct {
+	calls int
+}
+
+func (f *alwaysThrottlingCostExplorerAPI) GetReservationPurchaseRecommendation(ctx context.Context, params *costexplorer.GetReservationPurchaseRecommendationInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationPurchaseRecommendationOutput, error) {
+	f.calls++
+	return nil, errors.New("ThrottlingException: rate exceeded")
+}
+
+func (f *alwaysThrottlingCostExplorerAPI) GetSavingsPlansPurchaseRecommendation(ctx context.Context, params *costexplorer.GetSavingsPlansPurchaseRecommendationInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetSavingsPlansPurchaseRecommendationOutput, error) {
+	f.calls++
+	return nil, errors.New("ThrottlingException: rate exceeded")
+}
+
+func TestClient_SetRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		perRegion  bool
+	}{
+		{name: "three retries", maxRetries: 3},
+		{name: "no retries", maxRetries: 0},
+		{name: "per-region limiter", maxRetries: 2, perRegion: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &alwaysThrottlingCostExplorerAPI{}
+			client := NewClientWithAPI(api, "us-east-1")
+			if tt.perRegion {
+				client.EnablePerRegionRateLimiting()
+			}
+			client.SetRetryPolicy(tt.maxRetries, time.Millisecond)
+
+			_, err := client.GetRecommendations(context.Background(), common.RecommendationParams{Service: common.ServiceRDS, Region: "us-east-1", Term: "1yr"})
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "ThrottlingException")
+			assert.Equal(t, tt.maxRetries+1, api.calls, "the initial request plus the configured retries")
+		})
+	}
+}
+
+func TestNewRetryRateLimiter(t *testing.T) {
+	limiter := NewRetryRateLimiter(3, 40*time.Second)
+	assert.Equal(t, 3, limiter.maxRetries)
+	assert.Equal(t, 40*time.Second, limiter.baseDelay)
+	assert.Equal(t, 40*time.Second, limiter.maxDelay, "the cap never undercuts the base delay")
+
+	assert.Equal(t, defaultMaxRetryDelay, NewRetryRateLimiter(3, time.Second).maxDelay)
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"throttling", &smithy.GenericAPIError{Code: "ThrottlingException", Fault: smithy.FaultClient}, true},
+		{"Cost Explorer rate limit", &smithy.GenericAPIError{Code: "LimitExceededException", Fault: smithy.FaultClient}, true},
+		{"server error", &smithy.GenericAPIError{Code: "InternalServerError", Fault: smithy.FaultServer}, true},
+		{"unknown fault", &smithy.GenericAPIError{Code: "ServiceUnavailable"}, true},
+		{"access denied without fault", &smithy.GenericAPIError{Code: "AccessDeniedException"}, false},
+		{"validation", &smithy.GenericAPIError{Code: "ValidationException", Fault: smithy.FaultClient}, false},
+		{"other client fault", &smithy.GenericAPIError{Code: "InvalidNextTokenException", Fault: smithy.FaultClient}, false},
+		{"wrapped access denied", fmt.Errorf("operation error: %w", &smithy.GenericAPIError{Code: "AccessDenied"}), false},
+		{"network error", errors.New("connection reset by peer"), true},
+		{"cancelled", context.Canceled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isRetryableError(tt.err))
+		})
+	}
+}
+
+// failingCostExplorerAPI fails every request with err and counts them
+type failingCostExplorerAPI struct {
+	err   error
+	calls int
+}
+
+func (f *failingCostExplorerAPI) GetReservationPurchaseRecommendation(ctx context.Context, params *costexplorer.GetReservationPurchaseRecommendationInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationPurchaseRecommendationOutput, error) {
+	f.calls++
+	return nil, f.err
+}
+
+func (f *failingCostExplorerAPI) GetSavingsPlansPurchaseRecommendation(ctx context.Context, params *costexplorer.GetSavingsPlansPurchaseRecommendationInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetSavingsPlansPurchaseRecommendationOutput, error) {
+	f.calls++
+	return nil, f.err
+}
+
+func TestClient_RetriesOnlyRetryableErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		expectedCalls int
+	}{
+		{"throttling is retried", &smithy.GenericAPIError{Code: "ThrottlingException", Fault: smithy.FaultClient}, 4},
+		{"access denied fails immediately", &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &failingCostExplorerAPI{err: tt.err}
+			client := NewClientWithAPI(api, "us-east-1")
+			client.SetRetryPolicy(3, time.Millisecond)
+
+			_, err := client.GetRecommendations(context.Background(), common.RecommendationParams{Service: common.ServiceRDS, Region: "us-east-1", Term: "1yr"})
+
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.expectedCalls, api.calls)
+		})
+	}
+}
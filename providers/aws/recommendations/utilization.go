@@ -0,0 +1,125 @@
+package recommendations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// ReservationUtilizationAPI defines the Cost Explorer operations used to report reservation utilization
+type ReservationUtilizationAPI interface {
+	GetReservationUtilization(ctx context.Context, params *costexplorer.GetReservationUtilizationInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationUtilizationOutput, error)
+	GetReservationCoverage(ctx context.Context, params *costexplorer.GetReservationCoverageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error)
+}
+
+// ReservationUtilization is the utilization and coverage of the existing reservations of a service over a period
+type ReservationUtilization struct {
+	Service common.ServiceType `json:"service"`
+	// HasReservations is false when Cost Explorer has no reservation data for the service in the period
+	HasReservations       bool    `json:"has_reservations"`
+	UtilizationPercentage float64 `json:"utilization_percentage"`
+	PurchasedHours        float64 `json:"purchased_hours"`
+	UnusedHours           float64 `json:"unused_hours"`
+	NetSavings            float64 `json:"net_savings"`
+	// CoveragePercentage is the share of the running hours of the service covered by reservations
+	CoveragePercentage float64 `json:"coverage_percentage"`
+}
+
+// UtilizationClient fetches the utilization and coverage of existing reservations from Cost Explorer
+type UtilizationClient struct {
+	api         ReservationUtilizationAPI
+	rateLimiter *RateLimiter
+}
+
+// NewUtilizationClient creates a new reservation utilization client
+func NewUtilizationClient(cfg aws.Config) *UtilizationClient {
+	// Cost Explorer is only served from us-east-1
+	ceConfig := cfg.Copy()
+	ceConfig.Region = "us-east-1"
+	ceConfig.BaseEndpoint = aws.String("https://ce.us-east-1.amazonaws.com")
+
+	return NewUtilizationClientWithAPI(costexplorer.NewFromConfig(ceConfig))
+}
+
+// NewUtilizationClientWithAPI creates a new reservation utilization client with a custom Cost Explorer API (for testing)
+func NewUtilizationClientWithAPI(api ReservationUtilizationAPI) *UtilizationClient {
+	return &UtilizationClient{
+		api:         api,
+		rateLimiter: NewRateLimiter(),
+	}
+}
+
+// GetReservationUtilization returns the utilization and coverage of the reservations of a service between start and end
+// The end date is exclusive. A service without reservations in the period is reported with HasReservations false.
+func (c *UtilizationClient) GetReservationUtilization(ctx context.Context, service common.ServiceType, start, end time.Time) (ReservationUtilization, error) {
+	result := ReservationUtilization{Service: service}
+	period := &types.DateInterval{
+		Start: aws.String(start.Format("2006-01-02")),
+		End:   aws.String(end.Format("2006-01-02")),
+	}
+	filter := &types.Expression{
+		Dimensions: &types.DimensionValues{
+			Key:    types.DimensionService,
+			Values: []string{getServiceStringForCostExplorer(service)},
+		},
+	}
+
+	var utilization *costexplorer.GetReservationUtilizationOutput
+	err := c.withRetries(ctx, func() error {
+		var err error
+		utilization, err = c.api.GetReservationUtilization(ctx, &costexplorer.GetReservationUtilizationInput{TimePeriod: period, Filter: filter})
+		return err
+	})
+	var unavailable *types.DataUnavailableException
+	if errors.As(err, &unavailable) {
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to get reservation utilization: %w", err)
+	}
+
+	if total := utilization.Total; total != nil {
+		result.PurchasedHours = parseAmount(total.PurchasedHours)
+		result.HasReservations = result.PurchasedHours > 0
+		result.UtilizationPercentage = parseAmount(total.UtilizationPercentage)
+		result.UnusedHours = parseAmount(total.UnusedHours)
+		result.NetSavings = parseAmount(total.NetRISavings)
+	}
+	if !result.HasReservations {
+		return result, nil
+	}
+
+	var coverage *costexplorer.GetReservationCoverageOutput
+	err = c.withRetries(ctx, func() error {
+		var err error
+		coverage, err = c.api.GetReservationCoverage(ctx, &costexplorer.GetReservationCoverageInput{TimePeriod: period, Filter: filter})
+		return err
+	})
+	if err != nil && !errors.As(err, &unavailable) {
+		return result, fmt.Errorf("failed to get reservation coverage: %w", err)
+	}
+	if err == nil && coverage.Total != nil && coverage.Total.CoverageHours != nil {
+		result.CoveragePercentage = parseAmount(coverage.Total.CoverageHours.CoverageHoursPercentage)
+	}
+	return result, nil
+}
+
+// withRetries calls fn until it succeeds or the rate limiter gives up retrying its error
+func (c *UtilizationClient) withRetries(ctx context.Context, fn func() error) error {
+	_, err := c.rateLimiter.Do(ctx, fn)
+	return err
+}
+
+// parseAmount parses a Cost Explorer decimal string, returning 0 when it is missing or malformed
+func parseAmount(value *string) float64 {
+	amount, _ := strconv.ParseFloat(aws.ToString(value), 64)
+	return amount
+}
@@ -0,0 +1,102 @@
+package recommendations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// fakeUtilizationAPI returns canned Cost Explorer utilization and coverage responses and records the requests
+type fakeUtilizationAPI struct {
+	utilization    *costexplorer.GetReservationUtilizationOutput
+	utilizationErr error
+	coverage       *costexplorer.GetReservationCoverageOutput
+	coverageErr    error
+
+	utilizationInput *costexplorer.GetReservationUtilizationInput
+	coverageCalls    int
+}
+
+func (f *fakeUtilizationAPI) GetReservationUtilization(ctx context.Context, params *costexplorer.GetReservationUtilizationInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationUtilizationOutput, error) {
+	f.utilizationInput = params
+	return f.utilization, f.utilizationErr
+}
+
+func (f *fakeUtilizationAPI) GetReservationCoverage(ctx context.Context, params *costexplorer.GetReservationCoverageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error) {
+	f.coverageCalls++
+	return f.coverage, f.coverageErr
+}
+
+func TestUtilizationClient_GetReservationUtilization(t *testing.T) {
+	start := time.Date(2026, 9, 16, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		api           *fakeUtilizationAPI
+		expected      ReservationUtilization
+		errorContains string
+	}{
+		{
+			name: "utilization and coverage",
+			api: &fakeUtilizationAPI{
+				utilization: &costexplorer.GetReservationUtilizationOutput{Total: &types.ReservationAggregates{
+					UtilizationPercentage: aws.String("62.5"),
+					PurchasedHours:        aws.String("1440"),
+					UnusedHours:           aws.String("540"),
+					NetRISavings:          aws.String("123.45"),
+				}},
+				coverage: &costexplorer.GetReservationCoverageOutput{Total: &types.Coverage{
+					CoverageHours: &types.CoverageHours{CoverageHoursPercentage: aws.String("40")},
+				}},
+			},
+			expected: ReservationUtilization{Service: common.ServiceRDS, HasReservations: true, UtilizationPercentage: 62.5, PurchasedHours: 1440, UnusedHours: 540, NetSavings: 123.45, CoveragePercentage: 40},
+		},
+		{
+			name:     "no reservation data",
+			api:      &fakeUtilizationAPI{utilizationErr: &types.DataUnavailableException{Message: aws.String("no data")}},
+			expected: ReservationUtilization{Service: common.ServiceRDS},
+		},
+		{
+			name:     "no purchased hours",
+			api:      &fakeUtilizationAPI{utilization: &costexplorer.GetReservationUtilizationOutput{Total: &types.ReservationAggregates{PurchasedHours: aws.String("0")}}},
+			expected: ReservationUtilization{Service: common.ServiceRDS},
+		},
+		{
+			name:          "access denied",
+			api:           &fakeUtilizationAPI{utilizationErr: errors.New("AccessDeniedException: not authorized")},
+			errorContains: "failed to get reservation utilization",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewUtilizationClientWithAPI(tt.api)
+			client.rateLimiter = NewRetryRateLimiter(0, time.Millisecond)
+
+			result, err := client.GetReservationUtilization(context.Background(), common.ServiceRDS, start, end)
+
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, "2026-09-16", aws.ToString(tt.api.utilizationInput.TimePeriod.Start))
+			assert.Equal(t, "2026-10-16", aws.ToString(tt.api.utilizationInput.TimePeriod.End))
+			assert.Equal(t, []string{"Amazon Relational Database Service"}, tt.api.utilizationInput.Filter.Dimensions.Values)
+			if !tt.expected.HasReservations {
+				assert.Zero(t, tt.api.coverageCalls, "coverage is only fetched for services with reservations")
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package aws
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/LeanerCloud/CUDly/pkg/provider"
 
 	"github.com/LeanerCloud/CUDly/providers/aws/recommendations"
+	"github.com/LeanerCloud/CUDly/providers/aws/services/dynamodb"
 	"github.com/LeanerCloud/CUDly/providers/aws/services/ec2"
 	"github.com/LeanerCloud/CUDly/providers/aws/services/elasticache"
 	"github.com/LeanerCloud/CUDly/providers/aws/services/memorydb"
@@ -49,6 +51,11 @@ func NewMemoryDBClient(cfg aws.Config) provider.ServiceClient {
 	return memorydb.NewClient(cfg)
 }
 
+// NewDynamoDBClient creates a new DynamoDB reserved capacity service client
+func NewDynamoDBClient(cfg aws.Config) provider.ServiceClient {
+	return dynamodb.NewClient(cfg)
+}
+
 // NewSavingsPlansClient creates a new Savings Plans service client
 func NewSavingsPlansClient(cfg aws.Config) provider.ServiceClient {
 	return savingsplans.NewClient(cfg)
@@ -66,6 +73,28 @@ func NewRecommendationsClient(cfg aws.Config) provider.RecommendationsClient {
 	}
 }
 
+// NewPerRegionRecommendationsClient creates a recommendations client that rate limits each region independently
+func NewPerRegionRecommendationsClient(cfg aws.Config) provider.RecommendationsClient {
+	client := recommendations.NewClient(cfg)
+	client.EnablePerRegionRateLimiting()
+	return &RecommendationsClientAdapter{
+		client: client,
+	}
+}
+
+// NewRecommendationsClientWithRetries creates a recommendations client that retries failed Cost Explorer requests
+// up to maxRetries times with exponential backoff from baseDelay, optionally rate limiting each region independently
+func NewRecommendationsClientWithRetries(cfg aws.Config, perRegion bool, maxRetries int, baseDelay time.Duration) provider.RecommendationsClient {
+	client := recommendations.NewClient(cfg)
+	client.SetRetryPolicy(maxRetries, baseDelay)
+	if perRegion {
+		client.EnablePerRegionRateLimiting()
+	}
+	return &RecommendationsClientAdapter{
+		client: client,
+	}
+}
+
 // GetRecommendations gets recommendations with filtering
 func (r *RecommendationsClientAdapter) GetRecommendations(ctx context.Context, params common.RecommendationParams) ([]common.Recommendation, error) {
 	recs, err := r.client.GetRecommendations(ctx, params)
@@ -0,0 +1,92 @@
+// Package dynamodb provides AWS DynamoDB reserved capacity client
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+)
+
+// capacityBlockSize is the number of capacity units DynamoDB reserved capacity is sold in
+const capacityBlockSize = 100
+
+// Client handles AWS DynamoDB reserved capacity
+// DynamoDB does not expose reserved capacity offerings or purchases through its public API,
+// so recommendations come from Cost Explorer and purchases are made in the DynamoDB console.
+type Client struct {
+	region string
+}
+
+// NewClient creates a new DynamoDB client
+func NewClient(cfg aws.Config) *Client {
+	return &Client{
+		region: cfg.Region,
+	}
+}
+
+// GetServiceType returns the service type
+func (c *Client) GetServiceType() common.ServiceType {
+	return common.ServiceDynamoDB
+}
+
+// GetRegion returns the region
+func (c *Client) GetRegion() string {
+	return c.region
+}
+
+// GetRecommendations returns empty as DynamoDB uses centralized Cost Explorer recommendations
+func (c *Client) GetRecommendations(ctx context.Context, params common.RecommendationParams) ([]common.Recommendation, error) {
+	return []common.Recommendation{}, nil
+}
+
+// GetExistingCommitments returns no commitments as existing reserved capacity cannot be listed through the API
+func (c *Client) GetExistingCommitments(ctx context.Context) ([]common.Commitment, error) {
+	return []common.Commitment{}, nil
+}
+
+// PurchaseCommitment reports that DynamoDB reserved capacity has to be purchased in the console
+func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendation) (common.PurchaseResult, error) {
+	result := common.PurchaseResult{
+		Recommendation: rec,
+		DryRun:         false,
+		Success:        false,
+		Timestamp:      time.Now(),
+	}
+
+	if err := c.ValidateOffering(ctx, rec); err != nil {
+		result.Error = err
+		return result, result.Error
+	}
+
+	result.Error = fmt.Errorf("DynamoDB reserved capacity cannot be purchased through the AWS API, reserve %d %s units in %s in the DynamoDB console",
+		rec.Count, rec.ResourceType, c.region)
+	return result, result.Error
+}
+
+// ValidateOffering checks that the recommendation describes reservable DynamoDB capacity
+func (c *Client) ValidateOffering(ctx context.Context, rec common.Recommendation) error {
+	if rec.ResourceType != common.DynamoDBReadCapacity && rec.ResourceType != common.DynamoDBWriteCapacity {
+		return fmt.Errorf("invalid DynamoDB capacity type %q", rec.ResourceType)
+	}
+	if rec.Count < capacityBlockSize || rec.Count%capacityBlockSize != 0 {
+		return fmt.Errorf("DynamoDB reserved capacity is sold in blocks of %d units, got %d", capacityBlockSize, rec.Count)
+	}
+	return nil
+}
+
+// GetOfferingDetails is not supported as DynamoDB does not publish reserved capacity offerings through the API
+func (c *Client) GetOfferingDetails(ctx context.Context, rec common.Recommendation) (*common.OfferingDetails, error) {
+	return nil, fmt.Errorf("offering details are not available for DynamoDB reserved capacity")
+}
+
+// GetValidResourceTypes returns the DynamoDB capacity types that can be reserved
+func (c *Client) GetValidResourceTypes(ctx context.Context) ([]string, error) {
+	return []string{
+		common.DynamoDBReadCapacity,
+		common.DynamoDBWriteCapacity,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package dynamodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LeanerCloud/CUDly/pkg/common"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	client := NewClient(aws.Config{Region: "us-east-1"})
+
+	assert.NotNil(t, client)
+	assert.Equal(t, "us-east-1", client.GetRegion())
+	assert.Equal(t, common.ServiceDynamoDB, client.GetServiceType())
+}
+
+func TestClient_ValidateOffering(t *testing.T) {
+	client := &Client{region: "us-east-1"}
+
+	tests := []struct {
+		name    string
+		rec     common.Recommendation
+		wantErr string
+	}{
+		{
+			name: "read capacity",
+			rec:  common.Recommendation{ResourceType: common.DynamoDBReadCapacity, Count: 200},
+		},
+		{
+			name: "write capacity",
+			rec:  common.Recommendation{ResourceType: common.DynamoDBWriteCapacity, Count: 100},
+		},
+		{
+			name:    "unknown capacity type",
+			rec:     common.Recommendation{ResourceType: "db.r5.large", Count: 100},
+			wantErr: "invalid DynamoDB capacity type",
+		},
+		{
+			name:    "partial block",
+			rec:     common.Recommendation{ResourceType: common.DynamoDBReadCapacity, Count: 150},
+			wantErr: "blocks of 100 units",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.ValidateOffering(context.Background(), tt.rec)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_PurchaseCommitment(t *testing.T) {
+	client := &Client{region: "us-east-1"}
+	rec := common.Recommendation{ResourceType: common.DynamoDBWriteCapacity, Count: 100}
+
+	result, err := client.PurchaseCommitment(context.Background(), rec)
+	require.Error(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, err.Error(), "DynamoDB console")
+	assert.Equal(t, rec, result.Recommendation)
+}
+
+func TestClient_GetExistingCommitments(t *testing.T) {
+	client := &Client{region: "us-east-1"}
+
+	commitments, err := client.GetExistingCommitments(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, commitments)
+}
+
+func TestClient_GetValidResourceTypes(t *testing.T) {
+	client := &Client{region: "us-east-1"}
+
+	types, err := client.GetValidResourceTypes(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read-capacity", "write-capacity"}, types)
+}
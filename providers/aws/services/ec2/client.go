@@ -3,13 +3,16 @@ package ec2
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
 )
@@ -26,6 +29,8 @@ type EC2API interface {
 type Client struct {
 	client EC2API
 	region string
+	// includeMarketplace makes GetOfferingDetails consider Reserved Instance Marketplace listings
+	includeMarketplace bool
 }
 
 // NewClient creates a new EC2 client
@@ -36,6 +41,12 @@ func NewClient(cfg aws.Config) *Client {
 	}
 }
 
+// SetIncludeMarketplace makes GetOfferingDetails return a cheaper Marketplace listing when one exists
+// Purchases always use standard offerings regardless of this setting
+func (c *Client) SetIncludeMarketplace(include bool) {
+	c.includeMarketplace = include
+}
+
 // SetEC2API sets a custom EC2 API client (for testing)
 func (c *Client) SetEC2API(api EC2API) {
 	c.client = api
@@ -70,6 +81,7 @@ func (c *Client) GetExistingCommitments(ctx context.Context) ([]common.Commitmen
 		},
 	}
 
+	// DescribeReservedInstances is not paginated: it returns every matching reservation in one response
 	response, err := c.client.DescribeReservedInstances(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to describe reserved instances: %w", err)
@@ -130,16 +142,20 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 	}
 
 	// Execute the purchase
+	result.Request = input
 	response, err := c.client.PurchaseReservedInstancesOffering(ctx, input)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to purchase EC2 RI: %w", err)
 		return result, result.Error
 	}
+	result.Response = response
 
 	// Extract purchase information
 	if response.ReservedInstancesId != nil {
 		result.Success = true
 		result.CommitmentID = aws.ToString(response.ReservedInstancesId)
+		// The purchase response does not report the instance count; the purchase is all-or-nothing
+		result.RecordFulfillment(rec.Count)
 	} else {
 		result.Error = fmt.Errorf("purchase response was empty")
 		return result, result.Error
@@ -148,11 +164,80 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 	return result, nil
 }
 
+// DryRunPurchase validates an EC2 Reserved Instance purchase server-side with the DryRun parameter of the purchase API,
+// which checks permissions and the offering without buying anything. EC2 reports a request that would have
+// succeeded with the DryRunOperation error code.
+func (c *Client) DryRunPurchase(ctx context.Context, rec common.Recommendation) (common.PurchaseResult, error) {
+	result := common.PurchaseResult{
+		Recommendation: rec,
+		DryRun:         true,
+		Success:        false,
+		Timestamp:      time.Now(),
+	}
+
+	offeringID, err := c.findOfferingID(ctx, rec)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to find offering: %w", err)
+		return result, result.Error
+	}
+
+	input := &ec2.PurchaseReservedInstancesOfferingInput{
+		ReservedInstancesOfferingId: aws.String(offeringID),
+		InstanceCount:               aws.Int32(int32(rec.Count)),
+		DryRun:                      aws.Bool(true),
+	}
+	result.Request = input
+	_, err = c.client.PurchaseReservedInstancesOffering(ctx, input)
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "DryRunOperation" {
+		result.Success = true
+		return result, nil
+	}
+	if err == nil {
+		err = fmt.Errorf("purchase API did not report a dry run result")
+	}
+	result.Error = fmt.Errorf("dry run purchase of EC2 RI failed: %w", err)
+	return result, result.Error
+}
+
 // findOfferingID finds the appropriate EC2 Reserved Instance offering ID
 func (c *Client) findOfferingID(ctx context.Context, rec common.Recommendation) (string, error) {
+	filters, err := c.buildOfferingFilters(rec)
+	if err != nil {
+		return "", err
+	}
+
+	// Add duration filter
+	durationValue := c.getDurationValue(rec.Term)
+	filters = append(filters, types.Filter{
+		Name:   aws.String("duration"),
+		Values: []string{fmt.Sprintf("%d", durationValue)},
+	})
+
+	input := &ec2.DescribeReservedInstancesOfferingsInput{
+		Filters:            filters,
+		IncludeMarketplace: aws.Bool(false),
+		MaxResults:         aws.Int32(100),
+	}
+
+	result, err := c.client.DescribeReservedInstancesOfferings(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to describe offerings: %w", err)
+	}
+
+	if len(result.ReservedInstancesOfferings) == 0 {
+		return "", fmt.Errorf("no offerings found for %s", rec.ResourceType)
+	}
+
+	return aws.ToString(result.ReservedInstancesOfferings[0].ReservedInstancesOfferingId), nil
+}
+
+// buildOfferingFilters returns the offering search filters for a recommendation, excluding the duration
+func (c *Client) buildOfferingFilters(rec common.Recommendation) ([]types.Filter, error) {
 	details, ok := rec.Details.(*common.ComputeDetails)
 	if !ok || details == nil {
-		return "", fmt.Errorf("invalid service details for EC2")
+		return nil, fmt.Errorf("invalid service details for EC2")
 	}
 
 	// Default values if not specified
@@ -164,10 +249,6 @@ func (c *Client) findOfferingID(ctx context.Context, rec common.Recommendation)
 	if tenancy == "" {
 		tenancy = "default"
 	}
-	scope := details.Scope
-	if scope == "" {
-		scope = "Region"
-	}
 
 	// Prepare filters for the offering search
 	filters := []types.Filter{
@@ -185,40 +266,98 @@ func (c *Client) findOfferingID(ctx context.Context, rec common.Recommendation)
 		},
 		{
 			Name:   aws.String("scope"),
-			Values: []string{scope},
+			Values: []string{offeringScope(details.Scope)},
 		},
 	}
+	if details.AvailabilityZone != "" {
+		filters = append(filters, types.Filter{
+			Name:   aws.String("availability-zone"),
+			Values: []string{details.AvailabilityZone},
+		})
+	}
 
-	// Add duration filter
-	durationValue := c.getDurationValue(rec.Term)
+	// Add offering class filter, falling back to the class implied by the payment option
+	offeringClass := details.OfferingClass
+	if offeringClass == "" {
+		offeringClass = c.getOfferingClass(rec.PaymentOption)
+	}
 	filters = append(filters, types.Filter{
-		Name:   aws.String("duration"),
-		Values: []string{fmt.Sprintf("%d", durationValue)},
+		Name:   aws.String("offering-class"),
+		Values: []string{offeringClass},
 	})
 
-	// Add offering class filter
-	offeringClass := c.getOfferingClass(rec.PaymentOption)
+	return filters, nil
+}
+
+// offeringScope returns the offering scope filter value for a recommendation scope, defaulting to Region
+func offeringScope(scope string) string {
+	switch strings.ToLower(scope) {
+	case common.EC2ScopeAvailabilityZone, "availability zone", "zonal":
+		return "Availability Zone"
+	default:
+		return "Region"
+	}
+}
+
+// findCheapestMarketplaceOffering returns the Marketplace listing with the lowest effective hourly rate
+// Listings have a shorter remaining term, so any listing up to the recommendation's term is considered
+func (c *Client) findCheapestMarketplaceOffering(ctx context.Context, rec common.Recommendation) (*types.ReservedInstancesOffering, error) {
+	filters, err := c.buildOfferingFilters(rec)
+	if err != nil {
+		return nil, err
+	}
 	filters = append(filters, types.Filter{
-		Name:   aws.String("offering-class"),
-		Values: []string{offeringClass},
+		Name:   aws.String("marketplace"),
+		Values: []string{"true"},
 	})
 
 	input := &ec2.DescribeReservedInstancesOfferingsInput{
 		Filters:            filters,
-		IncludeMarketplace: aws.Bool(false),
+		IncludeMarketplace: aws.Bool(true),
+		MaxDuration:        aws.Int64(int64(c.getDurationValue(rec.Term))),
 		MaxResults:         aws.Int32(100),
 	}
 
 	result, err := c.client.DescribeReservedInstancesOfferings(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to describe offerings: %w", err)
+		return nil, fmt.Errorf("failed to describe marketplace offerings: %w", err)
 	}
 
-	if len(result.ReservedInstancesOfferings) == 0 {
-		return "", fmt.Errorf("no offerings found for %s %s %s", rec.ResourceType, platform, tenancy)
+	var cheapest *types.ReservedInstancesOffering
+	for i := range result.ReservedInstancesOfferings {
+		offering := &result.ReservedInstancesOfferings[i]
+		if !aws.ToBool(offering.Marketplace) || aws.ToInt64(offering.Duration) <= 0 {
+			continue
+		}
+		if cheapest == nil || effectiveHourlyRate(*offering) < effectiveHourlyRate(*cheapest) {
+			cheapest = offering
+		}
 	}
+	return cheapest, nil
+}
 
-	return aws.ToString(result.ReservedInstancesOfferings[0].ReservedInstancesOfferingId), nil
+// offeringUpfrontPrice returns the upfront price of an offering, preferring the Marketplace listing price
+func offeringUpfrontPrice(offering types.ReservedInstancesOffering) float64 {
+	for _, pricing := range offering.PricingDetails {
+		if pricing.Price != nil {
+			return *pricing.Price
+		}
+	}
+	return float64(aws.ToFloat32(offering.FixedPrice))
+}
+
+// effectiveHourlyRate returns the upfront price amortized over the term plus the hourly charges
+func effectiveHourlyRate(offering types.ReservedInstancesOffering) float64 {
+	rate := float64(aws.ToFloat32(offering.UsagePrice))
+	for _, charge := range offering.RecurringCharges {
+		if charge.Frequency == types.RecurringChargeFrequencyHourly {
+			rate += aws.ToFloat64(charge.Amount)
+		}
+	}
+	if hours := float64(aws.ToInt64(offering.Duration)) / 3600; hours > 0 {
+		rate += offeringUpfrontPrice(offering) / hours
+	}
+	return rate
 }
 
 // ValidateOffering checks if an offering exists without purchasing
@@ -248,6 +387,19 @@ func (c *Client) GetOfferingDetails(ctx context.Context, rec common.Recommendati
 	}
 
 	offering := result.ReservedInstancesOfferings[0]
+	marketplace := false
+
+	// Prefer a cheaper Marketplace listing if requested
+	if c.includeMarketplace {
+		listing, err := c.findCheapestMarketplaceOffering(ctx, rec)
+		if err != nil {
+			return nil, err
+		}
+		if listing != nil && effectiveHourlyRate(*listing) < effectiveHourlyRate(offering) {
+			offering = *listing
+			marketplace = true
+		}
+	}
 
 	// Extract fixed price from pricing details
 	var fixedPrice float64
@@ -259,13 +411,15 @@ func (c *Client) GetOfferingDetails(ctx context.Context, rec common.Recommendati
 	}
 
 	details := &common.OfferingDetails{
-		OfferingID:    aws.ToString(offering.ReservedInstancesOfferingId),
-		ResourceType:  string(offering.InstanceType),
-		Term:          rec.Term,
-		PaymentOption: string(offering.OfferingType),
-		UpfrontCost:   fixedPrice,
-		RecurringCost: float64(aws.ToFloat32(offering.UsagePrice)),
-		Currency:      string(offering.CurrencyCode),
+		OfferingID:          aws.ToString(offering.ReservedInstancesOfferingId),
+		ResourceType:        string(offering.InstanceType),
+		Term:                rec.Term,
+		PaymentOption:       string(offering.OfferingType),
+		UpfrontCost:         fixedPrice,
+		RecurringCost:       float64(aws.ToFloat32(offering.UsagePrice)),
+		EffectiveHourlyRate: effectiveHourlyRate(offering),
+		Currency:            string(offering.CurrencyCode),
+		Marketplace:         marketplace,
 	}
 
 	return details, nil
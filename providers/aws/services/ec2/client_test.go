@@ -10,8 +10,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockEC2Client implements EC2API for testing
@@ -338,6 +340,52 @@ func TestClient_PurchaseCommitment(t *testing.T) {
 	mockEC2.AssertExpectations(t)
 }
 
+func TestClient_DryRunPurchase(t *testing.T) {
+	tests := []struct {
+		name          string
+		purchaseErr   error
+		expectSuccess bool
+	}{
+		{"would succeed", &smithy.GenericAPIError{Code: "DryRunOperation", Message: "Request would have succeeded, but DryRun flag is set."}, true},
+		{"unauthorized", &smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: "You are not authorized to perform this operation."}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockEC2 := &MockEC2Client{}
+			client := &Client{client: mockEC2, region: "us-east-1"}
+			rec := common.Recommendation{
+				Service:       common.ServiceCompute,
+				ResourceType:  "t3.micro",
+				Count:         2,
+				PaymentOption: "no-upfront",
+				Term:          "1yr",
+				Details:       &common.ComputeDetails{Platform: "Linux/UNIX", Tenancy: "default", Scope: "Region"},
+			}
+
+			mockEC2.On("DescribeReservedInstancesOfferings", mock.Anything, mock.Anything).
+				Return(&ec2.DescribeReservedInstancesOfferingsOutput{
+					ReservedInstancesOfferings: []types.ReservedInstancesOffering{{ReservedInstancesOfferingId: aws.String("offering-123")}},
+				}, nil)
+			mockEC2.On("PurchaseReservedInstancesOffering", mock.Anything, mock.MatchedBy(func(input *ec2.PurchaseReservedInstancesOfferingInput) bool {
+				return aws.ToBool(input.DryRun) && aws.ToString(input.ReservedInstancesOfferingId) == "offering-123" && aws.ToInt32(input.InstanceCount) == 2
+			})).Return(nil, tt.purchaseErr)
+
+			result, err := client.DryRunPurchase(context.Background(), rec)
+
+			assert.True(t, result.DryRun)
+			assert.Equal(t, tt.expectSuccess, result.Success)
+			if tt.expectSuccess {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.purchaseErr)
+				assert.Equal(t, err, result.Error)
+			}
+			mockEC2.AssertExpectations(t)
+		})
+	}
+}
+
 func TestClient_GetOfferingDetails(t *testing.T) {
 	mockEC2 := &MockEC2Client{}
 	client := &Client{
@@ -384,6 +432,61 @@ func TestClient_GetOfferingDetails(t *testing.T) {
 	mockEC2.AssertExpectations(t)
 }
 
+func TestClient_GetOfferingDetails_Marketplace(t *testing.T) {
+	mockEC2 := &MockEC2Client{}
+	client := &Client{
+		client: mockEC2,
+		region: "us-east-1",
+	}
+	client.SetIncludeMarketplace(true)
+
+	rec := common.Recommendation{
+		Service:       common.ServiceCompute,
+		ResourceType:  "m5.large",
+		PaymentOption: "all-upfront",
+		Term:          "1yr",
+		Count:         1,
+		Details:       &common.ComputeDetails{Platform: "Linux/UNIX"},
+	}
+
+	standard := types.ReservedInstancesOffering{
+		ReservedInstancesOfferingId: aws.String("standard-offering"),
+		InstanceType:                types.InstanceTypeM5Large,
+		Duration:                    aws.Int64(31536000),
+		FixedPrice:                  aws.Float32(876),
+	}
+	isStandardQuery := func(input *ec2.DescribeReservedInstancesOfferingsInput) bool {
+		return !aws.ToBool(input.IncludeMarketplace)
+	}
+	mockEC2.On("DescribeReservedInstancesOfferings", mock.Anything, mock.MatchedBy(isStandardQuery)).
+		Return(&ec2.DescribeReservedInstancesOfferingsOutput{
+			ReservedInstancesOfferings: []types.ReservedInstancesOffering{standard},
+		}, nil).Twice()
+
+	// A half-year listing at a third of the price is cheaper per hour than the standard offering
+	mockEC2.On("DescribeReservedInstancesOfferings", mock.Anything, mock.MatchedBy(func(input *ec2.DescribeReservedInstancesOfferingsInput) bool {
+		return aws.ToBool(input.IncludeMarketplace)
+	})).Return(&ec2.DescribeReservedInstancesOfferingsOutput{
+		ReservedInstancesOfferings: []types.ReservedInstancesOffering{
+			{
+				ReservedInstancesOfferingId: aws.String("marketplace-offering"),
+				InstanceType:                types.InstanceTypeM5Large,
+				Marketplace:                 aws.Bool(true),
+				Duration:                    aws.Int64(15768000),
+				PricingDetails:              []types.PricingDetail{{Price: aws.Float64(292), Count: aws.Int32(1)}},
+			},
+		},
+	}, nil).Once()
+
+	details, err := client.GetOfferingDetails(context.Background(), rec)
+
+	require.NoError(t, err)
+	assert.True(t, details.Marketplace)
+	assert.Equal(t, "marketplace-offering", details.OfferingID)
+	assert.InDelta(t, 292.0/4380, details.EffectiveHourlyRate, 0.0001)
+	mockEC2.AssertExpectations(t)
+}
+
 func TestClient_GetOfferingClass(t *testing.T) {
 	client := &Client{}
 
@@ -406,6 +509,42 @@ func TestClient_GetOfferingClass(t *testing.T) {
 	}
 }
 
+func TestClient_BuildOfferingFiltersOfferingClass(t *testing.T) {
+	client := &Client{}
+
+	tests := []struct {
+		name          string
+		offeringClass string
+		paymentOption string
+		expected      string
+	}{
+		{"Explicit convertible", "convertible", "no-upfront", "convertible"},
+		{"Explicit standard overrides payment option", "standard", "all-upfront", "standard"},
+		{"Unset falls back to payment option", "", "all-upfront", "convertible"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := common.Recommendation{
+				ResourceType:  "m5.large",
+				PaymentOption: tt.paymentOption,
+				Details:       &common.ComputeDetails{OfferingClass: tt.offeringClass},
+			}
+
+			filters, err := client.buildOfferingFilters(rec)
+
+			require.NoError(t, err)
+			var classes []string
+			for _, filter := range filters {
+				if aws.ToString(filter.Name) == "offering-class" {
+					classes = filter.Values
+				}
+			}
+			assert.Equal(t, []string{tt.expected}, classes)
+		})
+	}
+}
+
 func TestClient_GetDurationValue(t *testing.T) {
 	client := &Client{}
 
@@ -427,3 +566,40 @@ func TestClient_GetDurationValue(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_BuildOfferingFiltersScope(t *testing.T) {
+	client := &Client{}
+
+	tests := []struct {
+		name          string
+		details       common.ComputeDetails
+		expectedScope string
+		expectedZone  []string
+	}{
+		{"Unset defaults to region", common.ComputeDetails{}, "Region", nil},
+		{"Region", common.ComputeDetails{Scope: common.EC2ScopeRegion}, "Region", nil},
+		{"Availability zone", common.ComputeDetails{Scope: common.EC2ScopeAvailabilityZone, AvailabilityZone: "us-east-1a"}, "Availability Zone", []string{"us-east-1a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := tt.details
+			rec := common.Recommendation{ResourceType: "m5.large", Details: &details}
+
+			filters, err := client.buildOfferingFilters(rec)
+
+			require.NoError(t, err)
+			var scopes, zones []string
+			for _, filter := range filters {
+				switch aws.ToString(filter.Name) {
+				case "scope":
+					scopes = filter.Values
+				case "availability-zone":
+					zones = filter.Values
+				}
+			}
+			assert.Equal(t, []string{tt.expectedScope}, scopes)
+			assert.Equal(t, tt.expectedZone, zones)
+		})
+	}
+}
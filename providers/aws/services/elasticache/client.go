@@ -123,7 +123,9 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		return result, result.Error
 	}
 
-	reservationID := common.SanitizeReservationID(fmt.Sprintf("elasticache-%s-%d", rec.ResourceType, time.Now().Unix()), "elasticache-reserved-")
+	// Reuse the reservation ID when the purchase is retried, so AWS rejects it as a duplicate
+	reservationID := common.IdempotentReservationID("elasticache", rec, time.Now())
+	result.IdempotencyToken = common.RecommendationIdempotencyToken(rec)
 
 	input := &elasticache.PurchaseReservedCacheNodesOfferingInput{
 		ReservedCacheNodesOfferingId: aws.String(offeringID),
@@ -132,11 +134,13 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		Tags:                         c.createPurchaseTags(rec),
 	}
 
+	result.Request = input
 	response, err := c.client.PurchaseReservedCacheNodesOffering(ctx, input)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to purchase Reserved Cache Node: %w", err)
 		return result, result.Error
 	}
+	result.Response = response
 
 	if response.ReservedCacheNode != nil {
 		result.Success = true
@@ -144,6 +148,7 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		if response.ReservedCacheNode.FixedPrice != nil {
 			result.Cost = *response.ReservedCacheNode.FixedPrice
 		}
+		result.RecordFulfillment(int(aws.ToInt32(response.ReservedCacheNode.CacheNodeCount)))
 	} else {
 		result.Error = fmt.Errorf("purchase response was empty")
 		return result, result.Error
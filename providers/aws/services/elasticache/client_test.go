@@ -270,6 +270,56 @@ func TestClient_ValidateOffering(t *testing.T) {
 	mockEC.AssertExpectations(t)
 }
 
+func TestClient_GetExistingCommitments_Pagination(t *testing.T) {
+	mockEC := &MockElastiCacheClient{}
+	client := &Client{
+		client: mockEC,
+		region: "us-east-1",
+	}
+
+	// First page
+	mockEC.On("DescribeReservedCacheNodes", mock.Anything, mock.MatchedBy(func(input *elasticache.DescribeReservedCacheNodesInput) bool {
+		return input.Marker == nil
+	})).Return(&elasticache.DescribeReservedCacheNodesOutput{
+		ReservedCacheNodes: []types.ReservedCacheNode{
+			{
+				ReservedCacheNodeId: aws.String("rcn-1"),
+				CacheNodeType:       aws.String("cache.t3.micro"),
+				CacheNodeCount:      aws.Int32(2),
+				State:               aws.String("active"),
+				Duration:            aws.Int32(31536000),
+				StartTime:           aws.Time(time.Now()),
+			},
+		},
+		Marker: aws.String("page2"),
+	}, nil).Once()
+
+	// Second page
+	mockEC.On("DescribeReservedCacheNodes", mock.Anything, mock.MatchedBy(func(input *elasticache.DescribeReservedCacheNodesInput) bool {
+		return input.Marker != nil && *input.Marker == "page2"
+	})).Return(&elasticache.DescribeReservedCacheNodesOutput{
+		ReservedCacheNodes: []types.ReservedCacheNode{
+			{
+				ReservedCacheNodeId: aws.String("rcn-2"),
+				CacheNodeType:       aws.String("cache.r6g.large"),
+				CacheNodeCount:      aws.Int32(3),
+				State:               aws.String("active"),
+				Duration:            aws.Int32(94608000),
+				StartTime:           aws.Time(time.Now()),
+			},
+		},
+		Marker: nil,
+	}, nil).Once()
+
+	result, err := client.GetExistingCommitments(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "rcn-1", result[0].CommitmentID)
+	assert.Equal(t, "rcn-2", result[1].CommitmentID)
+	mockEC.AssertExpectations(t)
+}
+
 func TestClient_PurchaseCommitment(t *testing.T) {
 	mockEC := &MockElastiCacheClient{}
 	client := &Client{
@@ -118,7 +118,9 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		return result, result.Error
 	}
 
-	reservationID := common.SanitizeReservationID(fmt.Sprintf("memorydb-%s-%d", rec.ResourceType, time.Now().Unix()), "memorydb-reserved-")
+	// Reuse the reservation ID when the purchase is retried, so AWS rejects it as a duplicate
+	reservationID := common.IdempotentReservationID("memorydb", rec, time.Now())
+	result.IdempotencyToken = common.RecommendationIdempotencyToken(rec)
 
 	input := &memorydb.PurchaseReservedNodesOfferingInput{
 		ReservedNodesOfferingId: aws.String(offeringID),
@@ -127,16 +129,19 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		Tags:                    c.createPurchaseTags(rec),
 	}
 
+	result.Request = input
 	response, err := c.client.PurchaseReservedNodesOffering(ctx, input)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to purchase MemoryDB Reserved Nodes: %w", err)
 		return result, result.Error
 	}
+	result.Response = response
 
 	if response.ReservedNode != nil {
 		result.Success = true
 		result.CommitmentID = aws.ToString(response.ReservedNode.ReservationId)
 		result.Cost = response.ReservedNode.FixedPrice
+		result.RecordFulfillment(int(response.ReservedNode.NodeCount))
 	} else {
 		result.Error = fmt.Errorf("purchase response was empty")
 		return result, result.Error
@@ -145,29 +150,38 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 	return result, nil
 }
 
-// findOfferingID finds the appropriate Reserved Node offering ID
+// findOfferingID finds the Reserved Node offering matching the node type, term and payment option of a recommendation
 func (c *Client) findOfferingID(ctx context.Context, rec common.Recommendation) (string, error) {
-	input := &memorydb.DescribeReservedNodesOfferingsInput{
-		NodeType:   aws.String(rec.ResourceType),
-		MaxResults: aws.Int32(100),
-	}
+	requiredMonths := c.getTermMonthsFromString(rec.Term)
+	var nextToken *string
+	for {
+		input := &memorydb.DescribeReservedNodesOfferingsInput{
+			NodeType:   aws.String(rec.ResourceType),
+			MaxResults: aws.Int32(100),
+			NextToken:  nextToken,
+		}
 
-	result, err := c.client.DescribeReservedNodesOfferings(ctx, input)
-	if err != nil {
-		return "", fmt.Errorf("failed to describe offerings: %w", err)
-	}
+		result, err := c.client.DescribeReservedNodesOfferings(ctx, input)
+		if err != nil {
+			return "", fmt.Errorf("failed to describe offerings: %w", err)
+		}
 
-	requiredMonths := c.getTermMonthsFromString(rec.Term)
-	for _, offering := range result.ReservedNodesOfferings {
-		if offering.NodeType != nil && *offering.NodeType == rec.ResourceType {
-			if c.matchesDuration(offering.Duration, requiredMonths) &&
-				c.matchesOfferingType(offering.OfferingType, rec.PaymentOption) {
-				return aws.ToString(offering.ReservedNodesOfferingId), nil
+		for _, offering := range result.ReservedNodesOfferings {
+			if offering.NodeType != nil && *offering.NodeType == rec.ResourceType {
+				if c.matchesDuration(offering.Duration, requiredMonths) &&
+					c.matchesOfferingType(offering.OfferingType, rec.PaymentOption) {
+					return aws.ToString(offering.ReservedNodesOfferingId), nil
+				}
 			}
 		}
+
+		if aws.ToString(result.NextToken) == "" {
+			break
+		}
+		nextToken = result.NextToken
 	}
 
-	return "", fmt.Errorf("no offerings found for %s", rec.ResourceType)
+	return "", fmt.Errorf("no offerings found for %s (%s, %s)", rec.ResourceType, rec.Term, rec.PaymentOption)
 }
 
 // matchesDuration checks if the offering duration matches
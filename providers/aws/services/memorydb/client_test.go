@@ -666,3 +666,52 @@ func TestClient_GetTermMonthsFromDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_FindOfferingIDTermMapping(t *testing.T) {
+	mockMDB := &MockMemoryDBClient{}
+	client := &Client{
+		client: mockMDB,
+		region: "us-east-1",
+	}
+
+	mockMDB.On("DescribeReservedNodesOfferings", mock.Anything, mock.MatchedBy(func(input *memorydb.DescribeReservedNodesOfferingsInput) bool {
+		return input.NextToken == nil && aws.ToString(input.NodeType) == "db.r7g.large"
+	})).Return(&memorydb.DescribeReservedNodesOfferingsOutput{
+		ReservedNodesOfferings: []types.ReservedNodesOffering{
+			{ReservedNodesOfferingId: aws.String("1yr-no"), NodeType: aws.String("db.r7g.large"), Duration: 31536000, OfferingType: aws.String("No Upfront")},
+			{ReservedNodesOfferingId: aws.String("3yr-partial"), NodeType: aws.String("db.r7g.large"), Duration: 94608000, OfferingType: aws.String("Partial Upfront")},
+		},
+		NextToken: aws.String("token-123"),
+	}, nil)
+	mockMDB.On("DescribeReservedNodesOfferings", mock.Anything, mock.MatchedBy(func(input *memorydb.DescribeReservedNodesOfferingsInput) bool {
+		return aws.ToString(input.NextToken) == "token-123"
+	})).Return(&memorydb.DescribeReservedNodesOfferingsOutput{
+		ReservedNodesOfferings: []types.ReservedNodesOffering{
+			{ReservedNodesOfferingId: aws.String("3yr-all"), NodeType: aws.String("db.r7g.large"), Duration: 94608000, OfferingType: aws.String("All Upfront")},
+		},
+	}, nil)
+
+	tests := []struct {
+		term          string
+		paymentOption string
+		expected      string
+	}{
+		{"1yr", "no-upfront", "1yr-no"},
+		{"3yr", "partial-upfront", "3yr-partial"},
+		{"3yr", "all-upfront", "3yr-all"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term+" "+tt.paymentOption, func(t *testing.T) {
+			rec := common.Recommendation{ResourceType: "db.r7g.large", Term: tt.term, PaymentOption: tt.paymentOption}
+
+			offeringID, err := client.findOfferingID(context.Background(), rec)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, offeringID)
+		})
+	}
+
+	_, err := client.findOfferingID(context.Background(), common.Recommendation{ResourceType: "db.r7g.large", Term: "1yr", PaymentOption: "all-upfront"})
+	assert.ErrorContains(t, err, "no offerings found for db.r7g.large (1yr, all-upfront)")
+}
@@ -126,15 +126,19 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		InstanceCount:              aws.Int32(int32(rec.Count)),
 	}
 
+	result.Request = input
 	response, err := c.client.PurchaseReservedInstanceOffering(ctx, input)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to purchase OpenSearch RI: %w", err)
 		return result, result.Error
 	}
+	result.Response = response
 
 	if response.ReservedInstanceId != nil {
 		result.Success = true
 		result.CommitmentID = aws.ToString(response.ReservedInstanceId)
+		// The purchase response does not report the instance count; the purchase is all-or-nothing
+		result.RecordFulfillment(rec.Count)
 	} else {
 		result.Error = fmt.Errorf("purchase response was empty")
 		return result, result.Error
@@ -127,8 +127,9 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		return result, result.Error
 	}
 
-	// Generate reservation ID (letters, digits, hyphens only; no leading/trailing/double hyphen)
-	reservationID := common.SanitizeReservationID(fmt.Sprintf("rds-%s-%d", rec.ResourceType, time.Now().Unix()), "rds-reserved-")
+	// Reuse the reservation ID when the purchase is retried, so AWS rejects it as a duplicate
+	reservationID := common.IdempotentReservationID("rds", rec, time.Now())
+	result.IdempotencyToken = common.RecommendationIdempotencyToken(rec)
 
 	// Create the purchase request
 	input := &rds.PurchaseReservedDBInstancesOfferingInput{
@@ -138,11 +139,13 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		Tags:                          c.createPurchaseTags(rec),
 	}
 
+	result.Request = input
 	response, err := c.client.PurchaseReservedDBInstancesOffering(ctx, input)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to purchase RDS RI: %w", err)
 		return result, result.Error
 	}
+	result.Response = response
 
 	if response.ReservedDBInstance != nil {
 		result.Success = true
@@ -150,6 +153,7 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		if response.ReservedDBInstance.FixedPrice != nil {
 			result.Cost = *response.ReservedDBInstance.FixedPrice
 		}
+		result.RecordFulfillment(int(aws.ToInt32(response.ReservedDBInstance.DBInstanceCount)))
 	} else {
 		result.Error = fmt.Errorf("purchase response was empty")
 		return result, result.Error
@@ -3,6 +3,7 @@ package rds
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/rds/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockRDSClient implements RDSAPI for testing
@@ -328,6 +330,56 @@ func TestClient_ValidateOffering_NotFound(t *testing.T) {
 	mockRDS.AssertExpectations(t)
 }
 
+func TestClient_GetExistingCommitments_Pagination(t *testing.T) {
+	mockRDS := &MockRDSClient{}
+	client := &Client{
+		client: mockRDS,
+		region: "us-east-1",
+	}
+
+	// First page
+	mockRDS.On("DescribeReservedDBInstances", mock.Anything, mock.MatchedBy(func(input *rds.DescribeReservedDBInstancesInput) bool {
+		return input.Marker == nil
+	})).Return(&rds.DescribeReservedDBInstancesOutput{
+		ReservedDBInstances: []types.ReservedDBInstance{
+			{
+				ReservedDBInstanceId: aws.String("ri-1"),
+				DBInstanceClass:      aws.String("db.t3.micro"),
+				DBInstanceCount:      aws.Int32(2),
+				State:                aws.String("active"),
+				Duration:             aws.Int32(31536000),
+				StartTime:            aws.Time(time.Now()),
+			},
+		},
+		Marker: aws.String("page2"),
+	}, nil).Once()
+
+	// Second page
+	mockRDS.On("DescribeReservedDBInstances", mock.Anything, mock.MatchedBy(func(input *rds.DescribeReservedDBInstancesInput) bool {
+		return input.Marker != nil && *input.Marker == "page2"
+	})).Return(&rds.DescribeReservedDBInstancesOutput{
+		ReservedDBInstances: []types.ReservedDBInstance{
+			{
+				ReservedDBInstanceId: aws.String("ri-2"),
+				DBInstanceClass:      aws.String("db.r6g.large"),
+				DBInstanceCount:      aws.Int32(1),
+				State:                aws.String("payment-pending"),
+				Duration:             aws.Int32(94608000),
+				StartTime:            aws.Time(time.Now()),
+			},
+		},
+		Marker: nil,
+	}, nil).Once()
+
+	result, err := client.GetExistingCommitments(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "ri-1", result[0].CommitmentID)
+	assert.Equal(t, "ri-2", result[1].CommitmentID)
+	mockRDS.AssertExpectations(t)
+}
+
 func TestClient_PurchaseCommitment(t *testing.T) {
 	mockRDS := &MockRDSClient{}
 	client := &Client{
@@ -362,7 +414,11 @@ func TestClient_PurchaseCommitment(t *testing.T) {
 			},
 		}, nil)
 
-	mockRDS.On("PurchaseReservedDBInstancesOffering", mock.Anything, mock.Anything).
+	token := common.RecommendationIdempotencyToken(rec)
+	mockRDS.On("PurchaseReservedDBInstancesOffering", mock.Anything, mock.MatchedBy(func(input *rds.PurchaseReservedDBInstancesOfferingInput) bool {
+		id := aws.ToString(input.ReservedDBInstanceId)
+		return strings.HasPrefix(id, "rds-") && strings.HasSuffix(id, token[:16])
+	})).
 		Return(&rds.PurchaseReservedDBInstancesOfferingOutput{
 			ReservedDBInstance: &types.ReservedDBInstance{
 				ReservedDBInstanceId: aws.String("ri-789"),
@@ -380,6 +436,58 @@ func TestClient_PurchaseCommitment(t *testing.T) {
 	assert.True(t, result.Success)
 	assert.Equal(t, "ri-789", result.CommitmentID)
 	assert.Equal(t, 10000.0, result.Cost)
+	assert.Equal(t, 2, result.PurchasedCount)
+	assert.False(t, result.Partial)
+	assert.Equal(t, token, result.IdempotencyToken)
+	require.IsType(t, &rds.PurchaseReservedDBInstancesOfferingInput{}, result.Request)
+	assert.Equal(t, "offering-456", aws.ToString(result.Request.(*rds.PurchaseReservedDBInstancesOfferingInput).ReservedDBInstancesOfferingId))
+	require.IsType(t, &rds.PurchaseReservedDBInstancesOfferingOutput{}, result.Response)
+	mockRDS.AssertExpectations(t)
+}
+
+func TestClient_PurchaseCommitment_PartialFulfillment(t *testing.T) {
+	mockRDS := &MockRDSClient{}
+	client := &Client{
+		client: mockRDS,
+		region: "us-east-1",
+	}
+
+	rec := common.Recommendation{
+		Service:       common.ServiceRelationalDB,
+		ResourceType:  "db.r6g.large",
+		Count:         10,
+		PaymentOption: "no-upfront",
+		Term:          "1yr",
+		Details: &common.DatabaseDetails{
+			Engine:   "postgresql",
+			AZConfig: "single-az",
+		},
+	}
+
+	mockRDS.On("DescribeReservedDBInstancesOfferings", mock.Anything, mock.Anything).
+		Return(&rds.DescribeReservedDBInstancesOfferingsOutput{
+			ReservedDBInstancesOfferings: []types.ReservedDBInstancesOffering{
+				{ReservedDBInstancesOfferingId: aws.String("offering-789")},
+			},
+		}, nil)
+
+	// Only 7 of the 10 requested instances could be fulfilled
+	mockRDS.On("PurchaseReservedDBInstancesOffering", mock.Anything, mock.Anything).
+		Return(&rds.PurchaseReservedDBInstancesOfferingOutput{
+			ReservedDBInstance: &types.ReservedDBInstance{
+				ReservedDBInstanceId: aws.String("ri-partial"),
+				DBInstanceCount:      aws.Int32(7),
+			},
+		}, nil)
+
+	result, err := client.PurchaseCommitment(context.Background(), rec)
+
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.True(t, result.Partial)
+	assert.Equal(t, 10, result.RequestedCount)
+	assert.Equal(t, 7, result.PurchasedCount)
+	assert.Equal(t, 3, result.Shortfall())
 	mockRDS.AssertExpectations(t)
 }
 
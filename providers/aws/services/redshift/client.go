@@ -8,6 +8,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/redshift"
+	"github.com/aws/aws-sdk-go-v2/service/redshift/types"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
 )
@@ -122,11 +123,13 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		NodeCount:              aws.Int32(int32(rec.Count)),
 	}
 
+	result.Request = input
 	response, err := c.client.PurchaseReservedNodeOffering(ctx, input)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to purchase Redshift Reserved Node: %w", err)
 		return result, result.Error
 	}
+	result.Response = response
 
 	if response.ReservedNode != nil {
 		result.Success = true
@@ -134,6 +137,7 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		if response.ReservedNode.FixedPrice != nil {
 			result.Cost = *response.ReservedNode.FixedPrice
 		}
+		result.RecordFulfillment(int(aws.ToInt32(response.ReservedNode.NodeCount)))
 	} else {
 		result.Error = fmt.Errorf("purchase response was empty")
 		return result, result.Error
@@ -142,47 +146,78 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 	return result, nil
 }
 
-// findOfferingID finds the appropriate Reserved Node offering ID
+// findOfferingID finds the Reserved Node offering matching the node type, term and payment option of a recommendation
+// Redshift can't filter offerings by node type, so every page is searched; Regular offerings are preferred over
+// Upgradable ones.
 func (c *Client) findOfferingID(ctx context.Context, rec common.Recommendation) (string, error) {
-	input := &redshift.DescribeReservedNodeOfferingsInput{
-		MaxRecords: aws.Int32(100),
-	}
+	var upgradableID string
+	var marker *string
+	for {
+		input := &redshift.DescribeReservedNodeOfferingsInput{
+			MaxRecords: aws.Int32(100),
+			Marker:     marker,
+		}
 
-	result, err := c.client.DescribeReservedNodeOfferings(ctx, input)
-	if err != nil {
-		return "", fmt.Errorf("failed to describe offerings: %w", err)
-	}
+		result, err := c.client.DescribeReservedNodeOfferings(ctx, input)
+		if err != nil {
+			return "", fmt.Errorf("failed to describe offerings: %w", err)
+		}
 
-	for _, offering := range result.ReservedNodeOfferings {
-		if offering.NodeType != nil && *offering.NodeType == rec.ResourceType {
-			if c.matchesDuration(offering.Duration, rec.Term) &&
-				c.matchesOfferingType(string(offering.ReservedNodeOfferingType), rec.PaymentOption) {
+		for _, offering := range result.ReservedNodeOfferings {
+			if aws.ToString(offering.NodeType) != rec.ResourceType ||
+				!c.matchesDuration(offering.Duration, rec.Term) ||
+				!c.matchesOfferingType(offering.OfferingType, rec.PaymentOption) {
+				continue
+			}
+			switch offering.ReservedNodeOfferingType {
+			case types.ReservedNodeOfferingTypeRegular:
 				return aws.ToString(offering.ReservedNodeOfferingId), nil
+			case types.ReservedNodeOfferingTypeUpgradable:
+				if upgradableID == "" {
+					upgradableID = aws.ToString(offering.ReservedNodeOfferingId)
+				}
 			}
 		}
+
+		if aws.ToString(result.Marker) == "" {
+			break
+		}
+		marker = result.Marker
 	}
 
-	return "", fmt.Errorf("no offerings found for %s", rec.ResourceType)
+	if upgradableID != "" {
+		return upgradableID, nil
+	}
+	return "", fmt.Errorf("no offerings found for %s (%s, %s)", rec.ResourceType, rec.Term, rec.PaymentOption)
 }
 
-// matchesDuration checks if the offering duration matches
+// matchesDuration checks if the offering duration matches the term, allowing for the month rounding of the duration
 func (c *Client) matchesDuration(offeringDuration *int32, term string) bool {
 	if offeringDuration == nil {
 		return false
 	}
 
-	offeringMonths := *offeringDuration / 2592000
-	requiredMonths := 12
-	if term == "3yr" || term == "3" {
-		requiredMonths = 36
-	}
-	return int(offeringMonths) == requiredMonths
+	offeringMonths := int(*offeringDuration / 2592000)
+	requiredMonths := getTermMonthsFromString(term)
+	return offeringMonths >= requiredMonths-1 && offeringMonths <= requiredMonths+1
 }
 
-// matchesOfferingType checks if the offering type matches
-func (c *Client) matchesOfferingType(offeringType string, paymentOption string) bool {
-	// Redshift uses "Regular" and "Upgradable" offering types
-	return offeringType == "Regular" || offeringType == "Upgradable"
+// matchesOfferingType checks if the offering's payment type (e.g. "All Upfront") matches the payment option
+func (c *Client) matchesOfferingType(offeringType *string, paymentOption string) bool {
+	if offeringType == nil {
+		return false
+	}
+
+	switch paymentOption {
+	case "all-upfront":
+		return *offeringType == "All Upfront"
+	case "partial-upfront":
+		return *offeringType == "Partial Upfront"
+	case "no-upfront":
+		return *offeringType == "No Upfront"
+	default:
+		return false
+	}
 }
 
 // ValidateOffering checks if an offering exists without purchasing
@@ -218,7 +253,7 @@ func (c *Client) GetOfferingDetails(ctx context.Context, rec common.Recommendati
 		OfferingID:    aws.ToString(offering.ReservedNodeOfferingId),
 		ResourceType:  aws.ToString(offering.NodeType),
 		Term:          fmt.Sprintf("%d", aws.ToInt32(offering.Duration)),
-		PaymentOption: string(offering.ReservedNodeOfferingType),
+		PaymentOption: aws.ToString(offering.OfferingType),
 		UpfrontCost:   aws.ToFloat64(offering.FixedPrice),
 		RecurringCost: aws.ToFloat64(offering.UsagePrice),
 		Currency:      aws.ToString(offering.CurrencyCode),
@@ -254,3 +289,13 @@ func getTermMonthsFromDuration(duration int32) int {
 	}
 	return 12
 }
+
+// getTermMonthsFromString converts a term such as "1yr" or "3yr" to months
+func getTermMonthsFromString(term string) int {
+	switch term {
+	case "3yr", "3", "36":
+		return 36
+	default:
+		return 12
+	}
+}
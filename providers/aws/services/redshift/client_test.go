@@ -215,6 +215,7 @@ func TestClient_ValidateOffering(t *testing.T) {
 					NodeType:                 aws.String("dc2.large"),
 					Duration:                 aws.Int32(31536000),
 					ReservedNodeOfferingType: types.ReservedNodeOfferingType("Regular"),
+					OfferingType:             aws.String("Partial Upfront"),
 				},
 			},
 		}, nil)
@@ -251,6 +252,7 @@ func TestClient_PurchaseCommitment(t *testing.T) {
 					NodeType:                 aws.String("ra3.xlplus"),
 					Duration:                 aws.Int32(94608000),
 					ReservedNodeOfferingType: types.ReservedNodeOfferingType("Regular"),
+					OfferingType:             aws.String("All Upfront"),
 					FixedPrice:               aws.Float64(10000.0),
 				},
 			},
@@ -304,19 +306,17 @@ func TestClient_MatchesDuration(t *testing.T) {
 func TestClient_MatchesOfferingType(t *testing.T) {
 	client := &Client{}
 
-	// Redshift uses "Regular" and "Upgradable" offering types, not payment options
-	// The function returns true for valid offering types regardless of payment option
 	tests := []struct {
 		name          string
-		offeringType  string
+		offeringType  *string
 		paymentOption string
 		expected      bool
 	}{
-		{"Regular offering type accepts any payment", "Regular", "all-upfront", true},
-		{"Regular offering type with partial", "Regular", "partial-upfront", true},
-		{"Upgradable offering type", "Upgradable", "all-upfront", true},
-		{"Unknown offering type rejected", "Unknown", "all-upfront", false},
-		{"Empty offering type rejected", "", "partial-upfront", false},
+		{"all upfront match", aws.String("All Upfront"), "all-upfront", true},
+		{"partial upfront match", aws.String("Partial Upfront"), "partial-upfront", true},
+		{"no upfront match", aws.String("No Upfront"), "no-upfront", true},
+		{"payment option mismatch", aws.String("All Upfront"), "partial-upfront", false},
+		{"nil offering type", nil, "all-upfront", false},
 	}
 
 	for _, tt := range tests {
@@ -327,6 +327,25 @@ func TestClient_MatchesOfferingType(t *testing.T) {
 	}
 }
 
+func TestGetTermMonthsFromString(t *testing.T) {
+	tests := []struct {
+		term     string
+		expected int
+	}{
+		{"1yr", 12},
+		{"3yr", 36},
+		{"3", 36},
+		{"36", 36},
+		{"", 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			assert.Equal(t, tt.expected, getTermMonthsFromString(tt.term))
+		})
+	}
+}
+
 func TestClient_SetRedshiftAPI(t *testing.T) {
 	client := &Client{region: "us-east-1"}
 	mockRS := &MockRedshiftClient{}
@@ -442,6 +461,7 @@ func TestClient_PurchaseCommitment_PurchaseAPIError(t *testing.T) {
 					NodeType:                 aws.String("dc2.large"),
 					Duration:                 aws.Int32(31536000),
 					ReservedNodeOfferingType: types.ReservedNodeOfferingType("Regular"),
+					OfferingType:             aws.String("All Upfront"),
 				},
 			},
 		}, nil).Once()
@@ -484,6 +504,7 @@ func TestClient_PurchaseCommitment_EmptyResponse(t *testing.T) {
 					NodeType:                 aws.String("dc2.large"),
 					Duration:                 aws.Int32(31536000),
 					ReservedNodeOfferingType: types.ReservedNodeOfferingType("Regular"),
+					OfferingType:             aws.String("All Upfront"),
 				},
 			},
 		}, nil).Once()
@@ -529,6 +550,7 @@ func TestClient_GetOfferingDetails_Success(t *testing.T) {
 				NodeType:                 aws.String("dc2.large"),
 				Duration:                 aws.Int32(31536000),
 				ReservedNodeOfferingType: types.ReservedNodeOfferingType("Regular"),
+				OfferingType:             aws.String("All Upfront"),
 			},
 		},
 	}, nil).Once()
@@ -543,6 +565,7 @@ func TestClient_GetOfferingDetails_Success(t *testing.T) {
 				NodeType:                 aws.String("dc2.large"),
 				Duration:                 aws.Int32(31536000),
 				ReservedNodeOfferingType: types.ReservedNodeOfferingType("Regular"),
+				OfferingType:             aws.String("All Upfront"),
 				FixedPrice:               aws.Float64(500.0),
 				UsagePrice:               aws.Float64(0.10),
 				CurrencyCode:             aws.String("USD"),
@@ -656,6 +679,7 @@ func TestClient_GetOfferingDetails_EmptyResponseAfterFind(t *testing.T) {
 				NodeType:                 aws.String("dc2.large"),
 				Duration:                 aws.Int32(31536000),
 				ReservedNodeOfferingType: types.ReservedNodeOfferingType("Regular"),
+				OfferingType:             aws.String("All Upfront"),
 			},
 		},
 	}, nil).Once()
@@ -701,6 +725,7 @@ func TestClient_GetOfferingDetails_EmptyOfferingsAfterFind(t *testing.T) {
 				NodeType:                 aws.String("dc2.large"),
 				Duration:                 aws.Int32(31536000),
 				ReservedNodeOfferingType: types.ReservedNodeOfferingType("Regular"),
+				OfferingType:             aws.String("All Upfront"),
 			},
 		},
 	}, nil).Once()
@@ -763,6 +788,7 @@ func TestClient_FindOfferingID_NoMatchingNodeType(t *testing.T) {
 					NodeType:                 aws.String("ra3.xlplus"), // Different node type
 					Duration:                 aws.Int32(31536000),
 					ReservedNodeOfferingType: types.ReservedNodeOfferingType("Regular"),
+					OfferingType:             aws.String("All Upfront"),
 				},
 			},
 		}, nil).Once()
@@ -797,6 +823,7 @@ func TestClient_FindOfferingID_NoMatchingDuration(t *testing.T) {
 					NodeType:                 aws.String("dc2.large"),
 					Duration:                 aws.Int32(31536000), // 1 year, not 3
 					ReservedNodeOfferingType: types.ReservedNodeOfferingType("Regular"),
+					OfferingType:             aws.String("All Upfront"),
 				},
 			},
 		}, nil).Once()
@@ -831,6 +858,7 @@ func TestClient_FindOfferingID_UnknownOfferingType(t *testing.T) {
 					NodeType:                 aws.String("dc2.large"),
 					Duration:                 aws.Int32(31536000),
 					ReservedNodeOfferingType: types.ReservedNodeOfferingType("Unknown"), // Invalid type
+					OfferingType:             aws.String("All Upfront"),
 				},
 			},
 		}, nil).Once()
@@ -841,3 +869,60 @@ func TestClient_FindOfferingID_UnknownOfferingType(t *testing.T) {
 	assert.Contains(t, err.Error(), "no offerings found")
 	mockRS.AssertExpectations(t)
 }
+
+func TestClient_FindOfferingIDTermMapping(t *testing.T) {
+	offering := func(id string, duration int32, offeringType string, class types.ReservedNodeOfferingType) types.ReservedNodeOffering {
+		return types.ReservedNodeOffering{
+			ReservedNodeOfferingId:   aws.String(id),
+			NodeType:                 aws.String("ra3.4xlarge"),
+			Duration:                 aws.Int32(duration),
+			OfferingType:             aws.String(offeringType),
+			ReservedNodeOfferingType: class,
+		}
+	}
+	mockRS := &MockRedshiftClient{}
+	mockRS.On("DescribeReservedNodeOfferings", mock.Anything, mock.MatchedBy(func(input *redshift.DescribeReservedNodeOfferingsInput) bool {
+		return input.Marker == nil
+	})).Return(&redshift.DescribeReservedNodeOfferingsOutput{
+		ReservedNodeOfferings: []types.ReservedNodeOffering{
+			offering("1yr-all-upgradable", 31536000, "All Upfront", types.ReservedNodeOfferingTypeUpgradable),
+			offering("1yr-no", 31536000, "No Upfront", types.ReservedNodeOfferingTypeRegular),
+			offering("3yr-partial", 94608000, "Partial Upfront", types.ReservedNodeOfferingTypeRegular),
+		},
+		Marker: aws.String("page2"),
+	}, nil)
+	mockRS.On("DescribeReservedNodeOfferings", mock.Anything, mock.MatchedBy(func(input *redshift.DescribeReservedNodeOfferingsInput) bool {
+		return aws.ToString(input.Marker) == "page2"
+	})).Return(&redshift.DescribeReservedNodeOfferingsOutput{
+		ReservedNodeOfferings: []types.ReservedNodeOffering{
+			offering("1yr-all", 31536000, "All Upfront", types.ReservedNodeOfferingTypeRegular),
+			offering("3yr-all", 94608000, "All Upfront", types.ReservedNodeOfferingTypeRegular),
+		},
+	}, nil)
+	client := &Client{client: mockRS, region: "us-east-1"}
+
+	tests := []struct {
+		term          string
+		paymentOption string
+		expected      string
+	}{
+		{"1yr", "all-upfront", "1yr-all"},
+		{"1yr", "no-upfront", "1yr-no"},
+		{"3yr", "partial-upfront", "3yr-partial"},
+		{"3yr", "all-upfront", "3yr-all"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term+" "+tt.paymentOption, func(t *testing.T) {
+			rec := common.Recommendation{ResourceType: "ra3.4xlarge", Term: tt.term, PaymentOption: tt.paymentOption}
+
+			offeringID, err := client.findOfferingID(context.Background(), rec)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, offeringID)
+		})
+	}
+
+	_, err := client.findOfferingID(context.Background(), common.Recommendation{ResourceType: "ra3.4xlarge", Term: "3yr", PaymentOption: "no-upfront"})
+	assert.ErrorContains(t, err, "no offerings found for ra3.4xlarge (3yr, no-upfront)")
+}
@@ -4,6 +4,7 @@ package savingsplans
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -57,49 +58,58 @@ func (c *Client) GetRecommendations(ctx context.Context, params common.Recommend
 
 // GetExistingCommitments retrieves existing Savings Plans
 func (c *Client) GetExistingCommitments(ctx context.Context) ([]common.Commitment, error) {
-	input := &savingsplans.DescribeSavingsPlansInput{
-		States: []types.SavingsPlanState{
-			types.SavingsPlanStateActive,
-			types.SavingsPlanStatePendingReturn,
-			types.SavingsPlanStateQueued,
-		},
-	}
-
-	result, err := c.client.DescribeSavingsPlans(ctx, input)
-	if err != nil {
-		return nil, fmt.Errorf("failed to describe Savings Plans: %w", err)
-	}
-
-	commitments := make([]common.Commitment, 0, len(result.SavingsPlans))
-
-	for _, sp := range result.SavingsPlans {
-		if sp.SavingsPlanId == nil {
-			continue
+	commitments := make([]common.Commitment, 0)
+	var nextToken *string
+
+	for {
+		input := &savingsplans.DescribeSavingsPlansInput{
+			States: []types.SavingsPlanState{
+				types.SavingsPlanStateActive,
+				types.SavingsPlanStatePendingReturn,
+				types.SavingsPlanStateQueued,
+			},
+			NextToken: nextToken,
 		}
 
-		commitment := common.Commitment{
-			Provider:       common.ProviderAWS,
-			CommitmentID:   *sp.SavingsPlanId,
-			CommitmentType: common.CommitmentSavingsPlan,
-			Service:        common.ServiceSavingsPlans,
-			Region:         aws.ToString(sp.Region),
-			ResourceType:   string(sp.SavingsPlanType),
-			Count:          1, // Savings Plans don't have a count
-			State:          string(sp.State),
+		result, err := c.client.DescribeSavingsPlans(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe Savings Plans: %w", err)
 		}
 
-		if sp.Start != nil {
-			if startTime, err := time.Parse(time.RFC3339, *sp.Start); err == nil {
-				commitment.StartDate = startTime
+		for _, sp := range result.SavingsPlans {
+			if sp.SavingsPlanId == nil {
+				continue
 			}
-		}
-		if sp.End != nil {
-			if endTime, err := time.Parse(time.RFC3339, *sp.End); err == nil {
-				commitment.EndDate = endTime
+
+			commitment := common.Commitment{
+				Provider:       common.ProviderAWS,
+				CommitmentID:   *sp.SavingsPlanId,
+				CommitmentType: common.CommitmentSavingsPlan,
+				Service:        common.ServiceSavingsPlans,
+				Region:         aws.ToString(sp.Region),
+				ResourceType:   string(sp.SavingsPlanType),
+				Count:          1, // Savings Plans don't have a count
+				State:          string(sp.State),
+			}
+
+			if sp.Start != nil {
+				if startTime, err := time.Parse(time.RFC3339, *sp.Start); err == nil {
+					commitment.StartDate = startTime
+				}
+			}
+			if sp.End != nil {
+				if endTime, err := time.Parse(time.RFC3339, *sp.End); err == nil {
+					commitment.EndDate = endTime
+				}
 			}
+
+			commitments = append(commitments, commitment)
 		}
 
-		commitments = append(commitments, commitment)
+		if result.NextToken == nil || aws.ToString(result.NextToken) == "" {
+			break
+		}
+		nextToken = result.NextToken
 	}
 
 	return commitments, nil
@@ -114,11 +124,15 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		Timestamp:      time.Now(),
 	}
 
-	spDetails, ok := rec.Details.(*common.SavingsPlanDetails)
+	spDetails, ok := savingsPlanDetails(rec)
 	if !ok {
 		result.Error = fmt.Errorf("invalid service details for Savings Plans")
 		return result, result.Error
 	}
+	if spDetails.HourlyCommitment <= 0 {
+		result.Error = fmt.Errorf("invalid hourly commitment %.3f for Savings Plan", spDetails.HourlyCommitment)
+		return result, result.Error
+	}
 
 	offeringID, err := c.findOfferingID(ctx, rec)
 	if err != nil {
@@ -126,22 +140,28 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 		return result, result.Error
 	}
 
+	// AWS deduplicates client tokens indefinitely, so the token is scoped to the day like the reservation IDs of other services
+	result.IdempotencyToken = common.DailyIdempotencyToken(rec, time.Now())
+	// Without a PurchaseTime the plan is purchased immediately rather than queued
 	input := &savingsplans.CreateSavingsPlanInput{
 		SavingsPlanOfferingId: aws.String(offeringID),
-		Commitment:            aws.String(fmt.Sprintf("%.2f", spDetails.HourlyCommitment)),
+		Commitment:            aws.String(strconv.FormatFloat(spDetails.HourlyCommitment, 'f', 3, 64)),
 		UpfrontPaymentAmount:  nil, // AWS calculates this based on payment option
-		PurchaseTime:          aws.Time(time.Now()),
+		ClientToken:           aws.String(result.IdempotencyToken),
 	}
 
+	result.Request = input
 	response, err := c.client.CreateSavingsPlan(ctx, input)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to purchase Savings Plan: %w", err)
 		return result, result.Error
 	}
+	result.Response = response
 
 	if response.SavingsPlanId != nil {
 		result.Success = true
 		result.CommitmentID = *response.SavingsPlanId
+		result.RecordFulfillment(rec.Count)
 	} else {
 		result.Error = fmt.Errorf("purchase response was empty")
 		return result, result.Error
@@ -150,9 +170,21 @@ func (c *Client) PurchaseCommitment(ctx context.Context, rec common.Recommendati
 	return result, nil
 }
 
+// savingsPlanDetails returns the Savings Plan details of a recommendation, accepting both value and pointer details
+func savingsPlanDetails(rec common.Recommendation) (*common.SavingsPlanDetails, bool) {
+	switch details := rec.Details.(type) {
+	case *common.SavingsPlanDetails:
+		return details, details != nil
+	case common.SavingsPlanDetails:
+		return &details, true
+	default:
+		return nil, false
+	}
+}
+
 // findOfferingID finds the appropriate Savings Plans offering ID
 func (c *Client) findOfferingID(ctx context.Context, rec common.Recommendation) (string, error) {
-	spDetails, ok := rec.Details.(*common.SavingsPlanDetails)
+	spDetails, ok := savingsPlanDetails(rec)
 	if !ok {
 		return "", fmt.Errorf("invalid service details for Savings Plans")
 	}
@@ -172,10 +204,10 @@ func (c *Client) findOfferingID(ctx context.Context, rec common.Recommendation)
 		return "", fmt.Errorf("unsupported Savings Plan type: %s", spDetails.PlanType)
 	}
 
-	// Convert term to months
-	termMonths := int64(12)
+	// Offering durations are expressed in seconds
+	durationSeconds := int64(31536000) // 1 year
 	if rec.Term == "3yr" || rec.Term == "3" {
-		termMonths = 36
+		durationSeconds = 94608000 // 3 years
 	}
 
 	// Convert payment option
@@ -191,8 +223,24 @@ func (c *Client) findOfferingID(ctx context.Context, rec common.Recommendation)
 
 	input := &savingsplans.DescribeSavingsPlansOfferingsInput{
 		PlanTypes:      []types.SavingsPlanType{planType},
-		Durations:      []int64{termMonths},
+		Durations:      []int64{durationSeconds},
 		PaymentOptions: []types.SavingsPlanPaymentOption{paymentOption},
+		Currencies:     []types.CurrencyCode{types.CurrencyCodeUsd},
+	}
+
+	// EC2Instance plans are bound to an instance family in a region, so the first unscoped offering could be any of them
+	if planType == types.SavingsPlanTypeEc2Instance {
+		region := spDetails.Region
+		if region == "" {
+			region = rec.Region
+		}
+		if region == "" || spDetails.InstanceFamily == "" {
+			return "", fmt.Errorf("EC2Instance Savings Plan recommendation is missing its region or instance family")
+		}
+		input.Filters = []types.SavingsPlanOfferingFilterElement{
+			{Name: types.SavingsPlanOfferingFilterAttributeRegion, Values: []string{region}},
+			{Name: types.SavingsPlanOfferingFilterAttributeInstanceFamily, Values: []string{spDetails.InstanceFamily}},
+		}
 	}
 
 	result, err := c.client.DescribeSavingsPlansOfferings(ctx, input)
@@ -220,7 +268,7 @@ func (c *Client) GetOfferingDetails(ctx context.Context, rec common.Recommendati
 		return nil, err
 	}
 
-	spDetails, ok := rec.Details.(*common.SavingsPlanDetails)
+	spDetails, ok := savingsPlanDetails(rec)
 	if !ok {
 		return nil, fmt.Errorf("invalid service details for Savings Plans")
 	}
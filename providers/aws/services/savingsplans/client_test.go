@@ -255,6 +255,49 @@ func TestClient_ValidateOffering_InvalidDetails(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid service details")
 }
 
+func TestClient_GetExistingCommitments_Pagination(t *testing.T) {
+	mockSP := &MockSavingsPlansClient{}
+	client := &Client{
+		client: mockSP,
+		region: "us-east-1",
+	}
+
+	// First page
+	mockSP.On("DescribeSavingsPlans", mock.Anything, mock.MatchedBy(func(input *savingsplans.DescribeSavingsPlansInput) bool {
+		return input.NextToken == nil
+	})).Return(&savingsplans.DescribeSavingsPlansOutput{
+		SavingsPlans: []types.SavingsPlan{
+			{
+				SavingsPlanId:   aws.String("sp-1"),
+				SavingsPlanType: types.SavingsPlanTypeCompute,
+				State:           types.SavingsPlanStateActive,
+			},
+		},
+		NextToken: aws.String("page2"),
+	}, nil).Once()
+
+	// Second page
+	mockSP.On("DescribeSavingsPlans", mock.Anything, mock.MatchedBy(func(input *savingsplans.DescribeSavingsPlansInput) bool {
+		return input.NextToken != nil && *input.NextToken == "page2"
+	})).Return(&savingsplans.DescribeSavingsPlansOutput{
+		SavingsPlans: []types.SavingsPlan{
+			{
+				SavingsPlanId:   aws.String("sp-2"),
+				SavingsPlanType: types.SavingsPlanTypeEc2Instance,
+				State:           types.SavingsPlanStateQueued,
+			},
+		},
+	}, nil).Once()
+
+	result, err := client.GetExistingCommitments(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "sp-1", result[0].CommitmentID)
+	assert.Equal(t, "sp-2", result[1].CommitmentID)
+	mockSP.AssertExpectations(t)
+}
+
 func TestClient_PurchaseCommitment(t *testing.T) {
 	mockSP := &MockSavingsPlansClient{}
 	client := &Client{
@@ -296,6 +339,150 @@ func TestClient_PurchaseCommitment(t *testing.T) {
 	mockSP.AssertExpectations(t)
 }
 
+func TestClient_PurchaseCommitment_RequestMapping(t *testing.T) {
+	mockSP := &MockSavingsPlansClient{}
+	client := &Client{
+		client: mockSP,
+		region: "us-east-1",
+	}
+
+	// Value details are accepted as well as pointers
+	rec := common.Recommendation{
+		Service:       common.ServiceSavingsPlans,
+		ResourceType:  "EC2Instance",
+		Count:         1,
+		PaymentOption: "partial-upfront",
+		Term:          "3yr",
+		Details: common.SavingsPlanDetails{
+			PlanType:         "EC2Instance",
+			HourlyCommitment: 0.125,
+			Region:           "eu-west-1",
+			InstanceFamily:   "m5",
+		},
+	}
+
+	mockSP.On("DescribeSavingsPlansOfferings", mock.Anything, mock.MatchedBy(func(input *savingsplans.DescribeSavingsPlansOfferingsInput) bool {
+		return assert.ObjectsAreEqual([]int64{94608000}, input.Durations) &&
+			assert.ObjectsAreEqual([]types.SavingsPlanType{types.SavingsPlanTypeEc2Instance}, input.PlanTypes) &&
+			assert.ObjectsAreEqual([]types.SavingsPlanPaymentOption{types.SavingsPlanPaymentOptionPartialUpfront}, input.PaymentOptions) &&
+			assert.ObjectsAreEqual([]types.CurrencyCode{types.CurrencyCodeUsd}, input.Currencies) &&
+			assert.ObjectsAreEqual([]types.SavingsPlanOfferingFilterElement{
+				{Name: types.SavingsPlanOfferingFilterAttributeRegion, Values: []string{"eu-west-1"}},
+				{Name: types.SavingsPlanOfferingFilterAttributeInstanceFamily, Values: []string{"m5"}},
+			}, input.Filters)
+	})).Return(&savingsplans.DescribeSavingsPlansOfferingsOutput{
+		SearchResults: []types.SavingsPlanOffering{{OfferingId: aws.String("offering-ec2")}},
+	}, nil)
+
+	mockSP.On("CreateSavingsPlan", mock.Anything, mock.MatchedBy(func(input *savingsplans.CreateSavingsPlanInput) bool {
+		return aws.ToString(input.SavingsPlanOfferingId) == "offering-ec2" &&
+			aws.ToString(input.Commitment) == "0.125" &&
+			len(aws.ToString(input.ClientToken)) == 32 &&
+			input.PurchaseTime == nil
+	})).Return(&savingsplans.CreateSavingsPlanOutput{SavingsPlanId: aws.String("sp-456")}, nil)
+
+	result, err := client.PurchaseCommitment(context.Background(), rec)
+
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "sp-456", result.CommitmentID)
+	assert.Equal(t, aws.ToString(result.Request.(*savingsplans.CreateSavingsPlanInput).ClientToken), result.IdempotencyToken)
+	assert.NotEqual(t, common.RecommendationIdempotencyToken(rec), result.IdempotencyToken, "the client token is scoped to the day")
+	assert.Equal(t, 1, result.PurchasedCount)
+	mockSP.AssertExpectations(t)
+}
+
+func TestClient_PurchaseCommitment_EC2InstanceScope(t *testing.T) {
+	tests := []struct {
+		name        string
+		rec         common.Recommendation
+		wantFilters []types.SavingsPlanOfferingFilterElement
+		wantErr     bool
+	}{
+		{
+			name: "region falls back to the recommendation",
+			rec: common.Recommendation{
+				Region:  "us-west-2",
+				Details: &common.SavingsPlanDetails{PlanType: "EC2Instance", HourlyCommitment: 1, InstanceFamily: "c6g"},
+			},
+			wantFilters: []types.SavingsPlanOfferingFilterElement{
+				{Name: types.SavingsPlanOfferingFilterAttributeRegion, Values: []string{"us-west-2"}},
+				{Name: types.SavingsPlanOfferingFilterAttributeInstanceFamily, Values: []string{"c6g"}},
+			},
+		},
+		{
+			name: "missing instance family",
+			rec: common.Recommendation{
+				Details: &common.SavingsPlanDetails{PlanType: "EC2Instance", HourlyCommitment: 1, Region: "us-east-1"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing region",
+			rec: common.Recommendation{
+				Details: &common.SavingsPlanDetails{PlanType: "EC2Instance", HourlyCommitment: 1, InstanceFamily: "m5"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "compute plans are not scoped",
+			rec: common.Recommendation{
+				Details: &common.SavingsPlanDetails{PlanType: "Compute", HourlyCommitment: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSP := &MockSavingsPlansClient{}
+			client := &Client{client: mockSP, region: "us-east-1"}
+			tt.rec.Service = common.ServiceSavingsPlans
+			tt.rec.Term = "1yr"
+
+			if !tt.wantErr {
+				mockSP.On("DescribeSavingsPlansOfferings", mock.Anything, mock.MatchedBy(func(input *savingsplans.DescribeSavingsPlansOfferingsInput) bool {
+					return assert.ObjectsAreEqual(tt.wantFilters, input.Filters)
+				})).Return(&savingsplans.DescribeSavingsPlansOfferingsOutput{
+					SearchResults: []types.SavingsPlanOffering{{OfferingId: aws.String("offering-1")}},
+				}, nil)
+				mockSP.On("CreateSavingsPlan", mock.Anything, mock.Anything).Return(&savingsplans.CreateSavingsPlanOutput{SavingsPlanId: aws.String("sp-1")}, nil)
+			}
+
+			result, err := client.PurchaseCommitment(context.Background(), tt.rec)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "missing its region or instance family")
+				assert.False(t, result.Success)
+				mockSP.AssertNotCalled(t, "DescribeSavingsPlansOfferings", mock.Anything, mock.Anything)
+				mockSP.AssertNotCalled(t, "CreateSavingsPlan", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, result.Success)
+			mockSP.AssertExpectations(t)
+		})
+	}
+}
+
+func TestClient_PurchaseCommitment_ZeroCommitment(t *testing.T) {
+	mockSP := &MockSavingsPlansClient{}
+	client := &Client{client: mockSP, region: "us-east-1"}
+
+	rec := common.Recommendation{
+		Service: common.ServiceSavingsPlans,
+		Term:    "1yr",
+		Details: &common.SavingsPlanDetails{PlanType: "Compute"},
+	}
+
+	result, err := client.PurchaseCommitment(context.Background(), rec)
+
+	assert.Error(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, err.Error(), "invalid hourly commitment")
+	mockSP.AssertNotCalled(t, "CreateSavingsPlan", mock.Anything, mock.Anything)
+}
+
 func TestClient_PurchaseCommitment_InvalidDetails(t *testing.T) {
 	client := &Client{region: "us-east-1"}
 
@@ -472,6 +659,8 @@ func TestClient_GetOfferingDetails_3YearTerm(t *testing.T) {
 		Details: &common.SavingsPlanDetails{
 			PlanType:         "EC2Instance",
 			HourlyCommitment: 5.0,
+			Region:           "us-east-1",
+			InstanceFamily:   "r5",
 		},
 	}
 
@@ -620,6 +809,8 @@ func TestClient_FindOfferingID_AllPlanTypes(t *testing.T) {
 				Details: &common.SavingsPlanDetails{
 					PlanType:         tt.planType,
 					HourlyCommitment: 10.0,
+					Region:           "us-east-1",
+					InstanceFamily:   "m5",
 				},
 			}
 
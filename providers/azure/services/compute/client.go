@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"strings"
@@ -129,8 +130,10 @@ func (c *ComputeClient) GetRecommendations(ctx context.Context, params common.Re
 			return nil, fmt.Errorf("failed to create consumption client: %w", err)
 		}
 
-		filter := "properties/scope eq 'Shared' and properties/resourceType eq 'VirtualMachines'"
-		pager = client.NewListPager(filter, &armconsumption.ReservationRecommendationsClientListOptions{})
+		filter := fmt.Sprintf("properties/scope eq 'Shared' and properties/resourceType eq 'VirtualMachines' and properties/lookBackPeriod eq '%s'",
+			azureLookBackPeriod(params.LookbackPeriod))
+		scope := fmt.Sprintf("/subscriptions/%s", c.subscriptionID)
+		pager = client.NewListPager(scope, &armconsumption.ReservationRecommendationsClientListOptions{Filter: &filter})
 	}
 
 	for pager.More() {
@@ -141,9 +144,14 @@ func (c *ComputeClient) GetRecommendations(ctx context.Context, params common.Re
 
 		for _, rec := range page.Value {
 			converted := c.convertAzureVMRecommendation(ctx, rec)
-			if converted != nil {
-				recommendations = append(recommendations, *converted)
+			if converted == nil {
+				continue
+			}
+			// The API returns both terms, keep the requested one
+			if params.Term != "" && converted.Term != params.Term {
+				continue
 			}
+			recommendations = append(recommendations, *converted)
 		}
 	}
 
@@ -483,18 +491,158 @@ func (c *ComputeClient) getVMPricing(ctx context.Context, vmSize, region string,
 	}, nil
 }
 
+// azureLookBackPeriod maps a look-back period such as "30d" to the Consumption API filter value
+func azureLookBackPeriod(period string) string {
+	switch period {
+	case "30d":
+		return "Last30Days"
+	case "60d":
+		return "Last60Days"
+	default:
+		return "Last7Days"
+	}
+}
+
+// lookBackDays returns the number of days of a Consumption API look-back period such as "Last30Days"
+func lookBackDays(period string) int {
+	switch period {
+	case "Last30Days":
+		return 30
+	case "Last60Days":
+		return 60
+	default:
+		return 7
+	}
+}
+
+// convertAzureTerm maps an ISO 8601 reservation term (P1Y, P3Y) to the common term format
+func convertAzureTerm(term string) string {
+	if strings.EqualFold(term, "P3Y") {
+		return "3yr"
+	}
+	return "1yr"
+}
+
+// normalizeLocation makes Azure locations comparable ("East US" and "eastus")
+func normalizeLocation(location string) string {
+	return strings.ToLower(strings.ReplaceAll(location, " ", ""))
+}
+
+// vmRecommendation holds the fields shared by legacy and modern VM reservation recommendations
+type vmRecommendation struct {
+	location    string
+	sku         string
+	term        string
+	quantity    float64
+	netSavings  float64
+	costWithout float64
+	lookBack    int
+}
+
+// extractVMRecommendation reads a legacy or modern Consumption API reservation recommendation
+func extractVMRecommendation(azureRec armconsumption.ReservationRecommendationClassification) (vmRecommendation, bool) {
+	var vm vmRecommendation
+	switch r := azureRec.(type) {
+	case *armconsumption.LegacyReservationRecommendation:
+		if r.Properties == nil {
+			return vm, false
+		}
+		props := r.Properties.GetLegacyReservationRecommendationProperties()
+		vm.location = deref(r.Location)
+		vm.sku = deref(r.SKU)
+		vm.term = deref(props.Term)
+		vm.quantity = derefFloat(props.RecommendedQuantity)
+		vm.netSavings = derefFloat(props.NetSavings)
+		vm.costWithout = derefFloat(props.CostWithNoReservedInstances)
+		vm.lookBack = lookBackDays(deref(props.LookBackPeriod))
+	case *armconsumption.ModernReservationRecommendation:
+		if r.Properties == nil {
+			return vm, false
+		}
+		props := r.Properties
+		vm.location = deref(props.Location)
+		if vm.location == "" {
+			vm.location = deref(r.Location)
+		}
+		vm.sku = deref(props.SKUName)
+		if vm.sku == "" {
+			vm.sku = deref(r.SKU)
+		}
+		vm.term = deref(props.Term)
+		vm.quantity = derefFloat(props.RecommendedQuantity)
+		vm.netSavings = amountValue(props.NetSavings)
+		vm.costWithout = amountValue(props.CostWithNoReservedInstances)
+		vm.lookBack = 7
+		if props.LookBackPeriod != nil && *props.LookBackPeriod > 0 {
+			vm.lookBack = int(*props.LookBackPeriod)
+		}
+	default:
+		return vm, false
+	}
+	return vm, true
+}
+
 // convertAzureVMRecommendation converts Azure VM reservation recommendation to common format
+// Recommendations for other regions than the client's (if set) and without a VM size or quantity are skipped.
+// Azure reports costs for the look-back period, which are scaled to monthly savings.
 func (c *ComputeClient) convertAzureVMRecommendation(ctx context.Context, azureRec armconsumption.ReservationRecommendationClassification) *common.Recommendation {
+	vm, ok := extractVMRecommendation(azureRec)
+	if !ok || vm.sku == "" || vm.quantity < 1 {
+		return nil
+	}
+	if c.region != "" && vm.location != "" && normalizeLocation(vm.location) != normalizeLocation(c.region) {
+		return nil
+	}
+
+	region := c.region
+	if region == "" {
+		region = normalizeLocation(vm.location)
+	}
+
 	rec := &common.Recommendation{
-		Provider:       common.ProviderAzure,
-		Service:        common.ServiceCompute,
-		Account:        c.subscriptionID,
-		Region:         c.region,
-		CommitmentType: common.CommitmentReservedInstance,
-		Timestamp:      time.Now(),
-		Term:           "1yr",
-		PaymentOption:  "upfront",
+		Provider:         common.ProviderAzure,
+		Service:          common.ServiceCompute,
+		Account:          c.subscriptionID,
+		Region:           region,
+		ResourceType:     vm.sku,
+		Count:            int(math.Floor(vm.quantity)),
+		CommitmentType:   common.CommitmentReservedInstance,
+		Timestamp:        time.Now(),
+		Term:             convertAzureTerm(vm.term),
+		PaymentOption:    "upfront",
+		EstimatedSavings: vm.netSavings / float64(vm.lookBack) * 30,
+		Details: &common.ComputeDetails{
+			InstanceType: vm.sku,
+			Platform:     "linux",
+			Tenancy:      "default",
+			Scope:        "shared",
+		},
+	}
+	if vm.costWithout > 0 {
+		rec.SavingsPercentage = vm.netSavings / vm.costWithout * 100
+		rec.OnDemandCost = vm.costWithout / float64(vm.lookBack) * 30
 	}
 
 	return rec
 }
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func derefFloat(f *float64) float64 {
+	if f == nil {
+		return 0
+	}
+	return *f
+}
+
+func amountValue(a *armconsumption.Amount) float64 {
+	if a == nil {
+		return 0
+	}
+	return derefFloat(a.Value)
+}
@@ -9,7 +9,9 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/consumption/armconsumption"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -574,17 +576,105 @@ func TestComputeClient_PurchaseCommitment_BadStatus(t *testing.T) {
 	assert.Contains(t, err.Error(), "reservation purchase failed with status 400")
 }
 
+func legacyVMRecommendation(location, sku, term, lookBack string, quantity, netSavings, costWithout float64) *armconsumption.LegacyReservationRecommendation {
+	return &armconsumption.LegacyReservationRecommendation{
+		Location: to.Ptr(location),
+		SKU:      to.Ptr(sku),
+		Properties: &armconsumption.LegacySharedScopeReservationRecommendationProperties{
+			Scope:                       to.Ptr("Shared"),
+			Term:                        to.Ptr(term),
+			LookBackPeriod:              to.Ptr(lookBack),
+			RecommendedQuantity:         to.Ptr(quantity),
+			NetSavings:                  to.Ptr(netSavings),
+			CostWithNoReservedInstances: to.Ptr(costWithout),
+		},
+	}
+}
+
 func TestComputeClient_ConvertAzureVMRecommendation(t *testing.T) {
 	ctx := context.Background()
 	client := NewClient(nil, "test-subscription", "eastus")
 
-	rec := client.convertAzureVMRecommendation(ctx, nil)
+	rec := client.convertAzureVMRecommendation(ctx, legacyVMRecommendation("eastus", "Standard_D2s_v3", "P3Y", "Last30Days", 2.6, 60, 240))
 	require.NotNil(t, rec)
 	assert.Equal(t, common.ProviderAzure, rec.Provider)
 	assert.Equal(t, common.ServiceCompute, rec.Service)
 	assert.Equal(t, "test-subscription", rec.Account)
 	assert.Equal(t, "eastus", rec.Region)
+	assert.Equal(t, "Standard_D2s_v3", rec.ResourceType)
+	assert.Equal(t, 2, rec.Count)
 	assert.Equal(t, common.CommitmentReservedInstance, rec.CommitmentType)
-	assert.Equal(t, "1yr", rec.Term)
+	assert.Equal(t, "3yr", rec.Term)
 	assert.Equal(t, "upfront", rec.PaymentOption)
+	assert.InDelta(t, 60, rec.EstimatedSavings, 0.001)
+	assert.InDelta(t, 25, rec.SavingsPercentage, 0.001)
+	details, ok := rec.Details.(*common.ComputeDetails)
+	require.True(t, ok)
+	assert.Equal(t, "Standard_D2s_v3", details.InstanceType)
+}
+
+func TestComputeClient_ConvertAzureVMRecommendation_Modern(t *testing.T) {
+	client := NewClient(nil, "test-subscription", "")
+
+	rec := client.convertAzureVMRecommendation(context.Background(), &armconsumption.ModernReservationRecommendation{
+		Properties: &armconsumption.ModernReservationRecommendationProperties{
+			Location:            to.Ptr("West Europe"),
+			SKUName:             to.Ptr("Standard_E4s_v5"),
+			Term:                to.Ptr("P1Y"),
+			LookBackPeriod:      to.Ptr[int32](7),
+			RecommendedQuantity: to.Ptr(3.0),
+			NetSavings:          &armconsumption.Amount{Value: to.Ptr(14.0)},
+		},
+	})
+	require.NotNil(t, rec)
+	assert.Equal(t, "westeurope", rec.Region, "the region comes from the recommendation when the client has none")
+	assert.Equal(t, "Standard_E4s_v5", rec.ResourceType)
+	assert.Equal(t, 3, rec.Count)
+	assert.Equal(t, "1yr", rec.Term)
+	assert.InDelta(t, 60, rec.EstimatedSavings, 0.001)
+}
+
+func TestComputeClient_ConvertAzureVMRecommendation_Skipped(t *testing.T) {
+	client := NewClient(nil, "test-subscription", "eastus")
+
+	tests := []struct {
+		name string
+		rec  armconsumption.ReservationRecommendationClassification
+	}{
+		{"nil", nil},
+		{"other region", legacyVMRecommendation("westus2", "Standard_D2s_v3", "P1Y", "Last7Days", 1, 10, 50)},
+		{"no quantity", legacyVMRecommendation("eastus", "Standard_D2s_v3", "P1Y", "Last7Days", 0.4, 10, 50)},
+		{"no SKU", legacyVMRecommendation("eastus", "", "P1Y", "Last7Days", 1, 10, 50)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Nil(t, client.convertAzureVMRecommendation(context.Background(), tt.rec))
+		})
+	}
+}
+
+func TestComputeClient_GetRecommendations_FiltersTerm(t *testing.T) {
+	client := NewClient(nil, "test-subscription", "eastus")
+	client.SetRecommendationsPager(&mocks.MockRecommendationsPager{
+		Results: []armconsumption.ReservationRecommendationClassification{
+			legacyVMRecommendation("eastus", "Standard_D2s_v3", "P1Y", "Last7Days", 2, 7, 35),
+			legacyVMRecommendation("eastus", "Standard_D2s_v3", "P3Y", "Last7Days", 2, 14, 35),
+		},
+		HasMore: true,
+	})
+
+	recommendations, err := client.GetRecommendations(context.Background(), common.RecommendationParams{
+		Service: common.ServiceCompute,
+		Term:    "3yr",
+	})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	assert.Equal(t, "3yr", recommendations[0].Term)
+}
+
+func TestAzureLookBackPeriod(t *testing.T) {
+	assert.Equal(t, "Last7Days", azureLookBackPeriod("7d"))
+	assert.Equal(t, "Last30Days", azureLookBackPeriod("30d"))
+	assert.Equal(t, "Last60Days", azureLookBackPeriod("60d"))
+	assert.Equal(t, "Last7Days", azureLookBackPeriod(""))
 }
@@ -4,6 +4,7 @@ package computeengine
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"google.golang.org/api/cloudbilling/v1"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
 )
@@ -208,14 +210,18 @@ func (c *ComputeEngineClient) GetRecommendations(ctx context.Context, params com
 			break
 		}
 		if err != nil {
-			// If recommender API fails, continue with empty recommendations
-			break
+			return nil, fmt.Errorf("failed to list CUD recommendations in %s: %w", c.region, err)
 		}
 
 		converted := c.convertGCPRecommendation(ctx, rec)
-		if converted != nil {
-			recommendations = append(recommendations, *converted)
+		if converted == nil {
+			continue
+		}
+		// The recommender returns both plans, keep the requested term
+		if params.Term != "" && converted.Term != params.Term {
+			continue
 		}
+		recommendations = append(recommendations, *converted)
 	}
 
 	return recommendations, nil
@@ -566,7 +572,10 @@ func skuMatchesMachineType(sku *cloudbilling.Sku, machineType, region string) bo
 	return true
 }
 
-// convertGCPRecommendation converts a GCP Recommender recommendation to common format
+// convertGCPRecommendation converts a GCP commitment recommendation to common format
+// Recommendations that add a commitment are keyed by machine family (e.g. "e2") and region, with
+// Count holding the number of committed vCPUs. The projected cost over the recommendation's
+// duration is scaled to monthly savings.
 func (c *ComputeEngineClient) convertGCPRecommendation(ctx context.Context, gcpRec *recommenderpb.Recommendation) *common.Recommendation {
 	rec := &common.Recommendation{
 		Provider:       common.ProviderGCP,
@@ -579,18 +588,18 @@ func (c *ComputeEngineClient) convertGCPRecommendation(ctx context.Context, gcpR
 		PaymentOption:  "upfront",
 	}
 
-	// Extract resource type and cost savings from recommendation content
+	// Extract the commitment (or, for older recommendations, the machine type) from the operations
 	if gcpRec.Content != nil {
-		if gcpRec.Content.OperationGroups != nil {
-			for _, opGroup := range gcpRec.Content.OperationGroups {
-				for _, op := range opGroup.Operations {
-					if op.Resource != "" {
-						// Extract machine type from resource path
-						parts := strings.Split(op.Resource, "/")
-						if len(parts) > 0 {
-							rec.ResourceType = parts[len(parts)-1]
-						}
-					}
+		for _, opGroup := range gcpRec.Content.OperationGroups {
+			for _, op := range opGroup.Operations {
+				if commitment := op.GetValue().GetStructValue(); commitment != nil && op.ResourceType == commitmentResourceType {
+					applyCommitment(rec, op.Resource, commitment)
+					continue
+				}
+				if op.Resource != "" && rec.ResourceType == "" {
+					// Extract machine type from resource path
+					parts := strings.Split(op.Resource, "/")
+					rec.ResourceType = parts[len(parts)-1]
 				}
 			}
 		}
@@ -604,6 +613,9 @@ func (c *ComputeEngineClient) convertGCPRecommendation(ctx context.Context, gcpR
 			cost := costProj.Cost
 			if cost.Units != 0 || cost.Nanos != 0 {
 				savings := -(float64(cost.Units) + float64(cost.Nanos)/1e9)
+				if days := costProj.GetDuration().AsDuration().Hours() / 24; days > 0 {
+					savings = savings / days * 30
+				}
 				rec.EstimatedSavings = savings
 			}
 		}
@@ -612,6 +624,71 @@ func (c *ComputeEngineClient) convertGCPRecommendation(ctx context.Context, gcpR
 	return rec
 }
 
+// commitmentResourceType is the resource type of the operations that add a commitment
+const commitmentResourceType = "compute.googleapis.com/Commitment"
+
+// applyCommitment fills rec from the commitment resource a recommendation adds
+func applyCommitment(rec *common.Recommendation, resource string, commitment *structpb.Struct) {
+	fields := commitment.GetFields()
+
+	if plan := fields["plan"].GetStringValue(); plan == "THIRTY_SIX_MONTH" {
+		rec.Term = "3yr"
+	}
+	if family := machineFamily(fields["type"].GetStringValue()); family != "" {
+		rec.ResourceType = family
+		rec.Details = &common.ComputeDetails{InstanceType: family}
+	}
+	if region := regionFromResource(resource); region != "" {
+		rec.Region = region
+	}
+
+	for _, resourceValue := range fields["resources"].GetListValue().GetValues() {
+		resourceFields := resourceValue.GetStructValue().GetFields()
+		if resourceFields["type"].GetStringValue() != "VCPU" {
+			continue
+		}
+		// Amounts are int64 values, which the API encodes as strings
+		amount := resourceFields["amount"]
+		if count, err := strconv.Atoi(amount.GetStringValue()); err == nil {
+			rec.Count = count
+		} else if amount.GetNumberValue() > 0 {
+			rec.Count = int(amount.GetNumberValue())
+		}
+	}
+}
+
+// baseCommitmentFamilies maps the commitment types without a family suffix to the machine family they cover
+var baseCommitmentFamilies = map[string]string{
+	"GENERAL_PURPOSE":       "n1",
+	"COMPUTE_OPTIMIZED":     "c2",
+	"MEMORY_OPTIMIZED":      "m1",
+	"ACCELERATOR_OPTIMIZED": "a2",
+	"GRAPHICS_OPTIMIZED":    "g2",
+}
+
+// machineFamily maps a commitment type such as GENERAL_PURPOSE_E2 to its machine family ("e2")
+func machineFamily(commitmentType string) string {
+	if commitmentType == "" {
+		return ""
+	}
+	if family, ok := baseCommitmentFamilies[commitmentType]; ok {
+		return family
+	}
+	parts := strings.Split(commitmentType, "_")
+	return strings.ToLower(parts[len(parts)-1])
+}
+
+// regionFromResource extracts the region of a resource path such as //compute.googleapis.com/projects/p/regions/us-central1/commitments/c
+func regionFromResource(resource string) string {
+	parts := strings.Split(resource, "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "regions" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
 // Helper functions
 func stringPtr(s string) *string {
 	return &s
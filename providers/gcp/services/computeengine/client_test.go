@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/compute/apiv1/computepb"
 	"cloud.google.com/go/recommender/apiv1/recommenderpb"
@@ -13,6 +14,8 @@ import (
 	"google.golang.org/api/cloudbilling/v1"
 	"google.golang.org/api/iterator"
 	"google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/LeanerCloud/CUDly/pkg/common"
 )
@@ -715,3 +718,87 @@ func TestComputeEngineClient_ConvertGCPRecommendation(t *testing.T) {
 	assert.Equal(t, "n1-standard-4", rec.ResourceType)
 	assert.Equal(t, 50.5, rec.EstimatedSavings)
 }
+
+// commitmentRecommendation builds a recommendation that adds a commitment like the CUD recommender returns
+func commitmentRecommendation(t *testing.T, plan, commitmentType, vcpus string, monthlyCost int64) *recommenderpb.Recommendation {
+	commitment, err := structpb.NewValue(map[string]any{
+		"plan": plan,
+		"type": commitmentType,
+		"resources": []any{
+			map[string]any{"type": "VCPU", "amount": vcpus},
+			map[string]any{"type": "MEMORY", "amount": "32768"},
+		},
+	})
+	require.NoError(t, err)
+
+	return &recommenderpb.Recommendation{
+		Name: "cud-rec",
+		PrimaryImpact: &recommenderpb.Impact{
+			Category: recommenderpb.Impact_COST,
+			Projection: &recommenderpb.Impact_CostProjection{
+				CostProjection: &recommenderpb.CostProjection{
+					Cost:     &money.Money{Units: -monthlyCost, CurrencyCode: "USD"},
+					Duration: durationpb.New(30 * 24 * time.Hour),
+				},
+			},
+		},
+		Content: &recommenderpb.RecommendationContent{
+			OperationGroups: []*recommenderpb.OperationGroup{{
+				Operations: []*recommenderpb.Operation{{
+					Action:       "add",
+					ResourceType: "compute.googleapis.com/Commitment",
+					Resource:     "//compute.googleapis.com/projects/test-project/regions/europe-west1/commitments/rec",
+					PathValue:    &recommenderpb.Operation_Value{Value: commitment},
+				}},
+			}},
+		},
+	}
+}
+
+func TestComputeEngineClient_ConvertGCPRecommendation_Commitment(t *testing.T) {
+	client, _ := NewClient(context.Background(), "test-project", "")
+
+	rec := client.convertGCPRecommendation(context.Background(), commitmentRecommendation(t, "THIRTY_SIX_MONTH", "GENERAL_PURPOSE_E2", "8", 120))
+	require.NotNil(t, rec)
+	assert.Equal(t, "e2", rec.ResourceType)
+	assert.Equal(t, "europe-west1", rec.Region)
+	assert.Equal(t, 8, rec.Count)
+	assert.Equal(t, "3yr", rec.Term)
+	assert.Equal(t, common.CommitmentCUD, rec.CommitmentType)
+	assert.InDelta(t, 120, rec.EstimatedSavings, 0.001)
+	details, ok := rec.Details.(*common.ComputeDetails)
+	require.True(t, ok)
+	assert.Equal(t, "e2", details.InstanceType)
+}
+
+func TestComputeEngineClient_GetRecommendations_FiltersTerm(t *testing.T) {
+	client, _ := NewClient(context.Background(), "test-project", "europe-west1")
+	client.SetRecommenderClient(&MockRecommenderClient{iterator: &MockRecommenderIterator{
+		recommendations: []*recommenderpb.Recommendation{
+			commitmentRecommendation(t, "TWELVE_MONTH", "GENERAL_PURPOSE_N2", "4", 40),
+			commitmentRecommendation(t, "THIRTY_SIX_MONTH", "GENERAL_PURPOSE_N2", "4", 70),
+		},
+	}})
+
+	recommendations, err := client.GetRecommendations(context.Background(), common.RecommendationParams{Term: "1yr"})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	assert.Equal(t, "n2", recommendations[0].ResourceType)
+	assert.Equal(t, "1yr", recommendations[0].Term)
+}
+
+func TestComputeEngineClient_GetRecommendations_Error(t *testing.T) {
+	client, _ := NewClient(context.Background(), "test-project", "europe-west1")
+	client.SetRecommenderClient(&MockRecommenderClient{iterator: &MockRecommenderIterator{err: errors.New("permission denied")}})
+
+	_, err := client.GetRecommendations(context.Background(), common.RecommendationParams{})
+	assert.ErrorContains(t, err, "failed to list CUD recommendations")
+}
+
+func TestMachineFamily(t *testing.T) {
+	assert.Equal(t, "e2", machineFamily("GENERAL_PURPOSE_E2"))
+	assert.Equal(t, "n2d", machineFamily("GENERAL_PURPOSE_N2D"))
+	assert.Equal(t, "n1", machineFamily("GENERAL_PURPOSE"))
+	assert.Equal(t, "c2", machineFamily("COMPUTE_OPTIMIZED"))
+	assert.Equal(t, "", machineFamily(""))
+}